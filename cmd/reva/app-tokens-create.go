@@ -40,6 +40,8 @@ type appTokenCreateOpts struct {
 	Label      string
 	Path       stringSlice
 	Share      stringSlice
+	Space      stringSlice
+	ReadOnly   bool
 	Unlimited  bool
 }
 
@@ -61,15 +63,17 @@ func appTokensCreateCommand() *command {
 	cmd.Description = func() string { return "create a new application tokens" }
 	cmd.Usage = func() string { return "Usage: token-create" }
 
-	var path, share stringSlice
+	var path, share, space stringSlice
 	label := cmd.String("label", "", "set a label")
 	expiration := cmd.String("expiration", "", "set expiration time (format <yyyy-mm-dd>)")
 	cmd.Var(&path, "path", "create a token for a file (format path:[r|w]). It is possible specify this flag multiple times")
 	cmd.Var(&share, "share", "create a token for a share (format shareid:[r|w]). It is possible specify this flag multiple times")
+	cmd.Var(&space, "space", "create a token for a storage space (format spaceid:[r|w]). It is possible specify this flag multiple times")
+	readOnly := cmd.Bool("readonly", false, "create a token with a read only access to all the user's resources")
 	unlimited := cmd.Bool("all", false, "create a token with an unlimited scope")
 
 	cmd.ResetFlags = func() {
-		path, share, label, expiration, unlimited = nil, nil, nil, nil, nil
+		path, share, space, label, expiration, readOnly, unlimited = nil, nil, nil, nil, nil, nil, nil
 	}
 
 	cmd.Action = func(w ...io.Writer) error {
@@ -78,6 +82,8 @@ func appTokensCreateCommand() *command {
 			Label:      *label,
 			Path:       path,
 			Share:      share,
+			Space:      space,
+			ReadOnly:   *readOnly,
 			Unlimited:  *unlimited,
 		}
 
@@ -138,6 +144,9 @@ func getScope(ctx context.Context, client gateway.GatewayAPIClient, opts *appTok
 	if opts.Unlimited {
 		return scope.AddOwnerScope(nil)
 	}
+	if opts.ReadOnly {
+		return scope.AddReadOnlyScope(nil)
+	}
 
 	var scopes map[string]*authpb.Scope
 	var err error
@@ -167,6 +176,21 @@ func getScope(ctx context.Context, client gateway.GatewayAPIClient, opts *appTok
 		}
 	}
 
+	if len(opts.Space) != 0 {
+		for _, entry := range opts.Space {
+			// space = spaceid:[r|w]
+			i := strings.LastIndex(entry, ":")
+			if i == -1 {
+				return nil, errtypes.BadRequest("space must be in the format spaceid:[r|w]")
+			}
+			spaceID, perm := entry[:i], entry[i+1:]
+			scopes, err = getSpaceScope(ctx, client, spaceID, perm, scopes)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	return scopes, nil
 }
 
@@ -214,6 +238,38 @@ func getPathScope(ctx context.Context, client gateway.GatewayAPIClient, path, pe
 	return scope.AddResourceInfoScope(statResponse.GetInfo(), role, scopes)
 }
 
+func getSpaceScope(ctx context.Context, client gateway.GatewayAPIClient, spaceID, perm string, scopes map[string]*authpb.Scope) (map[string]*authpb.Scope, error) {
+	role, err := parsePermission(perm)
+	if err != nil {
+		return nil, err
+	}
+
+	listSpacesResponse, err := client.ListStorageSpaces(ctx, &provider.ListStorageSpacesRequest{
+		Filters: []*provider.ListStorageSpacesRequest_Filter{
+			{
+				Type: provider.ListStorageSpacesRequest_Filter_TYPE_ID,
+				Term: &provider.ListStorageSpacesRequest_Filter_Id{
+					Id: &provider.StorageSpaceId{
+						OpaqueId: spaceID,
+					},
+				},
+			},
+		},
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	if listSpacesResponse.Status.Code != rpc.Code_CODE_OK {
+		return nil, formatError(listSpacesResponse.Status)
+	}
+	if len(listSpacesResponse.StorageSpaces) == 0 {
+		return nil, errtypes.NotFound(spaceID)
+	}
+
+	return scope.AddStorageSpaceScope(listSpacesResponse.StorageSpaces[0], role, scopes)
+}
+
 // parse permission string in the form of "rw" to create a role.
 func parsePermission(perm string) (authpb.Role, error) {
 	switch perm {
@@ -227,7 +283,7 @@ func parsePermission(perm string) (authpb.Role, error) {
 }
 
 func checkOpts(opts *appTokenCreateOpts) error {
-	if len(opts.Share) == 0 && len(opts.Path) == 0 && !opts.Unlimited {
+	if len(opts.Share) == 0 && len(opts.Path) == 0 && len(opts.Space) == 0 && !opts.ReadOnly && !opts.Unlimited {
 		return errtypes.BadRequest("specify a token scope")
 	}
 	return nil
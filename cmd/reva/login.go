@@ -22,10 +22,15 @@ import (
 	"bufio"
 	"context"
 	"encoding/gob"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
+	"net/url"
 	"os"
+	"strings"
+	"time"
 
 	registry "github.com/cs3org/go-cs3apis/cs3/auth/registry/v1beta1"
 	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
@@ -35,15 +40,17 @@ import (
 var loginCommand = func() *command {
 	cmd := newCommand("login")
 	cmd.Description = func() string { return "login into the reva server" }
-	cmd.Usage = func() string { return "Usage: login <type>" }
+	cmd.Usage = func() string { return "Usage: login <type> | login -oidc <url of the oidc login service>" }
 	listFlag := cmd.Bool("list", false, "list available login methods")
 	usernameOpt := cmd.String("username", "", "provide the username (only with machine auth)")
 	apiKeyOpt := cmd.String("api-key", "", "secret for the machine auth")
+	oidcFlag := cmd.Bool("oidc", false, "log in through the OIDC device flow of the given oidc login service")
 
 	cmd.ResetFlags = func() {
 		*listFlag = false
 		*usernameOpt = ""
 		*apiKeyOpt = ""
+		*oidcFlag = false
 	}
 
 	cmd.Action = func(w ...io.Writer) error {
@@ -84,6 +91,16 @@ var loginCommand = func() *command {
 			return errors.New("Invalid arguments: " + cmd.Usage())
 		}
 
+		if *oidcFlag {
+			token, err := loginOIDC(cmd.Args()[0])
+			if err != nil {
+				return err
+			}
+			writeToken(token)
+			fmt.Println("OK")
+			return nil
+		}
+
 		authType := cmd.Args()[0]
 		var username, password string
 		var err error
@@ -137,3 +154,87 @@ var loginCommand = func() *command {
 	}
 	return cmd
 }
+
+// loginOIDC runs the device authorization grant through the oidc login service
+// at serviceURL and returns the reva token obtained once the user approved it.
+func loginOIDC(serviceURL string) (string, error) {
+	serviceURL = strings.TrimSuffix(serviceURL, "/")
+
+	auth := struct {
+		DeviceCode              string `json:"device_code"`
+		UserCode                string `json:"user_code"`
+		VerificationURI         string `json:"verification_uri"`
+		VerificationURIComplete string `json:"verification_uri_complete"`
+		ExpiresIn               int    `json:"expires_in"`
+		Interval                int    `json:"interval"`
+		Error                   string `json:"error"`
+		ErrorDescription        string `json:"error_description"`
+	}{}
+	if err := postOIDCLogin(serviceURL+"/device", nil, &auth); err != nil {
+		return "", err
+	}
+	if auth.Error != "" {
+		return "", fmt.Errorf("error starting the device login: %s %s", auth.Error, auth.ErrorDescription)
+	}
+
+	if auth.VerificationURIComplete != "" {
+		fmt.Printf("To log in, visit %s\n", auth.VerificationURIComplete)
+	} else {
+		fmt.Printf("To log in, visit %s and enter the code %s\n", auth.VerificationURI, auth.UserCode)
+	}
+
+	interval := time.Duration(auth.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	expires := time.Now().Add(time.Duration(auth.ExpiresIn) * time.Second)
+	if auth.ExpiresIn <= 0 {
+		expires = time.Now().Add(10 * time.Minute)
+	}
+
+	for time.Now().Before(expires) {
+		time.Sleep(interval)
+
+		res := struct {
+			Token            string `json:"token"`
+			Error            string `json:"error"`
+			ErrorDescription string `json:"error_description"`
+		}{}
+		if err := postOIDCLogin(serviceURL+"/device/token", url.Values{"device_code": {auth.DeviceCode}}, &res); err != nil {
+			return "", err
+		}
+
+		switch res.Error {
+		case "":
+			if res.Token == "" {
+				return "", errors.New("no token received")
+			}
+			return res.Token, nil
+		case "authorization_pending":
+		case "slow_down":
+			interval += 5 * time.Second
+		default:
+			return "", fmt.Errorf("error logging in: %s %s", res.Error, res.ErrorDescription)
+		}
+	}
+	return "", errors.New("the device login has expired")
+}
+
+func postOIDCLogin(endpoint string, params url.Values, v interface{}) error {
+	httpReq, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(params.Encode()))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	httpRes, err := client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer httpRes.Body.Close()
+
+	if err := json.NewDecoder(httpRes.Body).Decode(v); err != nil {
+		return fmt.Errorf("error decoding the response of %s (%s): %v", endpoint, httpRes.Status, err)
+	}
+	return nil
+}
@@ -58,6 +58,10 @@ var (
 		ocmFindAcceptedUsersCommand(),
 		ocmInviteGenerateCommand(),
 		ocmInviteForwardCommand(),
+		ocmInviteListCommand(),
+		ocmInviteRevokeCommand(),
+		ocmInviteDeleteExpiredCommand(),
+		ocmInviteDeleteAcceptedUserCommand(),
 		ocmShareCreateCommand(),
 		ocmShareListCommand(),
 		ocmShareRemoveCommand(),
@@ -90,6 +94,9 @@ var (
 		setlockCommand(),
 		getlockCommand(),
 		unlockCommand(),
+		storageMaintenanceSetCommand(),
+		storageMaintenanceUnsetCommand(),
+		storageMaintenanceListCommand(),
 		helpCommand(),
 	}
 )
@@ -0,0 +1,62 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package main
+
+import (
+	"fmt"
+	"io"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	invitelifecyclepb "github.com/cs3org/reva/pkg/ocm/invite/proto"
+	"github.com/pkg/errors"
+)
+
+func ocmInviteDeleteAcceptedUserCommand() *command {
+	cmd := newCommand("ocm-invite-delete-accepted-user")
+	cmd.Description = func() string { return "remove a remote user from the users who have accepted an invitation" }
+	cmd.Usage = func() string { return "Usage: ocm-invite-delete-accepted-user [-flags] <user_id>" }
+	idp := cmd.String("idp", "", "the idp of the remote user, required if several remote users have the same id")
+
+	cmd.Action = func(w ...io.Writer) error {
+		if cmd.NArg() < 1 {
+			return errors.New("Invalid arguments: " + cmd.Usage())
+		}
+
+		conn, err := getConn()
+		if err != nil {
+			return err
+		}
+		client := invitelifecyclepb.NewInviteLifecycleServiceClient(conn)
+
+		res, err := client.DeleteAcceptedUser(getAuthContext(), &invitelifecyclepb.DeleteAcceptedUserRequest{
+			RemoteUserId: &userpb.UserId{OpaqueId: cmd.Args()[0], Idp: *idp},
+		})
+		if err != nil {
+			return err
+		}
+		if res.Status.Code != rpc.Code_CODE_OK {
+			return formatError(res.Status)
+		}
+
+		fmt.Println("OK")
+		return nil
+	}
+	return cmd
+}
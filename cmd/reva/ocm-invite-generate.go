@@ -24,12 +24,14 @@ import (
 
 	invitepb "github.com/cs3org/go-cs3apis/cs3/ocm/invite/v1beta1"
 	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	typespb "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
 )
 
 func ocmInviteGenerateCommand() *command {
 	cmd := newCommand("ocm-invite-generate")
 	cmd.Description = func() string { return "generate ocm invitation token" }
-	cmd.Usage = func() string { return "Usage: ocm-invite-generate" }
+	cmd.Usage = func() string { return "Usage: ocm-invite-generate [-flags]" }
+	email := cmd.String("email", "", "the mail address to deliver the invitation to")
 
 	cmd.Action = func(w ...io.Writer) error {
 		ctx := getAuthContext()
@@ -38,7 +40,16 @@ func ocmInviteGenerateCommand() *command {
 			return err
 		}
 
-		inviteToken, err := client.GenerateInviteToken(ctx, &invitepb.GenerateInviteTokenRequest{})
+		req := &invitepb.GenerateInviteTokenRequest{}
+		if *email != "" {
+			req.Opaque = &typespb.Opaque{
+				Map: map[string]*typespb.OpaqueEntry{
+					"recipient": {Decoder: "plain", Value: []byte(*email)},
+				},
+			}
+		}
+
+		inviteToken, err := client.GenerateInviteToken(ctx, req)
 		if err != nil {
 			return err
 		}
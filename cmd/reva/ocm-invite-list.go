@@ -0,0 +1,63 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package main
+
+import (
+	"io"
+	"os"
+	"time"
+
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	invitelifecyclepb "github.com/cs3org/reva/pkg/ocm/invite/proto"
+	"github.com/jedib0t/go-pretty/table"
+)
+
+func ocmInviteListCommand() *command {
+	cmd := newCommand("ocm-invite-list")
+	cmd.Description = func() string { return "list the ocm invitation tokens generated by the user" }
+	cmd.Usage = func() string { return "Usage: ocm-invite-list" }
+
+	cmd.Action = func(w ...io.Writer) error {
+		conn, err := getConn()
+		if err != nil {
+			return err
+		}
+		client := invitelifecyclepb.NewInviteLifecycleServiceClient(conn)
+
+		res, err := client.ListInviteTokens(getAuthContext(), &invitelifecyclepb.ListInviteTokensRequest{})
+		if err != nil {
+			return err
+		}
+		if res.Status.Code != rpc.Code_CODE_OK {
+			return formatError(res.Status)
+		}
+
+		t := table.NewWriter()
+		t.SetOutputMirror(os.Stdout)
+		t.AppendHeader(table.Row{"Token", "Description", "Expiration", "Expired"})
+		now := time.Now()
+		for _, token := range res.InviteTokens {
+			expiration := time.Unix(int64(token.Expiration.GetSeconds()), 0)
+			t.AppendRow(table.Row{token.Token, token.Description, expiration.String(), now.After(expiration)})
+		}
+		t.Render()
+		return nil
+	}
+	return cmd
+}
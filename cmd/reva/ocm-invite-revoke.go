@@ -0,0 +1,86 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package main
+
+import (
+	"fmt"
+	"io"
+
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	invitelifecyclepb "github.com/cs3org/reva/pkg/ocm/invite/proto"
+	"github.com/pkg/errors"
+)
+
+func ocmInviteRevokeCommand() *command {
+	cmd := newCommand("ocm-invite-revoke")
+	cmd.Description = func() string { return "revoke an ocm invitation token, which cannot be accepted anymore" }
+	cmd.Usage = func() string { return "Usage: ocm-invite-revoke <token>" }
+
+	cmd.Action = func(w ...io.Writer) error {
+		if cmd.NArg() < 1 {
+			return errors.New("Invalid arguments: " + cmd.Usage())
+		}
+
+		conn, err := getConn()
+		if err != nil {
+			return err
+		}
+		client := invitelifecyclepb.NewInviteLifecycleServiceClient(conn)
+
+		res, err := client.RevokeInviteToken(getAuthContext(), &invitelifecyclepb.RevokeInviteTokenRequest{
+			Token: cmd.Args()[0],
+		})
+		if err != nil {
+			return err
+		}
+		if res.Status.Code != rpc.Code_CODE_OK {
+			return formatError(res.Status)
+		}
+
+		fmt.Println("OK")
+		return nil
+	}
+	return cmd
+}
+
+func ocmInviteDeleteExpiredCommand() *command {
+	cmd := newCommand("ocm-invite-delete-expired")
+	cmd.Description = func() string { return "delete the expired ocm invitation tokens generated by the user" }
+	cmd.Usage = func() string { return "Usage: ocm-invite-delete-expired" }
+
+	cmd.Action = func(w ...io.Writer) error {
+		conn, err := getConn()
+		if err != nil {
+			return err
+		}
+		client := invitelifecyclepb.NewInviteLifecycleServiceClient(conn)
+
+		res, err := client.DeleteExpiredInviteTokens(getAuthContext(), &invitelifecyclepb.DeleteExpiredInviteTokensRequest{})
+		if err != nil {
+			return err
+		}
+		if res.Status.Code != rpc.Code_CODE_OK {
+			return formatError(res.Status)
+		}
+
+		fmt.Printf("%d expired invitation tokens deleted\n", res.Deleted)
+		return nil
+	}
+	return cmd
+}
@@ -46,9 +46,10 @@ func ocmShareCreateCommand() *command {
 	idp := cmd.String("idp", "", "the idp of the grantee, default to same idp as the user triggering the action")
 	userType := cmd.String("user-type", "primary", "the type of user account, defaults to primary")
 	rol := cmd.String("rol", "viewer", "the permission for the share (viewer or editor)")
+	protocols := cmd.String("protocol", "webdav", "comma-separated list of the protocols the share is offered with (webdav, webapp or datatx)")
 
 	cmd.ResetFlags = func() {
-		*grantType, *grantee, *idp, *rol, *userType = "user", "", "", "viewer", "primary"
+		*grantType, *grantee, *idp, *rol, *userType, *protocols = "user", "", "", "viewer", "primary", "webdav"
 	}
 
 	cmd.Action = func(w ...io.Writer) error {
@@ -127,6 +128,10 @@ func ocmShareCreateCommand() *command {
 					Decoder: "plain",
 					Value:   []byte(res.Info.Path),
 				},
+				"protocol": {
+					Decoder: "plain",
+					Value:   []byte(*protocols),
+				},
 			},
 		}
 
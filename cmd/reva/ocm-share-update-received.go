@@ -19,11 +19,14 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 
 	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
 	ocm "github.com/cs3org/go-cs3apis/cs3/sharing/ocm/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/cs3org/reva/pkg/ocm/share"
 	"github.com/pkg/errors"
 	"google.golang.org/protobuf/types/known/fieldmaskpb"
 )
@@ -33,9 +36,10 @@ func ocmShareUpdateReceivedCommand() *command {
 	cmd.Description = func() string { return "update a received OCM share" }
 	cmd.Usage = func() string { return "Usage: ocm-share-update-received [-flags] <share_id>" }
 	state := cmd.String("state", "pending", "the state of the share (pending, accepted or rejected)")
+	protocol := cmd.String("protocol", "", "the protocol to access an accepted share with (webdav, webapp or datatx), defaults to the share type")
 
 	cmd.ResetFlags = func() {
-		*state = "pending"
+		*state, *protocol = "pending", ""
 	}
 
 	cmd.Action = func(w ...io.Writer) error {
@@ -79,6 +83,16 @@ func ocmShareUpdateReceivedCommand() *command {
 			Share:      shareRes.Share,
 			UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"state"}},
 		}
+		if *protocol != "" {
+			shareRequest.Opaque = &types.Opaque{
+				Map: map[string]*types.OpaqueEntry{
+					"protocol": {
+						Decoder: "plain",
+						Value:   []byte(*protocol),
+					},
+				},
+			}
+		}
 
 		updateRes, err := shareClient.UpdateReceivedOCMShare(ctx, shareRequest)
 		if err != nil {
@@ -89,6 +103,14 @@ func ocmShareUpdateReceivedCommand() *command {
 			return formatError(updateRes.Status)
 		}
 
+		if e, ok := updateRes.GetOpaque().GetMap()[share.WebAppProtocol]; ok && e.Decoder == "json" {
+			var webapp share.WebApp
+			if err := json.Unmarshal(e.Value, &webapp); err != nil {
+				return err
+			}
+			fmt.Printf("Open the share at %s (%s)\n", webapp.URITemplate, webapp.ViewMode)
+		}
+
 		fmt.Println("OK")
 		return nil
 	}
@@ -0,0 +1,61 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package main
+
+import (
+	"io"
+	"os"
+	"time"
+
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	maintenancepb "github.com/cs3org/reva/pkg/storage/maintenance/proto"
+	"github.com/jedib0t/go-pretty/table"
+)
+
+func storageMaintenanceListCommand() *command {
+	cmd := newCommand("storage-maintenance-list")
+	cmd.Description = func() string { return "list the storage providers in maintenance" }
+	cmd.Usage = func() string { return "Usage: storage-maintenance-list" }
+
+	cmd.Action = func(w ...io.Writer) error {
+		conn, err := getConn()
+		if err != nil {
+			return err
+		}
+		client := maintenancepb.NewMaintenanceServiceClient(conn)
+
+		res, err := client.ListProviderMaintenance(getAuthContext(), &maintenancepb.ListProviderMaintenanceRequest{})
+		if err != nil {
+			return err
+		}
+		if res.Status.Code != rpc.Code_CODE_OK {
+			return formatError(res.Status)
+		}
+
+		t := table.NewWriter()
+		t.SetOutputMirror(os.Stdout)
+		t.AppendHeader(table.Row{"Provider", "Reason", "Retry After", "Since", "Set By"})
+		for _, p := range res.Providers {
+			t.AppendRow(table.Row{p.Provider, p.Reason, time.Duration(p.RetryAfter) * time.Second, time.Unix(p.Since, 0).String(), p.SetBy})
+		}
+		t.Render()
+		return nil
+	}
+	return cmd
+}
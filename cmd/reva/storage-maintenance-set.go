@@ -0,0 +1,88 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package main
+
+import (
+	"fmt"
+	"io"
+
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
+	maintenancepb "github.com/cs3org/reva/pkg/storage/maintenance/proto"
+)
+
+func storageMaintenanceSetCommand() *command {
+	cmd := newCommand("storage-maintenance-set")
+	cmd.Description = func() string { return "put a storage provider in maintenance, refusing new writes" }
+	cmd.Usage = func() string { return "Usage: storage-maintenance-set [-flags] <provider id or address>" }
+	reason := cmd.String("reason", "", "the reason of the maintenance, reported to the clients")
+	retryAfter := cmd.Int64("retry-after", 0, "the number of seconds after which clients should retry")
+
+	cmd.ResetFlags = func() {
+		*reason, *retryAfter = "", 0
+	}
+
+	cmd.Action = func(w ...io.Writer) error {
+		if cmd.NArg() != 1 {
+			return errtypes.BadRequest("Invalid arguments: " + cmd.Usage())
+		}
+		return setStorageMaintenance(&maintenancepb.SetProviderMaintenanceRequest{
+			Provider:   cmd.Arg(0),
+			Enabled:    true,
+			Reason:     *reason,
+			RetryAfter: *retryAfter,
+		})
+	}
+	return cmd
+}
+
+func storageMaintenanceUnsetCommand() *command {
+	cmd := newCommand("storage-maintenance-unset")
+	cmd.Description = func() string { return "put a storage provider back into rotation" }
+	cmd.Usage = func() string { return "Usage: storage-maintenance-unset <provider id or address>" }
+
+	cmd.Action = func(w ...io.Writer) error {
+		if cmd.NArg() != 1 {
+			return errtypes.BadRequest("Invalid arguments: " + cmd.Usage())
+		}
+		return setStorageMaintenance(&maintenancepb.SetProviderMaintenanceRequest{
+			Provider: cmd.Arg(0),
+		})
+	}
+	return cmd
+}
+
+func setStorageMaintenance(req *maintenancepb.SetProviderMaintenanceRequest) error {
+	conn, err := getConn()
+	if err != nil {
+		return err
+	}
+	client := maintenancepb.NewMaintenanceServiceClient(conn)
+
+	res, err := client.SetProviderMaintenance(getAuthContext(), req)
+	if err != nil {
+		return err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return formatError(res.Status)
+	}
+
+	fmt.Println("OK")
+	return nil
+}
@@ -30,11 +30,15 @@ import (
 	_ "github.com/cs3org/reva/pkg/app/provider/loader"
 	_ "github.com/cs3org/reva/pkg/app/registry/loader"
 	_ "github.com/cs3org/reva/pkg/appauth/manager/loader"
+	_ "github.com/cs3org/reva/pkg/audit/sink/loader"
+	_ "github.com/cs3org/reva/pkg/auth/lockout/loader"
 	_ "github.com/cs3org/reva/pkg/auth/manager/loader"
+	_ "github.com/cs3org/reva/pkg/auth/mfa/loader"
 	_ "github.com/cs3org/reva/pkg/auth/registry/loader"
 	_ "github.com/cs3org/reva/pkg/cbox/loader"
 	_ "github.com/cs3org/reva/pkg/datatx/manager/loader"
 	_ "github.com/cs3org/reva/pkg/group/manager/loader"
+	_ "github.com/cs3org/reva/pkg/guest/manager/loader"
 	_ "github.com/cs3org/reva/pkg/metrics/driver/loader"
 	_ "github.com/cs3org/reva/pkg/ocm/invite/manager/loader"
 	_ "github.com/cs3org/reva/pkg/ocm/provider/authorizer/loader"
@@ -42,6 +46,7 @@ import (
 	_ "github.com/cs3org/reva/pkg/permission/manager/loader"
 	_ "github.com/cs3org/reva/pkg/preferences/loader"
 	_ "github.com/cs3org/reva/pkg/publicshare/manager/loader"
+	_ "github.com/cs3org/reva/pkg/ratelimit/loader"
 	_ "github.com/cs3org/reva/pkg/rhttp/datatx/manager/loader"
 	_ "github.com/cs3org/reva/pkg/share/cache/loader"
 	_ "github.com/cs3org/reva/pkg/share/cache/warmup/loader"
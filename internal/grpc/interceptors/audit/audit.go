@@ -0,0 +1,227 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package audit
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	collaboration "github.com/cs3org/go-cs3apis/cs3/sharing/collaboration/v1beta1"
+	link "github.com/cs3org/go-cs3apis/cs3/sharing/link/v1beta1"
+	ocm "github.com/cs3org/go-cs3apis/cs3/sharing/ocm/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/audit"
+	"github.com/cs3org/reva/pkg/audit/sink/registry"
+	ctxpkg "github.com/cs3org/reva/pkg/ctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/rgrpc"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// run before readonly and similar interceptors,
+	// so that denied attempts are recorded as well.
+	defaultPriority = 150
+)
+
+func init() {
+	rgrpc.RegisterUnaryInterceptor("audit", NewUnary)
+}
+
+type config struct {
+	Sinks    []string                          `mapstructure:"sinks"`
+	Drivers  map[string]map[string]interface{} `mapstructure:"drivers"`
+	Priority int                               `mapstructure:"priority"`
+}
+
+func (c *config) init() {
+	if len(c.Sinks) == 0 {
+		c.Sinks = []string{"file"}
+	}
+	if c.Priority == 0 {
+		c.Priority = defaultPriority
+	}
+}
+
+func getLogger(c *config) (*audit.Logger, error) {
+	sinks := make([]audit.Sink, 0, len(c.Sinks))
+	for _, name := range c.Sinks {
+		f, ok := registry.NewFuncs[name]
+		if !ok {
+			return nil, errtypes.NotFound("driver not found: " + name)
+		}
+		s, err := f(c.Drivers[name])
+		if err != nil {
+			return nil, errors.Wrapf(err, "audit: error creating sink %s", name)
+		}
+		sinks = append(sinks, s)
+	}
+	return audit.NewLogger(sinks...), nil
+}
+
+// NewUnary returns a new unary interceptor that writes
+// an audit record for security relevant operations.
+func NewUnary(m map[string]interface{}) (grpc.UnaryServerInterceptor, int, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, 0, errors.Wrap(err, "audit: error decoding conf")
+	}
+	c.init()
+
+	logger, err := getLogger(c)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	interceptor := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		// services record events only they know about, e.g. lockouts, to the same sinks
+		ctx = audit.ContextSetLogger(ctx, logger)
+
+		action, resource := classify(req)
+		if action == "" {
+			return handler(ctx, req)
+		}
+
+		res, err := handler(ctx, req)
+
+		r := &audit.Record{
+			Action:   action,
+			Protocol: "grpc",
+			Method:   info.FullMethod,
+			Resource: resource,
+			Result:   result(res, err),
+		}
+		if u, ok := ctxpkg.ContextGetUser(ctx); ok {
+			r.UserIdp, r.UserOpaqueID, r.Username = u.GetId().GetIdp(), u.GetId().GetOpaqueId(), u.GetUsername()
+		} else if authRes, ok := res.(*gateway.AuthenticateResponse); ok && authRes.GetUser() != nil {
+			// on login, the acting user is the one just authenticated
+			u := authRes.GetUser()
+			r.UserIdp, r.UserOpaqueID, r.Username = u.GetId().GetIdp(), u.GetId().GetOpaqueId(), u.GetUsername()
+		}
+		if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+			r.ClientIP = p.Addr.String()
+			if host, _, err := net.SplitHostPort(r.ClientIP); err == nil {
+				r.ClientIP = host
+			}
+		}
+		logger.Log(ctx, r)
+
+		return res, err
+	}
+	return interceptor, c.Priority, nil
+}
+
+// classify returns the audit action for a request, or an empty one
+// if the request is not security relevant, and the target it refers to.
+// Public link tokens are recorded hashed, as they grant access to the link.
+func classify(req interface{}) (audit.Action, string) {
+	switch v := req.(type) {
+	case *gateway.AuthenticateRequest:
+		if v.GetType() == "publicshares" {
+			return audit.ActionPublicLinkAccess, audit.HashToken(v.GetClientId())
+		}
+		return audit.ActionLogin, fmt.Sprintf("%s:%s", v.GetType(), v.GetClientId())
+	case *collaboration.CreateShareRequest:
+		return audit.ActionShareCreate, formatResourceID(v.GetResourceInfo().GetId())
+	case *collaboration.UpdateShareRequest:
+		return audit.ActionShareUpdate, formatShareRef(v.GetRef())
+	case *collaboration.RemoveShareRequest:
+		return audit.ActionShareRemove, formatShareRef(v.GetRef())
+	case *ocm.CreateOCMShareRequest:
+		return audit.ActionShareCreate, formatResourceID(v.GetResourceId())
+	case *ocm.RemoveOCMShareRequest:
+		return audit.ActionShareRemove, v.GetRef().GetId().GetOpaqueId()
+	case *link.CreatePublicShareRequest:
+		return audit.ActionPublicLinkCreate, formatResourceID(v.GetResourceInfo().GetId())
+	case *link.UpdatePublicShareRequest:
+		return audit.ActionPublicLinkUpdate, formatPublicShareRef(v.GetRef())
+	case *link.RemovePublicShareRequest:
+		return audit.ActionPublicLinkRemove, formatPublicShareRef(v.GetRef())
+	case *link.GetPublicShareByTokenRequest:
+		return audit.ActionPublicLinkAccess, audit.HashToken(v.GetToken())
+	case *provider.AddGrantRequest:
+		return audit.ActionPermissionsChange, formatRef(v.GetRef())
+	case *provider.UpdateGrantRequest:
+		return audit.ActionPermissionsChange, formatRef(v.GetRef())
+	case *provider.RemoveGrantRequest:
+		return audit.ActionPermissionsChange, formatRef(v.GetRef())
+	case *provider.DenyGrantRequest:
+		return audit.ActionPermissionsChange, formatRef(v.GetRef())
+	case *provider.DeleteRequest:
+		return audit.ActionDelete, formatRef(v.GetRef())
+	case *provider.PurgeRecycleRequest:
+		return audit.ActionDelete, formatRef(v.GetRef())
+	case *provider.DeleteStorageSpaceRequest:
+		return audit.ActionDelete, v.GetId().GetOpaqueId()
+	case *provider.InitiateFileDownloadRequest:
+		return audit.ActionDownload, formatRef(v.GetRef())
+	}
+	return "", ""
+}
+
+func result(res interface{}, err error) string {
+	if err != nil {
+		return status.Code(err).String()
+	}
+	if s, ok := res.(interface{ GetStatus() *rpc.Status }); ok && s.GetStatus() != nil {
+		return s.GetStatus().GetCode().String()
+	}
+	return rpc.Code_CODE_OK.String()
+}
+
+func formatResourceID(id *provider.ResourceId) string {
+	if id == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s!%s", id.GetStorageId(), id.GetOpaqueId())
+}
+
+func formatRef(ref *provider.Reference) string {
+	if ref.GetResourceId() == nil {
+		return ref.GetPath()
+	}
+	if ref.GetPath() == "" {
+		return formatResourceID(ref.GetResourceId())
+	}
+	return formatResourceID(ref.GetResourceId()) + ":" + ref.GetPath()
+}
+
+func formatShareRef(ref *collaboration.ShareReference) string {
+	if id := ref.GetId(); id != nil {
+		return id.GetOpaqueId()
+	}
+	if key := ref.GetKey(); key != nil {
+		return formatResourceID(key.GetResourceId())
+	}
+	return ""
+}
+
+func formatPublicShareRef(ref *link.PublicShareReference) string {
+	if id := ref.GetId(); id != nil {
+		return id.GetOpaqueId()
+	}
+	return audit.HashToken(ref.GetToken())
+}
@@ -229,8 +229,10 @@ func dismantleToken(ctx context.Context, tkn string, req interface{}, mgr token.
 		return nil, nil, err
 	}
 
+	// the scopes of the token are not verified on unprotected endpoints,
+	// but they are still passed along, e.g. for the gateway to check the auth level
 	if unprotected {
-		return u, nil, nil
+		return u, tokenScope, nil
 	}
 
 	if sharedconf.SkipUserGroupsInToken() {
@@ -26,6 +26,7 @@ import (
 	collaboration "github.com/cs3org/go-cs3apis/cs3/sharing/collaboration/v1beta1"
 	"github.com/cs3org/reva/pkg/events"
 	"github.com/cs3org/reva/pkg/events/server"
+	"github.com/cs3org/reva/pkg/health"
 	"github.com/cs3org/reva/pkg/rgrpc"
 	"go-micro.dev/v4/util/log"
 	"google.golang.org/grpc"
@@ -92,6 +93,7 @@ func publisherFromConfig(m map[string]interface{}) (events.Publisher, error) {
 	case "nats":
 		address := m["address"].(string)
 		cid := m["clusterID"].(string)
+		health.Register("events:nats:"+address, health.TCP(address))
 		return server.NewNatsStream(nats.Address(address), nats.ClusterID(cid))
 	}
 }
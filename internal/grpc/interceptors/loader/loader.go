@@ -20,7 +20,9 @@ package loader
 
 import (
 	// Load core GRPC services.
+	_ "github.com/cs3org/reva/internal/grpc/interceptors/audit"
 	_ "github.com/cs3org/reva/internal/grpc/interceptors/eventsmiddleware"
+	_ "github.com/cs3org/reva/internal/grpc/interceptors/ratelimit"
 	_ "github.com/cs3org/reva/internal/grpc/interceptors/readonly"
 	// Add your own service here.
 )
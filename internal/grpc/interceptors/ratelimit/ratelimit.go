@@ -0,0 +1,145 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"strconv"
+
+	ctxpkg "github.com/cs3org/reva/pkg/ctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/ratelimit"
+	"github.com/cs3org/reva/pkg/ratelimit/registry"
+	"github.com/cs3org/reva/pkg/rgrpc"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultPriority = 100
+)
+
+func init() {
+	rgrpc.RegisterUnaryInterceptor("ratelimit", NewUnary)
+	rgrpc.RegisterStreamInterceptor("ratelimit", NewStream)
+}
+
+type config struct {
+	ratelimit.Config `mapstructure:",squash"`
+	Priority         int `mapstructure:"priority"`
+}
+
+func (c *config) init() {
+	if c.Driver == "" {
+		c.Driver = "memory"
+	}
+	if c.Priority == 0 {
+		c.Priority = defaultPriority
+	}
+}
+
+func parseConfig(m map[string]interface{}) (*config, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "ratelimit: error decoding conf")
+	}
+	c.init()
+	return c, nil
+}
+
+func getLimiter(c *config) (ratelimit.Limiter, error) {
+	if f, ok := registry.NewFuncs[c.Driver]; ok {
+		return f(c.Drivers[c.Driver])
+	}
+	return nil, errtypes.NotFound("driver not found: " + c.Driver)
+}
+
+func newPolicy(m map[string]interface{}) (*ratelimit.Policy, int, error) {
+	c, err := parseConfig(m)
+	if err != nil {
+		return nil, 0, err
+	}
+	limiter, err := getLimiter(c)
+	if err != nil {
+		return nil, 0, err
+	}
+	return ratelimit.NewPolicy(&c.Config, limiter), c.Priority, nil
+}
+
+// NewUnary returns a new unary interceptor that rejects
+// requests exceeding the configured rate limits.
+func NewUnary(m map[string]interface{}) (grpc.UnaryServerInterceptor, int, error) {
+	policy, prio, err := newPolicy(m)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	interceptor := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := check(ctx, policy, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+	return interceptor, prio, nil
+}
+
+// NewStream returns a new server stream interceptor that rejects
+// streams exceeding the configured rate limits.
+func NewStream(m map[string]interface{}) (grpc.StreamServerInterceptor, int, error) {
+	policy, prio, err := newPolicy(m)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	interceptor := func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := check(ss.Context(), policy, info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+	return interceptor, prio, nil
+}
+
+func check(ctx context.Context, policy *ratelimit.Policy, method string) error {
+	r := &ratelimit.Request{Method: method}
+	if u, ok := ctxpkg.ContextGetUser(ctx); ok && u.Id != nil {
+		r.User = u.Id.OpaqueId
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		r.IP = p.Addr.String()
+		if host, _, err := net.SplitHostPort(r.IP); err == nil {
+			r.IP = host
+		}
+	}
+
+	ok, wait := policy.Allow(ctx, r)
+	if ok {
+		return nil
+	}
+
+	retryAfter := strconv.Itoa(ratelimit.RetryAfterSeconds(wait))
+	_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", retryAfter))
+	return status.Errorf(codes.ResourceExhausted, "rate limit exceeded for %s, retry after %s seconds", method, retryAfter)
+}
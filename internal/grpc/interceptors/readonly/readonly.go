@@ -20,14 +20,29 @@ package readonly
 
 import (
 	"context"
+	"encoding/json"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
 
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
 	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	registry "github.com/cs3org/go-cs3apis/cs3/storage/registry/v1beta1"
 	"github.com/cs3org/reva/pkg/appctx"
+	ctxpkg "github.com/cs3org/reva/pkg/ctx"
 	"github.com/cs3org/reva/pkg/rgrpc"
 	rstatus "github.com/cs3org/reva/pkg/rgrpc/status"
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"github.com/cs3org/reva/pkg/sharedconf"
+	"github.com/cs3org/reva/pkg/utils"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
@@ -39,39 +54,319 @@ func init() {
 	rgrpc.RegisterUnaryInterceptor("readonly", NewUnary)
 }
 
+// rules describes what is read-only.
+type rules struct {
+	// Global makes the whole server read-only.
+	Global bool `mapstructure:"global" json:"global"`
+	// Users are usernames or user ids that cannot write anywhere.
+	Users []string `mapstructure:"users" json:"users"`
+	// Spaces are storage or space ids that cannot be written.
+	Spaces []string `mapstructure:"spaces" json:"spaces"`
+	// Paths are path prefixes that cannot be written.
+	// References relative to a resource id are resolved through the gateway.
+	Paths []string `mapstructure:"paths" json:"paths"`
+	// Message is appended to the error returned to clients,
+	// e.g. to tell them a migration is in progress.
+	Message string `mapstructure:"message" json:"message"`
+}
+
+type config struct {
+	rules `mapstructure:",squash"`
+	// PolicyFile is a JSON file with additional rules,
+	// reloaded whenever it changes.
+	PolicyFile     string `mapstructure:"policy_file"`
+	ReloadInterval int    `mapstructure:"reload_interval"`
+	// GatewaySvc is used to resolve the path of the resources referenced by id.
+	GatewaySvc string `mapstructure:"gatewaysvc"`
+}
+
+func (c *config) init() {
+	c.GatewaySvc = sharedconf.GetGatewaySVC(c.GatewaySvc)
+	if c.ReloadInterval == 0 {
+		c.ReloadInterval = 30
+	}
+	// without any rule, the interceptor keeps making the whole server read-only;
+	// a policy file must set global explicitly instead
+	if c.PolicyFile == "" && len(c.Users) == 0 && len(c.Spaces) == 0 && len(c.Paths) == 0 {
+		c.Global = true
+	}
+}
+
+// resolver returns the absolute path of a resource.
+type resolver func(ctx context.Context, id *provider.ResourceId) (string, error)
+
+type policy struct {
+	global  bool
+	users   map[string]struct{}
+	spaces  map[string]struct{}
+	paths   []string
+	message string
+	resolve resolver
+}
+
+func newPolicy(resolve resolver, r ...*rules) *policy {
+	p := &policy{
+		users:   map[string]struct{}{},
+		spaces:  map[string]struct{}{},
+		resolve: resolve,
+	}
+	for _, rr := range r {
+		for _, u := range rr.Users {
+			p.users[u] = struct{}{}
+		}
+		for _, s := range rr.Spaces {
+			p.spaces[s] = struct{}{}
+		}
+		p.paths = append(p.paths, rr.Paths...)
+		if rr.Message != "" {
+			p.message = rr.Message
+		}
+		p.global = p.global || rr.Global
+	}
+	return p
+}
+
+func (p *policy) matchesUser(ctx context.Context) bool {
+	u, ok := ctxpkg.ContextGetUser(ctx)
+	if !ok {
+		return false
+	}
+	if _, ok := p.users[u.GetUsername()]; ok {
+		return true
+	}
+	_, ok = p.users[u.GetId().GetOpaqueId()]
+	return ok
+}
+
+func (p *policy) matchesResource(id *provider.ResourceId, path string) bool {
+	if id != nil {
+		if _, ok := p.spaces[id.StorageId]; ok {
+			return true
+		}
+		if _, ok := p.spaces[id.SpaceId]; ok && id.SpaceId != "" {
+			return true
+		}
+	}
+	if strings.HasPrefix(path, "/") {
+		for _, prefix := range p.paths {
+			if path == prefix || strings.HasPrefix(path, strings.TrimSuffix(prefix, "/")+"/") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchesRef returns true if the reference points to a read-only resource.
+// The storage providers get references relative to a resource id from the gateway,
+// whose path is resolved to be checked against the path rules.
+func (p *policy) matchesRef(ctx context.Context, ref *provider.Reference) bool {
+	if p.matchesResource(ref.ResourceId, ref.Path) {
+		return true
+	}
+	if len(p.paths) == 0 || ref.GetResourceId().GetOpaqueId() == "" || strings.HasPrefix(ref.Path, "/") {
+		return false
+	}
+
+	base, err := p.resolve(ctx, ref.ResourceId)
+	if err != nil {
+		// the resource cannot be checked, block the write as for unknown requests
+		appctx.GetLogger(ctx).Error().Err(err).Interface("ref", ref).Msg("readonly: error resolving reference")
+		return true
+	}
+	return p.matchesResource(nil, path.Join(base, ref.Path))
+}
+
+// applies returns true if a write to any of the references must be blocked.
+func (p *policy) applies(ctx context.Context, refs ...*provider.Reference) bool {
+	if p.global || p.matchesUser(ctx) {
+		return true
+	}
+	for _, ref := range refs {
+		if ref != nil && p.matchesRef(ctx, ref) {
+			return true
+		}
+	}
+	return false
+}
+
+// appliesToUnknown returns true if a request that cannot be checked must be blocked.
+// As soon as resources are read-only, it cannot be told which ones the request touches.
+func (p *policy) appliesToUnknown(ctx context.Context) bool {
+	return p.global || p.matchesUser(ctx) || len(p.spaces) > 0 || len(p.paths) > 0
+}
+
+// spaceRef returns a reference to the root of a storage space.
+func spaceRef(id *provider.StorageSpaceId) *provider.Reference {
+	if id == nil {
+		return nil
+	}
+	storageID, spaceID, err := utils.SplitStorageSpaceID(id.OpaqueId)
+	if err != nil {
+		storageID = id.OpaqueId
+	}
+	return &provider.Reference{ResourceId: &provider.ResourceId{StorageId: storageID, SpaceId: spaceID}}
+}
+
+// appliesToInfo returns true if the resource must be shown as read-only.
+func (p *policy) appliesToInfo(ctx context.Context, info *provider.ResourceInfo) bool {
+	return p.global || p.matchesUser(ctx) || p.matchesResource(info.Id, info.Path)
+}
+
+// deny returns a PERMISSION_DENIED status for an operation on read-only storage.
+// The message contains "read-only", which lets ocdav pass it on to clients.
+func (p *policy) deny(ctx context.Context, op string) *rpc.Status {
+	msg := "permission denied: tried to " + op + " on read-only storage"
+	if p.message != "" {
+		msg += ": " + p.message
+	}
+	return rstatus.NewPermissionDenied(ctx, nil, msg)
+}
+
+type policyStore struct {
+	sync.RWMutex
+	conf    *config
+	p       *policy
+	modTime time.Time
+	resolve resolver
+}
+
+func (s *policyStore) get() *policy {
+	s.RLock()
+	defer s.RUnlock()
+	return s.p
+}
+
+// load reads the policy file if it changed since the last time.
+func (s *policyStore) load() error {
+	if s.conf.PolicyFile == "" {
+		s.Lock()
+		s.p = newPolicy(s.resolve, &s.conf.rules)
+		s.Unlock()
+		return nil
+	}
+
+	fi, err := os.Stat(s.conf.PolicyFile)
+	if err != nil {
+		return errors.Wrap(err, "readonly: error reading policy file")
+	}
+	if !fi.ModTime().After(s.modTime) {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.conf.PolicyFile)
+	if err != nil {
+		return errors.Wrap(err, "readonly: error reading policy file")
+	}
+	r := &rules{}
+	if err := json.Unmarshal(data, r); err != nil {
+		return errors.Wrap(err, "readonly: error decoding policy file")
+	}
+
+	s.Lock()
+	s.p = newPolicy(s.resolve, &s.conf.rules, r)
+	s.modTime = fi.ModTime()
+	s.Unlock()
+	return nil
+}
+
+func (s *policyStore) watch() {
+	for range time.Tick(time.Duration(s.conf.ReloadInterval) * time.Second) {
+		if err := s.load(); err != nil {
+			log.Error().Err(err).Msg("readonly: error reloading policy, keeping the previous one")
+		}
+	}
+}
+
+func newPolicyStore(m map[string]interface{}) (*policyStore, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "readonly: error decoding conf")
+	}
+	c.init()
+
+	s := &policyStore{conf: c}
+	s.resolve = s.getPath
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	if c.PolicyFile != "" {
+		go s.watch()
+	}
+	return s, nil
+}
+
+// getPath resolves the path of a resource through the gateway.
+func (s *policyStore) getPath(ctx context.Context, id *provider.ResourceId) (string, error) {
+	client, err := pool.GetGatewayServiceClient(pool.Endpoint(s.conf.GatewaySvc))
+	if err != nil {
+		return "", err
+	}
+	if tkn, ok := ctxpkg.ContextGetToken(ctx); ok {
+		if md, _ := metadata.FromOutgoingContext(ctx); len(md.Get(ctxpkg.TokenHeader)) == 0 {
+			ctx = metadata.AppendToOutgoingContext(ctx, ctxpkg.TokenHeader, tkn)
+		}
+	}
+
+	res, err := client.GetPath(ctx, &provider.GetPathRequest{ResourceId: id})
+	if err != nil {
+		return "", err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return "", rstatus.NewErrorFromCode(res.Status.Code, "readonly")
+	}
+	return res.Path, nil
+}
+
+func setReadOnly(ps *provider.ResourcePermissions) {
+	// use the existing PermissionsSet and change the writes to false
+	ps.AddGrant = false
+	ps.CreateContainer = false
+	ps.Delete = false
+	ps.InitiateFileUpload = false
+	ps.Move = false
+	ps.RemoveGrant = false
+	ps.PurgeRecycle = false
+	ps.RestoreFileVersion = false
+	ps.RestoreRecycleItem = false
+	ps.UpdateGrant = false
+}
+
 // NewUnary returns a new unary interceptor
 // that checks grpc calls and blocks write requests.
-func NewUnary(map[string]interface{}) (grpc.UnaryServerInterceptor, int, error) {
+// Without any rule the whole storage is read-only; users, spaces and
+// path prefixes can be configured to restrict it to part of it.
+func NewUnary(m map[string]interface{}) (grpc.UnaryServerInterceptor, int, error) {
+	store, err := newPolicyStore(m)
+	if err != nil {
+		return nil, 0, err
+	}
+
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
 		log := appctx.GetLogger(ctx)
+		p := store.get()
 
-		switch req.(type) {
+		switch v := req.(type) {
 		// handle known non-write request types
 		case *provider.GetHomeRequest,
+			*provider.GetLockRequest,
 			*provider.GetPathRequest,
 			*provider.GetQuotaRequest,
+			*registry.GetHomeRequest,
 			*registry.GetStorageProvidersRequest,
+			*registry.ListStorageProvidersRequest,
 			*provider.InitiateFileDownloadRequest,
 			*provider.ListFileVersionsRequest,
 			*provider.ListGrantsRequest,
-			*provider.ListRecycleRequest:
+			*provider.ListRecycleRequest,
+			*provider.ListStorageSpacesRequest:
 			return handler(ctx, req)
 		case *provider.ListContainerRequest:
 			resp, err := handler(ctx, req)
 			if listResp, ok := resp.(*provider.ListContainerResponse); ok && listResp.Infos != nil {
 				for _, info := range listResp.Infos {
-					// use the existing PermissionsSet and change the writes to false
-					if info.PermissionSet != nil {
-						info.PermissionSet.AddGrant = false
-						info.PermissionSet.CreateContainer = false
-						info.PermissionSet.Delete = false
-						info.PermissionSet.InitiateFileUpload = false
-						info.PermissionSet.Move = false
-						info.PermissionSet.RemoveGrant = false
-						info.PermissionSet.PurgeRecycle = false
-						info.PermissionSet.RestoreFileVersion = false
-						info.PermissionSet.RestoreRecycleItem = false
-						info.PermissionSet.UpdateGrant = false
+					if info.PermissionSet != nil && p.appliesToInfo(ctx, info) {
+						setReadOnly(info.PermissionSet)
 					}
 				}
 			}
@@ -79,72 +374,111 @@ func NewUnary(map[string]interface{}) (grpc.UnaryServerInterceptor, int, error)
 		case *provider.StatRequest:
 			resp, err := handler(ctx, req)
 			if statResp, ok := resp.(*provider.StatResponse); ok && statResp.Info != nil && statResp.Info.PermissionSet != nil {
-				// use the existing PermissionsSet and change the writes to false
-				statResp.Info.PermissionSet.AddGrant = false
-				statResp.Info.PermissionSet.CreateContainer = false
-				statResp.Info.PermissionSet.Delete = false
-				statResp.Info.PermissionSet.InitiateFileUpload = false
-				statResp.Info.PermissionSet.Move = false
-				statResp.Info.PermissionSet.RemoveGrant = false
-				statResp.Info.PermissionSet.PurgeRecycle = false
-				statResp.Info.PermissionSet.RestoreFileVersion = false
-				statResp.Info.PermissionSet.RestoreRecycleItem = false
-				statResp.Info.PermissionSet.UpdateGrant = false
+				if p.appliesToInfo(ctx, statResp.Info) {
+					setReadOnly(statResp.Info.PermissionSet)
+				}
 			}
 			return resp, err
 		// Don't allow the following requests types
 		case *provider.AddGrantRequest:
-			return &provider.AddGrantResponse{
-				Status: rstatus.NewPermissionDenied(ctx, nil, "permission denied: tried to add grant on readonly storage"),
-			}, nil
+			if p.applies(ctx, v.Ref) {
+				return &provider.AddGrantResponse{Status: p.deny(ctx, "add grant")}, nil
+			}
 		case *provider.CreateContainerRequest:
-			return &provider.CreateContainerResponse{
-				Status: rstatus.NewPermissionDenied(ctx, nil, "permission denied: tried to create resource on read-only storage"),
-			}, nil
+			if p.applies(ctx, v.Ref) {
+				return &provider.CreateContainerResponse{Status: p.deny(ctx, "create resource")}, nil
+			}
 		case *provider.TouchFileRequest:
-			return &provider.TouchFileResponse{
-				Status: rstatus.NewPermissionDenied(ctx, nil, "permission denied: tried to create resource on read-only storage"),
-			}, nil
+			if p.applies(ctx, v.Ref) {
+				return &provider.TouchFileResponse{Status: p.deny(ctx, "create resource")}, nil
+			}
 		case *provider.CreateHomeRequest:
-			return &provider.CreateHomeResponse{
-				Status: rstatus.NewPermissionDenied(ctx, nil, "permission denied: tried to create home on readonly storage"),
-			}, nil
+			if p.applies(ctx) {
+				return &provider.CreateHomeResponse{Status: p.deny(ctx, "create home")}, nil
+			}
 		case *provider.DeleteRequest:
-			return &provider.DeleteResponse{
-				Status: rstatus.NewPermissionDenied(ctx, nil, "permission denied: tried to delete resource on readonly storage"),
-			}, nil
+			if p.applies(ctx, v.Ref) {
+				return &provider.DeleteResponse{Status: p.deny(ctx, "delete resource")}, nil
+			}
 		case *provider.InitiateFileUploadRequest:
-			return &provider.InitiateFileUploadResponse{
-				Status: rstatus.NewPermissionDenied(ctx, nil, "permission denied: tried to upload resource on readonly storage"),
-			}, nil
+			if p.applies(ctx, v.Ref) {
+				return &provider.InitiateFileUploadResponse{Status: p.deny(ctx, "upload resource")}, nil
+			}
 		case *provider.MoveRequest:
-			return &provider.MoveResponse{
-				Status: rstatus.NewPermissionDenied(ctx, nil, "permission denied: tried to move resource on readonly storage"),
-			}, nil
+			if p.applies(ctx, v.Source, v.Destination) {
+				return &provider.MoveResponse{Status: p.deny(ctx, "move resource")}, nil
+			}
 		case *provider.PurgeRecycleRequest:
-			return &provider.PurgeRecycleResponse{
-				Status: rstatus.NewPermissionDenied(ctx, nil, "permission denied: tried to purge recycle on readonly storage"),
-			}, nil
+			if p.applies(ctx, v.Ref) {
+				return &provider.PurgeRecycleResponse{Status: p.deny(ctx, "purge recycle")}, nil
+			}
 		case *provider.RemoveGrantRequest:
-			return &provider.RemoveGrantResponse{
-				Status: rstatus.NewPermissionDenied(ctx, nil, "permission denied: tried to remove grant on readonly storage"),
-			}, nil
+			if p.applies(ctx, v.Ref) {
+				return &provider.RemoveGrantResponse{Status: p.deny(ctx, "remove grant")}, nil
+			}
 		case *provider.RestoreRecycleItemRequest:
-			return &provider.RestoreRecycleItemResponse{
-				Status: rstatus.NewPermissionDenied(ctx, nil, "permission denied: tried to restore recycle item on readonly storage"),
-			}, nil
+			if p.applies(ctx, v.Ref, v.RestoreRef) {
+				return &provider.RestoreRecycleItemResponse{Status: p.deny(ctx, "restore recycle item")}, nil
+			}
 		case *provider.SetArbitraryMetadataRequest:
-			return &provider.SetArbitraryMetadataResponse{
-				Status: rstatus.NewPermissionDenied(ctx, nil, "permission denied: tried to set arbitrary metadata on readonly storage"),
-			}, nil
+			if p.applies(ctx, v.Ref) {
+				return &provider.SetArbitraryMetadataResponse{Status: p.deny(ctx, "set arbitrary metadata")}, nil
+			}
 		case *provider.UnsetArbitraryMetadataRequest:
-			return &provider.UnsetArbitraryMetadataResponse{
-				Status: rstatus.NewPermissionDenied(ctx, nil, "permission denied: tried to unset arbitrary metadata on readonly storage"),
-			}, nil
+			if p.applies(ctx, v.Ref) {
+				return &provider.UnsetArbitraryMetadataResponse{Status: p.deny(ctx, "unset arbitrary metadata")}, nil
+			}
+		case *provider.UpdateGrantRequest:
+			if p.applies(ctx, v.Ref) {
+				return &provider.UpdateGrantResponse{Status: p.deny(ctx, "update grant")}, nil
+			}
+		case *provider.DenyGrantRequest:
+			if p.applies(ctx, v.Ref) {
+				return &provider.DenyGrantResponse{Status: p.deny(ctx, "deny grant")}, nil
+			}
+		case *provider.RestoreFileVersionRequest:
+			if p.applies(ctx, v.Ref) {
+				return &provider.RestoreFileVersionResponse{Status: p.deny(ctx, "restore file version")}, nil
+			}
+		case *provider.SetLockRequest:
+			if p.applies(ctx, v.Ref) {
+				return &provider.SetLockResponse{Status: p.deny(ctx, "set lock")}, nil
+			}
+		case *provider.RefreshLockRequest:
+			if p.applies(ctx, v.Ref) {
+				return &provider.RefreshLockResponse{Status: p.deny(ctx, "refresh lock")}, nil
+			}
+		case *provider.UnlockRequest:
+			if p.applies(ctx, v.Ref) {
+				return &provider.UnlockResponse{Status: p.deny(ctx, "unlock")}, nil
+			}
+		case *provider.CreateReferenceRequest:
+			if p.applies(ctx, v.Ref) {
+				return &provider.CreateReferenceResponse{Status: p.deny(ctx, "create reference")}, nil
+			}
+		case *provider.CreateSymlinkRequest:
+			if p.applies(ctx, v.Ref) {
+				return &provider.CreateSymlinkResponse{Status: p.deny(ctx, "create symlink")}, nil
+			}
+		case *provider.CreateStorageSpaceRequest:
+			if p.applies(ctx) {
+				return &provider.CreateStorageSpaceResponse{Status: p.deny(ctx, "create storage space")}, nil
+			}
+		case *provider.UpdateStorageSpaceRequest:
+			if p.applies(ctx, &provider.Reference{ResourceId: v.GetStorageSpace().GetRoot()}, spaceRef(v.GetStorageSpace().GetId())) {
+				return &provider.UpdateStorageSpaceResponse{Status: p.deny(ctx, "update storage space")}, nil
+			}
+		case *provider.DeleteStorageSpaceRequest:
+			if p.applies(ctx, spaceRef(v.Id)) {
+				return &provider.DeleteStorageSpaceResponse{Status: p.deny(ctx, "delete storage space")}, nil
+			}
 		// block unknown request types and return error
 		default:
-			log.Debug().Msg("storage is readonly")
-			return nil, status.Errorf(codes.PermissionDenied, "permission denied: tried to execute an unknown operation: %T!", req)
+			if p.appliesToUnknown(ctx) {
+				log.Debug().Msg("storage is readonly")
+				return nil, status.Errorf(codes.PermissionDenied, "permission denied: tried to execute an unknown operation on read-only storage: %T!", req)
+			}
 		}
+		return handler(ctx, req)
 	}, defaultPriority, nil
 }
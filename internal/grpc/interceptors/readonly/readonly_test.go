@@ -0,0 +1,236 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package readonly
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	ctxpkg "github.com/cs3org/reva/pkg/ctx"
+	"google.golang.org/grpc"
+)
+
+func ok(ctx context.Context, req interface{}) (interface{}, error) {
+	return &provider.DeleteResponse{Status: &rpc.Status{Code: rpc.Code_CODE_OK}}, nil
+}
+
+func deleteCode(t *testing.T, i grpc.UnaryServerInterceptor, ctx context.Context, ref *provider.Reference) rpc.Code {
+	res, err := i(ctx, &provider.DeleteRequest{Ref: ref}, &grpc.UnaryServerInfo{}, ok)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res.(*provider.DeleteResponse).Status.Code
+}
+
+func TestScopedPolicy(t *testing.T) {
+	i, _, err := NewUnary(map[string]interface{}{
+		"users":   []string{"marie"},
+		"spaces":  []string{"migrating-storage"},
+		"paths":   []string{"/eos/project/a"},
+		"message": "migration in progress",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	einstein := ctxpkg.ContextSetUser(context.Background(), &userpb.User{Id: &userpb.UserId{OpaqueId: "einstein-id"}, Username: "einstein"})
+	marie := ctxpkg.ContextSetUser(context.Background(), &userpb.User{Id: &userpb.UserId{OpaqueId: "marie-id"}, Username: "marie"})
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		ref  *provider.Reference
+		code rpc.Code
+	}{
+		{"writable path", einstein, &provider.Reference{Path: "/eos/project/b/file"}, rpc.Code_CODE_OK},
+		{"read-only path", einstein, &provider.Reference{Path: "/eos/project/a/file"}, rpc.Code_CODE_PERMISSION_DENIED},
+		{"path sharing a prefix", einstein, &provider.Reference{Path: "/eos/project/ab"}, rpc.Code_CODE_OK},
+		{"read-only space", einstein, &provider.Reference{ResourceId: &provider.ResourceId{StorageId: "migrating-storage", OpaqueId: "x"}}, rpc.Code_CODE_PERMISSION_DENIED},
+		{"read-only user", marie, &provider.Reference{Path: "/eos/project/b/file"}, rpc.Code_CODE_PERMISSION_DENIED},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := deleteCode(t, i, tt.ctx, tt.ref); code != tt.code {
+				t.Errorf("expected %s, got %s", tt.code, code)
+			}
+		})
+	}
+}
+
+func TestRelativeReferences(t *testing.T) {
+	s, err := newPolicyStore(map[string]interface{}{"paths": []string{"/eos/project/a"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.resolve = func(ctx context.Context, id *provider.ResourceId) (string, error) {
+		switch id.OpaqueId {
+		case "project-a":
+			return "/eos/project/a", nil
+		case "project-b":
+			return "/eos/project/b", nil
+		}
+		return "", errors.New("not found")
+	}
+	if err := s.load(); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		ref      *provider.Reference
+		readOnly bool
+	}{
+		{"file in read-only folder", &provider.Reference{ResourceId: &provider.ResourceId{StorageId: "eos", OpaqueId: "project-a"}, Path: "./file"}, true},
+		{"read-only folder by id", &provider.Reference{ResourceId: &provider.ResourceId{StorageId: "eos", OpaqueId: "project-a"}}, true},
+		{"file in writable folder", &provider.Reference{ResourceId: &provider.ResourceId{StorageId: "eos", OpaqueId: "project-b"}, Path: "./file"}, false},
+		{"unresolvable resource", &provider.Reference{ResourceId: &provider.ResourceId{StorageId: "eos", OpaqueId: "unknown"}, Path: "./file"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if readOnly := s.get().applies(ctx, tt.ref); readOnly != tt.readOnly {
+				t.Errorf("expected read-only %v, got %v", tt.readOnly, readOnly)
+			}
+		})
+	}
+}
+
+func TestPolicyReload(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "readonly.json")
+	if err := os.WriteFile(file, []byte(`{"paths": ["/a"]}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := newPolicyStore(map[string]interface{}{"policy_file": file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if !s.get().applies(ctx, &provider.Reference{Path: "/a/file"}) || s.get().applies(ctx, &provider.Reference{Path: "/b/file"}) {
+		t.Fatal("unexpected initial policy")
+	}
+
+	if err := os.WriteFile(file, []byte(`{"paths": ["/b"]}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(file, future, future); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.load(); err != nil {
+		t.Fatal(err)
+	}
+	if s.get().applies(ctx, &provider.Reference{Path: "/a/file"}) || !s.get().applies(ctx, &provider.Reference{Path: "/b/file"}) {
+		t.Fatal("policy was not reloaded")
+	}
+}
+
+func TestScopedWriteRequests(t *testing.T) {
+	i, _, err := NewUnary(map[string]interface{}{
+		"spaces": []string{"migrating-storage"},
+		"paths":  []string{"/eos/project/a"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := ctxpkg.ContextSetUser(context.Background(), &userpb.User{Id: &userpb.UserId{OpaqueId: "einstein-id"}, Username: "einstein"})
+
+	readOnly := &provider.Reference{Path: "/eos/project/a/file"}
+	space := &provider.ResourceId{StorageId: "migrating-storage", SpaceId: "space"}
+	tests := []struct {
+		name string
+		req  interface{}
+	}{
+		{"update grant", &provider.UpdateGrantRequest{Ref: readOnly}},
+		{"deny grant", &provider.DenyGrantRequest{Ref: readOnly}},
+		{"restore file version", &provider.RestoreFileVersionRequest{Ref: readOnly}},
+		{"set lock", &provider.SetLockRequest{Ref: readOnly}},
+		{"refresh lock", &provider.RefreshLockRequest{Ref: readOnly}},
+		{"unlock", &provider.UnlockRequest{Ref: readOnly}},
+		{"create reference", &provider.CreateReferenceRequest{Ref: readOnly}},
+		{"update storage space", &provider.UpdateStorageSpaceRequest{StorageSpace: &provider.StorageSpace{Root: space}}},
+		{"delete storage space", &provider.DeleteStorageSpaceRequest{Id: &provider.StorageSpaceId{OpaqueId: "migrating-storage!space"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			res, err := i(ctx, tt.req, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+				called = true
+				return nil, nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if called {
+				t.Fatal("the request reached the handler")
+			}
+			if code := res.(interface{ GetStatus() *rpc.Status }).GetStatus().GetCode(); code != rpc.Code_CODE_PERMISSION_DENIED {
+				t.Errorf("expected %s, got %s", rpc.Code_CODE_PERMISSION_DENIED, code)
+			}
+		})
+	}
+
+	// requests that cannot be checked are blocked as soon as resources are read-only
+	if _, err := i(ctx, &rpc.Status{}, &grpc.UnaryServerInfo{}, ok); err == nil {
+		t.Error("expected unknown requests to be blocked")
+	}
+	if code := deleteCode(t, i, ctx, &provider.Reference{Path: "/eos/project/b/file"}); code != rpc.Code_CODE_OK {
+		t.Errorf("expected writes outside of the read-only resources to succeed, got %s", code)
+	}
+}
+
+func TestGlobalPolicy(t *testing.T) {
+	dir := t.TempDir()
+	empty := filepath.Join(dir, "empty.json")
+	global := filepath.Join(dir, "global.json")
+	if err := os.WriteFile(empty, []byte(`{}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(global, []byte(`{"global": true}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		conf map[string]interface{}
+		code rpc.Code
+	}{
+		{"no rules", map[string]interface{}{}, rpc.Code_CODE_PERMISSION_DENIED},
+		{"empty policy file", map[string]interface{}{"policy_file": empty}, rpc.Code_CODE_OK},
+		{"global policy file", map[string]interface{}{"policy_file": global}, rpc.Code_CODE_PERMISSION_DENIED},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i, _, err := NewUnary(tt.conf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if code := deleteCode(t, i, context.Background(), &provider.Reference{Path: "/file"}); code != tt.code {
+				t.Errorf("expected %s, got %s", tt.code, code)
+			}
+		})
+	}
+}
@@ -20,42 +20,59 @@ package useragent
 
 import (
 	"context"
+	"net"
 
 	ctxpkg "github.com/cs3org/reva/pkg/ctx"
+	"github.com/cs3org/reva/pkg/utils"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 )
 
 // NewUnary returns a new unary interceptor that adds
-// the useragent to the context.
-func NewUnary() grpc.UnaryServerInterceptor {
+// the useragent and the client ip to the context.
+// The client ip is only taken from the request metadata
+// when the peer is one of the trusted proxies.
+func NewUnary(trusted []*net.IPNet) grpc.UnaryServerInterceptor {
 	interceptor := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-		if md, ok := metadata.FromIncomingContext(ctx); ok {
-			if lst, ok := md[ctxpkg.UserAgentHeader]; ok && len(lst) != 0 {
-				ctx = metadata.AppendToOutgoingContext(ctx, ctxpkg.UserAgentHeader, lst[0])
-			}
-		}
-		return handler(ctx, req)
+		return handler(forward(ctx, trusted), req)
 	}
 	return interceptor
 }
 
 // NewStream returns a new server stream interceptor
-// that adds the user agent to the context.
-func NewStream() grpc.StreamServerInterceptor {
+// that adds the user agent and the client ip to the context.
+func NewStream(trusted []*net.IPNet) grpc.StreamServerInterceptor {
 	interceptor := func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
-		ctx := ss.Context()
-		if md, ok := metadata.FromIncomingContext(ctx); ok {
-			if lst, ok := md[ctxpkg.UserAgentHeader]; ok && len(lst) != 0 {
-				ctx = metadata.AppendToOutgoingContext(ctx, ctxpkg.UserAgentHeader, lst[0])
-			}
-		}
-		wrapped := newWrappedServerStream(ctx, ss)
+		wrapped := newWrappedServerStream(forward(ss.Context(), trusted), ss)
 		return handler(srv, wrapped)
 	}
 	return interceptor
 }
 
+// forward propagates the user agent and the client ip to the outgoing requests.
+// A client ip sent by an untrusted peer is replaced with the address of the peer.
+func forward(ctx context.Context, trusted []*net.IPNet) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		md = metadata.MD{}
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil && p.Addr.Network() != "unix" {
+		if addr := utils.RemoteHost(p.Addr.String()); !utils.IsTrustedProxy(addr, trusted) || len(md[ctxpkg.ClientIPHeader]) == 0 {
+			md = md.Copy()
+			md.Set(ctxpkg.ClientIPHeader, addr)
+			ctx = metadata.NewIncomingContext(ctx, md)
+		}
+	}
+
+	for _, key := range []string{ctxpkg.UserAgentHeader, ctxpkg.ClientIPHeader} {
+		if lst, ok := md[key]; ok && len(lst) != 0 {
+			ctx = metadata.AppendToOutgoingContext(ctx, key, lst[0])
+		}
+	}
+	return ctx
+}
+
 func newWrappedServerStream(ctx context.Context, ss grpc.ServerStream) *wrappedServerStream {
 	return &wrappedServerStream{ServerStream: ss, newCtx: ctx}
 }
@@ -22,11 +22,17 @@ import (
 	"context"
 	"fmt"
 	"path/filepath"
+	"time"
 
 	provider "github.com/cs3org/go-cs3apis/cs3/auth/provider/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
 	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/audit"
 	"github.com/cs3org/reva/pkg/auth"
+	"github.com/cs3org/reva/pkg/auth/lockout"
+	lockoutregistry "github.com/cs3org/reva/pkg/auth/lockout/registry"
 	"github.com/cs3org/reva/pkg/auth/manager/registry"
+	ctxpkg "github.com/cs3org/reva/pkg/ctx"
 	"github.com/cs3org/reva/pkg/errtypes"
 	"github.com/cs3org/reva/pkg/plugin"
 	"github.com/cs3org/reva/pkg/rgrpc"
@@ -45,6 +51,9 @@ func init() {
 type config struct {
 	AuthManager  string                            `mapstructure:"auth_manager"`
 	AuthManagers map[string]map[string]interface{} `mapstructure:"auth_managers"`
+	// Lockout configures the protection against brute-force attacks.
+	// Lockouts are written to the sinks of the audit interceptor, if enabled.
+	Lockout      lockout.Config `mapstructure:"lockout"`
 	blockedUsers []string
 }
 
@@ -53,6 +62,7 @@ func (c *config) init() {
 		c.AuthManager = "json"
 	}
 	c.blockedUsers = sharedconf.GetBlockedUsers()
+	c.Lockout.Init()
 }
 
 type service struct {
@@ -60,6 +70,7 @@ type service struct {
 	conf         *config
 	plugin       *plugin.RevaPlugin
 	blockedUsers user.BlockedUsers
+	lockout      *lockout.Policy
 }
 
 func parseConfig(m map[string]interface{}) (*config, error) {
@@ -99,6 +110,21 @@ func getAuthManager(manager string, m map[string]map[string]interface{}) (auth.M
 	return nil, nil, errtypes.NotFound(fmt.Sprintf("authsvc: driver %s not found for auth manager", manager))
 }
 
+func getLockoutPolicy(c *config) (*lockout.Policy, error) {
+	if !c.Lockout.Enabled {
+		return nil, nil
+	}
+	f, ok := lockoutregistry.NewFuncs[c.Lockout.Driver]
+	if !ok {
+		return nil, errtypes.NotFound("authsvc: driver not found for lockout: " + c.Lockout.Driver)
+	}
+	store, err := f(c.Lockout.Drivers[c.Lockout.Driver])
+	if err != nil {
+		return nil, errors.Wrap(err, "authsvc: error creating lockout store")
+	}
+	return lockout.NewPolicy(&c.Lockout, store), nil
+}
+
 // New returns a new AuthProviderServiceServer.
 func New(m map[string]interface{}, ss *grpc.Server) (rgrpc.Service, error) {
 	c, err := parseConfig(m)
@@ -111,11 +137,17 @@ func New(m map[string]interface{}, ss *grpc.Server) (rgrpc.Service, error) {
 		return nil, err
 	}
 
+	lockoutPolicy, err := getLockoutPolicy(c)
+	if err != nil {
+		return nil, err
+	}
+
 	svc := &service{
 		conf:         c,
 		authmgr:      authManager,
 		plugin:       plug,
 		blockedUsers: user.NewBlockedUsersSet(c.blockedUsers),
+		lockout:      lockoutPolicy,
 	}
 
 	return svc, nil
@@ -147,7 +179,33 @@ func (s *service) Authenticate(ctx context.Context, req *provider.AuthenticateRe
 		}, nil
 	}
 
+	// the client address is forwarded by the gateway,
+	// the peer of this call is the gateway itself
+	clientIP, _ := ctxpkg.ContextGetClientIP(ctx)
+	var attempt *lockout.Attempt
+	if s.lockout != nil {
+		var wait time.Duration
+		if attempt, wait = s.lockout.Begin(ctx, &lockout.Request{Username: username, IP: clientIP}); attempt == nil {
+			log.Warn().Str("username", username).Str("ip", clientIP).Dur("wait", wait).Msg("authentication attempt rejected, too many failed attempts")
+			return &provider.AuthenticateResponse{
+				Status: status.NewPermissionDenied(ctx, errtypes.PermissionDenied(""), "too many failed attempts, retry later"),
+			}, nil
+		}
+	}
+
 	u, scope, err := s.authmgr.Authenticate(ctx, username, password)
+	if attempt != nil {
+		switch err.(type) {
+		case nil:
+			attempt.Success(ctx)
+		case errtypes.InvalidCredentials, errtypes.NotFound:
+			s.reportLockouts(ctx, username, clientIP, attempt.Failure(ctx))
+		default:
+			// the backend failed, the credentials may well be right
+			attempt.Abort(ctx)
+		}
+	}
+
 	switch v := err.(type) {
 	case nil:
 		log.Info().Interface("userId", u.Id).Msg("user authenticated")
@@ -171,3 +229,25 @@ func (s *service) Authenticate(ctx context.Context, req *provider.AuthenticateRe
 		}, nil
 	}
 }
+
+// reportLockouts logs the lockouts caused by a failed attempt
+// and writes them to the audit trail.
+func (s *service) reportLockouts(ctx context.Context, username, clientIP string, lockouts []lockout.Lockout) {
+	log := appctx.GetLogger(ctx)
+	auditLogger, auditEnabled := audit.ContextGetLogger(ctx)
+	for _, l := range lockouts {
+		log.Warn().Str("key", l.Key).Int("failures", l.Failures).Dur("duration", l.Duration).Msg("locked out after too many failed attempts")
+		if !auditEnabled {
+			continue
+		}
+		auditLogger.Log(ctx, &audit.Record{
+			Action:   audit.ActionLockout,
+			Protocol: "grpc",
+			Method:   "/cs3.auth.provider.v1beta1.ProviderAPI/Authenticate",
+			Username: username,
+			ClientIP: clientIP,
+			Resource: l.Key,
+			Result:   rpc.Code_CODE_PERMISSION_DENIED.String(),
+		})
+	}
+}
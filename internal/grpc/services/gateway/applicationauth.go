@@ -28,6 +28,10 @@ import (
 )
 
 func (s *svc) GenerateAppPassword(ctx context.Context, req *appauthpb.GenerateAppPasswordRequest) (*appauthpb.GenerateAppPasswordResponse, error) {
+	if st := s.checkStepUp(ctx, stepUpGenerateAppPassword); st != nil {
+		return &appauthpb.GenerateAppPasswordResponse{Status: st}, nil
+	}
+
 	c, err := pool.GetAppAuthProviderServiceClient(pool.Endpoint(s.c.ApplicationAuthEndpoint))
 	if err != nil {
 		err = errors.Wrap(err, "gateway: error calling GetAppAuthProviderServiceClient")
@@ -98,6 +98,12 @@ func (s *svc) Authenticate(ctx context.Context, req *gateway.AuthenticateRequest
 		u.Groups = []string{}
 	}
 
+	tokenScope, failed := s.addAuthLevel(ctx, req, res.User, res.TokenScope)
+	if failed != nil {
+		return failed, nil
+	}
+	res.TokenScope = tokenScope
+
 	// We need to expand the scopes of lightweight accounts, user shares and
 	// public shares, for which we need to retrieve the receieved shares and stat
 	// the resources referenced by these. Since the current scope can do that,
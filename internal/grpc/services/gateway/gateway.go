@@ -26,9 +26,13 @@ import (
 
 	"github.com/ReneKroon/ttlcache/v2"
 	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	"github.com/cs3org/reva/pkg/auth/mfa"
 	"github.com/cs3org/reva/pkg/errtypes"
+	invitelifecyclepb "github.com/cs3org/reva/pkg/ocm/invite/proto"
 	"github.com/cs3org/reva/pkg/rgrpc"
 	"github.com/cs3org/reva/pkg/sharedconf"
+	"github.com/cs3org/reva/pkg/storage/maintenance"
+	maintenancepb "github.com/cs3org/reva/pkg/storage/maintenance/proto"
 	"github.com/cs3org/reva/pkg/token"
 	"github.com/cs3org/reva/pkg/token/manager/registry"
 	"github.com/mitchellh/mapstructure"
@@ -71,6 +75,17 @@ type config struct {
 	EtagCacheTTL        int                               `mapstructure:"etag_cache_ttl"`
 	AllowedUserAgents   map[string][]string               `mapstructure:"allowed_user_agents"` // map[path][]user-agent
 	CreateHomeCacheTTL  int                               `mapstructure:"create_home_cache_ttl"`
+	// MaintenanceFile persists the maintenance state of the storage providers.
+	MaintenanceFile string `mapstructure:"maintenance_file"`
+	// MaintenanceAdmins are the usernames allowed to put storage providers in maintenance.
+	MaintenanceAdmins []string `mapstructure:"maintenance_admins"`
+	// SecondFactors configures the providers verifying a second authentication factor, keyed by method.
+	SecondFactors map[string]map[string]interface{} `mapstructure:"second_factors"`
+	// StepUpOperations are the operations requiring a recent authentication with a second factor:
+	// CreatePublicShare (without password, and any UpdatePublicShare), DeleteStorageSpace and GenerateAppPassword.
+	StepUpOperations []string `mapstructure:"step_up_operations"`
+	// StepUpMaxAge is the number of seconds an authentication with a second factor is considered recent.
+	StepUpMaxAge int `mapstructure:"step_up_max_age"`
 }
 
 // sets defaults.
@@ -115,6 +130,10 @@ func (c *config) init() {
 	if c.TransferExpires == 0 {
 		c.TransferExpires = 100 * 60 // seconds
 	}
+
+	if c.StepUpMaxAge == 0 {
+		c.StepUpMaxAge = 5 * 60 // seconds
+	}
 }
 
 type svc struct {
@@ -123,6 +142,8 @@ type svc struct {
 	tokenmgr        token.Manager
 	etagCache       *ttlcache.Cache `mapstructure:"etag_cache"`
 	createHomeCache *ttlcache.Cache `mapstructure:"create_home_cache"`
+	maintenance     *maintenance.Manager
+	secondFactors   map[string]mfa.Provider
 }
 
 // New creates a new gateway svc that acts as a proxy for any grpc operation.
@@ -155,12 +176,24 @@ func New(m map[string]interface{}, ss *grpc.Server) (rgrpc.Service, error) {
 	_ = createHomeCache.SetTTL(time.Duration(c.CreateHomeCacheTTL) * time.Second)
 	createHomeCache.SkipTTLExtensionOnHit(true)
 
+	maintenanceMgr, err := maintenance.New(c.MaintenanceFile)
+	if err != nil {
+		return nil, err
+	}
+
+	secondFactors, err := getSecondFactorProviders(c)
+	if err != nil {
+		return nil, err
+	}
+
 	s := &svc{
 		c:               c,
 		dataGatewayURL:  *u,
 		tokenmgr:        tokenManager,
 		etagCache:       etagCache,
 		createHomeCache: createHomeCache,
+		maintenance:     maintenanceMgr,
+		secondFactors:   secondFactors,
 	}
 
 	return s, nil
@@ -168,6 +201,8 @@ func New(m map[string]interface{}, ss *grpc.Server) (rgrpc.Service, error) {
 
 func (s *svc) Register(ss *grpc.Server) {
 	gateway.RegisterGatewayAPIServer(ss, s)
+	maintenancepb.RegisterMaintenanceServiceServer(ss, s)
+	invitelifecyclepb.RegisterInviteLifecycleServiceServer(ss, s)
 }
 
 func (s *svc) Close() error {
@@ -0,0 +1,132 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package gateway
+
+import (
+	"context"
+
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	registry "github.com/cs3org/go-cs3apis/cs3/storage/registry/v1beta1"
+	ctxpkg "github.com/cs3org/reva/pkg/ctx"
+	"github.com/cs3org/reva/pkg/rgrpc/status"
+	"github.com/cs3org/reva/pkg/storage/maintenance"
+	maintenancepb "github.com/cs3org/reva/pkg/storage/maintenance/proto"
+)
+
+func (s *svc) SetProviderMaintenance(ctx context.Context, req *maintenancepb.SetProviderMaintenanceRequest) (*maintenancepb.SetProviderMaintenanceResponse, error) {
+	user, ok := s.isMaintenanceAdmin(ctx)
+	if !ok {
+		return &maintenancepb.SetProviderMaintenanceResponse{
+			Status: status.NewPermissionDenied(ctx, nil, "gateway: only administrators can change the maintenance state of storage providers"),
+		}, nil
+	}
+	if req.Provider == "" {
+		return &maintenancepb.SetProviderMaintenanceResponse{
+			Status: status.NewInvalidArg(ctx, "gateway: storage provider id or address is required"),
+		}, nil
+	}
+
+	if !req.Enabled {
+		if err := s.maintenance.Unset(req.Provider); err != nil {
+			return &maintenancepb.SetProviderMaintenanceResponse{
+				Status: status.NewInternal(ctx, err, "gateway: error updating maintenance state"),
+			}, nil
+		}
+		return &maintenancepb.SetProviderMaintenanceResponse{Status: status.NewOK(ctx)}, nil
+	}
+
+	st := &maintenance.Status{
+		Provider:   req.Provider,
+		Reason:     req.Reason,
+		RetryAfter: req.RetryAfter,
+		SetBy:      user,
+	}
+	if err := s.maintenance.Set(st); err != nil {
+		return &maintenancepb.SetProviderMaintenanceResponse{
+			Status: status.NewInternal(ctx, err, "gateway: error updating maintenance state"),
+		}, nil
+	}
+
+	return &maintenancepb.SetProviderMaintenanceResponse{
+		Status:      status.NewOK(ctx),
+		Maintenance: maintenanceToProto(st),
+	}, nil
+}
+
+func (s *svc) ListProviderMaintenance(ctx context.Context, req *maintenancepb.ListProviderMaintenanceRequest) (*maintenancepb.ListProviderMaintenanceResponse, error) {
+	list := s.maintenance.List()
+	providers := make([]*maintenancepb.ProviderMaintenance, 0, len(list))
+	for _, st := range list {
+		providers = append(providers, maintenanceToProto(st))
+	}
+	return &maintenancepb.ListProviderMaintenanceResponse{
+		Status:    status.NewOK(ctx),
+		Providers: providers,
+	}, nil
+}
+
+func (s *svc) isMaintenanceAdmin(ctx context.Context) (string, bool) {
+	u, ok := ctxpkg.ContextGetUser(ctx)
+	if !ok {
+		return "", false
+	}
+	for _, admin := range s.c.MaintenanceAdmins {
+		if admin == u.Username {
+			return u.Username, true
+		}
+	}
+	return u.Username, false
+}
+
+func maintenanceToProto(st *maintenance.Status) *maintenancepb.ProviderMaintenance {
+	return &maintenancepb.ProviderMaintenance{
+		Provider:   st.Provider,
+		Reason:     st.Reason,
+		RetryAfter: st.RetryAfter,
+		Since:      st.Since.Unix(),
+		SetBy:      st.SetBy,
+	}
+}
+
+// checkMaintenance returns a maintenance.Error if any of the
+// storage providers is in maintenance and cannot accept writes.
+// The responses to the refused writes carry the retry hint
+// in the opaque returned by maintenance.Opaque.
+func (s *svc) checkMaintenance(providers ...*registry.ProviderInfo) error {
+	for _, p := range providers {
+		if st, ok := s.maintenance.Get(p); ok {
+			return &maintenance.Error{Status: st}
+		}
+	}
+	return nil
+}
+
+// findWritable is like find, but fails if the storage provider is in maintenance.
+// It must be used for the operations creating or modifying data, while
+// reads and the uploads already initiated are still served.
+func (s *svc) findWritable(ctx context.Context, ref *provider.Reference) (provider.ProviderAPIClient, error) {
+	p, err := s.findProviders(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkMaintenance(p[0]); err != nil {
+		return nil, err
+	}
+	return s.getStorageProviderClient(ctx, p[0])
+}
@@ -0,0 +1,92 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package gateway
+
+import (
+	"context"
+
+	invitelifecyclepb "github.com/cs3org/reva/pkg/ocm/invite/proto"
+	"github.com/cs3org/reva/pkg/rgrpc/status"
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"github.com/pkg/errors"
+)
+
+func (s *svc) ListInviteTokens(ctx context.Context, req *invitelifecyclepb.ListInviteTokensRequest) (*invitelifecyclepb.ListInviteTokensResponse, error) {
+	c, err := pool.GetOCMInviteLifecycleClient(pool.Endpoint(s.c.OCMInviteManagerEndpoint))
+	if err != nil {
+		return &invitelifecyclepb.ListInviteTokensResponse{
+			Status: status.NewInternal(ctx, err, "error getting user invite provider client"),
+		}, nil
+	}
+
+	res, err := c.ListInviteTokens(ctx, req)
+	if err != nil {
+		return nil, errors.Wrap(err, "gateway: error calling ListInviteTokens")
+	}
+
+	return res, nil
+}
+
+func (s *svc) RevokeInviteToken(ctx context.Context, req *invitelifecyclepb.RevokeInviteTokenRequest) (*invitelifecyclepb.RevokeInviteTokenResponse, error) {
+	c, err := pool.GetOCMInviteLifecycleClient(pool.Endpoint(s.c.OCMInviteManagerEndpoint))
+	if err != nil {
+		return &invitelifecyclepb.RevokeInviteTokenResponse{
+			Status: status.NewInternal(ctx, err, "error getting user invite provider client"),
+		}, nil
+	}
+
+	res, err := c.RevokeInviteToken(ctx, req)
+	if err != nil {
+		return nil, errors.Wrap(err, "gateway: error calling RevokeInviteToken")
+	}
+
+	return res, nil
+}
+
+func (s *svc) DeleteExpiredInviteTokens(ctx context.Context, req *invitelifecyclepb.DeleteExpiredInviteTokensRequest) (*invitelifecyclepb.DeleteExpiredInviteTokensResponse, error) {
+	c, err := pool.GetOCMInviteLifecycleClient(pool.Endpoint(s.c.OCMInviteManagerEndpoint))
+	if err != nil {
+		return &invitelifecyclepb.DeleteExpiredInviteTokensResponse{
+			Status: status.NewInternal(ctx, err, "error getting user invite provider client"),
+		}, nil
+	}
+
+	res, err := c.DeleteExpiredInviteTokens(ctx, req)
+	if err != nil {
+		return nil, errors.Wrap(err, "gateway: error calling DeleteExpiredInviteTokens")
+	}
+
+	return res, nil
+}
+
+func (s *svc) DeleteAcceptedUser(ctx context.Context, req *invitelifecyclepb.DeleteAcceptedUserRequest) (*invitelifecyclepb.DeleteAcceptedUserResponse, error) {
+	c, err := pool.GetOCMInviteLifecycleClient(pool.Endpoint(s.c.OCMInviteManagerEndpoint))
+	if err != nil {
+		return &invitelifecyclepb.DeleteAcceptedUserResponse{
+			Status: status.NewInternal(ctx, err, "error getting user invite provider client"),
+		}, nil
+	}
+
+	res, err := c.DeleteAcceptedUser(ctx, req)
+	if err != nil {
+		return nil, errors.Wrap(err, "gateway: error calling DeleteAcceptedUser")
+	}
+
+	return res, nil
+}
@@ -20,6 +20,7 @@ package gateway
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/url"
 	"path"
@@ -34,6 +35,7 @@ import (
 	"github.com/cs3org/reva/pkg/appctx"
 	ctxpkg "github.com/cs3org/reva/pkg/ctx"
 	"github.com/cs3org/reva/pkg/errtypes"
+	ocmshare "github.com/cs3org/reva/pkg/ocm/share"
 	"github.com/cs3org/reva/pkg/rgrpc/status"
 	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
 	"github.com/pkg/errors"
@@ -225,6 +227,12 @@ func (s *svc) UpdateReceivedOCMShare(ctx context.Context, req *ocm.UpdateReceive
 		}, nil
 	}
 
+	if res.Status.Code == rpc.Code_CODE_OK && isAcceptedWithProtocol(req, ocmshare.WebAppProtocol) {
+		// nothing to commit to the storage, the recipient opens the share
+		// in the editor of the owner's provider
+		return s.openOCMWebApp(ctx, req), nil
+	}
+
 	// if we don't need to create/delete references then we return early.
 	if !s.c.CommitShareToStorageGrant && !s.c.CommitShareToStorageRef {
 		return res, nil
@@ -268,7 +276,14 @@ func (s *svc) UpdateReceivedOCMShare(ctx context.Context, req *ocm.UpdateReceive
 					panic("gateway: error updating a received share: the share is nil")
 				}
 
-				if share.GetShare().ShareType == ocm.Share_SHARE_TYPE_TRANSFER {
+				protocol, protocols, err := getReceivedShareProtocol(req, share.GetShare())
+				if err != nil {
+					return &ocm.UpdateReceivedOCMShareResponse{
+						Status: status.NewInvalidArg(ctx, err.Error()),
+					}, nil
+				}
+
+				if protocol == ocmshare.DataTxProtocol {
 					srcIdp := share.GetShare().GetOwner().GetIdp()
 					meshProvider, err := s.GetInfoByDomain(ctx, &ocmprovider.GetInfoByDomainRequest{
 						Domain: srcIdp,
@@ -308,23 +323,7 @@ func (s *svc) UpdateReceivedOCMShare(ctx context.Context, req *ocm.UpdateReceive
 						}
 					}
 
-					var srcToken string
-					srcTokenOpaque, ok := share.GetShare().Grantee.Opaque.Map["token"]
-					if !ok {
-						return &ocm.UpdateReceivedOCMShareResponse{
-							Status: status.NewNotFound(ctx, "token not found"),
-						}, nil
-					}
-					switch srcTokenOpaque.Decoder {
-					case "plain":
-						srcToken = string(srcTokenOpaque.Value)
-					default:
-						err := errtypes.NotSupported("opaque entry decoder not recognized: " + srcTokenOpaque.Decoder)
-						return &ocm.UpdateReceivedOCMShareResponse{
-							Status: status.NewInternal(ctx, err, "error updating received share"),
-						}, nil
-					}
-
+					srcToken := protocols.DataTx.SharedSecret
 					srcPath := path.Join(srcEndpointPath, share.GetShare().Name)
 					srcTargetURI := fmt.Sprintf("%s://%s@%s?name=%s", srcEndpointScheme, srcToken, srcServiceHost, srcPath)
 
@@ -411,7 +410,7 @@ func (s *svc) UpdateReceivedOCMShare(ctx context.Context, req *ocm.UpdateReceive
 					}, nil
 				}
 
-				createRefStatus, err := s.createOCMReference(ctx, share.Share)
+				createRefStatus, err := s.createOCMReference(ctx, share.Share, protocols.WebDAV.SharedSecret)
 				return &ocm.UpdateReceivedOCMShareResponse{
 					Status: createRefStatus,
 				}, err
@@ -521,21 +520,11 @@ func (s *svc) removeOCMReference(ctx context.Context, resourceID *provider.Resou
 	return status.NewOK(ctx)
 }
 
-func (s *svc) createOCMReference(ctx context.Context, share *ocm.Share) (*rpc.Status, error) {
+func (s *svc) createOCMReference(ctx context.Context, share *ocm.Share, token string) (*rpc.Status, error) {
 	log := appctx.GetLogger(ctx)
-
-	var token string
-	tokenOpaque, ok := share.Grantee.Opaque.Map["token"]
-	if !ok {
+	if token == "" {
 		return status.NewNotFound(ctx, "token not found"), nil
 	}
-	switch tokenOpaque.Decoder {
-	case "plain":
-		token = string(tokenOpaque.Value)
-	default:
-		err := errtypes.NotSupported("opaque entry decoder not recognized: " + tokenOpaque.Decoder)
-		return status.NewInternal(ctx, err, "invalid opaque entry decoder"), nil
-	}
 
 	homeRes, err := s.GetHome(ctx, &provider.GetHomeRequest{})
 	if err != nil {
@@ -581,3 +570,91 @@ func (s *svc) createOCMReference(ctx context.Context, share *ocm.Share) (*rpc.St
 
 	return status.NewOK(ctx), nil
 }
+
+// openOCMWebApp returns the URI opening an accepted share in the editor of the owner's provider,
+// in the "webapp" opaque entry of the response.
+func (s *svc) openOCMWebApp(ctx context.Context, req *ocm.UpdateReceivedOCMShareRequest) *ocm.UpdateReceivedOCMShareResponse {
+	getShareRes, err := s.GetReceivedOCMShare(ctx, &ocm.GetReceivedOCMShareRequest{
+		Ref: &ocm.ShareReference{
+			Spec: &ocm.ShareReference_Id{
+				Id: req.GetShare().GetShare().GetId(),
+			},
+		},
+	})
+	if err != nil {
+		return &ocm.UpdateReceivedOCMShareResponse{
+			Status: status.NewInternal(ctx, err, "error getting received share"),
+		}
+	}
+	if getShareRes.Status.Code != rpc.Code_CODE_OK {
+		return &ocm.UpdateReceivedOCMShareResponse{
+			Status: getShareRes.Status,
+		}
+	}
+
+	_, protocols, err := getReceivedShareProtocol(req, getShareRes.GetShare().GetShare())
+	if err != nil {
+		return &ocm.UpdateReceivedOCMShareResponse{
+			Status: status.NewInvalidArg(ctx, err.Error()),
+		}
+	}
+	val, err := json.Marshal(protocols.WebApp)
+	if err != nil {
+		return &ocm.UpdateReceivedOCMShareResponse{
+			Status: status.NewInternal(ctx, err, "error encoding webapp protocol"),
+		}
+	}
+
+	return &ocm.UpdateReceivedOCMShareResponse{
+		Status: status.NewOK(ctx),
+		Opaque: &types.Opaque{
+			Map: map[string]*types.OpaqueEntry{
+				ocmshare.WebAppProtocol: {
+					Decoder: "json",
+					Value:   val,
+				},
+			},
+		},
+	}
+}
+
+// isAcceptedWithProtocol returns whether the request accepts a share
+// choosing to access it with the given protocol.
+func isAcceptedWithProtocol(req *ocm.UpdateReceivedOCMShareRequest, protocol string) bool {
+	if req.GetShare().GetState() != ocm.ShareState_SHARE_STATE_ACCEPTED {
+		return false
+	}
+	e, ok := req.GetOpaque().GetMap()["protocol"]
+	if !ok || e.Decoder != "plain" || string(e.Value) != protocol {
+		return false
+	}
+	for _, p := range req.GetUpdateMask().GetPaths() {
+		if p == "state" {
+			return true
+		}
+	}
+	return false
+}
+
+// getReceivedShareProtocol returns the protocol the recipient chose to access a share with,
+// set in the "protocol" opaque entry of the request, along with the protocols the share is offered with.
+// If not chosen, transfer shares are transferred and the others are mounted through webdav.
+func getReceivedShareProtocol(req *ocm.UpdateReceivedOCMShareRequest, share *ocm.Share) (string, *ocmshare.Protocols, error) {
+	protocols, err := ocmshare.GetProtocols(share)
+	if err != nil {
+		return "", nil, err
+	}
+
+	protocol := ocmshare.WebDAVProtocol
+	if share.GetShareType() == ocm.Share_SHARE_TYPE_TRANSFER {
+		protocol = ocmshare.DataTxProtocol
+	}
+	if e, ok := req.GetOpaque().GetMap()["protocol"]; ok && e.Decoder == "plain" {
+		protocol = string(e.Value)
+	}
+
+	if !protocols.Has(protocol) {
+		return "", nil, errtypes.BadRequest("share not offered with protocol " + protocol)
+	}
+	return protocol, protocols, nil
+}
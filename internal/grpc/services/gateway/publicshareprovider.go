@@ -34,6 +34,12 @@ func (s *svc) CreatePublicShare(ctx context.Context, req *link.CreatePublicShare
 		return nil, errtypes.AlreadyExists("gateway: can't create a public share of the share folder itself")
 	}
 
+	if req.GetGrant().GetPassword() == "" {
+		if st := s.checkStepUp(ctx, stepUpCreatePublicShare); st != nil {
+			return &link.CreatePublicShareResponse{Status: st}, nil
+		}
+	}
+
 	log := appctx.GetLogger(ctx)
 	log.Info().Msg("create public share")
 
@@ -125,6 +131,12 @@ func (s *svc) UpdatePublicShare(ctx context.Context, req *link.UpdatePublicShare
 	log := appctx.GetLogger(ctx)
 	log.Info().Msg("update public share")
 
+	// an update can remove the password or widen the permissions of a link,
+	// so it requires the same step-up as creating a link without password
+	if st := s.checkStepUp(ctx, stepUpCreatePublicShare); st != nil {
+		return &link.UpdatePublicShareResponse{Status: st}, nil
+	}
+
 	pClient, err := pool.GetPublicShareProviderClient(pool.Endpoint(s.c.PublicShareProviderEndpoint))
 	if err != nil {
 		log.Err(err).Msg("error connecting to a public share provider")
@@ -0,0 +1,139 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package gateway
+
+import (
+	"context"
+	"time"
+
+	authpb "github.com/cs3org/go-cs3apis/cs3/auth/provider/v1beta1"
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/cs3org/reva/pkg/auth/mfa"
+	"github.com/cs3org/reva/pkg/auth/mfa/registry"
+	"github.com/cs3org/reva/pkg/auth/scope"
+	ctxpkg "github.com/cs3org/reva/pkg/ctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/rgrpc/status"
+	"github.com/pkg/errors"
+)
+
+// Operations that can be configured to require a step-up authentication.
+const (
+	stepUpCreatePublicShare   = "CreatePublicShare"
+	stepUpDeleteStorageSpace  = "DeleteStorageSpace"
+	stepUpGenerateAppPassword = "GenerateAppPassword"
+)
+
+func getSecondFactorProviders(c *config) (map[string]mfa.Provider, error) {
+	providers := make(map[string]mfa.Provider, len(c.SecondFactors))
+	for method, conf := range c.SecondFactors {
+		f, ok := registry.NewFuncs[method]
+		if !ok {
+			return nil, errtypes.NotFound("gateway: second factor provider not found: " + method)
+		}
+		p, err := f(conf)
+		if err != nil {
+			return nil, errors.Wrapf(err, "gateway: error creating second factor provider %s", method)
+		}
+		providers[method] = p
+	}
+	return providers, nil
+}
+
+// addAuthLevel verifies the second factor sent along with the authentication
+// request, if any, and records the strength of the authentication in the
+// scopes of the token. A non nil response is returned when the authentication
+// cannot proceed.
+func (s *svc) addAuthLevel(ctx context.Context, req *gateway.AuthenticateRequest, u *userpb.User, scopes map[string]*authpb.Scope) (map[string]*authpb.Scope, *gateway.AuthenticateResponse) {
+	level := &scope.AuthLevel{
+		Factors: []string{req.Type},
+		Time:    time.Now().Unix(),
+	}
+
+	if factor, ok := req.Opaque.GetMap()[mfa.OpaqueKey]; ok && len(s.secondFactors) > 0 {
+		method, response := mfa.ParseFactor(string(factor.Value))
+		p, ok := s.secondFactors[method]
+		if !ok {
+			return nil, &gateway.AuthenticateResponse{
+				Status: status.NewInvalidArg(ctx, "gateway: unsupported second factor: "+method),
+			}
+		}
+
+		if response == "" {
+			challenge, err := p.Challenge(ctx, u)
+			if err != nil {
+				return nil, &gateway.AuthenticateResponse{
+					Status: status.NewStatusFromErrType(ctx, "gateway: error creating second factor challenge", err),
+				}
+			}
+			return nil, &gateway.AuthenticateResponse{
+				Status: status.NewUnauthenticated(ctx, nil, "gateway: the second factor challenge must be answered"),
+				Opaque: &types.Opaque{
+					Map: map[string]*types.OpaqueEntry{
+						mfa.ChallengeOpaqueKey: {
+							Decoder: "plain",
+							Value:   []byte(method + " " + challenge),
+						},
+					},
+				},
+			}
+		}
+
+		if err := p.Verify(ctx, u, response); err != nil {
+			return nil, &gateway.AuthenticateResponse{
+				Status: status.NewUnauthenticated(ctx, err, "gateway: invalid second factor"),
+			}
+		}
+		level.Factors = append(level.Factors, method)
+	}
+
+	scopes, err := scope.AddAuthLevelScope(level, scopes)
+	if err != nil {
+		return nil, &gateway.AuthenticateResponse{
+			Status: status.NewInternal(ctx, err, "gateway: error recording the authentication level"),
+		}
+	}
+	return scopes, nil
+}
+
+// checkStepUp returns a non nil status if the operation requires a recent
+// authentication with a second factor the caller has not passed.
+func (s *svc) checkStepUp(ctx context.Context, operation string) *rpc.Status {
+	if !s.requiresStepUp(operation) {
+		return nil
+	}
+
+	scopes, _ := ctxpkg.ContextGetScopes(ctx)
+	if level, ok := scope.GetAuthLevel(scopes); ok && level.Strong() && level.Fresh(time.Duration(s.c.StepUpMaxAge)*time.Second) {
+		return nil
+	}
+	return status.NewUnauthenticated(ctx, errtypes.PermissionDenied(operation), "gateway: "+operation+" requires a recent authentication with a second factor")
+}
+
+func (s *svc) requiresStepUp(operation string) bool {
+	for _, op := range s.c.StepUpOperations {
+		if op == operation {
+			return true
+		}
+	}
+	return false
+}
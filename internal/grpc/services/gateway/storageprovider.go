@@ -39,6 +39,7 @@ import (
 	"github.com/cs3org/reva/pkg/errtypes"
 	"github.com/cs3org/reva/pkg/rgrpc/status"
 	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"github.com/cs3org/reva/pkg/storage/maintenance"
 	rtrace "github.com/cs3org/reva/pkg/trace"
 	"github.com/cs3org/reva/pkg/utils"
 	"github.com/golang-jwt/jwt"
@@ -250,10 +251,11 @@ func (s *svc) listStorageSpacesOnProvider(ctx context.Context, req *provider.Lis
 func (s *svc) UpdateStorageSpace(ctx context.Context, req *provider.UpdateStorageSpaceRequest) (*provider.UpdateStorageSpaceResponse, error) {
 	log := appctx.GetLogger(ctx)
 	// TODO: needs to be fixed
-	c, err := s.find(ctx, &provider.Reference{ResourceId: req.StorageSpace.Root})
+	c, err := s.findWritable(ctx, &provider.Reference{ResourceId: req.StorageSpace.Root})
 	if err != nil {
 		return &provider.UpdateStorageSpaceResponse{
 			Status: status.NewStatusFromErrType(ctx, "error finding ID", err),
+			Opaque: maintenance.Opaque(err),
 		}, nil
 	}
 
@@ -268,6 +270,10 @@ func (s *svc) UpdateStorageSpace(ctx context.Context, req *provider.UpdateStorag
 }
 
 func (s *svc) DeleteStorageSpace(ctx context.Context, req *provider.DeleteStorageSpaceRequest) (*provider.DeleteStorageSpaceResponse, error) {
+	if st := s.checkStepUp(ctx, stepUpDeleteStorageSpace); st != nil {
+		return &provider.DeleteStorageSpaceResponse{Status: st}, nil
+	}
+
 	log := appctx.GetLogger(ctx)
 	// TODO: needs to be fixed
 	storageid, opaqeid, err := utils.SplitStorageSpaceID(req.Id.OpaqueId)
@@ -276,13 +282,14 @@ func (s *svc) DeleteStorageSpace(ctx context.Context, req *provider.DeleteStorag
 			Status: status.NewInvalidArg(ctx, "space id must be separated by !"),
 		}, nil
 	}
-	c, err := s.find(ctx, &provider.Reference{ResourceId: &provider.ResourceId{
+	c, err := s.findWritable(ctx, &provider.Reference{ResourceId: &provider.ResourceId{
 		StorageId: storageid,
 		OpaqueId:  opaqeid,
 	}})
 	if err != nil {
 		return &provider.DeleteStorageSpaceResponse{
 			Status: status.NewStatusFromErrType(ctx, "error finding path", err),
+			Opaque: maintenance.Opaque(err),
 		}, nil
 	}
 
@@ -671,10 +678,11 @@ func (s *svc) InitiateFileUpload(ctx context.Context, req *provider.InitiateFile
 }
 
 func (s *svc) initiateFileUpload(ctx context.Context, req *provider.InitiateFileUploadRequest) (*gateway.InitiateFileUploadResponse, error) {
-	c, err := s.find(ctx, req.Ref)
+	c, err := s.findWritable(ctx, req.Ref)
 	if err != nil {
 		return &gateway.InitiateFileUploadResponse{
 			Status: status.NewStatusFromErrType(ctx, "initiateFileUpload ref="+req.Ref.String(), err),
+			Opaque: maintenance.Opaque(err),
 		}, nil
 	}
 
@@ -824,10 +832,11 @@ func (s *svc) CreateContainer(ctx context.Context, req *provider.CreateContainer
 }
 
 func (s *svc) createContainer(ctx context.Context, req *provider.CreateContainerRequest) (*provider.CreateContainerResponse, error) {
-	c, err := s.find(ctx, req.Ref)
+	c, err := s.findWritable(ctx, req.Ref)
 	if err != nil {
 		return &provider.CreateContainerResponse{
 			Status: status.NewStatusFromErrType(ctx, "createContainer ref="+req.Ref.String(), err),
+			Opaque: maintenance.Opaque(err),
 		}, nil
 	}
 
@@ -843,10 +852,11 @@ func (s *svc) createContainer(ctx context.Context, req *provider.CreateContainer
 }
 
 func (s *svc) TouchFile(ctx context.Context, req *provider.TouchFileRequest) (*provider.TouchFileResponse, error) {
-	c, err := s.find(ctx, req.Ref)
+	c, err := s.findWritable(ctx, req.Ref)
 	if err != nil {
 		return &provider.TouchFileResponse{
 			Status: status.NewStatusFromErrType(ctx, "TouchFile ref="+req.Ref.String(), err),
+			Opaque: maintenance.Opaque(err),
 		}, nil
 	}
 
@@ -995,10 +1005,11 @@ func (s *svc) Delete(ctx context.Context, req *provider.DeleteRequest) (*provide
 
 func (s *svc) delete(ctx context.Context, req *provider.DeleteRequest) (*provider.DeleteResponse, error) {
 	// TODO(ishank011): enable deleting references spread across storage providers, eg. /eos
-	c, err := s.find(ctx, req.Ref)
+	c, err := s.findWritable(ctx, req.Ref)
 	if err != nil {
 		return &provider.DeleteResponse{
 			Status: status.NewStatusFromErrType(ctx, "delete ref="+req.Ref.String(), err),
+			Opaque: maintenance.Opaque(err),
 		}, nil
 	}
 
@@ -1161,6 +1172,13 @@ func (s *svc) move(ctx context.Context, req *provider.MoveRequest) (*provider.Mo
 		return res, nil
 	}
 
+	if err := s.checkMaintenance(srcProvider, dstProvider); err != nil {
+		return &provider.MoveResponse{
+			Status: status.NewStatusFromErrType(ctx, "move src="+req.Source.String(), err),
+			Opaque: maintenance.Opaque(err),
+		}, nil
+	}
+
 	c, err := s.getStorageProviderClient(ctx, srcProvider)
 	if err != nil {
 		return &provider.MoveResponse{
@@ -1173,10 +1191,11 @@ func (s *svc) move(ctx context.Context, req *provider.MoveRequest) (*provider.Mo
 
 func (s *svc) SetArbitraryMetadata(ctx context.Context, req *provider.SetArbitraryMetadataRequest) (*provider.SetArbitraryMetadataResponse, error) {
 	// TODO(ishank011): enable for references spread across storage providers, eg. /eos
-	c, err := s.find(ctx, req.Ref)
+	c, err := s.findWritable(ctx, req.Ref)
 	if err != nil {
 		return &provider.SetArbitraryMetadataResponse{
 			Status: status.NewStatusFromErrType(ctx, "SetArbitraryMetadata ref="+req.Ref.String(), err),
+			Opaque: maintenance.Opaque(err),
 		}, nil
 	}
 
@@ -1193,10 +1212,11 @@ func (s *svc) SetArbitraryMetadata(ctx context.Context, req *provider.SetArbitra
 
 func (s *svc) UnsetArbitraryMetadata(ctx context.Context, req *provider.UnsetArbitraryMetadataRequest) (*provider.UnsetArbitraryMetadataResponse, error) {
 	// TODO(ishank011): enable for references spread across storage providers, eg. /eos
-	c, err := s.find(ctx, req.Ref)
+	c, err := s.findWritable(ctx, req.Ref)
 	if err != nil {
 		return &provider.UnsetArbitraryMetadataResponse{
 			Status: status.NewStatusFromErrType(ctx, "UnsetArbitraryMetadata ref="+req.Ref.String(), err),
+			Opaque: maintenance.Opaque(err),
 		}, nil
 	}
 
@@ -1213,10 +1233,11 @@ func (s *svc) UnsetArbitraryMetadata(ctx context.Context, req *provider.UnsetArb
 
 // SetLock puts a lock on the given reference.
 func (s *svc) SetLock(ctx context.Context, req *provider.SetLockRequest) (*provider.SetLockResponse, error) {
-	c, err := s.find(ctx, req.Ref)
+	c, err := s.findWritable(ctx, req.Ref)
 	if err != nil {
 		return &provider.SetLockResponse{
 			Status: status.NewStatusFromErrType(ctx, "SetLock ref="+req.Ref.String(), err),
+			Opaque: maintenance.Opaque(err),
 		}, nil
 	}
 
@@ -1253,10 +1274,11 @@ func (s *svc) GetLock(ctx context.Context, req *provider.GetLockRequest) (*provi
 
 // RefreshLock refreshes an existing lock on the given reference.
 func (s *svc) RefreshLock(ctx context.Context, req *provider.RefreshLockRequest) (*provider.RefreshLockResponse, error) {
-	c, err := s.find(ctx, req.Ref)
+	c, err := s.findWritable(ctx, req.Ref)
 	if err != nil {
 		return &provider.RefreshLockResponse{
 			Status: status.NewStatusFromErrType(ctx, "RefreshLock ref="+req.Ref.String(), err),
+			Opaque: maintenance.Opaque(err),
 		}, nil
 	}
 
@@ -1273,10 +1295,11 @@ func (s *svc) RefreshLock(ctx context.Context, req *provider.RefreshLockRequest)
 
 // Unlock removes an existing lock from the given reference.
 func (s *svc) Unlock(ctx context.Context, req *provider.UnlockRequest) (*provider.UnlockResponse, error) {
-	c, err := s.find(ctx, req.Ref)
+	c, err := s.findWritable(ctx, req.Ref)
 	if err != nil {
 		return &provider.UnlockResponse{
 			Status: status.NewStatusFromErrType(ctx, "Unlock ref="+req.Ref.String(), err),
+			Opaque: maintenance.Opaque(err),
 		}, nil
 	}
 
@@ -1939,10 +1962,11 @@ func (s *svc) ListFileVersions(ctx context.Context, req *provider.ListFileVersio
 }
 
 func (s *svc) RestoreFileVersion(ctx context.Context, req *provider.RestoreFileVersionRequest) (*provider.RestoreFileVersionResponse, error) {
-	c, err := s.find(ctx, req.Ref)
+	c, err := s.findWritable(ctx, req.Ref)
 	if err != nil {
 		return &provider.RestoreFileVersionResponse{
 			Status: status.NewStatusFromErrType(ctx, "RestoreFileVersion ref="+req.Ref.String(), err),
+			Opaque: maintenance.Opaque(err),
 		}, nil
 	}
 
@@ -1976,10 +2000,11 @@ func (s *svc) ListRecycle(ctx context.Context, req *provider.ListRecycleRequest)
 }
 
 func (s *svc) RestoreRecycleItem(ctx context.Context, req *provider.RestoreRecycleItemRequest) (*provider.RestoreRecycleItemResponse, error) {
-	c, err := s.find(ctx, req.Ref)
+	c, err := s.findWritable(ctx, req.Ref)
 	if err != nil {
 		return &provider.RestoreRecycleItemResponse{
 			Status: status.NewStatusFromErrType(ctx, "RestoreRecycleItem ref="+req.Ref.String(), err),
+			Opaque: maintenance.Opaque(err),
 		}, nil
 	}
 
@@ -1992,10 +2017,11 @@ func (s *svc) RestoreRecycleItem(ctx context.Context, req *provider.RestoreRecyc
 }
 
 func (s *svc) PurgeRecycle(ctx context.Context, req *provider.PurgeRecycleRequest) (*provider.PurgeRecycleResponse, error) {
-	c, err := s.find(ctx, req.Ref)
+	c, err := s.findWritable(ctx, req.Ref)
 	if err != nil {
 		return &provider.PurgeRecycleResponse{
 			Status: status.NewStatusFromErrType(ctx, "PurgeRecycle ref="+req.Ref.String(), err),
+			Opaque: maintenance.Opaque(err),
 		}, nil
 	}
 
@@ -323,7 +323,8 @@ func (s *svc) UpdateReceivedShare(ctx context.Context, req *collaboration.Update
 	// if we don't need to create/delete references then we return early.
 	if !s.c.CommitShareToStorageRef ||
 		ctxpkg.ContextMustGetUser(ctx).Id.Type == userpb.UserType_USER_TYPE_LIGHTWEIGHT ||
-		ctxpkg.ContextMustGetUser(ctx).Id.Type == userpb.UserType_USER_TYPE_FEDERATED {
+		ctxpkg.ContextMustGetUser(ctx).Id.Type == userpb.UserType_USER_TYPE_FEDERATED ||
+		ctxpkg.ContextMustGetUser(ctx).Id.Type == userpb.UserType_USER_TYPE_GUEST {
 		return res, nil
 	}
 
@@ -502,10 +503,13 @@ func (s *svc) createReference(ctx context.Context, share *collaboration.Share) *
 	// from the main request.
 	// TODO(labkode): the name of the share should be the filename it points to by default.
 	refPath := &provider.Reference{Path: path.Join(homeRes.Path, s.c.ShareFolder, path.Base(statRes.Info.Path))}
-	c, err = s.findByPath(ctx, refPath.Path)
+	c, err = s.findWritable(ctx, refPath)
 	if err != nil {
-		if _, ok := err.(errtypes.IsNotFound); ok {
+		switch err.(type) {
+		case errtypes.IsNotFound:
 			return status.NewNotFound(ctx, "storage provider not found")
+		case errtypes.IsUnavailable:
+			return status.NewUnavailable(ctx, err, "storage provider under maintenance")
 		}
 		return status.NewInternal(ctx, err, "error finding storage provider")
 	}
@@ -554,10 +558,13 @@ func (s *svc) denyGrant(ctx context.Context, id *provider.ResourceId, g *provide
 		Grantee: g,
 	}
 
-	c, err := s.find(ctx, ref)
+	c, err := s.findWritable(ctx, ref)
 	if err != nil {
-		if _, ok := err.(errtypes.IsNotFound); ok {
+		switch err.(type) {
+		case errtypes.IsNotFound:
 			return status.NewNotFound(ctx, "storage provider not found"), nil
+		case errtypes.IsUnavailable:
+			return status.NewUnavailable(ctx, err, "storage provider under maintenance"), nil
 		}
 		return status.NewInternal(ctx, err, "error finding storage provider"), nil
 	}
@@ -587,10 +594,13 @@ func (s *svc) addGrant(ctx context.Context, id *provider.ResourceId, g *provider
 		},
 	}
 
-	c, err := s.find(ctx, ref)
+	c, err := s.findWritable(ctx, ref)
 	if err != nil {
-		if _, ok := err.(errtypes.IsNotFound); ok {
+		switch err.(type) {
+		case errtypes.IsNotFound:
 			return status.NewNotFound(ctx, "storage provider not found"), nil
+		case errtypes.IsUnavailable:
+			return status.NewUnavailable(ctx, err, "storage provider under maintenance"), nil
 		}
 		return status.NewInternal(ctx, err, "error finding storage provider"), nil
 	}
@@ -619,10 +629,13 @@ func (s *svc) updateGrant(ctx context.Context, id *provider.ResourceId, g *provi
 		},
 	}
 
-	c, err := s.find(ctx, ref)
+	c, err := s.findWritable(ctx, ref)
 	if err != nil {
-		if _, ok := err.(errtypes.IsNotFound); ok {
+		switch err.(type) {
+		case errtypes.IsNotFound:
 			return status.NewNotFound(ctx, "storage provider not found"), nil
+		case errtypes.IsUnavailable:
+			return status.NewUnavailable(ctx, err, "storage provider under maintenance"), nil
 		}
 		return status.NewInternal(ctx, err, "error finding storage provider"), nil
 	}
@@ -652,10 +665,13 @@ func (s *svc) removeGrant(ctx context.Context, id *provider.ResourceId, g *provi
 		},
 	}
 
-	c, err := s.find(ctx, ref)
+	c, err := s.findWritable(ctx, ref)
 	if err != nil {
-		if _, ok := err.(errtypes.IsNotFound); ok {
+		switch err.(type) {
+		case errtypes.IsNotFound:
 			return status.NewNotFound(ctx, "storage provider not found"), nil
+		case errtypes.IsUnavailable:
+			return status.NewUnavailable(ctx, err, "storage provider under maintenance"), nil
 		}
 		return status.NewInternal(ctx, err, "error finding storage provider"), nil
 	}
@@ -173,15 +173,14 @@ func (s *service) CreateOCMCoreShare(ctx context.Context, req *ocmcore.CreateOCM
 		},
 	}
 
-	var shareType ocm.Share_ShareType
-	switch req.Protocol.Name {
-	case "datatx":
-		shareType = ocm.Share_SHARE_TYPE_TRANSFER
-	default:
-		shareType = ocm.Share_SHARE_TYPE_REGULAR
+	protocols, err := getProtocols(req.Protocol, resourcePermissions, token)
+	if err != nil {
+		return &ocmcore.CreateOCMCoreShareResponse{
+			Status: status.NewInternal(ctx, err, "error decoding share protocols"),
+		}, nil
 	}
 
-	share, err := s.sm.Share(ctx, resource, grant, req.Name, nil, "", req.Owner, token, shareType)
+	share, err := s.sm.Share(ctx, resource, grant, req.Name, nil, protocols, req.Owner, token, protocols.ShareType())
 
 	if err != nil {
 		return &ocmcore.CreateOCMCoreShareResponse{
@@ -196,3 +195,29 @@ func (s *service) CreateOCMCoreShare(ctx context.Context, req *ocmcore.CreateOCM
 	}
 	return res, nil
 }
+
+// getProtocols returns the protocols the share can be accessed with.
+// Requests only carrying the protocol name refer to a single protocol,
+// accessed with the given token.
+func getProtocols(p *ocmcore.Protocol, perms *provider.ResourcePermissions, token string) (*share.Protocols, error) {
+	e, ok := p.Opaque.Map["protocols"]
+	if !ok {
+		switch p.Name {
+		case share.DataTxProtocol:
+			return &share.Protocols{DataTx: &share.DataTx{SharedSecret: token}}, nil
+		default:
+			return &share.Protocols{WebDAV: &share.WebDAV{SharedSecret: token, Permissions: share.WebDAVPermissions(perms)}}, nil
+		}
+	}
+	if e.Decoder != "json" {
+		return nil, errtypes.NotSupported("opaque entry decoder not recognized: " + e.Decoder)
+	}
+	var protocols share.Protocols
+	if err := json.Unmarshal(e.Value, &protocols); err != nil {
+		return nil, err
+	}
+	if len(protocols.Names()) == 0 {
+		return nil, errtypes.BadRequest("no protocol given")
+	}
+	return &protocols, nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ocminvitemanager
+
+import (
+	"context"
+
+	"github.com/cs3org/reva/pkg/errtypes"
+	invitelifecyclepb "github.com/cs3org/reva/pkg/ocm/invite/proto"
+	"github.com/cs3org/reva/pkg/rgrpc/status"
+)
+
+func (s *service) ListInviteTokens(ctx context.Context, req *invitelifecyclepb.ListInviteTokensRequest) (*invitelifecyclepb.ListInviteTokensResponse, error) {
+	tokens, err := s.im.ListInviteTokens(ctx)
+	if err != nil {
+		return &invitelifecyclepb.ListInviteTokensResponse{
+			Status: status.NewInternal(ctx, err, "error listing invite tokens"),
+		}, nil
+	}
+
+	return &invitelifecyclepb.ListInviteTokensResponse{
+		Status:       status.NewOK(ctx),
+		InviteTokens: tokens,
+	}, nil
+}
+
+func (s *service) RevokeInviteToken(ctx context.Context, req *invitelifecyclepb.RevokeInviteTokenRequest) (*invitelifecyclepb.RevokeInviteTokenResponse, error) {
+	if err := s.im.RevokeInviteToken(ctx, req.Token); err != nil {
+		if _, ok := err.(errtypes.IsNotFound); ok {
+			return &invitelifecyclepb.RevokeInviteTokenResponse{
+				Status: status.NewNotFound(ctx, "invite token not found"),
+			}, nil
+		}
+		return &invitelifecyclepb.RevokeInviteTokenResponse{
+			Status: status.NewInternal(ctx, err, "error revoking invite token"),
+		}, nil
+	}
+
+	return &invitelifecyclepb.RevokeInviteTokenResponse{
+		Status: status.NewOK(ctx),
+	}, nil
+}
+
+func (s *service) DeleteExpiredInviteTokens(ctx context.Context, req *invitelifecyclepb.DeleteExpiredInviteTokensRequest) (*invitelifecyclepb.DeleteExpiredInviteTokensResponse, error) {
+	deleted, err := s.im.DeleteExpiredInviteTokens(ctx)
+	if err != nil {
+		return &invitelifecyclepb.DeleteExpiredInviteTokensResponse{
+			Status: status.NewInternal(ctx, err, "error deleting expired invite tokens"),
+		}, nil
+	}
+
+	return &invitelifecyclepb.DeleteExpiredInviteTokensResponse{
+		Status:  status.NewOK(ctx),
+		Deleted: int64(deleted),
+	}, nil
+}
+
+func (s *service) DeleteAcceptedUser(ctx context.Context, req *invitelifecyclepb.DeleteAcceptedUserRequest) (*invitelifecyclepb.DeleteAcceptedUserResponse, error) {
+	if req.RemoteUserId == nil {
+		return &invitelifecyclepb.DeleteAcceptedUserResponse{
+			Status: status.NewInvalidArg(ctx, "remote user id not provided"),
+		}, nil
+	}
+
+	if err := s.im.DeleteAcceptedUser(ctx, req.RemoteUserId); err != nil {
+		if _, ok := err.(errtypes.IsNotFound); ok {
+			return &invitelifecyclepb.DeleteAcceptedUserResponse{
+				Status: status.NewNotFound(ctx, "accepted user not found"),
+			}, nil
+		}
+		return &invitelifecyclepb.DeleteAcceptedUserResponse{
+			Status: status.NewInternal(ctx, err, "error deleting accepted user"),
+		}, nil
+	}
+
+	return &invitelifecyclepb.DeleteAcceptedUserResponse{
+		Status: status.NewOK(ctx),
+	}, nil
+}
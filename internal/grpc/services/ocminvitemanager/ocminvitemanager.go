@@ -22,11 +22,15 @@ import (
 	"context"
 
 	invitepb "github.com/cs3org/go-cs3apis/cs3/ocm/invite/v1beta1"
+	ctxpkg "github.com/cs3org/reva/pkg/ctx"
 	"github.com/cs3org/reva/pkg/errtypes"
 	"github.com/cs3org/reva/pkg/ocm/invite"
 	"github.com/cs3org/reva/pkg/ocm/invite/manager/registry"
+	invitelifecyclepb "github.com/cs3org/reva/pkg/ocm/invite/proto"
+	"github.com/cs3org/reva/pkg/ocm/signature"
 	"github.com/cs3org/reva/pkg/rgrpc"
 	"github.com/cs3org/reva/pkg/rgrpc/status"
+	"github.com/cs3org/reva/pkg/smtpclient"
 	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
 	"google.golang.org/grpc"
@@ -39,11 +43,19 @@ func init() {
 type config struct {
 	Driver  string                            `mapstructure:"driver"`
 	Drivers map[string]map[string]interface{} `mapstructure:"drivers"`
+	// SigningKey is the private key in PEM format used to sign the invites forwarded to the remote providers.
+	SigningKey   string `mapstructure:"signing_key"`
+	SigningKeyID string `mapstructure:"signing_key_id" docs:";The URL of the OCM discovery document publishing the public key, e.g. https://cloud.example.org/ocm/ocm-provider#signature."`
+	// SMTPCredentials are used to deliver the invite tokens by mail to the recipients given on generation.
+	SMTPCredentials  *smtpclient.SMTPCredentials `mapstructure:"smtp_credentials"`
+	MeshDirectoryURL string                      `mapstructure:"mesh_directory_url"`
 }
 
 type service struct {
-	conf *config
-	im   invite.Manager
+	conf            *config
+	im              invite.Manager
+	signer          *signature.Signer
+	smtpCredentials *smtpclient.SMTPCredentials
 }
 
 func (c *config) init() {
@@ -54,6 +66,7 @@ func (c *config) init() {
 
 func (s *service) Register(ss *grpc.Server) {
 	invitepb.RegisterInviteAPIServer(ss, s)
+	invitelifecyclepb.RegisterInviteLifecycleServiceServer(ss, s)
 }
 
 func getInviteManager(c *config) (invite.Manager, error) {
@@ -89,6 +102,15 @@ func New(m map[string]interface{}, ss *grpc.Server) (rgrpc.Service, error) {
 		conf: c,
 		im:   im,
 	}
+	if c.SigningKey != "" {
+		service.signer, err = signature.NewSigner(c.SigningKeyID, c.SigningKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "ocminvitemanager: invalid signing_key or signing_key_id")
+		}
+	}
+	if c.SMTPCredentials != nil {
+		service.smtpCredentials = smtpclient.NewSMTPCredentials(c.SMTPCredentials)
+	}
 	return service, nil
 }
 
@@ -101,6 +123,17 @@ func (s *service) UnprotectedEndpoints() []string {
 }
 
 func (s *service) GenerateInviteToken(ctx context.Context, req *invitepb.GenerateInviteTokenRequest) (*invitepb.GenerateInviteTokenResponse, error) {
+	// the token is delivered by mail when a recipient is given
+	var recipient string
+	if e, ok := req.Opaque.GetMap()["recipient"]; ok && e.Decoder == "plain" {
+		recipient = string(e.Value)
+	}
+	if recipient != "" && s.smtpCredentials == nil {
+		return &invitepb.GenerateInviteTokenResponse{
+			Status: status.NewUnimplemented(ctx, nil, "delivering invite tokens by mail is not configured"),
+		}, nil
+	}
+
 	token, err := s.im.GenerateToken(ctx)
 	if err != nil {
 		return &invitepb.GenerateInviteTokenResponse{
@@ -108,6 +141,15 @@ func (s *service) GenerateInviteToken(ctx context.Context, req *invitepb.Generat
 		}, nil
 	}
 
+	if recipient != "" {
+		subject, body := invite.Mail(ctxpkg.ContextMustGetUser(ctx), token, s.conf.MeshDirectoryURL)
+		if err := s.smtpCredentials.SendMail(recipient, subject, body); err != nil {
+			return &invitepb.GenerateInviteTokenResponse{
+				Status: status.NewInternal(ctx, err, "error sending invite token by mail"),
+			}, nil
+		}
+	}
+
 	return &invitepb.GenerateInviteTokenResponse{
 		Status:      status.NewOK(ctx),
 		InviteToken: token,
@@ -115,7 +157,7 @@ func (s *service) GenerateInviteToken(ctx context.Context, req *invitepb.Generat
 }
 
 func (s *service) ForwardInvite(ctx context.Context, req *invitepb.ForwardInviteRequest) (*invitepb.ForwardInviteResponse, error) {
-	err := s.im.ForwardInvite(ctx, req.InviteToken, req.OriginSystemProvider)
+	err := s.im.ForwardInvite(signature.ContextSetSigner(ctx, s.signer), req.InviteToken, req.OriginSystemProvider)
 	if err != nil {
 		return &invitepb.ForwardInviteResponse{
 			Status: status.NewInternal(ctx, err, "error forwarding invite"),
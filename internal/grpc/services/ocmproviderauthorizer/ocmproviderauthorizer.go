@@ -20,6 +20,7 @@ package ocmproviderauthorizer
 
 import (
 	"context"
+	"io"
 
 	ocmprovider "github.com/cs3org/go-cs3apis/cs3/ocm/provider/v1beta1"
 	"github.com/cs3org/reva/pkg/errtypes"
@@ -93,6 +94,10 @@ func New(m map[string]interface{}, ss *grpc.Server) (rgrpc.Service, error) {
 }
 
 func (s *service) Close() error {
+	// the authorizers syncing the providers in the background stop doing so
+	if c, ok := s.pa.(io.Closer); ok {
+		return c.Close()
+	}
 	return nil
 }
 
@@ -20,13 +20,27 @@ package ocmshareprovider
 
 import (
 	"context"
+	"strconv"
+	"strings"
+	"text/template"
 
+	ocmprovider "github.com/cs3org/go-cs3apis/cs3/ocm/provider/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
 	ocm "github.com/cs3org/go-cs3apis/cs3/sharing/ocm/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	typespb "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/conversions"
+	"github.com/cs3org/reva/pkg/appctx"
+	ctxpkg "github.com/cs3org/reva/pkg/ctx"
 	"github.com/cs3org/reva/pkg/errtypes"
 	"github.com/cs3org/reva/pkg/ocm/share"
 	"github.com/cs3org/reva/pkg/ocm/share/manager/registry"
+	"github.com/cs3org/reva/pkg/ocm/share/sender"
+	"github.com/cs3org/reva/pkg/ocm/signature"
 	"github.com/cs3org/reva/pkg/rgrpc"
 	"github.com/cs3org/reva/pkg/rgrpc/status"
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"github.com/cs3org/reva/pkg/sharedconf"
 	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
 	"google.golang.org/grpc"
@@ -37,19 +51,31 @@ func init() {
 }
 
 type config struct {
-	Driver  string                            `mapstructure:"driver"`
-	Drivers map[string]map[string]interface{} `mapstructure:"drivers"`
+	Driver     string                            `mapstructure:"driver"`
+	Drivers    map[string]map[string]interface{} `mapstructure:"drivers"`
+	GatewaySvc string                            `mapstructure:"gatewaysvc"`
+	// SigningKey is the private key in PEM format used to sign the requests sent to the remote providers.
+	SigningKey   string `mapstructure:"signing_key"`
+	SigningKeyID string `mapstructure:"signing_key_id" docs:";The URL of the OCM discovery document publishing the public key, e.g. https://cloud.example.org/ocm/ocm-provider#signature."`
+	// WebAppTemplate is the template of the URI opening a shared resource in the web editor,
+	// rendered with the share name and resource id. Shares are offered with the webapp protocol only when set.
+	WebAppTemplate string `mapstructure:"webapp_template" docs:";The template of the URI opening a shared resource in the web editor, e.g. https://cloud.example.org/external/sciencemesh/{{.ResourceID.OpaqueId}}."`
+	// NotificationsUsers are the users allowed to apply the notifications of the remote providers.
+	NotificationsUsers []string `mapstructure:"notifications_users" docs:";The usernames allowed to apply the notifications of the remote providers, i.e. the service account of ocmd."`
 }
 
 type service struct {
-	conf *config
-	sm   share.Manager
+	conf   *config
+	sm     share.Manager
+	signer *signature.Signer
+	webapp *template.Template
 }
 
 func (c *config) init() {
 	if c.Driver == "" {
 		c.Driver = "json"
 	}
+	c.GatewaySvc = sharedconf.GetGatewaySVC(c.GatewaySvc)
 }
 
 func (s *service) Register(ss *grpc.Server) {
@@ -89,6 +115,19 @@ func New(m map[string]interface{}, ss *grpc.Server) (rgrpc.Service, error) {
 		conf: c,
 		sm:   sm,
 	}
+	if c.SigningKey != "" {
+		service.signer, err = signature.NewSigner(c.SigningKeyID, c.SigningKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "ocmshareprovider: invalid signing_key or signing_key_id")
+		}
+	}
+
+	if c.WebAppTemplate != "" {
+		service.webapp, err = template.New("webapp").Parse(c.WebAppTemplate)
+		if err != nil {
+			return nil, errors.Wrap(err, "error parsing webapp template")
+		}
+	}
 
 	return service, nil
 }
@@ -117,24 +156,7 @@ func (s *service) UnprotectedEndpoints() []string {
 func (s *service) CreateOCMShare(ctx context.Context, req *ocm.CreateOCMShareRequest) (*ocm.CreateOCMShareResponse, error) {
 	if req.Opaque == nil {
 		return &ocm.CreateOCMShareResponse{
-			Status: status.NewInternal(ctx, errtypes.BadRequest("can't find resource permissions"), ""),
-		}, nil
-	}
-
-	var permissions string
-	permOpaque, ok := req.Opaque.Map["permissions"]
-	if !ok {
-		return &ocm.CreateOCMShareResponse{
-			Status: status.NewInternal(ctx, errtypes.BadRequest("resource permissions not set"), ""),
-		}, nil
-	}
-	switch permOpaque.Decoder {
-	case "plain":
-		permissions = string(permOpaque.Value)
-	default:
-		err := errtypes.NotSupported("opaque entry decoder not recognized: " + permOpaque.Decoder)
-		return &ocm.CreateOCMShareResponse{
-			Status: status.NewInternal(ctx, err, "invalid opaque entry decoder"),
+			Status: status.NewInternal(ctx, errtypes.BadRequest("can't find resource name"), ""),
 		}, nil
 	}
 
@@ -155,15 +177,13 @@ func (s *service) CreateOCMShare(ctx context.Context, req *ocm.CreateOCMShareReq
 		}, nil
 	}
 
-	// discover share type
-	sharetype := ocm.Share_SHARE_TYPE_REGULAR
+	// the protocols the share is offered with, webdav by default
+	names := []string{share.WebDAVProtocol}
 	protocol, ok := req.Opaque.Map["protocol"]
 	if ok {
 		switch protocol.Decoder {
 		case "plain":
-			if string(protocol.Value) == "datatx" {
-				sharetype = ocm.Share_SHARE_TYPE_TRANSFER
-			}
+			names = strings.Split(string(protocol.Value), ",")
 		default:
 			err := errors.New("protocol decoder not recognized")
 			return &ocm.CreateOCMShareResponse{
@@ -172,9 +192,35 @@ func (s *service) CreateOCMShare(ctx context.Context, req *ocm.CreateOCMShareReq
 		}
 	}
 
+	// the OCS permissions sent by the clients take precedence over the ones of the grant
+	perms := req.Grant.GetPermissions().GetPermissions()
+	if permOpaque, ok := req.Opaque.Map["permissions"]; ok {
+		var err error
+		perms, err = getPermissions(permOpaque)
+		if err != nil {
+			return &ocm.CreateOCMShareResponse{
+				Status: status.NewInvalidArg(ctx, err.Error()),
+			}, nil
+		}
+	}
+
+	protocols, err := s.getProtocols(names, name, req.ResourceId, perms)
+	if err != nil {
+		return &ocm.CreateOCMShareResponse{
+			Status: status.NewInvalidArg(ctx, err.Error()),
+		}, nil
+	}
+
 	var sharedSecret string
-	share, err := s.sm.Share(ctx, req.ResourceId, req.Grant, name, req.RecipientMeshProvider, permissions, nil, sharedSecret, sharetype)
+	share, err := s.sm.Share(signature.ContextSetSigner(ctx, s.signer), req.ResourceId, req.Grant, name, req.RecipientMeshProvider, protocols, nil, sharedSecret, protocols.ShareType())
+
+	if err != nil {
+		return &ocm.CreateOCMShareResponse{
+			Status: status.NewInternal(ctx, err, "error creating share"),
+		}, nil
+	}
 
+	share, err = removeSecrets(share)
 	if err != nil {
 		return &ocm.CreateOCMShareResponse{
 			Status: status.NewInternal(ctx, err, "error creating share"),
@@ -188,14 +234,87 @@ func (s *service) CreateOCMShare(ctx context.Context, req *ocm.CreateOCMShareReq
 	return res, nil
 }
 
+// getPermissions returns the resource permissions matching the OCS permissions
+// of the "permissions" opaque entry.
+func getPermissions(e *typespb.OpaqueEntry) (*provider.ResourcePermissions, error) {
+	if e.Decoder != "plain" {
+		return nil, errtypes.NotSupported("opaque entry decoder not recognized: " + e.Decoder)
+	}
+	val, err := strconv.Atoi(string(e.Value))
+	if err != nil {
+		return nil, errtypes.BadRequest("invalid permissions " + string(e.Value))
+	}
+	perms, err := conversions.NewPermissions(val)
+	if err != nil {
+		return nil, errtypes.BadRequest(err.Error())
+	}
+	return conversions.RoleFromOCSPermissions(perms).CS3ResourcePermissions(), nil
+}
+
+// removeSecrets removes the secrets of the protocols of a sent share, which
+// give access to the resource and are meant for the recipient only.
+func removeSecrets(s *ocm.Share) (*ocm.Share, error) {
+	return share.RemoveSecrets(s)
+}
+
+// getProtocols returns the protocols with the given names a share is offered with.
+func (s *service) getProtocols(names []string, name string, id *provider.ResourceId, perms *provider.ResourcePermissions) (*share.Protocols, error) {
+	protocols := &share.Protocols{}
+	for _, n := range names {
+		switch strings.TrimSpace(n) {
+		case share.WebDAVProtocol:
+			protocols.WebDAV = &share.WebDAV{Permissions: share.WebDAVPermissions(perms)}
+		case share.WebAppProtocol:
+			if s.webapp == nil {
+				return nil, errtypes.NotSupported("webapp protocol not configured")
+			}
+			var uri strings.Builder
+			if err := s.webapp.Execute(&uri, struct {
+				Name       string
+				ResourceID *provider.ResourceId
+			}{name, id}); err != nil {
+				return nil, errors.Wrap(err, "error rendering webapp template")
+			}
+			viewMode := share.PermissionRead
+			if perms.GetInitiateFileUpload() {
+				viewMode = share.PermissionWrite
+			}
+			protocols.WebApp = &share.WebApp{URITemplate: uri.String(), ViewMode: viewMode}
+		case share.DataTxProtocol:
+			protocols.DataTx = &share.DataTx{}
+		default:
+			return nil, errtypes.NotSupported("protocol " + n)
+		}
+	}
+	return protocols, nil
+}
+
 func (s *service) RemoveOCMShare(ctx context.Context, req *ocm.RemoveOCMShareRequest) (*ocm.RemoveOCMShareResponse, error) {
-	err := s.sm.Unshare(ctx, req.Ref)
+	// get the share before removing it, to notify the provider of the recipient
+	sh, err := s.sm.GetShare(ctx, req.Ref)
+	if err != nil {
+		return &ocm.RemoveOCMShareResponse{
+			Status: status.NewInternal(ctx, err, "error getting share"),
+		}, nil
+	}
+
+	err = s.sm.Unshare(ctx, req.Ref)
 	if err != nil {
 		return &ocm.RemoveOCMShareResponse{
 			Status: status.NewInternal(ctx, err, "error removing share"),
 		}, nil
 	}
 
+	s.notify(ctx, sh.Grantee.GetUserId().GetIdp(), &share.Notification{
+		Type:         share.NotificationShareUnshared,
+		ResourceType: "file",
+		ProviderID:   sh.Id.GetOpaqueId(),
+		MeshProvider: sh.Owner.GetIdp(),
+		Notification: &share.NotificationDetails{
+			SharedSecret: share.Secret(sh),
+		},
+	})
+
 	return &ocm.RemoveOCMShareResponse{
 		Status: status.NewOK(ctx),
 	}, nil
@@ -203,6 +322,9 @@ func (s *service) RemoveOCMShare(ctx context.Context, req *ocm.RemoveOCMShareReq
 
 func (s *service) GetOCMShare(ctx context.Context, req *ocm.GetOCMShareRequest) (*ocm.GetOCMShareResponse, error) {
 	share, err := s.sm.GetShare(ctx, req.Ref)
+	if err == nil {
+		share, err = removeSecrets(share)
+	}
 	if err != nil {
 		return &ocm.GetOCMShareResponse{
 			Status: status.NewInternal(ctx, err, "error getting share"),
@@ -217,6 +339,9 @@ func (s *service) GetOCMShare(ctx context.Context, req *ocm.GetOCMShareRequest)
 
 func (s *service) ListOCMShares(ctx context.Context, req *ocm.ListOCMSharesRequest) (*ocm.ListOCMSharesResponse, error) {
 	shares, err := s.sm.ListShares(ctx, req.Filters) // TODO(labkode): add filter to share manager
+	for i := 0; err == nil && i < len(shares); i++ {
+		shares[i], err = removeSecrets(shares[i])
+	}
 	if err != nil {
 		return &ocm.ListOCMSharesResponse{
 			Status: status.NewInternal(ctx, err, "error listing shares"),
@@ -231,7 +356,19 @@ func (s *service) ListOCMShares(ctx context.Context, req *ocm.ListOCMSharesReque
 }
 
 func (s *service) UpdateOCMShare(ctx context.Context, req *ocm.UpdateOCMShareRequest) (*ocm.UpdateOCMShareResponse, error) {
-	_, err := s.sm.UpdateShare(ctx, req.Ref, req.Field.GetPermissions()) // TODO(labkode): check what to update
+	n, err := share.NotificationFromOpaque(req.Opaque)
+	if err != nil {
+		return &ocm.UpdateOCMShareResponse{
+			Status: status.NewInvalidArg(ctx, err.Error()),
+		}, nil
+	}
+	if n != nil {
+		return &ocm.UpdateOCMShareResponse{
+			Status: s.handleNotification(ctx, n),
+		}, nil
+	}
+
+	_, err = s.sm.UpdateShare(ctx, req.Ref, req.Field.GetPermissions()) // TODO(labkode): check what to update
 	if err != nil {
 		return &ocm.UpdateOCMShareResponse{
 			Status: status.NewInternal(ctx, err, "error updating share"),
@@ -260,13 +397,24 @@ func (s *service) ListReceivedOCMShares(ctx context.Context, req *ocm.ListReceiv
 }
 
 func (s *service) UpdateReceivedOCMShare(ctx context.Context, req *ocm.UpdateReceivedOCMShareRequest) (*ocm.UpdateReceivedOCMShareResponse, error) {
-	_, err := s.sm.UpdateReceivedShare(ctx, req.Share, req.UpdateMask) // TODO(labkode): check what to update
+	prev, err := s.sm.GetReceivedShare(ctx, &ocm.ShareReference{Spec: &ocm.ShareReference_Id{Id: req.Share.GetShare().GetId()}})
+	if err != nil {
+		return &ocm.UpdateReceivedOCMShareResponse{
+			Status: status.NewInternal(ctx, err, "error getting received share"),
+		}, nil
+	}
+
+	rs, err := s.sm.UpdateReceivedShare(ctx, req.Share, req.UpdateMask) // TODO(labkode): check what to update
 	if err != nil {
 		return &ocm.UpdateReceivedOCMShareResponse{
 			Status: status.NewInternal(ctx, err, "error updating received share"),
 		}, nil
 	}
 
+	if rs.State != prev.State {
+		s.notifyStateChange(ctx, rs)
+	}
+
 	res := &ocm.UpdateReceivedOCMShareResponse{
 		Status: status.NewOK(ctx),
 	}
@@ -287,3 +435,94 @@ func (s *service) GetReceivedOCMShare(ctx context.Context, req *ocm.GetReceivedO
 	}
 	return res, nil
 }
+
+// handleNotification applies a notification received from a remote provider.
+// Only the users forwarding the notifications, i.e. the service account of ocmd, can do so.
+func (s *service) handleNotification(ctx context.Context, n *share.Notification) *rpc.Status {
+	if u, ok := ctxpkg.ContextGetUser(ctx); !ok || !contains(s.conf.NotificationsUsers, u.Username) {
+		return status.NewPermissionDenied(ctx, nil, "the user is not allowed to apply notifications")
+	}
+
+	nm, ok := s.sm.(share.NotificationManager)
+	if !ok {
+		return status.NewUnimplemented(ctx, errtypes.NotSupported(s.conf.Driver), "the share manager does not support notifications")
+	}
+
+	err := nm.HandleNotification(ctx, n)
+	switch err.(type) {
+	case nil:
+		return status.NewOK(ctx)
+	case errtypes.IsNotFound:
+		return status.NewNotFound(ctx, "share not found")
+	case errtypes.IsPermissionDenied:
+		return status.NewPermissionDenied(ctx, err, "the share does not belong to the provider")
+	case errtypes.IsNotSupported:
+		return status.NewUnimplemented(ctx, err, err.Error())
+	case errtypes.IsBadRequest:
+		return status.NewInvalidArg(ctx, err.Error())
+	default:
+		return status.NewInternal(ctx, err, "error applying the notification")
+	}
+}
+
+// notifyStateChange informs the provider of the owner that a received share
+// has been accepted or declined.
+func (s *service) notifyStateChange(ctx context.Context, rs *ocm.ReceivedShare) {
+	var typ string
+	switch rs.State {
+	case ocm.ShareState_SHARE_STATE_ACCEPTED:
+		typ = share.NotificationShareAccepted
+	case ocm.ShareState_SHARE_STATE_REJECTED:
+		typ = share.NotificationShareDeclined
+	default:
+		return
+	}
+
+	remoteID := share.RemoteShareID(rs.Share)
+	if remoteID == "" {
+		return
+	}
+	s.notify(ctx, rs.Share.Owner.GetIdp(), &share.Notification{
+		Type:         typ,
+		ResourceType: "file",
+		ProviderID:   remoteID,
+		MeshProvider: rs.Share.Grantee.GetUserId().GetIdp(),
+		Notification: &share.NotificationDetails{
+			SharedSecret: share.Secret(rs.Share),
+		},
+	})
+}
+
+// notify sends a notification to the provider with the given domain.
+// Failures are only logged, as the change has already been applied locally.
+func (s *service) notify(ctx context.Context, domain string, n *share.Notification) {
+	log := appctx.GetLogger(ctx)
+
+	gatewayClient, err := pool.GetGatewayServiceClient(pool.Endpoint(s.conf.GatewaySvc))
+	if err != nil {
+		log.Err(err).Msg("ocmshareprovider: error getting gateway client")
+		return
+	}
+	res, err := gatewayClient.GetInfoByDomain(ctx, &ocmprovider.GetInfoByDomainRequest{Domain: domain})
+	if err != nil {
+		log.Err(err).Str("domain", domain).Msg("ocmshareprovider: error getting provider info")
+		return
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		log.Error().Str("domain", domain).Str("status", res.Status.Message).Msg("ocmshareprovider: error getting provider info")
+		return
+	}
+
+	if err := sender.SendNotification(signature.ContextSetSigner(ctx, s.signer), n, res.ProviderInfo); err != nil {
+		log.Err(err).Str("domain", domain).Str("type", n.Type).Msg("ocmshareprovider: error sending notification")
+	}
+}
+
+func contains(l []string, s string) bool {
+	for _, e := range l {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
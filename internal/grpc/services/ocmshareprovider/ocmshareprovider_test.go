@@ -0,0 +1,99 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ocmshareprovider
+
+import (
+	"reflect"
+	"testing"
+	"text/template"
+
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	typespb "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/cs3org/reva/pkg/ocm/share"
+)
+
+func TestGetPermissions(t *testing.T) {
+	tests := []struct {
+		name    string
+		entry   *typespb.OpaqueEntry
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:  "viewer",
+			entry: &typespb.OpaqueEntry{Decoder: "plain", Value: []byte("1")},
+			want:  []string{share.PermissionRead},
+		},
+		{
+			name:  "editor",
+			entry: &typespb.OpaqueEntry{Decoder: "plain", Value: []byte("15")},
+			want:  []string{share.PermissionRead, share.PermissionWrite},
+		},
+		{
+			name:    "not a number",
+			entry:   &typespb.OpaqueEntry{Decoder: "plain", Value: []byte("editor")},
+			wantErr: true,
+		},
+		{
+			name:    "out of range",
+			entry:   &typespb.OpaqueEntry{Decoder: "plain", Value: []byte("128")},
+			wantErr: true,
+		},
+		{
+			name:    "unknown decoder",
+			entry:   &typespb.OpaqueEntry{Decoder: "json", Value: []byte("1")},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			perms, err := getPermissions(tt.entry)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getPermissions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(share.WebDAVPermissions(perms), tt.want) {
+				t.Fatalf("getPermissions() = %v, want %v", share.WebDAVPermissions(perms), tt.want)
+			}
+		})
+	}
+}
+
+func TestGetProtocols(t *testing.T) {
+	s := &service{webapp: template.Must(template.New("webapp").Parse("https://cloud.example.org/open/{{.ResourceID.OpaqueId}}"))}
+	id := &provider.ResourceId{StorageId: "home", OpaqueId: "1"}
+	perms := &provider.ResourcePermissions{Stat: true, InitiateFileDownload: true, InitiateFileUpload: true}
+
+	p, err := s.getProtocols([]string{share.WebDAVProtocol, " webapp"}, "/home/file", id, perms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(p.WebDAV.Permissions, []string{share.PermissionRead, share.PermissionWrite}) {
+		t.Fatalf("unexpected webdav permissions %v", p.WebDAV.Permissions)
+	}
+	if p.WebApp.URITemplate != "https://cloud.example.org/open/1" || p.WebApp.ViewMode != share.PermissionWrite {
+		t.Fatalf("unexpected webapp protocol %+v", p.WebApp)
+	}
+
+	if _, err := (&service{}).getProtocols([]string{share.WebAppProtocol}, "/home/file", id, perms); err == nil {
+		t.Fatal("expected the webapp protocol to be refused when not configured")
+	}
+	if _, err := s.getProtocols([]string{"ftp"}, "/home/file", id, perms); err == nil {
+		t.Fatal("expected an unknown protocol to be refused")
+	}
+}
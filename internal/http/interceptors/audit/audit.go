@@ -0,0 +1,208 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package audit
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cs3org/reva/pkg/audit"
+	"github.com/cs3org/reva/pkg/audit/sink/registry"
+	ctxpkg "github.com/cs3org/reva/pkg/ctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/rhttp/global"
+	"github.com/cs3org/reva/pkg/utils"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultPriority = 300
+)
+
+func init() {
+	global.RegisterMiddleware("audit", New)
+}
+
+type config struct {
+	Sinks    []string                          `mapstructure:"sinks"`
+	Drivers  map[string]map[string]interface{} `mapstructure:"drivers"`
+	Priority int                               `mapstructure:"priority"`
+	// Prefixes restricts auditing to the requests whose path starts with
+	// one of them, i.e. the ocdav and ocs prefixes, as any other GET would
+	// be recorded as a download. Defaults to the ocdav and ocs routes.
+	Prefixes []string `mapstructure:"prefixes"`
+	// PublicPrefixes are the path prefixes serving public links.
+	PublicPrefixes []string `mapstructure:"public_prefixes"`
+}
+
+func (c *config) init() {
+	if len(c.Sinks) == 0 {
+		c.Sinks = []string{"file"}
+	}
+	if c.Priority == 0 {
+		c.Priority = defaultPriority
+	}
+	if len(c.Prefixes) == 0 {
+		c.Prefixes = []string{"/remote.php/", "/webdav/", "/dav/", "/ocs/", "/public-files/", "/s/"}
+	}
+	if len(c.PublicPrefixes) == 0 {
+		c.PublicPrefixes = []string{"/public-files/", "/dav/public-files/", "/remote.php/dav/public-files/", "/s/"}
+	}
+}
+
+func getLogger(c *config) (*audit.Logger, error) {
+	sinks := make([]audit.Sink, 0, len(c.Sinks))
+	for _, name := range c.Sinks {
+		f, ok := registry.NewFuncs[name]
+		if !ok {
+			return nil, errtypes.NotFound("driver not found: " + name)
+		}
+		s, err := f(c.Drivers[name])
+		if err != nil {
+			return nil, errors.Wrapf(err, "audit: error creating sink %s", name)
+		}
+		sinks = append(sinks, s)
+	}
+	return audit.NewLogger(sinks...), nil
+}
+
+// New returns a new HTTP middleware that writes an audit record
+// for security relevant WebDAV and OCS requests.
+func New(m map[string]interface{}) (global.Middleware, int, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, 0, errors.Wrap(err, "audit: error decoding conf")
+	}
+	c.init()
+
+	logger, err := getLogger(c)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	mw := func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !utils.Skip(r.URL.Path, c.Prefixes) {
+				h.ServeHTTP(w, r)
+				return
+			}
+			action := classify(r, c.PublicPrefixes)
+			if action == "" {
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+			h.ServeHTTP(sw, r)
+
+			ctx := r.Context()
+			rec := &audit.Record{
+				Action:   action,
+				Protocol: "http",
+				Method:   r.Method,
+				Resource: redactPublicPath(r.URL.Path, c.PublicPrefixes),
+				Result:   strconv.Itoa(sw.status),
+			}
+			if u, ok := ctxpkg.ContextGetUser(ctx); ok {
+				rec.UserIdp, rec.UserOpaqueID, rec.Username = u.GetId().GetIdp(), u.GetId().GetOpaqueId(), u.GetUsername()
+			}
+			if ip, err := utils.GetClientIP(r); err == nil {
+				rec.ClientIP = ip
+			}
+			logger.Log(ctx, rec)
+		})
+	}
+	return mw, c.Priority, nil
+}
+
+// classify returns the audit action for a request,
+// or an empty one if the request is not security relevant.
+func classify(r *http.Request, publicPrefixes []string) audit.Action {
+	p := r.URL.Path
+	if strings.Contains(p, "/ocs/") && strings.Contains(p, "/apps/files_sharing/api/v1/shares") {
+		switch r.Method {
+		case http.MethodPost:
+			return audit.ActionShareCreate
+		case http.MethodPut:
+			return audit.ActionShareUpdate
+		case http.MethodDelete:
+			return audit.ActionShareRemove
+		}
+		return ""
+	}
+	if utils.Skip(p, publicPrefixes) {
+		return audit.ActionPublicLinkAccess
+	}
+
+	switch r.Method {
+	case http.MethodDelete:
+		return audit.ActionDelete
+	case http.MethodGet:
+		return audit.ActionDownload
+	case http.MethodPut:
+		return audit.ActionUpload
+	case "MOVE":
+		return audit.ActionMove
+	}
+	return ""
+}
+
+// redactPublicPath replaces the token in the path of a public link with its hash,
+// as the token grants access to the link.
+func redactPublicPath(p string, publicPrefixes []string) string {
+	for _, prefix := range publicPrefixes {
+		if !strings.HasPrefix(p, prefix) {
+			continue
+		}
+		token, rest := p[len(prefix):], ""
+		if i := strings.Index(token, "/"); i >= 0 {
+			token, rest = token[:i], token[i:]
+		}
+		return prefix + audit.HashToken(token) + rest
+	}
+	return p
+}
+
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status = code
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements the http.Flusher interface, used when streaming downloads.
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
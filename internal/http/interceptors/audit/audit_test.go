@@ -0,0 +1,69 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package audit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/cs3org/reva/pkg/audit"
+	_ "github.com/cs3org/reva/pkg/audit/sink/file"
+)
+
+func TestRedactPublicPath(t *testing.T) {
+	c := &config{}
+	c.init()
+
+	tests := map[string]string{
+		"/s/token":                                "/s/" + audit.HashToken("token"),
+		"/public-files/token/some/file.txt":       "/public-files/" + audit.HashToken("token") + "/some/file.txt",
+		"/remote.php/dav/files/einstein/file.txt": "/remote.php/dav/files/einstein/file.txt",
+	}
+	for p, expected := range tests {
+		if got := redactPublicPath(p, c.PublicPrefixes); got != expected {
+			t.Errorf("expected %s to be redacted to %s, got %s", p, expected, got)
+		}
+	}
+}
+
+func TestPrefixes(t *testing.T) {
+	path := t.TempDir() + "/audit.log"
+	mw, _, err := New(map[string]interface{}{
+		"drivers": map[string]map[string]interface{}{"file": {"file": path}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, p := range []string{"/index.html", "/app/open", "/remote.php/webdav/file.txt"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, p, nil))
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "\n"); n != 1 {
+		t.Fatalf("expected only the webdav download to be recorded, got %d records", n)
+	}
+}
@@ -21,6 +21,7 @@ package auth
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"strings"
 	"time"
@@ -29,11 +30,13 @@ import (
 	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
 	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
 	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
 	"github.com/cs3org/reva/internal/http/interceptors/auth/credential/registry"
 	tokenregistry "github.com/cs3org/reva/internal/http/interceptors/auth/token/registry"
 	tokenwriterregistry "github.com/cs3org/reva/internal/http/interceptors/auth/tokenwriter/registry"
 	"github.com/cs3org/reva/pkg/appctx"
 	"github.com/cs3org/reva/pkg/auth"
+	"github.com/cs3org/reva/pkg/auth/mfa"
 	"github.com/cs3org/reva/pkg/auth/scope"
 	ctxpkg "github.com/cs3org/reva/pkg/ctx"
 	"github.com/cs3org/reva/pkg/errtypes"
@@ -66,6 +69,10 @@ type config struct {
 	TokenManagers          map[string]map[string]interface{} `mapstructure:"token_managers"`
 	TokenWriter            string                            `mapstructure:"token_writer"`
 	TokenWriters           map[string]map[string]interface{} `mapstructure:"token_writers"`
+	// TrustedProxies are the addresses and CIDR ranges of the reverse proxies
+	// allowed to set the X-Forwarded-For header.
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+	trustedProxies []*net.IPNet
 }
 
 func parseConfig(m map[string]interface{}) (*config, error) {
@@ -107,6 +114,10 @@ func New(m map[string]interface{}, unprotected []string) (global.Middleware, err
 		conf.CredentialsByUserAgent = map[string]string{}
 	}
 
+	if conf.trustedProxies, err = utils.ParseTrustedProxies(conf.TrustedProxies); err != nil {
+		return nil, err
+	}
+
 	userGroupsCache = gcache.New(1000000).LFU().Build()
 
 	credChain := map[string]auth.CredentialStrategy{}
@@ -194,6 +205,9 @@ func authenticateUser(w http.ResponseWriter, r *http.Request, conf *config, toke
 	// Add the request user-agent to the ctx
 	ctx = metadata.NewIncomingContext(ctx, metadata.New(map[string]string{ctxpkg.UserAgentHeader: r.UserAgent()}))
 
+	// Forward the client ip, e.g. to track where app passwords are used from
+	ctx = metadata.AppendToOutgoingContext(ctx, ctxpkg.ClientIPHeader, utils.GetTrustedClientIP(r, conf.trustedProxies))
+
 	client, err := pool.GetGatewayServiceClient(pool.Endpoint(conf.GatewaySvc))
 	if err != nil {
 		logError(isUnprotectedEndpoint, log, err, "error getting the authsvc client", http.StatusUnauthorized, w)
@@ -253,6 +267,16 @@ func authenticateUser(w http.ResponseWriter, r *http.Request, conf *config, toke
 		ClientId:     creds.ClientID,
 		ClientSecret: creds.ClientSecret,
 	}
+	if creds.SecondFactor != "" {
+		req.Opaque = &types.Opaque{
+			Map: map[string]*types.OpaqueEntry{
+				mfa.OpaqueKey: {
+					Decoder: "plain",
+					Value:   []byte(creds.SecondFactor),
+				},
+			},
+		}
+	}
 
 	log.Debug().Msgf("AuthenticateRequest: type: %s, client_id: %s against %s", req.Type, req.ClientId, conf.GatewaySvc)
 
@@ -263,6 +287,10 @@ func authenticateUser(w http.ResponseWriter, r *http.Request, conf *config, toke
 	}
 
 	if res.Status.Code != rpc.Code_CODE_OK {
+		// the second factor requires the client to answer a challenge first
+		if challenge, ok := res.Opaque.GetMap()[mfa.ChallengeOpaqueKey]; ok && !isUnprotectedEndpoint {
+			w.Header().Set(mfa.ChallengeHeader, string(challenge.Value))
+		}
 		err := status.NewErrorFromCode(res.Status.Code, "auth")
 		logError(isUnprotectedEndpoint, log, err, "error generating access token from credentials", http.StatusUnauthorized, w)
 		return nil, err
@@ -0,0 +1,118 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ratelimit
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	ctxpkg "github.com/cs3org/reva/pkg/ctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/ratelimit"
+	"github.com/cs3org/reva/pkg/ratelimit/registry"
+	"github.com/cs3org/reva/pkg/rhttp/global"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+const (
+	// run inside the cors middleware, so that rejected
+	// responses still carry the CORS headers.
+	defaultPriority = 300
+)
+
+func init() {
+	global.RegisterMiddleware("ratelimit", New)
+}
+
+type config struct {
+	ratelimit.Config `mapstructure:",squash"`
+	Priority         int `mapstructure:"priority"`
+	// TrustForwardedFor makes the middleware take the client address
+	// from the X-Forwarded-For header, set by a reverse proxy.
+	TrustForwardedFor bool `mapstructure:"trust_forwarded_for"`
+}
+
+func (c *config) init() {
+	if c.Driver == "" {
+		c.Driver = "memory"
+	}
+	if c.Priority == 0 {
+		c.Priority = defaultPriority
+	}
+}
+
+func getLimiter(c *config) (ratelimit.Limiter, error) {
+	if f, ok := registry.NewFuncs[c.Driver]; ok {
+		return f(c.Drivers[c.Driver])
+	}
+	return nil, errtypes.NotFound("driver not found: " + c.Driver)
+}
+
+// New returns a new HTTP middleware that rejects requests
+// exceeding the configured rate limits with a 429 status code.
+func New(m map[string]interface{}) (global.Middleware, int, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, 0, errors.Wrap(err, "ratelimit: error decoding conf")
+	}
+	c.init()
+
+	limiter, err := getLimiter(c)
+	if err != nil {
+		return nil, 0, err
+	}
+	policy := ratelimit.NewPolicy(&c.Config, limiter)
+
+	mw := func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			req := &ratelimit.Request{
+				Method: r.URL.Path,
+				IP:     clientIP(r, c.TrustForwardedFor),
+			}
+			if u, ok := ctxpkg.ContextGetUser(ctx); ok && u.Id != nil {
+				req.User = u.Id.OpaqueId
+			}
+
+			if ok, wait := policy.Allow(ctx, req); !ok {
+				w.Header().Set("Retry-After", strconv.Itoa(ratelimit.RetryAfterSeconds(wait)))
+				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+			h.ServeHTTP(w, r)
+		})
+	}
+	return mw, c.Priority, nil
+}
+
+func clientIP(r *http.Request, trustForwardedFor bool) string {
+	if trustForwardedFor {
+		if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+			return strings.TrimSpace(strings.Split(fwd, ",")[0])
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
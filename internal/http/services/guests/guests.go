@@ -0,0 +1,208 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package guests serves the pages where the guests invited through
+// a share activate their account by choosing a password.
+package guests
+
+import (
+	"html/template"
+	"net/http"
+
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/guest"
+	"github.com/cs3org/reva/pkg/guest/manager/registry"
+	"github.com/cs3org/reva/pkg/rhttp/global"
+	"github.com/go-chi/chi/v5"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+
+	// Load the guest managers.
+	_ "github.com/cs3org/reva/pkg/guest/manager/loader"
+)
+
+func init() {
+	global.Register("guests", New)
+}
+
+type config struct {
+	Prefix        string                            `mapstructure:"prefix" docs:"guests;The URL path where the service is exposed."`
+	GuestManager  string                            `mapstructure:"guest_manager" docs:"json;The driver used to store the guest accounts."`
+	GuestManagers map[string]map[string]interface{} `mapstructure:"guest_managers" docs:"url:pkg/guest/manager/json/json.go"`
+	LoginURL      string                            `mapstructure:"login_url" docs:";The URL of the login page, linked once the account is activated."`
+}
+
+func (c *config) init() {
+	if c.Prefix == "" {
+		c.Prefix = "guests"
+	}
+	if c.GuestManager == "" {
+		c.GuestManager = "json"
+	}
+}
+
+func parseConfig(m map[string]interface{}) (*config, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		err = errors.Wrap(err, "error decoding conf")
+		return nil, err
+	}
+	c.init()
+	return c, nil
+}
+
+type svc struct {
+	conf   *config
+	router *chi.Mux
+	guests guest.Manager
+}
+
+// New returns a new guests service.
+func New(m map[string]interface{}, log *zerolog.Logger) (global.Service, error) {
+	conf, err := parseConfig(m)
+	if err != nil {
+		return nil, err
+	}
+
+	f, ok := registry.NewFuncs[conf.GuestManager]
+	if !ok {
+		return nil, errtypes.NotFound("guests: guest manager not found: " + conf.GuestManager)
+	}
+	guests, err := f(conf.GuestManagers[conf.GuestManager])
+	if err != nil {
+		return nil, errors.Wrap(err, "guests: error creating guest manager")
+	}
+
+	s := &svc{
+		conf:   conf,
+		router: chi.NewRouter(),
+		guests: guests,
+	}
+	s.routerInit()
+	return s, nil
+}
+
+func (s *svc) routerInit() {
+	s.router.Get("/activate", s.handleActivateForm)
+	s.router.Post("/activate", s.handleActivate)
+}
+
+// Close performs cleanup.
+func (s *svc) Close() error {
+	return nil
+}
+
+func (s *svc) Prefix() string {
+	return s.conf.Prefix
+}
+
+func (s *svc) Unprotected() []string {
+	return []string{"/activate"}
+}
+
+func (s *svc) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.router.ServeHTTP(w, r)
+	})
+}
+
+var activateTemplate = template.Must(template.New("activate").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Activate your account</title>
+</head>
+<body>
+{{if .Mail}}
+<h1>Your account is active</h1>
+<p>You can now log in with your email address {{.Mail}} and the password you have chosen.</p>
+{{if .LoginURL}}<p><a href="{{.LoginURL}}">Log in</a></p>{{end}}
+{{else if .Token}}
+<form method="post">
+<h1>Activate your account</h1>
+{{if .Error}}<p class="error">{{.Error}}</p>{{end}}
+<p><label>Password <input type="password" name="password" autofocus required></label></p>
+<p><label>Confirm password <input type="password" name="password_confirmation" required></label></p>
+<input type="hidden" name="token" value="{{.Token}}">
+<p><button type="submit">Activate</button></p>
+</form>
+{{else}}
+<h1>Activate your account</h1>
+<p class="error">{{.Error}}</p>
+{{end}}
+</body>
+</html>
+`))
+
+func (s *svc) render(w http.ResponseWriter, r *http.Request, status int, data map[string]interface{}) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.WriteHeader(status)
+	data["LoginURL"] = s.conf.LoginURL
+	if err := activateTemplate.Execute(w, data); err != nil {
+		appctx.GetLogger(r.Context()).Error().Err(err).Msg("guests: error rendering activation page")
+	}
+}
+
+// handleActivateForm shows the form where the guest chooses a password.
+func (s *svc) handleActivateForm(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		http.Error(w, "missing activation token", http.StatusBadRequest)
+		return
+	}
+	s.render(w, r, http.StatusOK, map[string]interface{}{"Token": token})
+}
+
+// handleActivate sets the password of the guest owning the activation token.
+func (s *svc) handleActivate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := appctx.GetLogger(ctx)
+
+	token := r.FormValue("token")
+	if token == "" {
+		http.Error(w, "missing activation token", http.StatusBadRequest)
+		return
+	}
+	password := r.FormValue("password")
+	if password != r.FormValue("password_confirmation") {
+		s.render(w, r, http.StatusBadRequest, map[string]interface{}{"Token": token, "Error": "The passwords do not match."})
+		return
+	}
+
+	u, err := s.guests.Activate(ctx, token, password)
+	if err != nil {
+		switch err.(type) {
+		case errtypes.IsBadRequest:
+			s.render(w, r, http.StatusBadRequest, map[string]interface{}{"Token": token, "Error": "The password is too short."})
+		case errtypes.IsNotFound:
+			s.render(w, r, http.StatusNotFound, map[string]interface{}{"Error": "The activation link is invalid or has expired."})
+		default:
+			log.Error().Err(err).Msg("guests: error activating guest account")
+			http.Error(w, "error activating the account", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	log.Info().Str("mail", u.Mail).Msg("guests: guest account activated")
+	s.render(w, r, http.StatusOK, map[string]interface{}{"Mail": u.Mail})
+}
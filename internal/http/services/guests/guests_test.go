@@ -0,0 +1,103 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package guests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/go-chi/chi/v5"
+)
+
+type fakeGuests struct {
+	token    string
+	password string
+}
+
+func (f *fakeGuests) Invite(ctx context.Context, mail string, inviter *userpb.UserId) (*userpb.User, string, error) {
+	return nil, "", errtypes.NotSupported("invite")
+}
+
+func (f *fakeGuests) Activate(ctx context.Context, token, password string) (*userpb.User, error) {
+	if token != f.token {
+		return nil, errtypes.NotFound(token)
+	}
+	if len(password) < 8 {
+		return nil, errtypes.BadRequest("password too short")
+	}
+	f.password = password
+	return &userpb.User{Mail: "guest@example.org"}, nil
+}
+
+func (f *fakeGuests) Authenticate(ctx context.Context, mail, password string) (*userpb.User, error) {
+	return nil, errtypes.NotSupported("authenticate")
+}
+
+func newTestService(guests *fakeGuests) *svc {
+	conf := &config{}
+	conf.init()
+	s := &svc{conf: conf, router: chi.NewRouter(), guests: guests}
+	s.routerInit()
+	return s
+}
+
+func activate(s *svc, token, password, confirmation string) *httptest.ResponseRecorder {
+	form := url.Values{"token": {token}, "password": {password}, "password_confirmation": {confirmation}}
+	r := httptest.NewRequest(http.MethodPost, "/activate", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, r)
+	return w
+}
+
+func TestActivate(t *testing.T) {
+	guests := &fakeGuests{token: "secret"}
+	s := newTestService(guests)
+
+	r := httptest.NewRequest(http.MethodGet, "/activate?token=secret", nil)
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, r)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `value="secret"`) {
+		t.Fatalf("unexpected activation form: %d %s", w.Code, w.Body.String())
+	}
+
+	tests := []struct {
+		token, password, confirmation string
+		code                          int
+	}{
+		{"secret", "password1", "password2", http.StatusBadRequest},
+		{"secret", "short", "short", http.StatusBadRequest},
+		{"other", "password1", "password1", http.StatusNotFound},
+		{"secret", "password1", "password1", http.StatusOK},
+	}
+	for _, tt := range tests {
+		if w := activate(s, tt.token, tt.password, tt.confirmation); w.Code != tt.code {
+			t.Fatalf("activating with %q/%q: expected %d, got %d", tt.token, tt.password, tt.code, w.Code)
+		}
+	}
+	if guests.password != "password1" {
+		t.Fatalf("expected the password to be set, got %q", guests.password)
+	}
+}
@@ -131,10 +131,24 @@ func applyDefaultConfig(conf *config.Configuration) {
 		conf.UpdateInterval = "1h" // Update once per hour
 	}
 
+	// Health probing
+	if conf.Health.Timeout == "" {
+		conf.Health.Timeout = "10s"
+	}
+	if conf.Health.HistorySize == 0 {
+		conf.Health.HistorySize = 24
+	}
+
 	// Connectors
 	if conf.Connectors.GOCDB.Scope == "" {
 		conf.Connectors.GOCDB.Scope = "SM" // TODO(Daniel-WWU-IT): This might change in the future
 	}
+	if conf.Connectors.OCMDiscovery.Operator == "" {
+		conf.Connectors.OCMDiscovery.Operator = "OCM"
+	}
+	if conf.Connectors.OCMDiscovery.Timeout == 0 {
+		conf.Connectors.OCMDiscovery.Timeout = 10
+	}
 
 	// Exporters
 	addDefaultConnector := func(enabledList *[]string) {
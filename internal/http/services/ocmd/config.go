@@ -24,6 +24,7 @@ import (
 	"net/http"
 
 	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/ocm/signature"
 )
 
 type configData struct {
@@ -33,6 +34,8 @@ type configData struct {
 	Endpoint      string          `json:"endPoint" xml:"endPoint"`
 	Provider      string          `json:"provider" xml:"provider"`
 	ResourceTypes []resourceTypes `json:"resourceTypes" xml:"resourceTypes"`
+	// PublicKey is the key verifying the signatures of the requests sent by this provider.
+	PublicKey *signature.PublicKey `json:"publicKey,omitempty" xml:"publicKey,omitempty"`
 }
 
 type resourceTypes struct {
@@ -49,7 +52,7 @@ type configHandler struct {
 	c configData
 }
 
-func (h *configHandler) init(c *Config) {
+func (h *configHandler) init(c *Config) error {
 	h.c = c.Config
 	if h.c.APIVersion == "" {
 		h.c.APIVersion = "1.0-proposal1"
@@ -73,6 +76,22 @@ func (h *configHandler) init(c *Config) {
 			Webdav: fmt.Sprintf("/%s/ocm_webdav", h.c.Provider),
 		},
 	}}
+
+	if c.SigningKey != "" {
+		signer, err := signature.NewSigner(h.c.Endpoint+"/ocm-provider#signature", c.SigningKey)
+		if err != nil {
+			return err
+		}
+		publicKey, err := signer.PublicKeyPEM()
+		if err != nil {
+			return err
+		}
+		h.c.PublicKey = &signature.PublicKey{
+			KeyID:        signer.KeyID(),
+			PublicKeyPem: publicKey,
+		}
+	}
+	return nil
 }
 
 func (h *configHandler) Handler() http.Handler {
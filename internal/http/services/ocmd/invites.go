@@ -34,6 +34,8 @@ import (
 	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
 	"github.com/cs3org/reva/pkg/appctx"
 	ctxpkg "github.com/cs3org/reva/pkg/ctx"
+	"github.com/cs3org/reva/pkg/ocm/invite"
+	invitelifecyclepb "github.com/cs3org/reva/pkg/ocm/invite/proto"
 	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
 	"github.com/cs3org/reva/pkg/rhttp/router"
 	"github.com/cs3org/reva/pkg/smtpclient"
@@ -72,6 +74,14 @@ func (h *invitesHandler) Handler() http.Handler {
 			h.findAcceptedUsers(w, r)
 		case "generate":
 			h.generate(w, r)
+		case "list-tokens":
+			h.listInviteTokens(w, r)
+		case "revoke":
+			h.revokeInviteToken(w, r)
+		case "delete-expired":
+			h.deleteExpiredInviteTokens(w, r)
+		case "delete-accepted-user":
+			h.deleteAcceptedUser(w, r)
 		default:
 			w.WriteHeader(http.StatusNotFound)
 		}
@@ -95,17 +105,7 @@ func (h *invitesHandler) generateInviteToken(w http.ResponseWriter, r *http.Requ
 
 	if r.FormValue("recipient") != "" && h.smtpCredentials != nil {
 		usr := ctxpkg.ContextMustGetUser(ctx)
-
-		// TODO: the message body needs to point to the meshdirectory service
-		subject := fmt.Sprintf("ScienceMesh: %s wants to collaborate with you", usr.DisplayName)
-		body := "Hi,\n\n" +
-			usr.DisplayName + " (" + usr.Mail + ") wants to start sharing OCM resources with you. " +
-			"To accept the invite, please visit the following URL:\n" +
-			h.meshDirectoryURL + "?token=" + token.InviteToken.Token + "&providerDomain=" + usr.Id.Idp + "\n\n" +
-			"Alternatively, you can visit your mesh provider and use the following details:\n" +
-			"Token: " + token.InviteToken.Token + "\n" +
-			"ProviderDomain: " + usr.Id.Idp + "\n\n" +
-			"Best,\nThe ScienceMesh team"
+		subject, body := invite.Mail(usr, token.InviteToken, h.meshDirectoryURL)
 
 		err = h.smtpCredentials.SendMail(r.FormValue("recipient"), subject, body)
 		if err != nil {
@@ -263,6 +263,10 @@ func (h *invitesHandler) acceptInvite(w http.ResponseWriter, r *http.Request) {
 		WriteError(w, r, APIErrorUnauthenticated, "provider not authorized", errors.New(providerAllowedResp.Status.Message))
 		return
 	}
+	if !signedBy(r, recipientProvider) {
+		WriteError(w, r, APIErrorUnauthenticated, "request not signed by "+recipientProvider, nil)
+		return
+	}
 
 	userObj := &userpb.User{
 		Id: &userpb.UserId{
@@ -341,3 +345,114 @@ func (h *invitesHandler) generate(w http.ResponseWriter, r *http.Request) {
 		log.Err(err).Msg("Error writing to ResponseWriter")
 	}
 }
+
+func (h *invitesHandler) listInviteTokens(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	client, err := pool.GetOCMInviteLifecycleClient(pool.Endpoint(h.gatewayAddr))
+	if err != nil {
+		WriteError(w, r, APIErrorServerError, "error getting gateway grpc client", err)
+		return
+	}
+
+	response, err := client.ListInviteTokens(ctx, &invitelifecyclepb.ListInviteTokensRequest{})
+	if err != nil {
+		WriteError(w, r, APIErrorServerError, "error sending a grpc list invite tokens request", err)
+		return
+	}
+	writeLifecycleResponse(w, r, response.Status, response)
+}
+
+func (h *invitesHandler) revokeInviteToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		WriteError(w, r, APIErrorInvalidParameter, "Only POST and DELETE methods are allowed", nil)
+		return
+	}
+	token := r.FormValue("token")
+	if token == "" {
+		WriteError(w, r, APIErrorInvalidParameter, "token not provided", nil)
+		return
+	}
+
+	ctx := r.Context()
+	client, err := pool.GetOCMInviteLifecycleClient(pool.Endpoint(h.gatewayAddr))
+	if err != nil {
+		WriteError(w, r, APIErrorServerError, "error getting gateway grpc client", err)
+		return
+	}
+
+	response, err := client.RevokeInviteToken(ctx, &invitelifecyclepb.RevokeInviteTokenRequest{Token: token})
+	if err != nil {
+		WriteError(w, r, APIErrorServerError, "error sending a grpc revoke invite token request", err)
+		return
+	}
+	writeLifecycleResponse(w, r, response.Status, response)
+}
+
+func (h *invitesHandler) deleteExpiredInviteTokens(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		WriteError(w, r, APIErrorInvalidParameter, "Only POST and DELETE methods are allowed", nil)
+		return
+	}
+
+	ctx := r.Context()
+	client, err := pool.GetOCMInviteLifecycleClient(pool.Endpoint(h.gatewayAddr))
+	if err != nil {
+		WriteError(w, r, APIErrorServerError, "error getting gateway grpc client", err)
+		return
+	}
+
+	response, err := client.DeleteExpiredInviteTokens(ctx, &invitelifecyclepb.DeleteExpiredInviteTokensRequest{})
+	if err != nil {
+		WriteError(w, r, APIErrorServerError, "error sending a grpc delete expired invite tokens request", err)
+		return
+	}
+	writeLifecycleResponse(w, r, response.Status, response)
+}
+
+func (h *invitesHandler) deleteAcceptedUser(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		WriteError(w, r, APIErrorInvalidParameter, "Only POST and DELETE methods are allowed", nil)
+		return
+	}
+	userID, idp := r.FormValue("userID"), r.FormValue("idp")
+	if userID == "" {
+		WriteError(w, r, APIErrorInvalidParameter, "userID not provided", nil)
+		return
+	}
+
+	ctx := r.Context()
+	client, err := pool.GetOCMInviteLifecycleClient(pool.Endpoint(h.gatewayAddr))
+	if err != nil {
+		WriteError(w, r, APIErrorServerError, "error getting gateway grpc client", err)
+		return
+	}
+
+	response, err := client.DeleteAcceptedUser(ctx, &invitelifecyclepb.DeleteAcceptedUserRequest{
+		RemoteUserId: &userpb.UserId{OpaqueId: userID, Idp: idp},
+	})
+	if err != nil {
+		WriteError(w, r, APIErrorServerError, "error sending a grpc delete accepted user request", err)
+		return
+	}
+	writeLifecycleResponse(w, r, response.Status, response)
+}
+
+// writeLifecycleResponse writes the response of an invite lifecycle request, or the error it carries.
+func writeLifecycleResponse(w http.ResponseWriter, r *http.Request, s *rpc.Status, response interface{}) {
+	switch s.GetCode() {
+	case rpc.Code_CODE_OK:
+	case rpc.Code_CODE_NOT_FOUND:
+		WriteError(w, r, APIErrorNotFound, s.Message, nil)
+		return
+	default:
+		WriteError(w, r, APIErrorServerError, s.GetMessage(), errors.New(s.GetMessage()))
+		return
+	}
+
+	indentedResponse, _ := json.MarshalIndent(response, "", "   ")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(indentedResponse); err != nil {
+		appctx.GetLogger(r.Context()).Err(err).Msg("Error writing to ResponseWriter")
+	}
+}
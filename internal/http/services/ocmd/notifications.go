@@ -19,27 +19,169 @@
 package ocmd
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	ocmprovider "github.com/cs3org/go-cs3apis/cs3/ocm/provider/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	ocm "github.com/cs3org/go-cs3apis/cs3/sharing/ocm/v1beta1"
 	"github.com/cs3org/reva/pkg/appctx"
-	"github.com/cs3org/reva/pkg/rhttp/router"
+	ctxpkg "github.com/cs3org/reva/pkg/ctx"
+	"github.com/cs3org/reva/pkg/ocm/share"
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"github.com/cs3org/reva/pkg/utils"
+	"google.golang.org/grpc/metadata"
 )
 
 type notificationsHandler struct {
+	gatewayAddr          string
+	serviceAccountType   string
+	serviceAccountID     string
+	serviceAccountSecret string
 }
 
 func (h *notificationsHandler) init(c *Config) {
+	h.gatewayAddr = c.GatewaySvc
+	h.serviceAccountType = c.ServiceAccountType
+	h.serviceAccountID = c.ServiceAccountID
+	h.serviceAccountSecret = c.ServiceAccountSecret
 }
 
 func (h *notificationsHandler) Handler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		log := appctx.GetLogger(r.Context())
+		switch r.Method {
+		case http.MethodPost:
+			h.handleNotification(w, r)
+		default:
+			WriteError(w, r, APIErrorInvalidParameter, "Only POST method is allowed", nil)
+		}
+	})
+}
+
+func (h *notificationsHandler) handleNotification(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := appctx.GetLogger(ctx)
+
+	if h.serviceAccountID == "" {
+		WriteError(w, r, APIErrorUnimplemented, "notifications are not supported", nil)
+		return
+	}
+
+	var n share.Notification
+	if err := json.NewDecoder(r.Body).Decode(&n); err != nil {
+		WriteError(w, r, APIErrorInvalidParameter, "could not parse json request body", nil)
+		return
+	}
+	switch n.Type {
+	case share.NotificationShareAccepted, share.NotificationShareDeclined,
+		share.NotificationShareUnshared, share.NotificationRequestReshare:
+	default:
+		WriteError(w, r, APIErrorInvalidParameter, "unsupported notification type: "+n.Type, nil)
+		return
+	}
+	if n.ProviderID == "" || n.MeshProvider == "" {
+		WriteError(w, r, APIErrorInvalidParameter, "missing request parameters", nil)
+		return
+	}
 
-		var head string
-		head, r.URL.Path = router.ShiftPath(r.URL.Path)
+	gatewayClient, err := pool.GetGatewayServiceClient(pool.Endpoint(h.gatewayAddr))
+	if err != nil {
+		WriteError(w, r, APIErrorServerError, "error getting storage grpc client", err)
+		return
+	}
 
-		log.Debug().Str("head", head).Str("tail", r.URL.Path).Msg("http routing")
+	clientIP, err := utils.GetClientIP(r)
+	if err != nil {
+		WriteError(w, r, APIErrorServerError, fmt.Sprintf("error retrieving client IP from request: %s", r.RemoteAddr), err)
+		return
+	}
+	providerAllowedResp, err := gatewayClient.IsProviderAllowed(ctx, &ocmprovider.IsProviderAllowedRequest{
+		Provider: &ocmprovider.ProviderInfo{
+			Domain: n.MeshProvider,
+			Services: []*ocmprovider.Service{
+				{
+					Host: clientIP,
+				},
+			},
+		},
+	})
+	if err != nil {
+		WriteError(w, r, APIErrorServerError, "error sending a grpc is provider allowed request", err)
+		return
+	}
+	if providerAllowedResp.Status.Code != rpc.Code_CODE_OK {
+		WriteError(w, r, APIErrorUnauthenticated, "provider not authorized", errors.New(providerAllowedResp.Status.Message))
+		return
+	}
+	if !signedBy(r, n.MeshProvider) {
+		WriteError(w, r, APIErrorUnauthenticated, "request not signed by "+n.MeshProvider, nil)
+		return
+	}
+
+	// the notification is applied by the ocm share provider, through the gateway
+	opaque, err := share.NotificationToOpaque(&n)
+	if err != nil {
+		WriteError(w, r, APIErrorServerError, "error encoding the notification", err)
+		return
+	}
+	authCtx, err := h.authenticate(ctx, gatewayClient)
+	if err != nil {
+		WriteError(w, r, APIErrorServerError, "error authenticating the service account", err)
+		return
+	}
+	res, err := gatewayClient.UpdateOCMShare(authCtx, &ocm.UpdateOCMShareRequest{
+		Opaque: opaque,
+		Ref: &ocm.ShareReference{
+			Spec: &ocm.ShareReference_Id{
+				Id: &ocm.ShareId{OpaqueId: n.ProviderID},
+			},
+		},
+	})
+	if err != nil {
+		WriteError(w, r, APIErrorServerError, "error sending a grpc update ocm share request", err)
+		return
+	}
+	switch res.Status.Code {
+	case rpc.Code_CODE_OK:
+	case rpc.Code_CODE_NOT_FOUND:
+		WriteError(w, r, APIErrorNotFound, "share not found", nil)
+		return
+	case rpc.Code_CODE_PERMISSION_DENIED:
+		WriteError(w, r, APIErrorUntrustedService, "the notification is not allowed for the share", errors.New(res.Status.Message))
+		return
+	case rpc.Code_CODE_UNIMPLEMENTED:
+		WriteError(w, r, APIErrorUnimplemented, res.Status.Message, nil)
+		return
+	case rpc.Code_CODE_INVALID_ARGUMENT:
+		WriteError(w, r, APIErrorInvalidParameter, res.Status.Message, nil)
+		return
+	default:
+		WriteError(w, r, APIErrorServerError, "error applying the notification", errors.New(res.Status.Message))
+		return
+	}
+
+	log.Info().Str("type", n.Type).Str("provider_id", n.ProviderID).Str("mesh_provider", n.MeshProvider).Msg("OCM notification applied")
+	w.WriteHeader(http.StatusCreated)
+}
 
-		w.WriteHeader(http.StatusOK)
+// authenticate logs in with the service account of ocmd,
+// the only one allowed to apply the notifications.
+func (h *notificationsHandler) authenticate(ctx context.Context, c gateway.GatewayAPIClient) (context.Context, error) {
+	res, err := c.Authenticate(ctx, &gateway.AuthenticateRequest{
+		Type:         h.serviceAccountType,
+		ClientId:     h.serviceAccountID,
+		ClientSecret: h.serviceAccountSecret,
 	})
+	if err != nil {
+		return nil, err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return nil, errors.New(res.Status.Message)
+	}
+	ctx = ctxpkg.ContextSetToken(ctx, res.Token)
+	return metadata.AppendToOutgoingContext(ctx, ctxpkg.TokenHeader, res.Token), nil
 }
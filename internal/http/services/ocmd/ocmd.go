@@ -19,9 +19,15 @@
 package ocmd
 
 import (
+	"bytes"
+	"context"
+	"io"
 	"net/http"
+	"time"
 
 	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/ocm/signature"
+	"github.com/cs3org/reva/pkg/rhttp"
 	"github.com/cs3org/reva/pkg/rhttp/global"
 	"github.com/cs3org/reva/pkg/rhttp/router"
 	"github.com/cs3org/reva/pkg/sharedconf"
@@ -30,6 +36,9 @@ import (
 	"github.com/rs/zerolog"
 )
 
+// signatureMaxAge is the maximum age of the signatures of the incoming requests.
+const signatureMaxAge = 5 * time.Minute
+
 func init() {
 	global.Register("ocmd", New)
 }
@@ -42,6 +51,17 @@ type Config struct {
 	GatewaySvc       string                      `mapstructure:"gatewaysvc"`
 	MeshDirectoryURL string                      `mapstructure:"mesh_directory_url"`
 	Config           configData                  `mapstructure:"config"`
+	// SigningKey is the private key in PEM format whose public key is published in the discovery document,
+	// under the key id <endpoint>/ocm-provider#signature.
+	SigningKey string `mapstructure:"signing_key"`
+	// RequireSignatures makes the requests of remote providers without a valid signature to be refused.
+	// Signed requests are always verified.
+	RequireSignatures bool `mapstructure:"require_signatures"`
+	// ServiceAccountType, ServiceAccountID and ServiceAccountSecret are the credentials ocmd logs in with
+	// to apply the notifications of the remote providers, which must be allowed by the ocm share provider.
+	ServiceAccountType   string `mapstructure:"service_account_type"`
+	ServiceAccountID     string `mapstructure:"service_account_id"`
+	ServiceAccountSecret string `mapstructure:"service_account_secret"`
 }
 
 func (c *Config) init() {
@@ -50,6 +70,9 @@ func (c *Config) init() {
 	if c.Prefix == "" {
 		c.Prefix = "ocm"
 	}
+	if c.ServiceAccountType == "" {
+		c.ServiceAccountType = "basic"
+	}
 }
 
 type svc struct {
@@ -59,6 +82,7 @@ type svc struct {
 	ConfigHandler        *configHandler
 	InvitesHandler       *invitesHandler
 	SendHandler          *sendHandler
+	verifier             *signature.Verifier
 }
 
 // New returns a new ocmd object.
@@ -70,7 +94,8 @@ func New(m map[string]interface{}, log *zerolog.Logger) (global.Service, error)
 	conf.init()
 
 	s := &svc{
-		Conf: conf,
+		Conf:     conf,
+		verifier: signature.NewVerifier(rhttp.GetHTTPClient(rhttp.Timeout(5*time.Second)), signatureMaxAge),
 	}
 	s.SharesHandler = new(sharesHandler)
 	s.NotificationsHandler = new(notificationsHandler)
@@ -81,7 +106,9 @@ func New(m map[string]interface{}, log *zerolog.Logger) (global.Service, error)
 	s.NotificationsHandler.init(s.Conf)
 	log.Debug().Str("initializing ConfigHandler Host", s.Conf.Host)
 
-	s.ConfigHandler.init(s.Conf)
+	if err := s.ConfigHandler.init(s.Conf); err != nil {
+		return nil, err
+	}
 	s.InvitesHandler.init(s.Conf)
 	s.SendHandler.init(s.Conf)
 
@@ -101,6 +128,55 @@ func (s *svc) Unprotected() []string {
 	return []string{"/invites/accept", "/shares", "/ocm-provider", "/notifications"}
 }
 
+type verifyKey struct{}
+
+// maxBodySize is the maximum size of the body of the requests of remote providers.
+const maxBodySize = 1 << 20
+
+// readSignature prepares the verification of the signature of the requests of remote
+// providers. As the key must belong to the sending provider, which is only known once
+// the body has been parsed, the verification is deferred to signedBy. The handlers call
+// it once the provider is known to be part of the mesh, so that the key of a signed
+// request is only fetched from the providers of the mesh.
+func (s *svc) readSignature(w http.ResponseWriter, r *http.Request) (*http.Request, bool) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
+	if !signature.IsSigned(r) {
+		if s.Conf.RequireSignatures {
+			WriteError(w, r, APIErrorUnauthenticated, "request not signed", nil)
+			return nil, false
+		}
+		return r, true
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		WriteError(w, r, APIErrorInvalidParameter, "error reading request body", err)
+		return nil, false
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	signed := r.Clone(r.Context())
+	verify := func(provider string) error {
+		signed.Body = io.NopCloser(bytes.NewReader(body))
+		_, err := s.verifier.Verify(signed, provider)
+		return err
+	}
+	return r.WithContext(context.WithValue(r.Context(), verifyKey{}, verify)), true
+}
+
+// signedBy returns whether the request, when signed, was signed with a key of the given provider.
+func signedBy(r *http.Request, provider string) bool {
+	verify, ok := r.Context().Value(verifyKey{}).(func(string) error)
+	if !ok {
+		return true
+	}
+	if err := verify(provider); err != nil {
+		appctx.GetLogger(r.Context()).Warn().Err(err).Str("provider", provider).Msg("ocmd: invalid request signature")
+		return false
+	}
+	return true
+}
+
 func (s *svc) Handler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
@@ -110,6 +186,13 @@ func (s *svc) Handler() http.Handler {
 		head, r.URL.Path = router.ShiftPath(r.URL.Path)
 		log.Debug().Str("head", head).Str("tail", r.URL.Path).Msg("http routing")
 
+		if head == "shares" || head == "notifications" || (head == "invites" && r.URL.Path == "/accept") {
+			var ok bool
+			if r, ok = s.readSignature(w, r); !ok {
+				return
+			}
+		}
+
 		switch head {
 		case "ocm-provider":
 			s.ConfigHandler.Handler().ServeHTTP(w, r)
@@ -0,0 +1,123 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ocmd
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/conversions"
+	"github.com/cs3org/reva/pkg/ocm/share"
+)
+
+// protocolPayload is the protocol object of a share creation request.
+// Older providers send a single protocol described by its name and options,
+// newer ones describe each protocol the share is offered with in its own field.
+type protocolPayload struct {
+	Name    string                 `json:"name"`
+	Options map[string]interface{} `json:"options"`
+	share.Protocols
+}
+
+// getProtocols returns the protocols a share is offered with.
+func getProtocols(protocol map[string]interface{}) (*share.Protocols, error) {
+	raw, err := json.Marshal(protocol)
+	if err != nil {
+		return nil, err
+	}
+	var p protocolPayload
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return nil, errors.New("protocol: invalid parameters")
+	}
+	if len(p.Protocols.Names()) > 0 {
+		if p.WebDAV != nil && p.WebDAV.SharedSecret == "" {
+			return nil, errors.New("protocol: webdav token not provided")
+		}
+		if p.DataTx != nil && p.DataTx.SharedSecret == "" {
+			return nil, errors.New("protocol: datatx token not provided")
+		}
+		return &p.Protocols, nil
+	}
+
+	token, ok := p.Options["sharedSecret"].(string)
+	if !ok {
+		token, ok = p.Options["token"].(string)
+		if !ok {
+			return nil, errors.New("protocol: webdav token not provided")
+		}
+	}
+	switch p.Name {
+	case share.WebDAVProtocol:
+		return &share.Protocols{WebDAV: &share.WebDAV{
+			SharedSecret: token,
+			Permissions:  legacyPermissions(p.Options["permissions"]),
+		}}, nil
+	case share.DataTxProtocol:
+		return &share.Protocols{DataTx: &share.DataTx{SharedSecret: token}}, nil
+	default:
+		return nil, errors.New("protocol: " + p.Name + " not supported")
+	}
+}
+
+// legacyPermissions converts the OCS permissions sent
+// with the single protocol payloads.
+func legacyPermissions(val interface{}) []string {
+	pval, ok := val.(float64)
+	if !ok {
+		return nil
+	}
+	perms, err := conversions.NewPermissions(int(pval))
+	if err != nil {
+		return nil
+	}
+	var list []string
+	if perms.Contain(conversions.PermissionRead) {
+		list = append(list, share.PermissionRead)
+	}
+	if perms.Contain(conversions.PermissionWrite) || perms.Contain(conversions.PermissionCreate) || perms.Contain(conversions.PermissionDelete) {
+		list = append(list, share.PermissionWrite)
+	}
+	if perms.Contain(conversions.PermissionShare) {
+		list = append(list, share.PermissionShare)
+	}
+	return list
+}
+
+// getRole returns the role granted to the recipient of a share.
+// Shares not accessible through webdav are read only.
+func getRole(p *share.Protocols) *conversions.Role {
+	if p.WebDAV == nil || len(p.WebDAV.Permissions) == 0 {
+		return conversions.NewViewerRole()
+	}
+	var perms conversions.Permissions
+	for _, perm := range p.WebDAV.Permissions {
+		switch perm {
+		case share.PermissionRead:
+			perms |= conversions.PermissionRead
+		case share.PermissionWrite:
+			perms |= conversions.PermissionWrite | conversions.PermissionCreate | conversions.PermissionDelete
+		case share.PermissionShare:
+			perms |= conversions.PermissionShare
+		}
+	}
+	if perms == conversions.PermissionInvalid {
+		return conversions.NewViewerRole()
+	}
+	return conversions.RoleFromOCSPermissions(perms)
+}
@@ -35,7 +35,6 @@ import (
 	ocmprovider "github.com/cs3org/go-cs3apis/cs3/ocm/provider/v1beta1"
 	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
 	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
-	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/conversions"
 	"github.com/cs3org/reva/pkg/appctx"
 	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
 	"github.com/cs3org/reva/pkg/utils"
@@ -103,7 +102,8 @@ func (h *sharesHandler) createShare(w http.ResponseWriter, r *http.Request) {
 		WriteError(w, r, APIErrorInvalidParameter, "missing details about resource to be shared", nil)
 		return
 	}
-	if shareWith == "" || protocol["name"] == "" || meshProvider == "" {
+	protocolName, _ := protocol["name"].(string)
+	if shareWith == "" || protocolName == "" || meshProvider == "" {
 		WriteError(w, r, APIErrorInvalidParameter, "missing request parameters", nil)
 		return
 	}
@@ -139,6 +139,10 @@ func (h *sharesHandler) createShare(w http.ResponseWriter, r *http.Request) {
 		WriteError(w, r, APIErrorUnauthenticated, "provider not authorized", errors.New(providerAllowedResp.Status.Message))
 		return
 	}
+	if !signedBy(r, meshProvider) {
+		WriteError(w, r, APIErrorUnauthenticated, "request not signed by "+meshProvider, nil)
+		return
+	}
 
 	var shareWithParts = strings.Split(shareWith, "@")
 	userRes, err := gatewayClient.GetUser(ctx, &userpb.GetUserRequest{
@@ -153,36 +157,18 @@ func (h *sharesHandler) createShare(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var permissions conversions.Permissions
-	var token string
-	options, ok := protocol["options"].(map[string]interface{})
-	if !ok {
-		WriteError(w, r, APIErrorInvalidParameter, "protocol: webdav token not provided", nil)
+	protocols, err := getProtocols(protocol)
+	if err != nil {
+		WriteError(w, r, APIErrorInvalidParameter, err.Error(), nil)
 		return
 	}
-
-	token, ok = options["sharedSecret"].(string)
-	if !ok {
-		token, ok = options["token"].(string)
-		if !ok {
-			WriteError(w, r, APIErrorInvalidParameter, "protocol: webdav token not provided", nil)
-			return
-		}
-	}
-	var role *conversions.Role
-	pval, ok := options["permissions"].(int)
-	if !ok {
-		role = conversions.NewViewerRole()
-	} else {
-		permissions, err = conversions.NewPermissions(pval)
-		if err != nil {
-			WriteError(w, r, APIErrorInvalidParameter, err.Error(), nil)
-			return
-		}
-		role = conversions.RoleFromOCSPermissions(permissions)
+	encProtocols, err := json.Marshal(protocols)
+	if err != nil {
+		WriteError(w, r, APIErrorServerError, "could not encode protocols", err)
+		return
 	}
 
-	val, err := json.Marshal(role.CS3ResourcePermissions())
+	val, err := json.Marshal(getRole(protocols).CS3ResourcePermissions())
 	if err != nil {
 		WriteError(w, r, APIErrorServerError, "could not encode role", nil)
 		return
@@ -199,7 +185,7 @@ func (h *sharesHandler) createShare(w http.ResponseWriter, r *http.Request) {
 		Owner:      ownerID,
 		ShareWith:  userRes.User.GetId(),
 		Protocol: &ocmcore.Protocol{
-			Name: protocol["name"].(string),
+			Name: protocolName,
 			Opaque: &types.Opaque{
 				Map: map[string]*types.OpaqueEntry{
 					"permissions": {
@@ -208,7 +194,11 @@ func (h *sharesHandler) createShare(w http.ResponseWriter, r *http.Request) {
 					},
 					"token": {
 						Decoder: "plain",
-						Value:   []byte(token),
+						Value:   []byte(protocols.SharedSecret()),
+					},
+					"protocols": {
+						Decoder: "json",
+						Value:   encProtocols,
 					},
 				},
 			},
@@ -0,0 +1,505 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package oidclogin implements an HTTP service running the OpenID Connect
+// login flows against a configured issuer: the authorization code flow with
+// PKCE for browsers, and the device authorization grant (RFC 8628) for
+// browser-less clients like the reva CLI. The access token obtained from the
+// issuer is exchanged for a reva token through the gateway.
+package oidclogin
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc"
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"github.com/cs3org/reva/pkg/rhttp"
+	"github.com/cs3org/reva/pkg/rhttp/global"
+	"github.com/cs3org/reva/pkg/sharedconf"
+	"github.com/go-chi/chi/v5"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"golang.org/x/oauth2"
+)
+
+func init() {
+	global.Register("oidclogin", New)
+}
+
+const (
+	deviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+	// stateCookie binds a login to the browser that started it.
+	stateCookie = "oidc_login_state"
+)
+
+type config struct {
+	Prefix               string   `mapstructure:"prefix" docs:"oidc-login;The URL path where the service is exposed."`
+	Issuer               string   `mapstructure:"issuer" docs:";The issuer of the OIDC provider."`
+	ClientID             string   `mapstructure:"client_id" docs:";The client ID registered at the OIDC provider."`
+	ClientSecret         string   `mapstructure:"client_secret" docs:";The client secret; leave empty for public clients."`
+	Scopes               []string `mapstructure:"scopes" docs:"openid,profile,email;The scopes to request."`
+	RedirectURL          string   `mapstructure:"redirect_url" docs:";The callback URL registered at the OIDC provider, i.e. the public URL of the /callback endpoint of the service."`
+	PostLoginRedirectURL string   `mapstructure:"post_login_redirect_url" docs:";Where to send the browser after a successful login, with the reva token in the URL fragment. If empty, the token is returned as JSON."`
+	AuthType             string   `mapstructure:"auth_type" docs:"oidc;The type of the auth provider the access token is exchanged with."`
+	LoginTimeout         int      `mapstructure:"login_timeout" docs:"600;The time in seconds a browser login may take."`
+	MaxPendingLogins     int      `mapstructure:"max_pending_logins" docs:"1000;The maximum number of browser logins in progress at the same time."`
+	Insecure             bool     `mapstructure:"insecure" docs:"false;Whether to skip certificate checks when sending requests to the OIDC provider."`
+	GatewaySvc           string   `mapstructure:"gatewaysvc" docs:";The endpoint at which the GRPC gateway is exposed."`
+}
+
+func (c *config) init() {
+	if c.Prefix == "" {
+		c.Prefix = "oidc-login"
+	}
+	if len(c.Scopes) == 0 {
+		c.Scopes = []string{oidc.ScopeOpenID, "profile", "email"}
+	}
+	if c.AuthType == "" {
+		c.AuthType = "oidc"
+	}
+	if c.LoginTimeout <= 0 {
+		c.LoginTimeout = 600
+	}
+	if c.MaxPendingLogins <= 0 {
+		c.MaxPendingLogins = 1000
+	}
+	c.GatewaySvc = sharedconf.GetGatewaySVC(c.GatewaySvc)
+}
+
+func parseConfig(m map[string]interface{}) (*config, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		err = errors.Wrap(err, "error decoding conf")
+		return nil, err
+	}
+	c.init()
+	return c, nil
+}
+
+// pendingLogin holds the secrets of a browser login between the redirect to the provider and the callback.
+type pendingLogin struct {
+	verifier string
+	nonce    string
+	expires  time.Time
+}
+
+type svc struct {
+	conf   *config
+	router *chi.Mux
+
+	providerMutex sync.Mutex
+	provider      *oidc.Provider
+
+	loginsMutex sync.Mutex
+	logins      map[string]*pendingLogin
+
+	// authenticate exchanges an access token of the provider for a reva token.
+	authenticate func(ctx context.Context, accessToken string) (string, error)
+}
+
+// New returns a new OIDC login service.
+func New(m map[string]interface{}, log *zerolog.Logger) (global.Service, error) {
+	conf, err := parseConfig(m)
+	if err != nil {
+		return nil, err
+	}
+	if conf.Issuer == "" || conf.ClientID == "" || conf.RedirectURL == "" {
+		return nil, errors.New("oidclogin: issuer, client_id and redirect_url must be configured")
+	}
+	if _, err := url.Parse(conf.RedirectURL); err != nil {
+		return nil, errors.Wrap(err, "oidclogin: invalid redirect_url")
+	}
+
+	s := &svc{
+		conf:   conf,
+		router: chi.NewRouter(),
+		logins: map[string]*pendingLogin{},
+	}
+	s.authenticate = s.authenticateWithGateway
+	s.routerInit()
+	return s, nil
+}
+
+func (s *svc) routerInit() {
+	s.router.Get("/login", s.handleLogin)
+	s.router.Get("/callback", s.handleCallback)
+	s.router.Post("/device", s.handleDeviceAuthorization)
+	s.router.Post("/device/token", s.handleDeviceToken)
+}
+
+// Close performs cleanup.
+func (s *svc) Close() error {
+	return nil
+}
+
+func (s *svc) Prefix() string {
+	return s.conf.Prefix
+}
+
+func (s *svc) Unprotected() []string {
+	return []string{"/"}
+}
+
+func (s *svc) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.router.ServeHTTP(w, r)
+	})
+}
+
+func (s *svc) getOAuthCtx(ctx context.Context) context.Context {
+	client := rhttp.GetHTTPClient(
+		rhttp.Context(ctx),
+		rhttp.Timeout(time.Second*10),
+		rhttp.Insecure(s.conf.Insecure),
+	)
+	return context.WithValue(ctx, oauth2.HTTPClient, client)
+}
+
+// getProvider returns the OIDC provider, discovering it on first use.
+func (s *svc) getProvider(ctx context.Context) (*oidc.Provider, error) {
+	s.providerMutex.Lock()
+	defer s.providerMutex.Unlock()
+
+	if s.provider != nil {
+		return s.provider, nil
+	}
+	provider, err := oidc.NewProvider(s.getOAuthCtx(ctx), s.conf.Issuer)
+	if err != nil {
+		return nil, errors.Wrap(err, "oidclogin: error discovering the oidc provider")
+	}
+	s.provider = provider
+	return provider, nil
+}
+
+func (s *svc) getOAuthConfig(provider *oidc.Provider) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     s.conf.ClientID,
+		ClientSecret: s.conf.ClientSecret,
+		Endpoint:     provider.Endpoint(),
+		RedirectURL:  s.conf.RedirectURL,
+		Scopes:       s.conf.Scopes,
+	}
+}
+
+// setStateCookie stores the state of a login in the browser, so that the callback
+// can only complete a login started by the same browser.
+func (s *svc) setStateCookie(w http.ResponseWriter, state string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     stateCookie,
+		Value:    state,
+		Path:     path.Join("/", s.conf.Prefix),
+		MaxAge:   maxAge,
+		Secure:   strings.HasPrefix(s.conf.RedirectURL, "https://"),
+		HttpOnly: true,
+		// the callback is a top level navigation coming from the provider
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func (s *svc) authenticateWithGateway(ctx context.Context, accessToken string) (string, error) {
+	client, err := pool.GetGatewayServiceClient(pool.Endpoint(s.conf.GatewaySvc))
+	if err != nil {
+		return "", errors.Wrap(err, "oidclogin: error getting gateway client")
+	}
+	res, err := client.Authenticate(ctx, &gateway.AuthenticateRequest{
+		Type:         s.conf.AuthType,
+		ClientSecret: accessToken,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "oidclogin: error authenticating")
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return "", errors.New("oidclogin: error authenticating: " + res.Status.Message)
+	}
+	return res.Token, nil
+}
+
+func (s *svc) handleLogin(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	provider, err := s.getProvider(ctx)
+	if err != nil {
+		writeError(w, r, http.StatusBadGateway, "server_error", err)
+		return
+	}
+
+	var secrets [3]string
+	for i := range secrets {
+		if secrets[i], err = randomString(); err != nil {
+			writeError(w, r, http.StatusInternalServerError, "server_error", err)
+			return
+		}
+	}
+	state, verifier, nonce := secrets[0], secrets[1], secrets[2]
+	if !s.addLogin(state, &pendingLogin{
+		verifier: verifier,
+		nonce:    nonce,
+		expires:  time.Now().Add(time.Duration(s.conf.LoginTimeout) * time.Second),
+	}) {
+		writeError(w, r, http.StatusServiceUnavailable, "temporarily_unavailable", errors.New("too many logins in progress"))
+		return
+	}
+	s.setStateCookie(w, state, s.conf.LoginTimeout)
+
+	authURL := s.getOAuthConfig(provider).AuthCodeURL(state,
+		oidc.Nonce(nonce),
+		oauth2.SetAuthURLParam("code_challenge", pkceChallenge(verifier)),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+	)
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+func (s *svc) handleCallback(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q := r.URL.Query()
+
+	state := q.Get("state")
+	cookie, err := r.Cookie(stateCookie)
+	if err != nil || state == "" || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) != 1 {
+		writeError(w, r, http.StatusBadRequest, "invalid_request", errors.New("the login was not started by this browser"))
+		return
+	}
+	s.setStateCookie(w, "", -1)
+
+	login := s.takeLogin(state)
+	if login == nil {
+		writeError(w, r, http.StatusBadRequest, "invalid_request", errors.New("unknown or expired login state"))
+		return
+	}
+	if e := q.Get("error"); e != "" {
+		writeError(w, r, http.StatusUnauthorized, e, errors.New(q.Get("error_description")))
+		return
+	}
+
+	provider, err := s.getProvider(ctx)
+	if err != nil {
+		writeError(w, r, http.StatusBadGateway, "server_error", err)
+		return
+	}
+
+	token, err := s.getOAuthConfig(provider).Exchange(s.getOAuthCtx(ctx), q.Get("code"),
+		oauth2.SetAuthURLParam("code_verifier", login.verifier))
+	if err != nil {
+		writeError(w, r, http.StatusUnauthorized, "invalid_grant", err)
+		return
+	}
+
+	// the access token is what reva validates; the ID token, if any, must match this login
+	if rawIDToken, ok := token.Extra("id_token").(string); ok {
+		idToken, err := provider.Verifier(&oidc.Config{ClientID: s.conf.ClientID}).Verify(ctx, rawIDToken)
+		if err != nil {
+			writeError(w, r, http.StatusUnauthorized, "invalid_grant", errors.Wrap(err, "invalid id token"))
+			return
+		}
+		if idToken.Nonce != login.nonce {
+			writeError(w, r, http.StatusUnauthorized, "invalid_grant", errors.New("id token nonce mismatch"))
+			return
+		}
+	}
+
+	revaToken, err := s.authenticate(ctx, token.AccessToken)
+	if err != nil {
+		writeError(w, r, http.StatusUnauthorized, "access_denied", err)
+		return
+	}
+
+	if s.conf.PostLoginRedirectURL != "" {
+		http.Redirect(w, r, s.conf.PostLoginRedirectURL+"#"+url.Values{"token": {revaToken}}.Encode(), http.StatusFound)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, map[string]string{"token": revaToken})
+}
+
+// handleDeviceAuthorization starts a device authorization grant at the provider.
+func (s *svc) handleDeviceAuthorization(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	provider, err := s.getProvider(ctx)
+	if err != nil {
+		writeError(w, r, http.StatusBadGateway, "server_error", err)
+		return
+	}
+	var claims struct {
+		DeviceAuthorizationEndpoint string `json:"device_authorization_endpoint"`
+	}
+	if err := provider.Claims(&claims); err != nil || claims.DeviceAuthorizationEndpoint == "" {
+		writeError(w, r, http.StatusNotImplemented, "unsupported_grant_type", errors.New("the provider does not support the device authorization grant"))
+		return
+	}
+
+	params := s.clientParams()
+	params.Set("scope", strings.Join(s.conf.Scopes, " "))
+	status, res, err := s.postForm(ctx, claims.DeviceAuthorizationEndpoint, params)
+	if err != nil {
+		writeError(w, r, http.StatusBadGateway, "server_error", err)
+		return
+	}
+	writeJSON(w, r, status, res)
+}
+
+// handleDeviceToken polls the provider for the result of a device authorization grant
+// and, once the user has approved it, returns a reva token.
+func (s *svc) handleDeviceToken(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	deviceCode := r.FormValue("device_code")
+	if deviceCode == "" {
+		writeError(w, r, http.StatusBadRequest, "invalid_request", errors.New("missing device_code"))
+		return
+	}
+
+	provider, err := s.getProvider(ctx)
+	if err != nil {
+		writeError(w, r, http.StatusBadGateway, "server_error", err)
+		return
+	}
+
+	params := s.clientParams()
+	params.Set("grant_type", deviceCodeGrantType)
+	params.Set("device_code", deviceCode)
+	status, res, err := s.postForm(ctx, provider.Endpoint().TokenURL, params)
+	if err != nil {
+		writeError(w, r, http.StatusBadGateway, "server_error", err)
+		return
+	}
+
+	// pending authorizations and errors are passed to the client as they are
+	accessToken, _ := res["access_token"].(string)
+	if status != http.StatusOK || accessToken == "" {
+		if status == http.StatusOK {
+			status = http.StatusBadGateway
+		}
+		writeJSON(w, r, status, res)
+		return
+	}
+
+	revaToken, err := s.authenticate(ctx, accessToken)
+	if err != nil {
+		writeError(w, r, http.StatusUnauthorized, "access_denied", err)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, map[string]string{"token": revaToken})
+}
+
+func (s *svc) clientParams() url.Values {
+	params := url.Values{"client_id": {s.conf.ClientID}}
+	if s.conf.ClientSecret != "" {
+		params.Set("client_secret", s.conf.ClientSecret)
+	}
+	return params
+}
+
+// postForm sends a form to an endpoint of the provider and decodes its JSON response.
+func (s *svc) postForm(ctx context.Context, endpoint string, params url.Values) (int, map[string]interface{}, error) {
+	client, _ := s.getOAuthCtx(ctx).Value(oauth2.HTTPClient).(*http.Client)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(params.Encode()))
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	res, err := client.Do(req)
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "oidclogin: error contacting the oidc provider")
+	}
+	defer res.Body.Close()
+
+	body := map[string]interface{}{}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return 0, nil, errors.Wrap(err, "oidclogin: error decoding the response of the oidc provider")
+	}
+	return res.StatusCode, body, nil
+}
+
+// addLogin stores a pending login, returning false if too many logins are in progress.
+func (s *svc) addLogin(state string, login *pendingLogin) bool {
+	s.loginsMutex.Lock()
+	defer s.loginsMutex.Unlock()
+
+	now := time.Now()
+	for k, l := range s.logins {
+		if now.After(l.expires) {
+			delete(s.logins, k)
+		}
+	}
+	if len(s.logins) >= s.conf.MaxPendingLogins {
+		return false
+	}
+	s.logins[state] = login
+	return true
+}
+
+// takeLogin returns and forgets the pending login of a state; a state can only be used once.
+func (s *svc) takeLogin(state string) *pendingLogin {
+	s.loginsMutex.Lock()
+	defer s.loginsMutex.Unlock()
+
+	login, ok := s.logins[state]
+	if !ok {
+		return nil
+	}
+	delete(s.logins, state)
+	if time.Now().After(login.expires) {
+		return nil
+	}
+	return login
+}
+
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// pkceChallenge returns the S256 code challenge of a PKCE verifier (RFC 7636).
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		appctx.GetLogger(r.Context()).Error().Err(err).Msg("oidclogin: error writing response")
+	}
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, status int, code string, err error) {
+	appctx.GetLogger(r.Context()).Warn().Err(err).Str("error", code).Msg("oidclogin: login failed")
+	writeJSON(w, r, status, map[string]string{"error": code, "error_description": err.Error()})
+}
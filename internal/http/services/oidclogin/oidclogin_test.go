@@ -0,0 +1,223 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package oidclogin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+// fakeProvider is a minimal OIDC provider supporting the authorization code flow with PKCE
+// and the device authorization grant.
+type fakeProvider struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	challenges map[string]string
+	polls      int
+}
+
+func newFakeProvider() *fakeProvider {
+	p := &fakeProvider{challenges: map[string]string{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                        p.URL,
+			"authorization_endpoint":        p.URL + "/authorize",
+			"token_endpoint":                p.URL + "/token",
+			"device_authorization_endpoint": p.URL + "/device",
+			"jwks_uri":                      p.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/device", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"device_code":      "device-code",
+			"user_code":        "ABCD-EFGH",
+			"verification_uri": p.URL + "/activate",
+			"expires_in":       600,
+			"interval":         5,
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.FormValue("grant_type") {
+		case deviceCodeGrantType:
+			p.polls++
+			if p.polls == 1 {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": "authorization_pending"})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]string{"access_token": "device-access-token", "token_type": "Bearer"})
+		case "authorization_code":
+			challenge, ok := p.challenges[r.FormValue("code")]
+			if !ok || pkceChallenge(r.FormValue("code_verifier")) != challenge {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]string{"access_token": "code-access-token", "token_type": "Bearer"})
+		}
+	})
+	p.Server = httptest.NewServer(mux)
+	return p
+}
+
+func newTestService(t *testing.T, provider *fakeProvider, conf map[string]interface{}) *svc {
+	log := zerolog.Nop()
+	m := map[string]interface{}{
+		"issuer":       provider.URL,
+		"client_id":    "reva",
+		"redirect_url": "https://reva.example.com/oidc-login/callback",
+	}
+	for k, v := range conf {
+		m[k] = v
+	}
+	s, err := New(m, &log)
+	if err != nil {
+		t.Fatalf("error creating service: %v", err)
+	}
+	svc := s.(*svc)
+	svc.authenticate = func(ctx context.Context, accessToken string) (string, error) {
+		return "reva-" + accessToken, nil
+	}
+	return svc
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	provider := newFakeProvider()
+	defer provider.Close()
+	s := newTestService(t, provider, nil)
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/login", nil))
+	if rec.Code != http.StatusFound {
+		t.Fatalf("expected status %d, got %d", http.StatusFound, rec.Code)
+	}
+	location, err := url.Parse(rec.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := location.Query()
+	if !strings.HasPrefix(location.String(), provider.URL+"/authorize") || q.Get("code_challenge_method") != "S256" ||
+		q.Get("nonce") == "" || q.Get("redirect_uri") != "https://reva.example.com/oidc-login/callback" {
+		t.Fatalf("unexpected authorization URL: %s", location)
+	}
+
+	// the provider redirects back with a code bound to the challenge
+	provider.challenges["the-code"] = q.Get("code_challenge")
+	callback := "/callback?code=the-code&state=" + url.QueryEscape(q.Get("state"))
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != stateCookie || !cookies[0].HttpOnly || !cookies[0].Secure {
+		t.Fatalf("expected a secure state cookie, got %v", cookies)
+	}
+	callbackRequest := func(cookie *http.Cookie) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, callback, nil)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		return req
+	}
+
+	// a browser that did not start the login cannot complete it
+	for _, cookie := range []*http.Cookie{nil, {Name: stateCookie, Value: "another-state"}} {
+		rec = httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, callbackRequest(cookie))
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("expected status %d without the state cookie, got %d", http.StatusBadRequest, rec.Code)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, callbackRequest(cookies[0]))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	res := map[string]string{}
+	_ = json.Unmarshal(rec.Body.Bytes(), &res)
+	if res["token"] != "reva-code-access-token" {
+		t.Fatalf("unexpected response: %v", res)
+	}
+
+	// a state can only be used once
+	rec = httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, callbackRequest(cookies[0]))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, rec.Code)
+	}
+}
+
+func TestPendingLoginsLimit(t *testing.T) {
+	provider := newFakeProvider()
+	defer provider.Close()
+	s := newTestService(t, provider, map[string]interface{}{"max_pending_logins": 2})
+
+	for i, code := range []int{http.StatusFound, http.StatusFound, http.StatusServiceUnavailable} {
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/login", nil))
+		if rec.Code != code {
+			t.Fatalf("login %d: expected status %d, got %d", i, code, rec.Code)
+		}
+	}
+}
+
+func TestDeviceFlow(t *testing.T) {
+	provider := newFakeProvider()
+	defer provider.Close()
+	s := newTestService(t, provider, nil)
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/device", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	auth := map[string]interface{}{}
+	_ = json.Unmarshal(rec.Body.Bytes(), &auth)
+	if auth["user_code"] != "ABCD-EFGH" || auth["device_code"] != "device-code" {
+		t.Fatalf("unexpected device authorization: %v", auth)
+	}
+
+	poll := func() (int, map[string]string) {
+		req := httptest.NewRequest(http.MethodPost, "/device/token", strings.NewReader("device_code=device-code"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, req)
+		res := map[string]string{}
+		_ = json.Unmarshal(rec.Body.Bytes(), &res)
+		return rec.Code, res
+	}
+
+	if code, res := poll(); code != http.StatusBadRequest || res["error"] != "authorization_pending" {
+		t.Fatalf("expected a pending authorization, got %d %v", code, res)
+	}
+	if code, res := poll(); code != http.StatusOK || res["token"] != "reva-device-access-token" {
+		t.Fatalf("expected a token, got %d %v", code, res)
+	}
+}
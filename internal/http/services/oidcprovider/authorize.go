@@ -0,0 +1,219 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package oidcprovider
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/appctx"
+)
+
+// grant holds what a client has been granted, through an authorization code or a refresh token.
+type grant struct {
+	clientID        string
+	redirectURI     string
+	user            *userpb.User
+	scopes          []string
+	nonce           string
+	challenge       string
+	challengeMethod string
+	expires         time.Time
+}
+
+type authRequest struct {
+	ResponseType        string
+	ClientID            string
+	RedirectURI         string
+	Scope               string
+	State               string
+	Nonce               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+}
+
+func parseAuthRequest(r *http.Request) *authRequest {
+	return &authRequest{
+		ResponseType:        r.FormValue("response_type"),
+		ClientID:            r.FormValue("client_id"),
+		RedirectURI:         r.FormValue("redirect_uri"),
+		Scope:               r.FormValue("scope"),
+		State:               r.FormValue("state"),
+		Nonce:               r.FormValue("nonce"),
+		CodeChallenge:       r.FormValue("code_challenge"),
+		CodeChallengeMethod: r.FormValue("code_challenge_method"),
+	}
+}
+
+var loginTemplate = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Log in</title>
+</head>
+<body>
+<form method="post">
+<h1>Log in</h1>
+{{if .Error}}<p class="error">{{.Error}}</p>{{end}}
+<p><label>Username <input type="text" name="username" value="{{.Username}}" autofocus required></label></p>
+<p><label>Password <input type="password" name="password" required></label></p>
+<input type="hidden" name="response_type" value="{{.Request.ResponseType}}">
+<input type="hidden" name="client_id" value="{{.Request.ClientID}}">
+<input type="hidden" name="redirect_uri" value="{{.Request.RedirectURI}}">
+<input type="hidden" name="scope" value="{{.Request.Scope}}">
+<input type="hidden" name="state" value="{{.Request.State}}">
+<input type="hidden" name="nonce" value="{{.Request.Nonce}}">
+<input type="hidden" name="code_challenge" value="{{.Request.CodeChallenge}}">
+<input type="hidden" name="code_challenge_method" value="{{.Request.CodeChallengeMethod}}">
+<p><button type="submit">Log in</button></p>
+</form>
+</body>
+</html>
+`))
+
+// validateAuthRequest checks an authorization request. If the client or the redirect URI are invalid,
+// the error is shown to the user; otherwise the error is sent to the client.
+func (s *svc) validateAuthRequest(w http.ResponseWriter, r *http.Request, req *authRequest) ([]string, bool) {
+	client := s.getClient(req.ClientID)
+	if client == nil {
+		http.Error(w, "unknown client", http.StatusBadRequest)
+		return nil, false
+	}
+	if !contains(client.RedirectURIs, req.RedirectURI) {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return nil, false
+	}
+
+	switch {
+	case req.ResponseType != "code":
+		s.redirectError(w, r, req, "unsupported_response_type", "only the code response type is supported")
+		return nil, false
+	case !contains(client.GrantTypes, "authorization_code"):
+		s.redirectError(w, r, req, "unauthorized_client", "the client may not use the authorization code flow")
+		return nil, false
+	case client.Public && req.CodeChallenge == "":
+		s.redirectError(w, r, req, "invalid_request", "public clients must use PKCE")
+		return nil, false
+	case req.CodeChallenge != "" && req.CodeChallengeMethod != "" && req.CodeChallengeMethod != "S256" && req.CodeChallengeMethod != "plain":
+		s.redirectError(w, r, req, "invalid_request", "unsupported code_challenge_method")
+		return nil, false
+	}
+
+	scopes := []string{}
+	for _, scope := range strings.Fields(req.Scope) {
+		if contains(client.Scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	if !contains(scopes, "openid") {
+		s.redirectError(w, r, req, "invalid_scope", "the openid scope is required")
+		return nil, false
+	}
+	return scopes, true
+}
+
+func (s *svc) redirectError(w http.ResponseWriter, r *http.Request, req *authRequest, code, description string) {
+	s.redirect(w, r, req, url.Values{"error": {code}, "error_description": {description}})
+}
+
+func (s *svc) redirect(w http.ResponseWriter, r *http.Request, req *authRequest, params url.Values) {
+	u, err := url.Parse(req.RedirectURI)
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	q := u.Query()
+	for k, v := range params {
+		q[k] = v
+	}
+	if req.State != "" {
+		q.Set("state", req.State)
+	}
+	u.RawQuery = q.Encode()
+	http.Redirect(w, r, u.String(), http.StatusFound)
+}
+
+func renderLogin(w http.ResponseWriter, r *http.Request, req *authRequest, username, loginError string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Frame-Options", "DENY")
+	if loginError != "" {
+		w.WriteHeader(http.StatusUnauthorized)
+	}
+	err := loginTemplate.Execute(w, map[string]interface{}{
+		"Request":  req,
+		"Username": username,
+		"Error":    loginError,
+	})
+	if err != nil {
+		appctx.GetLogger(r.Context()).Error().Err(err).Msg("oidcprovider: error rendering login form")
+	}
+}
+
+// handleAuthorize shows the login form for a valid authorization request.
+func (s *svc) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	req := parseAuthRequest(r)
+	if _, ok := s.validateAuthRequest(w, r, req); !ok {
+		return
+	}
+	renderLogin(w, r, req, "", "")
+}
+
+// handleLogin checks the credentials entered in the login form and redirects to the client with an authorization code.
+func (s *svc) handleLogin(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := appctx.GetLogger(ctx)
+
+	req := parseAuthRequest(r)
+	scopes, ok := s.validateAuthRequest(w, r, req)
+	if !ok {
+		return
+	}
+
+	username := r.FormValue("username")
+	u, err := s.authenticate(ctx, username, r.FormValue("password"))
+	if err != nil {
+		log.Warn().Err(err).Str("username", username).Msg("oidcprovider: login failed")
+		renderLogin(w, r, req, username, "Invalid username or password.")
+		return
+	}
+
+	code, err := randomString()
+	if err != nil {
+		s.redirectError(w, r, req, "server_error", "error generating the authorization code")
+		return
+	}
+	s.store(s.codes, code, &grant{
+		clientID:        req.ClientID,
+		redirectURI:     req.RedirectURI,
+		user:            u,
+		scopes:          scopes,
+		nonce:           req.Nonce,
+		challenge:       req.CodeChallenge,
+		challengeMethod: req.CodeChallengeMethod,
+		expires:         expiresAt(s.conf.CodeExpiration),
+	})
+
+	s.redirect(w, r, req, url.Values{"code": {code}})
+}
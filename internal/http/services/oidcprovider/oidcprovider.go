@@ -0,0 +1,352 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package oidcprovider implements a minimal OpenID Connect provider, which
+// authenticates users through the gateway. It allows small
+// deployments to serve web and desktop clients without an external IdP.
+package oidcprovider
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/rgrpc/status"
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"github.com/cs3org/reva/pkg/rhttp/global"
+	"github.com/cs3org/reva/pkg/sharedconf"
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+func init() {
+	global.Register("oidcprovider", New)
+}
+
+type config struct {
+	Prefix                 string                   `mapstructure:"prefix" docs:"oauth2;The URL path where the service is exposed."`
+	Issuer                 string                   `mapstructure:"issuer" docs:";The issuer of the tokens, i.e. the URL at which the OIDC discovery document is served."`
+	SigningKey             string                   `mapstructure:"signing_key" docs:";The path to the PEM encoded RSA key used to sign the tokens. If empty, a key is generated at startup, invalidating all tokens on restart."`
+	AuthType               string                   `mapstructure:"auth_type" docs:"basic;The type of the auth provider the credentials of the users are checked with."`
+	Clients                map[string]*clientConfig `mapstructure:"clients" docs:";The clients allowed to use the provider, keyed by name."`
+	AccessTokenExpiration  int                      `mapstructure:"access_token_expiration" docs:"3600;The lifetime in seconds of the access and ID tokens."`
+	RefreshTokenExpiration int                      `mapstructure:"refresh_token_expiration" docs:"2592000;The lifetime in seconds of the refresh tokens."`
+	CodeExpiration         int                      `mapstructure:"code_expiration" docs:"60;The lifetime in seconds of the authorization codes."`
+	GatewaySvc             string                   `mapstructure:"gatewaysvc" docs:";The endpoint at which the GRPC gateway is exposed."`
+}
+
+type clientConfig struct {
+	ID            string   `mapstructure:"id"`
+	Secret        string   `mapstructure:"client_secret"`
+	RedirectURIs  []string `mapstructure:"redirect_uris"`
+	GrantTypes    []string `mapstructure:"grant_types"`
+	ResponseTypes []string `mapstructure:"response_types"`
+	Scopes        []string `mapstructure:"scopes"`
+	// Public clients have no secret and must use PKCE.
+	Public bool `mapstructure:"public"`
+}
+
+func (c *config) init() {
+	if c.Prefix == "" {
+		c.Prefix = "oauth2"
+	}
+	c.Issuer = strings.TrimSuffix(c.Issuer, "/")
+	if c.AuthType == "" {
+		c.AuthType = "basic"
+	}
+	if c.AccessTokenExpiration <= 0 {
+		c.AccessTokenExpiration = 3600
+	}
+	if c.RefreshTokenExpiration <= 0 {
+		c.RefreshTokenExpiration = 30 * 24 * 3600
+	}
+	if c.CodeExpiration <= 0 {
+		c.CodeExpiration = 60
+	}
+	for _, cl := range c.Clients {
+		if len(cl.GrantTypes) == 0 {
+			cl.GrantTypes = []string{"authorization_code", "refresh_token"}
+		}
+		if len(cl.Scopes) == 0 {
+			cl.Scopes = []string{"openid", "profile", "email", "offline_access"}
+		}
+	}
+	c.GatewaySvc = sharedconf.GetGatewaySVC(c.GatewaySvc)
+}
+
+func parseConfig(m map[string]interface{}) (*config, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		err = errors.Wrap(err, "error decoding conf")
+		return nil, err
+	}
+	c.init()
+	return c, nil
+}
+
+type svc struct {
+	conf   *config
+	router *chi.Mux
+
+	key   *rsa.PrivateKey
+	keyID string
+
+	mu            sync.Mutex
+	codes         map[string]*grant
+	refreshTokens map[string]*grant
+}
+
+// New returns a new OIDC provider service.
+func New(m map[string]interface{}, log *zerolog.Logger) (global.Service, error) {
+	conf, err := parseConfig(m)
+	if err != nil {
+		return nil, err
+	}
+	if conf.Issuer == "" {
+		return nil, errors.New("oidcprovider: issuer must be configured")
+	}
+
+	for name, cl := range conf.Clients {
+		if !cl.Public && cl.Secret == "" {
+			return nil, errors.New("oidcprovider: client " + name + " must either be public or have a client_secret")
+		}
+	}
+
+	key, err := loadSigningKey(conf.SigningKey)
+	if err != nil {
+		return nil, err
+	}
+	if conf.SigningKey == "" {
+		log.Warn().Msg("oidcprovider: no signing key configured, tokens will be invalidated on restart")
+	}
+
+	s := &svc{
+		conf:          conf,
+		router:        chi.NewRouter(),
+		key:           key,
+		keyID:         keyID(&key.PublicKey),
+		codes:         map[string]*grant{},
+		refreshTokens: map[string]*grant{},
+	}
+	s.routerInit()
+	return s, nil
+}
+
+func loadSigningKey(file string) (*rsa.PrivateKey, error) {
+	if file == "" {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, errors.Wrap(err, "oidcprovider: error generating signing key")
+		}
+		return key, nil
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "oidcprovider: error reading signing key")
+	}
+	key, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "oidcprovider: error parsing signing key")
+	}
+	return key, nil
+}
+
+// keyID derives the ID of a key from its modulus, so that it is stable across restarts.
+func keyID(key *rsa.PublicKey) string {
+	sum := sha256.Sum256(key.N.Bytes())
+	return base64.RawURLEncoding.EncodeToString(sum[:8])
+}
+
+func (s *svc) routerInit() {
+	s.router.Get("/.well-known/openid-configuration", s.handleDiscovery)
+	s.router.Get("/auth", s.handleAuthorize)
+	s.router.Post("/auth", s.handleLogin)
+	s.router.Post("/token", s.handleToken)
+	s.router.Get("/userinfo", s.handleUserInfo)
+	s.router.Post("/userinfo", s.handleUserInfo)
+	s.router.Get("/keys", s.handleKeys)
+}
+
+// Close performs cleanup.
+func (s *svc) Close() error {
+	return nil
+}
+
+func (s *svc) Prefix() string {
+	return s.conf.Prefix
+}
+
+func (s *svc) Unprotected() []string {
+	return []string{"/"}
+}
+
+func (s *svc) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.router.ServeHTTP(w, r)
+	})
+}
+
+// authenticate checks the credentials of a user through the gateway, so that
+// the auth provider, including its lockout of failed logins, applies.
+func (s *svc) authenticate(ctx context.Context, username, password string) (*userpb.User, error) {
+	client, err := pool.GetGatewayServiceClient(pool.Endpoint(s.conf.GatewaySvc))
+	if err != nil {
+		return nil, errors.Wrap(err, "oidcprovider: error getting gateway client")
+	}
+	res, err := client.Authenticate(ctx, &gateway.AuthenticateRequest{
+		Type:         s.conf.AuthType,
+		ClientId:     username,
+		ClientSecret: password,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "oidcprovider: error authenticating")
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return nil, status.NewErrorFromCode(res.Status.Code, "oidcprovider")
+	}
+	return res.User, nil
+}
+
+// getUser returns the current attributes of a user, or a not found error
+// if the user is not known to the user provider anymore.
+func (s *svc) getUser(ctx context.Context, id *userpb.UserId) (*userpb.User, error) {
+	client, err := pool.GetGatewayServiceClient(pool.Endpoint(s.conf.GatewaySvc))
+	if err != nil {
+		return nil, errors.Wrap(err, "oidcprovider: error getting gateway client")
+	}
+	res, err := client.GetUser(ctx, &userpb.GetUserRequest{UserId: id})
+	if err != nil {
+		return nil, errors.Wrap(err, "oidcprovider: error getting user")
+	}
+	switch res.Status.Code {
+	case rpc.Code_CODE_OK:
+		return res.User, nil
+	case rpc.Code_CODE_NOT_FOUND:
+		return nil, errtypes.NotFound(id.OpaqueId)
+	default:
+		return nil, status.NewErrorFromCode(res.Status.Code, "oidcprovider")
+	}
+}
+
+// endpoint returns the absolute URL of an endpoint of the provider.
+func (s *svc) endpoint(r *http.Request, name string) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + "/" + strings.Trim(s.conf.Prefix, "/") + "/" + name
+}
+
+func (s *svc) handleDiscovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, r, http.StatusOK, map[string]interface{}{
+		"issuer":                                s.conf.Issuer,
+		"authorization_endpoint":                s.endpoint(r, "auth"),
+		"token_endpoint":                        s.endpoint(r, "token"),
+		"userinfo_endpoint":                     s.endpoint(r, "userinfo"),
+		"jwks_uri":                              s.endpoint(r, "keys"),
+		"response_types_supported":              []string{"code"},
+		"grant_types_supported":                 []string{"authorization_code", "refresh_token", "password"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"scopes_supported":                      []string{"openid", "profile", "email", "offline_access"},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "none"},
+		"code_challenge_methods_supported":      []string{"S256", "plain"},
+		"claims_supported":                      []string{"sub", "iss", "aud", "exp", "iat", "nonce", "preferred_username", "name", "email", "email_verified", "groups", "uid", "gid"},
+	})
+}
+
+func (s *svc) handleKeys(w http.ResponseWriter, r *http.Request) {
+	pub := s.key.PublicKey
+	writeJSON(w, r, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": s.keyID,
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+func (s *svc) getClient(id string) *clientConfig {
+	for _, c := range s.conf.Clients {
+		if c.ID == id {
+			return c
+		}
+	}
+	return nil
+}
+
+func contains(list []string, v string) bool {
+	for _, e := range list {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
+
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		appctx.GetLogger(r.Context()).Error().Err(err).Msg("oidcprovider: error writing response")
+	}
+}
+
+// writeError writes an OAuth 2.0 error response (RFC 6749, section 5.2).
+func writeError(w http.ResponseWriter, r *http.Request, status int, code, description string) {
+	if status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Bearer error="`+code+`"`)
+	}
+	writeJSON(w, r, status, map[string]string{"error": code, "error_description": description})
+}
+
+// expiresAt returns the time a number of seconds from now.
+func expiresAt(seconds int) time.Time {
+	return time.Now().Add(time.Duration(seconds) * time.Second)
+}
@@ -0,0 +1,284 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package oidcprovider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/coreos/go-oidc"
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/rgrpc/status"
+	"github.com/rs/zerolog"
+	"golang.org/x/oauth2"
+	"google.golang.org/grpc"
+)
+
+const redirectURI = "http://localhost:8300/oidc-callback.html"
+
+// testGateway serves the gateway calls of the provider, knowing a single user.
+type testGateway struct {
+	gateway.UnimplementedGatewayAPIServer
+
+	mu      sync.Mutex
+	user    *userpb.User
+	removed bool
+}
+
+func (g *testGateway) Authenticate(ctx context.Context, req *gateway.AuthenticateRequest) (*gateway.AuthenticateResponse, error) {
+	if req.Type != "basic" || req.ClientId != "einstein" || req.ClientSecret != "relativity" {
+		return &gateway.AuthenticateResponse{Status: status.NewUnauthenticated(ctx, nil, "invalid credentials")}, nil
+	}
+	return &gateway.AuthenticateResponse{Status: status.NewOK(ctx), User: g.user}, nil
+}
+
+func (g *testGateway) GetUser(ctx context.Context, req *userpb.GetUserRequest) (*userpb.GetUserResponse, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.removed || req.UserId.GetOpaqueId() != g.user.Id.OpaqueId {
+		return &userpb.GetUserResponse{Status: status.NewNotFound(ctx, "user not found")}, nil
+	}
+	return &userpb.GetUserResponse{Status: status.NewOK(ctx), User: g.user}, nil
+}
+
+func (g *testGateway) remove() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.removed = true
+}
+
+func newTestProvider(t *testing.T) (*httptest.Server, *testGateway) {
+	gw := &testGateway{user: &userpb.User{
+		Id:          &userpb.UserId{Idp: "localhost", OpaqueId: "4c510ada", Type: userpb.UserType_USER_TYPE_PRIMARY},
+		Username:    "einstein",
+		Mail:        "einstein@example.org",
+		DisplayName: "Albert Einstein",
+		Groups:      []string{"sailing-lovers"},
+		UidNumber:   123,
+	}}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gs := grpc.NewServer()
+	gateway.RegisterGatewayAPIServer(gs, gw)
+	go func() { _ = gs.Serve(lis) }()
+	t.Cleanup(gs.Stop)
+
+	var handler http.Handler
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r)
+	}))
+
+	log := zerolog.Nop()
+	s, err := New(map[string]interface{}{
+		"issuer":     srv.URL + "/oauth2",
+		"gatewaysvc": lis.Addr().String(),
+		"clients": map[string]interface{}{
+			"web": map[string]interface{}{
+				"id":            "web",
+				"redirect_uris": []string{redirectURI},
+				"public":        true,
+			},
+			"desktop": map[string]interface{}{
+				"id":            "desktop",
+				"client_secret": "secret",
+				"grant_types":   []string{"password"},
+			},
+		},
+	}, &log)
+	if err != nil {
+		t.Fatalf("error creating service: %v", err)
+	}
+	handler = http.StripPrefix("/oauth2", s.Handler())
+	return srv, gw
+}
+
+func TestConfidentialClientWithoutSecret(t *testing.T) {
+	log := zerolog.Nop()
+	_, err := New(map[string]interface{}{
+		"issuer": "https://localhost/oauth2",
+		"clients": map[string]interface{}{
+			"desktop": map[string]interface{}{"id": "desktop"},
+		},
+	}, &log)
+	if err == nil {
+		t.Fatalf("expected confidential clients without a secret to be refused")
+	}
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	srv, gw := newTestProvider(t)
+	defer srv.Close()
+	ctx := context.Background()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+
+	provider, err := oidc.NewProvider(ctx, srv.URL+"/oauth2")
+	if err != nil {
+		t.Fatalf("error discovering provider: %v", err)
+	}
+
+	verifier := "a-very-long-code-verifier-for-the-pkce-challenge"
+	sum := sha256.Sum256([]byte(verifier))
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {"web"},
+		"redirect_uri":          {redirectURI},
+		"scope":                 {"openid profile email offline_access"},
+		"state":                 {"the-state"},
+		"nonce":                 {"the-nonce"},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(sum[:])},
+		"code_challenge_method": {"S256"},
+	}
+
+	// the login form is shown
+	res, err := client.Get(provider.Endpoint().AuthURL + "?" + params.Encode())
+	if err != nil || res.StatusCode != http.StatusOK {
+		t.Fatalf("error getting the login form: %v %v", res, err)
+	}
+	res.Body.Close()
+
+	// wrong credentials show the form again
+	params.Set("username", "einstein")
+	params.Set("password", "wrong")
+	res, err = client.PostForm(provider.Endpoint().AuthURL, params)
+	if err != nil || res.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected the login to fail: %v %v", res, err)
+	}
+	res.Body.Close()
+
+	params.Set("password", "relativity")
+	res, err = client.PostForm(provider.Endpoint().AuthURL, params)
+	if err != nil || res.StatusCode != http.StatusFound {
+		t.Fatalf("expected a redirect: %v %v", res, err)
+	}
+	res.Body.Close()
+	location, _ := url.Parse(res.Header.Get("Location"))
+	if !strings.HasPrefix(location.String(), redirectURI) || location.Query().Get("state") != "the-state" {
+		t.Fatalf("unexpected redirect: %s", location)
+	}
+
+	conf := &oauth2.Config{ClientID: "web", Endpoint: provider.Endpoint(), RedirectURL: redirectURI}
+	if _, err := conf.Exchange(ctx, location.Query().Get("code"), oauth2.SetAuthURLParam("code_verifier", "wrong")); err == nil {
+		t.Fatalf("expected the exchange with a wrong verifier to fail")
+	}
+
+	// the code has been consumed by the failed attempt
+	if _, err := conf.Exchange(ctx, location.Query().Get("code"), oauth2.SetAuthURLParam("code_verifier", verifier)); err == nil {
+		t.Fatalf("expected the code to be usable only once")
+	}
+
+	res, _ = client.PostForm(provider.Endpoint().AuthURL, params)
+	res.Body.Close()
+	location, _ = url.Parse(res.Header.Get("Location"))
+	token, err := conf.Exchange(ctx, location.Query().Get("code"), oauth2.SetAuthURLParam("code_verifier", verifier))
+	if err != nil {
+		t.Fatalf("error exchanging the code: %v", err)
+	}
+
+	// the ID token is signed with the published keys
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: "web"}).Verify(ctx, token.Extra("id_token").(string))
+	if err != nil {
+		t.Fatalf("error verifying the id token: %v", err)
+	}
+	if idToken.Nonce != "the-nonce" || idToken.Subject != "4c510ada" {
+		t.Fatalf("unexpected id token: %+v", idToken)
+	}
+
+	// the user info is what the oidc auth manager of reva reads
+	info, err := provider.UserInfo(ctx, oauth2.StaticTokenSource(token))
+	if err != nil {
+		t.Fatalf("error getting the user info: %v", err)
+	}
+	claims := map[string]interface{}{}
+	_ = info.Claims(&claims)
+	if claims["preferred_username"] != "einstein" || info.Email != "einstein@example.org" || claims["uid"] != float64(123) {
+		t.Fatalf("unexpected user info: %v", claims)
+	}
+	if _, err := provider.UserInfo(ctx, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token.Extra("id_token").(string)})); err == nil {
+		t.Fatalf("expected the id token to be refused as access token")
+	}
+
+	// refresh tokens are rotated
+	refreshed, err := conf.TokenSource(ctx, &oauth2.Token{RefreshToken: token.RefreshToken}).Token()
+	if err != nil || refreshed.AccessToken == "" || refreshed.RefreshToken == token.RefreshToken {
+		t.Fatalf("error refreshing the token: %v", err)
+	}
+	if _, err := conf.TokenSource(ctx, &oauth2.Token{RefreshToken: token.RefreshToken}).Token(); err == nil {
+		t.Fatalf("expected a used refresh token to be refused")
+	}
+
+	// removed users cannot refresh their tokens
+	gw.remove()
+	if _, err := conf.TokenSource(ctx, &oauth2.Token{RefreshToken: refreshed.RefreshToken}).Token(); err == nil {
+		t.Fatalf("expected the refresh token of a removed user to be refused")
+	}
+}
+
+func TestPasswordGrant(t *testing.T) {
+	srv, _ := newTestProvider(t)
+	defer srv.Close()
+
+	conf := &oauth2.Config{
+		ClientID:     "desktop",
+		ClientSecret: "secret",
+		Endpoint:     oauth2.Endpoint{TokenURL: srv.URL + "/oauth2/token"},
+		Scopes:       []string{"openid", "profile"},
+	}
+	token, err := conf.PasswordCredentialsToken(context.Background(), "einstein", "relativity")
+	if err != nil {
+		t.Fatalf("error getting a token: %v", err)
+	}
+	if token.RefreshToken != "" {
+		t.Fatalf("no refresh token expected")
+	}
+
+	if _, err := conf.PasswordCredentialsToken(context.Background(), "einstein", "wrong"); err == nil {
+		t.Fatalf("expected an error for wrong credentials")
+	}
+
+	conf.ClientSecret = "wrong"
+	if _, err := conf.PasswordCredentialsToken(context.Background(), "einstein", "relativity"); err == nil {
+		t.Fatalf("expected an error for a wrong client secret")
+	}
+
+	// the web client may not use the password grant
+	res, err := http.PostForm(srv.URL+"/oauth2/token", url.Values{
+		"grant_type": {"password"}, "client_id": {"web"}, "username": {"einstein"}, "password": {"relativity"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body := map[string]string{}
+	_ = json.NewDecoder(res.Body).Decode(&body)
+	if res.StatusCode != http.StatusBadRequest || body["error"] != "unauthorized_client" {
+		t.Fatalf("unexpected response: %d %v", res.StatusCode, body)
+	}
+}
@@ -0,0 +1,296 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package oidcprovider
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"time"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/golang-jwt/jwt"
+)
+
+// accessTokenType is the type of the JWT access tokens (RFC 9068), which tells them apart from ID tokens.
+const accessTokenType = "at+jwt"
+
+// authenticateClient checks the credentials of the client, sent either with HTTP basic auth or in the form.
+func (s *svc) authenticateClient(r *http.Request) *clientConfig {
+	id, secret, ok := r.BasicAuth()
+	if !ok {
+		id, secret = r.FormValue("client_id"), r.FormValue("client_secret")
+	}
+	client := s.getClient(id)
+	if client == nil {
+		return nil
+	}
+	if !client.Public && subtle.ConstantTimeCompare([]byte(secret), []byte(client.Secret)) != 1 {
+		return nil
+	}
+	return client
+}
+
+func (s *svc) handleToken(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	client := s.authenticateClient(r)
+	if client == nil {
+		writeError(w, r, http.StatusUnauthorized, "invalid_client", "client authentication failed")
+		return
+	}
+	grantType := r.FormValue("grant_type")
+	if !contains(client.GrantTypes, grantType) {
+		writeError(w, r, http.StatusBadRequest, "unauthorized_client", "the client may not use this grant type")
+		return
+	}
+
+	var g *grant
+	switch grantType {
+	case "authorization_code":
+		g = s.take(s.codes, r.FormValue("code"))
+		if g == nil || g.clientID != client.ID || g.redirectURI != r.FormValue("redirect_uri") {
+			writeError(w, r, http.StatusBadRequest, "invalid_grant", "invalid authorization code")
+			return
+		}
+		if !verifyPKCE(g, r.FormValue("code_verifier")) {
+			writeError(w, r, http.StatusBadRequest, "invalid_grant", "invalid code verifier")
+			return
+		}
+	case "refresh_token":
+		// refresh tokens are rotated: each can only be used once
+		g = s.take(s.refreshTokens, r.FormValue("refresh_token"))
+		if g == nil || g.clientID != client.ID {
+			writeError(w, r, http.StatusBadRequest, "invalid_grant", "invalid refresh token")
+			return
+		}
+		// the user may have been removed since the token was issued
+		u, err := s.getUser(ctx, g.user.Id)
+		switch err.(type) {
+		case nil:
+		case errtypes.IsNotFound:
+			appctx.GetLogger(ctx).Warn().Interface("user", g.user.Id).Msg("oidcprovider: refresh token of unknown user")
+			writeError(w, r, http.StatusBadRequest, "invalid_grant", "invalid refresh token")
+			return
+		default:
+			appctx.GetLogger(ctx).Error().Err(err).Msg("oidcprovider: error getting user")
+			writeError(w, r, http.StatusInternalServerError, "server_error", "error getting the user")
+			return
+		}
+		g.user = u
+		g.nonce = ""
+	case "password":
+		username := r.FormValue("username")
+		u, err := s.authenticate(ctx, username, r.FormValue("password"))
+		if err != nil {
+			appctx.GetLogger(ctx).Warn().Err(err).Str("username", username).Msg("oidcprovider: login failed")
+			writeError(w, r, http.StatusBadRequest, "invalid_grant", "invalid username or password")
+			return
+		}
+		scopes := []string{}
+		for _, scope := range strings.Fields(r.FormValue("scope")) {
+			if contains(client.Scopes, scope) {
+				scopes = append(scopes, scope)
+			}
+		}
+		g = &grant{clientID: client.ID, user: u, scopes: scopes}
+	default:
+		writeError(w, r, http.StatusBadRequest, "unsupported_grant_type", "unsupported grant type")
+		return
+	}
+
+	s.issueTokens(w, r, client, g)
+}
+
+func verifyPKCE(g *grant, verifier string) bool {
+	if g.challenge == "" {
+		return true
+	}
+	if g.challengeMethod == "S256" {
+		sum := sha256.Sum256([]byte(verifier))
+		verifier = base64.RawURLEncoding.EncodeToString(sum[:])
+	}
+	return subtle.ConstantTimeCompare([]byte(verifier), []byte(g.challenge)) == 1
+}
+
+func (s *svc) issueTokens(w http.ResponseWriter, r *http.Request, client *clientConfig, g *grant) {
+	now := time.Now()
+	exp := now.Add(time.Duration(s.conf.AccessTokenExpiration) * time.Second)
+	claims := userClaims(g.user, g.scopes)
+
+	accessClaims := jwt.MapClaims{
+		"iss":       s.conf.Issuer,
+		"sub":       g.user.Id.GetOpaqueId(),
+		"aud":       client.ID,
+		"client_id": client.ID,
+		"scope":     strings.Join(g.scopes, " "),
+		"iat":       now.Unix(),
+		"exp":       exp.Unix(),
+	}
+	for k, v := range claims {
+		accessClaims[k] = v
+	}
+	accessToken, err := s.sign(accessClaims, accessTokenType)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "server_error", "error signing the access token")
+		return
+	}
+
+	res := map[string]interface{}{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   s.conf.AccessTokenExpiration,
+		"scope":        strings.Join(g.scopes, " "),
+	}
+
+	if contains(g.scopes, "openid") {
+		idClaims := jwt.MapClaims{
+			"iss": s.conf.Issuer,
+			"sub": g.user.Id.GetOpaqueId(),
+			"aud": client.ID,
+			"iat": now.Unix(),
+			"exp": exp.Unix(),
+		}
+		if g.nonce != "" {
+			idClaims["nonce"] = g.nonce
+		}
+		for k, v := range claims {
+			idClaims[k] = v
+		}
+		idToken, err := s.sign(idClaims, "JWT")
+		if err != nil {
+			writeError(w, r, http.StatusInternalServerError, "server_error", "error signing the id token")
+			return
+		}
+		res["id_token"] = idToken
+	}
+
+	if (contains(g.scopes, "offline_access") || contains(g.scopes, "offline")) && contains(client.GrantTypes, "refresh_token") {
+		refreshToken, err := randomString()
+		if err != nil {
+			writeError(w, r, http.StatusInternalServerError, "server_error", "error generating the refresh token")
+			return
+		}
+		s.store(s.refreshTokens, refreshToken, &grant{
+			clientID: client.ID,
+			user:     g.user,
+			scopes:   g.scopes,
+			expires:  expiresAt(s.conf.RefreshTokenExpiration),
+		})
+		res["refresh_token"] = refreshToken
+	}
+
+	writeJSON(w, r, http.StatusOK, res)
+}
+
+// userClaims returns the claims describing a user, depending on the granted scopes.
+func userClaims(u *userpb.User, scopes []string) map[string]interface{} {
+	claims := map[string]interface{}{}
+	if contains(scopes, "profile") {
+		claims["preferred_username"] = u.Username
+		claims["name"] = u.DisplayName
+		claims["groups"] = u.Groups
+		if u.UidNumber != 0 {
+			claims["uid"] = u.UidNumber
+		}
+		if u.GidNumber != 0 {
+			claims["gid"] = u.GidNumber
+		}
+	}
+	if contains(scopes, "email") && u.Mail != "" {
+		claims["email"] = u.Mail
+		claims["email_verified"] = true
+	}
+	return claims
+}
+
+func (s *svc) sign(claims jwt.MapClaims, typ string) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = s.keyID
+	token.Header["typ"] = typ
+	return token.SignedString(s.key)
+}
+
+// handleUserInfo returns the claims of the user an access token was issued to.
+func (s *svc) handleUserInfo(w http.ResponseWriter, r *http.Request) {
+	raw := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if raw == "" {
+		raw = r.FormValue("access_token")
+	}
+
+	token, err := jwt.Parse(raw, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return &s.key.PublicKey, nil
+	})
+	if err != nil || !token.Valid || token.Header["typ"] != accessTokenType {
+		writeError(w, r, http.StatusUnauthorized, "invalid_token", "invalid access token")
+		return
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !claims.VerifyIssuer(s.conf.Issuer, true) {
+		writeError(w, r, http.StatusUnauthorized, "invalid_token", "invalid access token")
+		return
+	}
+
+	info := map[string]interface{}{}
+	for k, v := range claims {
+		switch k {
+		case "iss", "aud", "client_id", "scope", "iat", "exp":
+		default:
+			info[k] = v
+		}
+	}
+	writeJSON(w, r, http.StatusOK, info)
+}
+
+// store saves a grant, dropping the expired ones.
+func (s *svc) store(grants map[string]*grant, key string, g *grant) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for k, e := range grants {
+		if now.After(e.expires) {
+			delete(grants, k)
+		}
+	}
+	grants[key] = g
+}
+
+// take returns and removes a grant, unless it has expired.
+func (s *svc) take(grants map[string]*grant, key string) *grant {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, ok := grants[key]
+	if !ok {
+		return nil
+	}
+	delete(grants, key)
+	if time.Now().After(g.expires) {
+		return nil
+	}
+	return g
+}
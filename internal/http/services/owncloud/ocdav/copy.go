@@ -131,7 +131,7 @@ func (s *svc) executePathCopy(ctx context.Context, client gateway.GatewayAPIClie
 		if createRes.Status.Code != rpc.Code_CODE_OK {
 			if createRes.Status.Code == rpc.Code_CODE_PERMISSION_DENIED {
 				w.WriteHeader(http.StatusForbidden)
-				m := fmt.Sprintf("Permission denied to create %v", createReq.Ref.Path)
+				m := permissionDeniedMessage(createRes.Status, fmt.Sprintf("Permission denied to create %v", createReq.Ref.Path))
 				b, err := Marshal(exception{
 					code:    SabredavPermissionDenied,
 					message: m,
@@ -215,7 +215,7 @@ func (s *svc) executePathCopy(ctx context.Context, client gateway.GatewayAPIClie
 		if uRes.Status.Code != rpc.Code_CODE_OK {
 			if uRes.Status.Code == rpc.Code_CODE_PERMISSION_DENIED {
 				w.WriteHeader(http.StatusForbidden)
-				m := fmt.Sprintf("Permissions denied to create %v", uReq.Ref.Path)
+				m := permissionDeniedMessage(uRes.Status, fmt.Sprintf("Permissions denied to create %v", uReq.Ref.Path))
 				b, err := Marshal(exception{
 					code:    SabredavPermissionDenied,
 					message: m,
@@ -223,7 +223,7 @@ func (s *svc) executePathCopy(ctx context.Context, client gateway.GatewayAPIClie
 				HandleWebdavError(log, w, b, err)
 				return nil
 			}
-			HandleErrorStatus(log, w, uRes.Status)
+			HandleErrorResponse(log, w, uRes)
 			return nil
 		}
 
@@ -353,7 +353,7 @@ func (s *svc) executeSpacesCopy(ctx context.Context, w http.ResponseWriter, clie
 			if createRes.Status.Code == rpc.Code_CODE_PERMISSION_DENIED {
 				w.WriteHeader(http.StatusForbidden)
 				// TODO path could be empty or relative...
-				m := fmt.Sprintf("Permission denied to create %v", createReq.Ref.Path)
+				m := permissionDeniedMessage(createRes.Status, fmt.Sprintf("Permission denied to create %v", createReq.Ref.Path))
 				b, err := Marshal(exception{
 					code:    SabredavPermissionDenied,
 					message: m,
@@ -432,7 +432,7 @@ func (s *svc) executeSpacesCopy(ctx context.Context, w http.ResponseWriter, clie
 			if uRes.Status.Code == rpc.Code_CODE_PERMISSION_DENIED {
 				w.WriteHeader(http.StatusForbidden)
 				// TODO path can be empty or relative
-				m := fmt.Sprintf("Permissions denied to create %v", uReq.Ref.Path)
+				m := permissionDeniedMessage(uRes.Status, fmt.Sprintf("Permissions denied to create %v", uReq.Ref.Path))
 				b, err := Marshal(exception{
 					code:    SabredavPermissionDenied,
 					message: m,
@@ -440,7 +440,7 @@ func (s *svc) executeSpacesCopy(ctx context.Context, w http.ResponseWriter, clie
 				HandleWebdavError(log, w, b, err)
 				return nil
 			}
-			HandleErrorStatus(log, w, uRes.Status)
+			HandleErrorResponse(log, w, uRes)
 			return nil
 		}
 
@@ -582,7 +582,7 @@ func (s *svc) prepareCopy(ctx context.Context, w http.ResponseWriter, r *http.Re
 		}
 
 		if delRes.Status.Code != rpc.Code_CODE_OK && delRes.Status.Code != rpc.Code_CODE_NOT_FOUND {
-			HandleErrorStatus(log, w, delRes.Status)
+			HandleErrorResponse(log, w, delRes)
 			return nil
 		}
 	} else {
@@ -71,7 +71,7 @@ func (s *svc) handleDelete(ctx context.Context, w http.ResponseWriter, r *http.R
 		if res.Status.Code == rpc.Code_CODE_PERMISSION_DENIED {
 			w.WriteHeader(http.StatusForbidden)
 			// TODO path might be empty or relative...
-			m := fmt.Sprintf("Permission denied to delete %v", ref.Path)
+			m := permissionDeniedMessage(res.Status, fmt.Sprintf("Permission denied to delete %v", ref.Path))
 			b, err := Marshal(exception{
 				code:    SabredavPermissionDenied,
 				message: m,
@@ -87,7 +87,7 @@ func (s *svc) handleDelete(ctx context.Context, w http.ResponseWriter, r *http.R
 			HandleWebdavError(&log, w, b, err)
 		}
 
-		HandleErrorStatus(&log, w, res.Status)
+		HandleErrorResponse(&log, w, res)
 		return
 	}
 
@@ -21,8 +21,13 @@ package ocdav
 import (
 	"encoding/xml"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/cs3org/reva/pkg/storage/maintenance"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 )
@@ -94,6 +99,40 @@ type errorXML struct {
 
 var errInvalidPropfind = errors.New("webdav: invalid propfind")
 
+// readOnlyMarker is contained in the status message of
+// operations refused because the target is read-only.
+const readOnlyMarker = "read-only"
+
+// permissionDeniedMessage returns the message to send to the client
+// for a PERMISSION_DENIED status. The reason given by a read-only
+// storage is passed through, so that users know why they cannot
+// write, otherwise the default message is used.
+func permissionDeniedMessage(s *rpc.Status, def string) string {
+	if strings.Contains(s.Message, readOnlyMarker) {
+		return s.Message
+	}
+	return def
+}
+
+// defaultRetryAfter is advertised to the clients when the
+// UNAVAILABLE status does not come with a retry hint.
+const defaultRetryAfter = time.Minute
+
+// errorResponse is implemented by the CS3 responses.
+type errorResponse interface {
+	GetStatus() *rpc.Status
+	GetOpaque() *types.Opaque
+}
+
+// HandleErrorResponse works like HandleErrorStatus, additionally telling the
+// clients when to retry a write refused because the storage is under maintenance.
+func HandleErrorResponse(log *zerolog.Logger, w http.ResponseWriter, res errorResponse) {
+	if d, ok := maintenance.RetryAfter(res.GetOpaque()); ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(d/time.Second)))
+	}
+	HandleErrorStatus(log, w, res.GetStatus())
+}
+
 // HandleErrorStatus checks the status code, logs a Debug or Error level message
 // and writes an appropriate http status.
 func HandleErrorStatus(log *zerolog.Logger, w http.ResponseWriter, s *rpc.Status) {
@@ -122,6 +161,12 @@ func HandleErrorStatus(log *zerolog.Logger, w http.ResponseWriter, s *rpc.Status
 	case rpc.Code_CODE_FAILED_PRECONDITION:
 		log.Debug().Interface("status", s).Msg("destination does not exist")
 		w.WriteHeader(http.StatusConflict)
+	case rpc.Code_CODE_UNAVAILABLE:
+		log.Debug().Interface("status", s).Msg("service unavailable")
+		if w.Header().Get("Retry-After") == "" {
+			w.Header().Set("Retry-After", strconv.Itoa(int(defaultRetryAfter/time.Second)))
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
 	default:
 		log.Error().Interface("status", s).Msg("grpc request failed")
 		w.WriteHeader(http.StatusInternalServerError)
@@ -162,13 +162,13 @@ func (s *svc) handleMkcol(ctx context.Context, w http.ResponseWriter, r *http.Re
 	case rpc.Code_CODE_PERMISSION_DENIED:
 		w.WriteHeader(http.StatusForbidden)
 		// TODO path could be empty or relative...
-		m := fmt.Sprintf("Permission denied to create %v", childRef.Path)
+		m := permissionDeniedMessage(res.Status, fmt.Sprintf("Permission denied to create %v", childRef.Path))
 		b, err := Marshal(exception{
 			code:    SabredavPermissionDenied,
 			message: m,
 		})
 		HandleWebdavError(&log, w, b, err)
 	default:
-		HandleErrorStatus(&log, w, res.Status)
+		HandleErrorResponse(&log, w, res)
 	}
 }
@@ -188,7 +188,7 @@ func (s *svc) handleMove(ctx context.Context, w http.ResponseWriter, r *http.Req
 		}
 
 		if delRes.Status.Code != rpc.Code_CODE_OK && delRes.Status.Code != rpc.Code_CODE_NOT_FOUND {
-			HandleErrorStatus(&log, w, delRes.Status)
+			HandleErrorResponse(&log, w, delRes)
 			return
 		}
 	} else {
@@ -234,14 +234,14 @@ func (s *svc) handleMove(ctx context.Context, w http.ResponseWriter, r *http.Req
 	if mRes.Status.Code != rpc.Code_CODE_OK {
 		if mRes.Status.Code == rpc.Code_CODE_PERMISSION_DENIED {
 			w.WriteHeader(http.StatusForbidden)
-			m := fmt.Sprintf("Permission denied to move %v", src.Path)
+			m := permissionDeniedMessage(mRes.Status, fmt.Sprintf("Permission denied to move %v", src.Path))
 			b, err := Marshal(exception{
 				code:    SabredavPermissionDenied,
 				message: m,
 			})
 			HandleWebdavError(&log, w, b, err)
 		}
-		HandleErrorStatus(&log, w, mRes.Status)
+		HandleErrorResponse(&log, w, mRes)
 		return
 	}
 
@@ -25,8 +25,11 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
 	providerv1beta1 "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/storage/maintenance"
 	"github.com/cs3org/reva/pkg/utils/resourceid"
+	"github.com/rs/zerolog"
 )
 
 /*
@@ -159,3 +162,21 @@ func TestNameDoesNotContainRule(t *testing.T) {
 		}
 	}
 }
+
+func TestHandleErrorResponseUnavailable(t *testing.T) {
+	log := zerolog.Nop()
+	st := &rpc.Status{Code: rpc.Code_CODE_UNAVAILABLE, Message: "storage provider eoshome is under maintenance"}
+	tests := map[int64]string{
+		3600: "3600",
+		90:   "90",
+		0:    "60",
+	}
+	for retryAfter, expected := range tests {
+		err := &maintenance.Error{Status: &maintenance.Status{Provider: "eoshome", RetryAfter: retryAfter}}
+		w := httptest.NewRecorder()
+		HandleErrorResponse(&log, w, &providerv1beta1.MoveResponse{Status: st, Opaque: maintenance.Opaque(err)})
+		if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") != expected {
+			t.Errorf("%d: expected 503 with Retry-After %s, got %d with %q", retryAfter, expected, w.Code, w.Header().Get("Retry-After"))
+		}
+	}
+}
@@ -230,7 +230,7 @@ func (s *svc) handleProppatch(ctx context.Context, w http.ResponseWriter, r *htt
 						HandleWebdavError(&log, w, b, err)
 						return nil, nil, false
 					}
-					HandleErrorStatus(&log, w, res.Status)
+					HandleErrorResponse(&log, w, res)
 					return nil, nil, false
 				}
 				if key == "http://owncloud.org/ns/favorite" {
@@ -267,7 +267,7 @@ func (s *svc) handleProppatch(ctx context.Context, w http.ResponseWriter, r *htt
 						HandleWebdavError(&log, w, b, err)
 						return nil, nil, false
 					}
-					HandleErrorStatus(&log, w, res.Status)
+					HandleErrorResponse(&log, w, res)
 					return nil, nil, false
 				}
 
@@ -248,13 +248,13 @@ func (s *svc) handlePut(ctx context.Context, w http.ResponseWriter, r *http.Requ
 			w.WriteHeader(http.StatusForbidden)
 			b, err := Marshal(exception{
 				code:    SabredavPermissionDenied,
-				message: "permission denied: you have no permission to upload content",
+				message: permissionDeniedMessage(uRes.Status, "permission denied: you have no permission to upload content"),
 			})
 			HandleWebdavError(&log, w, b, err)
 		case rpc.Code_CODE_NOT_FOUND:
 			w.WriteHeader(http.StatusConflict)
 		default:
-			HandleErrorStatus(&log, w, uRes.Status)
+			HandleErrorResponse(&log, w, uRes)
 		}
 		return
 	}
@@ -528,7 +528,7 @@ func (h *TrashbinHandler) restore(w http.ResponseWriter, r *http.Request, s *svc
 		}
 
 		if delRes.Status.Code != rpc.Code_CODE_OK && delRes.Status.Code != rpc.Code_CODE_NOT_FOUND {
-			HandleErrorStatus(&sublog, w, delRes.Status)
+			HandleErrorResponse(&sublog, w, delRes)
 			return
 		}
 	}
@@ -561,7 +561,7 @@ func (h *TrashbinHandler) restore(w http.ResponseWriter, r *http.Request, s *svc
 			})
 			HandleWebdavError(&sublog, w, b, err)
 		}
-		HandleErrorStatus(&sublog, w, res.Status)
+		HandleErrorResponse(&sublog, w, res)
 		return
 	}
 
@@ -641,7 +641,7 @@ func (h *TrashbinHandler) delete(w http.ResponseWriter, r *http.Request, s *svc,
 		})
 		HandleWebdavError(&sublog, w, b, err)
 	default:
-		HandleErrorStatus(&sublog, w, res.Status)
+		HandleErrorResponse(&sublog, w, res)
 	}
 }
 
@@ -191,7 +191,7 @@ func (s *svc) handleTusPost(ctx context.Context, w http.ResponseWriter, r *http.
 			w.WriteHeader(http.StatusPreconditionFailed)
 			return
 		}
-		HandleErrorStatus(&log, w, uRes.Status)
+		HandleErrorResponse(&log, w, uRes)
 		return
 	}
 
@@ -214,7 +214,7 @@ func (h *VersionsHandler) doRestore(w http.ResponseWriter, r *http.Request, s *s
 		return
 	}
 	if res.Status.Code != rpc.Code_CODE_OK {
-		HandleErrorStatus(&sublog, w, res.Status)
+		HandleErrorResponse(&sublog, w, res)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -19,8 +19,11 @@
 package config
 
 import (
+	"strings"
+
 	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/data"
 	"github.com/cs3org/reva/pkg/sharedconf"
+	"github.com/cs3org/reva/pkg/smtpclient"
 )
 
 // Config holds the config options that need to be passed down to all ocs handlers.
@@ -43,6 +46,14 @@ type Config struct {
 	ResourceInfoCacheDrivers map[string]map[string]interface{} `mapstructure:"resource_info_caches"`
 	UserIdentifierCacheTTL   int                               `mapstructure:"user_identifier_cache_ttl"`
 	AllowedLanguages         []string                          `mapstructure:"allowed_languages"`
+	// GuestInvitations enables sharing with email addresses unknown to the user provider,
+	// creating a guest account for them.
+	GuestInvitations bool                              `mapstructure:"guest_invitations"`
+	GuestManager     string                            `mapstructure:"guest_manager"`
+	GuestManagers    map[string]map[string]interface{} `mapstructure:"guest_managers"`
+	// GuestActivationURL is the page of the guests service where the invited guests set their password.
+	GuestActivationURL string                      `mapstructure:"guest_activation_url"`
+	SMTPCredentials    *smtpclient.SMTPCredentials `mapstructure:"smtp_credentials"`
 }
 
 // Init sets sane defaults.
@@ -71,5 +82,13 @@ func (c *Config) Init() {
 		c.UserIdentifierCacheTTL = 60
 	}
 
+	if c.GuestManager == "" {
+		c.GuestManager = "json"
+	}
+
+	if c.GuestActivationURL == "" {
+		c.GuestActivationURL = strings.TrimSuffix(c.Config.Host, "/") + "/guests/activate"
+	}
+
 	c.GatewaySvc = sharedconf.GetGatewaySVC(c.GatewaySvc)
 }
@@ -0,0 +1,78 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package shares
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"path"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/config"
+	"github.com/cs3org/reva/pkg/appctx"
+	ctxpkg "github.com/cs3org/reva/pkg/ctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/guest"
+	guestregistry "github.com/cs3org/reva/pkg/guest/manager/registry"
+)
+
+func getGuestManager(c *config.Config) (guest.Manager, error) {
+	if f, ok := guestregistry.NewFuncs[c.GuestManager]; ok {
+		return f(c.GuestManagers[c.GuestManager])
+	}
+	return nil, fmt.Errorf("driver not found: %s", c.GuestManager)
+}
+
+// isMailAddress returns true if s is a bare email address.
+func isMailAddress(s string) bool {
+	a, err := mail.ParseAddress(s)
+	return err == nil && a.Address == s
+}
+
+// inviteGuest returns the guest account of the email address, creating it
+// if needed. Pending accounts also get a new activation token.
+func (h *Handler) inviteGuest(ctx context.Context, address string) (*userpb.User, string, error) {
+	u := ctxpkg.ContextMustGetUser(ctx)
+	if u.Id.Type != userpb.UserType_USER_TYPE_PRIMARY {
+		return nil, "", errtypes.PermissionDenied("only primary accounts can invite guests")
+	}
+	return h.guests.Invite(ctx, address, u.Id)
+}
+
+// notifyGuest notifies the guest of the share by mail, including the
+// activation link if a token has been issued. It is called once the share
+// has been created, so failures are only logged: inviting the guest again
+// sends a new link.
+func (h *Handler) notifyGuest(ctx context.Context, g *userpb.User, token string, statInfo *provider.ResourceInfo) {
+	log := appctx.GetLogger(ctx)
+	if h.smtpCredentials == nil {
+		if token != "" {
+			log.Warn().Str("mail", g.Mail).Msg("smtp not configured, the guest cannot receive the activation link")
+		}
+		return
+	}
+
+	u := ctxpkg.ContextMustGetUser(ctx)
+	subject, body := guest.Mail(u, g, path.Base(statInfo.Path), h.guestActivationURL, token)
+	if err := h.smtpCredentials.SendMail(g.Mail, subject, body); err != nil {
+		log.Error().Err(err).Str("mail", g.Mail).Msg("error sending the invitation to the guest")
+	}
+}
@@ -45,12 +45,14 @@ import (
 	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/conversions"
 	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/response"
 	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/guest"
 	"github.com/cs3org/reva/pkg/publicshare"
 	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
 	"github.com/cs3org/reva/pkg/share"
 	"github.com/cs3org/reva/pkg/share/cache"
 	cachereg "github.com/cs3org/reva/pkg/share/cache/registry"
 	warmupreg "github.com/cs3org/reva/pkg/share/cache/warmup/registry"
+	"github.com/cs3org/reva/pkg/smtpclient"
 	"github.com/cs3org/reva/pkg/utils"
 	"github.com/cs3org/reva/pkg/utils/resourceid"
 	"github.com/go-chi/chi/v5"
@@ -73,6 +75,9 @@ type Handler struct {
 	userIdentifierCache    *ttlcache.Cache
 	resourceInfoCache      cache.ResourceInfoCache
 	resourceInfoCacheTTL   time.Duration
+	guests                 guest.Manager
+	guestActivationURL     string
+	smtpCredentials        *smtpclient.SMTPCredentials
 }
 
 // we only cache the minimal set of data instead of the full user metadata.
@@ -97,7 +102,7 @@ func getCacheManager(c *config.Config) (cache.ResourceInfoCache, error) {
 }
 
 // Init initializes this and any contained handlers.
-func (h *Handler) Init(c *config.Config) {
+func (h *Handler) Init(c *config.Config) error {
 	h.gatewayAddr = c.GatewaySvc
 	h.storageRegistryAddr = c.StorageregistrySvc
 	h.publicURL = c.Config.Host
@@ -121,6 +126,19 @@ func (h *Handler) Init(c *config.Config) {
 			go h.startCacheWarmup(cwm)
 		}
 	}
+
+	if c.GuestInvitations {
+		guests, err := getGuestManager(c)
+		if err != nil {
+			return err
+		}
+		h.guests = guests
+		h.guestActivationURL = c.GuestActivationURL
+		if c.SMTPCredentials != nil {
+			h.smtpCredentials = smtpclient.NewSMTPCredentials(c.SMTPCredentials)
+		}
+	}
+	return nil
 }
 
 func (h *Handler) startCacheWarmup(c cache.Warmup) {
@@ -1119,33 +1137,36 @@ func (h *Handler) getResourceInfo(ctx context.Context, client gateway.GatewayAPI
 	return pinfo, status, nil
 }
 
-func (h *Handler) createCs3Share(ctx context.Context, w http.ResponseWriter, r *http.Request, client gateway.GatewayAPIClient, req *collaboration.CreateShareRequest, info *provider.ResourceInfo) {
+// createCs3Share creates the share and writes the response.
+// It returns the created share, or nil if the share could not be created.
+func (h *Handler) createCs3Share(ctx context.Context, w http.ResponseWriter, r *http.Request, client gateway.GatewayAPIClient, req *collaboration.CreateShareRequest, info *provider.ResourceInfo) *collaboration.Share {
 	createShareResponse, err := client.CreateShare(ctx, req)
 	if err != nil {
 		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "error sending a grpc create share request", err)
-		return
+		return nil
 	}
 	if createShareResponse.Status.Code != rpc.Code_CODE_OK {
 		if createShareResponse.Status.Code == rpc.Code_CODE_NOT_FOUND {
 			response.WriteOCSError(w, r, response.MetaNotFound.StatusCode, "not found", nil)
-			return
+			return nil
 		}
 		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "grpc create share request failed", err)
-		return
+		return nil
 	}
 	s, err := conversions.CS3Share2ShareData(ctx, createShareResponse.Share)
 	if err != nil {
 		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "error mapping share data", err)
-		return
+		return createShareResponse.Share
 	}
 	err = h.addFileInfo(ctx, s, info)
 	if err != nil {
 		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "error adding fileinfo to share", err)
-		return
+		return createShareResponse.Share
 	}
 	h.mapUserIds(ctx, client, s)
 
 	response.WriteOCSSuccess(w, r, s)
+	return createShareResponse.Share
 }
 
 func mapState(state collaboration.ShareState) int {
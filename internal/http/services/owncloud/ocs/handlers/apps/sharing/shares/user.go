@@ -34,6 +34,7 @@ import (
 	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/conversions"
 	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/response"
 	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/errtypes"
 	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
 )
 
@@ -61,7 +62,46 @@ func (h *Handler) createUserShare(w http.ResponseWriter, r *http.Request, statIn
 		return
 	}
 
-	if userRes.Status.Code != rpc.Code_CODE_OK {
+	// a recipient given by email address may be a known user,
+	// who must get the share instead of a new guest account
+	if userRes.Status.Code == rpc.Code_CODE_NOT_FOUND && isMailAddress(shareWith) {
+		userRes, err = c.GetUserByClaim(ctx, &userpb.GetUserByClaimRequest{
+			Claim:                  "mail",
+			Value:                  shareWith,
+			SkipFetchingUserGroups: true,
+		})
+		if err != nil {
+			response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "error searching recipient", err)
+			return
+		}
+		if code := userRes.Status.Code; code != rpc.Code_CODE_OK && code != rpc.Code_CODE_NOT_FOUND {
+			response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "error searching recipient", nil)
+			return
+		}
+	}
+
+	grantee := userRes.GetUser()
+	var invited bool
+	var token string
+	switch {
+	case userRes.Status.Code == rpc.Code_CODE_NOT_FOUND && h.guests != nil && isMailAddress(shareWith),
+		userRes.Status.Code == rpc.Code_CODE_OK && h.guests != nil && grantee.GetId().GetType() == userpb.UserType_USER_TYPE_GUEST:
+		// invite the guest, or notify an existing one of the new share
+		grantee, token, err = h.inviteGuest(ctx, shareWith)
+		switch err.(type) {
+		case nil:
+			invited = true
+		case errtypes.IsBadRequest, errtypes.IsAlreadyExists:
+			response.WriteOCSError(w, r, response.MetaBadRequest.StatusCode, err.Error(), nil)
+			return
+		case errtypes.IsPermissionDenied:
+			response.WriteOCSError(w, r, http.StatusForbidden, err.Error(), nil)
+			return
+		default:
+			response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "error inviting guest", err)
+			return
+		}
+	case userRes.Status.Code != rpc.Code_CODE_OK:
 		response.WriteOCSError(w, r, response.MetaNotFound.StatusCode, "user not found", err)
 		return
 	}
@@ -79,7 +119,7 @@ func (h *Handler) createUserShare(w http.ResponseWriter, r *http.Request, statIn
 		Grant: &collaboration.ShareGrant{
 			Grantee: &provider.Grantee{
 				Type: provider.GranteeType_GRANTEE_TYPE_USER,
-				Id:   &provider.Grantee_UserId{UserId: userRes.User.GetId()},
+				Id:   &provider.Grantee_UserId{UserId: grantee.GetId()},
 			},
 			Permissions: &collaboration.SharePermissions{
 				Permissions: role.CS3ResourcePermissions(),
@@ -87,7 +127,9 @@ func (h *Handler) createUserShare(w http.ResponseWriter, r *http.Request, statIn
 		},
 	}
 
-	h.createCs3Share(ctx, w, r, c, createShareReq, statInfo)
+	if share := h.createCs3Share(ctx, w, r, c, createShareReq, statInfo); share != nil && invited {
+		h.notifyGuest(ctx, grantee, token, statInfo)
+	}
 }
 
 func (h *Handler) isUserShare(r *http.Request, oid string) bool {
@@ -106,8 +106,8 @@ func (h *Handler) GetUsers(w http.ResponseWriter, r *http.Request) {
 
 	var total, used uint64 = 2, 1
 	var relative float32
-	// lightweight and federated accounts don't have access to their storage space
-	if u.Id.Type != userpb.UserType_USER_TYPE_LIGHTWEIGHT && u.Id.Type != userpb.UserType_USER_TYPE_FEDERATED {
+	// lightweight, federated and guest accounts don't have access to their storage space
+	if u.Id.Type != userpb.UserType_USER_TYPE_LIGHTWEIGHT && u.Id.Type != userpb.UserType_USER_TYPE_FEDERATED && u.Id.Type != userpb.UserType_USER_TYPE_GUEST {
 		getHomeRes, err := gc.GetHome(ctx, &provider.GetHomeRequest{})
 		if err != nil {
 			sublog.Error().Err(err).Msg("error calling GetHome")
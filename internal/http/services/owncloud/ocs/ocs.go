@@ -97,7 +97,9 @@ func (s *svc) routerInit() error {
 	usersHandler.Init(s.c)
 	userHandler.Init(s.c)
 	configHandler.Init(s.c)
-	sharesHandler.Init(s.c)
+	if err := sharesHandler.Init(s.c); err != nil {
+		return err
+	}
 	shareesHandler.Init(s.c)
 
 	s.router.Route("/v{version:(1|2)}.php", func(r chi.Router) {
@@ -0,0 +1,386 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// filter is a parsed SCIM filter expression (RFC 7644, section 3.4.2.2),
+// evaluated against the generic JSON representation of a resource.
+type filter interface {
+	matches(res map[string]interface{}) bool
+}
+
+type logicalFilter struct {
+	op          string
+	left, right filter
+}
+
+func (f *logicalFilter) matches(res map[string]interface{}) bool {
+	if f.op == "and" {
+		return f.left.matches(res) && f.right.matches(res)
+	}
+	return f.left.matches(res) || f.right.matches(res)
+}
+
+type notFilter struct {
+	f filter
+}
+
+func (f *notFilter) matches(res map[string]interface{}) bool {
+	return !f.f.matches(res)
+}
+
+type attributeFilter struct {
+	path  string
+	op    string
+	value interface{}
+}
+
+func (f *attributeFilter) matches(res map[string]interface{}) bool {
+	values := attributeValues(res, f.path)
+	switch f.op {
+	case "pr":
+		for _, v := range values {
+			if s, ok := v.(string); !ok || s != "" {
+				return true
+			}
+		}
+		return false
+	case "ne":
+		return !anyMatches(values, "eq", f.value)
+	default:
+		return anyMatches(values, f.op, f.value)
+	}
+}
+
+func anyMatches(values []interface{}, op string, expected interface{}) bool {
+	for _, v := range values {
+		if compare(v, op, expected) {
+			return true
+		}
+	}
+	return false
+}
+
+func compare(actual interface{}, op string, expected interface{}) bool {
+	switch e := expected.(type) {
+	case nil:
+		return op == "eq" && actual == nil
+	case bool:
+		a, ok := actual.(bool)
+		return ok && op == "eq" && a == e
+	case float64:
+		a, ok := actual.(float64)
+		if !ok {
+			return false
+		}
+		switch op {
+		case "eq":
+			return a == e
+		case "gt":
+			return a > e
+		case "ge":
+			return a >= e
+		case "lt":
+			return a < e
+		case "le":
+			return a <= e
+		}
+		return false
+	case string:
+		a, ok := actual.(string)
+		if !ok {
+			return false
+		}
+		a, e = strings.ToLower(a), strings.ToLower(e)
+		switch op {
+		case "eq":
+			return a == e
+		case "co":
+			return strings.Contains(a, e)
+		case "sw":
+			return strings.HasPrefix(a, e)
+		case "ew":
+			return strings.HasSuffix(a, e)
+		case "gt":
+			return a > e
+		case "ge":
+			return a >= e
+		case "lt":
+			return a < e
+		case "le":
+			return a <= e
+		}
+	}
+	return false
+}
+
+// splitSchema splits an attribute path into the schema URN it is qualified with, if any, and the attribute.
+func splitSchema(path string) (string, string) {
+	if !strings.HasPrefix(strings.ToLower(path), "urn:") {
+		return "", path
+	}
+	i := strings.LastIndex(path, ":")
+	return path[:i], path[i+1:]
+}
+
+func isCoreSchema(schema string) bool {
+	return strings.EqualFold(schema, schemaUser) || strings.EqualFold(schema, schemaGroup)
+}
+
+// findKey returns the key of an attribute, which are case-insensitive in SCIM.
+func findKey(m map[string]interface{}, attr string) (string, bool) {
+	if _, ok := m[attr]; ok {
+		return attr, true
+	}
+	for k := range m {
+		if strings.EqualFold(k, attr) {
+			return k, true
+		}
+	}
+	return attr, false
+}
+
+func getAttribute(m map[string]interface{}, attr string) interface{} {
+	if k, ok := findKey(m, attr); ok {
+		return m[k]
+	}
+	return nil
+}
+
+// attributeValues returns the values of an attribute path like "userName", "emails.value"
+// or "urn:ietf:params:scim:schemas:extension:reva:2.0:User:uidNumber".
+// Multi-valued attributes without a sub-attribute yield the values of their elements.
+func attributeValues(res map[string]interface{}, path string) []interface{} {
+	schema, attr := splitSchema(path)
+	obj := res
+	if schema != "" && !isCoreSchema(schema) {
+		ext, ok := getAttribute(res, schema).(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		obj = ext
+	}
+
+	parts := strings.SplitN(attr, ".", 2)
+	sub := "value"
+	if len(parts) == 2 {
+		sub = parts[1]
+	}
+
+	var values []interface{}
+	switch v := getAttribute(obj, parts[0]).(type) {
+	case nil:
+	case []interface{}:
+		for _, e := range v {
+			if m, ok := e.(map[string]interface{}); ok {
+				if sv := getAttribute(m, sub); sv != nil {
+					values = append(values, sv)
+				}
+			} else {
+				values = append(values, e)
+			}
+		}
+	case map[string]interface{}:
+		if len(parts) == 2 {
+			if sv := getAttribute(v, sub); sv != nil {
+				values = append(values, sv)
+			}
+		}
+	default:
+		values = append(values, v)
+	}
+	return values
+}
+
+// parseFilter parses a SCIM filter expression.
+func parseFilter(s string) (filter, error) {
+	tokens, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{tokens: tokens}
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.tokens) {
+		return nil, fmt.Errorf("unexpected token %q", p.tokens[p.pos])
+	}
+	return f, nil
+}
+
+func tokenize(s string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(s); {
+		switch c := s[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, string(c))
+			i++
+		case c == '"':
+			j := i + 1
+			for ; j < len(s) && s[j] != '"'; j++ {
+				if s[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(s) {
+				return nil, fmt.Errorf("unterminated string in filter")
+			}
+			tokens = append(tokens, s[i:j+1])
+			i = j + 1
+		default:
+			j := strings.IndexAny(s[i:], " \t\n\r()")
+			if j == -1 {
+				j = len(s) - i
+			}
+			tokens = append(tokens, s[i:i+j])
+			i += j
+		}
+	}
+	return tokens, nil
+}
+
+type filterParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *filterParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *filterParser) next() (string, error) {
+	if p.pos >= len(p.tokens) {
+		return "", fmt.Errorf("unexpected end of filter")
+	}
+	p.pos++
+	return p.tokens[p.pos-1], nil
+}
+
+func (p *filterParser) expect(tok string) error {
+	t, err := p.next()
+	if err != nil {
+		return err
+	}
+	if t != tok {
+		return fmt.Errorf("expected %q, got %q", tok, t)
+	}
+	return nil
+}
+
+func (p *filterParser) parseOr() (filter, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for strings.EqualFold(p.peek(), "or") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalFilter{op: "or", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (filter, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for strings.EqualFold(p.peek(), "and") {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalFilter{op: "and", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (filter, error) {
+	tok, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case strings.EqualFold(tok, "not"):
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return &notFilter{f: f}, nil
+	case tok == "(":
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return f, nil
+	case tok == ")":
+		return nil, fmt.Errorf("unexpected %q", tok)
+	}
+
+	op, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	op = strings.ToLower(op)
+	switch op {
+	case "pr":
+		return &attributeFilter{path: tok, op: op}, nil
+	case "eq", "ne", "co", "sw", "ew", "gt", "ge", "lt", "le":
+	default:
+		return nil, fmt.Errorf("unsupported operator %q", op)
+	}
+
+	raw, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(raw), &value); err != nil {
+		return nil, fmt.Errorf("invalid value %s", raw)
+	}
+	if _, ok := value.(string); !ok && op != "eq" && op != "ne" && op != "gt" && op != "ge" && op != "lt" && op != "le" {
+		return nil, fmt.Errorf("operator %q requires a string value", op)
+	}
+	return &attributeFilter{path: tok, op: op, value: value}, nil
+}
@@ -0,0 +1,207 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package scim
+
+import (
+	"reflect"
+	"testing"
+)
+
+var testUser = map[string]interface{}{
+	"id":          "4c510ada",
+	"userName":    "einstein",
+	"displayName": "Albert Einstein",
+	"emails": []interface{}{
+		map[string]interface{}{"value": "einstein@example.org", "primary": true},
+	},
+	"groups": []interface{}{
+		map[string]interface{}{"value": "sailing-lovers-id", "display": "sailing-lovers"},
+		map[string]interface{}{"value": "physics-lovers-id", "display": "physics-lovers"},
+	},
+	"urn:ietf:params:scim:schemas:extension:reva:2.0:User": map[string]interface{}{
+		"uidNumber": float64(123),
+	},
+}
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		filter  string
+		matches bool
+	}{
+		{`userName eq "einstein"`, true},
+		{`USERNAME eq "EINSTEIN"`, true},
+		{`userName eq "marie"`, false},
+		{`userName ne "marie"`, true},
+		{`displayName co "stein"`, true},
+		{`displayName sw "albert"`, true},
+		{`displayName ew "einstein"`, true},
+		{`emails eq "einstein@example.org"`, true},
+		{`emails.value ew "@example.org"`, true},
+		{`groups.display eq "physics-lovers"`, true},
+		{`groups eq "sailing-lovers-id"`, true},
+		{`title pr`, false},
+		{`emails pr`, true},
+		{`urn:ietf:params:scim:schemas:core:2.0:User:userName eq "einstein"`, true},
+		{`urn:ietf:params:scim:schemas:extension:reva:2.0:User:uidNumber gt 100`, true},
+		{`urn:ietf:params:scim:schemas:extension:reva:2.0:User:uidNumber le 100`, false},
+		{`userName eq "marie" or displayName co "albert"`, true},
+		{`userName eq "einstein" and displayName co "marie"`, false},
+		{`userName eq "einstein" and (displayName co "marie" or emails pr)`, true},
+		{`not (userName eq "einstein")`, false},
+		{`userName eq "with \"quotes\""`, false},
+	}
+
+	for _, tt := range tests {
+		f, err := parseFilter(tt.filter)
+		if err != nil {
+			t.Errorf("error parsing filter %s: %v", tt.filter, err)
+			continue
+		}
+		if got := f.matches(testUser); got != tt.matches {
+			t.Errorf("filter %s: expected %v, got %v", tt.filter, tt.matches, got)
+		}
+	}
+}
+
+func TestInvalidFilter(t *testing.T) {
+	tests := []string{
+		``,
+		`userName`,
+		`userName eq`,
+		`userName foo "x"`,
+		`userName eq "unterminated`,
+		`userName eq "x" and`,
+		`(userName eq "x"`,
+		`userName eq "x")`,
+		`displayName co 3`,
+		`userName eq unquoted`,
+	}
+
+	for _, tt := range tests {
+		if _, err := parseFilter(tt); err == nil {
+			t.Errorf("expected an error parsing filter %q", tt)
+		}
+	}
+}
+
+func TestApplyPatch(t *testing.T) {
+	group := func() map[string]interface{} {
+		return map[string]interface{}{
+			"displayName": "sailing-lovers",
+			"members": []interface{}{
+				map[string]interface{}{"value": "einstein"},
+				map[string]interface{}{"value": "marie"},
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		ops      []patchOperation
+		expected map[string]interface{}
+	}{
+		{
+			name: "add members",
+			ops: []patchOperation{{Op: "add", Path: "members", Value: []interface{}{
+				map[string]interface{}{"value": "richard"},
+				map[string]interface{}{"value": "marie"},
+			}}},
+			expected: map[string]interface{}{
+				"displayName": "sailing-lovers",
+				"members": []interface{}{
+					map[string]interface{}{"value": "einstein"},
+					map[string]interface{}{"value": "marie"},
+					map[string]interface{}{"value": "richard"},
+				},
+			},
+		},
+		{
+			name: "remove member by filter",
+			ops:  []patchOperation{{Op: "Remove", Path: `members[value eq "einstein"]`}},
+			expected: map[string]interface{}{
+				"displayName": "sailing-lovers",
+				"members": []interface{}{
+					map[string]interface{}{"value": "marie"},
+				},
+			},
+		},
+		{
+			name: "remove member by value",
+			ops:  []patchOperation{{Op: "remove", Path: "members", Value: []interface{}{map[string]interface{}{"value": "marie"}}}},
+			expected: map[string]interface{}{
+				"displayName": "sailing-lovers",
+				"members": []interface{}{
+					map[string]interface{}{"value": "einstein"},
+				},
+			},
+		},
+		{
+			name: "remove all members",
+			ops:  []patchOperation{{Op: "remove", Path: "members"}},
+			expected: map[string]interface{}{
+				"displayName": "sailing-lovers",
+			},
+		},
+		{
+			name: "replace without path",
+			ops: []patchOperation{{Op: "replace", Value: map[string]interface{}{
+				"displayName": "sailing-haters",
+				"urn:ietf:params:scim:schemas:extension:reva:2.0:Group": map[string]interface{}{"gidNumber": float64(42)},
+			}}},
+			expected: map[string]interface{}{
+				"displayName": "sailing-haters",
+				"members": []interface{}{
+					map[string]interface{}{"value": "einstein"},
+					map[string]interface{}{"value": "marie"},
+				},
+				"urn:ietf:params:scim:schemas:extension:reva:2.0:Group": map[string]interface{}{"gidNumber": float64(42)},
+			},
+		},
+		{
+			name: "replace extension attribute",
+			ops:  []patchOperation{{Op: "replace", Path: "urn:ietf:params:scim:schemas:extension:reva:2.0:Group:mail", Value: "sailing@example.org"}},
+			expected: map[string]interface{}{
+				"displayName": "sailing-lovers",
+				"members": []interface{}{
+					map[string]interface{}{"value": "einstein"},
+					map[string]interface{}{"value": "marie"},
+				},
+				"urn:ietf:params:scim:schemas:extension:reva:2.0:Group": map[string]interface{}{"mail": "sailing@example.org"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		res := group()
+		if err := applyPatch(res, tt.ops); err != nil {
+			t.Errorf("%s: error applying patch: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(res, tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, res)
+		}
+	}
+
+	if err := applyPatch(group(), []patchOperation{{Op: "replace", Path: `members[value eq "unknown"].display`, Value: "x"}}); err == nil {
+		t.Errorf("expected an error when no value matches the path filter")
+	}
+	if err := applyPatch(group(), []patchOperation{{Op: "move", Path: "members"}}); err == nil {
+		t.Errorf("expected an error for an unsupported operation")
+	}
+}
@@ -0,0 +1,319 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package scim
+
+import (
+	"context"
+	"net/http"
+	"sort"
+
+	grouppb "github.com/cs3org/go-cs3apis/cs3/identity/group/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/protobuf/proto"
+)
+
+func (s *svc) groupToSCIM(r *http.Request, g *grouppb.Group) *scimGroup {
+	sg := &scimGroup{
+		Schemas:     []string{schemaGroup},
+		ID:          g.Id.GetOpaqueId(),
+		DisplayName: g.GroupName,
+		Meta:        &meta{ResourceType: "Group", Location: s.location(r, "Groups", g.Id.GetOpaqueId())},
+	}
+	for _, m := range g.Members {
+		sg.Members = append(sg.Members, multiValued{
+			Value: m.OpaqueId,
+			Type:  "User",
+			Ref:   s.location(r, "Users", m.OpaqueId),
+		})
+	}
+	if g.GidNumber != 0 || g.Mail != "" {
+		sg.Schemas = append(sg.Schemas, schemaGroupExtension)
+		sg.Extension = &groupExtension{GIDNumber: g.GidNumber, Mail: g.Mail}
+	}
+	return sg
+}
+
+// toGroup applies a SCIM group to a copy of an existing group, or to a new one.
+// The SCIM display name is used as both the name and the display name of the group.
+func (s *svc) toGroup(ctx context.Context, sg *scimGroup, existing *grouppb.Group) (*grouppb.Group, error) {
+	if sg.DisplayName == "" {
+		return nil, newError(http.StatusBadRequest, "invalidValue", "displayName is required")
+	}
+
+	g := &grouppb.Group{
+		Id: &grouppb.GroupId{Idp: s.conf.IDP},
+	}
+	if existing != nil {
+		g = proto.Clone(existing).(*grouppb.Group)
+	}
+	g.GroupName = sg.DisplayName
+	g.DisplayName = sg.DisplayName
+	g.GidNumber, g.Mail = 0, ""
+	if sg.Extension != nil {
+		g.GidNumber, g.Mail = sg.Extension.GIDNumber, sg.Extension.Mail
+	}
+
+	g.Members = make([]*userpb.UserId, 0, len(sg.Members))
+	seen := map[string]bool{}
+	for _, m := range sg.Members {
+		if seen[m.Value] {
+			continue
+		}
+		seen[m.Value] = true
+		u, err := s.getUser(ctx, m.Value)
+		if err != nil {
+			if _, ok := err.(errtypes.IsNotFound); ok {
+				return nil, newError(http.StatusBadRequest, "invalidValue", "unknown member: "+m.Value)
+			}
+			return nil, err
+		}
+		g.Members = append(g.Members, u.Id)
+	}
+	return g, nil
+}
+
+// getGroup returns the group with the given ID; the managers also look up groups by name, which SCIM does not.
+func (s *svc) getGroup(ctx context.Context, id string) (*grouppb.Group, error) {
+	g, err := s.groups.GetGroup(ctx, &grouppb.GroupId{OpaqueId: id}, false)
+	if err != nil {
+		return nil, err
+	}
+	if g.Id.GetOpaqueId() != id {
+		return nil, errtypes.NotFound(id)
+	}
+	return g, nil
+}
+
+// syncMemberships updates the groups listed in the users when the name or the members of a group change.
+func (s *svc) syncMemberships(ctx context.Context, oldName string, oldMembers []*userpb.UserId, newName string, newMembers []*userpb.UserId) error {
+	log := appctx.GetLogger(ctx)
+
+	isMember := map[string]bool{}
+	affected := map[string]*userpb.UserId{}
+	for _, m := range oldMembers {
+		affected[m.OpaqueId] = m
+	}
+	for _, m := range newMembers {
+		affected[m.OpaqueId] = m
+		isMember[m.OpaqueId] = true
+	}
+
+	for id, uid := range affected {
+		u, err := s.users.GetUser(ctx, uid, false)
+		if err != nil {
+			log.Warn().Err(err).Str("user", id).Msg("scim: error getting group member, skipping")
+			continue
+		}
+
+		groups := make([]string, 0, len(u.Groups)+1)
+		for _, name := range u.Groups {
+			if name != oldName && name != newName {
+				groups = append(groups, name)
+			}
+		}
+		if isMember[id] {
+			groups = append(groups, newName)
+		}
+		if equalGroups(groups, u.Groups) {
+			continue
+		}
+
+		u.Groups = groups
+		if _, err := s.users.UpdateUser(ctx, u); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func equalGroups(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *svc) handleListGroups(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var f filter
+	if v := r.URL.Query().Get("filter"); v != "" {
+		var err error
+		if f, err = parseFilter(v); err != nil {
+			writeError(w, r, newError(http.StatusBadRequest, "invalidFilter", err.Error()))
+			return
+		}
+	}
+	startIndex, count, err := s.pagination(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	groups, err := s.groups.FindGroups(ctx, "", false)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Id.GetOpaqueId() < groups[j].Id.GetOpaqueId() })
+
+	resources := []interface{}{}
+	for _, g := range groups {
+		sg := s.groupToSCIM(r, g)
+		if f != nil {
+			m, err := toMap(sg)
+			if err != nil {
+				writeError(w, r, err)
+				return
+			}
+			if !f.matches(m) {
+				continue
+			}
+		}
+		resources = append(resources, sg)
+	}
+	writeResource(w, r, http.StatusOK, newListResponse(page(resources, startIndex, count), len(resources), startIndex))
+}
+
+func (s *svc) handleCreateGroup(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	sg := &scimGroup{}
+	if err := decodeBody(r, sg); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	g, err := s.toGroup(ctx, sg, nil)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	created, err := s.groups.CreateGroup(ctx, g)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := s.syncMemberships(ctx, "", nil, created.GroupName, created.Members); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res := s.groupToSCIM(r, created)
+	w.Header().Set("Location", res.Meta.Location)
+	writeResource(w, r, http.StatusCreated, res)
+}
+
+func (s *svc) handleGetGroup(w http.ResponseWriter, r *http.Request) {
+	g, err := s.getGroup(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeResource(w, r, http.StatusOK, s.groupToSCIM(r, g))
+}
+
+func (s *svc) handleReplaceGroup(w http.ResponseWriter, r *http.Request) {
+	existing, err := s.getGroup(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	sg := &scimGroup{}
+	if err := decodeBody(r, sg); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	s.updateGroup(w, r, sg, existing)
+}
+
+func (s *svc) handlePatchGroup(w http.ResponseWriter, r *http.Request) {
+	existing, err := s.getGroup(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	req := &patchRequest{}
+	if err := decodeBody(r, req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	m, err := toMap(s.groupToSCIM(r, existing))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := applyPatch(m, req.Operations); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	sg := &scimGroup{}
+	if err := fromMap(m, sg); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	s.updateGroup(w, r, sg, existing)
+}
+
+func (s *svc) updateGroup(w http.ResponseWriter, r *http.Request, sg *scimGroup, existing *grouppb.Group) {
+	ctx := r.Context()
+
+	g, err := s.toGroup(ctx, sg, existing)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	updated, err := s.groups.UpdateGroup(ctx, g)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := s.syncMemberships(ctx, existing.GroupName, existing.Members, updated.GroupName, updated.Members); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeResource(w, r, http.StatusOK, s.groupToSCIM(r, updated))
+}
+
+func (s *svc) handleDeleteGroup(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	g, err := s.getGroup(ctx, chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := s.groups.DeleteGroup(ctx, g.Id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := s.syncMemberships(ctx, g.GroupName, g.Members, "", nil); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,295 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package scim
+
+import (
+	"net/http"
+	"strings"
+)
+
+type patchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []patchOperation `json:"Operations"`
+}
+
+type patchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+// patchPath is a parsed PATCH path like `members[value eq "id"].display` (RFC 7644, section 3.5.2).
+type patchPath struct {
+	schema    string
+	attribute string
+	filter    filter
+	sub       string
+}
+
+func parsePatchPath(path string) (*patchPath, error) {
+	p := &patchPath{}
+
+	attr := path
+	if i := strings.Index(path, "["); i != -1 {
+		j := strings.LastIndex(path, "]")
+		if j < i {
+			return nil, newError(http.StatusBadRequest, "invalidPath", "invalid path: "+path)
+		}
+		f, err := parseFilter(path[i+1 : j])
+		if err != nil {
+			return nil, newError(http.StatusBadRequest, "invalidPath", "invalid path filter: "+err.Error())
+		}
+		p.filter = f
+		attr = path[:i]
+		if rest := path[j+1:]; rest != "" {
+			if !strings.HasPrefix(rest, ".") {
+				return nil, newError(http.StatusBadRequest, "invalidPath", "invalid path: "+path)
+			}
+			p.sub = rest[1:]
+		}
+	}
+
+	p.schema, attr = splitSchema(attr)
+	if p.filter == nil {
+		if i := strings.Index(attr, "."); i != -1 {
+			attr, p.sub = attr[:i], attr[i+1:]
+		}
+	}
+	if attr == "" {
+		return nil, newError(http.StatusBadRequest, "invalidPath", "invalid path: "+path)
+	}
+	p.attribute = attr
+	return p, nil
+}
+
+// applyPatch applies the PATCH operations to the generic JSON representation of a resource.
+func applyPatch(res map[string]interface{}, ops []patchOperation) error {
+	if len(ops) == 0 {
+		return newError(http.StatusBadRequest, "invalidValue", "no operations given")
+	}
+
+	for _, op := range ops {
+		name := strings.ToLower(op.Op)
+		switch name {
+		case "add", "replace":
+			if op.Path == "" {
+				// the value holds the attributes to add or replace
+				values, ok := op.Value.(map[string]interface{})
+				if !ok {
+					return newError(http.StatusBadRequest, "invalidValue", "the value of an operation without path must be an object")
+				}
+				for k, v := range values {
+					if err := applyOperation(res, name, k, v); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+		case "remove":
+			if op.Path == "" {
+				return newError(http.StatusBadRequest, "noTarget", "remove operations require a path")
+			}
+		default:
+			return newError(http.StatusBadRequest, "invalidSyntax", "unsupported operation: "+op.Op)
+		}
+		if err := applyOperation(res, name, op.Path, op.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyOperation(res map[string]interface{}, op, path string, value interface{}) error {
+	var p *patchPath
+	if isExtensionSchema(path) {
+		// the whole extension, e.g. as an attribute of the value of an operation without path
+		p = &patchPath{attribute: path}
+	} else {
+		var err error
+		if p, err = parsePatchPath(path); err != nil {
+			return err
+		}
+	}
+
+	container := res
+	if p.schema != "" && !isCoreSchema(p.schema) {
+		k, ok := findKey(res, p.schema)
+		ext, isMap := res[k].(map[string]interface{})
+		if !ok || !isMap {
+			if op == "remove" {
+				return nil
+			}
+			ext = map[string]interface{}{}
+			res[k] = ext
+		}
+		container = ext
+	}
+
+	key, _ := findKey(container, p.attribute)
+	if p.filter != nil {
+		return applyFiltered(container, key, op, p, value)
+	}
+
+	if p.sub != "" {
+		parent, ok := container[key].(map[string]interface{})
+		if !ok {
+			if op == "remove" {
+				return nil
+			}
+			parent = map[string]interface{}{}
+			container[key] = parent
+		}
+		subKey, _ := findKey(parent, p.sub)
+		if op == "remove" {
+			delete(parent, subKey)
+		} else {
+			parent[subKey] = value
+		}
+		return nil
+	}
+
+	switch op {
+	case "remove":
+		if arr, ok := container[key].([]interface{}); ok && value != nil {
+			// some clients list the elements to remove in the value
+			container[key] = removeValues(arr, value)
+			return nil
+		}
+		delete(container, key)
+	case "add":
+		switch existing := container[key].(type) {
+		case []interface{}:
+			container[key] = addValues(existing, value)
+		case map[string]interface{}:
+			if m, ok := value.(map[string]interface{}); ok {
+				mergeInto(existing, m)
+			} else {
+				container[key] = value
+			}
+		default:
+			container[key] = value
+		}
+	case "replace":
+		if existing, ok := container[key].(map[string]interface{}); ok {
+			if m, ok := value.(map[string]interface{}); ok {
+				mergeInto(existing, m)
+				return nil
+			}
+		}
+		container[key] = value
+	}
+	return nil
+}
+
+func applyFiltered(container map[string]interface{}, key, op string, p *patchPath, value interface{}) error {
+	arr, _ := container[key].([]interface{})
+	matched := false
+	result := make([]interface{}, 0, len(arr))
+	for _, e := range arr {
+		elem, ok := e.(map[string]interface{})
+		if !ok || !p.filter.matches(elem) {
+			result = append(result, e)
+			continue
+		}
+		matched = true
+
+		switch {
+		case op == "remove" && p.sub == "":
+			continue
+		case op == "remove":
+			subKey, _ := findKey(elem, p.sub)
+			delete(elem, subKey)
+		case p.sub != "":
+			subKey, _ := findKey(elem, p.sub)
+			elem[subKey] = value
+		default:
+			if m, ok := value.(map[string]interface{}); ok {
+				mergeInto(elem, m)
+			} else {
+				e = value
+			}
+		}
+		result = append(result, e)
+	}
+
+	if !matched {
+		if op == "remove" {
+			return nil
+		}
+		return newError(http.StatusBadRequest, "noTarget", "no value matches the path filter")
+	}
+	container[key] = result
+	return nil
+}
+
+// addValues appends values to a multi-valued attribute, skipping those already present.
+func addValues(arr []interface{}, value interface{}) []interface{} {
+	values, ok := value.([]interface{})
+	if !ok {
+		values = []interface{}{value}
+	}
+	for _, v := range values {
+		if indexOfValue(arr, v) == -1 {
+			arr = append(arr, v)
+		}
+	}
+	return arr
+}
+
+// removeValues removes the given values from a multi-valued attribute.
+func removeValues(arr []interface{}, value interface{}) []interface{} {
+	values, ok := value.([]interface{})
+	if !ok {
+		values = []interface{}{value}
+	}
+	for _, v := range values {
+		if i := indexOfValue(arr, v); i != -1 {
+			arr = append(arr[:i:i], arr[i+1:]...)
+		}
+	}
+	return arr
+}
+
+// indexOfValue returns the index of the element of a multi-valued attribute with the same value as v.
+func indexOfValue(arr []interface{}, v interface{}) int {
+	for i, e := range arr {
+		if elementValue(e) == elementValue(v) {
+			return i
+		}
+	}
+	return -1
+}
+
+func elementValue(e interface{}) interface{} {
+	if m, ok := e.(map[string]interface{}); ok {
+		return getAttribute(m, "value")
+	}
+	return e
+}
+
+func mergeInto(dst, src map[string]interface{}) {
+	for k, v := range src {
+		key, _ := findKey(dst, k)
+		dst[key] = v
+	}
+}
+
+func isExtensionSchema(path string) bool {
+	return strings.EqualFold(path, schemaUserExtension) || strings.EqualFold(path, schemaGroupExtension)
+}
@@ -0,0 +1,220 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+const (
+	schemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	schemaGroup                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	schemaUserExtension         = "urn:ietf:params:scim:schemas:extension:reva:2.0:User"
+	schemaGroupExtension        = "urn:ietf:params:scim:schemas:extension:reva:2.0:Group"
+	schemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	schemaResourceType          = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+	schemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	schemaPatchOp               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	schemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+)
+
+// scimError is the error response defined in RFC 7644, section 3.12.
+type scimError struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+
+	status int
+}
+
+func newError(status int, scimType, detail string) *scimError {
+	return &scimError{
+		Schemas:  []string{schemaError},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   detail,
+		status:   status,
+	}
+}
+
+func (e *scimError) Error() string {
+	return "scim: " + e.Detail
+}
+
+type meta struct {
+	ResourceType string `json:"resourceType"`
+	Location     string `json:"location,omitempty"`
+}
+
+type listResponse struct {
+	Schemas      []string      `json:"schemas"`
+	TotalResults int           `json:"totalResults"`
+	StartIndex   int           `json:"startIndex"`
+	ItemsPerPage int           `json:"itemsPerPage"`
+	Resources    []interface{} `json:"Resources"`
+}
+
+func newListResponse(resources []interface{}, total, startIndex int) *listResponse {
+	return &listResponse{
+		Schemas:      []string{schemaListResponse},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	}
+}
+
+// pagination reads the startIndex and count query parameters; startIndex is 1-based.
+func (s *svc) pagination(r *http.Request) (int, int, error) {
+	startIndex, count := 1, s.conf.MaxResults
+	if v := r.URL.Query().Get("startIndex"); v != "" {
+		i, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, 0, newError(http.StatusBadRequest, "invalidValue", "invalid startIndex: "+v)
+		}
+		if i > 1 {
+			startIndex = i
+		}
+	}
+	if v := r.URL.Query().Get("count"); v != "" {
+		i, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, 0, newError(http.StatusBadRequest, "invalidValue", "invalid count: "+v)
+		}
+		if i < 0 {
+			i = 0
+		}
+		if i < count {
+			count = i
+		}
+	}
+	return startIndex, count, nil
+}
+
+// page returns the requested page of a list of resources.
+func page(resources []interface{}, startIndex, count int) []interface{} {
+	if startIndex > len(resources) {
+		return []interface{}{}
+	}
+	end := startIndex - 1 + count
+	if end > len(resources) {
+		end = len(resources)
+	}
+	return resources[startIndex-1 : end]
+}
+
+type multiValued struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+type name struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+type userExtension struct {
+	UIDNumber int64 `json:"uidNumber,omitempty"`
+	GIDNumber int64 `json:"gidNumber,omitempty"`
+}
+
+type groupExtension struct {
+	GIDNumber int64  `json:"gidNumber,omitempty"`
+	Mail      string `json:"mail,omitempty"`
+}
+
+type scimUser struct {
+	Schemas     []string       `json:"schemas"`
+	ID          string         `json:"id,omitempty"`
+	UserName    string         `json:"userName"`
+	Name        *name          `json:"name,omitempty"`
+	DisplayName string         `json:"displayName,omitempty"`
+	Emails      []multiValued  `json:"emails,omitempty"`
+	Groups      []multiValued  `json:"groups,omitempty"`
+	Extension   *userExtension `json:"urn:ietf:params:scim:schemas:extension:reva:2.0:User,omitempty"`
+	Meta        *meta          `json:"meta,omitempty"`
+}
+
+// displayName returns the display name of the user, falling back to its name.
+func (u *scimUser) displayName() string {
+	if u.DisplayName != "" || u.Name == nil {
+		return u.DisplayName
+	}
+	if u.Name.Formatted != "" {
+		return u.Name.Formatted
+	}
+	if u.Name.GivenName != "" && u.Name.FamilyName != "" {
+		return u.Name.GivenName + " " + u.Name.FamilyName
+	}
+	return u.Name.GivenName + u.Name.FamilyName
+}
+
+// mail returns the primary email address of the user, or the first one if none is primary.
+func (u *scimUser) mail() string {
+	for _, e := range u.Emails {
+		if e.Primary {
+			return e.Value
+		}
+	}
+	if len(u.Emails) > 0 {
+		return u.Emails[0].Value
+	}
+	return ""
+}
+
+type scimGroup struct {
+	Schemas     []string        `json:"schemas"`
+	ID          string          `json:"id,omitempty"`
+	DisplayName string          `json:"displayName"`
+	Members     []multiValued   `json:"members,omitempty"`
+	Extension   *groupExtension `json:"urn:ietf:params:scim:schemas:extension:reva:2.0:Group,omitempty"`
+	Meta        *meta           `json:"meta,omitempty"`
+}
+
+// toMap converts a resource to its generic JSON representation, used for filtering and patching.
+func toMap(v interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]interface{}{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// fromMap converts a generic JSON representation back to a resource.
+func fromMap(m map[string]interface{}, v interface{}) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return newError(http.StatusBadRequest, "invalidValue", "invalid resource: "+err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,295 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package scim implements a SCIM 2.0 (RFC 7643, RFC 7644) provisioning
+// endpoint for users and groups, backed by writable user and group managers.
+package scim
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/group"
+	groupregistry "github.com/cs3org/reva/pkg/group/manager/registry"
+	"github.com/cs3org/reva/pkg/rhttp/global"
+	"github.com/cs3org/reva/pkg/user"
+	userregistry "github.com/cs3org/reva/pkg/user/manager/registry"
+	"github.com/go-chi/chi/v5"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+
+	// Load the user and group managers.
+	_ "github.com/cs3org/reva/pkg/group/manager/loader"
+	_ "github.com/cs3org/reva/pkg/user/manager/loader"
+)
+
+func init() {
+	global.Register("scim", New)
+}
+
+const contentType = "application/scim+json"
+
+type config struct {
+	Prefix       string                            `mapstructure:"prefix" docs:"scim;The URL path where the service is exposed."`
+	Token        string                            `mapstructure:"token" docs:";The bearer token the provisioning client must present."`
+	IDP          string                            `mapstructure:"idp" docs:";The identity provider assigned to the users and groups created through SCIM."`
+	MaxResults   int                               `mapstructure:"max_results" docs:"100;The maximum number of resources returned per page."`
+	UserDriver   string                            `mapstructure:"user_driver" docs:"json;The writable user manager driver."`
+	UserDrivers  map[string]map[string]interface{} `mapstructure:"user_drivers" docs:"url:pkg/user/manager/json/json.go"`
+	GroupDriver  string                            `mapstructure:"group_driver" docs:"json;The writable group manager driver."`
+	GroupDrivers map[string]map[string]interface{} `mapstructure:"group_drivers" docs:"url:pkg/group/manager/json/json.go"`
+}
+
+func (c *config) init() {
+	if c.Prefix == "" {
+		c.Prefix = "scim"
+	}
+	if c.MaxResults <= 0 {
+		c.MaxResults = 100
+	}
+	if c.UserDriver == "" {
+		c.UserDriver = "json"
+	}
+	if c.GroupDriver == "" {
+		c.GroupDriver = "json"
+	}
+}
+
+func parseConfig(m map[string]interface{}) (*config, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		err = errors.Wrap(err, "error decoding conf")
+		return nil, err
+	}
+	c.init()
+	return c, nil
+}
+
+type svc struct {
+	conf   *config
+	router *chi.Mux
+	users  user.WritableManager
+	groups group.WritableManager
+}
+
+// New returns a new SCIM provisioning service.
+func New(m map[string]interface{}, log *zerolog.Logger) (global.Service, error) {
+	conf, err := parseConfig(m)
+	if err != nil {
+		return nil, err
+	}
+	if conf.Token == "" {
+		return nil, errors.New("scim: a bearer token must be configured")
+	}
+
+	users, err := getUserManager(conf)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := getGroupManager(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &svc{
+		conf:   conf,
+		router: chi.NewRouter(),
+		users:  users,
+		groups: groups,
+	}
+	s.routerInit()
+	return s, nil
+}
+
+func getUserManager(c *config) (user.WritableManager, error) {
+	f, ok := userregistry.NewFuncs[c.UserDriver]
+	if !ok {
+		return nil, errtypes.NotFound("scim: driver not found: " + c.UserDriver)
+	}
+	mgr, err := f(c.UserDrivers[c.UserDriver])
+	if err != nil {
+		return nil, errors.Wrap(err, "scim: error creating user manager")
+	}
+	w, ok := mgr.(user.WritableManager)
+	if !ok {
+		return nil, errtypes.NotSupported("scim: user driver is not writable: " + c.UserDriver)
+	}
+	return w, nil
+}
+
+func getGroupManager(c *config) (group.WritableManager, error) {
+	f, ok := groupregistry.NewFuncs[c.GroupDriver]
+	if !ok {
+		return nil, errtypes.NotFound("scim: driver not found: " + c.GroupDriver)
+	}
+	mgr, err := f(c.GroupDrivers[c.GroupDriver])
+	if err != nil {
+		return nil, errors.Wrap(err, "scim: error creating group manager")
+	}
+	w, ok := mgr.(group.WritableManager)
+	if !ok {
+		return nil, errtypes.NotSupported("scim: group driver is not writable: " + c.GroupDriver)
+	}
+	return w, nil
+}
+
+func (s *svc) routerInit() {
+	s.router.Use(s.authenticate)
+
+	s.router.Get("/ServiceProviderConfig", s.handleServiceProviderConfig)
+	s.router.Get("/ResourceTypes", s.handleResourceTypes)
+
+	s.router.Get("/Users", s.handleListUsers)
+	s.router.Post("/Users", s.handleCreateUser)
+	s.router.Get("/Users/{id}", s.handleGetUser)
+	s.router.Put("/Users/{id}", s.handleReplaceUser)
+	s.router.Patch("/Users/{id}", s.handlePatchUser)
+	s.router.Delete("/Users/{id}", s.handleDeleteUser)
+
+	s.router.Get("/Groups", s.handleListGroups)
+	s.router.Post("/Groups", s.handleCreateGroup)
+	s.router.Get("/Groups/{id}", s.handleGetGroup)
+	s.router.Put("/Groups/{id}", s.handleReplaceGroup)
+	s.router.Patch("/Groups/{id}", s.handlePatchGroup)
+	s.router.Delete("/Groups/{id}", s.handleDeleteGroup)
+}
+
+// Close performs cleanup.
+func (s *svc) Close() error {
+	return nil
+}
+
+func (s *svc) Prefix() string {
+	return s.conf.Prefix
+}
+
+// Unprotected returns all paths: SCIM clients authenticate with the
+// configured bearer token, which is checked by the service itself.
+func (s *svc) Unprotected() []string {
+	return []string{"/"}
+}
+
+func (s *svc) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.router.ServeHTTP(w, r)
+	})
+}
+
+func (s *svc) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.conf.Token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, r, newError(http.StatusUnauthorized, "", "invalid bearer token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// location returns the absolute URL of a resource.
+func (s *svc) location(r *http.Request, resource, id string) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s%s", scheme, r.Host, path.Join("/", s.conf.Prefix, resource, id))
+}
+
+func writeResource(w http.ResponseWriter, r *http.Request, status int, v interface{}) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		appctx.GetLogger(r.Context()).Error().Err(err).Msg("scim: error writing response")
+	}
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	var se *scimError
+	switch e := err.(type) {
+	case *scimError:
+		se = e
+	case errtypes.IsNotFound:
+		se = newError(http.StatusNotFound, "", err.Error())
+	case errtypes.IsAlreadyExists:
+		se = newError(http.StatusConflict, "uniqueness", err.Error())
+	case errtypes.IsBadRequest:
+		se = newError(http.StatusBadRequest, "invalidValue", err.Error())
+	default:
+		appctx.GetLogger(r.Context()).Error().Err(err).Msg("scim: error processing request")
+		se = newError(http.StatusInternalServerError, "", "internal error")
+	}
+	writeResource(w, r, se.status, se)
+}
+
+func decodeBody(r *http.Request, v interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return newError(http.StatusBadRequest, "invalidSyntax", "invalid request body: "+err.Error())
+	}
+	return nil
+}
+
+func (s *svc) handleServiceProviderConfig(w http.ResponseWriter, r *http.Request) {
+	writeResource(w, r, http.StatusOK, map[string]interface{}{
+		"schemas":        []string{schemaServiceProviderConfig},
+		"patch":          map[string]bool{"supported": true},
+		"bulk":           map[string]interface{}{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         map[string]interface{}{"supported": true, "maxResults": s.conf.MaxResults},
+		"changePassword": map[string]bool{"supported": false},
+		"sort":           map[string]bool{"supported": false},
+		"etag":           map[string]bool{"supported": false},
+		"authenticationSchemes": []map[string]interface{}{{
+			"type":        "oauthbearertoken",
+			"name":        "OAuth Bearer Token",
+			"description": "Authentication using a static bearer token",
+			"primary":     true,
+		}},
+		"meta": meta{ResourceType: "ServiceProviderConfig", Location: s.location(r, "ServiceProviderConfig", "")},
+	})
+}
+
+func (s *svc) handleResourceTypes(w http.ResponseWriter, r *http.Request) {
+	types := []interface{}{
+		map[string]interface{}{
+			"schemas":          []string{schemaResourceType},
+			"id":               "User",
+			"name":             "User",
+			"endpoint":         "/Users",
+			"schema":           schemaUser,
+			"schemaExtensions": []map[string]interface{}{{"schema": schemaUserExtension, "required": false}},
+			"meta":             meta{ResourceType: "ResourceType", Location: s.location(r, "ResourceTypes", "User")},
+		},
+		map[string]interface{}{
+			"schemas":          []string{schemaResourceType},
+			"id":               "Group",
+			"name":             "Group",
+			"endpoint":         "/Groups",
+			"schema":           schemaGroup,
+			"schemaExtensions": []map[string]interface{}{{"schema": schemaGroupExtension, "required": false}},
+			"meta":             meta{ResourceType: "ResourceType", Location: s.location(r, "ResourceTypes", "Group")},
+		},
+	}
+	writeResource(w, r, http.StatusOK, newListResponse(types, len(types), 1))
+}
@@ -0,0 +1,221 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package scim
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func newTestService(t *testing.T) http.Handler {
+	dir := t.TempDir()
+	users := filepath.Join(dir, "users.json")
+	groups := filepath.Join(dir, "groups.json")
+	if err := os.WriteFile(users, []byte(`[{"id":{"idp":"localhost","opaque_id":"einstein","type":1},"username":"einstein","mail":"einstein@example.org","display_name":"Albert Einstein"}]`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(groups, []byte(`[]`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	log := zerolog.Nop()
+	s, err := New(map[string]interface{}{
+		"token": "secret",
+		"idp":   "localhost",
+		"user_drivers": map[string]interface{}{
+			"json": map[string]interface{}{"users": users},
+		},
+		"group_drivers": map[string]interface{}{
+			"json": map[string]interface{}{"groups": groups},
+		},
+	}, &log)
+	if err != nil {
+		t.Fatalf("error creating service: %v", err)
+	}
+	return s.Handler()
+}
+
+func doRequest(t *testing.T, h http.Handler, method, target string, body interface{}, result interface{}) int {
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req := httptest.NewRequest(method, target, &buf)
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Content-Type", contentType)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if result != nil && rec.Body.Len() > 0 {
+		if err := json.Unmarshal(rec.Body.Bytes(), result); err != nil {
+			t.Fatalf("%s %s: error decoding response %q: %v", method, target, rec.Body.String(), err)
+		}
+	}
+	return rec.Code
+}
+
+func TestUnauthorized(t *testing.T) {
+	h := newTestService(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/Users", nil)
+	req.Header.Set("Authorization", "Bearer wrong")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status %d, got %d", http.StatusUnauthorized, rec.Code)
+	}
+}
+
+func TestProvisioning(t *testing.T) {
+	h := newTestService(t)
+
+	// create a user
+	marie := &scimUser{}
+	code := doRequest(t, h, http.MethodPost, "/Users", map[string]interface{}{
+		"schemas":  []string{schemaUser},
+		"userName": "marie",
+		"name":     map[string]string{"givenName": "Marie", "familyName": "Curie"},
+		"emails":   []map[string]interface{}{{"value": "marie@example.org", "primary": true}},
+	}, marie)
+	if code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, code)
+	}
+	if marie.ID == "" || marie.DisplayName != "Marie Curie" || marie.Emails[0].Value != "marie@example.org" {
+		t.Fatalf("unexpected user: %+v", marie)
+	}
+	if code := doRequest(t, h, http.MethodPost, "/Users", map[string]interface{}{"userName": "marie"}, nil); code != http.StatusConflict {
+		t.Fatalf("expected status %d, got %d", http.StatusConflict, code)
+	}
+
+	// filter users
+	list := &listResponse{}
+	doRequest(t, h, http.MethodGet, `/Users?filter=userName+eq+%22marie%22`, nil, list)
+	if list.TotalResults != 1 || list.Resources[0].(map[string]interface{})["id"] != marie.ID {
+		t.Fatalf("unexpected filter result: %+v", list)
+	}
+	doRequest(t, h, http.MethodGet, "/Users?startIndex=2&count=1", nil, list)
+	if list.TotalResults != 2 || list.ItemsPerPage != 1 || list.StartIndex != 2 {
+		t.Fatalf("unexpected page: %+v", list)
+	}
+	if code := doRequest(t, h, http.MethodGet, "/Users?filter=userName+foo", nil, nil); code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, code)
+	}
+
+	// create a group with a member; the user must list the group
+	group := &scimGroup{}
+	code = doRequest(t, h, http.MethodPost, "/Groups", map[string]interface{}{
+		"schemas":     []string{schemaGroup},
+		"displayName": "physics-lovers",
+		"members":     []map[string]string{{"value": marie.ID}},
+	}, group)
+	if code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, code)
+	}
+	user := &scimUser{}
+	doRequest(t, h, http.MethodGet, "/Users/"+marie.ID, nil, user)
+	if len(user.Groups) != 1 || user.Groups[0].Value != group.ID || user.Groups[0].Display != "physics-lovers" {
+		t.Fatalf("unexpected groups: %+v", user.Groups)
+	}
+	if code := doRequest(t, h, http.MethodPost, "/Groups", map[string]interface{}{
+		"displayName": "unknown-members",
+		"members":     []map[string]string{{"value": "nobody"}},
+	}, nil); code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, code)
+	}
+
+	// patch the group: add a member, rename it
+	code = doRequest(t, h, http.MethodPatch, "/Groups/"+group.ID, map[string]interface{}{
+		"schemas": []string{schemaPatchOp},
+		"Operations": []map[string]interface{}{
+			{"op": "add", "path": "members", "value": []map[string]string{{"value": "einstein"}}},
+			{"op": "replace", "value": map[string]string{"displayName": "physics-fans"}},
+		},
+	}, group)
+	if code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, code)
+	}
+	if len(group.Members) != 2 || group.DisplayName != "physics-fans" {
+		t.Fatalf("unexpected group: %+v", group)
+	}
+	for _, id := range []string{marie.ID, "einstein"} {
+		user := &scimUser{}
+		doRequest(t, h, http.MethodGet, "/Users/"+id, nil, user)
+		if len(user.Groups) != 1 || user.Groups[0].Display != "physics-fans" {
+			t.Fatalf("unexpected groups of %s: %+v", id, user.Groups)
+		}
+	}
+
+	// remove a member
+	doRequest(t, h, http.MethodPatch, "/Groups/"+group.ID, map[string]interface{}{
+		"schemas":    []string{schemaPatchOp},
+		"Operations": []map[string]interface{}{{"op": "remove", "path": `members[value eq "einstein"]`}},
+	}, group)
+	user = &scimUser{}
+	doRequest(t, h, http.MethodGet, "/Users/einstein", nil, user)
+	if len(user.Groups) != 0 {
+		t.Fatalf("unexpected groups: %+v", user.Groups)
+	}
+
+	// patch a user
+	user = &scimUser{}
+	code = doRequest(t, h, http.MethodPatch, "/Users/"+marie.ID, map[string]interface{}{
+		"schemas": []string{schemaPatchOp},
+		"Operations": []map[string]interface{}{
+			{"op": "replace", "path": "displayName", "value": "Marie Skłodowska-Curie"},
+			{"op": "add", "path": schemaUserExtension + ":uidNumber", "value": 1000},
+		},
+	}, user)
+	if code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, code)
+	}
+	if user.DisplayName != "Marie Skłodowska-Curie" || user.Extension == nil || user.Extension.UIDNumber != 1000 || len(user.Groups) != 1 {
+		t.Fatalf("unexpected user: %+v", user)
+	}
+
+	// delete the user; it must be removed from the group
+	if code := doRequest(t, h, http.MethodDelete, "/Users/"+marie.ID, nil, nil); code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d", http.StatusNoContent, code)
+	}
+	if code := doRequest(t, h, http.MethodGet, "/Users/"+marie.ID, nil, nil); code != http.StatusNotFound {
+		t.Fatalf("expected status %d, got %d", http.StatusNotFound, code)
+	}
+	group = &scimGroup{ID: group.ID}
+	doRequest(t, h, http.MethodGet, "/Groups/"+group.ID, nil, group)
+	if !reflect.DeepEqual(group.Members, []multiValued(nil)) {
+		t.Fatalf("unexpected members: %+v", group.Members)
+	}
+
+	// delete the group
+	if code := doRequest(t, h, http.MethodDelete, "/Groups/"+group.ID, nil, nil); code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d", http.StatusNoContent, code)
+	}
+	if code := doRequest(t, h, http.MethodGet, "/Groups/"+group.ID, nil, nil); code != http.StatusNotFound {
+		t.Fatalf("expected status %d, got %d", http.StatusNotFound, code)
+	}
+}
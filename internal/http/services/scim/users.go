@@ -0,0 +1,270 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package scim
+
+import (
+	"context"
+	"net/http"
+	"sort"
+
+	grouppb "github.com/cs3org/go-cs3apis/cs3/identity/group/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/protobuf/proto"
+)
+
+func (s *svc) userToSCIM(ctx context.Context, r *http.Request, u *userpb.User) *scimUser {
+	su := &scimUser{
+		Schemas:     []string{schemaUser},
+		ID:          u.Id.GetOpaqueId(),
+		UserName:    u.Username,
+		DisplayName: u.DisplayName,
+		Meta:        &meta{ResourceType: "User", Location: s.location(r, "Users", u.Id.GetOpaqueId())},
+	}
+	if u.Mail != "" {
+		su.Emails = []multiValued{{Value: u.Mail, Type: "work", Primary: true}}
+	}
+	for _, name := range u.Groups {
+		ref := multiValued{Value: name, Display: name}
+		if g, err := s.groups.GetGroup(ctx, &grouppb.GroupId{OpaqueId: name}, true); err == nil {
+			ref.Value = g.Id.GetOpaqueId()
+			ref.Ref = s.location(r, "Groups", g.Id.GetOpaqueId())
+		}
+		su.Groups = append(su.Groups, ref)
+	}
+	if u.UidNumber != 0 || u.GidNumber != 0 {
+		su.Schemas = append(su.Schemas, schemaUserExtension)
+		su.Extension = &userExtension{UIDNumber: u.UidNumber, GIDNumber: u.GidNumber}
+	}
+	return su
+}
+
+// toUser applies a SCIM user to a copy of an existing user, or to a new one.
+// The ID and the groups of the user are read-only.
+func (s *svc) toUser(su *scimUser, existing *userpb.User) (*userpb.User, error) {
+	if su.UserName == "" {
+		return nil, newError(http.StatusBadRequest, "invalidValue", "userName is required")
+	}
+
+	u := &userpb.User{
+		Id: &userpb.UserId{Idp: s.conf.IDP, Type: userpb.UserType_USER_TYPE_PRIMARY},
+	}
+	if existing != nil {
+		u = proto.Clone(existing).(*userpb.User)
+	}
+	u.Username = su.UserName
+	u.DisplayName = su.displayName()
+	u.Mail = su.mail()
+	u.UidNumber, u.GidNumber = 0, 0
+	if su.Extension != nil {
+		u.UidNumber, u.GidNumber = su.Extension.UIDNumber, su.Extension.GIDNumber
+	}
+	return u, nil
+}
+
+// getUser returns the user with the given ID; the managers also look up users by name, which SCIM does not.
+func (s *svc) getUser(ctx context.Context, id string) (*userpb.User, error) {
+	u, err := s.users.GetUser(ctx, &userpb.UserId{OpaqueId: id}, false)
+	if err != nil {
+		return nil, err
+	}
+	if u.Id.GetOpaqueId() != id {
+		return nil, errtypes.NotFound(id)
+	}
+	return u, nil
+}
+
+func (s *svc) handleListUsers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var f filter
+	if v := r.URL.Query().Get("filter"); v != "" {
+		var err error
+		if f, err = parseFilter(v); err != nil {
+			writeError(w, r, newError(http.StatusBadRequest, "invalidFilter", err.Error()))
+			return
+		}
+	}
+	startIndex, count, err := s.pagination(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	users, err := s.users.FindUsers(ctx, "", false)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Id.GetOpaqueId() < users[j].Id.GetOpaqueId() })
+
+	resources := []interface{}{}
+	for _, u := range users {
+		su := s.userToSCIM(ctx, r, u)
+		if f != nil {
+			m, err := toMap(su)
+			if err != nil {
+				writeError(w, r, err)
+				return
+			}
+			if !f.matches(m) {
+				continue
+			}
+		}
+		resources = append(resources, su)
+	}
+	writeResource(w, r, http.StatusOK, newListResponse(page(resources, startIndex, count), len(resources), startIndex))
+}
+
+func (s *svc) handleCreateUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	su := &scimUser{}
+	if err := decodeBody(r, su); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	u, err := s.toUser(su, nil)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	created, err := s.users.CreateUser(ctx, u)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res := s.userToSCIM(ctx, r, created)
+	w.Header().Set("Location", res.Meta.Location)
+	writeResource(w, r, http.StatusCreated, res)
+}
+
+func (s *svc) handleGetUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	u, err := s.getUser(ctx, chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeResource(w, r, http.StatusOK, s.userToSCIM(ctx, r, u))
+}
+
+func (s *svc) handleReplaceUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	existing, err := s.getUser(ctx, chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	su := &scimUser{}
+	if err := decodeBody(r, su); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	s.updateUser(w, r, su, existing)
+}
+
+func (s *svc) handlePatchUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	existing, err := s.getUser(ctx, chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	req := &patchRequest{}
+	if err := decodeBody(r, req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	m, err := toMap(s.userToSCIM(ctx, r, existing))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := applyPatch(m, req.Operations); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	su := &scimUser{}
+	if err := fromMap(m, su); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	s.updateUser(w, r, su, existing)
+}
+
+func (s *svc) updateUser(w http.ResponseWriter, r *http.Request, su *scimUser, existing *userpb.User) {
+	ctx := r.Context()
+
+	u, err := s.toUser(su, existing)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	updated, err := s.users.UpdateUser(ctx, u)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeResource(w, r, http.StatusOK, s.userToSCIM(ctx, r, updated))
+}
+
+func (s *svc) handleDeleteUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	u, err := s.getUser(ctx, chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// remove the user from its groups first, so that no dangling members are left
+	for _, name := range u.Groups {
+		g, err := s.groups.GetGroup(ctx, &grouppb.GroupId{OpaqueId: name}, false)
+		if err != nil {
+			continue
+		}
+		members := make([]*userpb.UserId, 0, len(g.Members))
+		for _, m := range g.Members {
+			if m.OpaqueId != u.Id.OpaqueId {
+				members = append(members, m)
+			}
+		}
+		if len(members) == len(g.Members) {
+			continue
+		}
+		g.Members = members
+		if _, err := s.groups.UpdateGroup(ctx, g); err != nil {
+			writeError(w, r, err)
+			return
+		}
+	}
+
+	if err := s.users.DeleteUser(ctx, u.Id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -55,6 +55,15 @@ type jsonManager struct {
 	config *config
 	// map[userid][password]AppPassword
 	passwords map[string]map[string]*apppb.AppPassword
+	// map[password]IP address of the client that last used it
+	lastUsedIPs map[string]string
+}
+
+// storedPassword is an app password as stored in the file,
+// along with the IP address of the client that last used it.
+type storedPassword struct {
+	*apppb.AppPassword
+	LastUsedIP string `json:"last_used_ip,omitempty"`
 }
 
 // New returns a new mgr.
@@ -116,19 +125,34 @@ func loadOrCreate(file string) (*jsonManager, error) {
 		return nil, errors.Wrapf(err, "error reading the file %s", file)
 	}
 
-	m := &jsonManager{}
-	if err = json.Unmarshal(data, &m.passwords); err != nil {
+	stored := map[string]map[string]*storedPassword{}
+	if err = json.Unmarshal(data, &stored); err != nil {
 		return nil, errors.Wrapf(err, "error parsing the file %s", file)
 	}
 
-	if m.passwords == nil {
-		m.passwords = make(map[string]map[string]*apppb.AppPassword)
+	m := &jsonManager{
+		passwords: make(map[string]map[string]*apppb.AppPassword, len(stored)),
+	}
+	for user, passwords := range stored {
+		m.passwords[user] = make(map[string]*apppb.AppPassword, len(passwords))
+		for hash, pw := range passwords {
+			if pw.AppPassword == nil {
+				pw.AppPassword = &apppb.AppPassword{}
+			}
+			m.passwords[user][hash] = pw.AppPassword
+			if pw.LastUsedIP != "" {
+				m.setLastUsedIP(hash, pw.LastUsedIP)
+			}
+		}
 	}
 
 	return m, nil
 }
 
 func (mgr *jsonManager) GenerateAppPassword(ctx context.Context, scope map[string]*authpb.Scope, label string, expiration *typespb.Timestamp) (*apppb.AppPassword, error) {
+	if isExpired(expiration) {
+		return nil, errtypes.BadRequest("the expiration of the app password is in the past")
+	}
 	token, err := password.Generate(mgr.config.TokenStrength, mgr.config.TokenStrength/2, 0, false, false)
 	if err != nil {
 		return nil, errors.Wrap(err, "error creating new token")
@@ -153,6 +177,8 @@ func (mgr *jsonManager) GenerateAppPassword(ctx context.Context, scope map[strin
 	mgr.Lock()
 	defer mgr.Unlock()
 
+	mgr.purgeExpired()
+
 	// check if user has some previous password
 	if _, ok := mgr.passwords[userID.String()]; !ok {
 		mgr.passwords[userID.String()] = make(map[string]*apppb.AppPassword)
@@ -196,6 +222,7 @@ func (mgr *jsonManager) InvalidateAppPassword(ctx context.Context, password stri
 		return errtypes.NotFound("password not found")
 	}
 	delete(mgr.passwords[userID.String()], password)
+	delete(mgr.lastUsedIPs, password)
 
 	// if user has 0 passwords, delete user key from state map
 	if len(mgr.passwords[userID.String()]) == 0 {
@@ -218,13 +245,20 @@ func (mgr *jsonManager) GetAppPassword(ctx context.Context, userID *userpb.UserI
 		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 		if err == nil {
 			// password found
-			if pw.Expiration != nil && pw.Expiration.Seconds != 0 && uint64(time.Now().Unix()) > pw.Expiration.Seconds {
-				// password expired
+			if isExpired(pw.Expiration) {
+				// password expired, drop it
+				mgr.purgeExpired()
+				if err := mgr.save(); err != nil {
+					return nil, errors.Wrap(err, "error saving file")
+				}
 				return nil, errtypes.NotFound("password not found")
 			}
 			// password not expired
-			// update last used time
+			// update last used time and client
 			pw.Utime = now()
+			if ip, ok := ctxpkg.ContextGetClientIP(ctx); ok {
+				mgr.setLastUsedIP(hash, ip)
+			}
 			if err := mgr.save(); err != nil {
 				return nil, errors.Wrap(err, "error saving file")
 			}
@@ -236,12 +270,46 @@ func (mgr *jsonManager) GetAppPassword(ctx context.Context, userID *userpb.UserI
 	return nil, errtypes.NotFound("password not found")
 }
 
+func isExpired(expiration *typespb.Timestamp) bool {
+	return expiration != nil && expiration.Seconds != 0 && uint64(time.Now().Unix()) > expiration.Seconds
+}
+
+// purgeExpired removes the expired passwords of all users.
+func (mgr *jsonManager) purgeExpired() {
+	for user, passwords := range mgr.passwords {
+		for hash, pw := range passwords {
+			if isExpired(pw.Expiration) {
+				delete(passwords, hash)
+				delete(mgr.lastUsedIPs, hash)
+			}
+		}
+		if len(passwords) == 0 {
+			delete(mgr.passwords, user)
+		}
+	}
+}
+
+func (mgr *jsonManager) setLastUsedIP(hash, ip string) {
+	if mgr.lastUsedIPs == nil {
+		mgr.lastUsedIPs = make(map[string]string)
+	}
+	mgr.lastUsedIPs[hash] = ip
+}
+
 func now() *typespb.Timestamp {
 	return &typespb.Timestamp{Seconds: uint64(time.Now().Unix())}
 }
 
 func (mgr *jsonManager) save() error {
-	data, err := json.Marshal(mgr.passwords)
+	stored := make(map[string]map[string]*storedPassword, len(mgr.passwords))
+	for user, passwords := range mgr.passwords {
+		stored[user] = make(map[string]*storedPassword, len(passwords))
+		for hash, pw := range passwords {
+			stored[user][hash] = &storedPassword{AppPassword: pw, LastUsedIP: mgr.lastUsedIPs[hash]}
+		}
+	}
+
+	data, err := json.Marshal(stored)
 	if err != nil {
 		return errors.Wrap(err, "error encoding json file")
 	}
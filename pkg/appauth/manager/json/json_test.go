@@ -24,6 +24,7 @@ import (
 	"encoding/json"
 	"io"
 	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 	"time"
@@ -36,6 +37,7 @@ import (
 	"github.com/gdexlab/go-render/render"
 	"github.com/sethvargo/go-password/password"
 	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc/metadata"
 )
 
 func TestNewManager(t *testing.T) {
@@ -692,6 +694,44 @@ func TestGetAppPassword(t *testing.T) {
 	}
 }
 
+func TestLastUsedIP(t *testing.T) {
+	userTest := &userpb.User{Id: &userpb.UserId{Idp: "0"}, Username: "Test User"}
+	ctx := ctxpkg.ContextSetUser(context.Background(), userTest)
+	file := filepath.Join(t.TempDir(), "appauth.json")
+	conf := map[string]interface{}{"file": file, "password_hash_cost": 4}
+
+	manager, err := New(conf)
+	if err != nil {
+		t.Fatal("error creating manager:", err)
+	}
+	pw, err := manager.GenerateAppPassword(ctx, nil, "label", nil)
+	if err != nil {
+		t.Fatal("error generating password:", err)
+	}
+	ipCtx := metadata.NewIncomingContext(ctx, metadata.Pairs(ctxpkg.ClientIPHeader, "10.0.0.1"))
+	if _, err := manager.GetAppPassword(ipCtx, userTest.GetId(), pw.Password); err != nil {
+		t.Fatal("error getting password:", err)
+	}
+
+	// the client is persisted along with the password
+	manager, err = New(conf)
+	if err != nil {
+		t.Fatal("error creating manager:", err)
+	}
+	passwords := manager.(*jsonManager).passwords[userTest.GetId().String()]
+	if len(passwords) != 1 {
+		t.Fatalf("expected one password, got %d", len(passwords))
+	}
+	for hash := range passwords {
+		if ip := manager.(*jsonManager).lastUsedIPs[hash]; ip != "10.0.0.1" {
+			t.Fatalf("expected last used ip to be 10.0.0.1, got %q", ip)
+		}
+	}
+	if _, err := manager.GetAppPassword(ctx, userTest.GetId(), pw.Password); err != nil {
+		t.Fatal("error getting password:", err)
+	}
+}
+
 func createTempDir(t *testing.T, name string) string {
 	tempDir, err := os.MkdirTemp("", name)
 	if err != nil {
@@ -0,0 +1,311 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package sql implements an application auth manager storing the app
+// passwords in a SQL database, whose schema is created on startup.
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	apppb "github.com/cs3org/go-cs3apis/cs3/auth/applications/v1beta1"
+	authpb "github.com/cs3org/go-cs3apis/cs3/auth/provider/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	typespb "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/cs3org/reva/pkg/appauth"
+	"github.com/cs3org/reva/pkg/appauth/manager/registry"
+	"github.com/cs3org/reva/pkg/appctx"
+	ctxpkg "github.com/cs3org/reva/pkg/ctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/health"
+	"github.com/cs3org/reva/pkg/utils/sqlmigrate"
+
+	// Provides mysql drivers.
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/sethvargo/go-password/password"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func init() {
+	registry.Register("sql", NewMysql)
+}
+
+// an expiration of 0 means that the password never expires.
+var migrations = []sqlmigrate.Migration{
+	{
+		`CREATE TABLE app_passwords (
+			password_hash VARCHAR(255) NOT NULL PRIMARY KEY,
+			user_idp VARCHAR(255) NOT NULL,
+			user_opaque_id VARCHAR(255) NOT NULL,
+			user_type INTEGER NOT NULL DEFAULT 0,
+			label VARCHAR(255) NOT NULL DEFAULT '',
+			scope TEXT NOT NULL,
+			expiration BIGINT NOT NULL DEFAULT 0,
+			ctime BIGINT NOT NULL,
+			utime BIGINT NOT NULL
+		)`,
+		`CREATE INDEX app_passwords_user ON app_passwords (user_idp, user_opaque_id)`,
+		`CREATE INDEX app_passwords_expiration ON app_passwords (expiration)`,
+	},
+	{
+		`ALTER TABLE app_passwords ADD COLUMN last_used_ip VARCHAR(64) NOT NULL DEFAULT ''`,
+	},
+}
+
+type config struct {
+	DBUsername       string `mapstructure:"db_username" docs:";The database username."`
+	DBPassword       string `mapstructure:"db_password" docs:";The database password."`
+	DBHost           string `mapstructure:"db_host" docs:";The database host."`
+	DBPort           int    `mapstructure:"db_port" docs:";The database port."`
+	DBName           string `mapstructure:"db_name" docs:";The database name."`
+	TokenStrength    int    `mapstructure:"token_strength" docs:"16;The length of the generated app passwords."`
+	PasswordHashCost int    `mapstructure:"password_hash_cost" docs:"11;The bcrypt cost used to hash the app passwords."`
+	PurgeInterval    int    `mapstructure:"purge_interval" docs:"3600;The minimum interval in seconds between two purges of the expired app passwords."`
+}
+
+type mgr struct {
+	c  *config
+	db *sql.DB
+
+	sync.Mutex
+	lastPurge time.Time
+}
+
+func (c *config) init() {
+	if c.TokenStrength == 0 {
+		c.TokenStrength = 16
+	}
+	if c.PasswordHashCost == 0 {
+		c.PasswordHashCost = 11
+	}
+	if c.PurgeInterval == 0 {
+		c.PurgeInterval = 3600
+	}
+}
+
+func parseConfig(m map[string]interface{}) (*config, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// NewMysql returns a new application auth manager connected to a mysql database.
+func NewMysql(m map[string]interface{}) (appauth.Manager, error) {
+	c, err := parseConfig(m)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating a new manager")
+	}
+
+	db, err := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s:%d)/%s", c.DBUsername, c.DBPassword, c.DBHost, c.DBPort, c.DBName))
+	if err != nil {
+		return nil, errors.Wrap(err, "error connecting to the database")
+	}
+
+	health.Register(fmt.Sprintf("appauth:sql:%s:%d/%s", c.DBHost, c.DBPort, c.DBName), health.SQL(db))
+
+	return New(db, m)
+}
+
+// New returns a new application auth manager using the given sql.DB,
+// whose schema is migrated to the latest version.
+func New(db *sql.DB, m map[string]interface{}) (appauth.Manager, error) {
+	c, err := parseConfig(m)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating a new manager")
+	}
+	c.init()
+
+	if err := sqlmigrate.Migrate(db, "app_passwords", migrations); err != nil {
+		return nil, err
+	}
+
+	return &mgr{
+		c:  c,
+		db: db,
+	}, nil
+}
+
+func (m *mgr) GenerateAppPassword(ctx context.Context, scope map[string]*authpb.Scope, label string, expiration *typespb.Timestamp) (*apppb.AppPassword, error) {
+	if isExpired(expiration, time.Now()) {
+		return nil, errtypes.BadRequest("the expiration of the app password is in the past")
+	}
+	m.maybePurge(ctx)
+
+	token, err := password.Generate(m.c.TokenStrength, m.c.TokenStrength/2, 0, false, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating new token")
+	}
+	tokenHashed, err := bcrypt.GenerateFromPassword([]byte(token), m.c.PasswordHashCost)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating new token")
+	}
+	scopeJSON, err := json.Marshal(scope)
+	if err != nil {
+		return nil, errors.Wrap(err, "error marshalling the token scope")
+	}
+
+	userID := ctxpkg.ContextMustGetUser(ctx).GetId()
+	ctime := &typespb.Timestamp{Seconds: uint64(time.Now().Unix())}
+
+	query := "INSERT INTO app_passwords (user_idp, user_opaque_id, user_type, password_hash, label, scope, expiration, ctime, utime) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	if _, err := m.db.ExecContext(ctx, query, userID.Idp, userID.OpaqueId, int32(userID.Type), string(tokenHashed), label, string(scopeJSON), expiration.GetSeconds(), ctime.Seconds, ctime.Seconds); err != nil {
+		return nil, errors.Wrap(err, "error saving new token")
+	}
+
+	return &apppb.AppPassword{
+		Password:   token,
+		TokenScope: scope,
+		Label:      label,
+		Expiration: expiration,
+		Ctime:      ctime,
+		Utime:      ctime,
+		User:       userID,
+	}, nil
+}
+
+func (m *mgr) ListAppPasswords(ctx context.Context) ([]*apppb.AppPassword, error) {
+	userID := ctxpkg.ContextMustGetUser(ctx).GetId()
+	return m.getPasswords(ctx, userID)
+}
+
+func (m *mgr) InvalidateAppPassword(ctx context.Context, password string) error {
+	userID := ctxpkg.ContextMustGetUser(ctx).GetId()
+
+	query := "DELETE FROM app_passwords WHERE user_idp=? AND user_opaque_id=? AND password_hash=?"
+	res, err := m.db.ExecContext(ctx, query, userID.Idp, userID.OpaqueId, password)
+	if err != nil {
+		return errors.Wrap(err, "error deleting app password")
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "error deleting app password")
+	}
+	if n == 0 {
+		return errtypes.NotFound("password not found")
+	}
+	return nil
+}
+
+func (m *mgr) GetAppPassword(ctx context.Context, userID *userpb.UserId, password string) (*apppb.AppPassword, error) {
+	m.maybePurge(ctx)
+
+	passwords, err := m.getPasswords(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, pw := range passwords {
+		if err := bcrypt.CompareHashAndPassword([]byte(pw.Password), []byte(password)); err != nil {
+			continue
+		}
+		if isExpired(pw.Expiration, now) {
+			return nil, errtypes.NotFound("password not found")
+		}
+
+		pw.Utime = &typespb.Timestamp{Seconds: uint64(now.Unix())}
+		ip, _ := ctxpkg.ContextGetClientIP(ctx)
+		query := "UPDATE app_passwords SET utime=?, last_used_ip=? WHERE user_idp=? AND user_opaque_id=? AND password_hash=?"
+		if _, err := m.db.ExecContext(ctx, query, pw.Utime.Seconds, ip, userID.Idp, userID.OpaqueId, pw.Password); err != nil {
+			return nil, errors.Wrap(err, "error updating app password")
+		}
+		return pw, nil
+	}
+
+	return nil, errtypes.NotFound("password not found")
+}
+
+func (m *mgr) getPasswords(ctx context.Context, userID *userpb.UserId) ([]*apppb.AppPassword, error) {
+	query := "SELECT user_type, password_hash, label, scope, expiration, ctime, utime FROM app_passwords WHERE user_idp=? AND user_opaque_id=?"
+	rows, err := m.db.QueryContext(ctx, query, userID.Idp, userID.OpaqueId)
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing app passwords")
+	}
+	defer rows.Close()
+
+	passwords := []*apppb.AppPassword{}
+	for rows.Next() {
+		var (
+			userType                 int32
+			hash, label, scopeJSON   string
+			expiration, ctime, utime uint64
+		)
+		if err := rows.Scan(&userType, &hash, &label, &scopeJSON, &expiration, &ctime, &utime); err != nil {
+			return nil, errors.Wrap(err, "error reading app password")
+		}
+
+		scope := map[string]*authpb.Scope{}
+		if err := json.Unmarshal([]byte(scopeJSON), &scope); err != nil {
+			return nil, errors.Wrap(err, "error unmarshalling the token scope")
+		}
+
+		pw := &apppb.AppPassword{
+			Password:   hash,
+			TokenScope: scope,
+			Label:      label,
+			User: &userpb.UserId{
+				Idp:      userID.Idp,
+				OpaqueId: userID.OpaqueId,
+				Type:     userpb.UserType(userType),
+			},
+			Ctime: &typespb.Timestamp{Seconds: ctime},
+			Utime: &typespb.Timestamp{Seconds: utime},
+		}
+		if expiration != 0 {
+			pw.Expiration = &typespb.Timestamp{Seconds: expiration}
+		}
+		passwords = append(passwords, pw)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "error listing app passwords")
+	}
+
+	return passwords, nil
+}
+
+// maybePurge removes the expired app passwords from the database,
+// at most once every purge interval.
+func (m *mgr) maybePurge(ctx context.Context) {
+	now := time.Now()
+
+	m.Lock()
+	if now.Sub(m.lastPurge) < time.Duration(m.c.PurgeInterval)*time.Second {
+		m.Unlock()
+		return
+	}
+	m.lastPurge = now
+	m.Unlock()
+
+	query := "DELETE FROM app_passwords WHERE expiration > 0 AND expiration < ?"
+	if _, err := m.db.ExecContext(ctx, query, now.Unix()); err != nil {
+		appctx.GetLogger(ctx).Error().Err(err).Msg("appauth: error purging expired app passwords")
+	}
+}
+
+func isExpired(expiration *typespb.Timestamp, now time.Time) bool {
+	return expiration != nil && expiration.Seconds != 0 && uint64(now.Unix()) > expiration.Seconds
+}
@@ -0,0 +1,151 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	authpb "github.com/cs3org/go-cs3apis/cs3/auth/provider/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	typespb "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	ctxpkg "github.com/cs3org/reva/pkg/ctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+	_ "github.com/mattn/go-sqlite3"
+	"google.golang.org/grpc/metadata"
+)
+
+func newTestManager(t *testing.T) (*mgr, *sql.DB) {
+	f, err := os.CreateTemp("", "appauth-*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	t.Cleanup(func() { os.Remove(f.Name()) })
+
+	db, err := sql.Open("sqlite3", f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	m, err := New(db, map[string]interface{}{"password_hash_cost": 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m.(*mgr), db
+}
+
+func TestAppPasswords(t *testing.T) {
+	m, db := newTestManager(t)
+
+	userID := &userpb.UserId{Idp: "0", OpaqueId: "einstein", Type: userpb.UserType_USER_TYPE_PRIMARY}
+	ctx := ctxpkg.ContextSetUser(context.Background(), &userpb.User{Id: userID})
+	scope := map[string]*authpb.Scope{
+		"user": {Role: authpb.Role_ROLE_VIEWER},
+	}
+
+	pw, err := m.GenerateAppPassword(ctx, scope, "label", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := m.ListAppPasswords(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Label != "label" || list[0].TokenScope["user"].Role != authpb.Role_ROLE_VIEWER {
+		t.Fatalf("unexpected app passwords: %+v", list)
+	}
+
+	ipCtx := metadata.NewIncomingContext(ctx, metadata.Pairs(ctxpkg.ClientIPHeader, "10.0.0.1"))
+	got, err := m.GetAppPassword(ipCtx, userID, pw.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Label != "label" || got.User.Type != userpb.UserType_USER_TYPE_PRIMARY {
+		t.Fatalf("unexpected app password: %+v", got)
+	}
+
+	var ip string
+	if err := db.QueryRow("SELECT last_used_ip FROM app_passwords").Scan(&ip); err != nil {
+		t.Fatal(err)
+	}
+	if ip != "10.0.0.1" {
+		t.Fatalf("expected last used ip to be 10.0.0.1, got %q", ip)
+	}
+
+	if _, err := m.GetAppPassword(ctx, userID, "wrong"); err == nil {
+		t.Fatal("expected error for a wrong password")
+	}
+
+	if err := m.InvalidateAppPassword(ctx, list[0].Password); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.GetAppPassword(ctx, userID, pw.Password); err == nil {
+		t.Fatal("expected error for an invalidated password")
+	}
+	if err := m.InvalidateAppPassword(ctx, list[0].Password); err == nil {
+		t.Fatal("expected error invalidating a missing password")
+	}
+}
+
+func TestExpiredAppPasswords(t *testing.T) {
+	m, db := newTestManager(t)
+
+	userID := &userpb.UserId{Idp: "0", OpaqueId: "einstein"}
+	ctx := ctxpkg.ContextSetUser(context.Background(), &userpb.User{Id: userID})
+
+	past := &typespb.Timestamp{Seconds: uint64(time.Now().Add(-time.Hour).Unix())}
+	if _, err := m.GenerateAppPassword(ctx, nil, "", past); err == nil {
+		t.Fatal("expected error generating an already expired password")
+	} else if _, ok := err.(errtypes.IsBadRequest); !ok {
+		t.Fatalf("expected a bad request error, got %v", err)
+	}
+
+	future := &typespb.Timestamp{Seconds: uint64(time.Now().Add(time.Hour).Unix())}
+	pw, err := m.GenerateAppPassword(ctx, nil, "", future)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.GetAppPassword(ctx, userID, pw.Password); err != nil {
+		t.Fatal(err)
+	}
+
+	// expire the password
+	if _, err := db.Exec("UPDATE app_passwords SET expiration=?", past.Seconds); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.GetAppPassword(ctx, userID, pw.Password); err == nil {
+		t.Fatal("expected error for an expired password")
+	}
+
+	// force a purge
+	m.lastPurge = time.Time{}
+	m.maybePurge(ctx)
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM app_passwords").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Fatalf("expected expired passwords to be purged, %d left", count)
+	}
+}
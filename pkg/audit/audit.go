@@ -0,0 +1,143 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/cs3org/reva/pkg/appctx"
+)
+
+// Action identifies the kind of operation an audit record refers to.
+type Action string
+
+// Actions recorded in the audit trail.
+const (
+	ActionLogin             Action = "login"
+	ActionLockout           Action = "lockout"
+	ActionShareCreate       Action = "share_create"
+	ActionShareUpdate       Action = "share_update"
+	ActionShareRemove       Action = "share_remove"
+	ActionPublicLinkCreate  Action = "public_link_create"
+	ActionPublicLinkUpdate  Action = "public_link_update"
+	ActionPublicLinkRemove  Action = "public_link_remove"
+	ActionPublicLinkAccess  Action = "public_link_access"
+	ActionPermissionsChange Action = "permissions_change"
+	ActionDelete            Action = "delete"
+	ActionDownload          Action = "download"
+	ActionUpload            Action = "upload"
+	ActionMove              Action = "move"
+)
+
+// Record is a single entry of the audit trail.
+type Record struct {
+	Time     time.Time `json:"time"`
+	Action   Action    `json:"action"`
+	Protocol string    `json:"protocol"`
+	Method   string    `json:"method"`
+	// Acting user, empty for anonymous requests.
+	UserIdp      string `json:"user_idp,omitempty"`
+	UserOpaqueID string `json:"user_opaque_id,omitempty"`
+	Username     string `json:"username,omitempty"`
+	ClientIP     string `json:"client_ip,omitempty"`
+	// Target resource, share or credential the operation refers to.
+	Resource string `json:"resource,omitempty"`
+	Result   string `json:"result"`
+	// PrevHash and Hash chain the records together,
+	// they are only filled in by sinks supporting it.
+	PrevHash string `json:"prev_hash,omitempty"`
+	Hash     string `json:"hash,omitempty"`
+}
+
+// Unmarshal to fulfill the events unmarshaller interface.
+func (Record) Unmarshal(v []byte) (interface{}, error) {
+	r := Record{}
+	err := json.Unmarshal(v, &r)
+	return r, err
+}
+
+// HashToken returns a digest of a secret, e.g. the token of a public link, to be
+// recorded instead of it. The records referring to the same secret can still be matched.
+func HashToken(token string) string {
+	if token == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(token))
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
+
+// Sink is the interface that audit record destinations need to implement.
+type Sink interface {
+	Write(r *Record) error
+	Close() error
+}
+
+// Logger writes audit records to a set of sinks.
+type Logger struct {
+	sinks []Sink
+}
+
+// NewLogger returns a logger writing to all the given sinks.
+func NewLogger(sinks ...Sink) *Logger {
+	return &Logger{sinks: sinks}
+}
+
+// Log writes the record to every sink. Failures are logged
+// but not returned, as auditing must not break the audited operation.
+func (l *Logger) Log(ctx context.Context, r *Record) {
+	if r.Time.IsZero() {
+		r.Time = time.Now().UTC()
+	}
+	for _, s := range l.sinks {
+		// sinks may modify the record, e.g. to chain hashes
+		rec := *r
+		if err := s.Write(&rec); err != nil {
+			appctx.GetLogger(ctx).Error().Err(err).Str("action", string(r.Action)).Msg("audit: error writing audit record")
+		}
+	}
+}
+
+// Close closes all the sinks.
+func (l *Logger) Close() error {
+	var err error
+	for _, s := range l.sinks {
+		if e := s.Close(); e != nil {
+			err = e
+		}
+	}
+	return err
+}
+
+type loggerKey struct{}
+
+// ContextSetLogger stores the audit logger in the context, so that the
+// services handling the request can add records to the same audit trail.
+func ContextSetLogger(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// ContextGetLogger returns the audit logger stored in the context, if any.
+func ContextGetLogger(ctx context.Context) (*Logger, bool) {
+	l, ok := ctx.Value(loggerKey{}).(*Logger)
+	return l, ok
+}
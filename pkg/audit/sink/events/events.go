@@ -0,0 +1,72 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package events
+
+import (
+	"github.com/asim/go-micro/plugins/events/nats/v4"
+	"github.com/cs3org/reva/pkg/audit"
+	"github.com/cs3org/reva/pkg/audit/sink/registry"
+	"github.com/cs3org/reva/pkg/events"
+	"github.com/cs3org/reva/pkg/events/server"
+	"github.com/cs3org/reva/pkg/health"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	microevents "go-micro.dev/v4/events"
+)
+
+func init() {
+	registry.Register("events", New)
+}
+
+type config struct {
+	Address   string `mapstructure:"address"`
+	ClusterID string `mapstructure:"cluster_id"`
+}
+
+type sink struct {
+	stream microevents.Stream
+}
+
+// New returns an audit sink that publishes records to the nats event stream.
+// Consumers can subscribe to them registering an audit.Record unmarshaller.
+func New(m map[string]interface{}) (audit.Sink, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "error decoding conf")
+	}
+	if c.Address == "" {
+		return nil, errors.New("audit: address of the event stream is required")
+	}
+
+	health.Register("audit:nats:"+c.Address, health.TCP(c.Address))
+
+	stream, err := server.NewNatsStream(nats.Address(c.Address), nats.ClusterID(c.ClusterID))
+	if err != nil {
+		return nil, errors.Wrap(err, "audit: error connecting to the event stream")
+	}
+	return &sink{stream: stream}, nil
+}
+
+func (s *sink) Write(r *audit.Record) error {
+	return events.Publish(s.stream, *r)
+}
+
+func (s *sink) Close() error {
+	return nil
+}
@@ -0,0 +1,234 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package file
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/cs3org/reva/pkg/audit"
+	"github.com/cs3org/reva/pkg/audit/sink/registry"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	registry.Register("file", New)
+}
+
+type config struct {
+	File string `mapstructure:"file"`
+}
+
+func (c *config) init() {
+	if c.File == "" {
+		c.File = "/var/log/revad/audit.log"
+	}
+}
+
+// logs holds the audit logs opened in the process by their path, as the
+// gRPC and HTTP interceptors writing to the same file must share its hash chain.
+var (
+	logsMu sync.Mutex
+	logs   = map[string]*logFile{}
+)
+
+type logFile struct {
+	sync.Mutex
+	path     string
+	f        *os.File
+	lastHash string
+	refs     int
+}
+
+type sink struct {
+	l      *logFile
+	closed sync.Once
+}
+
+// New returns an audit sink that appends records as JSON lines to a file.
+// Every record carries the hash of the previous one, so that removing
+// or altering entries breaks the chain and can be detected with Verify.
+// The sinks writing to the same file share a single chain.
+func New(m map[string]interface{}) (audit.Sink, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "error decoding conf")
+	}
+	c.init()
+
+	path, err := filepath.Abs(c.File)
+	if err != nil {
+		return nil, errors.Wrap(err, "audit: invalid audit log path")
+	}
+
+	logsMu.Lock()
+	defer logsMu.Unlock()
+
+	l, ok := logs[path]
+	if !ok {
+		if l, err = open(path); err != nil {
+			return nil, err
+		}
+		logs[path] = l
+	}
+	l.refs++
+
+	return &sink{l: l}, nil
+}
+
+func open(path string) (*logFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, errors.Wrap(err, "audit: error creating audit log directory")
+	}
+
+	lastHash, err := readLastHash(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, errors.Wrap(err, "audit: error opening audit log")
+	}
+
+	return &logFile{path: path, f: f, lastHash: lastHash}, nil
+}
+
+func (s *sink) Write(r *audit.Record) error {
+	l := s.l
+	l.Lock()
+	defer l.Unlock()
+
+	if err := chain(r, l.lastHash); err != nil {
+		return err
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if _, err := l.f.Write(append(b, '\n')); err != nil {
+		return errors.Wrap(err, "audit: error writing audit record")
+	}
+	l.lastHash = r.Hash
+	return nil
+}
+
+// Close closes the file once all the sinks writing to it are closed.
+func (s *sink) Close() error {
+	var err error
+	s.closed.Do(func() {
+		logsMu.Lock()
+		defer logsMu.Unlock()
+
+		s.l.refs--
+		if s.l.refs == 0 {
+			delete(logs, s.l.path)
+			err = s.l.f.Close()
+		}
+	})
+	return err
+}
+
+// chain links the record to the previous one and computes its hash.
+func chain(r *audit.Record, prev string) error {
+	r.PrevHash = prev
+	h, err := hash(r)
+	if err != nil {
+		return err
+	}
+	r.Hash = h
+	return nil
+}
+
+func hash(r *audit.Record) (string, error) {
+	rec := *r
+	rec.Hash = ""
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func readLastHash(file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", errors.Wrap(err, "audit: error opening audit log")
+	}
+	defer f.Close()
+
+	var last string
+	err = scan(f, func(r *audit.Record) error {
+		last = r.Hash
+		return nil
+	})
+	return last, err
+}
+
+func scan(rd io.Reader, fn func(*audit.Record) error) error {
+	scanner := bufio.NewScanner(rd)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		r := &audit.Record{}
+		if err := json.Unmarshal(scanner.Bytes(), r); err != nil {
+			return errors.Wrapf(err, "audit: error decoding record at line %d", line)
+		}
+		if err := fn(r); err != nil {
+			return errors.Wrapf(err, "line %d", line)
+		}
+	}
+	return scanner.Err()
+}
+
+// Verify checks the hash chain of the audit log read from rd
+// and returns an error pointing at the first broken record.
+func Verify(rd io.Reader) error {
+	prev := ""
+	return scan(rd, func(r *audit.Record) error {
+		if r.PrevHash != prev {
+			return fmt.Errorf("audit: record does not follow the previous one")
+		}
+		h, err := hash(r)
+		if err != nil {
+			return err
+		}
+		if h != r.Hash {
+			return fmt.Errorf("audit: record hash mismatch")
+		}
+		prev = r.Hash
+		return nil
+	})
+}
@@ -0,0 +1,124 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package file
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cs3org/reva/pkg/audit"
+)
+
+func TestHashChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	s, err := New(map[string]interface{}{"file": path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range []audit.Action{audit.ActionLogin, audit.ActionShareCreate} {
+		if err := s.Write(&audit.Record{Action: a, Username: "einstein", Result: "CODE_OK"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// reopening the log continues the existing chain
+	s, err = New(map[string]interface{}{"file": path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Write(&audit.Record{Action: audit.ActionDelete, Username: "einstein", Result: "CODE_OK"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify(bytes.NewReader(data)); err != nil {
+		t.Fatalf("untouched log should verify: %v", err)
+	}
+
+	tampered := strings.Replace(string(data), `"username":"einstein"`, `"username":"marie"`, 1)
+	if err := Verify(strings.NewReader(tampered)); err == nil {
+		t.Fatal("altered record should not verify")
+	}
+
+	lines := strings.SplitN(string(data), "\n", 2)
+	if err := Verify(strings.NewReader(lines[1])); err == nil {
+		t.Fatal("log with a removed record should not verify")
+	}
+}
+
+func TestSharedChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	// e.g. the gRPC and the HTTP interceptors
+	s1, err := New(map[string]interface{}{"file": path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s2, err := New(map[string]interface{}{"file": path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 4; i++ {
+		s := s1
+		if i%2 == 1 {
+			s = s2
+		}
+		if err := s.Write(&audit.Record{Action: audit.ActionDownload, Username: "einstein", Result: "200"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the file stays open for the remaining sink
+	if err := s1.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s1.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s2.Write(&audit.Record{Action: audit.ActionDelete, Username: "einstein", Result: "204"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s2.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "\n"); n != 5 {
+		t.Fatalf("expected 5 records, got %d", n)
+	}
+	if err := Verify(bytes.NewReader(data)); err != nil {
+		t.Fatalf("log written by two sinks should verify: %v", err)
+	}
+}
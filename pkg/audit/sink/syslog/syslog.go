@@ -0,0 +1,77 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package syslog
+
+import (
+	"encoding/json"
+	"log/syslog"
+
+	"github.com/cs3org/reva/pkg/audit"
+	"github.com/cs3org/reva/pkg/audit/sink/registry"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	registry.Register("syslog", New)
+}
+
+type config struct {
+	// Network and Address of the syslog daemon, the local one is used if empty.
+	Network string `mapstructure:"network"`
+	Address string `mapstructure:"address"`
+	Tag     string `mapstructure:"tag"`
+}
+
+func (c *config) init() {
+	if c.Tag == "" {
+		c.Tag = "revad-audit"
+	}
+}
+
+type sink struct {
+	w *syslog.Writer
+}
+
+// New returns an audit sink that sends records as JSON messages to syslog.
+func New(m map[string]interface{}) (audit.Sink, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "error decoding conf")
+	}
+	c.init()
+
+	w, err := syslog.Dial(c.Network, c.Address, syslog.LOG_NOTICE|syslog.LOG_AUTH, c.Tag)
+	if err != nil {
+		return nil, errors.Wrap(err, "audit: error connecting to syslog")
+	}
+	return &sink{w: w}, nil
+}
+
+func (s *sink) Write(r *audit.Record) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return s.w.Notice(string(b))
+}
+
+func (s *sink) Close() error {
+	return s.w.Close()
+}
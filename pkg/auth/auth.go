@@ -34,11 +34,14 @@ type Manager interface {
 	Authenticate(ctx context.Context, clientID, clientSecret string) (*user.User, map[string]*authpb.Scope, error)
 }
 
-// Credentials contains the auth type, client id and secret.
+// Credentials contains the auth type, client id and secret,
+// optionally completed by a second authentication factor.
 type Credentials struct {
 	Type         string
 	ClientID     string
 	ClientSecret string
+	// SecondFactor is formatted as "<method> <response>", see the mfa package.
+	SecondFactor string
 }
 
 // CredentialStrategy obtains Credentials from the request.
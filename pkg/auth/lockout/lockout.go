@@ -0,0 +1,267 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package lockout implements the protection against brute-force attacks
+// on the credentials: failed authentications are tracked per username and,
+// optionally, per client address, and once a threshold is reached further
+// attempts are rejected for an exponentially growing period of time.
+package lockout
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/cs3org/reva/pkg/appctx"
+)
+
+// Config holds the brute-force protection settings.
+type Config struct {
+	Enabled bool                              `mapstructure:"enabled" docs:"false;Whether to track failed authentications and lock out the offending users and addresses."`
+	Driver  string                            `mapstructure:"driver" docs:"memory;The driver used to store the failed attempts."`
+	Drivers map[string]map[string]interface{} `mapstructure:"drivers" docs:"url:pkg/auth/lockout/memory/memory.go"`
+	// MaxAttempts is the number of failed attempts for the same username
+	// allowed before the username is locked out.
+	MaxAttempts int `mapstructure:"max_attempts" docs:"5;The number of failed attempts for the same username before locking it out."`
+	// IPMaxAttempts is the number of failed attempts from the same client
+	// address allowed before the address is locked out. It is disabled by
+	// default: behind a reverse proxy not listed in the trusted proxies, all
+	// the clients share the address of the proxy and would be locked out together.
+	IPMaxAttempts int `mapstructure:"ip_max_attempts" docs:"0;The number of failed attempts from the same client address before locking it out, 0 to disable it. Only enable it once the trusted proxies are configured."`
+	// BaseDelay is the duration in seconds of the first lockout,
+	// doubled at every further failed attempt.
+	BaseDelay int `mapstructure:"base_delay" docs:"1;The duration in seconds of the first lockout, doubled at every further failure."`
+	// MaxDelay is the maximum duration in seconds of a lockout.
+	MaxDelay int `mapstructure:"max_delay" docs:"900;The maximum duration in seconds of a lockout."`
+	// Window is the time in seconds after the last failed attempt
+	// after which the failures are forgotten. It is extended to
+	// MaxDelay if shorter.
+	Window int `mapstructure:"window" docs:"900;The time in seconds after the last failure after which the failed attempts are forgotten."`
+}
+
+// Init sets the default values of the unset options.
+func (c *Config) Init() {
+	if c.Driver == "" {
+		c.Driver = "memory"
+	}
+	if c.MaxAttempts == 0 {
+		c.MaxAttempts = 5
+	}
+	if c.BaseDelay == 0 {
+		c.BaseDelay = 1
+	}
+	if c.MaxDelay == 0 {
+		c.MaxDelay = 900
+	}
+	if c.Window == 0 {
+		c.Window = 900
+	}
+}
+
+// State is the record of the authentication attempts for a key.
+type State struct {
+	Failures int
+	// Pending is the number of attempts in progress.
+	Pending int
+	Last    time.Time
+}
+
+// Store is the interface that lockout backends need to implement.
+// Attempts are registered before the credentials are checked, so that
+// concurrent attempts cannot get past the threshold, and released once
+// the outcome is known.
+type Store interface {
+	// Acquire atomically registers an attempt in progress for the key and
+	// returns the state including it. The state is dropped after ttl.
+	Acquire(ctx context.Context, key string, ttl time.Duration) (State, error)
+	// Release atomically ends an attempt in progress for the key, recording
+	// a failure at the given time if failed is set, and returns the new state.
+	Release(ctx context.Context, key string, failed bool, now time.Time, ttl time.Duration) (State, error)
+	// Reset forgets the failures recorded for the key.
+	Reset(ctx context.Context, key string) error
+}
+
+// Request identifies the authentication attempt.
+type Request struct {
+	Username string
+	IP       string
+}
+
+// Lockout describes a key locked out after a failed attempt.
+type Lockout struct {
+	Key      string
+	Failures int
+	Duration time.Duration
+}
+
+// Policy applies the configured thresholds to the authentication attempts.
+type Policy struct {
+	store                    Store
+	userMax, ipMax           int
+	baseDelay, maxDelay, ttl time.Duration
+	now                      func() time.Time
+}
+
+// NewPolicy returns a policy applying the settings in c using the given store.
+func NewPolicy(c *Config, store Store) *Policy {
+	p := &Policy{
+		store:     store,
+		userMax:   c.MaxAttempts,
+		ipMax:     c.IPMaxAttempts,
+		baseDelay: time.Duration(c.BaseDelay) * time.Second,
+		maxDelay:  time.Duration(c.MaxDelay) * time.Second,
+		ttl:       time.Duration(c.Window) * time.Second,
+		now:       time.Now,
+	}
+	// keep the state around at least as long as the longest lockout
+	if p.maxDelay > p.ttl {
+		p.ttl = p.maxDelay
+	}
+	return p
+}
+
+type check struct {
+	key       string
+	threshold int
+}
+
+func (p *Policy) checks(r *Request) []check {
+	var checks []check
+	if r.Username != "" {
+		checks = append(checks, check{key: userKey(r.Username), threshold: p.userMax})
+	}
+	if r.IP != "" && p.ipMax > 0 {
+		checks = append(checks, check{key: "ip:" + r.IP, threshold: p.ipMax})
+	}
+	return checks
+}
+
+// userKey folds the username to lower case, as most
+// backends match usernames case-insensitively.
+func userKey(username string) string {
+	return "user:" + strings.ToLower(username)
+}
+
+// delay returns the lockout duration after the given number of failures.
+func (p *Policy) delay(failures, threshold int) time.Duration {
+	if failures < threshold {
+		return 0
+	}
+	d := p.baseDelay
+	for i := threshold; i < failures && d < p.maxDelay; i++ {
+		d *= 2
+	}
+	if d > p.maxDelay {
+		d = p.maxDelay
+	}
+	return d
+}
+
+// wait returns the time to wait before the attempt registered in s
+// is allowed. Attempts in progress are counted as failures, so that no more
+// than the remaining allowance can run in parallel, and no more than one
+// once the threshold has been reached.
+func (p *Policy) wait(s State, c check, now time.Time) time.Duration {
+	wait := s.Last.Add(p.delay(s.Failures, c.threshold)).Sub(now)
+	allowed := c.threshold - s.Failures
+	if allowed < 1 {
+		allowed = 1
+	}
+	if s.Pending > allowed && wait < p.baseDelay {
+		wait = p.baseDelay
+	}
+	return wait
+}
+
+// Attempt is an authentication attempt let through by the policy.
+// Its outcome must be reported with Success, Failure or Abort.
+type Attempt struct {
+	p      *Policy
+	r      *Request
+	checks []check
+}
+
+// Begin registers a new authentication attempt. If the caller is locked
+// out, or too many attempts are already in progress, it returns nil and
+// the time the caller has to wait before a new attempt.
+// Errors from the store are logged and the attempt is let through,
+// so an unavailable backend does not prevent users from logging in.
+func (p *Policy) Begin(ctx context.Context, r *Request) (*Attempt, time.Duration) {
+	now := p.now()
+	a := &Attempt{p: p, r: r}
+	var wait time.Duration
+	for _, c := range p.checks(r) {
+		s, err := p.store.Acquire(ctx, c.key, p.ttl)
+		if err != nil {
+			appctx.GetLogger(ctx).Error().Err(err).Str("key", c.key).Msg("lockout: error querying store, letting attempt through")
+			continue
+		}
+		a.checks = append(a.checks, c)
+		if w := p.wait(s, c, now); w > wait {
+			wait = w
+		}
+	}
+	if wait > 0 {
+		a.Abort(ctx)
+		return nil, wait
+	}
+	return a, 0
+}
+
+func (a *Attempt) release(ctx context.Context, failed bool) []Lockout {
+	now := a.p.now()
+	var lockouts []Lockout
+	for _, c := range a.checks {
+		s, err := a.p.store.Release(ctx, c.key, failed, now, a.p.ttl)
+		if err != nil {
+			appctx.GetLogger(ctx).Error().Err(err).Str("key", c.key).Msg("lockout: error recording attempt")
+			continue
+		}
+		if !failed {
+			continue
+		}
+		if d := a.p.delay(s.Failures, c.threshold); d > 0 {
+			lockouts = append(lockouts, Lockout{Key: c.key, Failures: s.Failures, Duration: d})
+		}
+	}
+	return lockouts
+}
+
+// Failure records the attempt as failed and returns the lockouts it caused.
+func (a *Attempt) Failure(ctx context.Context) []Lockout {
+	return a.release(ctx, true)
+}
+
+// Abort ends an attempt whose outcome is unknown, e.g. because the
+// authentication backend failed, without counting it as a failure.
+func (a *Attempt) Abort(ctx context.Context) {
+	a.release(ctx, false)
+}
+
+// Success forgets the failed attempts for the username. Failures from the
+// client address are kept, so that an attacker cannot reset them by
+// logging in with an account of their own.
+func (a *Attempt) Success(ctx context.Context) {
+	a.release(ctx, false)
+	if a.r.Username == "" {
+		return
+	}
+	if err := a.p.store.Reset(ctx, userKey(a.r.Username)); err != nil {
+		appctx.GetLogger(ctx).Error().Err(err).Str("username", a.r.Username).Msg("lockout: error resetting failed attempts")
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/cs3org/reva/pkg/auth/lockout"
+	"github.com/cs3org/reva/pkg/auth/lockout/registry"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	registry.Register("memory", New)
+}
+
+type config struct {
+	// CleanupInterval is the interval in seconds between two scans for expired entries.
+	CleanupInterval int `mapstructure:"cleanup_interval" docs:"300;The interval in seconds between two scans for expired entries."`
+}
+
+type entry struct {
+	state   lockout.State
+	expires time.Time
+}
+
+type store struct {
+	sync.Mutex
+	entries  map[string]*entry
+	interval time.Duration
+	lastScan time.Time
+	now      func() time.Time
+}
+
+// New returns a lockout store that keeps the failed attempts in memory.
+// Failures are therefore tracked per revad instance.
+func New(m map[string]interface{}) (lockout.Store, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "error decoding conf")
+	}
+	if c.CleanupInterval == 0 {
+		c.CleanupInterval = 300
+	}
+
+	return &store{
+		entries:  map[string]*entry{},
+		interval: time.Duration(c.CleanupInterval) * time.Second,
+		lastScan: time.Now(),
+		now:      time.Now,
+	}, nil
+}
+
+// entry returns the live entry for key, creating it if needed.
+// It must be called with the lock held.
+func (s *store) entry(key string, now time.Time) *entry {
+	e, ok := s.entries[key]
+	if !ok || !now.Before(e.expires) {
+		e = &entry{}
+		s.entries[key] = e
+	}
+	return e
+}
+
+func (s *store) Acquire(ctx context.Context, key string, ttl time.Duration) (lockout.State, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	now := s.now()
+	s.cleanup(now)
+
+	e := s.entry(key, now)
+	e.state.Pending++
+	e.expires = now.Add(ttl)
+	return e.state, nil
+}
+
+func (s *store) Release(ctx context.Context, key string, failed bool, last time.Time, ttl time.Duration) (lockout.State, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	now := s.now()
+	e := s.entry(key, now)
+	if e.state.Pending > 0 {
+		e.state.Pending--
+	}
+	if failed {
+		e.state.Failures++
+		e.state.Last = last
+	}
+	if e.state.Failures == 0 && e.state.Pending == 0 {
+		delete(s.entries, key)
+		return e.state, nil
+	}
+	e.expires = now.Add(ttl)
+	return e.state, nil
+}
+
+func (s *store) Reset(ctx context.Context, key string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	e, ok := s.entries[key]
+	if !ok {
+		return nil
+	}
+	// attempts still in progress are kept track of
+	if e.state.Pending == 0 {
+		delete(s.entries, key)
+		return nil
+	}
+	e.state.Failures = 0
+	e.state.Last = time.Time{}
+	return nil
+}
+
+// cleanup drops the expired entries.
+// It must be called with the lock held.
+func (s *store) cleanup(now time.Time) {
+	if now.Sub(s.lastScan) < s.interval {
+		return
+	}
+	for k, e := range s.entries {
+		if !now.Before(e.expires) {
+			delete(s.entries, k)
+		}
+	}
+	s.lastScan = now
+}
@@ -0,0 +1,158 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cs3org/reva/pkg/auth/lockout"
+)
+
+func TestAcquireRelease(t *testing.T) {
+	s, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := s.(*store)
+	now := time.Unix(1000, 0)
+	st.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	for i := 1; i <= 3; i++ {
+		if state, _ := st.Acquire(ctx, "user:einstein", time.Minute); state.Pending != 1 || state.Failures != i-1 {
+			t.Fatalf("expected one pending attempt and %d failures, got %+v", i-1, state)
+		}
+		state, _ := st.Release(ctx, "user:einstein", true, now, time.Minute)
+		if state.Failures != i || state.Pending != 0 {
+			t.Fatalf("expected %d failures, got %+v", i, state)
+		}
+	}
+
+	if state, _ := st.Acquire(ctx, "user:marie", time.Minute); state.Failures != 0 {
+		t.Fatal("other keys should have no failures")
+	}
+	if state, _ := st.Release(ctx, "user:marie", false, now, time.Minute); state.Failures != 0 || state.Pending != 0 {
+		t.Fatalf("expected no failures nor pending attempts, got %+v", state)
+	}
+
+	now = now.Add(time.Minute)
+	if state, _ := st.Acquire(ctx, "user:einstein", time.Minute); state.Failures != 0 {
+		t.Fatal("failures should be forgotten after the ttl")
+	}
+	if state, _ := st.Release(ctx, "user:einstein", true, now, time.Minute); state.Failures != 1 {
+		t.Fatalf("expected failures to start over, got %d", state.Failures)
+	}
+
+	_, _ = st.Acquire(ctx, "user:einstein", time.Minute)
+	_ = st.Reset(ctx, "user:einstein")
+	if state, _ := st.Release(ctx, "user:einstein", false, now, time.Minute); state.Failures != 0 || state.Pending != 0 {
+		t.Fatalf("failures should be forgotten after a reset, got %+v", state)
+	}
+}
+
+// fail runs a failed attempt and returns the lockouts it caused.
+func fail(ctx context.Context, t *testing.T, p *lockout.Policy, r *lockout.Request) []lockout.Lockout {
+	a, wait := p.Begin(ctx, r)
+	if a == nil {
+		t.Fatalf("attempt rejected, wait %s", wait)
+	}
+	return a.Failure(ctx)
+}
+
+func TestPolicy(t *testing.T) {
+	s, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	c := &lockout.Config{MaxAttempts: 3, IPMaxAttempts: 5, BaseDelay: 10, MaxDelay: 60}
+	c.Init()
+	p := lockout.NewPolicy(c, s)
+
+	r := &lockout.Request{Username: "einstein", IP: "10.0.0.1"}
+	for i := 0; i < 2; i++ {
+		if l := fail(ctx, t, p, r); len(l) != 0 {
+			t.Fatalf("failure %d should not lock out, got %+v", i, l)
+		}
+	}
+
+	// usernames are folded to lower case
+	l := fail(ctx, t, p, &lockout.Request{Username: "Einstein", IP: "10.0.0.1"})
+	if len(l) != 1 || l[0].Key != "user:einstein" || l[0].Duration != 10*time.Second {
+		t.Fatalf("expected user to be locked out for 10s, got %+v", l)
+	}
+	if a, wait := p.Begin(ctx, r); a != nil || wait <= 0 || wait > 10*time.Second {
+		t.Fatalf("expected to wait up to 10s, got %s", wait)
+	}
+
+	// the address is locked out as well once its threshold is reached
+	_ = fail(ctx, t, p, &lockout.Request{Username: "marie", IP: "10.0.0.1"})
+	l = fail(ctx, t, p, &lockout.Request{Username: "marie", IP: "10.0.0.1"})
+	if len(l) != 1 || l[0].Key != "ip:10.0.0.1" {
+		t.Fatalf("expected address to be locked out, got %+v", l)
+	}
+	if a, _ := p.Begin(ctx, &lockout.Request{Username: "richard", IP: "10.0.0.1"}); a != nil {
+		t.Fatal("attempts from a locked out address should be rejected")
+	}
+	a, wait := p.Begin(ctx, &lockout.Request{Username: "richard", IP: "10.0.0.2"})
+	if a == nil {
+		t.Fatalf("attempts from other addresses should be allowed, wait %s", wait)
+	}
+	a.Success(ctx)
+}
+
+func TestPolicyParallel(t *testing.T) {
+	s, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	c := &lockout.Config{MaxAttempts: 3, BaseDelay: 10, MaxDelay: 60}
+	c.Init()
+	p := lockout.NewPolicy(c, s)
+
+	r := &lockout.Request{Username: "einstein"}
+	var attempts []*lockout.Attempt
+	for i := 0; i < 3; i++ {
+		a, wait := p.Begin(ctx, r)
+		if a == nil {
+			t.Fatalf("attempt %d should be allowed, wait %s", i, wait)
+		}
+		attempts = append(attempts, a)
+	}
+	// the attempts in progress could all fail, so no more are allowed
+	if a, _ := p.Begin(ctx, r); a != nil {
+		t.Fatal("attempts past the threshold should be rejected while others are in progress")
+	}
+
+	attempts[0].Abort(ctx)
+	attempts[1].Success(ctx)
+	a, wait := p.Begin(ctx, r)
+	if a == nil {
+		t.Fatalf("attempt should be allowed once others completed, wait %s", wait)
+	}
+	a.Abort(ctx)
+	if l := attempts[2].Failure(ctx); len(l) != 0 {
+		t.Fatalf("a single failure should not lock out, got %+v", l)
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/cs3org/reva/pkg/auth/lockout"
+	"github.com/cs3org/reva/pkg/auth/lockout/registry"
+	"github.com/gomodule/redigo/redis"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	registry.Register("redis", New)
+}
+
+// acquire atomically registers an attempt in progress for KEYS[1],
+// refreshes the expiration and returns the failures, the attempts
+// in progress and the time of the last failure.
+var acquire = redis.NewScript(1, `
+local pending = redis.call("HINCRBY", KEYS[1], "pending", 1)
+redis.call("PEXPIRE", KEYS[1], ARGV[1])
+local v = redis.call("HMGET", KEYS[1], "failures", "last")
+return {tonumber(v[1]) or 0, pending, tonumber(v[2]) or 0}
+`)
+
+// release atomically ends an attempt in progress for KEYS[1], records
+// a failure at time ARGV[2] if ARGV[1] is set and returns the new state.
+var release = redis.NewScript(1, `
+local pending = redis.call("HINCRBY", KEYS[1], "pending", -1)
+if pending < 0 then
+  pending = 0
+  redis.call("HSET", KEYS[1], "pending", 0)
+end
+if ARGV[1] == "1" then
+  redis.call("HINCRBY", KEYS[1], "failures", 1)
+  redis.call("HSET", KEYS[1], "last", ARGV[2])
+end
+local v = redis.call("HMGET", KEYS[1], "failures", "last")
+local failures = tonumber(v[1]) or 0
+if pending == 0 and failures == 0 then
+  redis.call("DEL", KEYS[1])
+else
+  redis.call("PEXPIRE", KEYS[1], ARGV[3])
+end
+return {failures, pending, tonumber(v[2]) or 0}
+`)
+
+type config struct {
+	RedisAddress  string `mapstructure:"redis_address" docs:"localhost:6379;The address of the redis server."`
+	RedisUsername string `mapstructure:"redis_username" docs:";The username to authenticate to the redis server."`
+	RedisPassword string `mapstructure:"redis_password" docs:";The password to authenticate to the redis server."`
+	Prefix        string `mapstructure:"prefix" docs:"lockout:;The prefix of the keys stored in redis."`
+}
+
+type store struct {
+	redisPool *redis.Pool
+	prefix    string
+}
+
+// New returns a lockout store that keeps the failed attempts in redis,
+// so that they are shared among all the revad instances using it.
+func New(m map[string]interface{}) (lockout.Store, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "error decoding conf")
+	}
+
+	if c.RedisAddress == "" {
+		c.RedisAddress = "localhost:6379"
+	}
+	if c.Prefix == "" {
+		c.Prefix = "lockout:"
+	}
+
+	pool := &redis.Pool{
+		MaxIdle:     50,
+		MaxActive:   1000,
+		IdleTimeout: 240 * time.Second,
+
+		Dial: func() (redis.Conn, error) {
+			var opts []redis.DialOption
+			if c.RedisUsername != "" {
+				opts = append(opts, redis.DialUsername(c.RedisUsername))
+			}
+			if c.RedisPassword != "" {
+				opts = append(opts, redis.DialPassword(c.RedisPassword))
+			}
+
+			c, err := redis.Dial("tcp", c.RedisAddress, opts...)
+			if err != nil {
+				return nil, err
+			}
+			return c, err
+		},
+
+		TestOnBorrow: func(c redis.Conn, t time.Time) error {
+			_, err := c.Do("PING")
+			return err
+		},
+	}
+
+	return &store{
+		redisPool: pool,
+		prefix:    c.Prefix,
+	}, nil
+}
+
+func (s *store) Acquire(ctx context.Context, key string, ttl time.Duration) (lockout.State, error) {
+	conn, err := s.redisPool.GetContext(ctx)
+	if err != nil {
+		return lockout.State{}, errors.Wrap(err, "lockout: unable to get connection from redis pool")
+	}
+	defer conn.Close()
+
+	values, err := redis.Int64s(acquire.Do(conn, s.prefix+key, ttl.Milliseconds()))
+	if err != nil {
+		return lockout.State{}, errors.Wrap(err, "lockout: error registering attempt")
+	}
+	return toState(values)
+}
+
+func (s *store) Release(ctx context.Context, key string, failed bool, now time.Time, ttl time.Duration) (lockout.State, error) {
+	conn, err := s.redisPool.GetContext(ctx)
+	if err != nil {
+		return lockout.State{}, errors.Wrap(err, "lockout: unable to get connection from redis pool")
+	}
+	defer conn.Close()
+
+	flag := 0
+	if failed {
+		flag = 1
+	}
+	last := now.UnixNano() / int64(time.Millisecond)
+	values, err := redis.Int64s(release.Do(conn, s.prefix+key, flag, last, ttl.Milliseconds()))
+	if err != nil {
+		return lockout.State{}, errors.Wrap(err, "lockout: error recording attempt")
+	}
+	return toState(values)
+}
+
+// toState converts the failures, pending attempts and time
+// of the last failure returned by the scripts to a state.
+func toState(values []int64) (lockout.State, error) {
+	if len(values) != 3 {
+		return lockout.State{}, errors.New("lockout: unexpected response from redis")
+	}
+	return lockout.State{
+		Failures: int(values[0]),
+		Pending:  int(values[1]),
+		Last:     time.Unix(0, values[2]*int64(time.Millisecond)),
+	}, nil
+}
+
+func (s *store) Reset(ctx context.Context, key string) error {
+	conn, err := s.redisPool.GetContext(ctx)
+	if err != nil {
+		return errors.Wrap(err, "lockout: unable to get connection from redis pool")
+	}
+	defer conn.Close()
+
+	// attempts still in progress are kept track of
+	if _, err := conn.Do("HDEL", s.prefix+key, "failures", "last"); err != nil {
+		return errors.Wrap(err, "lockout: error resetting failed attempts")
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package guest
+
+import (
+	"context"
+
+	authpb "github.com/cs3org/go-cs3apis/cs3/auth/provider/v1beta1"
+	user "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/auth"
+	"github.com/cs3org/reva/pkg/auth/manager/registry"
+	"github.com/cs3org/reva/pkg/auth/scope"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/guest"
+	guestregistry "github.com/cs3org/reva/pkg/guest/manager/registry"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	registry.Register("guest", New)
+}
+
+type config struct {
+	GuestManager  string                            `mapstructure:"guest_manager" docs:"json;The guest manager storing the guest accounts."`
+	GuestManagers map[string]map[string]interface{} `mapstructure:"guest_managers" docs:"url:pkg/guest/manager/json/json.go"`
+	AuthManager   string                            `mapstructure:"auth_manager" docs:";The auth manager authenticating the users who are not guests. If empty, only guests can log in."`
+	AuthManagers  map[string]map[string]interface{} `mapstructure:"auth_managers" docs:"url:pkg/auth/manager/json/json.go"`
+}
+
+func (c *config) init() {
+	if c.GuestManager == "" {
+		c.GuestManager = "json"
+	}
+}
+
+type manager struct {
+	guests   guest.Manager
+	fallback auth.Manager
+}
+
+func parseConfig(m map[string]interface{}) (*config, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		err = errors.Wrap(err, "error decoding conf")
+		return nil, err
+	}
+	c.init()
+	return c, nil
+}
+
+// New returns an auth manager authenticating the guests with the password
+// they chose when activating their account. Guests are restricted to the
+// resources shared with them. The credentials of the other users can be
+// delegated to another auth manager, so that guests can use the same
+// login as everybody else.
+func New(m map[string]interface{}) (auth.Manager, error) {
+	mgr := &manager{}
+	err := mgr.Configure(m)
+	if err != nil {
+		return nil, err
+	}
+	return mgr, nil
+}
+
+func (m *manager) Configure(ml map[string]interface{}) error {
+	c, err := parseConfig(ml)
+	if err != nil {
+		return err
+	}
+
+	f, ok := guestregistry.NewFuncs[c.GuestManager]
+	if !ok {
+		return errtypes.NotFound("guest: guest manager not found: " + c.GuestManager)
+	}
+	if m.guests, err = f(c.GuestManagers[c.GuestManager]); err != nil {
+		return errors.Wrap(err, "guest: error creating guest manager")
+	}
+
+	if c.AuthManager != "" {
+		if c.AuthManager == "guest" {
+			return errtypes.BadRequest("guest: the guest auth manager cannot fall back to itself")
+		}
+		f, ok := registry.NewFuncs[c.AuthManager]
+		if !ok {
+			return errtypes.NotFound("guest: auth manager not found: " + c.AuthManager)
+		}
+		if m.fallback, err = f(c.AuthManagers[c.AuthManager]); err != nil {
+			return errors.Wrap(err, "guest: error creating auth manager")
+		}
+	}
+	return nil
+}
+
+func (m *manager) Authenticate(ctx context.Context, clientID, clientSecret string) (*user.User, map[string]*authpb.Scope, error) {
+	u, err := m.guests.Authenticate(ctx, clientID, clientSecret)
+	if _, ok := err.(errtypes.IsNotFound); ok && m.fallback != nil {
+		return m.fallback.Authenticate(ctx, clientID, clientSecret)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	scopes, err := scope.AddLightweightAccountScope(authpb.Role_ROLE_OWNER, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return u, scopes, nil
+}
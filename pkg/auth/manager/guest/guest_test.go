@@ -0,0 +1,116 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package guest
+
+import (
+	"context"
+	"testing"
+
+	authpb "github.com/cs3org/go-cs3apis/cs3/auth/provider/v1beta1"
+	user "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/auth"
+	"github.com/cs3org/reva/pkg/auth/manager/registry"
+	"github.com/cs3org/reva/pkg/auth/scope"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/guest"
+	guestregistry "github.com/cs3org/reva/pkg/guest/manager/registry"
+)
+
+type guests struct{}
+
+func (guests) Invite(ctx context.Context, mail string, inviter *user.UserId) (*user.User, string, error) {
+	return nil, "", errtypes.NotSupported("invite")
+}
+
+func (guests) Activate(ctx context.Context, token, password string) (*user.User, error) {
+	return nil, errtypes.NotSupported("activate")
+}
+
+func (guests) Authenticate(ctx context.Context, mail, password string) (*user.User, error) {
+	if mail != "marie@example.org" {
+		return nil, errtypes.NotFound(mail)
+	}
+	if password != "secretpassword" {
+		return nil, errtypes.InvalidCredentials(mail)
+	}
+	return &user.User{
+		Id:       &user.UserId{OpaqueId: "marie", Type: user.UserType_USER_TYPE_GUEST},
+		Username: mail,
+	}, nil
+}
+
+type fallback struct{}
+
+func (fallback) Configure(map[string]interface{}) error { return nil }
+
+func (fallback) Authenticate(ctx context.Context, clientID, clientSecret string) (*user.User, map[string]*authpb.Scope, error) {
+	if clientID != "einstein" || clientSecret != "relativity" {
+		return nil, nil, errtypes.InvalidCredentials(clientID)
+	}
+	scopes, _ := scope.AddOwnerScope(nil)
+	return &user.User{Id: &user.UserId{OpaqueId: "einstein"}, Username: clientID}, scopes, nil
+}
+
+func init() {
+	guestregistry.Register("test", func(map[string]interface{}) (guest.Manager, error) { return guests{}, nil })
+	registry.Register("test", func(map[string]interface{}) (auth.Manager, error) { return fallback{}, nil })
+}
+
+func TestAuthenticate(t *testing.T) {
+	ctx := context.Background()
+
+	m, err := New(map[string]interface{}{"guest_manager": "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, scopes, err := m.Authenticate(ctx, "marie@example.org", "secretpassword")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Id.OpaqueId != "marie" {
+		t.Fatalf("unexpected user %+v", u)
+	}
+	if _, ok := scopes["lightweight"]; !ok || len(scopes) != 1 {
+		t.Fatalf("guests should only get the lightweight scope, got %+v", scopes)
+	}
+	if _, _, err := m.Authenticate(ctx, "marie@example.org", "wrong"); err == nil {
+		t.Fatal("wrong passwords should be rejected")
+	}
+	if _, _, err := m.Authenticate(ctx, "einstein", "relativity"); err == nil {
+		t.Fatal("non guests should be rejected without a fallback")
+	}
+
+	m, err = New(map[string]interface{}{"guest_manager": "test", "auth_manager": "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, scopes, err = m.Authenticate(ctx, "einstein", "relativity")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Id.OpaqueId != "einstein" {
+		t.Fatalf("unexpected user %+v", u)
+	}
+	if _, ok := scopes["user"]; !ok {
+		t.Fatalf("non guests should get the scope of the fallback, got %+v", scopes)
+	}
+	if _, _, err := m.Authenticate(ctx, "marie@example.org", "wrong"); err == nil {
+		t.Fatal("wrong passwords of guests should not be checked by the fallback")
+	}
+}
@@ -20,6 +20,7 @@ package ldap
 
 import (
 	"context"
+	"fmt"
 	"strconv"
 	"strings"
 
@@ -31,10 +32,12 @@ import (
 	"github.com/cs3org/reva/pkg/auth/manager/registry"
 	"github.com/cs3org/reva/pkg/auth/scope"
 	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/health"
 	"github.com/cs3org/reva/pkg/logger"
 	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
 	"github.com/cs3org/reva/pkg/sharedconf"
 	"github.com/cs3org/reva/pkg/utils"
+	"github.com/cs3org/reva/pkg/utils/ldapcache"
 	"github.com/go-ldap/ldap/v3"
 	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
@@ -45,7 +48,8 @@ func init() {
 }
 
 type mgr struct {
-	c *config
+	c        *config
+	searcher *ldapcache.Searcher
 }
 
 type config struct {
@@ -57,6 +61,8 @@ type config struct {
 	GatewaySvc     string     `mapstructure:"gatewaysvc"`
 	Schema         attributes `mapstructure:"schema"`
 	Nobody         int64      `mapstructure:"nobody"`
+	// Cache holds the settings of the cache for user lookups; passwords are always verified against the LDAP server
+	Cache ldapcache.Config `mapstructure:"cache"`
 }
 
 type attributes struct {
@@ -126,19 +132,20 @@ func (am *mgr) Configure(m map[string]interface{}) error {
 		c.Nobody = 99
 	}
 
+	cache, err := ldapcache.New(&c.Cache, "authprovider:ldap:")
+	if err != nil {
+		return errors.Wrap(err, "error creating the lookup cache")
+	}
+
 	c.GatewaySvc = sharedconf.GetGatewaySVC(c.GatewaySvc)
 	am.c = c
+	am.searcher = ldapcache.NewSearcher(utils.GetLDAPPool(&c.LDAPConn), cache)
+	health.Register(fmt.Sprintf("authprovider:ldap:%s:%d", c.Hostname, c.Port), health.LDAP(&c.LDAPConn))
 	return nil
 }
 
 func (am *mgr) Authenticate(ctx context.Context, clientID, clientSecret string) (*user.User, map[string]*authpb.Scope, error) {
 	log := appctx.GetLogger(ctx)
-	l, err := utils.GetLDAPConnection(&am.c.LDAPConn)
-	if err != nil {
-		return nil, nil, err
-	}
-	defer l.Close()
-
 	// Search for the given clientID
 	searchRequest := ldap.NewSearchRequest(
 		am.c.BaseDN,
@@ -148,7 +155,7 @@ func (am *mgr) Authenticate(ctx context.Context, clientID, clientSecret string)
 		nil,
 	)
 
-	sr, err := l.Search(searchRequest)
+	sr, err := am.searcher.Search(searchRequest)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -160,9 +167,12 @@ func (am *mgr) Authenticate(ctx context.Context, clientID, clientSecret string)
 	userdn := sr.Entries[0].DN
 
 	// Bind as the user to verify their password
-	err = l.Bind(userdn, clientSecret)
+	err = am.searcher.Pool().Authenticate(userdn, clientSecret)
 	if err != nil {
 		log.Debug().Err(err).Interface("userdn", userdn).Msg("bind with user credentials failed")
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, nil, errtypes.InvalidCredentials(clientID)
+		}
 		return nil, nil, err
 	}
 
@@ -0,0 +1,510 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package oidc
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	user "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/pkg/errors"
+)
+
+// mappingConfig holds the expressions used to derive the user attributes
+// from the claims returned by the userinfo endpoint.
+//
+// An expression is a list of alternatives separated by `||`, the first one
+// producing a non empty value wins. Every alternative is either a quoted
+// literal or a claim path, optionally followed by a chain of `|` separated
+// transformations:
+//
+//	realm_access.roles | regex('^reva-(.*)$', '$1')
+//	['https://example.org/claims'].uid || uidNumber
+//	email | lower || preferred_username
+//
+// Claim paths walk into nested JSON objects and flatten arrays. Supported
+// transformations are `lower`, `upper`, `trim`, `split(sep)` and
+// `regex(pattern, replacement)`, the latter dropping the values that do not
+// match the pattern.
+type mappingConfig struct {
+	ID           string `mapstructure:"id" docs:";Expression for the opaque id of the user. Defaults to id_claim."`
+	Username     string `mapstructure:"username" docs:";Expression for the username. Defaults to preferred_username, then the id."`
+	DisplayName  string `mapstructure:"display_name" docs:";Expression for the display name. Defaults to name, then the id."`
+	Mail         string `mapstructure:"mail" docs:";Expression for the mail address. Defaults to email."`
+	MailVerified string `mapstructure:"mail_verified" docs:";Expression for the mail verification flag. Defaults to email_verified."`
+	UserType     string `mapstructure:"user_type" docs:";Expression for the user type, e.g. primary, lightweight or federated. Defaults to the type derived from the id."`
+	UID          string `mapstructure:"uid" docs:";Expression for the UID of the user. Defaults to uid_claim."`
+	GID          string `mapstructure:"gid" docs:";Expression for the GID of the user. Defaults to gid_claim."`
+	Groups       string `mapstructure:"groups" docs:";Expression for the groups of the user. When empty the groups are looked up through the gateway."`
+}
+
+func (c *mappingConfig) empty() bool {
+	return *c == mappingConfig{}
+}
+
+// claimMapping is the compiled form of a mappingConfig.
+type claimMapping struct {
+	id, username, displayName, mail, mailVerified *expression
+	userType, uid, gid, groups                    *expression
+}
+
+func newClaimMapping(c *config) (*claimMapping, error) {
+	m := c.Mapping
+	defaults := []struct {
+		expr *string
+		def  string
+	}{
+		{&m.ID, quoteClaim(c.IDClaim)},
+		{&m.Username, "preferred_username || " + quoteClaim(c.IDClaim)},
+		{&m.DisplayName, "name || " + quoteClaim(c.IDClaim)},
+		{&m.Mail, "email"},
+		{&m.MailVerified, "email_verified"},
+		{&m.UID, quoteClaim(c.UIDClaim)},
+		{&m.GID, quoteClaim(c.GIDClaim)},
+	}
+	for _, d := range defaults {
+		if *d.expr == "" {
+			*d.expr = d.def
+		}
+	}
+
+	cm := &claimMapping{}
+	for _, e := range []struct {
+		name string
+		src  string
+		dst  **expression
+	}{
+		{"id", m.ID, &cm.id},
+		{"username", m.Username, &cm.username},
+		{"display_name", m.DisplayName, &cm.displayName},
+		{"mail", m.Mail, &cm.mail},
+		{"mail_verified", m.MailVerified, &cm.mailVerified},
+		{"user_type", m.UserType, &cm.userType},
+		{"uid", m.UID, &cm.uid},
+		{"gid", m.GID, &cm.gid},
+		{"groups", m.Groups, &cm.groups},
+	} {
+		if e.src == "" {
+			continue
+		}
+		expr, err := parseExpression(e.src)
+		if err != nil {
+			return nil, errors.Wrapf(err, "oidc: error parsing mapping for %s", e.name)
+		}
+		*e.dst = expr
+	}
+	return cm, nil
+}
+
+// user builds a user out of the given claims. The groups are only set when
+// a groups expression is configured.
+func (cm *claimMapping) user(claims map[string]interface{}) (*user.User, error) {
+	opaqueID := cm.id.first(claims)
+	if opaqueID == "" {
+		return nil, errors.New("oidc: the mapping did not produce a user id")
+	}
+	idp, _ := claims["iss"].(string)
+
+	userType := getUserType(opaqueID)
+	if cm.userType != nil {
+		if v := cm.userType.first(claims); v != "" {
+			t, ok := parseUserType(v)
+			if !ok {
+				return nil, fmt.Errorf("oidc: unknown user type %q", v)
+			}
+			userType = t
+		}
+	}
+
+	uid, err := cm.uid.int64(claims)
+	if err != nil {
+		return nil, errors.Wrap(err, "oidc: invalid uid")
+	}
+	gid, err := cm.gid.int64(claims)
+	if err != nil {
+		return nil, errors.Wrap(err, "oidc: invalid gid")
+	}
+	verified, _ := strconv.ParseBool(cm.mailVerified.first(claims))
+
+	u := &user.User{
+		Id: &user.UserId{
+			OpaqueId: opaqueID,
+			Idp:      idp,
+			Type:     userType,
+		},
+		Username:     cm.username.first(claims),
+		DisplayName:  cm.displayName.first(claims),
+		Mail:         cm.mail.first(claims),
+		MailVerified: verified,
+		UidNumber:    uid,
+		GidNumber:    gid,
+	}
+	if cm.groups != nil {
+		u.Groups = cm.groups.all(claims)
+	}
+	return u, nil
+}
+
+func parseUserType(s string) (user.UserType, bool) {
+	name := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(s), "-", "_"))
+	if !strings.HasPrefix(name, "USER_TYPE_") {
+		name = "USER_TYPE_" + name
+	}
+	t, ok := user.UserType_value[name]
+	if !ok || t == int32(user.UserType_USER_TYPE_INVALID) {
+		return user.UserType_USER_TYPE_INVALID, false
+	}
+	return user.UserType(t), true
+}
+
+// quoteClaim returns a path expression for a top level claim, whatever
+// characters its name contains.
+func quoteClaim(name string) string {
+	return "[" + strconv.Quote(name) + "]"
+}
+
+type expression struct {
+	alternatives []*pipeline
+}
+
+type pipeline struct {
+	literal    *string
+	path       []string
+	transforms []transform
+}
+
+type transform func([]string) []string
+
+// all returns the values of the first alternative producing any.
+func (e *expression) all(claims map[string]interface{}) []string {
+	if e == nil {
+		return nil
+	}
+	for _, p := range e.alternatives {
+		if values := p.eval(claims); len(values) > 0 {
+			return values
+		}
+	}
+	return nil
+}
+
+func (e *expression) first(claims map[string]interface{}) string {
+	if values := e.all(claims); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (e *expression) int64(claims map[string]interface{}) (int64, error) {
+	v := e.first(claims)
+	if v == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(v, 10, 64)
+}
+
+func (p *pipeline) eval(claims map[string]interface{}) []string {
+	var values []string
+	if p.literal != nil {
+		values = []string{*p.literal}
+	} else {
+		values = lookup(claims, p.path)
+	}
+	for _, t := range p.transforms {
+		values = t(values)
+	}
+	res := values[:0]
+	for _, v := range values {
+		if v != "" {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// lookup resolves a claim path, walking into nested objects and flattening
+// arrays on the way.
+func lookup(claims map[string]interface{}, path []string) []string {
+	current := []interface{}{claims}
+	for _, key := range path {
+		var next []interface{}
+		for _, c := range flatten(current) {
+			if obj, ok := c.(map[string]interface{}); ok {
+				if v, ok := obj[key]; ok && v != nil {
+					next = append(next, v)
+				}
+			}
+		}
+		current = next
+	}
+
+	var values []string
+	for _, v := range flatten(current) {
+		switch v := v.(type) {
+		case string:
+			values = append(values, v)
+		case float64:
+			values = append(values, strconv.FormatFloat(v, 'f', -1, 64))
+		case int64:
+			values = append(values, strconv.FormatInt(v, 10))
+		case int:
+			values = append(values, strconv.Itoa(v))
+		case bool:
+			values = append(values, strconv.FormatBool(v))
+		}
+	}
+	return values
+}
+
+func flatten(values []interface{}) []interface{} {
+	var res []interface{}
+	for _, v := range values {
+		if a, ok := v.([]interface{}); ok {
+			res = append(res, flatten(a)...)
+		} else {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+func mapValues(f func(string) string) transform {
+	return func(values []string) []string {
+		res := make([]string, 0, len(values))
+		for _, v := range values {
+			res = append(res, f(v))
+		}
+		return res
+	}
+}
+
+func newTransform(name string, args []string) (transform, error) {
+	arity := map[string]int{"lower": 0, "upper": 0, "trim": 0, "split": 1, "regex": 2}
+	n, ok := arity[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %q", name)
+	}
+	if len(args) != n {
+		return nil, fmt.Errorf("function %q expects %d argument(s), got %d", name, n, len(args))
+	}
+
+	switch name {
+	case "lower":
+		return mapValues(strings.ToLower), nil
+	case "upper":
+		return mapValues(strings.ToUpper), nil
+	case "trim":
+		return mapValues(strings.TrimSpace), nil
+	case "split":
+		return func(values []string) []string {
+			var res []string
+			for _, v := range values {
+				for _, s := range strings.Split(v, args[0]) {
+					res = append(res, strings.TrimSpace(s))
+				}
+			}
+			return res
+		}, nil
+	default:
+		re, err := regexp.Compile(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return func(values []string) []string {
+			var res []string
+			for _, v := range values {
+				if m := re.FindStringSubmatchIndex(v); m != nil {
+					res = append(res, string(re.ExpandString(nil, args[1], v, m)))
+				}
+			}
+			return res
+		}, nil
+	}
+}
+
+// parser is a small recursive descent parser for mapping expressions.
+type parser struct {
+	s   string
+	pos int
+}
+
+func parseExpression(s string) (*expression, error) {
+	p := &parser{s: s}
+	e := &expression{}
+	for {
+		pl, err := p.pipeline()
+		if err != nil {
+			return nil, err
+		}
+		e.alternatives = append(e.alternatives, pl)
+		p.skipSpaces()
+		if p.pos == len(p.s) {
+			return e, nil
+		}
+		if !p.consume("||") {
+			return nil, p.errorf("expected '||'")
+		}
+	}
+}
+
+func (p *parser) pipeline() (*pipeline, error) {
+	pl := &pipeline{}
+	p.skipSpaces()
+	switch {
+	case p.peek() == '\'' || p.peek() == '"':
+		lit, err := p.quoted()
+		if err != nil {
+			return nil, err
+		}
+		pl.literal = &lit
+	default:
+		path, err := p.path()
+		if err != nil {
+			return nil, err
+		}
+		pl.path = path
+	}
+
+	for {
+		p.skipSpaces()
+		if strings.HasPrefix(p.s[p.pos:], "||") || !p.consume("|") {
+			return pl, nil
+		}
+		p.skipSpaces()
+		name := p.ident()
+		if name == "" {
+			return nil, p.errorf("expected function name")
+		}
+		var args []string
+		p.skipSpaces()
+		if p.consume("(") {
+			for {
+				p.skipSpaces()
+				if p.consume(")") {
+					break
+				}
+				if len(args) > 0 && !p.consume(",") {
+					return nil, p.errorf("expected ',' or ')'")
+				}
+				p.skipSpaces()
+				arg, err := p.quoted()
+				if err != nil {
+					return nil, err
+				}
+				args = append(args, arg)
+			}
+		}
+		t, err := newTransform(name, args)
+		if err != nil {
+			return nil, p.errorf("%v", err)
+		}
+		pl.transforms = append(pl.transforms, t)
+	}
+}
+
+func (p *parser) path() ([]string, error) {
+	var path []string
+	for {
+		if p.consume("[") {
+			key, err := p.quoted()
+			if err != nil {
+				return nil, err
+			}
+			if !p.consume("]") {
+				return nil, p.errorf("expected ']'")
+			}
+			path = append(path, key)
+		} else {
+			key := p.ident()
+			if key == "" {
+				return nil, p.errorf("expected claim name")
+			}
+			path = append(path, key)
+		}
+		if p.peek() != '.' && p.peek() != '[' {
+			return path, nil
+		}
+		p.consume(".")
+	}
+}
+
+func (p *parser) ident() string {
+	start := p.pos
+	for p.pos < len(p.s) && isIdentChar(p.s[p.pos]) {
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '-' || c == ':' || c == '$' || c == '@' ||
+		'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
+
+// quoted parses a single or double quoted string, where a backslash escapes
+// the following character.
+func (p *parser) quoted() (string, error) {
+	q := p.peek()
+	if q != '\'' && q != '"' {
+		return "", p.errorf("expected quoted string")
+	}
+	p.pos++
+	var b strings.Builder
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		p.pos++
+		switch {
+		case c == q:
+			return b.String(), nil
+		case c == '\\' && p.pos < len(p.s):
+			b.WriteByte(p.s[p.pos])
+			p.pos++
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+func (p *parser) peek() byte {
+	if p.pos < len(p.s) {
+		return p.s[p.pos]
+	}
+	return 0
+}
+
+func (p *parser) consume(s string) bool {
+	if strings.HasPrefix(p.s[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+func (p *parser) skipSpaces() {
+	for p.pos < len(p.s) && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s at position %d in %q", fmt.Sprintf(format, args...), p.pos, p.s)
+}
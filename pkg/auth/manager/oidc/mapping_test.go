@@ -0,0 +1,226 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	user "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	_ "github.com/cs3org/reva/pkg/user/manager/json"
+)
+
+func TestExpressions(t *testing.T) {
+	var claims map[string]interface{}
+	if err := json.Unmarshal([]byte(`{
+		"sub": "4711",
+		"email": "Einstein@Example.org",
+		"uidNumber": 123,
+		"realm_access": {"roles": ["reva-admins", "offline_access", "reva-physics"]},
+		"resource_access": [{"roles": ["a"]}, {"roles": ["b", "c"]}],
+		"https://example.org/claims": {"type": "guest"},
+		"memberof": "cn=a, cn=b"
+	}`), &claims); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{"sub", []string{"4711"}},
+		{"uidNumber", []string{"123"}},
+		{"email | lower", []string{"einstein@example.org"}},
+		{"missing || email | upper", []string{"EINSTEIN@EXAMPLE.ORG"}},
+		{"missing || 'fallback'", []string{"fallback"}},
+		{"realm_access.roles | regex('^reva-(.*)$', '$1')", []string{"admins", "physics"}},
+		{"resource_access.roles", []string{"a", "b", "c"}},
+		{`["https://example.org/claims"].type`, []string{"guest"}},
+		{"memberof | split(',') | regex('^cn=(.*)$', '${1}')", []string{"a", "b"}},
+		{"email | regex('^(.*)@cern.ch$', '$1') || sub", []string{"4711"}},
+		{"realm_access", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			e, err := parseExpression(tt.expr)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := e.all(claims); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInvalidExpressions(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"sub ||",
+		"sub | unknown",
+		"sub | regex('(')",
+		"sub | regex('(', '$1')",
+		"'unterminated",
+		"['key'",
+		"sub sub",
+	} {
+		if _, err := parseExpression(expr); err == nil {
+			t.Errorf("expected an error parsing %q", expr)
+		}
+	}
+}
+
+func TestAuthenticateWithMapping(t *testing.T) {
+	claims := map[string]interface{}{
+		"sub":         "4711",
+		"email":       "einstein@example.org",
+		"given_name":  "Albert",
+		"uid_number":  1001,
+		"gid_number":  2001,
+		"account":     map[string]interface{}{"login": "einstein", "kind": "guest"},
+		"roles":       []string{"reva-physics", "reva-admins", "other"},
+		"email_valid": true,
+	}
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"issuer":                 srv.URL,
+				"authorization_endpoint": srv.URL + "/auth",
+				"token_endpoint":         srv.URL + "/token",
+				"jwks_uri":               srv.URL + "/keys",
+				"userinfo_endpoint":      srv.URL + "/userinfo",
+			})
+		case "/userinfo":
+			if r.Header.Get("Authorization") != "Bearer secret-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(claims)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	// a user whose username is the same as the subject must not be taken for the provisioned one
+	usersFile := filepath.Join(t.TempDir(), "users.json")
+	if err := os.WriteFile(usersFile, []byte(`[{"id":{"idp":"`+srv.URL+`","opaque_id":"marie","type":1},"username":"4711"}]`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	am, err := New(map[string]interface{}{
+		"issuer": srv.URL,
+		"mapping": map[string]interface{}{
+			"username":      "account.login",
+			"display_name":  "name || given_name",
+			"mail_verified": "email_valid",
+			"user_type":     "account.kind | regex('^guest$', 'lightweight') || 'primary'",
+			"uid":           "uid_number",
+			"gid":           "gid_number",
+			"groups":        "roles | regex('^reva-(.*)$', '$1')",
+		},
+		"provisioning": map[string]interface{}{
+			"enabled": true,
+			"drivers": map[string]map[string]interface{}{
+				"json": {"users": usersFile},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	u, scopes, err := am.Authenticate(ctx, "", "secret-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := scopes["lightweight"]; !ok {
+		t.Errorf("expected a lightweight account scope, got %v", scopes)
+	}
+	want := &user.User{
+		Id: &user.UserId{
+			OpaqueId: "4711",
+			Idp:      srv.URL,
+			Type:     user.UserType_USER_TYPE_LIGHTWEIGHT,
+		},
+		Username:     "einstein",
+		DisplayName:  "Albert",
+		Mail:         "einstein@example.org",
+		MailVerified: true,
+		UidNumber:    1001,
+		GidNumber:    2001,
+		Groups:       []string{"physics", "admins"},
+	}
+	assertUser(t, u, want)
+
+	// the user has been provisioned
+	var stored []*user.User
+	data, err := os.ReadFile(usersFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 2 || stored[0].Id.OpaqueId != "marie" {
+		t.Fatalf("expected one provisioned user next to marie, got %v", stored)
+	}
+	assertUser(t, stored[1], want)
+
+	// changed claims are synced to the store on the next login
+	claims["given_name"] = "Albert E."
+	claims["roles"] = []string{"reva-physics"}
+	want.DisplayName = "Albert E."
+	want.Groups = []string{"physics"}
+	if u, _, err = am.Authenticate(ctx, "", "secret-token"); err != nil {
+		t.Fatal(err)
+	}
+	assertUser(t, u, want)
+
+	stored = nil
+	data, _ = os.ReadFile(usersFile)
+	if err := json.Unmarshal(data, &stored); err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 2 || stored[0].Id.OpaqueId != "marie" {
+		t.Fatalf("expected one provisioned user next to marie, got %v", stored)
+	}
+	assertUser(t, stored[1], want)
+}
+
+func assertUser(t *testing.T, got, want *user.User) {
+	t.Helper()
+	if got.Id.OpaqueId != want.Id.OpaqueId || got.Id.Idp != want.Id.Idp || got.Id.Type != want.Id.Type ||
+		got.Username != want.Username || got.DisplayName != want.DisplayName || got.Mail != want.Mail ||
+		got.MailVerified != want.MailVerified || got.UidNumber != want.UidNumber || got.GidNumber != want.GidNumber ||
+		!reflect.DeepEqual(got.Groups, want.Groups) {
+		t.Errorf("got user %+v, want %+v", got, want)
+	}
+}
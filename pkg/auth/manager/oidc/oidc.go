@@ -41,6 +41,7 @@ import (
 	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
 	"github.com/cs3org/reva/pkg/rhttp"
 	"github.com/cs3org/reva/pkg/sharedconf"
+	userstore "github.com/cs3org/reva/pkg/user"
 	"github.com/juliangruber/go-intersect"
 	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
@@ -55,6 +56,8 @@ type mgr struct {
 	provider         *oidc.Provider // cached on first request
 	c                *config
 	oidcUsersMapping map[string]*oidcUserMapping
+	mapping          *claimMapping
+	users            userstore.WritableManager
 }
 
 type config struct {
@@ -66,6 +69,10 @@ type config struct {
 	GatewaySvc   string `mapstructure:"gatewaysvc" docs:";The endpoint at which the GRPC gateway is exposed."`
 	UsersMapping string `mapstructure:"users_mapping" docs:"; The optional OIDC users mapping file path"`
 	GroupClaim   string `mapstructure:"group_claim" docs:"; The group claim to be looked up to map the user (default to 'groups')."`
+	// Mapping holds the expressions used to derive the user from the claims.
+	// When set, it takes over the fixed claim options above.
+	Mapping      mappingConfig      `mapstructure:"mapping"`
+	Provisioning provisioningConfig `mapstructure:"provisioning"`
 }
 
 type oidcUserMapping struct {
@@ -88,6 +95,9 @@ func (c *config) init() {
 	if c.GIDClaim == "" {
 		c.GIDClaim = "gid"
 	}
+	if c.Provisioning.Driver == "" {
+		c.Provisioning.Driver = "json"
+	}
 
 	c.GatewaySvc = sharedconf.GetGatewaySVC(c.GatewaySvc)
 }
@@ -119,6 +129,17 @@ func (am *mgr) Configure(m map[string]interface{}) error {
 	c.init()
 	am.c = c
 
+	if !c.Mapping.empty() || c.Provisioning.Enabled {
+		if am.mapping, err = newClaimMapping(c); err != nil {
+			return err
+		}
+	}
+	if c.Provisioning.Enabled {
+		if am.users, err = getUserManager(&c.Provisioning); err != nil {
+			return err
+		}
+	}
+
 	am.oidcUsersMapping = map[string]*oidcUserMapping{}
 	if c.UsersMapping == "" {
 		// no mapping defined, leave the map empty and move on
@@ -167,9 +188,7 @@ func (am *mgr) Authenticate(ctx context.Context, clientID, clientSecret string)
 	}
 
 	// claims contains the standard OIDC claims like iss, iat, aud, ... and any other non-standard one.
-	// TODO(labkode): make claims configuration dynamic from the config file so we can add arbitrary mappings from claims to user struct.
-	// For now, only the group claim is dynamic.
-	// TODO(labkode): may do like K8s does it: https://github.com/kubernetes/kubernetes/blob/master/staging/src/k8s.io/apiserver/plugin/pkg/authenticator/token/oidc/oidc.go
+	// Arbitrary mappings from claims to the user struct are configured through the mapping rules.
 	var claims map[string]interface{}
 	if err := userInfo.Claims(&claims); err != nil {
 		return nil, nil, fmt.Errorf("oidc: error unmarshaling userinfo claims: %v", err)
@@ -183,6 +202,9 @@ func (am *mgr) Authenticate(ctx context.Context, clientID, clientSecret string)
 	if claims["email_verified"] == nil { // This is not set in simplesamlphp
 		claims["email_verified"] = false
 	}
+	if am.mapping != nil {
+		return am.authenticateMapped(ctx, claims)
+	}
 	if claims["preferred_username"] == nil {
 		claims["preferred_username"] = claims[am.c.IDClaim]
 	}
@@ -210,24 +232,15 @@ func (am *mgr) Authenticate(ctx context.Context, clientID, clientSecret string)
 		Type:     getUserType(claims[am.c.IDClaim].(string)),
 	}
 
-	gwc, err := pool.GetGatewayServiceClient(pool.Endpoint(am.c.GatewaySvc))
+	groups, err := am.getUserGroups(ctx, userID)
 	if err != nil {
-		return nil, nil, errors.Wrap(err, "oidc: error getting gateway grpc client")
-	}
-	getGroupsResp, err := gwc.GetUserGroups(ctx, &user.GetUserGroupsRequest{
-		UserId: userID,
-	})
-	if err != nil {
-		return nil, nil, errors.Wrapf(err, "oidc: error getting user groups for '%+v'", userID)
-	}
-	if getGroupsResp.Status.Code != rpc.Code_CODE_OK {
-		return nil, nil, status.NewErrorFromCode(getGroupsResp.Status.Code, "oidc")
+		return nil, nil, err
 	}
 
 	u := &user.User{
 		Id:           userID,
 		Username:     claims["preferred_username"].(string),
-		Groups:       getGroupsResp.Groups,
+		Groups:       groups,
 		Mail:         claims["email"].(string),
 		MailVerified: claims["email_verified"].(bool),
 		DisplayName:  claims["name"].(string),
@@ -235,26 +248,80 @@ func (am *mgr) Authenticate(ctx context.Context, clientID, clientSecret string)
 		GidNumber:    claims[am.c.GIDClaim].(int64),
 	}
 
-	var scopes map[string]*authpb.Scope
-	if userID != nil && (userID.Type == user.UserType_USER_TYPE_LIGHTWEIGHT || userID.Type == user.UserType_USER_TYPE_FEDERATED) {
-		scopes, err = scope.AddLightweightAccountScope(authpb.Role_ROLE_OWNER, nil)
-		if err != nil {
-			return nil, nil, err
-		}
+	scopes, err := getScopes(userID.Type)
+	if err != nil {
+		return nil, nil, err
+	}
+	if isLightweight(userID.Type) {
 		// strip the `guest:` prefix if present in the email claim (appears to come from LDAP at CERN?)
 		u.Mail = strings.Replace(u.Mail, "guest: ", "", 1)
 		// and decorate the display name with the email domain to make it different from a primary account
 		u.DisplayName = u.DisplayName + " (" + strings.Split(u.Mail, "@")[1] + ")"
-	} else {
-		scopes, err = scope.AddOwnerScope(nil)
-		if err != nil {
+	}
+
+	return u, scopes, nil
+}
+
+// authenticateMapped builds the user out of the claims through the configured
+// mapping rules, provisioning it to the user store if enabled.
+func (am *mgr) authenticateMapped(ctx context.Context, claims map[string]interface{}) (*user.User, map[string]*authpb.Scope, error) {
+	if len(am.oidcUsersMapping) > 0 {
+		if err := am.resolveUser(ctx, claims, ""); err != nil {
+			return nil, nil, errors.Wrapf(err, "oidc: error resolving username for external user '%v'", claims["email"])
+		}
+	}
+
+	u, err := am.mapping.user(claims)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch {
+	case am.users != nil:
+		if u, err = am.provision(ctx, u); err != nil {
+			return nil, nil, err
+		}
+	case am.mapping.groups == nil:
+		if u.Groups, err = am.getUserGroups(ctx, u.Id); err != nil {
 			return nil, nil, err
 		}
 	}
 
+	scopes, err := getScopes(u.Id.Type)
+	if err != nil {
+		return nil, nil, err
+	}
 	return u, scopes, nil
 }
 
+func (am *mgr) getUserGroups(ctx context.Context, userID *user.UserId) ([]string, error) {
+	gwc, err := pool.GetGatewayServiceClient(pool.Endpoint(am.c.GatewaySvc))
+	if err != nil {
+		return nil, errors.Wrap(err, "oidc: error getting gateway grpc client")
+	}
+	getGroupsResp, err := gwc.GetUserGroups(ctx, &user.GetUserGroupsRequest{
+		UserId: userID,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "oidc: error getting user groups for '%+v'", userID)
+	}
+	if getGroupsResp.Status.Code != rpc.Code_CODE_OK {
+		return nil, status.NewErrorFromCode(getGroupsResp.Status.Code, "oidc")
+	}
+	return getGroupsResp.Groups, nil
+}
+
+func isLightweight(t user.UserType) bool {
+	return t == user.UserType_USER_TYPE_LIGHTWEIGHT || t == user.UserType_USER_TYPE_FEDERATED
+}
+
+func getScopes(t user.UserType) (map[string]*authpb.Scope, error) {
+	if isLightweight(t) {
+		return scope.AddLightweightAccountScope(authpb.Role_ROLE_OWNER, nil)
+	}
+	return scope.AddOwnerScope(nil)
+}
+
 func (am *mgr) getUserID(claims map[string]interface{}) (int64, int64) {
 	uidf, _ := claims[am.c.UIDClaim].(float64)
 	uid := int64(uidf)
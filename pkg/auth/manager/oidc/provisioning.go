@@ -0,0 +1,104 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package oidc
+
+import (
+	"context"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/user"
+	"github.com/cs3org/reva/pkg/user/manager/registry"
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+)
+
+type provisioningConfig struct {
+	Enabled bool                              `mapstructure:"enabled" docs:"false;Whether to create the users in the user store on their first login."`
+	Driver  string                            `mapstructure:"driver" docs:"json;The writable user manager driver the users are provisioned to."`
+	Drivers map[string]map[string]interface{} `mapstructure:"drivers" docs:"url:pkg/user/manager/json/json.go"`
+}
+
+func getUserManager(c *provisioningConfig) (user.WritableManager, error) {
+	f, ok := registry.NewFuncs[c.Driver]
+	if !ok {
+		return nil, errtypes.NotFound("oidc: user driver not found: " + c.Driver)
+	}
+	mgr, err := f(c.Drivers[c.Driver])
+	if err != nil {
+		return nil, errors.Wrap(err, "oidc: error creating user manager")
+	}
+	w, ok := mgr.(user.WritableManager)
+	if !ok {
+		return nil, errtypes.NotSupported("oidc: user driver is not writable: " + c.Driver)
+	}
+	return w, nil
+}
+
+// provision makes sure the user store knows about the user, creating it on
+// its first login and keeping its attributes in sync with the claims
+// afterwards. When the groups are not part of the mapping, the ones already
+// stored are kept.
+func (am *mgr) provision(ctx context.Context, u *userpb.User) (*userpb.User, error) {
+	log := appctx.GetLogger(ctx)
+
+	stored, err := am.getUser(ctx, u.Id)
+	if _, ok := err.(errtypes.IsNotFound); ok {
+		created, err := am.users.CreateUser(ctx, u)
+		if err != nil {
+			return nil, errors.Wrapf(err, "oidc: error provisioning user '%s'", u.Username)
+		}
+		log.Info().Str("username", created.Username).Interface("id", created.Id).Msg("oidc: provisioned new user")
+		return created, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "oidc: error looking up user '%s'", u.Username)
+	}
+
+	updated := proto.Clone(u).(*userpb.User)
+	if am.mapping.groups == nil {
+		updated.Groups = stored.Groups
+	}
+	if proto.Equal(stored, updated) {
+		return stored, nil
+	}
+	updated, err = am.users.UpdateUser(ctx, updated)
+	if err != nil {
+		return nil, errors.Wrapf(err, "oidc: error updating provisioned user '%s'", u.Username)
+	}
+	log.Debug().Str("username", updated.Username).Msg("oidc: updated provisioned user")
+	return updated, nil
+}
+
+// getUser looks the user up by its id only: some drivers also match the
+// usernames in GetUser, which must not let an identity provider take over
+// the account of another user whose username is the same as its subject.
+func (am *mgr) getUser(ctx context.Context, uid *userpb.UserId) (*userpb.User, error) {
+	users, err := am.users.FindUsers(ctx, uid.OpaqueId, false)
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range users {
+		if u.Id.GetOpaqueId() == uid.OpaqueId && u.Id.GetIdp() == uid.Idp {
+			return u, nil
+		}
+	}
+	return nil, errtypes.NotFound(uid.OpaqueId)
+}
@@ -0,0 +1,56 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package mfa defines the providers verifying a second authentication factor.
+package mfa
+
+import (
+	"context"
+	"strings"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+)
+
+const (
+	// Header is the HTTP header carrying the second factor, formatted as "<method> <response>".
+	Header = "X-Second-Factor"
+	// ChallengeHeader is the HTTP header carrying the challenge of a second factor,
+	// formatted as "<method> <challenge>".
+	ChallengeHeader = "X-Second-Factor-Challenge"
+
+	// OpaqueKey is the opaque entry of the authenticate requests carrying the second factor.
+	OpaqueKey = "second_factor"
+	// ChallengeOpaqueKey is the opaque entry of the authenticate responses carrying the challenge.
+	ChallengeOpaqueKey = "second_factor_challenge"
+)
+
+// Provider verifies a second authentication factor of a user.
+type Provider interface {
+	// Challenge returns the challenge the user has to answer before
+	// sending the response, or an empty string if the factor needs none.
+	Challenge(ctx context.Context, u *userpb.User) (string, error)
+	// Verify checks the response of the user to the second factor.
+	Verify(ctx context.Context, u *userpb.User, response string) error
+}
+
+// ParseFactor splits a second factor into its method and response.
+// An empty response asks for the challenge of the method.
+func ParseFactor(s string) (method, response string) {
+	method, response, _ = strings.Cut(strings.TrimSpace(s), " ")
+	return strings.ToLower(method), strings.TrimSpace(response)
+}
@@ -0,0 +1,192 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package totp verifies time-based one-time passwords as defined in RFC 6238.
+package totp
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/auth/lockout"
+	lockoutregistry "github.com/cs3org/reva/pkg/auth/lockout/registry"
+	"github.com/cs3org/reva/pkg/auth/mfa"
+	"github.com/cs3org/reva/pkg/auth/mfa/registry"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/utils/totp"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	registry.Register("totp", New)
+}
+
+type config struct {
+	Secrets string `mapstructure:"secrets" docs:"/etc/revad/totp.json;JSON file mapping user ids to their base32 encoded TOTP secret."`
+	Period  int    `mapstructure:"period" docs:"30;Number of seconds a password is valid for."`
+	Digits  int    `mapstructure:"digits" docs:"6;Number of digits of the passwords."`
+	// Skew is a pointer so that 0, accepting the current period only, can be configured.
+	Skew *int `mapstructure:"skew" docs:"1;Number of periods before and after the current one that are accepted to allow for clock drift."`
+	// Lockout limits the failed attempts per user, it is enabled unless explicitly disabled.
+	Lockout map[string]interface{} `mapstructure:"lockout" docs:"url:pkg/auth/lockout/lockout.go"`
+}
+
+func (c *config) init() {
+	if c.Secrets == "" {
+		c.Secrets = "/etc/revad/totp.json"
+	}
+	if c.Period == 0 {
+		c.Period = totp.DefaultPeriod
+	}
+	if c.Digits == 0 {
+		c.Digits = totp.DefaultDigits
+	}
+	if c.Skew == nil {
+		skew := totp.DefaultSkew
+		c.Skew = &skew
+	}
+}
+
+type provider struct {
+	c       *config
+	secrets map[string][]byte
+	lockout *lockout.Policy
+	now     func() time.Time
+
+	sync.Mutex
+	// last holds the last accepted counter of every user to prevent replays.
+	last map[string]uint64
+}
+
+func parseConfig(m map[string]interface{}) (*config, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		err = errors.Wrap(err, "error decoding conf")
+		return nil, err
+	}
+	return c, nil
+}
+
+// New returns a second factor provider verifying TOTP codes.
+func New(m map[string]interface{}) (mfa.Provider, error) {
+	c, err := parseConfig(m)
+	if err != nil {
+		return nil, err
+	}
+	c.init()
+
+	f, err := os.ReadFile(c.Secrets)
+	if err != nil {
+		return nil, errors.Wrap(err, "totp: error reading secrets file")
+	}
+	encoded := map[string]string{}
+	if err := json.Unmarshal(f, &encoded); err != nil {
+		return nil, errors.Wrap(err, "totp: error decoding secrets file")
+	}
+	secrets := make(map[string][]byte, len(encoded))
+	for userID, s := range encoded {
+		secret, err := totp.DecodeSecret(s)
+		if err != nil {
+			return nil, errors.Wrapf(err, "totp: invalid secret for user %s", userID)
+		}
+		secrets[userID] = secret
+	}
+
+	lockoutPolicy, err := getLockoutPolicy(c.Lockout)
+	if err != nil {
+		return nil, err
+	}
+
+	return &provider{
+		c:       c,
+		secrets: secrets,
+		lockout: lockoutPolicy,
+		now:     time.Now,
+		last:    map[string]uint64{},
+	}, nil
+}
+
+// Challenge returns an empty challenge, TOTP codes do not need any.
+func (p *provider) Challenge(ctx context.Context, u *userpb.User) (string, error) {
+	return "", nil
+}
+
+func (p *provider) Verify(ctx context.Context, u *userpb.User, response string) error {
+	// usernames can be reassigned, ids cannot
+	userID := u.GetId().GetOpaqueId()
+	secret, ok := p.secrets[userID]
+	if !ok {
+		return errtypes.InvalidCredentials("totp: no secret enrolled for " + u.Username)
+	}
+
+	var attempt *lockout.Attempt
+	if p.lockout != nil {
+		if attempt, _ = p.lockout.Begin(ctx, &lockout.Request{Username: userID}); attempt == nil {
+			return errtypes.InvalidCredentials("totp: too many failed attempts, retry later")
+		}
+	}
+
+	p.Lock()
+	counter, ok := totp.Validate(secret, response, p.now(), p.c.Period, p.c.Digits, *p.c.Skew, p.last[userID])
+	if ok {
+		p.last[userID] = counter
+	}
+	p.Unlock()
+
+	if !ok {
+		if attempt != nil {
+			for _, l := range attempt.Failure(ctx) {
+				appctx.GetLogger(ctx).Warn().Str("key", l.Key).Int("failures", l.Failures).Dur("duration", l.Duration).Msg("totp: locked out after too many failed attempts")
+			}
+		}
+		return errtypes.InvalidCredentials("totp: invalid code")
+	}
+	if attempt != nil {
+		attempt.Success(ctx)
+	}
+	return nil
+}
+
+// getLockoutPolicy returns the policy limiting the failed attempts,
+// or nil if explicitly disabled.
+func getLockoutPolicy(m map[string]interface{}) (*lockout.Policy, error) {
+	c := &lockout.Config{Enabled: true}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "totp: error decoding lockout conf")
+	}
+	if !c.Enabled {
+		return nil, nil
+	}
+	c.Init()
+
+	f, ok := lockoutregistry.NewFuncs[c.Driver]
+	if !ok {
+		return nil, errtypes.NotFound("totp: driver not found for lockout: " + c.Driver)
+	}
+	store, err := f(c.Drivers[c.Driver])
+	if err != nil {
+		return nil, errors.Wrap(err, "totp: error creating lockout store")
+	}
+	return lockout.NewPolicy(c, store), nil
+}
@@ -0,0 +1,120 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package totp
+
+import (
+	"context"
+	"encoding/base32"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	_ "github.com/cs3org/reva/pkg/auth/lockout/memory"
+	"github.com/cs3org/reva/pkg/utils/totp"
+)
+
+// newTestProvider returns a provider with the secret of the RFC 6238 test vectors enrolled for einstein.
+func newTestProvider(t *testing.T, conf map[string]interface{}) (*provider, []byte, time.Time) {
+	secret := []byte("12345678901234567890")
+	file := filepath.Join(t.TempDir(), "totp.json")
+	encoded := base32.StdEncoding.EncodeToString(secret)
+	if err := os.WriteFile(file, []byte(`{"4c510ada-c86b-4815-8820-42cdf82c3d51": "`+encoded+`"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if conf == nil {
+		conf = map[string]interface{}{}
+	}
+	conf["secrets"] = file
+	m, err := New(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := m.(*provider)
+	now := time.Unix(1111111111, 0)
+	p.now = func() time.Time { return now }
+	return p, secret, now
+}
+
+var einstein = &userpb.User{
+	Id:       &userpb.UserId{OpaqueId: "4c510ada-c86b-4815-8820-42cdf82c3d51"},
+	Username: "einstein",
+}
+
+func TestVerify(t *testing.T) {
+	p, secret, now := newTestProvider(t, nil)
+	ctx := context.Background()
+	counter := uint64(now.Unix()) / 30
+
+	if err := p.Verify(ctx, einstein, "000000"); err == nil {
+		t.Fatal("expected an invalid code to be rejected")
+	}
+	// codes of the previous period are accepted to allow for clock drift
+	if err := p.Verify(ctx, einstein, totp.Code(secret, counter-1, 6)); err != nil {
+		t.Fatalf("expected the code of the previous period to be accepted: %v", err)
+	}
+	if err := p.Verify(ctx, einstein, totp.Code(secret, counter, 6)); err != nil {
+		t.Fatalf("expected the current code to be accepted: %v", err)
+	}
+	// but never twice, nor older ones
+	if err := p.Verify(ctx, einstein, totp.Code(secret, counter, 6)); err == nil {
+		t.Fatal("expected a replayed code to be rejected")
+	}
+	if err := p.Verify(ctx, einstein, totp.Code(secret, counter-1, 6)); err == nil {
+		t.Fatal("expected an older code to be rejected")
+	}
+	if err := p.Verify(ctx, einstein, totp.Code(secret, counter+2, 6)); err == nil {
+		t.Fatal("expected a code out of the accepted window to be rejected")
+	}
+	if err := p.Verify(ctx, &userpb.User{Id: &userpb.UserId{OpaqueId: "marie"}, Username: "einstein"}, totp.Code(secret, counter+1, 6)); err == nil {
+		t.Fatal("expected a user without secret to be rejected, whatever their username")
+	}
+}
+
+func TestVerifyNoSkew(t *testing.T) {
+	p, secret, now := newTestProvider(t, map[string]interface{}{"skew": 0})
+	ctx := context.Background()
+	counter := uint64(now.Unix()) / 30
+
+	if err := p.Verify(ctx, einstein, totp.Code(secret, counter-1, 6)); err == nil {
+		t.Fatal("expected the code of the previous period to be rejected")
+	}
+	if err := p.Verify(ctx, einstein, totp.Code(secret, counter, 6)); err != nil {
+		t.Fatalf("expected the current code to be accepted: %v", err)
+	}
+}
+
+func TestVerifyLockout(t *testing.T) {
+	p, secret, now := newTestProvider(t, map[string]interface{}{
+		"lockout": map[string]interface{}{"max_attempts": 3},
+	})
+	ctx := context.Background()
+	counter := uint64(now.Unix()) / 30
+
+	for i := 0; i < 3; i++ {
+		if err := p.Verify(ctx, einstein, "000000"); err == nil {
+			t.Fatal("expected an invalid code to be rejected")
+		}
+	}
+	if err := p.Verify(ctx, einstein, totp.Code(secret, counter, 6)); err == nil {
+		t.Fatal("expected a valid code to be rejected once the user is locked out")
+	}
+}
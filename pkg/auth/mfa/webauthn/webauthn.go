@@ -0,0 +1,342 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package webauthn verifies WebAuthn assertions of security keys whose public
+// keys have been registered out of band.
+package webauthn
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/auth/mfa"
+	"github.com/cs3org/reva/pkg/auth/mfa/registry"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	registry.Register("webauthn", New)
+}
+
+const (
+	flagUserPresent  = 0x01
+	flagUserVerified = 0x04
+)
+
+type config struct {
+	RPID                string   `mapstructure:"rp_id" docs:";The relying party id the credentials are scoped to, usually the domain of the web UI."`
+	Origins             []string `mapstructure:"origins" docs:";The origins the assertions are accepted from."`
+	Credentials         string   `mapstructure:"credentials" docs:"/etc/revad/webauthn.json;JSON file mapping user ids to their credentials, each with a base64url encoded id and a PEM encoded public key."`
+	UserVerification    bool     `mapstructure:"user_verification" docs:"false;Whether the authenticator must have verified the user, e.g. through a PIN."`
+	ChallengeExpiration int      `mapstructure:"challenge_expiration" docs:"300;Number of seconds a challenge can be answered in."`
+}
+
+func (c *config) init() {
+	if c.Credentials == "" {
+		c.Credentials = "/etc/revad/webauthn.json"
+	}
+	if c.ChallengeExpiration == 0 {
+		c.ChallengeExpiration = 300
+	}
+}
+
+type credentialConfig struct {
+	ID        string `json:"id"`
+	PublicKey string `json:"public_key"`
+}
+
+type credential struct {
+	id        []byte
+	publicKey crypto.PublicKey
+	signCount uint32
+}
+
+type challenge struct {
+	userID  string
+	expires time.Time
+}
+
+type provider struct {
+	c *config
+
+	sync.Mutex
+	credentials map[string][]*credential
+	challenges  map[string]*challenge
+}
+
+func parseConfig(m map[string]interface{}) (*config, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		err = errors.Wrap(err, "error decoding conf")
+		return nil, err
+	}
+	return c, nil
+}
+
+// New returns a second factor provider verifying WebAuthn assertions.
+func New(m map[string]interface{}) (mfa.Provider, error) {
+	c, err := parseConfig(m)
+	if err != nil {
+		return nil, err
+	}
+	c.init()
+	if c.RPID == "" || len(c.Origins) == 0 {
+		return nil, errors.New("webauthn: rp_id and origins must be configured")
+	}
+
+	f, err := os.ReadFile(c.Credentials)
+	if err != nil {
+		return nil, errors.Wrap(err, "webauthn: error reading credentials file")
+	}
+	conf := map[string][]*credentialConfig{}
+	if err := json.Unmarshal(f, &conf); err != nil {
+		return nil, errors.Wrap(err, "webauthn: error decoding credentials file")
+	}
+	credentials := make(map[string][]*credential, len(conf))
+	for userID, creds := range conf {
+		for _, cc := range creds {
+			cred, err := parseCredential(cc)
+			if err != nil {
+				return nil, errors.Wrapf(err, "webauthn: invalid credential for user %s", userID)
+			}
+			credentials[userID] = append(credentials[userID], cred)
+		}
+	}
+
+	return &provider{
+		c:           c,
+		credentials: credentials,
+		challenges:  map[string]*challenge{},
+	}, nil
+}
+
+func parseCredential(c *credentialConfig) (*credential, error) {
+	id, err := decode(c.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "error decoding credential id")
+	}
+	block, _ := pem.Decode([]byte(c.PublicKey))
+	if block == nil {
+		return nil, errors.New("public key is not PEM encoded")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "error parsing public key")
+	}
+	switch pub.(type) {
+	case *ecdsa.PublicKey, *rsa.PublicKey, ed25519.PublicKey:
+	default:
+		return nil, errors.New("unsupported public key type")
+	}
+	return &credential{id: id, publicKey: pub}, nil
+}
+
+// decode accepts both the padded and unpadded base64url encodings.
+func decode(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+// Challenge returns the options of a WebAuthn assertion request as JSON,
+// ready to be passed to navigator.credentials.get.
+func (p *provider) Challenge(ctx context.Context, u *userpb.User) (string, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	creds, ok := p.credentials[u.GetId().GetOpaqueId()]
+	if !ok {
+		return "", errtypes.NotFound("webauthn: no credentials registered for " + u.Username)
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", errors.Wrap(err, "webauthn: error generating challenge")
+	}
+	value := base64.RawURLEncoding.EncodeToString(buf)
+
+	now := time.Now()
+	for k, c := range p.challenges {
+		if now.After(c.expires) {
+			delete(p.challenges, k)
+		}
+	}
+	p.challenges[value] = &challenge{
+		userID:  u.GetId().GetOpaqueId(),
+		expires: now.Add(time.Duration(p.c.ChallengeExpiration) * time.Second),
+	}
+
+	type allowed struct {
+		Type string `json:"type"`
+		ID   string `json:"id"`
+	}
+	options := struct {
+		Challenge        string    `json:"challenge"`
+		RPID             string    `json:"rpId"`
+		Timeout          int       `json:"timeout"`
+		AllowCredentials []allowed `json:"allowCredentials"`
+		UserVerification string    `json:"userVerification"`
+	}{
+		Challenge:        value,
+		RPID:             p.c.RPID,
+		Timeout:          p.c.ChallengeExpiration * 1000,
+		UserVerification: "preferred",
+	}
+	if p.c.UserVerification {
+		options.UserVerification = "required"
+	}
+	for _, c := range creds {
+		options.AllowCredentials = append(options.AllowCredentials, allowed{
+			Type: "public-key",
+			ID:   base64.RawURLEncoding.EncodeToString(c.id),
+		})
+	}
+
+	b, err := json.Marshal(options)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+type assertion struct {
+	ID                string `json:"id"`
+	ClientDataJSON    string `json:"clientDataJSON"`
+	AuthenticatorData string `json:"authenticatorData"`
+	Signature         string `json:"signature"`
+}
+
+type clientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+// Verify checks a WebAuthn assertion, given as a JSON object with the base64url
+// encoded id, clientDataJSON, authenticatorData and signature of the credential.
+func (p *provider) Verify(ctx context.Context, u *userpb.User, response string) error {
+	var a assertion
+	if err := json.Unmarshal([]byte(response), &a); err != nil {
+		return errtypes.BadRequest("webauthn: invalid assertion")
+	}
+	id, err1 := decode(a.ID)
+	rawClientData, err2 := decode(a.ClientDataJSON)
+	authData, err3 := decode(a.AuthenticatorData)
+	sig, err4 := decode(a.Signature)
+	if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
+		return errtypes.BadRequest("webauthn: invalid assertion encoding")
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	var cred *credential
+	for _, c := range p.credentials[u.GetId().GetOpaqueId()] {
+		if bytes.Equal(c.id, id) {
+			cred = c
+			break
+		}
+	}
+	if cred == nil {
+		return errtypes.InvalidCredentials("webauthn: unknown credential")
+	}
+
+	var cd clientData
+	if err := json.Unmarshal(rawClientData, &cd); err != nil {
+		return errtypes.BadRequest("webauthn: invalid client data")
+	}
+	if cd.Type != "webauthn.get" {
+		return errtypes.InvalidCredentials("webauthn: unexpected client data type " + cd.Type)
+	}
+	if !p.validOrigin(cd.Origin) {
+		return errtypes.InvalidCredentials("webauthn: unexpected origin " + cd.Origin)
+	}
+	// challenges can only be answered once
+	ch, ok := p.challenges[cd.Challenge]
+	delete(p.challenges, cd.Challenge)
+	if !ok || ch.userID != u.GetId().GetOpaqueId() || time.Now().After(ch.expires) {
+		return errtypes.InvalidCredentials("webauthn: unknown or expired challenge")
+	}
+
+	if len(authData) < 37 {
+		return errtypes.BadRequest("webauthn: authenticator data too short")
+	}
+	rpIDHash := sha256.Sum256([]byte(p.c.RPID))
+	if !bytes.Equal(authData[:32], rpIDHash[:]) {
+		return errtypes.InvalidCredentials("webauthn: unexpected relying party")
+	}
+	flags := authData[32]
+	if flags&flagUserPresent == 0 {
+		return errtypes.InvalidCredentials("webauthn: user not present")
+	}
+	if p.c.UserVerification && flags&flagUserVerified == 0 {
+		return errtypes.InvalidCredentials("webauthn: user not verified")
+	}
+
+	clientDataHash := sha256.Sum256(rawClientData)
+	if !verifySignature(cred.publicKey, append(authData[:len(authData):len(authData)], clientDataHash[:]...), sig) {
+		return errtypes.InvalidCredentials("webauthn: invalid signature")
+	}
+
+	// a counter that does not increase hints at a cloned authenticator
+	signCount := binary.BigEndian.Uint32(authData[33:37])
+	if (signCount != 0 || cred.signCount != 0) && signCount <= cred.signCount {
+		return errtypes.InvalidCredentials("webauthn: signature counter did not increase")
+	}
+	cred.signCount = signCount
+	return nil
+}
+
+func (p *provider) validOrigin(origin string) bool {
+	for _, o := range p.c.Origins {
+		if o == origin {
+			return true
+		}
+	}
+	return false
+}
+
+func verifySignature(pub crypto.PublicKey, data, sig []byte) bool {
+	switch k := pub.(type) {
+	case *ecdsa.PublicKey:
+		h := sha256.Sum256(data)
+		return ecdsa.VerifyASN1(k, h[:], sig)
+	case *rsa.PublicKey:
+		h := sha256.Sum256(data)
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, h[:], sig) == nil
+	case ed25519.PublicKey:
+		return ed25519.Verify(k, data, sig)
+	}
+	return false
+}
@@ -0,0 +1,158 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package webauthn
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+)
+
+type authenticator struct {
+	key       *ecdsa.PrivateKey
+	id        []byte
+	signCount uint32
+}
+
+func (a *authenticator) assert(t *testing.T, rpID, origin, challenge string, flags byte) string {
+	t.Helper()
+	a.signCount++
+	clientData, _ := json.Marshal(map[string]string{
+		"type":      "webauthn.get",
+		"challenge": challenge,
+		"origin":    origin,
+	})
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	authData := append(rpIDHash[:], flags, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(authData[33:], a.signCount)
+
+	clientDataHash := sha256.Sum256(clientData)
+	h := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	sig, err := ecdsa.SignASN1(rand.Reader, a.key, h[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	enc := base64.RawURLEncoding.EncodeToString
+	b, _ := json.Marshal(assertion{
+		ID:                enc(a.id),
+		ClientDataJSON:    enc(clientData),
+		AuthenticatorData: enc(authData),
+		Signature:         enc(sig),
+	})
+	return string(b)
+}
+
+func TestVerify(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &authenticator{key: key, id: []byte("credential-1")}
+
+	file := filepath.Join(t.TempDir(), "webauthn.json")
+	creds, _ := json.Marshal(map[string][]*credentialConfig{
+		"4c510ada-c86b-4815-8820-42cdf82c3d51": {{
+			ID:        base64.RawURLEncoding.EncodeToString(a.id),
+			PublicKey: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+		}},
+	})
+	if err := os.WriteFile(file, creds, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	const (
+		rpID   = "cloud.example.org"
+		origin = "https://cloud.example.org"
+	)
+	p, err := New(map[string]interface{}{
+		"rp_id":             rpID,
+		"origins":           []string{origin},
+		"credentials":       file,
+		"user_verification": true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	einstein := &userpb.User{Id: &userpb.UserId{OpaqueId: "4c510ada-c86b-4815-8820-42cdf82c3d51"}, Username: "einstein"}
+	challenge := func() string {
+		opts, err := p.Challenge(ctx, einstein)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var o struct {
+			Challenge string `json:"challenge"`
+		}
+		if err := json.Unmarshal([]byte(opts), &o); err != nil {
+			t.Fatal(err)
+		}
+		return o.Challenge
+	}
+
+	ch := challenge()
+	if err := p.Verify(ctx, einstein, a.assert(t, rpID, origin, ch, flagUserPresent|flagUserVerified)); err != nil {
+		t.Fatalf("expected the assertion to be valid: %v", err)
+	}
+	if err := p.Verify(ctx, einstein, a.assert(t, rpID, origin, ch, flagUserPresent|flagUserVerified)); err == nil {
+		t.Fatal("expected a reused challenge to be rejected")
+	}
+
+	tests := map[string]struct {
+		user   *userpb.User
+		rpID   string
+		origin string
+		flags  byte
+	}{
+		"other user":        {&userpb.User{Id: &userpb.UserId{OpaqueId: "f7fbf8c8-139b-4376-b307-cf0a8c2d0d9c"}, Username: "marie"}, rpID, origin, flagUserPresent | flagUserVerified},
+		"wrong origin":      {einstein, rpID, "https://evil.example.org", flagUserPresent | flagUserVerified},
+		"wrong rp id":       {einstein, "evil.example.org", origin, flagUserPresent | flagUserVerified},
+		"user not verified": {einstein, rpID, origin, flagUserPresent},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := p.Verify(ctx, tt.user, a.assert(t, tt.rpID, tt.origin, challenge(), tt.flags)); err == nil {
+				t.Fatal("expected the assertion to be rejected")
+			}
+		})
+	}
+
+	// a counter going backwards hints at a cloned authenticator
+	a.signCount = 0
+	if err := p.Verify(ctx, einstein, a.assert(t, rpID, origin, challenge(), flagUserPresent|flagUserVerified)); err == nil {
+		t.Fatal("expected a stale signature counter to be rejected")
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package scope
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	authpb "github.com/cs3org/go-cs3apis/cs3/auth/provider/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/rs/zerolog"
+)
+
+// AuthLevel records how the user behind a token has been authenticated.
+type AuthLevel struct {
+	// Factors lists the methods the user authenticated with, e.g. basic and totp.
+	Factors []string `json:"factors"`
+	// Time is the unix time of the authentication.
+	Time int64 `json:"time"`
+}
+
+// Strong returns whether the user passed more than one authentication factor.
+func (l *AuthLevel) Strong() bool {
+	return len(l.Factors) > 1
+}
+
+// Fresh returns whether the authentication happened within the given duration.
+func (l *AuthLevel) Fresh(maxAge time.Duration) bool {
+	return time.Since(time.Unix(l.Time, 0)) <= maxAge
+}
+
+func authLevelScope(_ context.Context, _ *authpb.Scope, _ interface{}, _ *zerolog.Logger) (bool, error) {
+	// The auth level only records the strength of the authentication,
+	// it does not grant access to any resource on its own.
+	return false, nil
+}
+
+// AddAuthLevelScope adds the scope recording the strength of the authentication.
+func AddAuthLevelScope(level *AuthLevel, scopes map[string]*authpb.Scope) (map[string]*authpb.Scope, error) {
+	val, err := json.Marshal(level)
+	if err != nil {
+		return nil, err
+	}
+	if scopes == nil {
+		scopes = make(map[string]*authpb.Scope)
+	}
+	scopes["authlevel"] = &authpb.Scope{
+		Resource: &types.OpaqueEntry{
+			Decoder: "json",
+			Value:   val,
+		},
+	}
+	return scopes, nil
+}
+
+// GetAuthLevel returns the auth level recorded in the scopes, if any.
+func GetAuthLevel(scopes map[string]*authpb.Scope) (*AuthLevel, bool) {
+	s, ok := scopes["authlevel"]
+	if !ok || s.Resource == nil {
+		return nil, false
+	}
+	var level AuthLevel
+	if err := json.Unmarshal(s.Resource.Value, &level); err != nil {
+		return nil, false
+	}
+	return &level, true
+}
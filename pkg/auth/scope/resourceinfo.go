@@ -41,45 +41,57 @@ func resourceinfoScope(_ context.Context, scope *authpb.Scope, resource interfac
 		return false, err
 	}
 
+	if path, ok := resource.(string); ok {
+		return checkResourcePath(path), nil
+	}
+	if allowed, ok := checkStorageRequest(scope, resource, func(ref *provider.Reference) bool {
+		return checkResourceInfo(&r, ref)
+	}); ok {
+		return allowed, nil
+	}
+
+	msg := fmt.Sprintf("resource type assertion failed: %+v", resource)
+	logger.Debug().Str("scope", "resourceinfoScope").Msg(msg)
+	return false, errtypes.InternalError(msg)
+}
+
+// checkStorageRequest checks the references of the storage requests against
+// the scope, requiring the editor role for the ones modifying resources.
+// The second return value is false when the request is not a storage one.
+func checkStorageRequest(scope *authpb.Scope, resource interface{}, check func(*provider.Reference) bool) (bool, bool) {
 	switch v := resource.(type) {
 	// Viewer role
 	case *registry.GetStorageProvidersRequest:
-		return checkResourceInfo(&r, v.GetRef()), nil
+		return check(v.GetRef()), true
 	case *provider.StatRequest:
-		return checkResourceInfo(&r, v.GetRef()), nil
+		return check(v.GetRef()), true
 	case *provider.ListContainerRequest:
-		return checkResourceInfo(&r, v.GetRef()), nil
+		return check(v.GetRef()), true
 	case *provider.InitiateFileDownloadRequest:
-		return checkResourceInfo(&r, v.GetRef()), nil
+		return check(v.GetRef()), true
 	case *appprovider.OpenInAppRequest:
-		return checkResourceInfo(&r, &provider.Reference{ResourceId: v.ResourceInfo.Id}), nil
+		return check(&provider.Reference{ResourceId: v.ResourceInfo.Id}), true
 	case *gateway.OpenInAppRequest:
-		return checkResourceInfo(&r, v.GetRef()), nil
+		return check(v.GetRef()), true
 
 	// Editor role
 	// need to return appropriate status codes in the ocs/ocdav layers.
 	case *provider.CreateContainerRequest:
-		return hasRoleEditor(*scope) && checkResourceInfo(&r, v.GetRef()), nil
+		return hasRoleEditor(*scope) && check(v.GetRef()), true
 	case *provider.TouchFileRequest:
-		return hasRoleEditor(*scope) && checkResourceInfo(&r, v.GetRef()), nil
+		return hasRoleEditor(*scope) && check(v.GetRef()), true
 	case *provider.DeleteRequest:
-		return hasRoleEditor(*scope) && checkResourceInfo(&r, v.GetRef()), nil
+		return hasRoleEditor(*scope) && check(v.GetRef()), true
 	case *provider.MoveRequest:
-		return hasRoleEditor(*scope) && checkResourceInfo(&r, v.GetSource()) && checkResourceInfo(&r, v.GetDestination()), nil
+		return hasRoleEditor(*scope) && check(v.GetSource()) && check(v.GetDestination()), true
 	case *provider.InitiateFileUploadRequest:
-		return hasRoleEditor(*scope) && checkResourceInfo(&r, v.GetRef()), nil
+		return hasRoleEditor(*scope) && check(v.GetRef()), true
 	case *provider.SetArbitraryMetadataRequest:
-		return hasRoleEditor(*scope) && checkResourceInfo(&r, v.GetRef()), nil
+		return hasRoleEditor(*scope) && check(v.GetRef()), true
 	case *provider.UnsetArbitraryMetadataRequest:
-		return hasRoleEditor(*scope) && checkResourceInfo(&r, v.GetRef()), nil
-
-	case string:
-		return checkResourcePath(v), nil
+		return hasRoleEditor(*scope) && check(v.GetRef()), true
 	}
-
-	msg := fmt.Sprintf("resource type assertion failed: %+v", resource)
-	logger.Debug().Str("scope", "resourceinfoScope").Msg(msg)
-	return false, errtypes.InternalError(msg)
+	return false, false
 }
 
 func checkResourceInfo(inf *provider.ResourceInfo, ref *provider.Reference) bool {
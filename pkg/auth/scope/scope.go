@@ -37,6 +37,8 @@ var supportedScopes = map[string]Verifier{
 	"share":         shareScope,
 	"receivedshare": receivedShareScope,
 	"lightweight":   lightweightAccountScope,
+	"authlevel":     authLevelScope,
+	"space":         spaceScope,
 }
 
 // VerifyScope is the function to be called when dismantling tokens to check if
@@ -0,0 +1,112 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package scope
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	authpb "github.com/cs3org/go-cs3apis/cs3/auth/provider/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/utils"
+	"github.com/rs/zerolog"
+)
+
+func spaceScope(_ context.Context, scope *authpb.Scope, resource interface{}, logger *zerolog.Logger) (bool, error) {
+	var space provider.StorageSpace
+	err := utils.UnmarshalJSONToProtoV1(scope.Resource.Value, &space)
+	if err != nil {
+		return false, err
+	}
+
+	switch v := resource.(type) {
+	case *provider.ListStorageSpacesRequest:
+		return checkSpaceFilters(&space, v.GetFilters()), nil
+	case string:
+		return checkSpacePath(&space, v) || checkResourcePath(v), nil
+	}
+	if allowed, ok := checkStorageRequest(scope, resource, func(ref *provider.Reference) bool {
+		return checkSpaceRef(&space, ref)
+	}); ok {
+		return allowed, nil
+	}
+
+	msg := fmt.Sprintf("resource type assertion failed: %+v", resource)
+	logger.Debug().Str("scope", "spaceScope").Msg(msg)
+	return false, errtypes.InternalError(msg)
+}
+
+// checkSpaceRef only allows references relative to the root of the space,
+// as the space a resource id belongs to cannot be resolved from here.
+func checkSpaceRef(space *provider.StorageSpace, ref *provider.Reference) bool {
+	id := ref.GetResourceId()
+	if id == nil || id.StorageId != space.Root.GetStorageId() || id.OpaqueId != space.Root.GetOpaqueId() {
+		return false
+	}
+	p := path.Clean(ref.Path)
+	return p != ".." && !strings.HasPrefix(p, "../") && !path.IsAbs(p)
+}
+
+// checkSpaceFilters only allows looking up the space itself.
+func checkSpaceFilters(space *provider.StorageSpace, filters []*provider.ListStorageSpacesRequest_Filter) bool {
+	if len(filters) == 0 {
+		return false
+	}
+	for _, f := range filters {
+		if f.Type != provider.ListStorageSpacesRequest_Filter_TYPE_ID || f.GetId().GetOpaqueId() != space.Id.GetOpaqueId() {
+			return false
+		}
+	}
+	return true
+}
+
+func checkSpacePath(space *provider.StorageSpace, p string) bool {
+	prefix := "/dav/spaces/" + space.Id.GetOpaqueId()
+	i := strings.Index(p, prefix)
+	if i < 0 {
+		return false
+	}
+	rest := p[i+len(prefix):]
+	return rest == "" || strings.HasPrefix(rest, "/")
+}
+
+// AddStorageSpaceScope adds the scope to allow access to a storage space.
+func AddStorageSpaceScope(space *provider.StorageSpace, role authpb.Role, scopes map[string]*authpb.Scope) (map[string]*authpb.Scope, error) {
+	// Only expose the fields required to check the access to the scope.
+	scopeSpace := &provider.StorageSpace{Id: space.Id, Root: space.Root}
+	val, err := utils.MarshalProtoV1ToJSON(scopeSpace)
+	if err != nil {
+		return nil, err
+	}
+	if scopes == nil {
+		scopes = make(map[string]*authpb.Scope)
+	}
+	scopes["space:"+space.Id.GetOpaqueId()] = &authpb.Scope{
+		Resource: &types.OpaqueEntry{
+			Decoder: "json",
+			Value:   val,
+		},
+		Role: role,
+	}
+	return scopes, nil
+}
@@ -21,19 +21,98 @@ package scope
 import (
 	"context"
 
+	appprovider "github.com/cs3org/go-cs3apis/cs3/app/provider/v1beta1"
+	appregistry "github.com/cs3org/go-cs3apis/cs3/app/registry/v1beta1"
+	appauthpb "github.com/cs3org/go-cs3apis/cs3/auth/applications/v1beta1"
 	authpb "github.com/cs3org/go-cs3apis/cs3/auth/provider/v1beta1"
+	authregistry "github.com/cs3org/go-cs3apis/cs3/auth/registry/v1beta1"
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	grouppb "github.com/cs3org/go-cs3apis/cs3/identity/group/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	invitepb "github.com/cs3org/go-cs3apis/cs3/ocm/invite/v1beta1"
+	ocmprovider "github.com/cs3org/go-cs3apis/cs3/ocm/provider/v1beta1"
+	permissions "github.com/cs3org/go-cs3apis/cs3/permissions/v1beta1"
+	preferences "github.com/cs3org/go-cs3apis/cs3/preferences/v1beta1"
+	collaboration "github.com/cs3org/go-cs3apis/cs3/sharing/collaboration/v1beta1"
+	link "github.com/cs3org/go-cs3apis/cs3/sharing/link/v1beta1"
+	ocm "github.com/cs3org/go-cs3apis/cs3/sharing/ocm/v1beta1"
 	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	registry "github.com/cs3org/go-cs3apis/cs3/storage/registry/v1beta1"
+	tx "github.com/cs3org/go-cs3apis/cs3/tx/v1beta1"
 	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
 	"github.com/cs3org/reva/pkg/utils"
 	"github.com/rs/zerolog"
 )
 
 func userScope(_ context.Context, scope *authpb.Scope, resource interface{}, _ *zerolog.Logger) (bool, error) {
-	// Always return true. Registered users can access all paths.
+	// Registered users can access all paths, read-only scopes
+	// are only granted the requests reading resources.
 	// TODO(ishank011): Add checks for read/write permissions.
+	if scope.Role == authpb.Role_ROLE_VIEWER {
+		return isReadRequest(resource), nil
+	}
 	return true, nil
 }
 
+// isReadRequest returns whether the request only reads resources. This is an
+// allow-list, so that requests added to the APIs are denied until reviewed.
+func isReadRequest(resource interface{}) bool {
+	switch v := resource.(type) {
+	case string:
+		// the requests to the HTTP services are checked again
+		// when they reach the gRPC services
+		return true
+	case *provider.StatRequest, *provider.ListContainerRequest, *provider.ListContainerStreamRequest,
+		*provider.InitiateFileDownloadRequest, *provider.GetPathRequest, *provider.GetQuotaRequest,
+		*provider.GetHomeRequest, *provider.GetLockRequest, *provider.ListFileVersionsRequest,
+		*provider.ListGrantsRequest, *provider.ListRecycleRequest, *provider.ListRecycleStreamRequest,
+		*provider.ListStorageSpacesRequest:
+		return true
+	case *registry.GetStorageProvidersRequest, *registry.ListStorageProvidersRequest, *registry.GetHomeRequest:
+		return true
+	case *gateway.WhoAmIRequest, *gateway.GetQuotaRequest:
+		return true
+	case *gateway.OpenInAppRequest:
+		return v.GetViewMode() == gateway.OpenInAppRequest_VIEW_MODE_VIEW_ONLY ||
+			v.GetViewMode() == gateway.OpenInAppRequest_VIEW_MODE_READ_ONLY ||
+			v.GetViewMode() == gateway.OpenInAppRequest_VIEW_MODE_PREVIEW
+	case *appprovider.OpenInAppRequest:
+		return v.GetViewMode() == appprovider.OpenInAppRequest_VIEW_MODE_VIEW_ONLY ||
+			v.GetViewMode() == appprovider.OpenInAppRequest_VIEW_MODE_READ_ONLY ||
+			v.GetViewMode() == appprovider.OpenInAppRequest_VIEW_MODE_PREVIEW
+	case *appregistry.GetAppProvidersRequest, *appregistry.ListAppProvidersRequest,
+		*appregistry.GetDefaultAppProviderForMimeTypeRequest, *appregistry.ListSupportedMimeTypesRequest:
+		return true
+	case *authregistry.GetAuthProvidersRequest, *authregistry.ListAuthProvidersRequest:
+		return true
+	case *appauthpb.GetAppPasswordRequest, *appauthpb.ListAppPasswordsRequest:
+		return true
+	case *userpb.GetUserRequest, *userpb.GetUserByClaimRequest, *userpb.GetUserGroupsRequest, *userpb.FindUsersRequest:
+		return true
+	case *grouppb.GetGroupRequest, *grouppb.GetGroupByClaimRequest, *grouppb.GetMembersRequest, *grouppb.FindGroupsRequest:
+		return true
+	case *collaboration.GetShareRequest, *collaboration.ListSharesRequest,
+		*collaboration.GetReceivedShareRequest, *collaboration.ListReceivedSharesRequest:
+		return true
+	case *link.GetPublicShareRequest, *link.GetPublicShareByTokenRequest, *link.ListPublicSharesRequest:
+		return true
+	case *ocm.GetOCMShareRequest, *ocm.ListOCMSharesRequest,
+		*ocm.GetReceivedOCMShareRequest, *ocm.ListReceivedOCMSharesRequest:
+		return true
+	case *invitepb.GetAcceptedUserRequest, *invitepb.FindAcceptedUsersRequest:
+		return true
+	case *ocmprovider.GetInfoByDomainRequest, *ocmprovider.IsProviderAllowedRequest, *ocmprovider.ListAllProvidersRequest:
+		return true
+	case *preferences.GetKeyRequest:
+		return true
+	case *permissions.CheckPermissionRequest:
+		return true
+	case *tx.GetTransferStatusRequest, *tx.ListTransfersRequest:
+		return true
+	}
+	return false
+}
+
 // AddOwnerScope adds the default owner scope with access to all resources.
 func AddOwnerScope(scopes map[string]*authpb.Scope) (map[string]*authpb.Scope, error) {
 	ref := &provider.Reference{Path: "/"}
@@ -53,3 +132,13 @@ func AddOwnerScope(scopes map[string]*authpb.Scope) (map[string]*authpb.Scope, e
 	}
 	return scopes, nil
 }
+
+// AddReadOnlyScope adds the scope with read-only access to all resources of the user.
+func AddReadOnlyScope(scopes map[string]*authpb.Scope) (map[string]*authpb.Scope, error) {
+	scopes, err := AddOwnerScope(scopes)
+	if err != nil {
+		return nil, err
+	}
+	scopes["user"].Role = authpb.Role_ROLE_VIEWER
+	return scopes, nil
+}
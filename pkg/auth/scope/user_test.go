@@ -0,0 +1,68 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package scope
+
+import (
+	"context"
+	"testing"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	invitepb "github.com/cs3org/go-cs3apis/cs3/ocm/invite/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	tx "github.com/cs3org/go-cs3apis/cs3/tx/v1beta1"
+	"github.com/rs/zerolog"
+)
+
+func TestReadOnlyUserScope(t *testing.T) {
+	scopes, err := AddReadOnlyScope(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := zerolog.Nop()
+
+	tests := []struct {
+		name     string
+		resource interface{}
+		allowed  bool
+	}{
+		{"stat", &provider.StatRequest{}, true},
+		{"download", &provider.InitiateFileDownloadRequest{}, true},
+		{"http path", "/remote.php/webdav/file", true},
+		{"view in app", &gateway.OpenInAppRequest{ViewMode: gateway.OpenInAppRequest_VIEW_MODE_READ_ONLY}, true},
+		{"edit in app", &gateway.OpenInAppRequest{ViewMode: gateway.OpenInAppRequest_VIEW_MODE_READ_WRITE}, false},
+		{"upload", &provider.InitiateFileUploadRequest{}, false},
+		{"accept invite", &invitepb.AcceptInviteRequest{}, false},
+		{"forward invite", &invitepb.ForwardInviteRequest{}, false},
+		{"pull transfer", &tx.PullTransferRequest{}, false},
+	}
+	for _, tt := range tests {
+		allowed, err := userScope(context.Background(), scopes["user"], tt.resource, &log)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if allowed != tt.allowed {
+			t.Errorf("%s: expected allowed to be %v", tt.name, tt.allowed)
+		}
+	}
+
+	owner, _ := AddOwnerScope(nil)
+	if allowed, _ := userScope(context.Background(), owner["user"], &provider.InitiateFileUploadRequest{}, &log); !allowed {
+		t.Error("expected the owner scope to allow uploads")
+	}
+}
@@ -19,8 +19,10 @@
 package scope
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	authpb "github.com/cs3org/go-cs3apis/cs3/auth/provider/v1beta1"
 	link "github.com/cs3org/go-cs3apis/cs3/sharing/link/v1beta1"
@@ -56,6 +58,19 @@ func FormatScope(scopeType string, scope *authpb.Scope) (string, error) {
 			return "", err
 		}
 		return fmt.Sprintf("path:\"%s\" %s", resInfo.Path, scope.Role.String()), nil
+	case strings.HasPrefix(scopeType, "space"):
+		var space provider.StorageSpace
+		err := utils.UnmarshalJSONToProtoV1(scope.Resource.Value, &space)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("space:\"%s\" %s", space.Id.GetOpaqueId(), scope.Role.String()), nil
+	case strings.HasPrefix(scopeType, "authlevel"):
+		var level AuthLevel
+		if err := json.Unmarshal(scope.Resource.Value, &level); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("factors:\"%s\" at %s", strings.Join(level.Factors, ","), time.Unix(level.Time, 0).UTC().Format(time.RFC3339)), nil
 	default:
 		return "", errtypes.NotSupported("scope not yet supported")
 	}
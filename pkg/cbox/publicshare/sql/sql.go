@@ -36,6 +36,7 @@ import (
 	conversions "github.com/cs3org/reva/pkg/cbox/utils"
 	ctxpkg "github.com/cs3org/reva/pkg/ctx"
 	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/health"
 	"github.com/cs3org/reva/pkg/publicshare"
 	"github.com/cs3org/reva/pkg/publicshare/manager/registry"
 	"github.com/cs3org/reva/pkg/sharedconf"
@@ -118,6 +119,8 @@ func New(m map[string]interface{}) (publicshare.Manager, error) {
 		return nil, err
 	}
 
+	health.Register(fmt.Sprintf("publicshare:cbox:%s:%d/%s", c.DBHost, c.DBPort, c.DBName), health.SQL(db))
+
 	mgr := manager{
 		c:  c,
 		db: db,
@@ -34,6 +34,7 @@ import (
 	conversions "github.com/cs3org/reva/pkg/cbox/utils"
 	ctxpkg "github.com/cs3org/reva/pkg/ctx"
 	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/health"
 	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
 	"github.com/cs3org/reva/pkg/share"
 	"github.com/cs3org/reva/pkg/share/manager/registry"
@@ -88,6 +89,8 @@ func New(m map[string]interface{}) (share.Manager, error) {
 		return nil, err
 	}
 
+	health.Register(fmt.Sprintf("share:cbox:%s:%d/%s", c.DBHost, c.DBPort, c.DBName), health.SQL(db))
+
 	return &mgr{
 		c:  c,
 		db: db,
@@ -0,0 +1,42 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ctx
+
+import (
+	"context"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// ClientIPHeader is the header used to forward the IP address of the client
+// that sent the original HTTP request.
+const ClientIPHeader = "x-client-ip"
+
+// ContextGetClientIP returns the IP address of the client if set in the given context.
+func ContextGetClientIP(ctx context.Context) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+	lst, ok := md[ClientIPHeader]
+	if !ok || len(lst) == 0 {
+		return "", false
+	}
+	return lst[0], true
+}
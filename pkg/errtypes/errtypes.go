@@ -122,6 +122,15 @@ func (e InsufficientStorage) IsInsufficientStorage() {}
 // https://developer.mozilla.org/en-US/docs/Web/HTTP/Status/507
 const StatusInssufficientStorage = 507
 
+// Unavailable is the error to use when a service is temporarily unavailable,
+// e.g. because it is under maintenance.
+type Unavailable string
+
+func (e Unavailable) Error() string { return "error: unavailable: " + string(e) }
+
+// IsUnavailable implements the IsUnavailable interface.
+func (e Unavailable) IsUnavailable() {}
+
 // IsNotFound is the interface to implement
 // to specify that an a resource is not found.
 type IsNotFound interface {
@@ -187,3 +196,9 @@ type IsChecksumMismatch interface {
 type IsInsufficientStorage interface {
 	IsInsufficientStorage()
 }
+
+// IsUnavailable is the interface to implement
+// to specify that a service is temporarily unavailable.
+type IsUnavailable interface {
+	IsUnavailable()
+}
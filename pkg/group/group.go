@@ -33,3 +33,15 @@ type Manager interface {
 	GetMembers(ctx context.Context, gid *grouppb.GroupId) ([]*userpb.UserId, error)
 	HasMember(ctx context.Context, gid *grouppb.GroupId, uid *userpb.UserId) (bool, error)
 }
+
+// WritableManager is the interface to implement by group managers which
+// support provisioning groups, e.g. through SCIM.
+type WritableManager interface {
+	Manager
+	// CreateGroup stores a new group; if the ID of the group is empty, a new one is assigned.
+	CreateGroup(ctx context.Context, g *grouppb.Group) (*grouppb.Group, error)
+	// UpdateGroup replaces the stored data, including the members, of the group identified by the ID of g.
+	UpdateGroup(ctx context.Context, g *grouppb.Group) (*grouppb.Group, error)
+	// DeleteGroup removes the group identified by a gid.
+	DeleteGroup(ctx context.Context, gid *grouppb.GroupId) error
+}
@@ -22,14 +22,20 @@ import (
 	"context"
 	"encoding/json"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	grouppb "github.com/cs3org/go-cs3apis/cs3/identity/group/v1beta1"
 	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
 	"github.com/cs3org/reva/pkg/errtypes"
 	"github.com/cs3org/reva/pkg/group"
 	"github.com/cs3org/reva/pkg/group/manager/registry"
+	"github.com/cs3org/reva/pkg/utils/filelock"
+	"github.com/golang/protobuf/proto"
+	"github.com/google/uuid"
 	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
 )
@@ -39,7 +45,12 @@ func init() {
 }
 
 type manager struct {
-	groups []*grouppb.Group
+	sync.Mutex
+
+	file    string
+	modTime time.Time
+	size    int64
+	groups  []*grouppb.Group
 }
 
 type config struct {
@@ -64,31 +75,124 @@ func parseConfig(m map[string]interface{}) (*config, error) {
 }
 
 // New returns a group manager implementation that reads a json file to provide group metadata.
+// The manager is writable: groups created, updated or deleted are persisted to the same file.
 func New(m map[string]interface{}) (group.Manager, error) {
 	c, err := parseConfig(m)
 	if err != nil {
 		return nil, err
 	}
 
-	f, err := os.ReadFile(c.Groups)
-	if err != nil {
+	mgr := &manager{
+		file: c.Groups,
+	}
+	if err := mgr.reload(); err != nil {
 		return nil, err
 	}
+	return mgr, nil
+}
+
+// reload reads the groups file again if it has been modified since it was last read,
+// e.g. by another manager instance writing to the same file.
+// The caller must hold the lock.
+func (m *manager) reload() error {
+	info, err := os.Stat(m.file)
+	if err != nil {
+		return err
+	}
+	if m.groups != nil && info.ModTime().Equal(m.modTime) && info.Size() == m.size {
+		return nil
+	}
+
+	f, err := os.ReadFile(m.file)
+	if err != nil {
+		return err
+	}
 
 	groups := []*grouppb.Group{}
 
 	err = json.Unmarshal(f, &groups)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	m.groups = groups
+	m.modTime = info.ModTime()
+	m.size = info.Size()
+	return nil
+}
+
+// lock takes the lock of the manager and the lock file next to the groups
+// file, so that the managers sharing the file do not overwrite each other's
+// changes, then reads the file again if needed.
+// The returned function releases both locks.
+func (m *manager) lock() (func(), error) {
+	m.Lock()
+	unlockFile, err := filelock.Lock(m.file + ".lock")
+	if err != nil {
+		m.Unlock()
+		return nil, errors.Wrap(err, "json: error locking groups file")
+	}
+	unlock := func() {
+		unlockFile()
+		m.Unlock()
+	}
+	if err := m.reload(); err != nil {
+		unlock()
+		return nil, errors.Wrap(err, "json: error reading groups file")
+	}
+	return unlock, nil
+}
+
+// snapshot returns the current list of groups, reloading the file if needed.
+func (m *manager) snapshot() ([]*grouppb.Group, error) {
+	m.Lock()
+	defer m.Unlock()
+	if err := m.reload(); err != nil {
+		return nil, errors.Wrap(err, "json: error reading groups file")
+	}
+	return m.groups, nil
+}
+
+// persist atomically writes the groups to the file.
+// The caller must hold the lock returned by lock.
+func (m *manager) persist(groups []*grouppb.Group) error {
+	data, err := json.MarshalIndent(groups, "", "\t")
+	if err != nil {
+		return errors.Wrap(err, "json: error marshalling groups")
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(m.file), filepath.Base(m.file)+".*")
+	if err != nil {
+		return errors.Wrap(err, "json: error creating temporary file")
 	}
+	defer os.Remove(tmp.Name())
 
-	return &manager{
-		groups: groups,
-	}, nil
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "json: error writing groups file")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "json: error writing groups file")
+	}
+	if err := os.Rename(tmp.Name(), m.file); err != nil {
+		return errors.Wrap(err, "json: error replacing groups file")
+	}
+
+	info, err := os.Stat(m.file)
+	if err != nil {
+		return err
+	}
+	m.groups = groups
+	m.modTime = info.ModTime()
+	m.size = info.Size()
+	return nil
 }
 
 func (m *manager) GetGroup(ctx context.Context, gid *grouppb.GroupId, skipFetchingMembers bool) (*grouppb.Group, error) {
-	for _, g := range m.groups {
+	groups, err := m.snapshot()
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range groups {
 		if g.Id.GetOpaqueId() == gid.OpaqueId || g.GroupName == gid.OpaqueId {
 			group := *g
 			if skipFetchingMembers {
@@ -101,7 +205,11 @@ func (m *manager) GetGroup(ctx context.Context, gid *grouppb.GroupId, skipFetchi
 }
 
 func (m *manager) GetGroupByClaim(ctx context.Context, claim, value string, skipFetchingMembers bool) (*grouppb.Group, error) {
-	for _, g := range m.groups {
+	groups, err := m.snapshot()
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range groups {
 		if groupClaim, err := extractClaim(g, claim); err == nil && value == groupClaim {
 			group := *g
 			if skipFetchingMembers {
@@ -128,8 +236,12 @@ func extractClaim(g *grouppb.Group, claim string) (string, error) {
 }
 
 func (m *manager) FindGroups(ctx context.Context, query string, skipFetchingMembers bool) ([]*grouppb.Group, error) {
+	all, err := m.snapshot()
+	if err != nil {
+		return nil, err
+	}
 	groups := []*grouppb.Group{}
-	for _, g := range m.groups {
+	for _, g := range all {
 		if groupContains(g, query) {
 			group := *g
 			if skipFetchingMembers {
@@ -148,7 +260,11 @@ func groupContains(g *grouppb.Group, query string) bool {
 }
 
 func (m *manager) GetMembers(ctx context.Context, gid *grouppb.GroupId) ([]*userpb.UserId, error) {
-	for _, g := range m.groups {
+	groups, err := m.snapshot()
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range groups {
 		if g.Id.GetOpaqueId() == gid.OpaqueId || g.GroupName == gid.OpaqueId {
 			return g.Members, nil
 		}
@@ -169,3 +285,96 @@ func (m *manager) HasMember(ctx context.Context, gid *grouppb.GroupId, uid *user
 	}
 	return false, nil
 }
+
+func (m *manager) CreateGroup(ctx context.Context, g *grouppb.Group) (*grouppb.Group, error) {
+	if g.GroupName == "" {
+		return nil, errtypes.BadRequest("json: group name must not be empty")
+	}
+
+	unlock, err := m.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	ng := proto.Clone(g).(*grouppb.Group)
+	if ng.Id == nil {
+		ng.Id = &grouppb.GroupId{}
+	}
+	if ng.Id.OpaqueId == "" {
+		ng.Id.OpaqueId = uuid.New().String()
+	}
+
+	for _, e := range m.groups {
+		if e.Id.GetOpaqueId() == ng.Id.OpaqueId || e.GroupName == ng.GroupName {
+			return nil, errtypes.AlreadyExists(ng.GroupName)
+		}
+	}
+
+	groups := append(append([]*grouppb.Group{}, m.groups...), ng)
+	if err := m.persist(groups); err != nil {
+		return nil, err
+	}
+	return proto.Clone(ng).(*grouppb.Group), nil
+}
+
+func (m *manager) UpdateGroup(ctx context.Context, g *grouppb.Group) (*grouppb.Group, error) {
+	if g.Id.GetOpaqueId() == "" {
+		return nil, errtypes.BadRequest("json: group id must not be empty")
+	}
+
+	unlock, err := m.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	idx := -1
+	for i, e := range m.groups {
+		if e.Id.GetOpaqueId() == g.Id.OpaqueId {
+			idx = i
+		} else if g.GroupName != "" && e.GroupName == g.GroupName {
+			return nil, errtypes.AlreadyExists(g.GroupName)
+		}
+	}
+	if idx == -1 {
+		return nil, errtypes.NotFound(g.Id.OpaqueId)
+	}
+
+	ng := proto.Clone(g).(*grouppb.Group)
+	if ng.Id.Idp == "" {
+		ng.Id.Idp = m.groups[idx].Id.GetIdp()
+	}
+	if ng.GroupName == "" {
+		ng.GroupName = m.groups[idx].GroupName
+	}
+
+	groups := append([]*grouppb.Group{}, m.groups...)
+	groups[idx] = ng
+	if err := m.persist(groups); err != nil {
+		return nil, err
+	}
+	return proto.Clone(ng).(*grouppb.Group), nil
+}
+
+func (m *manager) DeleteGroup(ctx context.Context, gid *grouppb.GroupId) error {
+	unlock, err := m.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	groups := make([]*grouppb.Group, 0, len(m.groups))
+	found := false
+	for _, e := range m.groups {
+		if e.Id.GetOpaqueId() == gid.GetOpaqueId() {
+			found = true
+			continue
+		}
+		groups = append(groups, e)
+	}
+	if !found {
+		return errtypes.NotFound(gid.GetOpaqueId())
+	}
+	return m.persist(groups)
+}
@@ -21,12 +21,14 @@ package json
 import (
 	"context"
 	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 
 	grouppb "github.com/cs3org/go-cs3apis/cs3/identity/group/v1beta1"
 	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
 	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/group"
 )
 
 var ctx = context.Background()
@@ -169,3 +171,78 @@ func TestUserManager(t *testing.T) {
 		t.Fatalf("group differ: expected=%v got=%v", "sailing-lovers", resFind[0].GroupName)
 	}
 }
+
+func TestWritableGroupManager(t *testing.T) {
+	tempdir, err := os.MkdirTemp("", "json_test")
+	if err != nil {
+		t.Fatalf("error while create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempdir)
+
+	file := filepath.Join(tempdir, "groups.json")
+	if err := os.WriteFile(file, []byte(`[{"id":{"opaque_id":"sailing-lovers"},"group_name":"sailing-lovers","mail":"sailing-lovers@example.org","display_name":"Sailing Lovers","gid_number":1234,"members":[{"idp":"localhost","opaque_id":"einstein","type":1}]}]`), 0600); err != nil {
+		t.Fatalf("error while writing temp file: %v", err)
+	}
+
+	input := map[string]interface{}{
+		"groups": file,
+	}
+	mgr, err := New(input)
+	if err != nil {
+		t.Fatalf("error while get manager: %v", err)
+	}
+	manager := mgr.(group.WritableManager)
+
+	// a second instance reading the same file must see the changes of the first one
+	other, err := New(input)
+	if err != nil {
+		t.Fatalf("error while get manager: %v", err)
+	}
+
+	// create
+	created, err := manager.CreateGroup(ctx, &grouppb.Group{GroupName: "physics-lovers", DisplayName: "Physics Lovers"})
+	if err != nil {
+		t.Fatalf("error while creating group: %v", err)
+	}
+	if created.Id.OpaqueId == "" {
+		t.Fatalf("group id not assigned")
+	}
+	if _, err := manager.CreateGroup(ctx, &grouppb.Group{GroupName: "sailing-lovers"}); !reflect.DeepEqual(err, errtypes.AlreadyExists("sailing-lovers")) {
+		t.Fatalf("expected an already exists error, got %v", err)
+	}
+
+	// update the members
+	marie := &userpb.UserId{Idp: "localhost", OpaqueId: "marie", Type: userpb.UserType_USER_TYPE_PRIMARY}
+	created.Members = []*userpb.UserId{marie}
+	if _, err := manager.UpdateGroup(ctx, created); err != nil {
+		t.Fatalf("error while updating group: %v", err)
+	}
+	ok, err := other.HasMember(ctx, created.Id, marie)
+	if err != nil || !ok {
+		t.Fatalf("member not found by other instance: %v", err)
+	}
+	if _, err := manager.UpdateGroup(ctx, &grouppb.Group{Id: &grouppb.GroupId{OpaqueId: "unknown"}}); err == nil {
+		t.Fatalf("expected an error when updating an unknown group")
+	}
+
+	// delete
+	if err := manager.DeleteGroup(ctx, created.Id); err != nil {
+		t.Fatalf("error while deleting group: %v", err)
+	}
+	if _, err := other.GetGroup(ctx, created.Id, false); err == nil {
+		t.Fatalf("deleted group still found")
+	}
+	if err := manager.DeleteGroup(ctx, created.Id); err == nil {
+		t.Fatalf("expected an error when deleting an unknown group")
+	}
+
+	// the file stays readable by a fresh manager
+	fresh, err := New(input)
+	if err != nil {
+		t.Fatalf("error while get manager: %v", err)
+	}
+	members, err := fresh.GetMembers(ctx, &grouppb.GroupId{OpaqueId: "sailing-lovers"})
+	if err != nil || len(members) != 1 || members[0].OpaqueId != "einstein" {
+		t.Fatalf("unexpected members after reload: %v %v", members, err)
+	}
+}
@@ -33,7 +33,9 @@ import (
 	"github.com/cs3org/reva/pkg/errtypes"
 	"github.com/cs3org/reva/pkg/group"
 	"github.com/cs3org/reva/pkg/group/manager/registry"
+	"github.com/cs3org/reva/pkg/health"
 	"github.com/cs3org/reva/pkg/utils"
+	"github.com/cs3org/reva/pkg/utils/ldapcache"
 	"github.com/go-ldap/ldap/v3"
 	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
@@ -47,6 +49,7 @@ type manager struct {
 	c            *config
 	groupfilter  *template.Template
 	memberfilter *template.Template
+	searcher     *ldapcache.Searcher
 }
 
 type config struct {
@@ -59,6 +62,10 @@ type config struct {
 	Idp             string     `mapstructure:"idp"`
 	Schema          attributes `mapstructure:"schema"`
 	Nobody          int64      `mapstructure:"nobody"`
+	// Cache holds the settings of the lookup cache
+	Cache ldapcache.Config `mapstructure:"cache"`
+	// Nested holds the settings for resolving members of nested groups
+	Nested utils.LDAPNestedGroups `mapstructure:",squash"`
 }
 
 type attributes struct {
@@ -110,10 +117,20 @@ func New(m map[string]interface{}) (group.Manager, error) {
 		c.FindFilter = c.GroupFilter
 	}
 	c.MemberFilter = strings.ReplaceAll(c.MemberFilter, "%s", "{{.OpaqueId}}")
+	if err := c.Nested.Init(); err != nil {
+		return nil, err
+	}
+
+	cache, err := ldapcache.New(&c.Cache, "groupprovider:ldap:")
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating the lookup cache")
+	}
 
 	mgr := &manager{
-		c: c,
+		c:        c,
+		searcher: ldapcache.NewSearcher(utils.GetLDAPPool(&c.LDAPConn), cache),
 	}
+	health.Register(fmt.Sprintf("groupprovider:ldap:%s:%d", c.Hostname, c.Port), health.LDAP(&c.LDAPConn))
 
 	mgr.groupfilter, err = template.New("gf").Funcs(sprig.TxtFuncMap()).Parse(c.GroupFilter)
 	if err != nil {
@@ -131,12 +148,6 @@ func New(m map[string]interface{}) (group.Manager, error) {
 
 func (m *manager) GetGroup(ctx context.Context, gid *grouppb.GroupId, skipFetchingMembers bool) (*grouppb.Group, error) {
 	log := appctx.GetLogger(ctx)
-	l, err := utils.GetLDAPConnection(&m.c.LDAPConn)
-	if err != nil {
-		return nil, err
-	}
-	defer l.Close()
-
 	// Search for the given clientID
 	searchRequest := ldap.NewSearchRequest(
 		m.c.BaseDN,
@@ -146,7 +157,7 @@ func (m *manager) GetGroup(ctx context.Context, gid *grouppb.GroupId, skipFetchi
 		nil,
 	)
 
-	sr, err := l.Search(searchRequest)
+	sr, err := m.searcher.Search(searchRequest)
 	if err != nil {
 		return nil, err
 	}
@@ -207,12 +218,6 @@ func (m *manager) GetGroupByClaim(ctx context.Context, claim, value string, skip
 	}
 
 	log := appctx.GetLogger(ctx)
-	l, err := utils.GetLDAPConnection(&m.c.LDAPConn)
-	if err != nil {
-		return nil, err
-	}
-	defer l.Close()
-
 	// Search for the given clientID
 	searchRequest := ldap.NewSearchRequest(
 		m.c.BaseDN,
@@ -222,7 +227,7 @@ func (m *manager) GetGroupByClaim(ctx context.Context, claim, value string, skip
 		nil,
 	)
 
-	sr, err := l.Search(searchRequest)
+	sr, err := m.searcher.Search(searchRequest)
 	if err != nil {
 		return nil, err
 	}
@@ -264,12 +269,6 @@ func (m *manager) GetGroupByClaim(ctx context.Context, claim, value string, skip
 }
 
 func (m *manager) FindGroups(ctx context.Context, query string, skipFetchingMembers bool) ([]*grouppb.Group, error) {
-	l, err := utils.GetLDAPConnection(&m.c.LDAPConn)
-	if err != nil {
-		return nil, err
-	}
-	defer l.Close()
-
 	// Search for the given clientID
 	searchRequest := ldap.NewSearchRequest(
 		m.c.BaseDN,
@@ -279,7 +278,7 @@ func (m *manager) FindGroups(ctx context.Context, query string, skipFetchingMemb
 		nil,
 	)
 
-	sr, err := l.Search(searchRequest)
+	sr, err := m.searcher.Search(searchRequest)
 	if err != nil {
 		return nil, err
 	}
@@ -320,12 +319,42 @@ func (m *manager) FindGroups(ctx context.Context, query string, skipFetchingMemb
 }
 
 func (m *manager) GetMembers(ctx context.Context, gid *grouppb.GroupId) ([]*userpb.UserId, error) {
-	l, err := utils.GetLDAPConnection(&m.c.LDAPConn)
+	users, err := m.getDirectMembers(gid)
+	if err != nil || !m.c.Nested.Enabled() {
+		return users, err
+	}
+
+	// Add the members of all groups nested in this group
+	subgroups, err := m.getSubgroups(gid)
 	if err != nil {
 		return nil, err
 	}
-	defer l.Close()
 
+	seen := make(map[string]bool, len(users))
+	for _, u := range users {
+		seen[u.OpaqueId] = true
+	}
+	for _, subgroup := range subgroups {
+		subgroupID := &grouppb.GroupId{
+			Idp:      m.c.Idp,
+			OpaqueId: subgroup.GetEqualFoldAttributeValue(m.c.Schema.GID),
+		}
+		members, err := m.getDirectMembers(subgroupID)
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range members {
+			if !seen[u.OpaqueId] {
+				seen[u.OpaqueId] = true
+				users = append(users, u)
+			}
+		}
+	}
+
+	return users, nil
+}
+
+func (m *manager) getDirectMembers(gid *grouppb.GroupId) ([]*userpb.UserId, error) {
 	// Search for the given clientID
 	searchRequest := ldap.NewSearchRequest(
 		m.c.BaseDN,
@@ -335,7 +364,7 @@ func (m *manager) GetMembers(ctx context.Context, gid *grouppb.GroupId) ([]*user
 		nil,
 	)
 
-	sr, err := l.Search(searchRequest)
+	sr, err := m.searcher.Search(searchRequest)
 	if err != nil {
 		return nil, err
 	}
@@ -353,6 +382,26 @@ func (m *manager) GetMembers(ctx context.Context, gid *grouppb.GroupId) ([]*user
 	return users, nil
 }
 
+func (m *manager) getSubgroups(gid *grouppb.GroupId) ([]*ldap.Entry, error) {
+	searchRequest := ldap.NewSearchRequest(
+		m.c.BaseDN,
+		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
+		m.getGroupFilter(gid),
+		append([]string{m.c.Schema.GID}, m.c.Nested.Attributes()...),
+		nil,
+	)
+
+	sr, err := m.searcher.Search(searchRequest)
+	if err != nil {
+		return nil, err
+	}
+	if len(sr.Entries) != 1 {
+		return nil, errtypes.NotFound(gid.OpaqueId)
+	}
+
+	return m.c.Nested.ExpandSubgroups(m.searcher, m.c.BaseDN, sr.Entries, []string{m.c.Schema.GID})
+}
+
 func (m *manager) HasMember(ctx context.Context, gid *grouppb.GroupId, uid *userpb.UserId) (bool, error) {
 	members, err := m.GetMembers(ctx, gid)
 	if err != nil {
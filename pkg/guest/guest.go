@@ -0,0 +1,44 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package guest manages the accounts of external collaborators. A guest
+// account is created when a resource is shared with an email address
+// unknown to the user provider, and is activated by the guest setting a
+// password through a link delivered by mail.
+package guest
+
+import (
+	"context"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+)
+
+// Manager is the interface that is used to perform operations on guest accounts.
+type Manager interface {
+	// Invite returns the guest account of the email address, creating it if
+	// missing. If the account has not been activated yet, a new activation
+	// token is returned as well, otherwise the token is empty.
+	Invite(ctx context.Context, mail string, inviter *userpb.UserId) (*userpb.User, string, error)
+
+	// Activate sets the password of the guest account the activation token was issued for.
+	Activate(ctx context.Context, token, password string) (*userpb.User, error)
+
+	// Authenticate checks the password of an activated guest account,
+	// identified by its email address.
+	Authenticate(ctx context.Context, mail, password string) (*userpb.User, error)
+}
@@ -0,0 +1,43 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package guest
+
+import (
+	"fmt"
+	"net/url"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+)
+
+// Mail returns the subject and the body of the mail notifying the guest g
+// that the user u shared the named resource. If an activation token is given,
+// the mail contains the link through which the guest can set a password.
+func Mail(u, g *userpb.User, resource, activationURL, token string) (string, string) {
+	subject := fmt.Sprintf("%s shared '%s' with you", u.DisplayName, resource)
+	body := "Hi,\n\n" +
+		u.DisplayName + " (" + u.Mail + ") shared '" + resource + "' with you.\n\n"
+	if token != "" {
+		body += "To access it, please activate your guest account by choosing a password at the following URL:\n" +
+			activationURL + "?token=" + url.QueryEscape(token) + "\n\n" +
+			"The link can be used only once and expires after a while; if it does, ask " + u.DisplayName + " to share again.\n\n"
+	}
+	body += "You can log in with your email address " + g.Mail + " and the password of your guest account.\n\n" +
+		"Best"
+	return subject, body
+}
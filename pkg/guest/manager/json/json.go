@@ -0,0 +1,496 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package json
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/guest"
+	"github.com/cs3org/reva/pkg/guest/manager/registry"
+	"github.com/cs3org/reva/pkg/ratelimit"
+	"github.com/cs3org/reva/pkg/ratelimit/memory"
+	"github.com/cs3org/reva/pkg/user"
+	userregistry "github.com/cs3org/reva/pkg/user/manager/registry"
+	"github.com/cs3org/reva/pkg/utils/filelock"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func init() {
+	registry.Register("json", New)
+}
+
+type config struct {
+	File              string                            `mapstructure:"file" docs:"/var/tmp/reva/guests.json;The file storing the guest accounts."`
+	Idp               string                            `mapstructure:"idp" docs:";The identity provider assigned to the guest users."`
+	TokenExpiration   int                               `mapstructure:"token_expiration" docs:"604800;The validity in seconds of the activation links."`
+	PasswordHashCost  int                               `mapstructure:"password_hash_cost" docs:"11;The bcrypt cost used to hash the passwords of the guests."`
+	PasswordMinLength int                               `mapstructure:"password_min_length" docs:"8;The minimum length of the passwords of the guests."`
+	AllowedDomains    []string                          `mapstructure:"allowed_domains" docs:";The email domains guests can be invited from. If empty, addresses of any domain can be invited."`
+	InvitationsPerDay int                               `mapstructure:"invitations_per_day" docs:"50;The maximum number of invitations a user can send per day, counted by each instance of the manager, e.g. per replica of the ocs service. Sharing with an activated guest does not count. Set to -1 for no limit."`
+	UserDriver        string                            `mapstructure:"user_driver" docs:"json;The writable user manager driver the guest users are created with."`
+	UserDrivers       map[string]map[string]interface{} `mapstructure:"user_drivers" docs:"url:pkg/user/manager/json/json.go"`
+}
+
+func (c *config) init() {
+	if c.File == "" {
+		c.File = "/var/tmp/reva/guests.json"
+	}
+	if c.TokenExpiration == 0 {
+		c.TokenExpiration = 604800
+	}
+	if c.PasswordHashCost == 0 {
+		c.PasswordHashCost = 11
+	}
+	if c.PasswordMinLength == 0 {
+		c.PasswordMinLength = 8
+	}
+	if c.InvitationsPerDay == 0 {
+		c.InvitationsPerDay = 50
+	}
+	for i, d := range c.AllowedDomains {
+		c.AllowedDomains[i] = strings.ToLower(strings.TrimPrefix(d, "@"))
+	}
+	if c.UserDriver == "" {
+		c.UserDriver = "json"
+	}
+}
+
+// account is the stored state of a guest account. The user attributes
+// are kept in the user store, the account only holds the credentials.
+type account struct {
+	UserID  *userpb.UserId `json:"user_id"`
+	Mail    string         `json:"mail"`
+	Inviter *userpb.UserId `json:"inviter,omitempty"`
+	Ctime   int64          `json:"ctime"`
+	// Password is the bcrypt hash of the password, empty until the account is activated.
+	Password string `json:"password,omitempty"`
+	// Tokens are the pending activation tokens. Every invitation adds one,
+	// so that the links sent earlier keep working until they expire.
+	Tokens []*token `json:"tokens,omitempty"`
+}
+
+type token struct {
+	// Hash is the sha256 hash of the token.
+	Hash       string `json:"hash"`
+	Expiration int64  `json:"expiration"`
+}
+
+type manager struct {
+	sync.Mutex
+	c       *config
+	users   user.WritableManager
+	limiter ratelimit.Limiter
+
+	modTime  time.Time
+	size     int64
+	accounts []*account
+}
+
+func parseConfig(m map[string]interface{}) (*config, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "error decoding conf")
+	}
+	c.init()
+	return c, nil
+}
+
+func getUserManager(c *config) (user.WritableManager, error) {
+	f, ok := userregistry.NewFuncs[c.UserDriver]
+	if !ok {
+		return nil, errtypes.NotFound("guest: user driver not found: " + c.UserDriver)
+	}
+	mgr, err := f(c.UserDrivers[c.UserDriver])
+	if err != nil {
+		return nil, errors.Wrap(err, "guest: error creating user manager")
+	}
+	w, ok := mgr.(user.WritableManager)
+	if !ok {
+		return nil, errtypes.NotSupported("guest: user driver is not writable: " + c.UserDriver)
+	}
+	return w, nil
+}
+
+// New returns a guest manager storing the guest accounts in a json file.
+// Several instances, e.g. in the ocs and guests services and in the guest
+// auth manager, can share the same file.
+func New(m map[string]interface{}) (guest.Manager, error) {
+	c, err := parseConfig(m)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := getUserManager(c)
+	if err != nil {
+		return nil, err
+	}
+	return newManager(c, users)
+}
+
+func newManager(c *config, users user.WritableManager) (guest.Manager, error) {
+	if _, err := os.Stat(c.File); os.IsNotExist(err) {
+		if err := os.WriteFile(c.File, []byte("[]"), 0600); err != nil {
+			return nil, errors.Wrapf(err, "guest: error creating the file %s", c.File)
+		}
+	}
+
+	limiter, err := memory.New(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	mgr := &manager{c: c, users: users, limiter: limiter}
+	if err := mgr.reload(); err != nil {
+		return nil, errors.Wrapf(err, "guest: error reading the file %s", c.File)
+	}
+	return mgr, nil
+}
+
+// lock takes the lock of the manager and the lock file next to the
+// accounts file, so that the managers sharing the file do not overwrite
+// each other's changes, then reads the file again if needed.
+// The returned function releases both locks.
+func (m *manager) lock() (func(), error) {
+	m.Lock()
+	unlockFile, err := filelock.Lock(m.c.File + ".lock")
+	if err != nil {
+		m.Unlock()
+		return nil, errors.Wrap(err, "guest: error locking accounts file")
+	}
+	unlock := func() {
+		unlockFile()
+		m.Unlock()
+	}
+	if err := m.reload(); err != nil {
+		unlock()
+		return nil, errors.Wrap(err, "guest: error reading accounts file")
+	}
+	return unlock, nil
+}
+
+// reload reads the file again if it has been modified since it was last read,
+// e.g. by another manager instance writing to the same file.
+// The caller must hold the lock.
+func (m *manager) reload() error {
+	info, err := os.Stat(m.c.File)
+	if err != nil {
+		return err
+	}
+	if m.accounts != nil && info.ModTime().Equal(m.modTime) && info.Size() == m.size {
+		return nil
+	}
+
+	data, err := os.ReadFile(m.c.File)
+	if err != nil {
+		return err
+	}
+	accounts := []*account{}
+	if err := json.Unmarshal(data, &accounts); err != nil {
+		return err
+	}
+	m.accounts = accounts
+	m.modTime = info.ModTime()
+	m.size = info.Size()
+	return nil
+}
+
+// save atomically writes the accounts to the file.
+// The caller must hold the lock.
+func (m *manager) save() error {
+	data, err := json.MarshalIndent(m.accounts, "", "\t")
+	if err != nil {
+		return errors.Wrap(err, "guest: error marshalling accounts")
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(m.c.File), filepath.Base(m.c.File)+".*")
+	if err != nil {
+		return errors.Wrap(err, "guest: error creating temporary file")
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "guest: error writing accounts file")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "guest: error writing accounts file")
+	}
+	if err := os.Rename(tmp.Name(), m.c.File); err != nil {
+		return errors.Wrap(err, "guest: error replacing accounts file")
+	}
+
+	info, err := os.Stat(m.c.File)
+	if err != nil {
+		return err
+	}
+	m.modTime = info.ModTime()
+	m.size = info.Size()
+	return nil
+}
+
+func normalizeMail(address string) (string, error) {
+	address = strings.ToLower(strings.TrimSpace(address))
+	a, err := mail.ParseAddress(address)
+	if err != nil || a.Address != address {
+		return "", errtypes.BadRequest("guest: invalid email address: " + address)
+	}
+	return address, nil
+}
+
+func hashToken(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// checkDomain enforces the allowed domains.
+func (m *manager) checkDomain(address string) error {
+	if len(m.c.AllowedDomains) > 0 {
+		domain := address[strings.LastIndex(address, "@")+1:]
+		allowed := false
+		for _, d := range m.c.AllowedDomains {
+			if domain == d {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return errtypes.PermissionDenied("guest: invitations to the domain " + domain + " are not allowed")
+		}
+	}
+	return nil
+}
+
+// checkLimit enforces the number of invitations each user can send.
+// The limit is tracked in memory, so it applies to each manager instance.
+func (m *manager) checkLimit(ctx context.Context, inviter *userpb.UserId) error {
+	if m.c.InvitationsPerDay > 0 && inviter != nil {
+		limit := ratelimit.Limit{Rate: float64(m.c.InvitationsPerDay) / 86400, Burst: m.c.InvitationsPerDay}
+		ok, _, err := m.limiter.Allow(ctx, inviter.Idp+"/"+inviter.OpaqueId, limit)
+		if err != nil {
+			return errors.Wrap(err, "guest: error checking invitation limit")
+		}
+		if !ok {
+			return errtypes.PermissionDenied("guest: too many invitations, try again later")
+		}
+	}
+	return nil
+}
+
+func (m *manager) getByMail(mail string) *account {
+	for _, a := range m.accounts {
+		if a.Mail == mail {
+			return a
+		}
+	}
+	return nil
+}
+
+// getByToken returns the pending account the token has been issued for,
+// if the token has not expired.
+func (m *manager) getByToken(hash string) *account {
+	now := time.Now().Unix()
+	for _, a := range m.accounts {
+		for _, t := range a.Tokens {
+			if t.Hash == hash && t.Expiration >= now {
+				return a
+			}
+		}
+	}
+	return nil
+}
+
+func (m *manager) Invite(ctx context.Context, address string, inviter *userpb.UserId) (*userpb.User, string, error) {
+	address, err := normalizeMail(address)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := m.checkDomain(address); err != nil {
+		return nil, "", err
+	}
+
+	unlock, err := m.lock()
+	if err != nil {
+		return nil, "", err
+	}
+	defer unlock()
+
+	a := m.getByMail(address)
+	// only the invitations sending an activation link count against the
+	// limit, sharing with an activated guest does not
+	if a == nil || a.Password == "" {
+		if err := m.checkLimit(ctx, inviter); err != nil {
+			return nil, "", err
+		}
+	}
+	if a == nil {
+		// do not shadow the accounts already known to the user store
+		if u, err := m.users.GetUserByClaim(ctx, "mail", address, true); err == nil && u.Id.GetType() != userpb.UserType_USER_TYPE_GUEST {
+			return nil, "", errtypes.AlreadyExists("guest: a user with email address " + address + " already exists")
+		}
+
+		u, err := m.users.CreateUser(ctx, &userpb.User{
+			Id: &userpb.UserId{
+				Idp:  m.c.Idp,
+				Type: userpb.UserType_USER_TYPE_GUEST,
+			},
+			Username:    address,
+			Mail:        address,
+			DisplayName: address,
+		})
+		if err != nil {
+			return nil, "", errors.Wrap(err, "guest: error creating guest user")
+		}
+
+		a = &account{
+			UserID:  u.Id,
+			Mail:    address,
+			Inviter: inviter,
+			Ctime:   time.Now().Unix(),
+		}
+		m.accounts = append(m.accounts, a)
+		appctx.GetLogger(ctx).Info().Str("mail", address).Interface("inviter", inviter).Msg("guest: created guest account")
+	}
+
+	var t string
+	if a.Password == "" {
+		// issue a new activation token to pending accounts
+		if t, err = newToken(); err != nil {
+			return nil, "", errors.Wrap(err, "guest: error generating activation token")
+		}
+		now := time.Now()
+		tokens := []*token{}
+		for _, e := range a.Tokens {
+			if e.Expiration > now.Unix() {
+				tokens = append(tokens, e)
+			}
+		}
+		a.Tokens = append(tokens, &token{
+			Hash:       hashToken(t),
+			Expiration: now.Add(time.Duration(m.c.TokenExpiration) * time.Second).Unix(),
+		})
+	}
+
+	if err := m.save(); err != nil {
+		return nil, "", err
+	}
+
+	u, err := m.users.GetUser(ctx, a.UserID, true)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "guest: error getting guest user")
+	}
+	return u, t, nil
+}
+
+func (m *manager) Activate(ctx context.Context, token, password string) (*userpb.User, error) {
+	if len(password) < m.c.PasswordMinLength {
+		return nil, errtypes.BadRequest("guest: the password is too short")
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), m.c.PasswordHashCost)
+	if err != nil {
+		return nil, errors.Wrap(err, "guest: error hashing password")
+	}
+
+	unlock, err := m.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	a := m.getByToken(hashToken(token))
+	if a == nil {
+		return nil, errtypes.NotFound("guest: activation token not found or expired")
+	}
+
+	a.Password = string(hash)
+	a.Tokens = nil
+	if err := m.save(); err != nil {
+		return nil, err
+	}
+
+	u, err := m.users.GetUser(ctx, a.UserID, true)
+	if err != nil {
+		return nil, errors.Wrap(err, "guest: error getting guest user")
+	}
+	if !u.MailVerified {
+		// following the link proves the guest owns the address
+		u.MailVerified = true
+		if u, err = m.users.UpdateUser(ctx, u); err != nil {
+			return nil, errors.Wrap(err, "guest: error updating guest user")
+		}
+	}
+	appctx.GetLogger(ctx).Info().Str("mail", a.Mail).Msg("guest: activated guest account")
+	return u, nil
+}
+
+func (m *manager) Authenticate(ctx context.Context, address, password string) (*userpb.User, error) {
+	address = strings.ToLower(strings.TrimSpace(address))
+
+	m.Lock()
+	if err := m.reload(); err != nil {
+		m.Unlock()
+		return nil, errors.Wrap(err, "guest: error reading accounts file")
+	}
+	a := m.getByMail(address)
+	var userID *userpb.UserId
+	var hash string
+	if a != nil {
+		userID, hash = a.UserID, a.Password
+	}
+	m.Unlock()
+
+	if a == nil {
+		return nil, errtypes.NotFound(address)
+	}
+	if hash == "" {
+		return nil, errtypes.InvalidCredentials("guest: account not activated")
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil {
+		return nil, errtypes.InvalidCredentials(address)
+	}
+
+	u, err := m.users.GetUser(ctx, userID, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "guest: error getting guest user")
+	}
+	return u, nil
+}
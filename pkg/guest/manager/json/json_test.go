@@ -0,0 +1,236 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package json
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/user"
+	userjson "github.com/cs3org/reva/pkg/user/manager/json"
+)
+
+func newTestManager(t *testing.T) (*manager, user.WritableManager) {
+	return newTestManagerWithConfig(t, t.TempDir(), nil)
+}
+
+func newTestManagerWithConfig(t *testing.T, dir string, conf map[string]interface{}) (*manager, user.WritableManager) {
+	usersFile := filepath.Join(dir, "users.json")
+	users := `[{"id": {"idp": "cernbox.cern.ch", "opaque_id": "einstein", "type": 1}, "username": "einstein", "mail": "einstein@cern.ch"}]`
+	if _, err := os.Stat(usersFile); os.IsNotExist(err) {
+		if err := os.WriteFile(usersFile, []byte(users), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	um, err := userjson.New(map[string]interface{}{"users": usersFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wm := um.(user.WritableManager)
+
+	m := map[string]interface{}{
+		"file":               filepath.Join(dir, "guests.json"),
+		"idp":                "guests.cernbox.cern.ch",
+		"password_hash_cost": 4,
+	}
+	for k, v := range conf {
+		m[k] = v
+	}
+	c, err := parseConfig(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mgr, err := newManager(c, wm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mgr.(*manager), wm
+}
+
+func TestInviteAndActivate(t *testing.T) {
+	m, users := newTestManager(t)
+	ctx := context.Background()
+	inviter := &userpb.UserId{Idp: "cernbox.cern.ch", OpaqueId: "einstein"}
+
+	g, token, err := m.Invite(ctx, " Marie@Example.org ", inviter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token == "" {
+		t.Fatal("expected an activation token for a new guest")
+	}
+	if g.Id.Type != userpb.UserType_USER_TYPE_GUEST || g.Id.Idp != "guests.cernbox.cern.ch" || g.Username != "marie@example.org" {
+		t.Fatalf("unexpected guest user: %+v", g)
+	}
+	if _, err := users.GetUserByClaim(ctx, "mail", "marie@example.org", true); err != nil {
+		t.Fatalf("guest should be known to the user store: %v", err)
+	}
+
+	if _, err := m.Authenticate(ctx, "marie@example.org", "whatever"); err == nil {
+		t.Fatal("pending accounts should not authenticate")
+	}
+
+	// inviting again issues a new token, the previous one stays valid
+	g2, token2, err := m.Invite(ctx, "marie@example.org", inviter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g2.Id.OpaqueId != g.Id.OpaqueId || token2 == "" || token2 == token {
+		t.Fatalf("expected the same guest with a new token, got %+v %q", g2, token2)
+	}
+
+	if _, err := m.Activate(ctx, token, "short"); err == nil {
+		t.Fatal("short passwords should be rejected")
+	}
+	activated, err := m.Activate(ctx, token, "secretpassword")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !activated.MailVerified {
+		t.Fatal("the mail of activated guests should be verified")
+	}
+	if _, err := m.Activate(ctx, token, "secretpassword"); err == nil {
+		t.Fatal("tokens should be usable only once")
+	}
+	if _, err := m.Activate(ctx, token2, "secretpassword"); err == nil {
+		t.Fatal("the other tokens should be invalidated by the activation")
+	}
+
+	if _, err := m.Authenticate(ctx, "marie@example.org", "wrongpassword"); err == nil {
+		t.Fatal("wrong passwords should be rejected")
+	} else if _, ok := err.(errtypes.InvalidCredentials); !ok {
+		t.Fatalf("expected invalid credentials, got %v", err)
+	}
+	u, err := m.Authenticate(ctx, "Marie@example.org", "secretpassword")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Id.OpaqueId != g.Id.OpaqueId {
+		t.Fatalf("authenticated the wrong user: %+v", u)
+	}
+
+	// activated guests get no new token
+	if _, token, err := m.Invite(ctx, "marie@example.org", inviter); err != nil || token != "" {
+		t.Fatalf("expected no token for an activated guest, got %q, %v", token, err)
+	}
+}
+
+func TestInviteExistingUser(t *testing.T) {
+	m, _ := newTestManager(t)
+	ctx := context.Background()
+
+	if _, _, err := m.Invite(ctx, "einstein@cern.ch", nil); err == nil {
+		t.Fatal("users known to the user store should not be invited as guests")
+	}
+	if _, _, err := m.Invite(ctx, "not an address", nil); err == nil {
+		t.Fatal("invalid addresses should be rejected")
+	}
+	if _, err := m.Authenticate(ctx, "unknown@example.org", "secretpassword"); err == nil {
+		t.Fatal("unknown guests should not authenticate")
+	} else if _, ok := err.(errtypes.NotFound); !ok {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+func TestInvitationLimits(t *testing.T) {
+	m, _ := newTestManagerWithConfig(t, t.TempDir(), map[string]interface{}{
+		"allowed_domains":     []string{"@Example.org"},
+		"invitations_per_day": 2,
+	})
+	ctx := context.Background()
+	inviter := &userpb.UserId{Idp: "cernbox.cern.ch", OpaqueId: "einstein"}
+
+	if _, _, err := m.Invite(ctx, "marie@example.com", inviter); err == nil {
+		t.Fatal("addresses of other domains should be rejected")
+	} else if _, ok := err.(errtypes.PermissionDenied); !ok {
+		t.Fatalf("expected permission denied, got %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, _, err := m.Invite(ctx, "marie@example.org", inviter); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, _, err := m.Invite(ctx, "pierre@example.org", inviter); err == nil {
+		t.Fatal("invitations over the limit should be rejected")
+	} else if _, ok := err.(errtypes.PermissionDenied); !ok {
+		t.Fatalf("expected permission denied, got %v", err)
+	}
+	if _, _, err := m.Invite(ctx, "pierre@example.org", &userpb.UserId{Idp: "cernbox.cern.ch", OpaqueId: "marie"}); err != nil {
+		t.Fatalf("the limit should apply to each inviter: %v", err)
+	}
+
+	// sharing with an activated guest is not an invitation
+	_, token, err := m.Invite(ctx, "pierre@example.org", &userpb.UserId{Idp: "cernbox.cern.ch", OpaqueId: "marie"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Activate(ctx, token, "secret-password"); err != nil {
+		t.Fatal(err)
+	}
+	if _, token, err := m.Invite(ctx, "pierre@example.org", inviter); err != nil {
+		t.Fatalf("sharing with an activated guest should not count against the limit: %v", err)
+	} else if token != "" {
+		t.Fatal("no activation token should be issued to an activated guest")
+	}
+}
+
+func TestSharedFile(t *testing.T) {
+	dir := t.TempDir()
+	m1, _ := newTestManagerWithConfig(t, dir, nil)
+	m2, _ := newTestManagerWithConfig(t, dir, nil)
+	ctx := context.Background()
+
+	// accounts created through one manager are not lost by the other one
+	var wg sync.WaitGroup
+	tokens := make([]string, 10)
+	for i := range tokens {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			m := m1
+			if i%2 == 1 {
+				m = m2
+			}
+			_, token, err := m.Invite(ctx, fmt.Sprintf("guest%d@example.org", i), nil)
+			if err != nil {
+				t.Error(err)
+			}
+			tokens[i] = token
+		}(i)
+	}
+	wg.Wait()
+
+	for i, token := range tokens {
+		m := m2
+		if i%2 == 1 {
+			m = m1
+		}
+		if _, err := m.Activate(ctx, token, "secretpassword"); err != nil {
+			t.Fatalf("token %d: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package health keeps track of the checks that services and drivers
+// contribute to report whether the dependencies of revad are healthy.
+package health
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/utils"
+)
+
+// DefaultTimeout is the time given to every check to complete.
+const DefaultTimeout = 5 * time.Second
+
+// CheckInterval is how often the checks are run in the background.
+const CheckInterval = 10 * time.Second
+
+// Status values reported by the checks.
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// Check verifies that a dependency is healthy, returning an error otherwise.
+type Check func(ctx context.Context) error
+
+var (
+	mu     sync.RWMutex
+	checks = map[string]Check{}
+)
+
+// Register registers a check with the given name.
+// A check previously registered with the same name is replaced.
+func Register(name string, check Check) {
+	mu.Lock()
+	defer mu.Unlock()
+	checks[name] = check
+}
+
+// Unregister removes the check with the given name.
+func Unregister(name string) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(checks, name)
+}
+
+// Result is the outcome of a single check.
+type Result struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Report is the outcome of all the registered checks.
+type Report struct {
+	Status string    `json:"status"`
+	Checks []*Result `json:"checks,omitempty"`
+}
+
+// Healthy returns whether all the checks succeeded.
+func (r *Report) Healthy() bool {
+	return r.Status == StatusOK
+}
+
+// Run runs all the registered checks concurrently, each with the given timeout.
+func Run(ctx context.Context, timeout time.Duration) *Report {
+	mu.RLock()
+	current := make(map[string]Check, len(checks))
+	for name, check := range checks {
+		current[name] = check
+	}
+	mu.RUnlock()
+
+	report := &Report{Status: StatusOK, Checks: make([]*Result, 0, len(current))}
+	results := make(chan *Result, len(current))
+	for name, check := range current {
+		go func(name string, check Check) {
+			results <- run(ctx, name, check, timeout)
+		}(name, check)
+	}
+	for range current {
+		res := <-results
+		if res.Status != StatusOK {
+			report.Status = StatusFail
+		}
+		report.Checks = append(report.Checks, res)
+	}
+	sort.Slice(report.Checks, func(i, j int) bool { return report.Checks[i].Name < report.Checks[j].Name })
+	return report
+}
+
+func run(ctx context.Context, name string, check Check, timeout time.Duration) *Result {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	errc := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				errc <- fmt.Errorf("check panicked: %v", r)
+			}
+		}()
+		errc <- check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-errc:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	res := &Result{Name: name, Status: StatusOK, Duration: time.Since(start).String()}
+	if err != nil {
+		res.Status = StatusFail
+		res.Error = err.Error()
+	}
+	return res
+}
+
+// Monitor runs the registered checks periodically in the background and
+// keeps the last report, so that the probes never wait for the checks.
+type Monitor struct {
+	interval time.Duration
+	timeout  time.Duration
+
+	once   sync.Once
+	stop   sync.Once
+	done   chan struct{}
+	mu     sync.RWMutex
+	report *Report
+}
+
+// NewMonitor returns a monitor running the checks every interval,
+// each with the given timeout. The checks start with the first report.
+func NewMonitor(interval, timeout time.Duration) *Monitor {
+	return &Monitor{
+		interval: interval,
+		timeout:  timeout,
+		done:     make(chan struct{}),
+		// not ready until the checks ran once
+		report: &Report{Status: StatusFail},
+	}
+}
+
+// Report returns the report of the last run of the checks.
+func (m *Monitor) Report() *Report {
+	m.once.Do(func() { go m.loop() })
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.report
+}
+
+// Close stops running the checks.
+func (m *Monitor) Close() {
+	m.once.Do(func() {})
+	m.stop.Do(func() { close(m.done) })
+}
+
+func (m *Monitor) loop() {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		report := Run(context.Background(), m.timeout)
+		m.mu.Lock()
+		m.report = report
+		m.mu.Unlock()
+
+		select {
+		case <-m.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+var monitor = NewMonitor(CheckInterval, DefaultTimeout)
+
+// Current returns the report of the last run of the registered checks,
+// which are run every CheckInterval in the background.
+func Current() *Report {
+	return monitor.Report()
+}
+
+// SQL returns a check verifying the connectivity to a database.
+func SQL(db *sql.DB) Check {
+	return db.PingContext
+}
+
+// LDAP returns a check verifying that the LDAP server can be bound to.
+func LDAP(c *utils.LDAPConn) Check {
+	return func(ctx context.Context) error {
+		l, err := utils.GetLDAPConnection(c)
+		if err != nil {
+			return err
+		}
+		l.Close()
+		return nil
+	}
+}
+
+// TCP returns a check verifying that a TCP connection can be established to address.
+func TCP(address string) Check {
+	return func(ctx context.Context) error {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", address)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
+
+// Writable returns a check verifying that files can be created in dir.
+func Writable(dir string) Check {
+	return func(ctx context.Context) error {
+		f, err := os.CreateTemp(dir, ".health-")
+		if err != nil {
+			return err
+		}
+		f.Close()
+		return os.Remove(f.Name())
+	}
+}
+
+// LivenessHandler reports that the process is up and able to serve requests.
+func LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeReport(w, r, http.StatusOK, &Report{Status: StatusOK})
+	})
+}
+
+// ReadinessHandler reports the outcome of the last run of the registered
+// checks, with 503 Service Unavailable if any of them failed.
+func ReadinessHandler() http.Handler {
+	return readinessHandler(monitor)
+}
+
+func readinessHandler(m *Monitor) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := m.Report()
+		code := http.StatusOK
+		if !report.Healthy() {
+			code = http.StatusServiceUnavailable
+		}
+		writeReport(w, r, code, report)
+	})
+}
+
+func writeReport(w http.ResponseWriter, r *http.Request, code int, report *Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log := appctx.GetLogger(r.Context())
+		log.Err(err).Msg("health: error writing report")
+	}
+}
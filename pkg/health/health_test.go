@@ -0,0 +1,114 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReadiness(t *testing.T) {
+	Register("test:ok", func(ctx context.Context) error { return nil })
+	Register("test:writable", Writable(t.TempDir()))
+	defer Unregister("test:ok")
+	defer Unregister("test:writable")
+
+	m := NewMonitor(10*time.Millisecond, 50*time.Millisecond)
+	defer m.Close()
+	waitFor(t, m, true)
+
+	rec := httptest.NewRecorder()
+	readinessHandler(m).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+
+	Register("test:fail", func(ctx context.Context) error { return errors.New("connection refused") })
+	Register("test:slow", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+	defer Unregister("test:fail")
+	defer Unregister("test:slow")
+
+	report := Run(context.Background(), 50*time.Millisecond)
+	if report.Healthy() {
+		t.Fatal("expected the report to be unhealthy")
+	}
+	expected := map[string]string{
+		"test:fail":     StatusFail,
+		"test:ok":       StatusOK,
+		"test:slow":     StatusFail,
+		"test:writable": StatusOK,
+	}
+	if len(report.Checks) != len(expected) {
+		t.Fatalf("expected %d checks, got %d", len(expected), len(report.Checks))
+	}
+	for _, res := range report.Checks {
+		if res.Status != expected[res.Name] {
+			t.Errorf("check %s: expected status %s, got %s (%s)", res.Name, expected[res.Name], res.Status, res.Error)
+		}
+	}
+
+	Unregister("test:slow")
+	waitFor(t, m, false)
+	rec = httptest.NewRecorder()
+	readinessHandler(m).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected status %d, got %d", http.StatusServiceUnavailable, rec.Code)
+	}
+	var r Report
+	if err := json.NewDecoder(rec.Body).Decode(&r); err != nil {
+		t.Fatal(err)
+	}
+	if r.Status != StatusFail {
+		t.Errorf("expected status %s, got %s", StatusFail, r.Status)
+	}
+
+	// liveness does not depend on the checks
+	rec = httptest.NewRecorder()
+	LivenessHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+}
+
+func TestMonitorNotReadyBeforeChecks(t *testing.T) {
+	m := NewMonitor(time.Hour, time.Second)
+	m.Close()
+	if m.Report().Healthy() {
+		t.Fatal("expected the report to be unhealthy before the checks ran")
+	}
+}
+
+// waitFor waits for the checks run by the monitor to report the given health.
+func waitFor(t *testing.T, m *Monitor, healthy bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for m.Report().Healthy() != healthy {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the report to become healthy=%t", healthy)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
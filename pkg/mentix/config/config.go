@@ -28,6 +28,17 @@ type Configuration struct {
 			Scope   string `mapstructure:"scope"`
 			APIKey  string `mapstructure:"apikey"`
 		} `mapstructure:"gocdb"`
+
+		File struct {
+			Path string `mapstructure:"path"`
+		} `mapstructure:"file"`
+
+		OCMDiscovery struct {
+			Endpoints []string `mapstructure:"endpoints"`
+			Operator  string   `mapstructure:"operator"`
+			Email     string   `mapstructure:"email"`
+			Timeout   int      `mapstructure:"timeout"`
+		} `mapstructure:"ocmdiscovery"`
 	} `mapstructure:"connectors"`
 
 	UpdateInterval string `mapstructure:"update_interval"`
@@ -36,6 +47,13 @@ type Configuration struct {
 		CriticalTypes []string `mapstructure:"critical_types"`
 	} `mapstructure:"services"`
 
+	Health struct {
+		Interval    string `mapstructure:"interval"`
+		Timeout     string `mapstructure:"timeout"`
+		HistorySize int    `mapstructure:"history_size"`
+		Insecure    bool   `mapstructure:"insecure"`
+	} `mapstructure:"health"`
+
 	Exporters struct {
 		WebAPI struct {
 			Endpoint          string   `mapstructure:"endpoint"`
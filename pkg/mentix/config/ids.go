@@ -21,6 +21,10 @@ package config
 const (
 	// ConnectorIDGOCDB is the connector identifier for GOCDB.
 	ConnectorIDGOCDB = "gocdb"
+	// ConnectorIDFile is the connector identifier for mesh data files.
+	ConnectorIDFile = "file"
+	// ConnectorIDOCMDiscovery is the connector identifier for OCM discovery endpoints.
+	ConnectorIDOCMDiscovery = "ocmdiscovery"
 )
 
 const (
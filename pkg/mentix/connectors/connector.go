@@ -38,6 +38,13 @@ type Connector interface {
 	UpdateMeshData(data *meshdata.MeshData) error
 }
 
+// SourceWatcher is implemented by connectors that can detect changes of their data source on their own; a detected
+// change triggers an immediate update of the mesh data instead of waiting for the next update interval.
+type SourceWatcher interface {
+	// SourceChanged checks whether the data source was modified since the mesh data was last retrieved.
+	SourceChanged() bool
+}
+
 // BaseConnector implements basic connector functionality common to all connectors.
 type BaseConnector struct {
 	conf *config.Configuration
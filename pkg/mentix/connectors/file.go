@@ -0,0 +1,221 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package connectors
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cs3org/reva/pkg/mentix/config"
+	"github.com/cs3org/reva/pkg/mentix/connectors/file"
+	"github.com/cs3org/reva/pkg/mentix/meshdata"
+	"github.com/rs/zerolog"
+	"gopkg.in/yaml.v2"
+)
+
+// FileConnector is used to read mesh data from a JSON or YAML file.
+type FileConnector struct {
+	BaseConnector
+
+	filePath string
+	modTime  time.Time
+}
+
+// Activate activates the connector.
+func (connector *FileConnector) Activate(conf *config.Configuration, log *zerolog.Logger) error {
+	if err := connector.BaseConnector.Activate(conf, log); err != nil {
+		return err
+	}
+
+	// Check and store file specific settings
+	connector.filePath = conf.Connectors.File.Path
+	if len(connector.filePath) == 0 {
+		return fmt.Errorf("no mesh data file configured")
+	}
+
+	return nil
+}
+
+// RetrieveMeshData fetches new mesh data.
+func (connector *FileConnector) RetrieveMeshData() (*meshdata.MeshData, error) {
+	info, err := os.Stat(connector.filePath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to access the mesh data file: %v", err)
+	}
+	// Remember the modification time even if the file turns out to be invalid, so it won't be reloaded until it changes again
+	connector.modTime = info.ModTime()
+
+	data, err := os.ReadFile(connector.filePath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the mesh data file: %v", err)
+	}
+
+	var mesh file.Mesh
+	switch strings.ToLower(filepath.Ext(connector.filePath)) {
+	case ".json":
+		err = json.Unmarshal(data, &mesh)
+	default:
+		err = yaml.Unmarshal(data, &mesh)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to unmarshal the mesh data file: %v", err)
+	}
+
+	meshData := connector.convertMesh(&mesh)
+	meshData.InferMissingData()
+	if err := meshData.Verify(); err != nil {
+		return nil, fmt.Errorf("invalid mesh data file: %v", err)
+	}
+
+	return meshData, nil
+}
+
+// SourceChanged checks whether the mesh data file was modified since the mesh data was last retrieved.
+func (connector *FileConnector) SourceChanged() bool {
+	info, err := os.Stat(connector.filePath)
+	if err != nil {
+		return false
+	}
+	return !info.ModTime().Equal(connector.modTime)
+}
+
+func (connector *FileConnector) convertMesh(mesh *file.Mesh) *meshdata.MeshData {
+	meshData := new(meshdata.MeshData)
+
+	for _, serviceType := range mesh.ServiceTypes {
+		meshData.AddServiceType(&meshdata.ServiceType{
+			Name:        serviceType.Name,
+			Description: serviceType.Description,
+		})
+	}
+
+	for _, op := range mesh.Operators {
+		operator := &meshdata.Operator{
+			ID:            op.ID,
+			Name:          op.Name,
+			Homepage:      op.Homepage,
+			Email:         op.Email,
+			HelpdeskEmail: op.HelpdeskEmail,
+			SecurityEmail: op.SecurityEmail,
+			Properties:    connector.copyProperties(op.Properties),
+		}
+
+		for _, site := range op.Sites {
+			properties := connector.copyProperties(site.Properties)
+
+			// Like with GOCDB, the site ID and organization can also be set through properties
+			siteID := site.ID
+			if siteID == "" {
+				siteID = meshdata.GetPropertyValue(properties, meshdata.PropertySiteID, site.Name)
+			}
+			organization := site.Organization
+			if organization == "" {
+				organization = meshdata.GetPropertyValue(properties, meshdata.PropertyOrganization, site.FullName)
+			}
+
+			meshsite := &meshdata.Site{
+				ID:           siteID,
+				Name:         site.Name,
+				FullName:     site.FullName,
+				Organization: organization,
+				Domain:       site.Domain,
+				Homepage:     site.Homepage,
+				Email:        site.Email,
+				Description:  site.Description,
+				Country:      site.Country,
+				CountryCode:  site.CountryCode,
+				Location:     site.Location,
+				Latitude:     site.Latitude,
+				Longitude:    site.Longitude,
+				Properties:   properties,
+				Downtimes:    meshdata.Downtimes{},
+			}
+
+			for _, service := range site.Services {
+				var endpoints []*meshdata.ServiceEndpoint
+				for _, endpoint := range service.Endpoints {
+					endpoints = append(endpoints, connector.convertEndpoint(meshData, endpoint))
+				}
+
+				meshsite.Services = append(meshsite.Services, &meshdata.Service{
+					ServiceEndpoint:     connector.convertEndpoint(meshData, &service.Endpoint),
+					Host:                service.Host,
+					AdditionalEndpoints: endpoints,
+				})
+			}
+
+			operator.Sites = append(operator.Sites, meshsite)
+		}
+
+		meshData.AddOperator(operator)
+	}
+
+	return meshData
+}
+
+func (connector *FileConnector) convertEndpoint(meshData *meshdata.MeshData, endpoint *file.Endpoint) *meshdata.ServiceEndpoint {
+	name := endpoint.Name
+	if name == "" {
+		name = endpoint.Type
+	}
+
+	return &meshdata.ServiceEndpoint{
+		Type:        connector.findServiceType(meshData, endpoint.Type),
+		Name:        name,
+		RawURL:      endpoint.URL,
+		URL:         endpoint.URL,
+		IsMonitored: endpoint.IsMonitored,
+		Properties:  connector.copyProperties(endpoint.Properties),
+	}
+}
+
+func (connector *FileConnector) findServiceType(meshData *meshdata.MeshData, name string) *meshdata.ServiceType {
+	if serviceType := meshData.FindServiceType(name); serviceType != nil {
+		return serviceType
+	}
+
+	// If the service type doesn't exist, create a default one
+	return &meshdata.ServiceType{Name: name, Description: ""}
+}
+
+func (connector *FileConnector) copyProperties(props map[string]string) map[string]string {
+	properties := make(map[string]string, len(props))
+	for key, value := range props {
+		properties[key] = value
+	}
+	return properties
+}
+
+// GetID returns the ID of the connector.
+func (connector *FileConnector) GetID() string {
+	return config.ConnectorIDFile
+}
+
+// GetName returns the display name of the connector.
+func (connector *FileConnector) GetName() string {
+	return "File"
+}
+
+func init() {
+	registerConnector(&FileConnector{})
+}
@@ -0,0 +1,78 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package file
+
+// Mesh describes the entire mesh stored in a file.
+type Mesh struct {
+	ServiceTypes []*ServiceType `json:"serviceTypes" yaml:"serviceTypes"`
+	Operators    []*Operator    `json:"operators" yaml:"operators"`
+}
+
+// ServiceType describes a service type.
+type ServiceType struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description" yaml:"description"`
+}
+
+// Operator describes an operator and its sites.
+type Operator struct {
+	ID            string            `json:"id" yaml:"id"`
+	Name          string            `json:"name" yaml:"name"`
+	Homepage      string            `json:"homepage" yaml:"homepage"`
+	Email         string            `json:"email" yaml:"email"`
+	HelpdeskEmail string            `json:"helpdeskEmail" yaml:"helpdeskEmail"`
+	SecurityEmail string            `json:"securityEmail" yaml:"securityEmail"`
+	Sites         []*Site           `json:"sites" yaml:"sites"`
+	Properties    map[string]string `json:"properties" yaml:"properties"`
+}
+
+// Site describes a single site and its services.
+type Site struct {
+	ID           string            `json:"id" yaml:"id"`
+	Name         string            `json:"name" yaml:"name"`
+	FullName     string            `json:"fullName" yaml:"fullName"`
+	Organization string            `json:"organization" yaml:"organization"`
+	Domain       string            `json:"domain" yaml:"domain"`
+	Homepage     string            `json:"homepage" yaml:"homepage"`
+	Email        string            `json:"email" yaml:"email"`
+	Description  string            `json:"description" yaml:"description"`
+	Country      string            `json:"country" yaml:"country"`
+	CountryCode  string            `json:"countryCode" yaml:"countryCode"`
+	Location     string            `json:"location" yaml:"location"`
+	Latitude     float32           `json:"latitude" yaml:"latitude"`
+	Longitude    float32           `json:"longitude" yaml:"longitude"`
+	Services     []*Service        `json:"services" yaml:"services"`
+	Properties   map[string]string `json:"properties" yaml:"properties"`
+}
+
+// Service describes a service of a site.
+type Service struct {
+	Endpoint  `yaml:",inline"`
+	Host      string      `json:"host" yaml:"host"`
+	Endpoints []*Endpoint `json:"endpoints" yaml:"endpoints"`
+}
+
+// Endpoint describes a service endpoint.
+type Endpoint struct {
+	Type        string            `json:"type" yaml:"type"`
+	Name        string            `json:"name" yaml:"name"`
+	URL         string            `json:"url" yaml:"url"`
+	IsMonitored bool              `json:"monitored" yaml:"monitored"`
+	Properties  map[string]string `json:"properties" yaml:"properties"`
+}
@@ -0,0 +1,114 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package connectors
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cs3org/reva/pkg/mentix/config"
+	"github.com/rs/zerolog"
+)
+
+const meshFile = `
+serviceTypes:
+  - name: REVAD
+    description: Reva daemon
+operators:
+  - id: MESH
+    email: ops@example.org
+    sites:
+      - name: example
+        fullName: Example Site
+        domain: example.org
+        properties:
+          site_id: EX
+        services:
+          - type: REVAD
+            url: https://reva.example.org
+            monitored: true
+            endpoints:
+              - type: OCM
+                url: https://reva.example.org/ocm
+`
+
+func TestFileConnector(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mesh.yaml")
+	if err := os.WriteFile(path, []byte(meshFile), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	conf := &config.Configuration{}
+	conf.Connectors.File.Path = path
+	log := zerolog.Nop()
+
+	connector := &FileConnector{}
+	if err := connector.Activate(conf, &log); err != nil {
+		t.Fatal(err)
+	}
+	if !connector.SourceChanged() {
+		t.Fatal("expected source to be changed before the first retrieval")
+	}
+
+	meshData, err := connector.RetrieveMeshData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if connector.SourceChanged() {
+		t.Fatal("expected source to be unchanged after retrieval")
+	}
+
+	op := meshData.FindOperator("MESH")
+	if op == nil || len(op.Sites) != 1 {
+		t.Fatalf("unexpected operators: %+v", meshData.Operators)
+	}
+	site := op.FindSite("EX")
+	if site == nil {
+		t.Fatalf("site EX not found: %+v", op.Sites)
+	}
+	if site.Organization != "Example Site" || site.Homepage != "http://www.example.org" {
+		t.Fatalf("missing data not inferred: %+v", site)
+	}
+	service := site.FindService("REVAD")
+	if service == nil || service.Host != "reva.example.org" || service.Type.Description != "Reva daemon" {
+		t.Fatalf("unexpected service: %+v", service)
+	}
+	if endpoint := service.FindEndpoint("OCM"); endpoint == nil || endpoint.URL != "https://reva.example.org/ocm" {
+		t.Fatalf("unexpected endpoint: %+v", endpoint)
+	}
+
+	// Modifying the file must be detected
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if !connector.SourceChanged() {
+		t.Fatal("expected modified source to be detected")
+	}
+
+	// Invalid data is rejected
+	if err := os.WriteFile(path, []byte("operators:\n  - id: MESH\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := connector.RetrieveMeshData(); err == nil {
+		t.Fatal("expected invalid mesh data to be rejected")
+	}
+}
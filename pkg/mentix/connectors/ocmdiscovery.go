@@ -0,0 +1,236 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package connectors
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/cs3org/reva/pkg/mentix/config"
+	"github.com/cs3org/reva/pkg/mentix/meshdata"
+	"github.com/cs3org/reva/pkg/mentix/utils/network"
+	"github.com/rs/zerolog"
+)
+
+// ocmDiscoveryData holds the data published by the OCM discovery endpoint of a provider.
+type ocmDiscoveryData struct {
+	Enabled       bool   `json:"enabled"`
+	APIVersion    string `json:"apiVersion"`
+	Endpoint      string `json:"endPoint"`
+	Provider      string `json:"provider"`
+	ResourceTypes []struct {
+		Name       string            `json:"name"`
+		ShareTypes []string          `json:"shareTypes"`
+		Protocols  map[string]string `json:"protocols"`
+	} `json:"resourceTypes"`
+}
+
+// OCMDiscoveryConnector is used to build mesh data from the OCM discovery endpoints of a list of providers.
+type OCMDiscoveryConnector struct {
+	BaseConnector
+
+	endpoints []string
+	client    *http.Client
+}
+
+// Activate activates the connector.
+func (connector *OCMDiscoveryConnector) Activate(conf *config.Configuration, log *zerolog.Logger) error {
+	if err := connector.BaseConnector.Activate(conf, log); err != nil {
+		return err
+	}
+
+	// Check and store OCM discovery specific settings
+	connector.endpoints = conf.Connectors.OCMDiscovery.Endpoints
+	if len(connector.endpoints) == 0 {
+		return fmt.Errorf("no OCM discovery endpoints configured")
+	}
+
+	// Unreachable providers must not block the retrieval of the mesh data
+	connector.client = &http.Client{Timeout: time.Duration(conf.Connectors.OCMDiscovery.Timeout) * time.Second}
+
+	return nil
+}
+
+// RetrieveMeshData fetches new mesh data.
+func (connector *OCMDiscoveryConnector) RetrieveMeshData() (*meshdata.MeshData, error) {
+	meshData := new(meshdata.MeshData)
+	for _, name := range []string{meshdata.EndpointRevad, meshdata.EndpointOCM, meshdata.EndpointWebdav} {
+		meshData.AddServiceType(&meshdata.ServiceType{Name: name})
+	}
+
+	op := &meshdata.Operator{
+		ID:         connector.conf.Connectors.OCMDiscovery.Operator,
+		Email:      connector.conf.Connectors.OCMDiscovery.Email,
+		Properties: map[string]string{},
+	}
+
+	for _, endpoint := range connector.endpoints {
+		site, err := connector.querySite(meshData, endpoint)
+		if err != nil {
+			// A single unreachable provider shouldn't make the entire mesh disappear
+			connector.log.Warn().Err(err).Msgf("unable to query OCM discovery endpoint '%v'", endpoint)
+			continue
+		}
+		op.AddSite(site)
+	}
+	// If no provider answered, the problem is most likely on our side, so do not report an empty mesh
+	if len(op.Sites) == 0 {
+		return nil, fmt.Errorf("none of the OCM discovery endpoints could be queried")
+	}
+
+	meshData.AddOperator(op)
+	meshData.InferMissingData()
+	return meshData, nil
+}
+
+func (connector *OCMDiscoveryConnector) querySite(meshData *meshdata.MeshData, endpoint string) (*meshdata.Site, error) {
+	baseURL, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse URL '%v': %v", endpoint, err)
+	}
+	if len(baseURL.Scheme) == 0 {
+		baseURL, _ = url.Parse("https://" + strings.TrimSuffix(endpoint, "/"))
+	}
+
+	disco, err := connector.discover(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	if !disco.Enabled {
+		return nil, fmt.Errorf("OCM is disabled")
+	}
+
+	ocmURL, err := url.Parse(disco.Endpoint)
+	if err != nil || len(ocmURL.Host) == 0 {
+		return nil, fmt.Errorf("invalid OCM endpoint '%v'", disco.Endpoint)
+	}
+	hostURL := &url.URL{Scheme: ocmURL.Scheme, Host: ocmURL.Host}
+	host := ocmURL.Hostname()
+
+	properties := map[string]string{}
+	meshdata.SetPropertyValue(&properties, meshdata.PropertyAPIVersion, disco.APIVersion)
+
+	// The OCM endpoint is the main entry point of the provider, all supported protocols are published as additional endpoints
+	endpoints := []*meshdata.ServiceEndpoint{{
+		Type:        meshData.FindServiceType(meshdata.EndpointOCM),
+		Name:        meshdata.EndpointOCM,
+		RawURL:      disco.Endpoint,
+		URL:         disco.Endpoint,
+		IsMonitored: true,
+		Properties:  map[string]string{},
+	}}
+	for _, resourceType := range disco.ResourceTypes {
+		if webdav, ok := resourceType.Protocols["webdav"]; ok && resourceType.Name == "file" {
+			endpoints = append(endpoints, &meshdata.ServiceEndpoint{
+				Type:        meshData.FindServiceType(meshdata.EndpointWebdav),
+				Name:        meshdata.EndpointWebdav,
+				RawURL:      webdav,
+				URL:         connector.resolveURL(hostURL, webdav),
+				IsMonitored: true,
+				Properties:  map[string]string{},
+			})
+		}
+	}
+
+	// Providers often keep the default provider name, so use the host as the (unique) site name
+	fullName := disco.Provider
+	if fullName == "" {
+		fullName = host
+	}
+
+	return &meshdata.Site{
+		ID:       host,
+		Name:     host,
+		FullName: fullName,
+		Domain:   host,
+		Homepage: hostURL.String(),
+		Services: []*meshdata.Service{{
+			ServiceEndpoint: &meshdata.ServiceEndpoint{
+				Type:        meshData.FindServiceType(meshdata.EndpointRevad),
+				Name:        meshdata.EndpointRevad,
+				RawURL:      hostURL.String(),
+				URL:         hostURL.String(),
+				IsMonitored: true,
+				Properties:  properties,
+			},
+			Host:                ocmURL.Host,
+			AdditionalEndpoints: endpoints,
+		}},
+		Properties: map[string]string{},
+		Downtimes:  meshdata.Downtimes{},
+	}, nil
+}
+
+func (connector *OCMDiscoveryConnector) discover(baseURL *url.URL) (*ocmDiscoveryData, error) {
+	// If the discovery document itself was configured, use it directly; otherwise, try the well-known locations
+	candidates := []string{"/.well-known/ocm", "/ocm-provider"}
+	if strings.HasSuffix(baseURL.Path, "/ocm-provider") || strings.HasSuffix(baseURL.Path, "/.well-known/ocm") {
+		candidates = []string{""}
+	}
+
+	var err error
+	for _, candidate := range candidates {
+		discoURL := *baseURL
+		discoURL.Path = strings.TrimSuffix(discoURL.Path, "/") + candidate
+
+		var data []byte
+		if data, err = network.ReadEndpointWithClient(connector.client, &discoURL, nil, true); err != nil {
+			connector.log.Debug().Err(err).Msgf("unable to read OCM discovery document '%v'", discoURL.String())
+			continue
+		}
+
+		disco := &ocmDiscoveryData{}
+		if err = json.Unmarshal(data, disco); err != nil {
+			err = fmt.Errorf("unable to unmarshal data: %v", err)
+			continue
+		}
+		return disco, nil
+	}
+	return nil, err
+}
+
+func (connector *OCMDiscoveryConnector) resolveURL(hostURL *url.URL, ref string) string {
+	// Absolute URLs are used as they are, relative ones are resolved against the host of the provider
+	if refURL, err := url.Parse(ref); err == nil && len(refURL.Scheme) > 0 {
+		return ref
+	}
+
+	resolved := *hostURL
+	resolved.Path = path.Join("/", ref)
+	return resolved.String()
+}
+
+// GetID returns the ID of the connector.
+func (connector *OCMDiscoveryConnector) GetID() string {
+	return config.ConnectorIDOCMDiscovery
+}
+
+// GetName returns the display name of the connector.
+func (connector *OCMDiscoveryConnector) GetName() string {
+	return "OCM Discovery"
+}
+
+func init() {
+	registerConnector(&OCMDiscoveryConnector{})
+}
@@ -0,0 +1,113 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package connectors
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cs3org/reva/pkg/mentix/config"
+	"github.com/cs3org/reva/pkg/mentix/meshdata"
+	"github.com/rs/zerolog"
+)
+
+func TestOCMDiscoveryConnector(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ocm-provider" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{"enabled":true,"apiVersion":"1.0-proposal1","endPoint":"%s/ocm","provider":"cernbox",`+
+			`"resourceTypes":[{"name":"file","shareTypes":["user"],"protocols":{"webdav":"/cernbox/ocm_webdav"}}]}`, server.URL)
+	}))
+	defer server.Close()
+
+	// A provider that never answers must not block the retrieval
+	hanging := make(chan struct{})
+	hangingServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-hanging
+	}))
+	defer hangingServer.Close()
+	defer close(hanging)
+
+	conf := &config.Configuration{}
+	conf.Connectors.OCMDiscovery.Endpoints = []string{server.URL, "http://127.0.0.1:1", hangingServer.URL}
+	conf.Connectors.OCMDiscovery.Operator = "OCM"
+	conf.Connectors.OCMDiscovery.Email = "ops@example.org"
+	conf.Connectors.OCMDiscovery.Timeout = 1
+	logs := &bytes.Buffer{}
+	log := zerolog.New(logs).Level(zerolog.WarnLevel)
+
+	connector := &OCMDiscoveryConnector{}
+	if err := connector.Activate(conf, &log); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	meshData, err := connector.RetrieveMeshData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Fatalf("the unreachable providers took too long: %v", time.Since(start))
+	}
+	if n := strings.Count(logs.String(), "unable to query OCM discovery endpoint"); n != 2 {
+		t.Fatalf("expected the unreachable providers to be logged: %s", logs.String())
+	}
+	op := meshData.FindOperator("OCM")
+	if op == nil || len(op.Sites) != 1 {
+		t.Fatalf("expected a single reachable site: %+v", meshData.Operators)
+	}
+
+	site := op.Sites[0]
+	if site.Domain != "127.0.0.1" || site.FullName != "cernbox" {
+		t.Fatalf("unexpected site: %+v", site)
+	}
+	service := site.FindService(meshdata.EndpointRevad)
+	if service == nil {
+		t.Fatal("revad service missing")
+	}
+	if endpoint := service.FindEndpoint(meshdata.EndpointOCM); endpoint == nil || endpoint.URL != server.URL+"/ocm" {
+		t.Fatalf("unexpected OCM endpoint: %+v", endpoint)
+	}
+	if endpoint := service.FindEndpoint(meshdata.EndpointWebdav); endpoint == nil || endpoint.URL != server.URL+"/cernbox/ocm_webdav" {
+		t.Fatalf("unexpected WebDAV endpoint: %+v", endpoint)
+	}
+}
+
+func TestOCMDiscoveryConnectorUnreachable(t *testing.T) {
+	conf := &config.Configuration{}
+	conf.Connectors.OCMDiscovery.Endpoints = []string{"http://127.0.0.1:1", "http://127.0.0.1:2"}
+	conf.Connectors.OCMDiscovery.Timeout = 1
+	log := zerolog.Nop()
+
+	connector := &OCMDiscoveryConnector{}
+	if err := connector.Activate(conf, &log); err != nil {
+		t.Fatal(err)
+	}
+	if meshData, err := connector.RetrieveMeshData(); err == nil {
+		t.Fatalf("expected an error when no endpoint answered, got %+v", meshData)
+	}
+}
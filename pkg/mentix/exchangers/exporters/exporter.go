@@ -20,6 +20,7 @@ package exporters
 
 import (
 	"github.com/cs3org/reva/pkg/mentix/exchangers"
+	"github.com/cs3org/reva/pkg/mentix/health"
 	"github.com/cs3org/reva/pkg/mentix/meshdata"
 )
 
@@ -28,6 +29,12 @@ type Exporter interface {
 	exchangers.Exchanger
 }
 
+// HealthExporter is implemented by exporters that also expose the health status of the mesh.
+type HealthExporter interface {
+	// UpdateHealth is called whenever new health probe results are available.
+	UpdateHealth(history []*health.EndpointHistory) error
+}
+
 // BaseExporter implements basic exporter functionality common to all exporters.
 type BaseExporter struct {
 	exchangers.BaseExchanger
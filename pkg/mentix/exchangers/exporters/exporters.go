@@ -68,6 +68,17 @@ func (collection *Collection) GetRequestExporters() []exchangers.RequestExchange
 	return exchangers.GetRequestExchangers(collection)
 }
 
+// GetHealthExporters returns all exporters that implement the HealthExporter interface.
+func (collection *Collection) GetHealthExporters() []HealthExporter {
+	exporters := make([]HealthExporter, 0, len(collection.Exporters))
+	for _, exporter := range collection.Exporters {
+		if healthExporter, ok := exporter.(HealthExporter); ok {
+			exporters = append(exporters, healthExporter)
+		}
+	}
+	return exporters
+}
+
 // AvailableExporters returns a list of all exporters that are enabled in the configuration.
 func AvailableExporters(conf *config.Configuration) (*Collection, error) {
 	// Try to add all exporters configured in the environment
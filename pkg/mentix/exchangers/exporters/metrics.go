@@ -21,6 +21,7 @@ package exporters
 import (
 	"github.com/cs3org/reva/pkg/mentix/config"
 	"github.com/cs3org/reva/pkg/mentix/exchangers/exporters/metrics"
+	"github.com/cs3org/reva/pkg/mentix/health"
 	"github.com/cs3org/reva/pkg/mentix/meshdata"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
@@ -69,6 +70,15 @@ func (exporter *MetricsExporter) Update(meshDataSet meshdata.Map) error {
 	return nil
 }
 
+// UpdateHealth is called whenever new health probe results are available.
+func (exporter *MetricsExporter) UpdateHealth(history []*health.EndpointHistory) error {
+	if err := exporter.metrics.UpdateHealth(history); err != nil {
+		return errors.Wrap(err, "error while updating the health metrics")
+	}
+
+	return nil
+}
+
 // GetID returns the ID of the exporter.
 func (exporter *MetricsExporter) GetID() string {
 	return config.ExporterIDMetrics
@@ -22,6 +22,7 @@ import (
 	"context"
 
 	"github.com/cs3org/reva/pkg/mentix/config"
+	"github.com/cs3org/reva/pkg/mentix/health"
 	"github.com/cs3org/reva/pkg/mentix/meshdata"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
@@ -36,6 +37,11 @@ type Metrics struct {
 	log  *zerolog.Logger
 
 	isScheduledStats *stats.Int64Measure
+
+	endpointUpStats        *stats.Int64Measure
+	endpointLatencyStats   *stats.Int64Measure
+	endpointTLSExpiryStats *stats.Int64Measure
+	endpointOCMValidStats  *stats.Int64Measure
 }
 
 const (
@@ -43,6 +49,7 @@ const (
 	keySiteID      = "site_id"
 	keySiteName    = "site"
 	keyServiceType = "service_type"
+	keyEndpoint    = "endpoint"
 )
 
 func (m *Metrics) initialize(conf *config.Configuration, log *zerolog.Logger) error {
@@ -78,6 +85,27 @@ func (m *Metrics) registerMetrics() error {
 		return errors.Wrap(err, "unable to register the site schedule status metrics view")
 	}
 
+	// Create the health statistics and their views
+	m.endpointUpStats = stats.Int64("service_endpoint_up", "A boolean metric which shows whether the given service endpoint passed its last health probe", stats.UnitDimensionless)
+	m.endpointLatencyStats = stats.Int64("service_endpoint_latency", "The response time of the given service endpoint during its last health probe", stats.UnitMilliseconds)
+	m.endpointTLSExpiryStats = stats.Int64("service_endpoint_tls_expiry", "The expiry date of the TLS certificate of the given service endpoint as a Unix timestamp", stats.UnitSeconds)
+	m.endpointOCMValidStats = stats.Int64("service_endpoint_ocm_valid", "A boolean metric which shows whether the given OCM endpoint publishes a valid discovery document", stats.UnitDimensionless)
+
+	healthTagKeys := []tag.Key{tag.MustNewKey(keyOperatorID), tag.MustNewKey(keySiteID), tag.MustNewKey(keySiteName), tag.MustNewKey(keyServiceType), tag.MustNewKey(keyEndpoint)}
+	for _, measure := range []*stats.Int64Measure{m.endpointUpStats, m.endpointLatencyStats, m.endpointTLSExpiryStats, m.endpointOCMValidStats} {
+		healthView := &view.View{
+			Name:        measure.Name(),
+			Description: measure.Description(),
+			Measure:     measure,
+			TagKeys:     healthTagKeys,
+			Aggregation: view.LastValue(),
+		}
+
+		if err := view.Register(healthView); err != nil {
+			return errors.Wrapf(err, "unable to register the %v metrics view", measure.Name())
+		}
+	}
+
 	return nil
 }
 
@@ -115,6 +143,47 @@ func (m *Metrics) exportSiteMetrics(site *meshdata.Site, op *meshdata.Operator)
 	return nil
 }
 
+// UpdateHealth is used to expose the latest health probe results.
+func (m *Metrics) UpdateHealth(history []*health.EndpointHistory) error {
+	for _, entry := range history {
+		status := entry.Latest()
+		if status == nil {
+			continue
+		}
+
+		mutators := make([]tag.Mutator, 0)
+		mutators = append(mutators, tag.Insert(tag.MustNewKey(keyOperatorID), entry.OperatorID))
+		mutators = append(mutators, tag.Insert(tag.MustNewKey(keySiteID), entry.SiteID))
+		mutators = append(mutators, tag.Insert(tag.MustNewKey(keySiteName), entry.SiteName))
+		mutators = append(mutators, tag.Insert(tag.MustNewKey(keyServiceType), entry.ServiceType))
+		mutators = append(mutators, tag.Insert(tag.MustNewKey(keyEndpoint), entry.URL))
+
+		ctx, err := tag.New(context.Background(), mutators...)
+		if err != nil {
+			return errors.Wrapf(err, "unable to create a context for the health metrics of '%v'", entry.URL)
+		}
+
+		isUp := int64(0)
+		if status.IsHealthy() {
+			isUp = 1
+		}
+		measurements := []stats.Measurement{m.endpointUpStats.M(isUp), m.endpointLatencyStats.M(status.Latency.Milliseconds())}
+		if !status.TLSExpiry.IsZero() {
+			measurements = append(measurements, m.endpointTLSExpiryStats.M(status.TLSExpiry.Unix()))
+		}
+		if status.OCMValid != nil {
+			isValid := int64(0)
+			if *status.OCMValid {
+				isValid = 1
+			}
+			measurements = append(measurements, m.endpointOCMValidStats.M(isValid))
+		}
+		stats.Record(ctx, measurements...)
+	}
+
+	return nil
+}
+
 // New creates a new Metrics instance.
 func New(conf *config.Configuration, log *zerolog.Logger) (*Metrics, error) {
 	m := &Metrics{}
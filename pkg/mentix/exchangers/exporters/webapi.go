@@ -19,14 +19,22 @@
 package exporters
 
 import (
+	"net/url"
+	"sync"
+
 	"github.com/cs3org/reva/pkg/mentix/config"
 	"github.com/cs3org/reva/pkg/mentix/exchangers/exporters/webapi"
+	"github.com/cs3org/reva/pkg/mentix/health"
+	"github.com/cs3org/reva/pkg/mentix/meshdata"
 	"github.com/rs/zerolog"
 )
 
 // WebAPIExporter implements the generic Web API exporter.
 type WebAPIExporter struct {
 	BaseRequestExporter
+
+	healthHistory []*health.EndpointHistory
+	healthLocker  sync.RWMutex
 }
 
 // Activate activates the exporter.
@@ -40,10 +48,27 @@ func (exporter *WebAPIExporter) Activate(conf *config.Configuration, log *zerolo
 	exporter.SetEnabledConnectors(conf.Exporters.WebAPI.EnabledConnectors)
 
 	exporter.RegisterActionHandler("", webapi.HandleDefaultQuery)
+	exporter.RegisterActionHandler("health", exporter.handleHealthQuery)
 
 	return nil
 }
 
+// UpdateHealth is called whenever new health probe results are available.
+func (exporter *WebAPIExporter) UpdateHealth(history []*health.EndpointHistory) error {
+	exporter.healthLocker.Lock()
+	defer exporter.healthLocker.Unlock()
+
+	exporter.healthHistory = history
+	return nil
+}
+
+func (exporter *WebAPIExporter) handleHealthQuery(_ *meshdata.MeshData, params url.Values, conf *config.Configuration, log *zerolog.Logger) (int, []byte, error) {
+	exporter.healthLocker.RLock()
+	defer exporter.healthLocker.RUnlock()
+
+	return webapi.HandleHealthQuery(exporter.healthHistory, params, conf, log)
+}
+
 // GetID returns the ID of the exporter.
 func (exporter *WebAPIExporter) GetID() string {
 	return config.ExporterIDWebAPI
@@ -0,0 +1,54 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package webapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/cs3org/reva/pkg/mentix/config"
+	"github.com/cs3org/reva/pkg/mentix/health"
+	"github.com/rs/zerolog"
+)
+
+// HandleHealthQuery returns the health status history of all probed service endpoints; the results can be limited
+// to a single site using the 'site' parameter.
+func HandleHealthQuery(history []*health.EndpointHistory, params url.Values, _ *config.Configuration, _ *zerolog.Logger) (int, []byte, error) {
+	if history == nil {
+		return http.StatusServiceUnavailable, []byte{}, fmt.Errorf("no health status available")
+	}
+
+	entries := make([]*health.EndpointHistory, 0, len(history))
+	site := params.Get("site")
+	for _, entry := range history {
+		if site == "" || strings.EqualFold(entry.SiteID, site) || strings.EqualFold(entry.SiteName, site) {
+			entries = append(entries, entry)
+		}
+	}
+
+	data, err := json.MarshalIndent(entries, "", "\t")
+	if err != nil {
+		return http.StatusBadRequest, []byte{}, fmt.Errorf("unable to marshal the health status: %v", err)
+	}
+
+	return http.StatusOK, data, nil
+}
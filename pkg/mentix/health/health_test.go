@@ -0,0 +1,91 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package health
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cs3org/reva/pkg/mentix/meshdata"
+)
+
+func TestProbe(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ocm/ocm-provider":
+			fmt.Fprintf(w, `{"enabled":true,"endPoint":"%s/ocm"}`, server.URL)
+		case "/broken":
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	prober := NewProber(5*time.Second, true)
+
+	status := prober.Probe(Target{ServiceType: meshdata.EndpointOCM, URL: server.URL + "/ocm"})
+	if !status.IsHealthy() || status.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected healthy OCM endpoint: %+v", status)
+	}
+	if status.OCMValid == nil || !*status.OCMValid {
+		t.Fatalf("expected valid OCM discovery: %+v", status)
+	}
+	if status.TLSExpiry.IsZero() {
+		t.Fatal("expected TLS expiry to be set")
+	}
+
+	status = prober.Probe(Target{ServiceType: meshdata.EndpointWebdav, URL: server.URL + "/broken"})
+	if status.IsHealthy() || status.OCMValid != nil {
+		t.Fatalf("expected unhealthy endpoint: %+v", status)
+	}
+
+	status = prober.Probe(Target{ServiceType: meshdata.EndpointOCM, URL: server.URL + "/other"})
+	if status.IsHealthy() {
+		t.Fatalf("expected OCM endpoint without discovery to be unhealthy: %+v", status)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	history := NewHistory(2)
+	a := Target{SiteID: "A", URL: "https://a.example.org"}
+	b := Target{SiteID: "B", URL: "https://b.example.org"}
+
+	for i := 1; i <= 3; i++ {
+		history.Record(a, &Status{StatusCode: i})
+	}
+	history.Record(b, &Status{StatusCode: 1})
+
+	snapshot := history.Snapshot()
+	if len(snapshot) != 2 || snapshot[0].SiteID != "A" {
+		t.Fatalf("unexpected snapshot: %+v", snapshot)
+	}
+	if len(snapshot[0].Statuses) != 2 || snapshot[0].Latest().StatusCode != 3 {
+		t.Fatalf("expected a rolling window of two statuses: %+v", snapshot[0].Statuses)
+	}
+
+	history.Prune([]Target{b})
+	if snapshot = history.Snapshot(); len(snapshot) != 1 || snapshot[0].SiteID != "B" {
+		t.Fatalf("expected pruned history: %+v", snapshot)
+	}
+}
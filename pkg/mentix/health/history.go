@@ -0,0 +1,147 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package health
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Status is the outcome of a single probe of a service endpoint.
+type Status struct {
+	Timestamp  time.Time
+	Reachable  bool
+	StatusCode int
+	Latency    time.Duration
+	// OCMValid is only set for OCM endpoints and tells whether a valid discovery document is served.
+	OCMValid *bool `json:",omitempty"`
+	// TLSExpiry holds the expiry date of the server certificate; it is zero for plain HTTP endpoints.
+	TLSExpiry time.Time
+	Error     string
+}
+
+// IsHealthy tells whether the probe succeeded without any issues.
+func (status *Status) IsHealthy() bool {
+	return status.Reachable && status.Error == "" && (status.OCMValid == nil || *status.OCMValid)
+}
+
+// Target identifies a service endpoint to probe.
+type Target struct {
+	OperatorID  string
+	SiteID      string
+	SiteName    string
+	ServiceType string
+	Name        string
+	URL         string
+}
+
+func (target *Target) key() string {
+	return strings.Join([]string{target.OperatorID, target.SiteID, target.ServiceType, target.Name, target.URL}, "|")
+}
+
+// EndpointHistory holds the most recent probe results of a service endpoint, ordered from oldest to newest.
+type EndpointHistory struct {
+	Target
+	Statuses []*Status
+}
+
+// Latest returns the most recent probe result.
+func (history *EndpointHistory) Latest() *Status {
+	if len(history.Statuses) == 0 {
+		return nil
+	}
+	return history.Statuses[len(history.Statuses)-1]
+}
+
+// History stores a rolling window of probe results for all probed endpoints.
+type History struct {
+	size int
+
+	entries map[string]*EndpointHistory
+	mutex   sync.RWMutex
+}
+
+// Record appends a new probe result for the given target, dropping the oldest one if the window is full.
+func (history *History) Record(target Target, status *Status) {
+	history.mutex.Lock()
+	defer history.mutex.Unlock()
+
+	entry, ok := history.entries[target.key()]
+	if !ok {
+		entry = &EndpointHistory{Target: target}
+		history.entries[target.key()] = entry
+	}
+
+	entry.Statuses = append(entry.Statuses, status)
+	if len(entry.Statuses) > history.size {
+		entry.Statuses = entry.Statuses[len(entry.Statuses)-history.size:]
+	}
+}
+
+// Prune removes the history of all endpoints which are not among the given targets anymore.
+func (history *History) Prune(targets []Target) {
+	keep := make(map[string]bool, len(targets))
+	for _, target := range targets {
+		keep[target.key()] = true
+	}
+
+	history.mutex.Lock()
+	defer history.mutex.Unlock()
+
+	for key := range history.entries {
+		if !keep[key] {
+			delete(history.entries, key)
+		}
+	}
+}
+
+// Snapshot returns a copy of the entire history, sorted by site and endpoint.
+func (history *History) Snapshot() []*EndpointHistory {
+	history.mutex.RLock()
+	defer history.mutex.RUnlock()
+
+	keys := make([]string, 0, len(history.entries))
+	for key := range history.entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	snapshot := make([]*EndpointHistory, 0, len(keys))
+	for _, key := range keys {
+		entry := history.entries[key]
+		snapshot = append(snapshot, &EndpointHistory{
+			Target:   entry.Target,
+			Statuses: append([]*Status(nil), entry.Statuses...),
+		})
+	}
+	return snapshot
+}
+
+// NewHistory creates a new history keeping the given number of probe results per endpoint.
+func NewHistory(size int) *History {
+	if size <= 0 {
+		size = 1
+	}
+	return &History{
+		size:    size,
+		entries: make(map[string]*EndpointHistory),
+	}
+}
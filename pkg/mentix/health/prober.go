@@ -0,0 +1,177 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package health
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cs3org/reva/pkg/mentix/meshdata"
+)
+
+const (
+	maxConcurrentProbes = 8
+)
+
+// Prober probes service endpoints for their reachability, the validity of their TLS certificates and, for OCM
+// endpoints, the validity of the published OCM discovery document.
+type Prober struct {
+	client *http.Client
+}
+
+// GetTargets returns all monitored service endpoints of the given mesh data.
+func GetTargets(meshData *meshdata.MeshData) []Target {
+	var targets []Target
+	for _, op := range meshData.Operators {
+		for _, site := range op.Sites {
+			addTarget := func(endpoint *meshdata.ServiceEndpoint) {
+				if endpoint == nil || !endpoint.IsMonitored || endpoint.URL == "" {
+					return
+				}
+				serviceType := ""
+				if endpoint.Type != nil {
+					serviceType = endpoint.Type.Name
+				}
+				targets = append(targets, Target{
+					OperatorID:  op.ID,
+					SiteID:      site.ID,
+					SiteName:    site.Name,
+					ServiceType: serviceType,
+					Name:        endpoint.Name,
+					URL:         endpoint.URL,
+				})
+			}
+
+			for _, service := range site.Services {
+				addTarget(service.ServiceEndpoint)
+				for _, endpoint := range service.AdditionalEndpoints {
+					addTarget(endpoint)
+				}
+			}
+		}
+	}
+	return targets
+}
+
+// ProbeAll probes all given targets concurrently and records the results in the history.
+func (prober *Prober) ProbeAll(targets []Target, history *History) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrentProbes)
+
+	for _, target := range targets {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(target Target) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			history.Record(target, prober.Probe(target))
+		}(target)
+	}
+	wg.Wait()
+
+	history.Prune(targets)
+}
+
+// Probe probes a single target.
+func (prober *Prober) Probe(target Target) *Status {
+	status := &Status{Timestamp: time.Now()}
+
+	resp, err := prober.client.Get(target.URL)
+	status.Latency = time.Since(status.Timestamp)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	// Any response that isn't a server error means that the endpoint is up
+	status.StatusCode = resp.StatusCode
+	status.Reachable = resp.StatusCode < http.StatusInternalServerError
+	if !status.Reachable {
+		status.Error = fmt.Sprintf("server error: %v", resp.Status)
+	}
+
+	if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+		status.TLSExpiry = resp.TLS.PeerCertificates[0].NotAfter
+		if status.TLSExpiry.Before(time.Now()) {
+			status.Error = "TLS certificate expired"
+		}
+	}
+
+	if strings.EqualFold(target.ServiceType, meshdata.EndpointOCM) {
+		valid := prober.hasValidDiscovery(target.URL)
+		status.OCMValid = &valid
+	}
+
+	return status
+}
+
+func (prober *Prober) hasValidDiscovery(endpoint string) bool {
+	endpointURL, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil {
+		return false
+	}
+
+	// The discovery document is either served below the OCM endpoint or at the well-known locations of the host
+	candidates := []string{
+		endpointURL.String() + "/ocm-provider",
+		endpointURL.Scheme + "://" + endpointURL.Host + "/.well-known/ocm",
+		endpointURL.Scheme + "://" + endpointURL.Host + "/ocm-provider",
+	}
+	for _, candidate := range candidates {
+		resp, err := prober.client.Get(candidate)
+		if err != nil {
+			continue
+		}
+
+		var disco struct {
+			Enabled  bool   `json:"enabled"`
+			Endpoint string `json:"endPoint"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&disco)
+		resp.Body.Close()
+
+		if err == nil && resp.StatusCode == http.StatusOK && disco.Enabled && disco.Endpoint != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// NewProber creates a new prober.
+func NewProber(timeout time.Duration, insecure bool) *Prober {
+	return &Prober{
+		client: &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
+			},
+		},
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/cs3org/reva/pkg/appctx"
@@ -31,6 +32,7 @@ import (
 	"github.com/cs3org/reva/pkg/mentix/exchangers"
 	"github.com/cs3org/reva/pkg/mentix/exchangers/exporters"
 	"github.com/cs3org/reva/pkg/mentix/exchangers/importers"
+	"github.com/cs3org/reva/pkg/mentix/health"
 	"github.com/cs3org/reva/pkg/mentix/meshdata"
 	"github.com/rs/zerolog"
 )
@@ -47,6 +49,12 @@ type Mentix struct {
 	meshDataSet meshdata.Map
 
 	updateInterval time.Duration
+
+	prober          *health.Prober
+	healthHistory   *health.History
+	probeInterval   time.Duration
+	probeTimestamp  time.Time
+	probeInProgress int32
 }
 
 const (
@@ -85,6 +93,11 @@ func (mntx *Mentix) initialize(conf *config.Configuration, log *zerolog.Logger)
 	// Create empty mesh data set
 	mntx.meshDataSet = make(meshdata.Map)
 
+	// Set up health probing if enabled
+	if err := mntx.initHealthProbing(); err != nil {
+		return fmt.Errorf("unable to initialize health probing: %v", err)
+	}
+
 	// Log some infos
 	connectorNames := entity.GetNames(mntx.connectors)
 	importerNames := entity.GetNames(mntx.importers)
@@ -114,6 +127,29 @@ func (mntx *Mentix) initConnectors() error {
 	return nil
 }
 
+func (mntx *Mentix) initHealthProbing() error {
+	// Health probing is only enabled if a probing interval has been configured
+	if mntx.conf.Health.Interval == "" {
+		return nil
+	}
+
+	interval, err := time.ParseDuration(mntx.conf.Health.Interval)
+	if err != nil {
+		return fmt.Errorf("invalid probing interval: %v", err)
+	}
+	timeout, err := time.ParseDuration(mntx.conf.Health.Timeout)
+	if err != nil {
+		return fmt.Errorf("invalid probing timeout: %v", err)
+	}
+
+	mntx.prober = health.NewProber(timeout, mntx.conf.Health.Insecure)
+	mntx.healthHistory = health.NewHistory(mntx.conf.Health.HistorySize)
+	mntx.probeInterval = interval
+
+	mntx.log.Info().Msgf("health probing enabled; probing interval: %v", interval)
+	return nil
+}
+
 func (mntx *Mentix) initExchangers() error {
 	// Use all importers exposed by the importers package
 	imps, err := importers.AvailableImporters(mntx.conf)
@@ -203,7 +239,7 @@ func (mntx *Mentix) tick(updateTimestamp *time.Time) {
 	}
 
 	// If mesh data has been imported or enough time has passed, update the stored mesh data and all exporters
-	if meshDataUpdated || time.Since(*updateTimestamp) >= mntx.updateInterval {
+	if meshDataUpdated || mntx.connectorSourcesChanged() || time.Since(*updateTimestamp) >= mntx.updateInterval {
 		// Retrieve and update the mesh data; if the importers modified any data, these changes will
 		// be reflected automatically here
 		if meshDataSet, err := mntx.retrieveMeshDataSet(); err == nil {
@@ -216,6 +252,49 @@ func (mntx *Mentix) tick(updateTimestamp *time.Time) {
 
 		*updateTimestamp = time.Now()
 	}
+
+	// Probe the health of all services regularly in the background
+	if mntx.prober != nil && time.Since(mntx.probeTimestamp) >= mntx.probeInterval && len(mntx.meshDataSet) > 0 {
+		if atomic.CompareAndSwapInt32(&mntx.probeInProgress, 0, 1) {
+			mntx.probeTimestamp = time.Now()
+			go mntx.probeHealth(mntx.cloneMeshData())
+		}
+	}
+}
+
+func (mntx *Mentix) cloneMeshData() *meshdata.MeshData {
+	// The merged data must not share any data with the mesh data set, so clone everything first
+	meshDataSet := make(meshdata.Map, len(mntx.meshDataSet))
+	for connectorID, meshData := range mntx.meshDataSet {
+		meshDataSet[connectorID] = meshData.Clone()
+	}
+	return meshdata.MergeMeshDataMap(meshDataSet)
+}
+
+func (mntx *Mentix) probeHealth(meshData *meshdata.MeshData) {
+	defer atomic.StoreInt32(&mntx.probeInProgress, 0)
+
+	targets := health.GetTargets(meshData)
+	mntx.prober.ProbeAll(targets, mntx.healthHistory)
+	mntx.log.Debug().Msgf("probed the health of %v service endpoints", len(targets))
+
+	history := mntx.healthHistory.Snapshot()
+	for _, exporter := range mntx.exporters.GetHealthExporters() {
+		if err := exporter.UpdateHealth(history); err != nil {
+			mntx.log.Err(err).Msg("unable to update the health status on exporter")
+		}
+	}
+}
+
+func (mntx *Mentix) connectorSourcesChanged() bool {
+	changed := false
+	for _, connector := range mntx.connectors.Connectors {
+		if watcher, ok := connector.(connectors.SourceWatcher); ok && watcher.SourceChanged() {
+			mntx.log.Debug().Msgf("data source of connector '%v' changed", connector.GetName())
+			changed = true
+		}
+	}
+	return changed
 }
 
 func (mntx *Mentix) processImporters() (bool, error) {
@@ -65,7 +65,7 @@ func GenerateURL(host string, path string, params URLParams) (*url.URL, error) {
 	return fullURL, nil
 }
 
-func queryEndpoint(method string, endpointURL *url.URL, auth *BasicAuth, checkStatus bool) ([]byte, error) {
+func queryEndpoint(client *http.Client, method string, endpointURL *url.URL, auth *BasicAuth, checkStatus bool) ([]byte, error) {
 	// Prepare the request
 	req, err := http.NewRequest(method, endpointURL.String(), nil)
 	if err != nil {
@@ -77,7 +77,7 @@ func queryEndpoint(method string, endpointURL *url.URL, auth *BasicAuth, checkSt
 	}
 
 	// Fetch the data and read the body
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("unable to get data from endpoint: %v", err)
 	}
@@ -93,12 +93,17 @@ func queryEndpoint(method string, endpointURL *url.URL, auth *BasicAuth, checkSt
 
 // ReadEndpoint reads data from an HTTP endpoint via GET.
 func ReadEndpoint(endpointURL *url.URL, auth *BasicAuth, checkStatus bool) ([]byte, error) {
-	return queryEndpoint(http.MethodGet, endpointURL, auth, checkStatus)
+	return queryEndpoint(http.DefaultClient, http.MethodGet, endpointURL, auth, checkStatus)
+}
+
+// ReadEndpointWithClient reads data from an HTTP endpoint via GET using the given client, e.g. to apply a timeout.
+func ReadEndpointWithClient(client *http.Client, endpointURL *url.URL, auth *BasicAuth, checkStatus bool) ([]byte, error) {
+	return queryEndpoint(client, http.MethodGet, endpointURL, auth, checkStatus)
 }
 
 // WriteEndpoint sends data to an HTTP endpoint via POST.
 func WriteEndpoint(endpointURL *url.URL, auth *BasicAuth, checkStatus bool) ([]byte, error) {
-	return queryEndpoint(http.MethodPost, endpointURL, auth, checkStatus)
+	return queryEndpoint(http.DefaultClient, http.MethodPost, endpointURL, auth, checkStatus)
 }
 
 // CreateResponse creates a generic HTTP response in JSON format.
@@ -42,4 +42,16 @@ type Manager interface {
 
 	// FindAcceptedUsers finds remote users who have accepted invites based on their attributes.
 	FindAcceptedUsers(ctx context.Context, query string) ([]*userpb.User, error)
+
+	// ListInviteTokens returns the invite tokens generated by the user, including the expired ones.
+	ListInviteTokens(ctx context.Context) ([]*invitepb.InviteToken, error)
+
+	// RevokeInviteToken deletes an invite token generated by the user, so that it cannot be accepted anymore.
+	RevokeInviteToken(ctx context.Context, token string) error
+
+	// DeleteExpiredInviteTokens deletes the expired invite tokens of the user and returns how many were deleted.
+	DeleteExpiredInviteTokens(ctx context.Context) (int, error)
+
+	// DeleteAcceptedUser removes a remote user from the users who have accepted an invite of the user.
+	DeleteAcceptedUser(ctx context.Context, remoteUserID *userpb.UserId) error
 }
@@ -0,0 +1,56 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package invite
+
+import (
+	"fmt"
+	"net/url"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	invitepb "github.com/cs3org/go-cs3apis/cs3/ocm/invite/v1beta1"
+)
+
+// Mail returns the subject and the body of the mail delivering an invite token
+// generated by the user u. The recipient can accept it through the mesh directory.
+func Mail(u *userpb.User, token *invitepb.InviteToken, meshDirectoryURL string) (string, string) {
+	subject := fmt.Sprintf("ScienceMesh: %s wants to collaborate with you", u.DisplayName)
+	body := "Hi,\n\n" +
+		u.DisplayName + " (" + u.Mail + ") wants to start sharing OCM resources with you. " +
+		"To accept the invite, please visit the following URL:\n" +
+		acceptURL(meshDirectoryURL, token.Token, u.Id.Idp) + "\n\n" +
+		"Alternatively, you can visit your mesh provider and use the following details:\n" +
+		"Token: " + token.Token + "\n" +
+		"ProviderDomain: " + u.Id.Idp + "\n\n" +
+		"Best,\nThe ScienceMesh team"
+	return subject, body
+}
+
+// acceptURL returns the mesh directory link through which the token can be accepted,
+// preserving any query parameters already present in the configured URL.
+func acceptURL(meshDirectoryURL, token, providerDomain string) string {
+	u, err := url.Parse(meshDirectoryURL)
+	if err != nil {
+		return meshDirectoryURL + "?" + url.Values{"token": {token}, "providerDomain": {providerDomain}}.Encode()
+	}
+	q := u.Query()
+	q.Set("token", token)
+	q.Set("providerDomain", providerDomain)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
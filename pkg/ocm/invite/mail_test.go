@@ -0,0 +1,50 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package invite
+
+import (
+	"strings"
+	"testing"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	invitepb "github.com/cs3org/go-cs3apis/cs3/ocm/invite/v1beta1"
+)
+
+func TestMail(t *testing.T) {
+	u := &userpb.User{
+		Id:          &userpb.UserId{OpaqueId: "einstein", Idp: "cernbox.cern.ch"},
+		DisplayName: "Albert Einstein",
+		Mail:        "einstein@cern.ch",
+	}
+	token := &invitepb.InviteToken{Token: "a b&c"}
+
+	tests := map[string]string{
+		"https://sciencemesh.example.org/meshdir":         "https://sciencemesh.example.org/meshdir?providerDomain=cernbox.cern.ch&token=a+b%26c",
+		"https://sciencemesh.example.org/meshdir?lang=en": "https://sciencemesh.example.org/meshdir?lang=en&providerDomain=cernbox.cern.ch&token=a+b%26c",
+	}
+	for meshDirectoryURL, link := range tests {
+		subject, body := Mail(u, token, meshDirectoryURL)
+		if !strings.Contains(subject, "Albert Einstein") {
+			t.Errorf("unexpected subject %q", subject)
+		}
+		if !strings.Contains(body, "\n"+link+"\n") {
+			t.Errorf("expected body to contain %q, got %q", link, body)
+		}
+	}
+}
@@ -27,6 +27,7 @@ import (
 	"net/url"
 	"os"
 	"path"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -39,7 +40,9 @@ import (
 	"github.com/cs3org/reva/pkg/ocm/invite"
 	"github.com/cs3org/reva/pkg/ocm/invite/manager/registry"
 	"github.com/cs3org/reva/pkg/ocm/invite/token"
+	"github.com/cs3org/reva/pkg/ocm/signature"
 	"github.com/cs3org/reva/pkg/rhttp"
+	"github.com/cs3org/reva/pkg/utils"
 	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
 )
@@ -217,11 +220,15 @@ func (m *manager) ForwardInvite(ctx context.Context, invite *invitepb.InviteToke
 	u.Path = path.Join(u.Path, acceptInviteEndpoint)
 	recipientURL := u.String()
 
-	req, err := http.NewRequest(http.MethodPost, recipientURL, strings.NewReader(requestBody.Encode()))
+	body := requestBody.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, recipientURL, strings.NewReader(body))
 	if err != nil {
 		return errors.Wrap(err, "json: error framing post request")
 	}
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; param=value")
+	if err := signature.Sign(req, []byte(body)); err != nil {
+		return errors.Wrap(err, "json: error signing post request")
+	}
 
 	resp, err := m.client.Do(req)
 	if err != nil {
@@ -291,6 +298,85 @@ func (m *manager) FindAcceptedUsers(ctx context.Context, query string) ([]*userp
 	return users, nil
 }
 
+func (m *manager) ListInviteTokens(ctx context.Context) ([]*invitepb.InviteToken, error) {
+	userID := ctxpkg.ContextMustGetUser(ctx).GetId()
+
+	m.Lock()
+	defer m.Unlock()
+
+	tokens := []*invitepb.InviteToken{}
+	for _, inviteToken := range m.model.Invites {
+		if utils.UserEqual(inviteToken.UserId, userID) {
+			tokens = append(tokens, inviteToken)
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].Expiration.GetSeconds() < tokens[j].Expiration.GetSeconds()
+	})
+	return tokens, nil
+}
+
+func (m *manager) RevokeInviteToken(ctx context.Context, token string) error {
+	userID := ctxpkg.ContextMustGetUser(ctx).GetId()
+
+	m.Lock()
+	defer m.Unlock()
+
+	inviteToken, ok := m.model.Invites[token]
+	if !ok || !utils.UserEqual(inviteToken.UserId, userID) {
+		return errtypes.NotFound(token)
+	}
+	delete(m.model.Invites, token)
+	if err := m.model.Save(); err != nil {
+		err = errors.Wrap(err, "json: error saving model")
+		return err
+	}
+	return nil
+}
+
+func (m *manager) DeleteExpiredInviteTokens(ctx context.Context) (int, error) {
+	userID := ctxpkg.ContextMustGetUser(ctx).GetId()
+	now := uint64(time.Now().Unix())
+
+	m.Lock()
+	defer m.Unlock()
+
+	deleted := 0
+	for token, inviteToken := range m.model.Invites {
+		if utils.UserEqual(inviteToken.UserId, userID) && now > inviteToken.Expiration.GetSeconds() {
+			delete(m.model.Invites, token)
+			deleted++
+		}
+	}
+	if deleted > 0 {
+		if err := m.model.Save(); err != nil {
+			err = errors.Wrap(err, "json: error saving model")
+			return 0, err
+		}
+	}
+	return deleted, nil
+}
+
+func (m *manager) DeleteAcceptedUser(ctx context.Context, remoteUserID *userpb.UserId) error {
+	userKey := ctxpkg.ContextMustGetUser(ctx).GetId().GetOpaqueId()
+
+	m.Lock()
+	defer m.Unlock()
+
+	acceptedUsers := m.model.AcceptedUsers[userKey]
+	for i, acceptedUser := range acceptedUsers {
+		if (acceptedUser.Id.GetOpaqueId() == remoteUserID.OpaqueId) && (remoteUserID.Idp == "" || acceptedUser.Id.GetIdp() == remoteUserID.Idp) {
+			m.model.AcceptedUsers[userKey] = append(acceptedUsers[:i], acceptedUsers[i+1:]...)
+			if err := m.model.Save(); err != nil {
+				err = errors.Wrap(err, "json: error saving model")
+				return err
+			}
+			return nil
+		}
+	}
+	return errtypes.NotFound(remoteUserID.OpaqueId)
+}
+
 func userContains(u *userpb.User, query string) bool {
 	query = strings.ToLower(query)
 	return strings.Contains(strings.ToLower(u.Username), query) || strings.Contains(strings.ToLower(u.DisplayName), query) ||
@@ -0,0 +1,92 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package json
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	ctxpkg "github.com/cs3org/reva/pkg/ctx"
+)
+
+func TestInviteLifecycle(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "invites.json")
+	m, err := New(map[string]interface{}{"file": file, "expiration": "-1h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	einstein := &userpb.User{Id: &userpb.UserId{OpaqueId: "einstein", Idp: "cernbox.cern.ch"}}
+	ctx := ctxpkg.ContextSetUser(context.Background(), einstein)
+
+	expired, err := m.GenerateToken(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	revoked, err := m.GenerateToken(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.RevokeInviteToken(ctx, revoked.Token); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.RevokeInviteToken(ctx, revoked.Token); err == nil {
+		t.Fatal("expected revoking a token twice to fail")
+	}
+
+	tokens, err := m.ListInviteTokens(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 1 || tokens[0].Token != expired.Token {
+		t.Fatalf("expected only the expired token, got %v", tokens)
+	}
+
+	// the changes must be persisted
+	m, err = New(map[string]interface{}{"file": file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := m.DeleteExpiredInviteTokens(ctx); err != nil || n != 1 {
+		t.Fatalf("expected the expired token to be deleted, got %d, %v", n, err)
+	}
+	if tokens, _ := m.ListInviteTokens(ctx); len(tokens) != 0 {
+		t.Fatalf("expected no token left, got %v", tokens)
+	}
+
+	valid, err := m.GenerateToken(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	marie := &userpb.User{Id: &userpb.UserId{OpaqueId: "marie", Idp: "cesnet.cz"}}
+	if err := m.AcceptInvite(ctx, valid, marie); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.DeleteAcceptedUser(ctx, &userpb.UserId{OpaqueId: "marie", Idp: "other.org"}); err == nil {
+		t.Fatal("expected a user of another provider not to be found")
+	}
+	if err := m.DeleteAcceptedUser(ctx, &userpb.UserId{OpaqueId: "marie"}); err != nil {
+		t.Fatal(err)
+	}
+	if users, _ := m.FindAcceptedUsers(ctx, ""); len(users) != 0 {
+		t.Fatalf("expected no accepted users left, got %v", users)
+	}
+}
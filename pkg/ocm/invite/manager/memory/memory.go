@@ -23,6 +23,7 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -35,7 +36,9 @@ import (
 	"github.com/cs3org/reva/pkg/ocm/invite"
 	"github.com/cs3org/reva/pkg/ocm/invite/manager/registry"
 	"github.com/cs3org/reva/pkg/ocm/invite/token"
+	"github.com/cs3org/reva/pkg/ocm/signature"
 	"github.com/cs3org/reva/pkg/rhttp"
+	"github.com/cs3org/reva/pkg/utils"
 	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
 )
@@ -77,6 +80,9 @@ type manager struct {
 	AcceptedUsers sync.Map
 	Client        *http.Client
 	Config        *config
+
+	// mu serializes the read-modify-write updates of the accepted users lists
+	mu sync.Mutex
 }
 
 type config struct {
@@ -116,11 +122,15 @@ func (m *manager) ForwardInvite(ctx context.Context, invite *invitepb.InviteToke
 	u.Path = path.Join(u.Path, acceptInviteEndpoint)
 	recipientURL := u.String()
 
-	req, err := http.NewRequest(http.MethodPost, recipientURL, strings.NewReader(requestBody.Encode()))
+	body := requestBody.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, recipientURL, strings.NewReader(body))
 	if err != nil {
 		return errors.Wrap(err, "json: error framing post request")
 	}
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; param=value")
+	if err := signature.Sign(req, []byte(body)); err != nil {
+		return errors.Wrap(err, "memory: error signing post request")
+	}
 
 	resp, err := m.Client.Do(req)
 	if err != nil {
@@ -150,9 +160,12 @@ func (m *manager) AcceptInvite(ctx context.Context, invite *invitepb.InviteToken
 		return errors.New("memory: token creator and recipient are the same")
 	}
 
-	usersList, ok := m.AcceptedUsers.Load(currUser)
-	acceptedUsers := usersList.([]*userpb.User)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	usersList, ok := m.AcceptedUsers.Load(currUser.GetOpaqueId())
 	if ok {
+		acceptedUsers := usersList.([]*userpb.User)
 		for _, acceptedUser := range acceptedUsers {
 			if acceptedUser.Id.GetOpaqueId() == remoteUser.Id.OpaqueId && acceptedUser.Id.GetIdp() == remoteUser.Id.Idp {
 				return errors.New("memory: user already added to accepted users")
@@ -201,6 +214,66 @@ func (m *manager) FindAcceptedUsers(ctx context.Context, query string) ([]*userp
 	return users, nil
 }
 
+func (m *manager) ListInviteTokens(ctx context.Context) ([]*invitepb.InviteToken, error) {
+	userID := ctxpkg.ContextMustGetUser(ctx).GetId()
+	tokens := []*invitepb.InviteToken{}
+	m.Invites.Range(func(_, value interface{}) bool {
+		if inviteToken := value.(*invitepb.InviteToken); utils.UserEqual(inviteToken.UserId, userID) {
+			tokens = append(tokens, inviteToken)
+		}
+		return true
+	})
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].Expiration.GetSeconds() < tokens[j].Expiration.GetSeconds()
+	})
+	return tokens, nil
+}
+
+func (m *manager) RevokeInviteToken(ctx context.Context, token string) error {
+	userID := ctxpkg.ContextMustGetUser(ctx).GetId()
+	value, ok := m.Invites.Load(token)
+	if !ok || !utils.UserEqual(value.(*invitepb.InviteToken).UserId, userID) {
+		return errtypes.NotFound(token)
+	}
+	m.Invites.Delete(token)
+	return nil
+}
+
+func (m *manager) DeleteExpiredInviteTokens(ctx context.Context) (int, error) {
+	userID := ctxpkg.ContextMustGetUser(ctx).GetId()
+	now := uint64(time.Now().Unix())
+	deleted := 0
+	m.Invites.Range(func(key, value interface{}) bool {
+		if inviteToken := value.(*invitepb.InviteToken); utils.UserEqual(inviteToken.UserId, userID) && now > inviteToken.Expiration.GetSeconds() {
+			m.Invites.Delete(key)
+			deleted++
+		}
+		return true
+	})
+	return deleted, nil
+}
+
+func (m *manager) DeleteAcceptedUser(ctx context.Context, remoteUserID *userpb.UserId) error {
+	currUser := ctxpkg.ContextMustGetUser(ctx).GetId().GetOpaqueId()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	usersList, ok := m.AcceptedUsers.Load(currUser)
+	if !ok {
+		return errtypes.NotFound(remoteUserID.OpaqueId)
+	}
+
+	acceptedUsers := usersList.([]*userpb.User)
+	for i, acceptedUser := range acceptedUsers {
+		if (acceptedUser.Id.GetOpaqueId() == remoteUserID.OpaqueId) && (remoteUserID.Idp == "" || acceptedUser.Id.GetIdp() == remoteUserID.Idp) {
+			remaining := append([]*userpb.User{}, acceptedUsers[:i]...)
+			m.AcceptedUsers.Store(currUser, append(remaining, acceptedUsers[i+1:]...))
+			return nil
+		}
+	}
+	return errtypes.NotFound(remoteUserID.OpaqueId)
+}
+
 func userContains(u *userpb.User, query string) bool {
 	query = strings.ToLower(query)
 	return strings.Contains(strings.ToLower(u.Username), query) || strings.Contains(strings.ToLower(u.DisplayName), query) ||
@@ -0,0 +1,154 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	ctxpkg "github.com/cs3org/reva/pkg/ctx"
+)
+
+func TestInviteLifecycle(t *testing.T) {
+	m, err := New(map[string]interface{}{"expiration": "-1h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	einstein := &userpb.User{Id: &userpb.UserId{OpaqueId: "einstein", Idp: "cernbox.cern.ch"}}
+	ctx := ctxpkg.ContextSetUser(context.Background(), einstein)
+
+	expired, err := m.GenerateToken(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	revoked, err := m.GenerateToken(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.RevokeInviteToken(ctx, revoked.Token); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.RevokeInviteToken(ctx, revoked.Token); err == nil {
+		t.Fatal("expected revoking a token twice to fail")
+	}
+
+	marie := &userpb.User{Id: &userpb.UserId{OpaqueId: "marie", Idp: "cesnet.cz"}}
+	if err := m.AcceptInvite(ctx, expired, marie); err == nil {
+		t.Fatal("expected an expired token to be refused")
+	}
+
+	tokens, err := m.ListInviteTokens(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 1 || tokens[0].Token != expired.Token {
+		t.Fatalf("expected only the expired token, got %v", tokens)
+	}
+	if n, err := m.DeleteExpiredInviteTokens(ctx); err != nil || n != 1 {
+		t.Fatalf("expected the expired token to be deleted, got %d, %v", n, err)
+	}
+	if tokens, _ := m.ListInviteTokens(ctx); len(tokens) != 0 {
+		t.Fatalf("expected no token left, got %v", tokens)
+	}
+
+	m, err = New(map[string]interface{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid, err := m.GenerateToken(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.AcceptInvite(ctx, valid, marie); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.AcceptInvite(ctx, valid, marie); err == nil {
+		t.Fatal("expected accepting the same user twice to fail")
+	}
+	if u, err := m.GetAcceptedUser(ctx, &userpb.UserId{OpaqueId: "marie"}); err != nil || u.Id.Idp != "cesnet.cz" {
+		t.Fatalf("expected marie to be an accepted user, got %v, %v", u, err)
+	}
+	if err := m.DeleteAcceptedUser(ctx, &userpb.UserId{OpaqueId: "marie", Idp: "other.org"}); err == nil {
+		t.Fatal("expected a user of another provider not to be found")
+	}
+	if err := m.DeleteAcceptedUser(ctx, &userpb.UserId{OpaqueId: "marie"}); err != nil {
+		t.Fatal(err)
+	}
+	if users, _ := m.FindAcceptedUsers(ctx, ""); len(users) != 0 {
+		t.Fatalf("expected no accepted users left, got %v", users)
+	}
+}
+
+func TestConcurrentAcceptedUsers(t *testing.T) {
+	m, err := New(map[string]interface{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	einstein := &userpb.User{Id: &userpb.UserId{OpaqueId: "einstein", Idp: "cernbox.cern.ch"}}
+	ctx := ctxpkg.ContextSetUser(context.Background(), einstein)
+	token, err := m.GenerateToken(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const n = 50
+	for i := 0; i < n; i++ {
+		u := &userpb.User{Id: &userpb.UserId{OpaqueId: fmt.Sprintf("remove-%d", i), Idp: "cesnet.cz"}}
+		if err := m.AcceptInvite(ctx, token, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			if err := m.DeleteAcceptedUser(ctx, &userpb.UserId{OpaqueId: fmt.Sprintf("remove-%d", i)}); err != nil {
+				t.Error(err)
+			}
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			u := &userpb.User{Id: &userpb.UserId{OpaqueId: fmt.Sprintf("keep-%d", i), Idp: "cesnet.cz"}}
+			if err := m.AcceptInvite(ctx, token, u); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	users, err := m.FindAcceptedUsers(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != n {
+		t.Fatalf("expected %d accepted users, got %d", n, len(users))
+	}
+	for _, u := range users {
+		if !strings.HasPrefix(u.Id.OpaqueId, "keep-") {
+			t.Fatalf("unexpected accepted user %s", u.Id.OpaqueId)
+		}
+	}
+}
@@ -0,0 +1,421 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package sql implements an OCM invite manager storing the invite tokens
+// and the accepted users in a MySQL or SQLite database.
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	invitepb "github.com/cs3org/go-cs3apis/cs3/ocm/invite/v1beta1"
+	ocmprovider "github.com/cs3org/go-cs3apis/cs3/ocm/provider/v1beta1"
+	typespb "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	ctxpkg "github.com/cs3org/reva/pkg/ctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/health"
+	"github.com/cs3org/reva/pkg/ocm/invite"
+	"github.com/cs3org/reva/pkg/ocm/invite/manager/registry"
+	"github.com/cs3org/reva/pkg/ocm/invite/token"
+	"github.com/cs3org/reva/pkg/ocm/signature"
+	"github.com/cs3org/reva/pkg/rhttp"
+	"github.com/cs3org/reva/pkg/utils/sqlmigrate"
+
+	// Provides mysql drivers.
+	_ "github.com/go-sql-driver/mysql"
+	// Provides sqlite drivers.
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+const acceptInviteEndpoint = "invites/accept"
+
+const tokenColumns = "token, initiator_idp, initiator_opaque_id, initiator_type, expiration, description"
+
+func init() {
+	registry.Register("sql", New)
+}
+
+var migrations = []sqlmigrate.Migration{
+	{
+		`CREATE TABLE ocm_invite_tokens (
+			token VARCHAR(255) NOT NULL PRIMARY KEY,
+			initiator_idp VARCHAR(255) NOT NULL,
+			initiator_opaque_id VARCHAR(255) NOT NULL,
+			initiator_type INTEGER NOT NULL,
+			expiration BIGINT NOT NULL,
+			description TEXT NOT NULL
+		)`,
+		`CREATE TABLE ocm_remote_users (
+			initiator VARCHAR(255) NOT NULL,
+			opaque_id VARCHAR(255) NOT NULL,
+			idp VARCHAR(255) NOT NULL,
+			user_type INTEGER NOT NULL,
+			email VARCHAR(255) NOT NULL,
+			display_name VARCHAR(255) NOT NULL,
+			username VARCHAR(255) NOT NULL,
+			PRIMARY KEY (initiator, opaque_id, idp)
+		)`,
+	},
+}
+
+type config struct {
+	DBEngine            string `mapstructure:"db_engine" docs:"mysql;The database engine, either mysql or sqlite3."`
+	DBUsername          string `mapstructure:"db_username"`
+	DBPassword          string `mapstructure:"db_password"`
+	DBHost              string `mapstructure:"db_host"`
+	DBPort              int    `mapstructure:"db_port"`
+	DBName              string `mapstructure:"db_name" docs:";The name of the database, or the path of the database file for sqlite3."`
+	Expiration          string `mapstructure:"expiration"`
+	InsecureConnections bool   `mapstructure:"insecure_connections"`
+}
+
+func (c *config) init() {
+	if c.DBEngine == "" {
+		c.DBEngine = "mysql"
+	}
+	if c.Expiration == "" {
+		c.Expiration = token.DefaultExpirationTime
+	}
+}
+
+type manager struct {
+	config *config
+	db     *sql.DB
+	client *http.Client
+}
+
+func parseConfig(m map[string]interface{}) (*config, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// New returns a new invite manager connecting to the configured database.
+func New(m map[string]interface{}) (invite.Manager, error) {
+	c, err := parseConfig(m)
+	if err != nil {
+		err = errors.Wrap(err, "error parsing config for sql invite manager")
+		return nil, err
+	}
+	c.init()
+
+	var db *sql.DB
+	switch c.DBEngine {
+	case "mysql":
+		db, err = sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s:%d)/%s", c.DBUsername, c.DBPassword, c.DBHost, c.DBPort, c.DBName))
+	case "sqlite3":
+		db, err = sql.Open("sqlite3", c.DBName)
+	default:
+		return nil, errtypes.NotSupported("sql: unsupported database engine " + c.DBEngine)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "sql: error opening database")
+	}
+
+	health.Register(fmt.Sprintf("ocminvite:sql:%s:%s:%d/%s", c.DBEngine, c.DBHost, c.DBPort, c.DBName), health.SQL(db))
+
+	return NewWithDB(db, c.Expiration, c.InsecureConnections)
+}
+
+// NewWithDB returns a new invite manager using the given database,
+// whose schema is migrated to the latest version.
+func NewWithDB(db *sql.DB, expiration string, insecure bool) (invite.Manager, error) {
+	if err := sqlmigrate.Migrate(db, "ocm_invites", migrations); err != nil {
+		return nil, err
+	}
+	if expiration == "" {
+		expiration = token.DefaultExpirationTime
+	}
+	return &manager{
+		config: &config{Expiration: expiration, InsecureConnections: insecure},
+		db:     db,
+		client: rhttp.GetHTTPClient(
+			rhttp.Timeout(5*time.Second),
+			rhttp.Insecure(insecure),
+		),
+	}, nil
+}
+
+func (m *manager) GenerateToken(ctx context.Context) (*invitepb.InviteToken, error) {
+	contexUser := ctxpkg.ContextMustGetUser(ctx)
+	inviteToken, err := token.CreateToken(m.config.Expiration, contexUser.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	query := "INSERT INTO ocm_invite_tokens (" + tokenColumns + ") VALUES (?, ?, ?, ?, ?, ?)"
+	userID := inviteToken.GetUserId()
+	if _, err := m.db.ExecContext(ctx, query, inviteToken.Token, userID.GetIdp(), userID.GetOpaqueId(), int32(userID.GetType()),
+		int64(inviteToken.Expiration.GetSeconds()), inviteToken.Description); err != nil {
+		return nil, errors.Wrap(err, "sql: error storing token")
+	}
+	return inviteToken, nil
+}
+
+func (m *manager) ForwardInvite(ctx context.Context, invite *invitepb.InviteToken, originProvider *ocmprovider.ProviderInfo) error {
+	contextUser := ctxpkg.ContextMustGetUser(ctx)
+	recipientProvider := contextUser.GetId().GetIdp()
+
+	requestBody := url.Values{
+		"token":             {invite.GetToken()},
+		"userID":            {contextUser.GetId().GetOpaqueId()},
+		"recipientProvider": {recipientProvider},
+		"email":             {contextUser.GetMail()},
+		"name":              {contextUser.GetDisplayName()},
+	}
+
+	ocmEndpoint, err := getOCMEndpoint(originProvider)
+	if err != nil {
+		return err
+	}
+	u, err := url.Parse(ocmEndpoint)
+	if err != nil {
+		return err
+	}
+	u.Path = path.Join(u.Path, acceptInviteEndpoint)
+	recipientURL := u.String()
+
+	body := requestBody.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, recipientURL, strings.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "sql: error framing post request")
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; param=value")
+	if err := signature.Sign(req, []byte(body)); err != nil {
+		return errors.Wrap(err, "sql: error signing post request")
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		err = errors.Wrap(err, "sql: error sending post request")
+		return err
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		respBody, e := io.ReadAll(resp.Body)
+		if e != nil {
+			return errors.Wrap(e, "sql: error reading request body")
+		}
+		return errors.Wrap(fmt.Errorf("%s: %s", resp.Status, string(respBody)), "sql: error sending accept post request")
+	}
+
+	return nil
+}
+
+func (m *manager) AcceptInvite(ctx context.Context, invite *invitepb.InviteToken, remoteUser *userpb.User) error {
+	inviteToken, err := m.getTokenIfValid(ctx, invite)
+	if err != nil {
+		return err
+	}
+
+	currUser := inviteToken.GetUserId()
+
+	// do not allow the user who created the token to accept it
+	if remoteUser.Id.Idp == currUser.Idp && remoteUser.Id.OpaqueId == currUser.OpaqueId {
+		return errors.New("sql: token creator and recipient are the same")
+	}
+
+	var n int
+	query := "SELECT COUNT(*) FROM ocm_remote_users WHERE initiator=? AND opaque_id=? AND idp=?"
+	if err := m.db.QueryRowContext(ctx, query, currUser.GetOpaqueId(), remoteUser.Id.OpaqueId, remoteUser.Id.Idp).Scan(&n); err != nil {
+		return err
+	}
+	if n > 0 {
+		return errors.New("sql: user already added to accepted users")
+	}
+
+	query = "INSERT INTO ocm_remote_users (initiator, opaque_id, idp, user_type, email, display_name, username) VALUES (?, ?, ?, ?, ?, ?, ?)"
+	if _, err := m.db.ExecContext(ctx, query, currUser.GetOpaqueId(), remoteUser.Id.OpaqueId, remoteUser.Id.Idp, int32(remoteUser.Id.Type),
+		remoteUser.Mail, remoteUser.DisplayName, remoteUser.Username); err != nil {
+		return errors.Wrap(err, "sql: error storing accepted user")
+	}
+	return nil
+}
+
+func (m *manager) GetAcceptedUser(ctx context.Context, remoteUserID *userpb.UserId) (*userpb.User, error) {
+	userKey := ctxpkg.ContextMustGetUser(ctx).GetId().GetOpaqueId()
+	query := "SELECT opaque_id, idp, user_type, email, display_name, username FROM ocm_remote_users WHERE initiator=? AND opaque_id=?"
+	params := []interface{}{userKey, remoteUserID.OpaqueId}
+	if remoteUserID.Idp != "" {
+		query += " AND idp=?"
+		params = append(params, remoteUserID.Idp)
+	}
+
+	u, err := scanUser(m.db.QueryRowContext(ctx, query, params...))
+	if err == sql.ErrNoRows {
+		return nil, errtypes.NotFound(remoteUserID.OpaqueId)
+	}
+	return u, err
+}
+
+func (m *manager) FindAcceptedUsers(ctx context.Context, query string) ([]*userpb.User, error) {
+	userKey := ctxpkg.ContextMustGetUser(ctx).GetId().GetOpaqueId()
+	sqlQuery := "SELECT opaque_id, idp, user_type, email, display_name, username FROM ocm_remote_users WHERE initiator=?"
+	params := []interface{}{userKey}
+	if query != "" {
+		sqlQuery += " AND (LOWER(username) LIKE ? OR LOWER(display_name) LIKE ? OR LOWER(email) LIKE ? OR LOWER(opaque_id) LIKE ?)"
+		like := "%" + strings.ToLower(query) + "%"
+		params = append(params, like, like, like, like)
+	}
+
+	rows, err := m.db.QueryContext(ctx, sqlQuery, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []*userpb.User{}
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+func (m *manager) ListInviteTokens(ctx context.Context) ([]*invitepb.InviteToken, error) {
+	userID := ctxpkg.ContextMustGetUser(ctx).GetId()
+	query := "SELECT " + tokenColumns + " FROM ocm_invite_tokens WHERE initiator_idp=? AND initiator_opaque_id=? ORDER BY expiration"
+	rows, err := m.db.QueryContext(ctx, query, userID.GetIdp(), userID.GetOpaqueId())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []*invitepb.InviteToken{}
+	for rows.Next() {
+		inviteToken, err := scanToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, inviteToken)
+	}
+	return tokens, rows.Err()
+}
+
+func (m *manager) RevokeInviteToken(ctx context.Context, token string) error {
+	userID := ctxpkg.ContextMustGetUser(ctx).GetId()
+	query := "DELETE FROM ocm_invite_tokens WHERE token=? AND initiator_idp=? AND initiator_opaque_id=?"
+	res, err := m.db.ExecContext(ctx, query, token, userID.GetIdp(), userID.GetOpaqueId())
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return errtypes.NotFound(token)
+	}
+	return nil
+}
+
+func (m *manager) DeleteExpiredInviteTokens(ctx context.Context) (int, error) {
+	userID := ctxpkg.ContextMustGetUser(ctx).GetId()
+	query := "DELETE FROM ocm_invite_tokens WHERE initiator_idp=? AND initiator_opaque_id=? AND expiration<?"
+	res, err := m.db.ExecContext(ctx, query, userID.GetIdp(), userID.GetOpaqueId(), time.Now().Unix())
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+func (m *manager) DeleteAcceptedUser(ctx context.Context, remoteUserID *userpb.UserId) error {
+	userKey := ctxpkg.ContextMustGetUser(ctx).GetId().GetOpaqueId()
+	query := "DELETE FROM ocm_remote_users WHERE initiator=? AND opaque_id=?"
+	params := []interface{}{userKey, remoteUserID.OpaqueId}
+	if remoteUserID.Idp != "" {
+		query += " AND idp=?"
+		params = append(params, remoteUserID.Idp)
+	}
+	res, err := m.db.ExecContext(ctx, query, params...)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return errtypes.NotFound(remoteUserID.OpaqueId)
+	}
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanUser(row scanner) (*userpb.User, error) {
+	var userType int32
+	u := &userpb.User{Id: &userpb.UserId{}}
+	if err := row.Scan(&u.Id.OpaqueId, &u.Id.Idp, &userType, &u.Mail, &u.DisplayName, &u.Username); err != nil {
+		return nil, err
+	}
+	u.Id.Type = userpb.UserType(userType)
+	return u, nil
+}
+
+func scanToken(row scanner) (*invitepb.InviteToken, error) {
+	var (
+		inviteToken = &invitepb.InviteToken{UserId: &userpb.UserId{}}
+		userType    int32
+		expiration  int64
+	)
+	if err := row.Scan(&inviteToken.Token, &inviteToken.UserId.Idp, &inviteToken.UserId.OpaqueId,
+		&userType, &expiration, &inviteToken.Description); err != nil {
+		return nil, err
+	}
+	inviteToken.UserId.Type = userpb.UserType(userType)
+	inviteToken.Expiration = &typespb.Timestamp{Seconds: uint64(expiration)}
+	return inviteToken, nil
+}
+
+func (m *manager) getTokenIfValid(ctx context.Context, t *invitepb.InviteToken) (*invitepb.InviteToken, error) {
+	query := "SELECT " + tokenColumns + " FROM ocm_invite_tokens WHERE token=?"
+	inviteToken, err := scanToken(m.db.QueryRowContext(ctx, query, t.GetToken()))
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, errors.New("sql: invalid token")
+	case err != nil:
+		return nil, err
+	}
+
+	if uint64(time.Now().Unix()) > inviteToken.Expiration.Seconds {
+		return nil, errors.New("sql: token expired")
+	}
+	return inviteToken, nil
+}
+
+func getOCMEndpoint(originProvider *ocmprovider.ProviderInfo) (string, error) {
+	for _, s := range originProvider.Services {
+		if s.Endpoint.Type.Name == "OCM" {
+			return s.Endpoint.Path, nil
+		}
+	}
+	return "", errors.New("sql: ocm endpoint not specified for mesh provider")
+}
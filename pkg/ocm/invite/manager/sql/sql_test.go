@@ -0,0 +1,174 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	invitepb "github.com/cs3org/go-cs3apis/cs3/ocm/invite/v1beta1"
+	ctxpkg "github.com/cs3org/reva/pkg/ctx"
+	"github.com/cs3org/reva/pkg/ocm/invite"
+)
+
+func newManager(t *testing.T, expiration string) invite.Manager {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "invites.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	m, err := NewWithDB(db, expiration, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestInvites(t *testing.T) {
+	m := newManager(t, "")
+	einstein := &userpb.User{Id: &userpb.UserId{OpaqueId: "einstein", Idp: "cernbox.cern.ch"}}
+	ctx := ctxpkg.ContextSetUser(context.Background(), einstein)
+
+	token, err := m.GenerateToken(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	marie := &userpb.User{
+		Id:          &userpb.UserId{OpaqueId: "marie", Idp: "cesnet.cz", Type: userpb.UserType_USER_TYPE_PRIMARY},
+		Username:    "marie",
+		Mail:        "marie@cesnet.cz",
+		DisplayName: "Marie Curie",
+	}
+	if err := m.AcceptInvite(ctx, &invitepb.InviteToken{Token: "unknown"}, marie); err == nil {
+		t.Fatal("expected an unknown token to be refused")
+	}
+	if err := m.AcceptInvite(ctx, token, einstein); err == nil {
+		t.Fatal("expected the creator of the token not to be able to accept it")
+	}
+	if err := m.AcceptInvite(ctx, token, marie); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.AcceptInvite(ctx, token, marie); err == nil {
+		t.Fatal("expected accepting an invite twice to fail")
+	}
+
+	u, err := m.GetAcceptedUser(ctx, &userpb.UserId{OpaqueId: "marie"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.DisplayName != "Marie Curie" || u.Id.Idp != "cesnet.cz" || u.Id.Type != userpb.UserType_USER_TYPE_PRIMARY {
+		t.Fatalf("unexpected accepted user %+v", u)
+	}
+	if _, err := m.GetAcceptedUser(ctx, &userpb.UserId{OpaqueId: "marie", Idp: "other.org"}); err == nil {
+		t.Fatal("expected a user of another provider not to be found")
+	}
+
+	for query, expected := range map[string]int{"": 1, "CURIE": 1, "cesnet.cz": 1, "einstein": 0} {
+		users, err := m.FindAcceptedUsers(ctx, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(users) != expected {
+			t.Fatalf("query %q: expected %d users, got %d", query, expected, len(users))
+		}
+	}
+
+	// accepted users are scoped to the user who created the invite
+	other := ctxpkg.ContextSetUser(context.Background(), &userpb.User{Id: &userpb.UserId{OpaqueId: "richard", Idp: "cernbox.cern.ch"}})
+	if users, _ := m.FindAcceptedUsers(other, ""); len(users) != 0 {
+		t.Fatal("expected no accepted users for another user")
+	}
+}
+
+func TestExpiredToken(t *testing.T) {
+	m := newManager(t, "-1h")
+	ctx := ctxpkg.ContextSetUser(context.Background(), &userpb.User{Id: &userpb.UserId{OpaqueId: "einstein", Idp: "cernbox.cern.ch"}})
+
+	token, err := m.GenerateToken(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = m.AcceptInvite(ctx, token, &userpb.User{Id: &userpb.UserId{OpaqueId: "marie", Idp: "cesnet.cz"}})
+	if err == nil || err.Error() != "sql: token expired" {
+		t.Fatalf("expected the token to be expired, got %v", err)
+	}
+
+	if n, err := m.DeleteExpiredInviteTokens(ctx); err != nil || n != 1 {
+		t.Fatalf("expected the expired token to be deleted, got %d, %v", n, err)
+	}
+	if tokens, _ := m.ListInviteTokens(ctx); len(tokens) != 0 {
+		t.Fatalf("expected no token left, got %v", tokens)
+	}
+}
+
+func TestInviteLifecycle(t *testing.T) {
+	m := newManager(t, "")
+	einstein := &userpb.User{Id: &userpb.UserId{OpaqueId: "einstein", Idp: "cernbox.cern.ch"}}
+	ctx := ctxpkg.ContextSetUser(context.Background(), einstein)
+	other := ctxpkg.ContextSetUser(context.Background(), &userpb.User{Id: &userpb.UserId{OpaqueId: "richard", Idp: "cernbox.cern.ch"}})
+
+	revoked, err := m.GenerateToken(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept, err := m.GenerateToken(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.RevokeInviteToken(other, revoked.Token); err == nil {
+		t.Fatal("expected the token not to be revocable by another user")
+	}
+	if err := m.RevokeInviteToken(ctx, revoked.Token); err != nil {
+		t.Fatal(err)
+	}
+	marie := &userpb.User{Id: &userpb.UserId{OpaqueId: "marie", Idp: "cesnet.cz"}}
+	if err := m.AcceptInvite(ctx, revoked, marie); err == nil {
+		t.Fatal("expected a revoked token to be refused")
+	}
+
+	tokens, err := m.ListInviteTokens(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 1 || tokens[0].Token != kept.Token {
+		t.Fatalf("expected only the kept token, got %v", tokens)
+	}
+	if n, err := m.DeleteExpiredInviteTokens(ctx); err != nil || n != 0 {
+		t.Fatalf("expected no expired token to be deleted, got %d, %v", n, err)
+	}
+
+	if err := m.AcceptInvite(ctx, kept, marie); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.DeleteAcceptedUser(other, marie.Id); err == nil {
+		t.Fatal("expected the accepted user not to be found for another user")
+	}
+	if err := m.DeleteAcceptedUser(ctx, marie.Id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.GetAcceptedUser(ctx, marie.Id); err == nil {
+		t.Fatal("expected the accepted user to be deleted")
+	}
+}
@@ -0,0 +1,237 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             (unknown)
+// source: invite.proto
+
+package proto
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// InviteLifecycleServiceClient is the client API for InviteLifecycleService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type InviteLifecycleServiceClient interface {
+	// Lists the invite tokens generated by the user, including the expired ones.
+	ListInviteTokens(ctx context.Context, in *ListInviteTokensRequest, opts ...grpc.CallOption) (*ListInviteTokensResponse, error)
+	// Revokes an invite token of the user, which cannot be accepted anymore.
+	RevokeInviteToken(ctx context.Context, in *RevokeInviteTokenRequest, opts ...grpc.CallOption) (*RevokeInviteTokenResponse, error)
+	// Deletes all the expired invite tokens of the user.
+	DeleteExpiredInviteTokens(ctx context.Context, in *DeleteExpiredInviteTokensRequest, opts ...grpc.CallOption) (*DeleteExpiredInviteTokensResponse, error)
+	// Removes a remote user from the users who accepted an invite of the user.
+	DeleteAcceptedUser(ctx context.Context, in *DeleteAcceptedUserRequest, opts ...grpc.CallOption) (*DeleteAcceptedUserResponse, error)
+}
+
+type inviteLifecycleServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewInviteLifecycleServiceClient(cc grpc.ClientConnInterface) InviteLifecycleServiceClient {
+	return &inviteLifecycleServiceClient{cc}
+}
+
+func (c *inviteLifecycleServiceClient) ListInviteTokens(ctx context.Context, in *ListInviteTokensRequest, opts ...grpc.CallOption) (*ListInviteTokensResponse, error) {
+	out := new(ListInviteTokensResponse)
+	err := c.cc.Invoke(ctx, "/revad.ocm.invite.InviteLifecycleService/ListInviteTokens", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *inviteLifecycleServiceClient) RevokeInviteToken(ctx context.Context, in *RevokeInviteTokenRequest, opts ...grpc.CallOption) (*RevokeInviteTokenResponse, error) {
+	out := new(RevokeInviteTokenResponse)
+	err := c.cc.Invoke(ctx, "/revad.ocm.invite.InviteLifecycleService/RevokeInviteToken", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *inviteLifecycleServiceClient) DeleteExpiredInviteTokens(ctx context.Context, in *DeleteExpiredInviteTokensRequest, opts ...grpc.CallOption) (*DeleteExpiredInviteTokensResponse, error) {
+	out := new(DeleteExpiredInviteTokensResponse)
+	err := c.cc.Invoke(ctx, "/revad.ocm.invite.InviteLifecycleService/DeleteExpiredInviteTokens", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *inviteLifecycleServiceClient) DeleteAcceptedUser(ctx context.Context, in *DeleteAcceptedUserRequest, opts ...grpc.CallOption) (*DeleteAcceptedUserResponse, error) {
+	out := new(DeleteAcceptedUserResponse)
+	err := c.cc.Invoke(ctx, "/revad.ocm.invite.InviteLifecycleService/DeleteAcceptedUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// InviteLifecycleServiceServer is the server API for InviteLifecycleService service.
+// All implementations should embed UnimplementedInviteLifecycleServiceServer
+// for forward compatibility
+type InviteLifecycleServiceServer interface {
+	// Lists the invite tokens generated by the user, including the expired ones.
+	ListInviteTokens(context.Context, *ListInviteTokensRequest) (*ListInviteTokensResponse, error)
+	// Revokes an invite token of the user, which cannot be accepted anymore.
+	RevokeInviteToken(context.Context, *RevokeInviteTokenRequest) (*RevokeInviteTokenResponse, error)
+	// Deletes all the expired invite tokens of the user.
+	DeleteExpiredInviteTokens(context.Context, *DeleteExpiredInviteTokensRequest) (*DeleteExpiredInviteTokensResponse, error)
+	// Removes a remote user from the users who accepted an invite of the user.
+	DeleteAcceptedUser(context.Context, *DeleteAcceptedUserRequest) (*DeleteAcceptedUserResponse, error)
+}
+
+// UnimplementedInviteLifecycleServiceServer should be embedded to have forward compatible implementations.
+type UnimplementedInviteLifecycleServiceServer struct {
+}
+
+func (UnimplementedInviteLifecycleServiceServer) ListInviteTokens(context.Context, *ListInviteTokensRequest) (*ListInviteTokensResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListInviteTokens not implemented")
+}
+func (UnimplementedInviteLifecycleServiceServer) RevokeInviteToken(context.Context, *RevokeInviteTokenRequest) (*RevokeInviteTokenResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeInviteToken not implemented")
+}
+func (UnimplementedInviteLifecycleServiceServer) DeleteExpiredInviteTokens(context.Context, *DeleteExpiredInviteTokensRequest) (*DeleteExpiredInviteTokensResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteExpiredInviteTokens not implemented")
+}
+func (UnimplementedInviteLifecycleServiceServer) DeleteAcceptedUser(context.Context, *DeleteAcceptedUserRequest) (*DeleteAcceptedUserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteAcceptedUser not implemented")
+}
+
+// UnsafeInviteLifecycleServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to InviteLifecycleServiceServer will
+// result in compilation errors.
+type UnsafeInviteLifecycleServiceServer interface {
+	mustEmbedUnimplementedInviteLifecycleServiceServer()
+}
+
+func RegisterInviteLifecycleServiceServer(s grpc.ServiceRegistrar, srv InviteLifecycleServiceServer) {
+	s.RegisterService(&InviteLifecycleService_ServiceDesc, srv)
+}
+
+func _InviteLifecycleService_ListInviteTokens_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListInviteTokensRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InviteLifecycleServiceServer).ListInviteTokens(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/revad.ocm.invite.InviteLifecycleService/ListInviteTokens",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InviteLifecycleServiceServer).ListInviteTokens(ctx, req.(*ListInviteTokensRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InviteLifecycleService_RevokeInviteToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeInviteTokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InviteLifecycleServiceServer).RevokeInviteToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/revad.ocm.invite.InviteLifecycleService/RevokeInviteToken",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InviteLifecycleServiceServer).RevokeInviteToken(ctx, req.(*RevokeInviteTokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InviteLifecycleService_DeleteExpiredInviteTokens_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteExpiredInviteTokensRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InviteLifecycleServiceServer).DeleteExpiredInviteTokens(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/revad.ocm.invite.InviteLifecycleService/DeleteExpiredInviteTokens",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InviteLifecycleServiceServer).DeleteExpiredInviteTokens(ctx, req.(*DeleteExpiredInviteTokensRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InviteLifecycleService_DeleteAcceptedUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteAcceptedUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InviteLifecycleServiceServer).DeleteAcceptedUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/revad.ocm.invite.InviteLifecycleService/DeleteAcceptedUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InviteLifecycleServiceServer).DeleteAcceptedUser(ctx, req.(*DeleteAcceptedUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// InviteLifecycleService_ServiceDesc is the grpc.ServiceDesc for InviteLifecycleService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var InviteLifecycleService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "revad.ocm.invite.InviteLifecycleService",
+	HandlerType: (*InviteLifecycleServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListInviteTokens",
+			Handler:    _InviteLifecycleService_ListInviteTokens_Handler,
+		},
+		{
+			MethodName: "RevokeInviteToken",
+			Handler:    _InviteLifecycleService_RevokeInviteToken_Handler,
+		},
+		{
+			MethodName: "DeleteExpiredInviteTokens",
+			Handler:    _InviteLifecycleService_DeleteExpiredInviteTokens_Handler,
+		},
+		{
+			MethodName: "DeleteAcceptedUser",
+			Handler:    _InviteLifecycleService_DeleteAcceptedUser_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "invite.proto",
+}
@@ -0,0 +1,510 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package cached implements a provider authorizer keeping a local copy of
+// the providers of the mesh, periodically synced from Mentix or an OCM directory.
+package cached
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	ocmprovider "github.com/cs3org/go-cs3apis/cs3/ocm/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/ocm/provider"
+	"github.com/cs3org/reva/pkg/ocm/provider/authorizer/registry"
+	"github.com/cs3org/reva/pkg/ocm/signature"
+	"github.com/cs3org/reva/pkg/rhttp"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+func init() {
+	registry.Register("cached", New)
+}
+
+type config struct {
+	Source                string   `mapstructure:"source" docs:"mentix;Where the providers are synced from, either mentix or directory."`
+	URL                   string   `mapstructure:"url" docs:"http://localhost:9600/mentix/cs3;The URL of the Mentix CS3 connector or of the OCM directory."`
+	CacheFile             string   `mapstructure:"cache_file" docs:"/var/tmp/reva/ocm-providers.json;The file keeping the last synced providers, used when the source is unreachable."`
+	RefreshInterval       int64    `mapstructure:"refresh" docs:"300;The interval in seconds between two syncs."`
+	Timeout               int64    `mapstructure:"timeout" docs:"10;The timeout in seconds of the requests to the source and to the providers."`
+	Insecure              bool     `mapstructure:"insecure" docs:"false;Whether to skip certificate checks when syncing from the source."`
+	VerifyTLS             bool     `mapstructure:"verify_tls" docs:"false;Whether to leave out the providers whose OCM endpoint does not present a valid certificate."`
+	VerifyPublicKeys      bool     `mapstructure:"verify_public_keys" docs:"false;Whether to leave out the providers not publishing a valid public key in their OCM discovery document."`
+	VerifyRequestHostname bool     `mapstructure:"verify_request_hostname" docs:"false;Whether to check that the requests of a provider come from the host of its OCM endpoint."`
+	Allow                 []string `mapstructure:"allow" docs:";The domains always allowed, even if not part of the mesh."`
+	Deny                  []string `mapstructure:"deny" docs:";The domains never allowed, even if part of the mesh."`
+}
+
+func (c *config) init() {
+	if c.Source == "" {
+		c.Source = "mentix"
+	}
+	if c.URL == "" {
+		c.URL = "http://localhost:9600/mentix/cs3"
+	}
+	if c.CacheFile == "" {
+		c.CacheFile = "/var/tmp/reva/ocm-providers.json"
+	}
+	if c.RefreshInterval == 0 {
+		c.RefreshInterval = 300
+	}
+	if c.Timeout == 0 {
+		c.Timeout = 10
+	}
+}
+
+type authorizer struct {
+	conf   *config
+	client *http.Client
+
+	mu          sync.RWMutex
+	providers   []*ocmprovider.ProviderInfo
+	providerIPs sync.Map
+
+	done chan struct{}
+}
+
+// New returns a new authorizer object. It starts with the providers of the
+// snapshot, if any, and syncs them in the background.
+func New(m map[string]interface{}) (provider.Authorizer, error) {
+	a, err := newAuthorizer(m)
+	if err != nil {
+		return nil, err
+	}
+	go a.syncPeriodically()
+	return a, nil
+}
+
+func newAuthorizer(m map[string]interface{}) (*authorizer, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		err = errors.Wrap(err, "error decoding conf")
+		return nil, err
+	}
+	c.init()
+
+	if c.Source != "mentix" && c.Source != "directory" {
+		return nil, errtypes.NotSupported("cached: unsupported provider source " + c.Source)
+	}
+
+	a := &authorizer{
+		conf: c,
+		client: rhttp.GetHTTPClient(
+			rhttp.Context(context.Background()),
+			rhttp.Timeout(time.Duration(c.Timeout*int64(time.Second))),
+			rhttp.Insecure(c.Insecure),
+		),
+		done: make(chan struct{}),
+	}
+
+	if err := a.loadSnapshot(); err != nil {
+		log.Warn().Err(err).Msg("cached: error loading the providers snapshot")
+	}
+	return a, nil
+}
+
+// Close stops syncing the providers.
+func (a *authorizer) Close() error {
+	close(a.done)
+	return nil
+}
+
+func (a *authorizer) syncPeriodically() {
+	ticker := time.NewTicker(time.Duration(a.conf.RefreshInterval) * time.Second)
+	defer ticker.Stop()
+	for {
+		if err := a.sync(); err != nil {
+			// keep on using the known providers, the next sync may succeed
+			log.Warn().Err(err).Msg("cached: error syncing the providers")
+		}
+		select {
+		case <-a.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sync fetches the providers from the source and, if successful,
+// replaces the known providers and the snapshot on disk.
+// The providers failing verification keep their previous entry, if any,
+// so that an outage of their endpoints does not shrink the mesh.
+func (a *authorizer) sync() error {
+	var providers []*ocmprovider.ProviderInfo
+	var err error
+	switch a.conf.Source {
+	case "mentix":
+		providers, err = a.fetchFromMentix()
+	case "directory":
+		providers, err = a.fetchFromDirectory()
+	}
+	if err != nil {
+		return err
+	}
+
+	a.mu.RLock()
+	previous := a.providers
+	a.mu.RUnlock()
+
+	verified := make([]*ocmprovider.ProviderInfo, 0, len(providers))
+	for _, p := range providers {
+		if err := a.verify(p); err != nil {
+			if prev := findProvider(previous, normalizeDomain(p.Domain)); prev != nil {
+				log.Warn().Err(err).Str("domain", p.Domain).Msg("cached: error verifying provider, keeping its previous entry")
+				verified = append(verified, prev)
+			} else {
+				log.Warn().Err(err).Str("domain", p.Domain).Msg("cached: leaving out provider")
+			}
+			continue
+		}
+		verified = append(verified, p)
+	}
+	a.mu.Lock()
+	a.providers = verified
+	a.mu.Unlock()
+	a.providerIPs.Range(func(host, _ interface{}) bool {
+		a.providerIPs.Delete(host)
+		return true
+	})
+
+	return a.saveSnapshot(verified)
+}
+
+func (a *authorizer) get(u string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json; charset=utf-8")
+
+	res, err := a.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "cached: error fetching "+u)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return errors.New(fmt.Sprintf("cached: error fetching %s: %s", u, res.Status))
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+func (a *authorizer) fetchFromMentix() ([]*ocmprovider.ProviderInfo, error) {
+	providers := []*ocmprovider.ProviderInfo{}
+	if err := a.get(a.conf.URL, &providers); err != nil {
+		return nil, err
+	}
+
+	var po []*ocmprovider.ProviderInfo
+	for _, p := range providers {
+		if getOCMEndpoint(p) != "" {
+			po = append(po, p)
+		}
+	}
+	return po, nil
+}
+
+// directory is the list of servers published by an OCM directory.
+type directory struct {
+	Federation string `json:"federation"`
+	Servers    []struct {
+		URL         string `json:"url"`
+		DisplayName string `json:"displayName"`
+	} `json:"servers"`
+}
+
+// discovery is the OCM discovery document of a provider.
+type discovery struct {
+	Enabled   bool                 `json:"enabled"`
+	Endpoint  string               `json:"endPoint"`
+	Provider  string               `json:"provider"`
+	PublicKey *signature.PublicKey `json:"publicKey"`
+}
+
+func (a *authorizer) fetchFromDirectory() ([]*ocmprovider.ProviderInfo, error) {
+	var d directory
+	if err := a.get(a.conf.URL, &d); err != nil {
+		return nil, err
+	}
+
+	var providers []*ocmprovider.ProviderInfo
+	for _, s := range d.Servers {
+		u, err := url.Parse(s.URL)
+		if err != nil {
+			log.Warn().Err(err).Str("url", s.URL).Msg("cached: invalid server URL in directory")
+			continue
+		}
+		disc, err := a.discover(strings.TrimSuffix(s.URL, "/"))
+		if err != nil {
+			log.Warn().Err(err).Str("url", s.URL).Msg("cached: error discovering server")
+			continue
+		}
+		if !disc.Enabled || disc.Endpoint == "" {
+			continue
+		}
+
+		name := s.DisplayName
+		if name == "" {
+			name = disc.Provider
+		}
+		providers = append(providers, &ocmprovider.ProviderInfo{
+			Name:         name,
+			FullName:     name,
+			Organization: d.Federation,
+			Domain:       u.Hostname(),
+			Services: []*ocmprovider.Service{{
+				Host: disc.Endpoint,
+				Endpoint: &ocmprovider.ServiceEndpoint{
+					Type: &ocmprovider.ServiceType{Name: "OCM"},
+					Name: name + " - OCM API",
+					Path: disc.Endpoint,
+				},
+			}},
+		})
+	}
+	return providers, nil
+}
+
+// discover fetches the OCM discovery document of the server at the given URL,
+// which can be either the base URL of the server or its OCM endpoint.
+func (a *authorizer) discover(base string) (*discovery, error) {
+	var err error
+	for _, u := range []string{base + "/.well-known/ocm", base + "/ocm-provider"} {
+		var d discovery
+		if err = a.get(u, &d); err == nil {
+			return &d, nil
+		}
+	}
+	return nil, err
+}
+
+// verify checks the certificate and the public key of a provider, when configured.
+func (a *authorizer) verify(p *ocmprovider.ProviderInfo) error {
+	endpoint := getOCMEndpoint(p)
+	if a.conf.VerifyTLS {
+		if err := a.verifyTLS(endpoint); err != nil {
+			return err
+		}
+	}
+	if a.conf.VerifyPublicKeys {
+		d, err := a.discover(strings.TrimSuffix(endpoint, "/"))
+		if err != nil {
+			return err
+		}
+		if d.PublicKey == nil {
+			return errtypes.NotFound("public key of " + p.Domain)
+		}
+		if !signature.KeyBelongsTo(d.PublicKey.KeyID, p.Domain) {
+			return errtypes.PermissionDenied(fmt.Sprintf("key %s does not belong to %s", d.PublicKey.KeyID, p.Domain))
+		}
+		if _, err := signature.ParsePublicKey([]byte(d.PublicKey.PublicKeyPem)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyTLS checks that the OCM endpoint is served over TLS with a valid certificate.
+func (a *authorizer) verifyTLS(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return errors.Wrap(err, "cached: error parsing OCM endpoint")
+	}
+	if u.Scheme != "https" {
+		return errtypes.PermissionDenied("OCM endpoint not served over TLS: " + endpoint)
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "443")
+	}
+
+	cfg := &tls.Config{ServerName: u.Hostname()}
+	dialer := &net.Dialer{Timeout: time.Duration(a.conf.Timeout * int64(time.Second))}
+	conn, err := tls.DialWithDialer(dialer, "tcp", host, cfg)
+	if err != nil {
+		return errors.Wrap(err, "cached: invalid certificate for "+host)
+	}
+	return conn.Close()
+}
+
+func (a *authorizer) loadSnapshot() error {
+	data, err := os.ReadFile(a.conf.CacheFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	providers := []*ocmprovider.ProviderInfo{}
+	if err := json.Unmarshal(data, &providers); err != nil {
+		return errors.Wrap(err, "cached: error decoding snapshot")
+	}
+	a.mu.Lock()
+	a.providers = providers
+	a.mu.Unlock()
+	return nil
+}
+
+func (a *authorizer) saveSnapshot(providers []*ocmprovider.ProviderInfo) error {
+	data, err := json.Marshal(providers)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(a.conf.CacheFile), 0700); err != nil {
+		return errors.Wrap(err, "cached: error creating snapshot folder")
+	}
+	// write to a temporary file first, not to leave a truncated snapshot behind
+	tmp := a.conf.CacheFile + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return errors.Wrap(err, "cached: error writing snapshot")
+	}
+	return os.Rename(tmp, a.conf.CacheFile)
+}
+
+func normalizeDomain(d string) string {
+	if !strings.Contains(d, "://") {
+		d = "https://" + d
+	}
+	u, err := url.Parse(d)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+func contains(domains []string, domain string) bool {
+	for _, d := range domains {
+		if normalizeDomain(d) == domain {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *authorizer) find(domain string) *ocmprovider.ProviderInfo {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return findProvider(a.providers, domain)
+}
+
+func findProvider(providers []*ocmprovider.ProviderInfo, domain string) *ocmprovider.ProviderInfo {
+	for _, p := range providers {
+		if normalizeDomain(p.Domain) == domain {
+			return p
+		}
+	}
+	return nil
+}
+
+func (a *authorizer) GetInfoByDomain(ctx context.Context, domain string) (*ocmprovider.ProviderInfo, error) {
+	d := normalizeDomain(domain)
+	if contains(a.conf.Deny, d) {
+		return nil, errtypes.NotFound(domain)
+	}
+	if p := a.find(d); p != nil {
+		return p, nil
+	}
+	return nil, errtypes.NotFound(domain)
+}
+
+func (a *authorizer) IsProviderAllowed(ctx context.Context, provider *ocmprovider.ProviderInfo) error {
+	d := normalizeDomain(provider.Domain)
+	if d == "" {
+		return nil
+	}
+	switch {
+	case contains(a.conf.Deny, d):
+		return errtypes.PermissionDenied("provider denied: " + provider.Domain)
+	case contains(a.conf.Allow, d):
+		return nil
+	}
+
+	p := a.find(d)
+	switch {
+	case p == nil:
+		return errtypes.NotFound(provider.Domain)
+	case !a.conf.VerifyRequestHostname:
+		return nil
+	case len(provider.Services) == 0:
+		return errtypes.NotSupported("No IP provided")
+	}
+
+	u, err := url.Parse(getOCMEndpoint(p))
+	if err != nil || u.Hostname() == "" {
+		return errtypes.InternalError("cached: ocm host not specified for mesh provider")
+	}
+	ocmHost := u.Hostname()
+
+	var ipList []string
+	if hostIPs, ok := a.providerIPs.Load(ocmHost); ok {
+		ipList = hostIPs.([]string)
+	} else {
+		addr, err := net.LookupIP(ocmHost)
+		if err != nil {
+			return errors.Wrap(err, "cached: error looking up client IP")
+		}
+		for _, a := range addr {
+			ipList = append(ipList, a.String())
+		}
+		a.providerIPs.Store(ocmHost, ipList)
+	}
+
+	for _, ip := range ipList {
+		if ip == provider.Services[0].Host {
+			return nil
+		}
+	}
+	return errtypes.NotFound("OCM Host")
+}
+
+func (a *authorizer) ListAllProviders(ctx context.Context) ([]*ocmprovider.ProviderInfo, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	providers := make([]*ocmprovider.ProviderInfo, 0, len(a.providers))
+	for _, p := range a.providers {
+		if !contains(a.conf.Deny, normalizeDomain(p.Domain)) {
+			providers = append(providers, p)
+		}
+	}
+	return providers, nil
+}
+
+func getOCMEndpoint(p *ocmprovider.ProviderInfo) string {
+	for _, s := range p.Services {
+		if s.GetEndpoint().GetType().GetName() == "OCM" {
+			if s.Endpoint.Path != "" {
+				return s.Endpoint.Path
+			}
+			return s.Host
+		}
+	}
+	return ""
+}
@@ -0,0 +1,271 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package cached
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	ocmprovider "github.com/cs3org/go-cs3apis/cs3/ocm/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/ocm/signature"
+)
+
+func ocmProvider(domain, endpoint string) *ocmprovider.ProviderInfo {
+	return &ocmprovider.ProviderInfo{
+		Domain: domain,
+		Services: []*ocmprovider.Service{{
+			Host: endpoint,
+			Endpoint: &ocmprovider.ServiceEndpoint{
+				Type: &ocmprovider.ServiceType{Name: "OCM"},
+				Path: endpoint,
+			},
+		}},
+	}
+}
+
+// newSynced returns an authorizer synced once, which does not sync in the background.
+func newSynced(t *testing.T, conf map[string]interface{}) *authorizer {
+	a, err := newAuthorizer(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.sync(); err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func TestMentixSnapshot(t *testing.T) {
+	ctx := context.Background()
+	mentix := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]*ocmprovider.ProviderInfo{
+			ocmProvider("cernbox.cern.ch", "https://cernbox.cern.ch/ocm"),
+			ocmProvider("cesnet.cz", "https://cesnet.cz/ocm"),
+			{Domain: "no-ocm.org"},
+		})
+	}))
+	defer mentix.Close()
+
+	conf := map[string]interface{}{
+		"url":        mentix.URL,
+		"cache_file": filepath.Join(t.TempDir(), "providers.json"),
+		"deny":       []string{"cesnet.cz"},
+		"allow":      []string{"https://partner.org"},
+	}
+	a := newSynced(t, conf)
+
+	providers, err := a.ListAllProviders(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(providers) != 1 || providers[0].Domain != "cernbox.cern.ch" {
+		t.Fatalf("expected only the allowed OCM providers to be listed, got %v", providers)
+	}
+	if _, err := a.GetInfoByDomain(ctx, "cesnet.cz"); err == nil {
+		t.Fatal("expected a denied provider not to be found")
+	}
+
+	tests := []struct {
+		domain   string
+		expected error
+	}{
+		{"cernbox.cern.ch", nil},
+		{"https://cernbox.cern.ch", nil},
+		{"cesnet.cz", errtypes.PermissionDenied("provider denied: cesnet.cz")},
+		{"partner.org", nil},
+		{"unknown.org", errtypes.NotFound("unknown.org")},
+	}
+	for _, tt := range tests {
+		if err := a.IsProviderAllowed(ctx, &ocmprovider.ProviderInfo{Domain: tt.domain}); err != tt.expected {
+			t.Errorf("%s: expected %v, got %v", tt.domain, tt.expected, err)
+		}
+	}
+
+	// the snapshot is used when the source is unreachable
+	mentix.Close()
+	offline, err := New(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer offline.(*authorizer).Close()
+	if _, err := offline.GetInfoByDomain(ctx, "cernbox.cern.ch"); err != nil {
+		t.Fatalf("expected the provider to be loaded from the snapshot, got %v", err)
+	}
+}
+
+func TestDirectoryVerification(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pemKey := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	var srv *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/directory", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"federation": "Test Mesh", "servers": [{"url": "` + srv.URL + `", "displayName": "Test"}]}`))
+	})
+	mux.HandleFunc("/.well-known/ocm", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(discovery{Enabled: true, Endpoint: srv.URL + "/ocm", Provider: "test"})
+	})
+	mux.HandleFunc("/ocm/ocm-provider", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(discovery{
+			Enabled:   true,
+			Endpoint:  srv.URL + "/ocm",
+			PublicKey: &signature.PublicKey{KeyID: srv.URL + "/ocm/ocm-provider#signature", PublicKeyPem: pemKey},
+		})
+	})
+	srv = httptest.NewTLSServer(mux)
+	defer srv.Close()
+
+	a := newSynced(t, map[string]interface{}{
+		"source":     "directory",
+		"url":        srv.URL + "/directory",
+		"cache_file": filepath.Join(t.TempDir(), "providers.json"),
+		"insecure":   true,
+	})
+
+	p, err := a.GetInfoByDomain(context.Background(), "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "Test" || p.Organization != "Test Mesh" || getOCMEndpoint(p) != srv.URL+"/ocm" {
+		t.Fatalf("unexpected provider %+v", p)
+	}
+
+	// the certificate of the test server is self-signed,
+	// but the provider keeps its previous entry
+	a.conf.VerifyTLS = true
+	if err := a.sync(); err != nil {
+		t.Fatal(err)
+	}
+	if providers, _ := a.ListAllProviders(context.Background()); len(providers) != 1 {
+		t.Fatalf("expected the provider failing verification to keep its previous entry, got %v", providers)
+	}
+
+	// and is left out when it was not known
+	b := newSynced(t, map[string]interface{}{
+		"source":     "directory",
+		"url":        srv.URL + "/directory",
+		"cache_file": filepath.Join(t.TempDir(), "providers.json"),
+		"insecure":   true,
+		"verify_tls": true,
+	})
+	if providers, _ := b.ListAllProviders(context.Background()); len(providers) != 0 {
+		t.Fatal("expected the provider with an untrusted certificate to be left out")
+	}
+
+	a.conf.VerifyTLS, a.conf.VerifyPublicKeys = false, true
+	if err := a.sync(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.GetInfoByDomain(context.Background(), "127.0.0.1"); err != nil {
+		t.Fatalf("expected the provider publishing a valid public key to be kept, got %v", err)
+	}
+}
+
+func TestSyncKeepsProviders(t *testing.T) {
+	ctx := context.Background()
+	providers := []*ocmprovider.ProviderInfo{ocmProvider("cernbox.cern.ch", "https://cernbox.cern.ch/ocm")}
+	mentix := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if providers == nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(providers)
+	}))
+	defer mentix.Close()
+
+	cacheFile := filepath.Join(t.TempDir(), "providers.json")
+	a := newSynced(t, map[string]interface{}{
+		"url":        mentix.URL,
+		"cache_file": cacheFile,
+	})
+
+	// the source is temporarily unavailable
+	providers = nil
+	if err := a.sync(); err == nil {
+		t.Fatal("expected the sync to fail")
+	}
+	if _, err := a.GetInfoByDomain(ctx, "cernbox.cern.ch"); err != nil {
+		t.Fatalf("expected the provider to be kept, got %v", err)
+	}
+	if snapshot := readSnapshot(t, cacheFile); len(snapshot) != 1 {
+		t.Fatalf("expected the snapshot not to be emptied, got %v", snapshot)
+	}
+
+	// the mesh has shrunk to nothing
+	providers = []*ocmprovider.ProviderInfo{}
+	if err := a.sync(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.GetInfoByDomain(ctx, "cernbox.cern.ch"); err == nil {
+		t.Fatal("expected the provider to be removed")
+	}
+	if snapshot := readSnapshot(t, cacheFile); len(snapshot) != 0 {
+		t.Fatalf("expected the snapshot to be emptied, got %v", snapshot)
+	}
+}
+
+func TestNewDoesNotBlock(t *testing.T) {
+	blocked := make(chan struct{})
+	mentix := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-blocked
+	}))
+	defer mentix.Close()
+	defer close(blocked)
+
+	a, err := New(map[string]interface{}{
+		"url":        mentix.URL,
+		"cache_file": filepath.Join(t.TempDir(), "providers.json"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.(*authorizer).Close()
+	if providers, _ := a.ListAllProviders(context.Background()); len(providers) != 0 {
+		t.Fatalf("expected no provider before the first sync, got %v", providers)
+	}
+}
+
+func readSnapshot(t *testing.T, file string) []*ocmprovider.ProviderInfo {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var snapshot []*ocmprovider.ProviderInfo
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		t.Fatal(err)
+	}
+	return snapshot
+}
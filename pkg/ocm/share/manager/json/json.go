@@ -21,9 +21,9 @@ package json
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"io"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
@@ -181,7 +181,7 @@ func genID() string {
 // pi is provider info
 // pm is permissions.
 func (m *mgr) Share(ctx context.Context, md *provider.ResourceId, g *ocm.ShareGrant, name string,
-	pi *ocmprovider.ProviderInfo, pm string, owner *userpb.UserId, token string, st ocm.Share_ShareType) (*ocm.Share, error) {
+	pi *ocmprovider.ProviderInfo, p *share.Protocols, owner *userpb.UserId, token string, st ocm.Share_ShareType) (*ocm.Share, error) {
 	id := genID()
 	now := time.Now().UnixNano()
 	ts := &typespb.Timestamp{
@@ -207,16 +207,17 @@ func (m *mgr) Share(ctx context.Context, md *provider.ResourceId, g *ocm.ShareGr
 			return nil, errors.New("json: owner of resource not provided")
 		}
 		userID = owner
-		g.Grantee.Opaque = &typespb.Opaque{
-			Map: map[string]*typespb.OpaqueEntry{
-				"token": {
-					Decoder: "plain",
-					Value:   []byte(token),
-				},
-			},
+		// keep the shared secret, needed to access the share
+		if g.Grantee.Opaque == nil || g.Grantee.Opaque.Map == nil {
+			g.Grantee.Opaque = &typespb.Opaque{Map: map[string]*typespb.OpaqueEntry{}}
+		}
+		g.Grantee.Opaque.Map["token"] = &typespb.OpaqueEntry{
+			Decoder: "plain",
+			Value:   []byte(token),
 		}
 	} else {
 		userID = ctxpkg.ContextMustGetUser(ctx).GetId()
+		p.SetSharedSecret(ctxpkg.ContextMustGetToken(ctx))
 	}
 
 	// do not allow share to myself if share is for a user
@@ -252,31 +253,29 @@ func (m *mgr) Share(ctx context.Context, md *provider.ResourceId, g *ocm.ShareGr
 		ShareType:   st,
 	}
 
-	if isOwnersMeshProvider {
-		protocol := map[string]interface{}{
-			"name": "webdav",
-			"options": map[string]string{
-				"permissions": pm,
-				"token":       ctxpkg.ContextMustGetToken(ctx),
-			},
-		}
-		if st == ocm.Share_SHARE_TYPE_TRANSFER {
-			protocol["name"] = "datatx"
-		}
+	if err := share.SetProtocols(g.Grantee, p); err != nil {
+		return nil, err
+	}
 
+	if isOwnersMeshProvider {
 		requestBodyMap := map[string]interface{}{
 			"shareWith":    g.Grantee.GetUserId().OpaqueId,
 			"name":         name,
-			"providerId":   fmt.Sprintf("%s:%s", md.StorageId, md.OpaqueId),
+			"providerId":   id, // identifies the share in the notifications sent back by the remote provider
 			"owner":        userID.OpaqueId,
-			"protocol":     protocol,
+			"protocol":     p.Payload(),
 			"meshProvider": userID.Idp, // FIXME: move this into the 'owner' string?
 		}
-		err = sender.Send(requestBodyMap, pi)
+		err = sender.Send(ctx, requestBodyMap, pi)
 		if err != nil {
 			err = errors.Wrap(err, "error sending OCM POST")
 			return nil, err
 		}
+		// the secret is the token of the owner, which must not be stored
+		p.DigestSecrets()
+		if err := share.SetProtocols(g.Grantee, p); err != nil {
+			return nil, err
+		}
 	}
 
 	m.Lock()
@@ -598,3 +597,103 @@ func (m *mgr) UpdateReceivedShare(ctx context.Context, share *ocm.ReceivedShare,
 
 	return rs, nil
 }
+
+// HandleNotification applies a notification received from the remote provider n.MeshProvider.
+// Notifications sent by the recipient of a share refer to the shares created here,
+// whereas a SHARE_UNSHARED sent by the owner refers to a received share.
+func (m *mgr) HandleNotification(ctx context.Context, n *share.Notification) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if err := m.model.ReadFile(); err != nil {
+		err = errors.Wrap(err, "error reading model")
+		return err
+	}
+
+	id, sh, err := m.getSentShare(n)
+	if err != nil {
+		return err
+	}
+	if sh != nil {
+		if sh.Grantee.GetUserId().GetIdp() != n.MeshProvider {
+			return errtypes.PermissionDenied("ocm: share " + n.ProviderID + " was not shared with " + n.MeshProvider)
+		}
+		if err := n.CheckSecret(sh); err != nil {
+			return err
+		}
+
+		switch n.Type {
+		case share.NotificationShareAccepted:
+			return nil
+		case share.NotificationShareDeclined, share.NotificationShareUnshared:
+			delete(m.model.Shares, id)
+			if err := m.model.Save(); err != nil {
+				err = errors.Wrap(err, "error saving model")
+				return err
+			}
+			return nil
+		case share.NotificationRequestReshare:
+			return errtypes.NotSupported("ocm: resharing is not supported")
+		default:
+			return errtypes.BadRequest("ocm: unknown notification type " + n.Type)
+		}
+	}
+
+	if n.Type != share.NotificationShareUnshared {
+		return errtypes.NotFound(n.ProviderID)
+	}
+	for id, s := range m.model.ReceivedShares {
+		var rs ocm.ReceivedShare
+		if err := utils.UnmarshalJSONToProtoV1([]byte(s.(string)), &rs); err != nil {
+			continue
+		}
+		if share.RemoteShareID(rs.Share) == n.ProviderID && rs.Share.Owner.GetIdp() == n.MeshProvider {
+			if err := n.CheckSecret(rs.Share); err != nil {
+				return err
+			}
+			delete(m.model.ReceivedShares, id)
+			if err := m.model.Save(); err != nil {
+				err = errors.Wrap(err, "error saving model")
+				return err
+			}
+			return nil
+		}
+	}
+	return errtypes.NotFound(n.ProviderID)
+}
+
+// getSentShare returns the share created here a notification refers to, if any.
+// Up to now the shares were sent with the <storage id>:<opaque id> of the resource
+// as providerId, so those still in use are looked up by resource and remote provider.
+func (m *mgr) getSentShare(n *share.Notification) (string, *ocm.Share, error) {
+	if s, ok := m.model.Shares[n.ProviderID]; ok {
+		var sh ocm.Share
+		if err := utils.UnmarshalJSONToProtoV1([]byte(s.(string)), &sh); err != nil {
+			return "", nil, err
+		}
+		return n.ProviderID, &sh, nil
+	}
+
+	storageID, opaqueID, ok := strings.Cut(n.ProviderID, ":")
+	if !ok {
+		return "", nil, nil
+	}
+	var (
+		id    string
+		found *ocm.Share
+	)
+	for k, s := range m.model.Shares {
+		var sh ocm.Share
+		if err := utils.UnmarshalJSONToProtoV1([]byte(s.(string)), &sh); err != nil {
+			continue
+		}
+		if sh.ResourceId.GetStorageId() != storageID || sh.ResourceId.GetOpaqueId() != opaqueID || sh.Grantee.GetUserId().GetIdp() != n.MeshProvider {
+			continue
+		}
+		if found != nil {
+			return "", nil, errtypes.BadRequest("ocm: " + n.ProviderID + " refers to more than one share")
+		}
+		id, found = k, &sh
+	}
+	return id, found, nil
+}
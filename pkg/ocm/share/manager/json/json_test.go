@@ -0,0 +1,172 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package json
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	ocm "github.com/cs3org/go-cs3apis/cs3/sharing/ocm/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	typespb "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	ctxpkg "github.com/cs3org/reva/pkg/ctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/ocm/share"
+	"github.com/cs3org/reva/pkg/utils"
+)
+
+func TestHandleNotification(t *testing.T) {
+	m, err := New(map[string]interface{}{
+		"file": filepath.Join(t.TempDir(), "shares.json"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	nm := m.(share.NotificationManager)
+
+	einstein := &userpb.User{Id: &userpb.UserId{OpaqueId: "einstein", Idp: "cernbox.cern.ch"}}
+	marie := &userpb.UserId{OpaqueId: "marie", Idp: "cesnet.cz"}
+	ctx := ctxpkg.ContextSetUser(context.Background(), einstein)
+
+	// a share received from marie's provider
+	received, err := m.Share(ctx, &provider.ResourceId{StorageId: "remote", OpaqueId: "notes.txt"}, &ocm.ShareGrant{
+		Grantee: &provider.Grantee{
+			Type: provider.GranteeType_GRANTEE_TYPE_USER,
+			Id:   &provider.Grantee_UserId{UserId: einstein.Id},
+			Opaque: &typespb.Opaque{
+				Map: map[string]*typespb.OpaqueEntry{
+					"remoteShareId": {Decoder: "plain", Value: []byte("remote-share-id")},
+				},
+			},
+		},
+		Permissions: &ocm.SharePermissions{},
+	}, "notes.txt", nil, &share.Protocols{WebDAV: &share.WebDAV{SharedSecret: "secret"}}, marie, "secret", ocm.Share_SHARE_TYPE_REGULAR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := share.RemoteShareID(received); id != "remote-share-id" {
+		t.Fatalf("expected the remote share id to be kept, got %q", id)
+	}
+	if secret := share.SharedSecret(received); secret != "secret" {
+		t.Fatalf("expected the shared secret to be stored, got %q", secret)
+	}
+
+	tests := []struct {
+		description  string
+		notification *share.Notification
+		expected     error
+	}{
+		{
+			description:  "unknown share",
+			notification: &share.Notification{Type: share.NotificationShareAccepted, ProviderID: "unknown", MeshProvider: "cesnet.cz"},
+			expected:     errtypes.NotFound("unknown"),
+		},
+		{
+			description:  "unshared by a provider not owning the share",
+			notification: &share.Notification{Type: share.NotificationShareUnshared, ProviderID: "remote-share-id", MeshProvider: "example.org"},
+			expected:     errtypes.NotFound("remote-share-id"),
+		},
+		{
+			description:  "unshared without the shared secret",
+			notification: &share.Notification{Type: share.NotificationShareUnshared, ProviderID: "remote-share-id", MeshProvider: "cesnet.cz"},
+			expected:     errtypes.PermissionDenied("ocm: invalid shared secret for share remote-share-id"),
+		},
+		{
+			description:  "unshared with a wrong shared secret",
+			notification: &share.Notification{Type: share.NotificationShareUnshared, ProviderID: "remote-share-id", MeshProvider: "cesnet.cz", Notification: &share.NotificationDetails{SharedSecret: "guessed"}},
+			expected:     errtypes.PermissionDenied("ocm: invalid shared secret for share remote-share-id"),
+		},
+		{
+			description:  "unshared by the owner",
+			notification: &share.Notification{Type: share.NotificationShareUnshared, ProviderID: "remote-share-id", MeshProvider: "cesnet.cz", Notification: &share.NotificationDetails{SharedSecret: "secret"}},
+		},
+		{
+			description:  "unshared twice",
+			notification: &share.Notification{Type: share.NotificationShareUnshared, ProviderID: "remote-share-id", MeshProvider: "cesnet.cz", Notification: &share.NotificationDetails{SharedSecret: "secret"}},
+			expected:     errtypes.NotFound("remote-share-id"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			if err := nm.HandleNotification(context.Background(), tt.notification); err != tt.expected {
+				t.Errorf("expected error %v, got %v", tt.expected, err)
+			}
+		})
+	}
+
+	rss, err := m.ListReceivedShares(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rss) != 0 {
+		t.Errorf("expected the received share to be removed, got %v", rss)
+	}
+}
+
+func TestHandleLegacyNotification(t *testing.T) {
+	m, err := New(map[string]interface{}{
+		"file": filepath.Join(t.TempDir(), "shares.json"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mgr := m.(*mgr)
+
+	// shares sent before the share id was used as providerId
+	for id, idp := range map[string]string{"share-1": "cesnet.cz", "share-2": "example.org", "share-3": "example.org"} {
+		s, err := utils.MarshalProtoV1ToJSON(&ocm.Share{
+			Id:         &ocm.ShareId{OpaqueId: id},
+			ResourceId: &provider.ResourceId{StorageId: "storage", OpaqueId: "notes.txt"},
+			Grantee: &provider.Grantee{
+				Type: provider.GranteeType_GRANTEE_TYPE_USER,
+				Id:   &provider.Grantee_UserId{UserId: &userpb.UserId{OpaqueId: "marie", Idp: idp}},
+				Opaque: &typespb.Opaque{
+					Map: map[string]*typespb.OpaqueEntry{
+						"token": {Decoder: "plain", Value: []byte("secret-" + id)},
+					},
+				},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		mgr.model.Shares[id] = string(s)
+	}
+	if err := mgr.model.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	n := &share.Notification{Type: share.NotificationShareDeclined, ProviderID: "storage:notes.txt", MeshProvider: "example.org", Notification: &share.NotificationDetails{SharedSecret: "secret-share-1"}}
+	if err := mgr.HandleNotification(context.Background(), n); err != errtypes.BadRequest("ocm: storage:notes.txt refers to more than one share") {
+		t.Errorf("expected an ambiguous share to be refused, got %v", err)
+	}
+
+	n.MeshProvider = "cesnet.cz"
+	if err := mgr.HandleNotification(context.Background(), n); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := mgr.model.Shares["share-1"]; ok {
+		t.Error("expected the declined share to be removed")
+	}
+	if len(mgr.model.Shares) != 2 {
+		t.Errorf("expected the other shares to be kept, got %v", mgr.model.Shares)
+	}
+}
@@ -214,9 +214,9 @@ func (sm *Manager) do(ctx context.Context, a Action, username string) (int, []by
 // Share is called from both grpc CreateOCMShare for outgoing
 // and http /ocm/shares for incoming
 // pi is provider info
-// pm is permissions.
+// p are the protocols the share can be accessed with.
 func (sm *Manager) Share(ctx context.Context, md *provider.ResourceId, g *ocm.ShareGrant, name string,
-	pi *ocmprovider.ProviderInfo, pm string, owner *userpb.UserId, token string, st ocm.Share_ShareType) (*ocm.Share, error) {
+	pi *ocmprovider.ProviderInfo, p *share.Protocols, owner *userpb.UserId, token string, st ocm.Share_ShareType) (*ocm.Share, error) {
 	// Since both OCMCore and OCMShareProvider use the same package, we distinguish
 	// between calls received from them on the basis of whether they provide info
 	// about the remote provider on which the share is to be created.
@@ -231,6 +231,7 @@ func (sm *Manager) Share(ctx context.Context, md *provider.ResourceId, g *ocm.Sh
 		apiMethod = "addSentShare"
 		username = getUsername(ctx)
 		token = randSeq(10)
+		p.SetSharedSecret(token)
 	} else {
 		apiMethod = "addReceivedShare"
 		username = g.Grantee.GetUserId().OpaqueId
@@ -277,6 +278,9 @@ func (sm *Manager) Share(ctx context.Context, md *provider.ResourceId, g *ocm.Sh
 				},
 			},
 		}
+		if err := share.SetProtocols(s.Grantee, p); err != nil {
+			return nil, err
+		}
 
 		encShare, err = utils.MarshalProtoV1ToJSON(s)
 		if err != nil {
@@ -298,6 +302,9 @@ func (sm *Manager) Share(ctx context.Context, md *provider.ResourceId, g *ocm.Sh
 				},
 			},
 		}
+		if err := share.SetProtocols(s.Grantee, p); err != nil {
+			return nil, err
+		}
 
 		encShare, err = utils.MarshalProtoV1ToJSON(&ocm.ReceivedShare{
 			Share: s,
@@ -327,33 +334,15 @@ func (sm *Manager) Share(ctx context.Context, md *provider.ResourceId, g *ocm.Sh
 		// if !ok {
 		// 	return nil, errors.New("Could not get token from context")
 		// }
-		var protocol map[string]interface{}
-		if st == ocm.Share_SHARE_TYPE_TRANSFER {
-			protocol = map[string]interface{}{
-				"name": "datatx",
-				"options": map[string]string{
-					"permissions": pm,
-					"token":       token, // FIXME: Where is the token for datatx generated?
-				},
-			}
-		} else {
-			protocol = map[string]interface{}{
-				"name": "webdav",
-				"options": map[string]string{
-					"permissions":  pm,
-					"sharedSecret": token,
-				},
-			}
-		}
 		requestBodyMap := map[string]interface{}{
 			"shareWith":    g.Grantee.GetUserId().OpaqueId,
 			"name":         name,
 			"providerId":   s.Id.OpaqueId,
 			"owner":        userID.OpaqueId,
-			"protocol":     protocol,
+			"protocol":     p.Payload(),
 			"meshProvider": userID.Idp, // FIXME: move this into the 'owner' string?
 		}
-		err = sender.Send(requestBodyMap, pi)
+		err = sender.Send(ctx, requestBodyMap, pi)
 		if err != nil {
 			err = errors.Wrap(err, "error sending OCM POST")
 			return nil, err
@@ -619,3 +608,32 @@ func (sm Manager) UpdateReceivedShare(ctx context.Context, receivedShare *ocm.Re
 		State: altResult.State,
 	}, err
 }
+
+// HandleNotification as defined in the ocm.share.NotificationManager interface.
+// The notifications are not sent on behalf of a user, so Nextcloud looks the share up by its id.
+func (sm *Manager) HandleNotification(ctx context.Context, n *share.Notification) error {
+	bodyStr, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+
+	code, respBody, err := sm.do(ctx, Action{"HandleNotification", string(bodyStr)}, getUsername(ctx))
+	if err != nil {
+		return err
+	}
+
+	switch code {
+	case http.StatusOK, http.StatusCreated, http.StatusNoContent:
+		return nil
+	case http.StatusNotFound:
+		return errtypes.NotFound(n.ProviderID)
+	case http.StatusForbidden:
+		return errtypes.PermissionDenied(string(respBody))
+	case http.StatusBadRequest:
+		return errtypes.BadRequest(string(respBody))
+	case http.StatusNotImplemented:
+		return errtypes.NotSupported(string(respBody))
+	default:
+		return errtypes.InternalError(string(respBody))
+	}
+}
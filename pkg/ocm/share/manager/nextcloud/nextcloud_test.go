@@ -28,6 +28,8 @@ import (
 	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
 	"github.com/cs3org/reva/pkg/auth/scope"
 	ctxpkg "github.com/cs3org/reva/pkg/ctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/ocm/share"
 	"github.com/cs3org/reva/pkg/ocm/share/manager/nextcloud"
 	jwt "github.com/cs3org/reva/pkg/token/manager/jwt"
 	. "github.com/onsi/ginkgo"
@@ -821,4 +823,34 @@ var _ = Describe("Nextcloud", func() {
 		})
 	})
 
+	// HandleNotification(ctx context.Context, n *share.Notification) error
+	Describe("HandleNotification", func() {
+		It("calls the HandleNotification endpoint", func() {
+			am, called, teardown := setUpNextcloudServer()
+			defer teardown()
+
+			err := am.HandleNotification(context.Background(), &share.Notification{
+				Type:         share.NotificationShareUnshared,
+				ResourceType: "file",
+				ProviderID:   "some-share-id",
+				MeshProvider: "cesnet.cz",
+			})
+			Expect(err).ToNot(HaveOccurred())
+			checkCalled(called, `POST /apps/sciencemesh/~unknown/api/ocm/HandleNotification {"notificationType":"SHARE_UNSHARED","resourceType":"file","providerId":"some-share-id","meshProvider":"cesnet.cz"}`)
+		})
+
+		It("returns not found for unknown shares", func() {
+			am, _, teardown := setUpNextcloudServer()
+			defer teardown()
+
+			err := am.HandleNotification(context.Background(), &share.Notification{
+				Type:         share.NotificationShareUnshared,
+				ResourceType: "file",
+				ProviderID:   "unknown",
+				MeshProvider: "cesnet.cz",
+			})
+			Expect(err).To(Equal(errtypes.NotFound("unknown")))
+		})
+	})
+
 })
@@ -0,0 +1,607 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package sql implements an OCM share manager storing the shares in a
+// MySQL or SQLite database.
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	ocmprovider "github.com/cs3org/go-cs3apis/cs3/ocm/provider/v1beta1"
+	ocm "github.com/cs3org/go-cs3apis/cs3/sharing/ocm/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	typespb "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	ctxpkg "github.com/cs3org/reva/pkg/ctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/health"
+	"github.com/cs3org/reva/pkg/ocm/share"
+	"github.com/cs3org/reva/pkg/ocm/share/manager/registry"
+	"github.com/cs3org/reva/pkg/ocm/share/sender"
+	"github.com/cs3org/reva/pkg/utils"
+	"github.com/cs3org/reva/pkg/utils/sqlmigrate"
+	"github.com/google/uuid"
+
+	// Provides mysql drivers.
+	_ "github.com/go-sql-driver/mysql"
+	// Provides sqlite drivers.
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"google.golang.org/genproto/protobuf/field_mask"
+)
+
+func init() {
+	registry.Register("sql", New)
+}
+
+var migrations = []sqlmigrate.Migration{
+	{
+		`CREATE TABLE ocm_shares (
+			id VARCHAR(255) NOT NULL PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			resource_storage_id VARCHAR(255) NOT NULL,
+			resource_opaque_id VARCHAR(255) NOT NULL,
+			owner_idp VARCHAR(255) NOT NULL,
+			owner_opaque_id VARCHAR(255) NOT NULL,
+			owner_type INTEGER NOT NULL,
+			grantee_type INTEGER NOT NULL,
+			grantee_idp VARCHAR(255) NOT NULL,
+			grantee_opaque_id VARCHAR(255) NOT NULL,
+			grantee_user_type INTEGER NOT NULL,
+			permissions TEXT NOT NULL,
+			share_type INTEGER NOT NULL,
+			ctime BIGINT NOT NULL,
+			mtime BIGINT NOT NULL
+		)`,
+		`CREATE INDEX ocm_shares_owner ON ocm_shares (owner_idp, owner_opaque_id)`,
+		`CREATE TABLE ocm_received_shares (
+			id VARCHAR(255) NOT NULL PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			resource_storage_id VARCHAR(255) NOT NULL,
+			resource_opaque_id VARCHAR(255) NOT NULL,
+			owner_idp VARCHAR(255) NOT NULL,
+			owner_opaque_id VARCHAR(255) NOT NULL,
+			owner_type INTEGER NOT NULL,
+			grantee_type INTEGER NOT NULL,
+			grantee_idp VARCHAR(255) NOT NULL,
+			grantee_opaque_id VARCHAR(255) NOT NULL,
+			grantee_user_type INTEGER NOT NULL,
+			permissions TEXT NOT NULL,
+			share_type INTEGER NOT NULL,
+			ctime BIGINT NOT NULL,
+			mtime BIGINT NOT NULL,
+			state INTEGER NOT NULL,
+			remote_share_id VARCHAR(255) NOT NULL,
+			shared_secret TEXT NOT NULL
+		)`,
+		`CREATE INDEX ocm_received_shares_grantee ON ocm_received_shares (grantee_idp, grantee_opaque_id)`,
+		`CREATE INDEX ocm_received_shares_remote ON ocm_received_shares (remote_share_id)`,
+	},
+	{
+		`ALTER TABLE ocm_shares ADD COLUMN protocols TEXT`,
+		`ALTER TABLE ocm_received_shares ADD COLUMN protocols TEXT`,
+	},
+}
+
+const shareColumns = "id, name, resource_storage_id, resource_opaque_id, owner_idp, owner_opaque_id, owner_type, " +
+	"grantee_type, grantee_idp, grantee_opaque_id, grantee_user_type, permissions, share_type, ctime, mtime, protocols"
+
+const receivedShareColumns = shareColumns + ", state, remote_share_id, shared_secret"
+
+type config struct {
+	DBEngine   string `mapstructure:"db_engine" docs:"mysql;The database engine, either mysql or sqlite3."`
+	DBUsername string `mapstructure:"db_username"`
+	DBPassword string `mapstructure:"db_password"`
+	DBHost     string `mapstructure:"db_host"`
+	DBPort     int    `mapstructure:"db_port"`
+	DBName     string `mapstructure:"db_name" docs:";The name of the database, or the path of the database file for sqlite3."`
+}
+
+func (c *config) init() {
+	if c.DBEngine == "" {
+		c.DBEngine = "mysql"
+	}
+}
+
+type mgr struct {
+	db *sql.DB
+}
+
+func parseConfig(m map[string]interface{}) (*config, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// New returns a new OCM share manager connecting to the configured database.
+func New(m map[string]interface{}) (share.Manager, error) {
+	c, err := parseConfig(m)
+	if err != nil {
+		err = errors.Wrap(err, "error creating a new manager")
+		return nil, err
+	}
+	c.init()
+
+	var db *sql.DB
+	switch c.DBEngine {
+	case "mysql":
+		db, err = sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s:%d)/%s", c.DBUsername, c.DBPassword, c.DBHost, c.DBPort, c.DBName))
+	case "sqlite3":
+		db, err = sql.Open("sqlite3", c.DBName)
+	default:
+		return nil, errtypes.NotSupported("sql: unsupported database engine " + c.DBEngine)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "sql: error opening database")
+	}
+
+	health.Register(fmt.Sprintf("ocmshare:sql:%s:%s:%d/%s", c.DBEngine, c.DBHost, c.DBPort, c.DBName), health.SQL(db))
+
+	return NewWithDB(db)
+}
+
+// NewWithDB returns a new OCM share manager using the given database,
+// whose schema is migrated to the latest version.
+func NewWithDB(db *sql.DB) (share.Manager, error) {
+	if err := sqlmigrate.Migrate(db, "ocm_shares", migrations); err != nil {
+		return nil, err
+	}
+	return &mgr{db: db}, nil
+}
+
+// Called from both grpc CreateOCMShare for outgoing
+// and grpc CreateOCMCoreShare for incoming shares.
+func (m *mgr) Share(ctx context.Context, md *provider.ResourceId, g *ocm.ShareGrant, name string,
+	pi *ocmprovider.ProviderInfo, p *share.Protocols, owner *userpb.UserId, token string, st ocm.Share_ShareType) (*ocm.Share, error) {
+	// if the info about the remote provider is given, the share is created by a local user
+	// and sent to the remote provider, else the share was received from a remote provider
+	isOwnersMeshProvider := pi != nil
+
+	var userID *userpb.UserId
+	if isOwnersMeshProvider {
+		userID = ctxpkg.ContextMustGetUser(ctx).GetId()
+		p.SetSharedSecret(ctxpkg.ContextMustGetToken(ctx))
+	} else {
+		if owner == nil {
+			return nil, errors.New("sql: owner of resource not provided")
+		}
+		userID = owner
+	}
+
+	// do not allow share to myself if share is for a user
+	if g.Grantee.Type == provider.GranteeType_GRANTEE_TYPE_USER && utils.UserEqual(g.Grantee.GetUserId(), userID) {
+		return nil, errors.New("sql: user and grantee are the same")
+	}
+
+	now := time.Now()
+	s := &ocm.Share{
+		Id:          &ocm.ShareId{OpaqueId: uuid.New().String()},
+		Name:        name,
+		ResourceId:  md,
+		Permissions: g.Permissions,
+		Grantee:     g.Grantee,
+		Owner:       userID,
+		Creator:     userID,
+		Ctime:       timestamp(now.UnixNano()),
+		Mtime:       timestamp(now.UnixNano()),
+		ShareType:   st,
+	}
+
+	permissions, err := utils.MarshalProtoV1ToJSON(g.Permissions)
+	if err != nil {
+		return nil, err
+	}
+	protocols, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	if err := share.SetProtocols(g.Grantee, p); err != nil {
+		return nil, err
+	}
+
+	if !isOwnersMeshProvider {
+		remoteShareID := share.RemoteShareID(s)
+		if g.Grantee.Opaque == nil || g.Grantee.Opaque.Map == nil {
+			g.Grantee.Opaque = &typespb.Opaque{Map: map[string]*typespb.OpaqueEntry{}}
+		}
+		g.Grantee.Opaque.Map["token"] = &typespb.OpaqueEntry{
+			Decoder: "plain",
+			Value:   []byte(token),
+		}
+
+		query := "INSERT INTO ocm_received_shares (" + receivedShareColumns + ") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+		params := append(shareParams(s, string(permissions), string(protocols)), int32(ocm.ShareState_SHARE_STATE_PENDING), remoteShareID, token)
+		if _, err := m.db.ExecContext(ctx, query, params...); err != nil {
+			return nil, errors.Wrap(err, "sql: error storing received share")
+		}
+		return s, nil
+	}
+
+	// check if share already exists.
+	key := &ocm.ShareKey{
+		Owner:      userID,
+		ResourceId: md,
+		Grantee:    g.Grantee,
+	}
+	if _, err := m.getByKey(ctx, key); err == nil {
+		return nil, errtypes.AlreadyExists(key.String())
+	}
+
+	requestBodyMap := map[string]interface{}{
+		"shareWith":    g.Grantee.GetUserId().OpaqueId,
+		"name":         name,
+		"providerId":   s.Id.OpaqueId,
+		"owner":        userID.OpaqueId,
+		"protocol":     p.Payload(),
+		"meshProvider": userID.Idp,
+	}
+	if err := sender.Send(ctx, requestBodyMap, pi); err != nil {
+		err = errors.Wrap(err, "error sending OCM POST")
+		return nil, err
+	}
+
+	// the secret is the token of the owner, which must not be stored
+	p.DigestSecrets()
+	if protocols, err = json.Marshal(p); err != nil {
+		return nil, err
+	}
+	if err := share.SetProtocols(g.Grantee, p); err != nil {
+		return nil, err
+	}
+
+	query := "INSERT INTO ocm_shares (" + shareColumns + ") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	if _, err := m.db.ExecContext(ctx, query, shareParams(s, string(permissions), string(protocols))...); err != nil {
+		return nil, errors.Wrap(err, "sql: error storing share")
+	}
+	return s, nil
+}
+
+func shareParams(s *ocm.Share, permissions, protocols string) []interface{} {
+	grantee := s.Grantee.GetUserId()
+	return []interface{}{
+		s.Id.OpaqueId, s.Name, s.ResourceId.StorageId, s.ResourceId.OpaqueId,
+		s.Owner.Idp, s.Owner.OpaqueId, int32(s.Owner.Type),
+		int32(s.Grantee.Type), grantee.GetIdp(), grantee.GetOpaqueId(), int32(grantee.GetType()),
+		permissions, int32(s.ShareType), unixNano(s.Ctime), unixNano(s.Mtime), protocols,
+	}
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanShare(row scanner, extra ...interface{}) (*ocm.Share, error) {
+	var (
+		s                                       ocm.Share
+		id, storageID, opaqueID                 string
+		ownerIdp, ownerOpaqueID                 string
+		granteeIdp, granteeOpaqueID             string
+		ownerType, granteeType, granteeUserType int32
+		shareType                               int32
+		permissions                             string
+		ctime, mtime                            int64
+		protocols                               sql.NullString
+	)
+	dest := []interface{}{&id, &s.Name, &storageID, &opaqueID, &ownerIdp, &ownerOpaqueID, &ownerType,
+		&granteeType, &granteeIdp, &granteeOpaqueID, &granteeUserType, &permissions, &shareType, &ctime, &mtime, &protocols}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+
+	var perms ocm.SharePermissions
+	if err := utils.UnmarshalJSONToProtoV1([]byte(permissions), &perms); err != nil {
+		return nil, err
+	}
+
+	owner := &userpb.UserId{Idp: ownerIdp, OpaqueId: ownerOpaqueID, Type: userpb.UserType(ownerType)}
+	s.Id = &ocm.ShareId{OpaqueId: id}
+	s.ResourceId = &provider.ResourceId{StorageId: storageID, OpaqueId: opaqueID}
+	s.Owner, s.Creator = owner, owner
+	s.Grantee = &provider.Grantee{
+		Type: provider.GranteeType(granteeType),
+		Id: &provider.Grantee_UserId{UserId: &userpb.UserId{
+			Idp:      granteeIdp,
+			OpaqueId: granteeOpaqueID,
+			Type:     userpb.UserType(granteeUserType),
+		}},
+	}
+	if protocols.Valid {
+		s.Grantee.Opaque = &typespb.Opaque{
+			Map: map[string]*typespb.OpaqueEntry{
+				"protocols": {Decoder: "json", Value: []byte(protocols.String)},
+			},
+		}
+	}
+	s.Permissions = &perms
+	s.ShareType = ocm.Share_ShareType(shareType)
+	s.Ctime, s.Mtime = timestamp(ctime), timestamp(mtime)
+	return &s, nil
+}
+
+func scanReceivedShare(row scanner) (*ocm.ReceivedShare, error) {
+	var (
+		state                       int32
+		remoteShareID, sharedSecret string
+	)
+	s, err := scanShare(row, &state, &remoteShareID, &sharedSecret)
+	if err != nil {
+		return nil, err
+	}
+	if s.Grantee.Opaque == nil {
+		s.Grantee.Opaque = &typespb.Opaque{Map: map[string]*typespb.OpaqueEntry{}}
+	}
+	s.Grantee.Opaque.Map["token"] = &typespb.OpaqueEntry{Decoder: "plain", Value: []byte(sharedSecret)}
+	s.Grantee.Opaque.Map["remoteShareId"] = &typespb.OpaqueEntry{Decoder: "plain", Value: []byte(remoteShareID)}
+	return &ocm.ReceivedShare{Share: s, State: ocm.ShareState(state)}, nil
+}
+
+func timestamp(ns int64) *typespb.Timestamp {
+	return &typespb.Timestamp{
+		Seconds: uint64(ns / 1000000000),
+		Nanos:   uint32(ns % 1000000000),
+	}
+}
+
+func unixNano(ts *typespb.Timestamp) int64 {
+	return int64(ts.GetSeconds())*1000000000 + int64(ts.GetNanos())
+}
+
+// refCondition returns the condition selecting the share pointed by ref.
+func refCondition(ref *ocm.ShareReference) (string, []interface{}, error) {
+	switch {
+	case ref.GetId() != nil:
+		return "id=?", []interface{}{ref.GetId().OpaqueId}, nil
+	case ref.GetKey() != nil:
+		key := ref.GetKey()
+		return "owner_idp=? AND owner_opaque_id=? AND resource_storage_id=? AND resource_opaque_id=? AND grantee_idp=? AND grantee_opaque_id=?",
+			[]interface{}{key.Owner.GetIdp(), key.Owner.GetOpaqueId(), key.ResourceId.GetStorageId(), key.ResourceId.GetOpaqueId(),
+				key.Grantee.GetUserId().GetIdp(), key.Grantee.GetUserId().GetOpaqueId()}, nil
+	default:
+		return "", nil, errtypes.NotFound(ref.String())
+	}
+}
+
+func (m *mgr) getByKey(ctx context.Context, key *ocm.ShareKey) (*ocm.Share, error) {
+	ref := &ocm.ShareReference{Spec: &ocm.ShareReference_Key{Key: key}}
+	cond, params, err := refCondition(ref)
+	if err != nil {
+		return nil, err
+	}
+	s, err := scanShare(m.db.QueryRowContext(ctx, "SELECT "+shareColumns+" FROM ocm_shares WHERE "+cond, params...))
+	if err == sql.ErrNoRows {
+		return nil, errtypes.NotFound(key.String())
+	}
+	return s, err
+}
+
+func (m *mgr) GetShare(ctx context.Context, ref *ocm.ShareReference) (*ocm.Share, error) {
+	cond, params, err := refCondition(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	// only the owner can access the share, otherwise we return not found to not disclose information
+	user := ctxpkg.ContextMustGetUser(ctx)
+	query := "SELECT " + shareColumns + " FROM ocm_shares WHERE " + cond + " AND owner_idp=? AND owner_opaque_id=?"
+	params = append(params, user.Id.Idp, user.Id.OpaqueId)
+
+	s, err := scanShare(m.db.QueryRowContext(ctx, query, params...))
+	if err == sql.ErrNoRows {
+		return nil, errtypes.NotFound(ref.String())
+	}
+	return s, err
+}
+
+func (m *mgr) Unshare(ctx context.Context, ref *ocm.ShareReference) error {
+	cond, params, err := refCondition(ref)
+	if err != nil {
+		return err
+	}
+
+	user := ctxpkg.ContextMustGetUser(ctx)
+	query := "DELETE FROM ocm_shares WHERE " + cond + " AND owner_idp=? AND owner_opaque_id=?"
+	params = append(params, user.Id.Idp, user.Id.OpaqueId)
+	return m.execAffectingOne(ctx, ref.String(), query, params...)
+}
+
+func (m *mgr) UpdateShare(ctx context.Context, ref *ocm.ShareReference, p *ocm.SharePermissions) (*ocm.Share, error) {
+	cond, params, err := refCondition(ref)
+	if err != nil {
+		return nil, err
+	}
+	permissions, err := utils.MarshalProtoV1ToJSON(p)
+	if err != nil {
+		return nil, err
+	}
+
+	user := ctxpkg.ContextMustGetUser(ctx)
+	query := "UPDATE ocm_shares SET permissions=?, mtime=? WHERE " + cond + " AND owner_idp=? AND owner_opaque_id=?"
+	params = append([]interface{}{string(permissions), time.Now().UnixNano()}, params...)
+	params = append(params, user.Id.Idp, user.Id.OpaqueId)
+	if err := m.execAffectingOne(ctx, ref.String(), query, params...); err != nil {
+		return nil, err
+	}
+	return m.GetShare(ctx, ref)
+}
+
+func (m *mgr) ListShares(ctx context.Context, filters []*ocm.ListOCMSharesRequest_Filter) ([]*ocm.Share, error) {
+	user := ctxpkg.ContextMustGetUser(ctx)
+	query := "SELECT " + shareColumns + " FROM ocm_shares WHERE owner_idp=? AND owner_opaque_id=?"
+	params := []interface{}{user.Id.Idp, user.Id.OpaqueId}
+
+	if len(filters) > 0 {
+		// TODO: add the rest of filters.
+		conds := []string{}
+		for _, f := range filters {
+			if f.Type == ocm.ListOCMSharesRequest_Filter_TYPE_RESOURCE_ID {
+				conds = append(conds, "(resource_storage_id=? AND resource_opaque_id=?)")
+				params = append(params, f.GetResourceId().GetStorageId(), f.GetResourceId().GetOpaqueId())
+			}
+		}
+		if len(conds) == 0 {
+			return []*ocm.Share{}, nil
+		}
+		query += " AND (" + strings.Join(conds, " OR ") + ")"
+	}
+
+	rows, err := m.db.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ss := []*ocm.Share{}
+	for rows.Next() {
+		s, err := scanShare(rows)
+		if err != nil {
+			return nil, err
+		}
+		ss = append(ss, s)
+	}
+	return ss, rows.Err()
+}
+
+func (m *mgr) ListReceivedShares(ctx context.Context) ([]*ocm.ReceivedShare, error) {
+	user := ctxpkg.ContextMustGetUser(ctx)
+	query := "SELECT " + receivedShareColumns + " FROM ocm_received_shares WHERE grantee_type=? AND grantee_idp=? AND grantee_opaque_id=?" +
+		" AND NOT (owner_idp=? AND owner_opaque_id=?)"
+	rows, err := m.db.QueryContext(ctx, query, int32(provider.GranteeType_GRANTEE_TYPE_USER), user.Id.Idp, user.Id.OpaqueId, user.Id.Idp, user.Id.OpaqueId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rss := []*ocm.ReceivedShare{}
+	for rows.Next() {
+		rs, err := scanReceivedShare(rows)
+		if err != nil {
+			return nil, err
+		}
+		rss = append(rss, rs)
+	}
+	return rss, rows.Err()
+}
+
+func (m *mgr) GetReceivedShare(ctx context.Context, ref *ocm.ShareReference) (*ocm.ReceivedShare, error) {
+	cond, params, err := refCondition(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	user := ctxpkg.ContextMustGetUser(ctx)
+	query := "SELECT " + receivedShareColumns + " FROM ocm_received_shares WHERE " + cond + " AND grantee_type=? AND grantee_idp=? AND grantee_opaque_id=?"
+	params = append(params, int32(provider.GranteeType_GRANTEE_TYPE_USER), user.Id.Idp, user.Id.OpaqueId)
+
+	rs, err := scanReceivedShare(m.db.QueryRowContext(ctx, query, params...))
+	if err == sql.ErrNoRows {
+		return nil, errtypes.NotFound(ref.String())
+	}
+	return rs, err
+}
+
+func (m *mgr) UpdateReceivedShare(ctx context.Context, share *ocm.ReceivedShare, fieldMask *field_mask.FieldMask) (*ocm.ReceivedShare, error) {
+	rs, err := m.GetReceivedShare(ctx, &ocm.ShareReference{Spec: &ocm.ShareReference_Id{Id: share.Share.Id}})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range fieldMask.Paths {
+		switch fieldMask.Paths[i] {
+		case "state":
+			rs.State = share.State
+		// TODO case "mount_point":
+		default:
+			return nil, errtypes.NotSupported("updating " + fieldMask.Paths[i] + " is not supported")
+		}
+	}
+
+	query := "UPDATE ocm_received_shares SET state=? WHERE id=?"
+	if _, err := m.db.ExecContext(ctx, query, int32(rs.State), rs.Share.Id.OpaqueId); err != nil {
+		return nil, errors.Wrap(err, "sql: error updating received share")
+	}
+	return rs, nil
+}
+
+// HandleNotification applies a notification received from the remote provider n.MeshProvider.
+// Notifications sent by the recipient of a share refer to the shares created here,
+// whereas a SHARE_UNSHARED sent by the owner refers to a received share.
+func (m *mgr) HandleNotification(ctx context.Context, n *share.Notification) error {
+	s, err := scanShare(m.db.QueryRowContext(ctx, "SELECT "+shareColumns+" FROM ocm_shares WHERE id=?", n.ProviderID))
+	switch {
+	case err == nil:
+		if s.Grantee.GetUserId().GetIdp() != n.MeshProvider {
+			return errtypes.PermissionDenied("ocm: share " + n.ProviderID + " was not shared with " + n.MeshProvider)
+		}
+		if err := n.CheckSecret(s); err != nil {
+			return err
+		}
+
+		switch n.Type {
+		case share.NotificationShareAccepted:
+			return nil
+		case share.NotificationShareDeclined, share.NotificationShareUnshared:
+			return m.execAffectingOne(ctx, n.ProviderID, "DELETE FROM ocm_shares WHERE id=?", n.ProviderID)
+		case share.NotificationRequestReshare:
+			return errtypes.NotSupported("ocm: resharing is not supported")
+		default:
+			return errtypes.BadRequest("ocm: unknown notification type " + n.Type)
+		}
+	case err != sql.ErrNoRows:
+		return err
+	}
+
+	if n.Type != share.NotificationShareUnshared {
+		return errtypes.NotFound(n.ProviderID)
+	}
+	rs, err := scanReceivedShare(m.db.QueryRowContext(ctx, "SELECT "+receivedShareColumns+" FROM ocm_received_shares WHERE remote_share_id=? AND owner_idp=?", n.ProviderID, n.MeshProvider))
+	switch {
+	case err == sql.ErrNoRows:
+		return errtypes.NotFound(n.ProviderID)
+	case err != nil:
+		return err
+	}
+	if err := n.CheckSecret(rs.Share); err != nil {
+		return err
+	}
+	return m.execAffectingOne(ctx, n.ProviderID, "DELETE FROM ocm_received_shares WHERE id=?", rs.Share.Id.OpaqueId)
+}
+
+// execAffectingOne executes a statement, returning a not found error if no row was affected.
+func (m *mgr) execAffectingOne(ctx context.Context, ref, query string, params ...interface{}) error {
+	res, err := m.db.ExecContext(ctx, query, params...)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errtypes.NotFound(ref)
+	}
+	return nil
+}
@@ -0,0 +1,287 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	ocmprovider "github.com/cs3org/go-cs3apis/cs3/ocm/provider/v1beta1"
+	ocm "github.com/cs3org/go-cs3apis/cs3/sharing/ocm/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	typespb "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	ctxpkg "github.com/cs3org/reva/pkg/ctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/ocm/share"
+	"google.golang.org/genproto/protobuf/field_mask"
+)
+
+var (
+	einstein = &userpb.User{Id: &userpb.UserId{OpaqueId: "einstein", Idp: "cernbox.cern.ch", Type: userpb.UserType_USER_TYPE_PRIMARY}}
+	marie    = &userpb.UserId{OpaqueId: "marie", Idp: "cesnet.cz", Type: userpb.UserType_USER_TYPE_PRIMARY}
+)
+
+func newManager(t *testing.T) share.Manager {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "ocm.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	m, err := NewWithDB(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// migrating an up to date schema must be a no-op
+	if _, err := NewWithDB(db); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func newRemoteProvider(t *testing.T) *ocmprovider.ProviderInfo {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	t.Cleanup(srv.Close)
+	return &ocmprovider.ProviderInfo{
+		Domain: "cesnet.cz",
+		Services: []*ocmprovider.Service{{
+			Endpoint: &ocmprovider.ServiceEndpoint{
+				Type: &ocmprovider.ServiceType{Name: "OCM"},
+				Path: srv.URL,
+			},
+		}},
+	}
+}
+
+func grant(grantee *userpb.UserId, opaque *typespb.Opaque) *ocm.ShareGrant {
+	return &ocm.ShareGrant{
+		Grantee: &provider.Grantee{
+			Type:   provider.GranteeType_GRANTEE_TYPE_USER,
+			Id:     &provider.Grantee_UserId{UserId: grantee},
+			Opaque: opaque,
+		},
+		Permissions: &ocm.SharePermissions{Permissions: &provider.ResourcePermissions{Stat: true}},
+	}
+}
+
+func webdav() *share.Protocols {
+	return &share.Protocols{WebDAV: &share.WebDAV{Permissions: []string{share.PermissionRead}}}
+}
+
+func TestShares(t *testing.T) {
+	m := newManager(t)
+	ctx := ctxpkg.ContextSetToken(ctxpkg.ContextSetUser(context.Background(), einstein), "token")
+	resource := &provider.ResourceId{StorageId: "storage", OpaqueId: "file"}
+
+	s, err := m.Share(ctx, resource, grant(marie, nil), "file", newRemoteProvider(t), webdav(), nil, "", ocm.Share_SHARE_TYPE_REGULAR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Share(ctx, resource, grant(marie, nil), "file", newRemoteProvider(t), webdav(), nil, "", ocm.Share_SHARE_TYPE_REGULAR); err == nil {
+		t.Fatal("expected sharing the same resource twice to fail")
+	}
+
+	byID := &ocm.ShareReference{Spec: &ocm.ShareReference_Id{Id: s.Id}}
+	byKey := &ocm.ShareReference{Spec: &ocm.ShareReference_Key{Key: &ocm.ShareKey{Owner: einstein.Id, ResourceId: resource, Grantee: s.Grantee}}}
+	for _, ref := range []*ocm.ShareReference{byID, byKey} {
+		got, err := m.GetShare(ctx, ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Id.OpaqueId != s.Id.OpaqueId || got.Name != "file" || !got.Permissions.Permissions.Stat || got.Ctime.Seconds != s.Ctime.Seconds {
+			t.Fatalf("unexpected share %+v", got)
+		}
+		// the token sent to the recipient is not stored
+		if p, err := share.GetProtocols(got); err != nil || p.WebDAV == nil || p.WebDAV.SharedSecret != share.SecretDigest("token") {
+			t.Fatalf("expected the share to be offered through webdav with the digest of the user token, got %+v (%v)", p, err)
+		}
+	}
+
+	// shares are only visible to their owner
+	other := ctxpkg.ContextSetUser(context.Background(), &userpb.User{Id: &userpb.UserId{OpaqueId: "richard", Idp: "cernbox.cern.ch"}})
+	if _, err := m.GetShare(other, byID); err == nil {
+		t.Fatal("expected the share not to be found for another user")
+	}
+
+	updated, err := m.UpdateShare(ctx, byID, &ocm.SharePermissions{Permissions: &provider.ResourcePermissions{Stat: true, Delete: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !updated.Permissions.Permissions.Delete {
+		t.Fatal("expected the permissions to be updated")
+	}
+
+	filter := func(id *provider.ResourceId) []*ocm.ListOCMSharesRequest_Filter {
+		return []*ocm.ListOCMSharesRequest_Filter{{
+			Type: ocm.ListOCMSharesRequest_Filter_TYPE_RESOURCE_ID,
+			Term: &ocm.ListOCMSharesRequest_Filter_ResourceId{ResourceId: id},
+		}}
+	}
+	for _, tt := range []struct {
+		filters  []*ocm.ListOCMSharesRequest_Filter
+		expected int
+	}{
+		{nil, 1},
+		{filter(resource), 1},
+		{filter(&provider.ResourceId{StorageId: "storage", OpaqueId: "other"}), 0},
+	} {
+		ss, err := m.ListShares(ctx, tt.filters)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ss) != tt.expected {
+			t.Fatalf("expected %d shares, got %d", tt.expected, len(ss))
+		}
+	}
+
+	if err := m.Unshare(ctx, byID); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Unshare(ctx, byID); err == nil {
+		t.Fatal("expected removing a share twice to fail")
+	}
+}
+
+func TestReceivedShares(t *testing.T) {
+	m := newManager(t)
+	ctx := ctxpkg.ContextSetUser(context.Background(), einstein)
+
+	opaque := &typespb.Opaque{Map: map[string]*typespb.OpaqueEntry{
+		"remoteShareId": {Decoder: "plain", Value: []byte("remote-share-id")},
+	}}
+	protocols := &share.Protocols{
+		WebDAV: &share.WebDAV{SharedSecret: "secret", Permissions: []string{share.PermissionRead}},
+		WebApp: &share.WebApp{URITemplate: "https://cesnet.cz/open/notes.txt", ViewMode: share.PermissionRead},
+	}
+	s, err := m.Share(ctx, &provider.ResourceId{StorageId: "remote", OpaqueId: "notes.txt"}, grant(einstein.Id, opaque),
+		"notes.txt", nil, protocols, marie, "secret", ocm.Share_SHARE_TYPE_REGULAR)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rss, err := m.ListReceivedShares(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rss) != 1 || rss[0].State != ocm.ShareState_SHARE_STATE_PENDING {
+		t.Fatalf("expected one pending received share, got %+v", rss)
+	}
+	if share.RemoteShareID(rss[0].Share) != "remote-share-id" || share.SharedSecret(rss[0].Share) != "secret" {
+		t.Fatalf("expected the remote share id and secret to be stored, got %+v", rss[0].Share.Grantee.Opaque)
+	}
+	if p, err := share.GetProtocols(rss[0].Share); err != nil || !p.Has(share.WebAppProtocol) || p.WebApp.URITemplate != protocols.WebApp.URITemplate {
+		t.Fatalf("expected the protocols to be stored, got %+v (%v)", p, err)
+	}
+
+	rss[0].State = ocm.ShareState_SHARE_STATE_ACCEPTED
+	if _, err := m.UpdateReceivedShare(ctx, rss[0], &field_mask.FieldMask{Paths: []string{"state"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.UpdateReceivedShare(ctx, rss[0], &field_mask.FieldMask{Paths: []string{"mount_point"}}); err == nil {
+		t.Fatal("expected updating the mount point to fail")
+	}
+
+	rs, err := m.GetReceivedShare(ctx, &ocm.ShareReference{Spec: &ocm.ShareReference_Id{Id: s.Id}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rs.State != ocm.ShareState_SHARE_STATE_ACCEPTED {
+		t.Fatalf("expected the share to be accepted, got %s", rs.State)
+	}
+
+	nm := m.(share.NotificationManager)
+	n := &share.Notification{Type: share.NotificationShareUnshared, ProviderID: "remote-share-id", MeshProvider: "other.org"}
+	if err := nm.HandleNotification(ctx, n); err != errtypes.NotFound("remote-share-id") {
+		t.Fatalf("expected notifications from other providers to be ignored, got %v", err)
+	}
+	n.MeshProvider = "cesnet.cz"
+	if err := nm.HandleNotification(ctx, n); err != errtypes.PermissionDenied("ocm: invalid shared secret for share remote-share-id") {
+		t.Fatalf("expected notifications without the shared secret to be refused, got %v", err)
+	}
+	n.Notification = &share.NotificationDetails{SharedSecret: "secret"}
+	if err := nm.HandleNotification(ctx, n); err != nil {
+		t.Fatal(err)
+	}
+	if rss, _ := m.ListReceivedShares(ctx); len(rss) != 0 {
+		t.Fatal("expected the received share to be removed")
+	}
+}
+
+func TestHandleNotification(t *testing.T) {
+	m := newManager(t)
+	nm := m.(share.NotificationManager)
+	ctx := ctxpkg.ContextSetToken(ctxpkg.ContextSetUser(context.Background(), einstein), "token")
+
+	s, err := m.Share(ctx, &provider.ResourceId{StorageId: "storage", OpaqueId: "file"}, grant(marie, nil), "file",
+		newRemoteProvider(t), webdav(), nil, "", ocm.Share_SHARE_TYPE_REGULAR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := &share.NotificationDetails{SharedSecret: "token"}
+
+	tests := []struct {
+		description  string
+		notification *share.Notification
+		expected     error
+	}{
+		{
+			description:  "wrong provider",
+			notification: &share.Notification{Type: share.NotificationShareDeclined, ProviderID: s.Id.OpaqueId, MeshProvider: "other.org"},
+			expected:     errtypes.PermissionDenied("ocm: share " + s.Id.OpaqueId + " was not shared with other.org"),
+		},
+		{
+			description:  "wrong secret",
+			notification: &share.Notification{Type: share.NotificationShareDeclined, ProviderID: s.Id.OpaqueId, MeshProvider: "cesnet.cz", Notification: &share.NotificationDetails{SharedSecret: "guessed"}},
+			expected:     errtypes.PermissionDenied("ocm: invalid shared secret for share " + s.Id.OpaqueId),
+		},
+		{
+			description:  "accepted",
+			notification: &share.Notification{Type: share.NotificationShareAccepted, ProviderID: s.Id.OpaqueId, MeshProvider: "cesnet.cz", Notification: secret},
+		},
+		{
+			description:  "reshare",
+			notification: &share.Notification{Type: share.NotificationRequestReshare, ProviderID: s.Id.OpaqueId, MeshProvider: "cesnet.cz", Notification: secret},
+			expected:     errtypes.NotSupported("ocm: resharing is not supported"),
+		},
+		{
+			description:  "declined",
+			notification: &share.Notification{Type: share.NotificationShareDeclined, ProviderID: s.Id.OpaqueId, MeshProvider: "cesnet.cz", Notification: secret},
+		},
+		{
+			description:  "already removed",
+			notification: &share.Notification{Type: share.NotificationShareDeclined, ProviderID: s.Id.OpaqueId, MeshProvider: "cesnet.cz", Notification: secret},
+			expected:     errtypes.NotFound(s.Id.OpaqueId),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			if err := nm.HandleNotification(ctx, tt.notification); err != tt.expected {
+				t.Fatalf("expected error %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package share
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+
+	ocm "github.com/cs3org/go-cs3apis/cs3/sharing/ocm/v1beta1"
+	typespb "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
+)
+
+// The notification types defined by the OCM API.
+const (
+	NotificationShareAccepted  = "SHARE_ACCEPTED"
+	NotificationShareDeclined  = "SHARE_DECLINED"
+	NotificationShareUnshared  = "SHARE_UNSHARED"
+	NotificationRequestReshare = "REQUEST_RESHARE"
+)
+
+// Notification is sent to a remote OCM provider when a share changes state.
+// ProviderID identifies the share at the provider of the owner of the resource,
+// i.e. the id of the share for the owner and the remote share id for the recipient.
+type Notification struct {
+	Type         string               `json:"notificationType"`
+	ResourceType string               `json:"resourceType"`
+	ProviderID   string               `json:"providerId"`
+	MeshProvider string               `json:"meshProvider"`
+	Notification *NotificationDetails `json:"notification,omitempty"`
+}
+
+// NotificationDetails carries the optional data of a notification.
+type NotificationDetails struct {
+	SharedSecret string `json:"sharedSecret,omitempty"`
+	Message      string `json:"message,omitempty"`
+	// ShareWith is the user a reshare is requested for.
+	ShareWith string `json:"shareWith,omitempty"`
+}
+
+// NotificationManager is implemented by the share managers able to apply
+// the notifications received from remote OCM providers.
+type NotificationManager interface {
+	// HandleNotification applies a notification sent by the provider n.MeshProvider.
+	HandleNotification(ctx context.Context, n *Notification) error
+}
+
+// notificationOpaqueKey is the opaque entry carrying a notification in an UpdateOCMShareRequest,
+// as the CS3 APIs have no call dedicated to the notifications.
+const notificationOpaqueKey = "notification"
+
+// NotificationToOpaque encodes the notification as the opaque of an UpdateOCMShareRequest.
+func NotificationToOpaque(n *Notification) (*typespb.Opaque, error) {
+	b, err := json.Marshal(n)
+	if err != nil {
+		return nil, err
+	}
+	return &typespb.Opaque{
+		Map: map[string]*typespb.OpaqueEntry{
+			notificationOpaqueKey: {
+				Decoder: "json",
+				Value:   b,
+			},
+		},
+	}, nil
+}
+
+// NotificationFromOpaque decodes the notification carried by the opaque of an UpdateOCMShareRequest.
+// It returns nil if the opaque carries no notification.
+func NotificationFromOpaque(o *typespb.Opaque) (*Notification, error) {
+	e, ok := o.GetMap()[notificationOpaqueKey]
+	if !ok {
+		return nil, nil
+	}
+	if e.Decoder != "json" {
+		return nil, errtypes.BadRequest("ocm: unsupported notification decoder " + e.Decoder)
+	}
+	var n Notification
+	if err := json.Unmarshal(e.Value, &n); err != nil {
+		return nil, errtypes.BadRequest("ocm: invalid notification: " + err.Error())
+	}
+	return &n, nil
+}
+
+// RemoteShareID returns the id of a received share at the provider of its owner.
+func RemoteShareID(s *ocm.Share) string {
+	return opaqueValue(s, "remoteShareId")
+}
+
+// SharedSecret returns the secret used to access a received share.
+func SharedSecret(s *ocm.Share) string {
+	return opaqueValue(s, "token")
+}
+
+// Secret returns the secret shared with the recipient of a share, be it sent or received.
+func Secret(s *ocm.Share) string {
+	if p, err := GetProtocols(s); err == nil && p.SharedSecret() != "" {
+		return p.SharedSecret()
+	}
+	return SharedSecret(s)
+}
+
+// CheckSecret verifies that the notification carries the secret of the share it refers to,
+// which only the owner and the recipient know. As the owner's provider only keeps the digest
+// of the secret, the digest is accepted as well.
+func (n *Notification) CheckSecret(s *ocm.Share) error {
+	var given string
+	if n.Notification != nil {
+		given = n.Notification.SharedSecret
+	}
+	secret := Secret(s)
+	if secret == "" || given == "" || subtle.ConstantTimeCompare([]byte(SecretDigest(given)), []byte(SecretDigest(secret))) != 1 {
+		return errtypes.PermissionDenied("ocm: invalid shared secret for share " + n.ProviderID)
+	}
+	return nil
+}
+
+func opaqueValue(s *ocm.Share, key string) string {
+	if e, ok := s.GetGrantee().GetOpaque().GetMap()[key]; ok && e.Decoder == "plain" {
+		return string(e.Value)
+	}
+	return ""
+}
@@ -0,0 +1,93 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package share
+
+import (
+	"reflect"
+	"testing"
+
+	ocm "github.com/cs3org/go-cs3apis/cs3/sharing/ocm/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	typespb "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+)
+
+func TestNotificationOpaque(t *testing.T) {
+	n := &Notification{
+		Type:         NotificationShareDeclined,
+		ResourceType: "file",
+		ProviderID:   "share-id",
+		MeshProvider: "cesnet.cz",
+		Notification: &NotificationDetails{SharedSecret: "secret"},
+	}
+	o, err := NotificationToOpaque(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := NotificationFromOpaque(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, n) {
+		t.Fatalf("expected %+v, got %+v", n, got)
+	}
+
+	if got, err := NotificationFromOpaque(nil); got != nil || err != nil {
+		t.Fatalf("expected no notification, got %+v, %v", got, err)
+	}
+
+	o.Map[notificationOpaqueKey] = &typespb.OpaqueEntry{Decoder: "json", Value: []byte("{")}
+	if _, err := NotificationFromOpaque(o); err == nil {
+		t.Fatal("expected an error decoding an invalid notification")
+	}
+}
+
+func TestCheckSecret(t *testing.T) {
+	newShare := func(p *Protocols) *ocm.Share {
+		s := &ocm.Share{Grantee: &provider.Grantee{}}
+		if err := SetProtocols(s.Grantee, p); err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	received := newShare(&Protocols{WebDAV: &WebDAV{SharedSecret: "secret"}})
+	digested := &Protocols{WebDAV: &WebDAV{SharedSecret: "secret"}}
+	digested.DigestSecrets()
+	sent := newShare(digested)
+
+	tests := []struct {
+		share   *ocm.Share
+		secret  string
+		allowed bool
+	}{
+		{received, "secret", true},
+		{received, SecretDigest("secret"), true},
+		{received, "guessed", false},
+		{received, "", false},
+		{sent, "secret", true},
+		{sent, SecretDigest("secret"), true},
+		{sent, "guessed", false},
+		{newShare(&Protocols{WebDAV: &WebDAV{}}), "", false},
+	}
+	for i, tt := range tests {
+		n := &Notification{ProviderID: "share-id", Notification: &NotificationDetails{SharedSecret: tt.secret}}
+		if err := n.CheckSecret(tt.share); (err == nil) != tt.allowed {
+			t.Errorf("%d: expected allowed=%t, got %v", i, tt.allowed, err)
+		}
+	}
+}
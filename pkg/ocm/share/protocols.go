@@ -0,0 +1,264 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package share
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	ocm "github.com/cs3org/go-cs3apis/cs3/sharing/ocm/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	typespb "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+)
+
+// The protocols a share can be accessed with.
+const (
+	WebDAVProtocol = "webdav"
+	WebAppProtocol = "webapp"
+	DataTxProtocol = "datatx"
+	// MultiProtocol is the protocol name of the OCM payloads
+	// carrying more than one protocol.
+	MultiProtocol = "multi"
+)
+
+// The permissions granted through the webdav protocol.
+const (
+	PermissionRead  = "read"
+	PermissionWrite = "write"
+	PermissionShare = "share"
+)
+
+// Protocols holds the ways a share can be accessed by the recipient.
+// At least one of them is set.
+type Protocols struct {
+	WebDAV *WebDAV `json:"webdav,omitempty"`
+	WebApp *WebApp `json:"webapp,omitempty"`
+	DataTx *DataTx `json:"datatx,omitempty"`
+}
+
+// WebDAV gives access to the shared resource through webdav.
+type WebDAV struct {
+	SharedSecret string   `json:"sharedSecret"`
+	Permissions  []string `json:"permissions"`
+	URI          string   `json:"uri,omitempty"`
+}
+
+// WebApp allows to open the shared resource in the editor of the
+// provider of the owner.
+type WebApp struct {
+	URITemplate string `json:"uriTemplate"`
+	ViewMode    string `json:"viewMode"`
+}
+
+// DataTx allows to transfer a copy of the shared resource
+// to the provider of the recipient.
+type DataTx struct {
+	SharedSecret string `json:"sharedSecret"`
+	SourceURI    string `json:"srcUri,omitempty"`
+	Size         uint64 `json:"size,omitempty"`
+}
+
+// WebDAVPermissions returns the webdav permissions matching
+// the given resource permissions.
+func WebDAVPermissions(p *provider.ResourcePermissions) []string {
+	perms := []string{}
+	if p.GetStat() || p.GetInitiateFileDownload() || p.GetListContainer() {
+		perms = append(perms, PermissionRead)
+	}
+	if p.GetInitiateFileUpload() || p.GetCreateContainer() || p.GetDelete() || p.GetMove() {
+		perms = append(perms, PermissionWrite)
+	}
+	if p.GetAddGrant() {
+		perms = append(perms, PermissionShare)
+	}
+	return perms
+}
+
+// Names returns the names of the protocols that are set.
+func (p *Protocols) Names() []string {
+	var names []string
+	if p.WebDAV != nil {
+		names = append(names, WebDAVProtocol)
+	}
+	if p.WebApp != nil {
+		names = append(names, WebAppProtocol)
+	}
+	if p.DataTx != nil {
+		names = append(names, DataTxProtocol)
+	}
+	return names
+}
+
+// Has returns whether the protocol with the given name is set.
+func (p *Protocols) Has(name string) bool {
+	switch name {
+	case WebDAVProtocol:
+		return p.WebDAV != nil
+	case WebAppProtocol:
+		return p.WebApp != nil
+	case DataTxProtocol:
+		return p.DataTx != nil
+	}
+	return false
+}
+
+// ShareType returns the type of a share offered with these protocols:
+// shares that can only be transferred are transfer shares.
+func (p *Protocols) ShareType() ocm.Share_ShareType {
+	if p.DataTx != nil && p.WebDAV == nil && p.WebApp == nil {
+		return ocm.Share_SHARE_TYPE_TRANSFER
+	}
+	return ocm.Share_SHARE_TYPE_REGULAR
+}
+
+// SharedSecret returns the secret used to access the share,
+// taken from the webdav or else the datatx protocol.
+func (p *Protocols) SharedSecret() string {
+	if p.WebDAV != nil && p.WebDAV.SharedSecret != "" {
+		return p.WebDAV.SharedSecret
+	}
+	if p.DataTx != nil {
+		return p.DataTx.SharedSecret
+	}
+	return ""
+}
+
+// SetSharedSecret sets the secret of the protocols not having one yet.
+func (p *Protocols) SetSharedSecret(secret string) {
+	if p.WebDAV != nil && p.WebDAV.SharedSecret == "" {
+		p.WebDAV.SharedSecret = secret
+	}
+	if p.DataTx != nil && p.DataTx.SharedSecret == "" {
+		p.DataTx.SharedSecret = secret
+	}
+}
+
+// secretDigestPrefix marks the shared secrets stored as a digest.
+const secretDigestPrefix = "sha256:"
+
+// SecretDigest returns the digest of a shared secret, which is the secret itself if already digested.
+func SecretDigest(secret string) string {
+	if strings.HasPrefix(secret, secretDigestPrefix) {
+		return secret
+	}
+	sum := sha256.Sum256([]byte(secret))
+	return secretDigestPrefix + hex.EncodeToString(sum[:])
+}
+
+// DigestSecrets replaces the shared secrets with their digest. The owner's provider
+// stores the protocols of the shares it sent that way, as the secret is the access
+// token of the owner: the digest is enough to verify the notifications of the recipient.
+func (p *Protocols) DigestSecrets() {
+	if p.WebDAV != nil && p.WebDAV.SharedSecret != "" {
+		p.WebDAV.SharedSecret = SecretDigest(p.WebDAV.SharedSecret)
+	}
+	if p.DataTx != nil && p.DataTx.SharedSecret != "" {
+		p.DataTx.SharedSecret = SecretDigest(p.DataTx.SharedSecret)
+	}
+}
+
+// Payload returns the protocol object of the OCM share creation request.
+// The options carry the shared secret as well, so that providers only
+// supporting a single protocol can still access the share.
+func (p *Protocols) Payload() map[string]interface{} {
+	payload := map[string]interface{}{
+		"name": MultiProtocol,
+		"options": map[string]string{
+			"sharedSecret": p.SharedSecret(),
+		},
+	}
+	if p.WebDAV != nil {
+		payload[WebDAVProtocol] = p.WebDAV
+	}
+	if p.WebApp != nil {
+		payload[WebAppProtocol] = p.WebApp
+	}
+	if p.DataTx != nil {
+		payload[DataTxProtocol] = p.DataTx
+	}
+	return payload
+}
+
+// SetProtocols stores the protocols of a share in the opaque of its grantee,
+// since the share itself does not have an opaque.
+func SetProtocols(g *provider.Grantee, p *Protocols) error {
+	val, err := json.Marshal(p)
+	if err != nil {
+		return errors.Wrap(err, "error encoding protocols")
+	}
+	if g.Opaque == nil || g.Opaque.Map == nil {
+		g.Opaque = &typespb.Opaque{Map: map[string]*typespb.OpaqueEntry{}}
+	}
+	g.Opaque.Map["protocols"] = &typespb.OpaqueEntry{
+		Decoder: "json",
+		Value:   val,
+	}
+	return nil
+}
+
+// GetProtocols returns the protocols a share can be accessed with.
+// Shares created before multiple protocols were supported
+// only have the shared secret, their protocol is derived from the share type.
+func GetProtocols(s *ocm.Share) (*Protocols, error) {
+	e, ok := s.GetGrantee().GetOpaque().GetMap()["protocols"]
+	if !ok {
+		if s.GetShareType() == ocm.Share_SHARE_TYPE_TRANSFER {
+			return &Protocols{DataTx: &DataTx{SharedSecret: SharedSecret(s)}}, nil
+		}
+		return &Protocols{WebDAV: &WebDAV{SharedSecret: SharedSecret(s)}}, nil
+	}
+	if e.Decoder != "json" {
+		return nil, errors.New("protocols: opaque entry decoder not recognized: " + e.Decoder)
+	}
+	var p Protocols
+	if err := json.Unmarshal(e.Value, &p); err != nil {
+		return nil, errors.Wrap(err, "error decoding protocols")
+	}
+	return &p, nil
+}
+
+// RemoveSecrets removes the shared secrets from the protocols stored in the grantee of a share,
+// so that the share can be handed out without giving away the access to the resource.
+// The grantee is copied, leaving the one of the given share untouched.
+func RemoveSecrets(s *ocm.Share) (*ocm.Share, error) {
+	if s.GetGrantee().GetOpaque() == nil {
+		return s, nil
+	}
+	p, err := GetProtocols(s)
+	if err != nil {
+		return nil, err
+	}
+	if p.WebDAV != nil {
+		p.WebDAV.SharedSecret = ""
+	}
+	if p.DataTx != nil {
+		p.DataTx.SharedSecret = ""
+	}
+
+	c := proto.Clone(s).(*ocm.Share)
+	delete(c.Grantee.Opaque.Map, "token")
+	if err := SetProtocols(c.Grantee, p); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
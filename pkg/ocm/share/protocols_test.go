@@ -0,0 +1,133 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package share
+
+import (
+	"reflect"
+	"testing"
+
+	ocm "github.com/cs3org/go-cs3apis/cs3/sharing/ocm/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	typespb "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+)
+
+func TestProtocols(t *testing.T) {
+	p := &Protocols{
+		WebDAV: &WebDAV{Permissions: WebDAVPermissions(&provider.ResourcePermissions{Stat: true, InitiateFileUpload: true})},
+		DataTx: &DataTx{},
+	}
+	p.SetSharedSecret("secret")
+
+	if names := p.Names(); !reflect.DeepEqual(names, []string{WebDAVProtocol, DataTxProtocol}) {
+		t.Fatalf("unexpected protocol names %v", names)
+	}
+	if !reflect.DeepEqual(p.WebDAV.Permissions, []string{PermissionRead, PermissionWrite}) {
+		t.Fatalf("unexpected webdav permissions %v", p.WebDAV.Permissions)
+	}
+	if p.ShareType() != ocm.Share_SHARE_TYPE_REGULAR {
+		t.Fatal("expected a share also accessible through webdav to be a regular share")
+	}
+	if (&Protocols{DataTx: p.DataTx}).ShareType() != ocm.Share_SHARE_TYPE_TRANSFER {
+		t.Fatal("expected a share only offered with datatx to be a transfer share")
+	}
+
+	payload := p.Payload()
+	if payload["name"] != MultiProtocol || payload[WebDAVProtocol] != p.WebDAV || payload[DataTxProtocol] != p.DataTx {
+		t.Fatalf("unexpected payload %v", payload)
+	}
+	if _, ok := payload[WebAppProtocol]; ok {
+		t.Fatal("expected the webapp protocol not to be sent")
+	}
+
+	g := &provider.Grantee{}
+	if err := SetProtocols(g, p); err != nil {
+		t.Fatal(err)
+	}
+	got, err := GetProtocols(&ocm.Share{Grantee: g})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, p) {
+		t.Fatalf("expected %+v, got %+v", p, got)
+	}
+}
+
+func TestGetProtocolsLegacy(t *testing.T) {
+	g := &provider.Grantee{Opaque: &typespb.Opaque{Map: map[string]*typespb.OpaqueEntry{
+		"token": {Decoder: "plain", Value: []byte("secret")},
+	}}}
+
+	p, err := GetProtocols(&ocm.Share{Grantee: g})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.WebDAV == nil || p.WebDAV.SharedSecret != "secret" || p.DataTx != nil {
+		t.Fatalf("expected a webdav share, got %+v", p)
+	}
+
+	p, err = GetProtocols(&ocm.Share{Grantee: g, ShareType: ocm.Share_SHARE_TYPE_TRANSFER})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.DataTx == nil || p.DataTx.SharedSecret != "secret" || p.WebDAV != nil {
+		t.Fatalf("expected a datatx share, got %+v", p)
+	}
+}
+
+func TestRemoveSecrets(t *testing.T) {
+	p := &Protocols{
+		WebDAV: &WebDAV{Permissions: []string{PermissionRead}},
+		WebApp: &WebApp{URITemplate: "https://cloud.example.org/open/1", ViewMode: PermissionRead},
+		DataTx: &DataTx{},
+	}
+	p.SetSharedSecret("secret")
+	g := &provider.Grantee{Opaque: &typespb.Opaque{Map: map[string]*typespb.OpaqueEntry{
+		"token": {Decoder: "plain", Value: []byte("secret")},
+	}}}
+	if err := SetProtocols(g, p); err != nil {
+		t.Fatal(err)
+	}
+	s := &ocm.Share{Grantee: g}
+
+	stripped, err := RemoveSecrets(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := stripped.Grantee.Opaque.Map["token"]; ok {
+		t.Fatal("expected the token to be removed")
+	}
+	got, err := GetProtocols(stripped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.WebDAV.SharedSecret != "" || got.DataTx.SharedSecret != "" {
+		t.Fatalf("expected no shared secret, got %+v %+v", got.WebDAV, got.DataTx)
+	}
+	if !reflect.DeepEqual(got.WebDAV.Permissions, p.WebDAV.Permissions) || !reflect.DeepEqual(got.WebApp, p.WebApp) {
+		t.Fatalf("expected the other fields to be kept, got %+v", got)
+	}
+
+	// the original share is left untouched
+	if orig, _ := GetProtocols(s); orig.SharedSecret() != "secret" {
+		t.Fatal("expected the original share to keep its secret")
+	}
+	if _, ok := s.Grantee.Opaque.Map["token"]; !ok {
+		t.Fatal("expected the original share to keep its token")
+	}
+}
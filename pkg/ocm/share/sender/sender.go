@@ -19,6 +19,7 @@
 package sender
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -29,11 +30,16 @@ import (
 	"time"
 
 	ocmprovider "github.com/cs3org/go-cs3apis/cs3/ocm/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/ocm/share"
+	"github.com/cs3org/reva/pkg/ocm/signature"
 	"github.com/cs3org/reva/pkg/rhttp"
 	"github.com/pkg/errors"
 )
 
-const createOCMCoreShareEndpoint = "shares"
+const (
+	createOCMCoreShareEndpoint = "shares"
+	notificationsEndpoint      = "notifications"
+)
 
 func getOCMEndpoint(originProvider *ocmprovider.ProviderInfo) (string, error) {
 	for _, s := range originProvider.Services {
@@ -46,8 +52,19 @@ func getOCMEndpoint(originProvider *ocmprovider.ProviderInfo) (string, error) {
 
 // Send executes the POST to the OCM shares endpoint to create the share at the
 // remote site.
-func Send(requestBodyMap map[string]interface{}, pi *ocmprovider.ProviderInfo) error {
-	requestBody, err := json.Marshal(requestBodyMap)
+func Send(ctx context.Context, requestBodyMap map[string]interface{}, pi *ocmprovider.ProviderInfo) error {
+	return post(ctx, createOCMCoreShareEndpoint, requestBodyMap, pi)
+}
+
+// SendNotification executes the POST to the OCM notifications endpoint to inform
+// the remote site that a share changed state.
+func SendNotification(ctx context.Context, n *share.Notification, pi *ocmprovider.ProviderInfo) error {
+	return post(ctx, notificationsEndpoint, n, pi)
+}
+
+// post sends body to the endpoint of the provider, signed with the signer stored in ctx.
+func post(ctx context.Context, endpoint string, body interface{}, pi *ocmprovider.ProviderInfo) error {
+	requestBody, err := json.Marshal(body)
 	if err != nil {
 		err = errors.Wrap(err, "error marshalling request body")
 		return err
@@ -60,14 +77,17 @@ func Send(requestBodyMap map[string]interface{}, pi *ocmprovider.ProviderInfo) e
 	if err != nil {
 		return err
 	}
-	u.Path = path.Join(u.Path, createOCMCoreShareEndpoint)
+	u.Path = path.Join(u.Path, endpoint)
 	recipientURL := u.String()
 
-	req, err := http.NewRequest(http.MethodPost, recipientURL, strings.NewReader(string(requestBody)))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, recipientURL, strings.NewReader(string(requestBody)))
 	if err != nil {
 		return errors.Wrap(err, "sender: error framing post request")
 	}
 	req.Header.Set("Content-Type", "application/json")
+	if err := signature.Sign(req, requestBody); err != nil {
+		return errors.Wrap(err, "sender: error signing post request")
+	}
 	client := rhttp.GetHTTPClient(
 		rhttp.Timeout(5 * time.Second),
 	)
@@ -85,7 +105,7 @@ func Send(requestBodyMap map[string]interface{}, pi *ocmprovider.ProviderInfo) e
 			e = errors.Wrap(e, "sender: error reading request body")
 			return e
 		}
-		err = errors.Wrap(fmt.Errorf("%s: %s", resp.Status, string(respBody)), "sender: error sending create ocm core share post request")
+		err = errors.Wrap(fmt.Errorf("%s: %s", resp.Status, string(respBody)), "sender: error sending post request to "+endpoint)
 		return err
 	}
 	return nil
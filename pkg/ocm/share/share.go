@@ -30,9 +30,9 @@ import (
 
 // Manager is the interface that manipulates the OCM shares.
 type Manager interface {
-	// Create a new share in fn with the given acl.
+	// Create a new share in fn with the given acl, accessible with the given protocols.
 	Share(ctx context.Context, md *provider.ResourceId, g *ocm.ShareGrant, name string,
-		pi *ocmprovider.ProviderInfo, pm string, owner *userpb.UserId, token string, st ocm.Share_ShareType) (*ocm.Share, error)
+		pi *ocmprovider.ProviderInfo, p *Protocols, owner *userpb.UserId, token string, st ocm.Share_ShareType) (*ocm.Share, error)
 
 	// GetShare gets the information for a share by the given ref.
 	GetShare(ctx context.Context, ref *ocm.ShareReference) (*ocm.Share, error)
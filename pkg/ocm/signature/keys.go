@@ -0,0 +1,177 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package signature
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// PublicKey is the public key of a provider, as published in its OCM discovery document.
+type PublicKey struct {
+	KeyID        string `json:"keyId" xml:"keyId"`
+	PublicKeyPem string `json:"publicKeyPem" xml:"publicKeyPem"`
+}
+
+// discovery is the subset of the OCM discovery document holding the public key.
+type discovery struct {
+	PublicKey *PublicKey `json:"publicKey"`
+}
+
+type signerKey struct{}
+
+// ContextSetSigner stores in ctx the signer of the outgoing OCM requests.
+func ContextSetSigner(ctx context.Context, s *Signer) context.Context {
+	if s == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, signerKey{}, s)
+}
+
+// Sign signs req with the signer stored in its context. Requests are sent
+// unsigned when the service sending them has no signing key configured.
+func Sign(req *http.Request, body []byte) error {
+	s, ok := req.Context().Value(signerKey{}).(*Signer)
+	if !ok {
+		return nil
+	}
+	return s.Sign(req, body)
+}
+
+// KeyBelongsTo returns whether the key identified by keyID is published by the given domain.
+func KeyBelongsTo(keyID, domain string) bool {
+	u, err := url.Parse(keyID)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Hostname(), hostname(domain))
+}
+
+func hostname(domain string) string {
+	if u, err := url.Parse(domain); err == nil && u.Host != "" {
+		return u.Hostname()
+	}
+	if host, _, err := net.SplitHostPort(domain); err == nil {
+		return host
+	}
+	return domain
+}
+
+// parseKeyID checks that keyID is the https URL of a discovery document.
+func parseKeyID(keyID string) (*url.URL, error) {
+	u, err := url.Parse(keyID)
+	if err != nil || u.Host == "" || u.Scheme != "https" {
+		return nil, fmt.Errorf("signature: invalid key id %q, expected an https URL", keyID)
+	}
+	return u, nil
+}
+
+// isPublic returns whether ip is a globally routable unicast address.
+func isPublic(ip net.IP) bool {
+	return ip != nil && ip.IsGlobalUnicast() && !ip.IsPrivate() && !ip.IsLoopback() && !ip.IsLinkLocalUnicast()
+}
+
+// checkAddress refuses to connect to internal addresses when fetching the keys,
+// so that the key ids sent by unauthenticated clients cannot reach internal services.
+func (v *Verifier) checkAddress(network, address string, _ syscall.RawConn) error {
+	if v.allowInternal {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if !isPublic(net.ParseIP(host)) {
+		return fmt.Errorf("signature: refusing to fetch a public key from the internal address %s", host)
+	}
+	return nil
+}
+
+func (v *Verifier) publicKey(ctx context.Context, keyID string) (crypto.PublicKey, error) {
+	if cached, ok := v.keys.Get(keyID); ok && v.now().Before(cached.(cachedKey).expires) {
+		return cached.(cachedKey).key, nil
+	}
+
+	key, err := v.fetchPublicKey(ctx, keyID)
+	if err != nil {
+		return nil, err
+	}
+	v.keys.Add(keyID, cachedKey{key: key, expires: v.now().Add(v.ttl)})
+	return key, nil
+}
+
+// fetchPublicKey gets the key from the discovery document located at the keyID URL.
+func (v *Verifier) fetchPublicKey(ctx context.Context, keyID string) (crypto.PublicKey, error) {
+	u, err := parseKeyID(keyID)
+	if err != nil {
+		return nil, err
+	}
+	u.Fragment = ""
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "signature: error fetching public key")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("signature: error fetching public key: %s", resp.Status)
+	}
+
+	var d discovery
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&d); err != nil {
+		return nil, errors.Wrap(err, "signature: error decoding discovery document")
+	}
+	if d.PublicKey == nil || d.PublicKey.KeyID != keyID {
+		return nil, fmt.Errorf("signature: key %s not published by %s", keyID, u.Host)
+	}
+	return ParsePublicKey([]byte(d.PublicKey.PublicKeyPem))
+}
+
+// ParsePublicKey parses an Ed25519 or RSA public key in PEM format.
+func ParsePublicKey(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("signature: no PEM data found in public key")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "signature: error parsing public key")
+	}
+	if _, err := algorithm(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
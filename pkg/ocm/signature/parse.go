@@ -0,0 +1,143 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package signature
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// signatureInput is a member of the Signature-Input header.
+type signatureInput struct {
+	label      string
+	components []string
+	// params is the serialized value of the member, as covered by @signature-params
+	params  string
+	created int64
+	keyID   string
+	alg     string
+}
+
+// parseSignatureInput parses the Signature-Input dictionary, returning the
+// signature labeled as the ones created by this package, or the first one.
+func parseSignatureInput(header string) (*signatureInput, error) {
+	members := splitOutsideQuotes(header, ',')
+	if header == "" || len(members) == 0 {
+		return nil, errors.New("signature: missing signature input")
+	}
+
+	var chosen string
+	for _, m := range members {
+		m = strings.TrimSpace(m)
+		if chosen == "" || strings.HasPrefix(m, label+"=") {
+			chosen = m
+		}
+	}
+
+	i := strings.IndexByte(chosen, '=')
+	if i <= 0 {
+		return nil, errors.New("signature: malformed signature input")
+	}
+	in := &signatureInput{label: chosen[:i], params: chosen[i+1:]}
+
+	if !strings.HasPrefix(in.params, "(") {
+		return nil, errors.New("signature: malformed signature input")
+	}
+	end := strings.IndexByte(in.params, ')')
+	if end < 0 {
+		return nil, errors.New("signature: malformed signature input")
+	}
+	for _, c := range strings.Fields(in.params[1:end]) {
+		c, err := strconv.Unquote(c)
+		if err != nil {
+			return nil, errors.New("signature: malformed component in signature input")
+		}
+		in.components = append(in.components, strings.ToLower(c))
+	}
+
+	for _, p := range splitOutsideQuotes(in.params[end+1:], ';') {
+		if p == "" {
+			continue
+		}
+		kv := strings.SplitN(p, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		var err error
+		switch kv[0] {
+		case "created":
+			in.created, err = strconv.ParseInt(kv[1], 10, 64)
+		case "keyid":
+			in.keyID, err = strconv.Unquote(kv[1])
+		case "alg":
+			in.alg, err = strconv.Unquote(kv[1])
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "signature: malformed parameter "+kv[0])
+		}
+	}
+	if in.keyID == "" {
+		return nil, errors.New("signature: missing key id")
+	}
+	return in, nil
+}
+
+// parseSignature returns the signature with the given label from the Signature header.
+func parseSignature(header, label string) ([]byte, error) {
+	for _, m := range splitOutsideQuotes(header, ',') {
+		m = strings.TrimSpace(m)
+		if !strings.HasPrefix(m, label+"=") {
+			continue
+		}
+		v := strings.TrimPrefix(m, label+"=")
+		if len(v) < 2 || v[0] != ':' || v[len(v)-1] != ':' {
+			return nil, errors.New("signature: malformed signature")
+		}
+		sig, err := base64.StdEncoding.DecodeString(v[1 : len(v)-1])
+		if err != nil {
+			return nil, errors.Wrap(err, "signature: malformed signature")
+		}
+		return sig, nil
+	}
+	return nil, errors.New("signature: missing signature " + label)
+}
+
+// splitOutsideQuotes splits s around sep, ignoring the separators within quoted strings.
+func splitOutsideQuotes(s string, sep byte) []string {
+	var (
+		parts    []string
+		start    int
+		inQuotes bool
+	)
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && inQuotes:
+			i++
+		case s[i] == '"':
+			inQuotes = !inQuotes
+		case s[i] == sep && !inQuotes:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
@@ -0,0 +1,365 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package signature implements HTTP message signatures (RFC 9421) for the
+// requests exchanged between OCM providers. Outgoing requests are signed
+// with the private key of the provider, and incoming requests are verified
+// with the public key published by the sender in its OCM discovery document.
+package signature
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/pkg/errors"
+)
+
+const (
+	// HeaderSignature is the header carrying the signature.
+	HeaderSignature = "Signature"
+	// HeaderSignatureInput is the header carrying the signed components and parameters.
+	HeaderSignatureInput = "Signature-Input"
+	// HeaderContentDigest is the header carrying the digest of the body (RFC 9530).
+	HeaderContentDigest = "Content-Digest"
+
+	// AlgorithmEd25519 is the EdDSA algorithm using curve edwards25519.
+	AlgorithmEd25519 = "ed25519"
+	// AlgorithmRSA is the RSASSA-PKCS1-v1_5 algorithm using SHA-256.
+	AlgorithmRSA = "rsa-v1_5-sha256"
+
+	label = "ocm"
+
+	// maxCachedKeys bounds the number of public keys kept in memory.
+	maxCachedKeys = 1024
+)
+
+// components are the components covered by the signatures of the outgoing requests.
+var components = []string{"@method", "@authority", "@path", "content-type", "content-digest"}
+
+// Signer signs HTTP requests.
+type Signer struct {
+	keyID string
+	key   crypto.Signer
+	now   func() time.Time
+}
+
+// NewSigner returns a signer using the private key in PEM format stored in keyFile.
+// The keyID is the https URL of the OCM discovery document publishing the public key.
+func NewSigner(keyID, keyFile string) (*Signer, error) {
+	if _, err := parseKeyID(keyID); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "signature: error reading private key")
+	}
+	key, err := ParsePrivateKey(data)
+	if err != nil {
+		return nil, err
+	}
+	return &Signer{keyID: keyID, key: key, now: time.Now}, nil
+}
+
+// ParsePrivateKey parses an Ed25519 or RSA private key in PEM format,
+// either PKCS#8 or PKCS#1 encoded.
+func ParsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("signature: no PEM data found in private key")
+	}
+	if block.Type == "RSA PRIVATE KEY" {
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "signature: error parsing private key")
+	}
+	switch k := key.(type) {
+	case ed25519.PrivateKey:
+		return k, nil
+	case *rsa.PrivateKey:
+		return k, nil
+	default:
+		return nil, fmt.Errorf("signature: unsupported private key type %T", key)
+	}
+}
+
+// KeyID returns the id of the key used by the signer.
+func (s *Signer) KeyID() string {
+	return s.keyID
+}
+
+// PublicKeyPEM returns the public key of the signer in PEM format.
+func (s *Signer) PublicKeyPEM() (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(s.key.Public())
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), nil
+}
+
+// Sign signs req, whose body is body. The Content-Type header must already be set,
+// as it is covered by the signature together with the digest of the body.
+func (s *Signer) Sign(req *http.Request, body []byte) error {
+	alg, err := algorithm(s.key.Public())
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set(HeaderContentDigest, ContentDigest(body))
+
+	params := fmt.Sprintf("(%s);created=%d;keyid=%q;alg=%q", quoteAll(components), s.now().Unix(), s.keyID, alg)
+	base, err := signatureBase(components, params, requestComponents(req, req.URL.Host, req.URL.EscapedPath(), req.URL.RawQuery))
+	if err != nil {
+		return err
+	}
+
+	var sig []byte
+	switch alg {
+	case AlgorithmEd25519:
+		sig, err = s.key.Sign(rand.Reader, []byte(base), crypto.Hash(0))
+	case AlgorithmRSA:
+		digest := sha256.Sum256([]byte(base))
+		sig, err = s.key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}
+	if err != nil {
+		return errors.Wrap(err, "signature: error signing request")
+	}
+
+	req.Header.Set(HeaderSignatureInput, label+"="+params)
+	req.Header.Set(HeaderSignature, label+"=:"+base64.StdEncoding.EncodeToString(sig)+":")
+	return nil
+}
+
+// ContentDigest returns the value of the Content-Digest header for body.
+func ContentDigest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return "sha-256=:" + base64.StdEncoding.EncodeToString(sum[:]) + ":"
+}
+
+func algorithm(key crypto.PublicKey) (string, error) {
+	switch key.(type) {
+	case ed25519.PublicKey:
+		return AlgorithmEd25519, nil
+	case *rsa.PublicKey:
+		return AlgorithmRSA, nil
+	default:
+		return "", fmt.Errorf("signature: unsupported key type %T", key)
+	}
+}
+
+func quoteAll(ss []string) string {
+	quoted := make([]string, 0, len(ss))
+	for _, s := range ss {
+		quoted = append(quoted, strconv.Quote(s))
+	}
+	return strings.Join(quoted, " ")
+}
+
+// requestComponents returns a function resolving the value of the components of a request.
+func requestComponents(req *http.Request, authority, path, query string) func(string) (string, error) {
+	return func(c string) (string, error) {
+		switch c {
+		case "@method":
+			return req.Method, nil
+		case "@authority":
+			return strings.ToLower(authority), nil
+		case "@path":
+			if path == "" {
+				return "/", nil
+			}
+			return path, nil
+		case "@query":
+			return "?" + query, nil
+		}
+		if strings.HasPrefix(c, "@") {
+			return "", fmt.Errorf("signature: unsupported component %s", c)
+		}
+		values := req.Header.Values(c)
+		if len(values) == 0 {
+			return "", fmt.Errorf("signature: missing header %s", c)
+		}
+		trimmed := make([]string, 0, len(values))
+		for _, v := range values {
+			trimmed = append(trimmed, strings.TrimSpace(v))
+		}
+		return strings.Join(trimmed, ", "), nil
+	}
+}
+
+// signatureBase builds the signature base of RFC 9421, section 2.5.
+func signatureBase(components []string, params string, value func(string) (string, error)) (string, error) {
+	var b strings.Builder
+	for _, c := range components {
+		v, err := value(c)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "%q: %s\n", c, v)
+	}
+	fmt.Fprintf(&b, "%q: %s", "@signature-params", params)
+	return b.String(), nil
+}
+
+// Verifier verifies the signatures of incoming HTTP requests, fetching the
+// public keys of the senders from their OCM discovery documents.
+type Verifier struct {
+	client *http.Client
+	maxAge time.Duration
+	ttl    time.Duration
+	now    func() time.Time
+	keys   *lru.Cache
+
+	// allowInternal lets the tests fetch the keys from local servers.
+	allowInternal bool
+}
+
+type cachedKey struct {
+	key     crypto.PublicKey
+	expires time.Time
+}
+
+// NewVerifier returns a verifier fetching the public keys with the TLS settings
+// and timeout of client. Signatures created more than maxAge ago are refused.
+func NewVerifier(client *http.Client, maxAge time.Duration) *Verifier {
+	keys, _ := lru.New(maxCachedKeys)
+	v := &Verifier{
+		maxAge: maxAge,
+		ttl:    time.Hour,
+		now:    time.Now,
+		keys:   keys,
+	}
+
+	// the connections are checked once resolved, and never go through a proxy
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok {
+		transport = http.DefaultTransport.(*http.Transport)
+	}
+	transport = transport.Clone()
+	transport.Proxy = nil
+	transport.DialContext = (&net.Dialer{Timeout: 10 * time.Second, Control: v.checkAddress}).DialContext
+	v.client = &http.Client{Transport: transport, Timeout: client.Timeout}
+	return v
+}
+
+// IsSigned returns whether the request carries a signature.
+func IsSigned(r *http.Request) bool {
+	return r.Header.Get(HeaderSignature) != "" || r.Header.Get(HeaderSignatureInput) != ""
+}
+
+// Verify verifies that r was signed by the provider at domain and returns the id
+// of the key that signed it. The key must be published by that provider, which is
+// checked before fetching it. The body of the request is read to check its digest,
+// and replaced so that it can be read again by the handlers.
+func (v *Verifier) Verify(r *http.Request, domain string) (string, error) {
+	input, err := parseSignatureInput(r.Header.Get(HeaderSignatureInput))
+	if err != nil {
+		return "", err
+	}
+	sig, err := parseSignature(r.Header.Get(HeaderSignature), input.label)
+	if err != nil {
+		return "", err
+	}
+	if _, err := parseKeyID(input.keyID); err != nil {
+		return "", err
+	}
+	if domain == "" || !KeyBelongsTo(input.keyID, domain) {
+		return "", fmt.Errorf("signature: key %s is not published by %s", input.keyID, domain)
+	}
+
+	if !contains(input.components, "content-digest") {
+		return "", errors.New("signature: the digest of the body is not signed")
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return "", errors.Wrap(err, "signature: error reading body")
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if r.Header.Get(HeaderContentDigest) != ContentDigest(body) {
+		return "", errors.New("signature: digest mismatch")
+	}
+
+	if input.created == 0 {
+		return "", errors.New("signature: missing creation time")
+	}
+	created := time.Unix(input.created, 0)
+	if now := v.now(); now.Sub(created) > v.maxAge || created.Sub(now) > time.Minute {
+		return "", errors.New("signature: signature expired or created in the future")
+	}
+
+	key, err := v.publicKey(r.Context(), input.keyID)
+	if err != nil {
+		return "", err
+	}
+	alg, err := algorithm(key)
+	if err != nil {
+		return "", err
+	}
+	if input.alg != "" && input.alg != alg {
+		return "", fmt.Errorf("signature: algorithm %s does not match the key", input.alg)
+	}
+
+	// the path and query are taken from the original request URI,
+	// as the handlers may have shifted the path of the URL
+	path, query := r.RequestURI, ""
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path, query = path[:i], path[i+1:]
+	}
+	base, err := signatureBase(input.components, input.params, requestComponents(r, r.Host, path, query))
+	if err != nil {
+		return "", err
+	}
+
+	switch k := key.(type) {
+	case ed25519.PublicKey:
+		if !ed25519.Verify(k, []byte(base), sig) {
+			return "", errors.New("signature: invalid signature")
+		}
+	case *rsa.PublicKey:
+		digest := sha256.Sum256([]byte(base))
+		if err := rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], sig); err != nil {
+			return "", errors.New("signature: invalid signature")
+		}
+	}
+	return input.keyID, nil
+}
+
+func contains(ss []string, s string) bool {
+	for _, e := range ss {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
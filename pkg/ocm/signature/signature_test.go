@@ -0,0 +1,250 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package signature
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeKey(t *testing.T, key crypto.Signer) string {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+// domain is the domain of the test providers.
+const domain = "127.0.0.1"
+
+// newProvider serves a discovery document publishing the public key of a new signer.
+func newProvider(t *testing.T, key crypto.Signer) (*Signer, *http.Client) {
+	var signer *Signer
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		publicKey, _ := signer.PublicKeyPEM()
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"enabled":   true,
+			"publicKey": PublicKey{KeyID: signer.KeyID(), PublicKeyPem: publicKey},
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	signer, err := NewSigner(srv.URL+"/ocm/ocm-provider#signature", writeKey(t, key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer, srv.Client()
+}
+
+// newVerifier returns a verifier allowed to fetch the keys of the local test providers.
+func newVerifier(client *http.Client) *Verifier {
+	v := NewVerifier(client, 5*time.Minute)
+	v.allowInternal = true
+	return v
+}
+
+// newRequest returns an outgoing request and the same request as received by the server.
+func newRequest(t *testing.T, signer *Signer, body string) (*http.Request, func() *http.Request) {
+	out, err := http.NewRequest(http.MethodPost, "https://Cloud.Example.org/ocm/shares?x=1", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out.Header.Set("Content-Type", "application/json")
+	if err := signer.Sign(out, []byte(body)); err != nil {
+		t.Fatal(err)
+	}
+
+	return out, func() *http.Request {
+		in := httptest.NewRequest(http.MethodPost, "/ocm/shares?x=1", strings.NewReader(body))
+		in.Host = "cloud.example.org"
+		in.Header = out.Header.Clone()
+		// the path shifted by the handlers must not matter
+		in.URL.Path = "/"
+		return in
+	}
+}
+
+func TestSignAndVerify(t *testing.T) {
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for alg, key := range map[string]crypto.Signer{AlgorithmEd25519: edKey, AlgorithmRSA: rsaKey} {
+		t.Run(alg, func(t *testing.T) {
+			signer, client := newProvider(t, key)
+			v := newVerifier(client)
+
+			out, received := newRequest(t, signer, `{"name":"file"}`)
+			if !strings.Contains(out.Header.Get(HeaderSignatureInput), `alg="`+alg+`"`) {
+				t.Fatalf("unexpected signature input %s", out.Header.Get(HeaderSignatureInput))
+			}
+
+			keyID, err := v.Verify(received(), domain)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if keyID != signer.KeyID() {
+				t.Fatalf("expected key id %s, got %s", signer.KeyID(), keyID)
+			}
+
+			tampered := received()
+			tampered.Body = http.NoBody
+			if _, err := v.Verify(tampered, domain); err == nil {
+				t.Fatal("expected a tampered body to be refused")
+			}
+
+			tampered = received()
+			tampered.Method = http.MethodPut
+			if _, err := v.Verify(tampered, domain); err == nil {
+				t.Fatal("expected a tampered method to be refused")
+			}
+
+			tampered = received()
+			tampered.Host = "other.org"
+			if _, err := v.Verify(tampered, domain); err == nil {
+				t.Fatal("expected a tampered authority to be refused")
+			}
+		})
+	}
+}
+
+func TestVerifyExpired(t *testing.T) {
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	signer, client := newProvider(t, key)
+	signer.now = func() time.Time { return time.Now().Add(-10 * time.Minute) }
+
+	_, received := newRequest(t, signer, "{}")
+	if _, err := newVerifier(client).Verify(received(), domain); err == nil {
+		t.Fatal("expected an old signature to be refused")
+	}
+}
+
+func TestVerifyUnpublishedKey(t *testing.T) {
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	signer, client := newProvider(t, key)
+
+	// a key not matching the one published by the provider
+	_, other, _ := ed25519.GenerateKey(rand.Reader)
+	forger := &Signer{keyID: signer.KeyID(), key: other, now: time.Now}
+	_, received := newRequest(t, forger, "{}")
+	if _, err := newVerifier(client).Verify(received(), domain); err == nil {
+		t.Fatal("expected a signature with an unpublished key to be refused")
+	}
+
+	// a key id not published by the provider
+	forger.keyID = strings.Replace(signer.KeyID(), "#signature", "#other", 1)
+	_, received = newRequest(t, forger, "{}")
+	if _, err := newVerifier(client).Verify(received(), domain); err == nil {
+		t.Fatal("expected an unknown key id to be refused")
+	}
+}
+
+func TestVerifyOtherProvider(t *testing.T) {
+	fetched := 0
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetched++
+	}))
+	defer srv.Close()
+
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	signer, err := NewSigner(srv.URL+"/ocm/ocm-provider#signature", writeKey(t, key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, received := newRequest(t, signer, "{}")
+
+	// the key of another provider is never fetched
+	if _, err := newVerifier(srv.Client()).Verify(received(), "cloud.example.org"); err == nil {
+		t.Fatal("expected a key of another provider to be refused")
+	}
+	if fetched != 0 {
+		t.Fatal("the key of another provider was fetched")
+	}
+
+	// nor are keys served from internal addresses
+	if _, err := NewVerifier(srv.Client(), 5*time.Minute).Verify(received(), domain); err == nil {
+		t.Fatal("expected a key served from an internal address to be refused")
+	}
+	if fetched != 0 {
+		t.Fatal("a key was fetched from an internal address")
+	}
+}
+
+func TestNewSignerKeyID(t *testing.T) {
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	file := writeKey(t, key)
+	for _, keyID := range []string{"", "cloud.example.org", "http://cloud.example.org/ocm/ocm-provider#signature"} {
+		if _, err := NewSigner(keyID, file); err == nil {
+			t.Errorf("expected key id %q to be refused", keyID)
+		}
+	}
+}
+
+func TestKeyBelongsTo(t *testing.T) {
+	tests := []struct {
+		keyID, domain string
+		expected      bool
+	}{
+		{"https://cloud.example.org/ocm/ocm-provider#signature", "cloud.example.org", true},
+		{"https://cloud.example.org/ocm/ocm-provider#signature", "Cloud.Example.org:443", true},
+		{"https://cloud.example.org:8443/ocm/ocm-provider#signature", "https://cloud.example.org", true},
+		{"https://cloud.example.org/ocm/ocm-provider#signature", "evil.org", false},
+		{"https://cloud.example.org.evil.org/ocm#signature", "cloud.example.org", false},
+	}
+	for _, tt := range tests {
+		if got := KeyBelongsTo(tt.keyID, tt.domain); got != tt.expected {
+			t.Errorf("KeyBelongsTo(%s, %s) = %v, expected %v", tt.keyID, tt.domain, got, tt.expected)
+		}
+	}
+}
+
+func TestParseSignatureInput(t *testing.T) {
+	in, err := parseSignatureInput(`sig1=("@method" "@authority");created=1618884473;keyid="test-key-rsa, pss";alg="rsa-v1_5-sha256", ocm=("@method" "content-digest");created=1618884475;keyid="https://example.org/ocm#signature"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if in.label != "ocm" || in.created != 1618884475 || in.keyID != "https://example.org/ocm#signature" || in.alg != "" {
+		t.Fatalf("unexpected signature input %+v", in)
+	}
+	if strings.Join(in.components, ",") != "@method,content-digest" {
+		t.Fatalf("unexpected components %v", in.components)
+	}
+	if in.params != `("@method" "content-digest");created=1618884475;keyid="https://example.org/ocm#signature"` {
+		t.Fatalf("unexpected params %s", in.params)
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package memory
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/cs3org/reva/pkg/ratelimit"
+	"github.com/cs3org/reva/pkg/ratelimit/registry"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	registry.Register("memory", New)
+}
+
+type config struct {
+	// CleanupInterval is the interval in seconds after which idle buckets are dropped.
+	CleanupInterval int `mapstructure:"cleanup_interval"`
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type limiter struct {
+	sync.Mutex
+	buckets  map[string]*bucket
+	idle     time.Duration
+	lastScan time.Time
+	now      func() time.Time
+}
+
+// New returns a rate limiter that keeps the token buckets in memory.
+// Limits are therefore enforced per revad instance.
+func New(m map[string]interface{}) (ratelimit.Limiter, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "error decoding conf")
+	}
+	if c.CleanupInterval == 0 {
+		c.CleanupInterval = 300
+	}
+
+	return &limiter{
+		buckets:  map[string]*bucket{},
+		idle:     time.Duration(c.CleanupInterval) * time.Second,
+		lastScan: time.Now(),
+		now:      time.Now,
+	}, nil
+}
+
+func (l *limiter) Allow(ctx context.Context, key string, limit ratelimit.Limit) (bool, time.Duration, error) {
+	return l.AllowAll(ctx, []ratelimit.Bucket{{Key: key, Limit: limit}})
+}
+
+func (l *limiter) AllowAll(ctx context.Context, buckets []ratelimit.Bucket) (bool, time.Duration, error) {
+	l.Lock()
+	defer l.Unlock()
+
+	now := l.now()
+	l.cleanup(now)
+
+	var wait time.Duration
+	refilled := make([]*bucket, 0, len(buckets))
+	for _, rb := range buckets {
+		b := l.refill(rb.Key, rb.Limit, now)
+		if b.tokens < 1 {
+			if w := time.Duration((1 - b.tokens) / rb.Limit.Rate * float64(time.Second)); w > wait {
+				wait = w
+			}
+		}
+		refilled = append(refilled, b)
+	}
+	if wait > 0 {
+		return false, wait, nil
+	}
+
+	for _, b := range refilled {
+		b.tokens--
+	}
+	return true, 0, nil
+}
+
+// refill returns the bucket identified by key, adding the tokens
+// accumulated since it was last used. It must be called with the lock held.
+func (l *limiter) refill(key string, limit ratelimit.Limit, now time.Time) *bucket {
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		l.buckets[key] = b
+	}
+
+	elapsed := now.Sub(b.last).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed*limit.Rate)
+		b.last = now
+	}
+	return b
+}
+
+// cleanup drops the buckets that have not been used for a while.
+// It must be called with the lock held.
+func (l *limiter) cleanup(now time.Time) {
+	if now.Sub(l.lastScan) < l.idle {
+		return
+	}
+	for k, b := range l.buckets {
+		if now.Sub(b.last) >= l.idle {
+			delete(l.buckets, k)
+		}
+	}
+	l.lastScan = now
+}
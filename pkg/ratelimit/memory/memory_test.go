@@ -0,0 +1,129 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cs3org/reva/pkg/ratelimit"
+)
+
+func TestAllow(t *testing.T) {
+	l, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lim := l.(*limiter)
+	now := time.Unix(1000, 0)
+	lim.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	limit := ratelimit.Limit{Rate: 2, Burst: 3}
+
+	for i := 0; i < 3; i++ {
+		if ok, _, _ := lim.Allow(ctx, "user:einstein", limit); !ok {
+			t.Fatalf("request %d should be allowed within burst", i)
+		}
+	}
+
+	ok, wait, _ := lim.Allow(ctx, "user:einstein", limit)
+	if ok {
+		t.Fatal("request exceeding burst should be rejected")
+	}
+	if wait != 500*time.Millisecond {
+		t.Fatalf("expected to wait 500ms, got %s", wait)
+	}
+
+	// other keys have their own bucket
+	if ok, _, _ := lim.Allow(ctx, "user:marie", limit); !ok {
+		t.Fatal("request for a different key should be allowed")
+	}
+
+	now = now.Add(500 * time.Millisecond)
+	if ok, _, _ := lim.Allow(ctx, "user:einstein", limit); !ok {
+		t.Fatal("request should be allowed after the bucket refilled")
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	l, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	p := ratelimit.NewPolicy(&ratelimit.Config{
+		IP: &ratelimit.Limit{Rate: 2, Burst: 2},
+		Methods: map[string]*ratelimit.Limit{
+			"/cs3.gateway.v1beta1.GatewayAPI/":     {Rate: 100},
+			"/cs3.gateway.v1beta1.GatewayAPI/Stat": {Rate: 1},
+		},
+	}, l)
+
+	stat := &ratelimit.Request{User: "einstein", IP: "10.0.0.1", Method: "/cs3.gateway.v1beta1.GatewayAPI/Stat"}
+	if ok, _ := p.Allow(ctx, stat); !ok {
+		t.Fatal("first request should be allowed")
+	}
+	ok, wait := p.Allow(ctx, stat)
+	if ok {
+		t.Fatal("second request should hit the most specific method limit")
+	}
+	if ratelimit.RetryAfterSeconds(wait) != 1 {
+		t.Fatalf("expected retry after 1 second, got %s", wait)
+	}
+
+	// the rejected request did not consume a token of the address limit
+	list := &ratelimit.Request{User: "einstein", IP: "10.0.0.1", Method: "/cs3.gateway.v1beta1.GatewayAPI/ListContainer"}
+	if ok, _ := p.Allow(ctx, list); !ok {
+		t.Fatal("requests to other methods should be allowed")
+	}
+	if ok, _ := p.Allow(ctx, list); ok {
+		t.Fatal("third request should hit the address limit")
+	}
+}
+
+func TestAllowAll(t *testing.T) {
+	l, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lim := l.(*limiter)
+	now := time.Unix(1000, 0)
+	lim.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	method := ratelimit.Bucket{Key: "method:Stat:user:einstein", Limit: ratelimit.Limit{Rate: 1, Burst: 5}}
+	ip := ratelimit.Bucket{Key: "ip:10.0.0.1", Limit: ratelimit.Limit{Rate: 1, Burst: 1}}
+
+	if ok, _, _ := lim.AllowAll(ctx, []ratelimit.Bucket{method, ip}); !ok {
+		t.Fatal("first request should be allowed")
+	}
+	for i := 0; i < 3; i++ {
+		if ok, wait, _ := lim.AllowAll(ctx, []ratelimit.Bucket{method, ip}); ok || wait != time.Second {
+			t.Fatalf("request should hit the address limit, got %v, %s", ok, wait)
+		}
+	}
+
+	// the rejected requests did not consume the tokens of the method limit
+	if tokens := lim.buckets[method.Key].tokens; tokens != 4 {
+		t.Fatalf("expected 4 tokens left for the method, got %v", tokens)
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cs3org/reva/pkg/appctx"
+)
+
+// Limit describes a token bucket: Rate tokens are added per second
+// up to a maximum of Burst tokens.
+type Limit struct {
+	Rate  float64 `mapstructure:"rate"`
+	Burst int     `mapstructure:"burst"`
+}
+
+// Enabled returns true if the limit has a positive rate.
+func (l *Limit) Enabled() bool {
+	return l != nil && l.Rate > 0
+}
+
+// Limiter is the interface that rate limiting backends need to implement.
+type Limiter interface {
+	// Allow consumes a token from the bucket identified by key.
+	// If the bucket is empty, it returns false and the time to wait
+	// until a new token becomes available.
+	Allow(ctx context.Context, key string, limit Limit) (bool, time.Duration, error)
+	// AllowAll consumes a token from each of the given buckets, only if
+	// all of them have one available. Otherwise, no token is consumed and
+	// it returns false and the time to wait until all of them have one.
+	AllowAll(ctx context.Context, buckets []Bucket) (bool, time.Duration, error)
+}
+
+// Bucket identifies a token bucket and the limit it enforces.
+type Bucket struct {
+	Key   string
+	Limit Limit
+}
+
+// Config holds the rate limiting rules shared by the gRPC and HTTP interceptors.
+type Config struct {
+	Driver  string                            `mapstructure:"driver"`
+	Drivers map[string]map[string]interface{} `mapstructure:"drivers"`
+	// User is the limit applied to each authenticated user.
+	User *Limit `mapstructure:"user"`
+	// IP is the limit applied to each client address.
+	IP *Limit `mapstructure:"ip"`
+	// Methods maps gRPC methods or HTTP path prefixes to the limit
+	// applied to each caller of that method or path.
+	Methods map[string]*Limit `mapstructure:"methods"`
+}
+
+// Request identifies the caller and the operation being rate limited.
+type Request struct {
+	User   string
+	IP     string
+	Method string
+}
+
+// Policy applies the configured limits to incoming requests.
+type Policy struct {
+	limiter  Limiter
+	user, ip *Limit
+	methods  map[string]*Limit
+	prefixes []string
+}
+
+// NewPolicy returns a policy that applies the limits in c using the given limiter.
+func NewPolicy(c *Config, limiter Limiter) *Policy {
+	p := &Policy{
+		limiter: limiter,
+		user:    normalize(c.User),
+		ip:      normalize(c.IP),
+		methods: map[string]*Limit{},
+	}
+	for m, l := range c.Methods {
+		if l = normalize(l); l.Enabled() {
+			p.methods[m] = l
+			p.prefixes = append(p.prefixes, m)
+		}
+	}
+	// longest prefixes first, so the most specific rule wins
+	sort.Slice(p.prefixes, func(i, j int) bool {
+		return len(p.prefixes[i]) > len(p.prefixes[j])
+	})
+	return p
+}
+
+func normalize(l *Limit) *Limit {
+	if !l.Enabled() {
+		return nil
+	}
+	if l.Burst <= 0 {
+		l.Burst = int(l.Rate)
+		if l.Burst < 1 {
+			l.Burst = 1
+		}
+	}
+	return l
+}
+
+// Allow checks the request against every applicable limit and returns false
+// together with the time to wait if any of them has been exceeded.
+// Errors from the limiter are logged and the request is let through,
+// so an unavailable backend does not take the service down with it.
+func (p *Policy) Allow(ctx context.Context, r *Request) (bool, time.Duration) {
+	caller := "ip:" + r.IP
+	if r.User != "" {
+		caller = "user:" + r.User
+	}
+
+	var buckets []Bucket
+	if prefix, l := p.methodLimit(r.Method); l != nil {
+		buckets = append(buckets, Bucket{Key: fmt.Sprintf("method:%s:%s", prefix, caller), Limit: *l})
+	}
+	if p.user.Enabled() && r.User != "" {
+		buckets = append(buckets, Bucket{Key: "user:" + r.User, Limit: *p.user})
+	}
+	if p.ip.Enabled() && r.IP != "" {
+		buckets = append(buckets, Bucket{Key: "ip:" + r.IP, Limit: *p.ip})
+	}
+	if len(buckets) == 0 {
+		return true, 0
+	}
+
+	// a rejected request must not consume the tokens of the limits it did not exceed
+	ok, wait, err := p.limiter.AllowAll(ctx, buckets)
+	if err != nil {
+		appctx.GetLogger(ctx).Error().Err(err).Msg("ratelimit: error querying limiter, letting request through")
+		return true, 0
+	}
+	return ok, wait
+}
+
+func (p *Policy) methodLimit(method string) (string, *Limit) {
+	for _, prefix := range p.prefixes {
+		if strings.HasPrefix(method, prefix) {
+			return prefix, p.methods[prefix]
+		}
+	}
+	return "", nil
+}
+
+// RetryAfterSeconds converts a wait duration to the number of whole seconds
+// to advertise to clients, never less than one.
+func RetryAfterSeconds(d time.Duration) int {
+	s := int((d + time.Second - 1) / time.Second)
+	if s < 1 {
+		s = 1
+	}
+	return s
+}
@@ -0,0 +1,174 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/cs3org/reva/pkg/ratelimit"
+	"github.com/cs3org/reva/pkg/ratelimit/registry"
+	"github.com/gomodule/redigo/redis"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	registry.Register("redis", New)
+}
+
+// tokenBuckets atomically refills the buckets stored in KEYS and consumes
+// a token from each of them, only if all of them have one available.
+// ARGV holds the current time in milliseconds followed by the rate, burst
+// and ttl of each bucket. It returns whether the request is allowed and,
+// if not, the number of milliseconds until all the buckets have a token.
+var tokenBuckets = redis.NewScript(-1, `
+local now = tonumber(ARGV[1])
+local tokens = {}
+local wait = 0
+
+for i, key in ipairs(KEYS) do
+	local rate = tonumber(ARGV[3 * i - 1])
+	local burst = tonumber(ARGV[3 * i])
+
+	local state = redis.call("HMGET", key, "tokens", "ts")
+	local t = tonumber(state[1])
+	local ts = tonumber(state[2])
+	if t == nil or ts == nil then
+		t = burst
+		ts = now
+	end
+
+	local elapsed = math.max(0, now - ts) / 1000
+	t = math.min(burst, t + elapsed * rate)
+	if t < 1 then
+		wait = math.max(wait, math.ceil((1 - t) / rate * 1000))
+	end
+	tokens[i] = t
+end
+
+local allowed = 0
+if wait == 0 then
+	allowed = 1
+end
+
+for i, key in ipairs(KEYS) do
+	local t = tokens[i]
+	if allowed == 1 then
+		t = t - 1
+	end
+	redis.call("HMSET", key, "tokens", tostring(t), "ts", now)
+	redis.call("PEXPIRE", key, tonumber(ARGV[3 * i + 1]))
+end
+return {allowed, wait}
+`)
+
+type config struct {
+	RedisAddress  string `mapstructure:"redis_address"`
+	RedisUsername string `mapstructure:"redis_username"`
+	RedisPassword string `mapstructure:"redis_password"`
+	Prefix        string `mapstructure:"prefix"`
+}
+
+type limiter struct {
+	redisPool *redis.Pool
+	prefix    string
+}
+
+// New returns a rate limiter that keeps the token buckets in redis,
+// so that limits are shared among all the revad instances using it.
+func New(m map[string]interface{}) (ratelimit.Limiter, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "error decoding conf")
+	}
+
+	if c.RedisAddress == "" {
+		c.RedisAddress = "localhost:6379"
+	}
+	if c.Prefix == "" {
+		c.Prefix = "ratelimit:"
+	}
+
+	pool := &redis.Pool{
+		MaxIdle:     50,
+		MaxActive:   1000,
+		IdleTimeout: 240 * time.Second,
+
+		Dial: func() (redis.Conn, error) {
+			var opts []redis.DialOption
+			if c.RedisUsername != "" {
+				opts = append(opts, redis.DialUsername(c.RedisUsername))
+			}
+			if c.RedisPassword != "" {
+				opts = append(opts, redis.DialPassword(c.RedisPassword))
+			}
+
+			c, err := redis.Dial("tcp", c.RedisAddress, opts...)
+			if err != nil {
+				return nil, err
+			}
+			return c, err
+		},
+
+		TestOnBorrow: func(c redis.Conn, t time.Time) error {
+			_, err := c.Do("PING")
+			return err
+		},
+	}
+
+	return &limiter{
+		redisPool: pool,
+		prefix:    c.Prefix,
+	}, nil
+}
+
+func (l *limiter) Allow(ctx context.Context, key string, limit ratelimit.Limit) (bool, time.Duration, error) {
+	return l.AllowAll(ctx, []ratelimit.Bucket{{Key: key, Limit: limit}})
+}
+
+func (l *limiter) AllowAll(ctx context.Context, buckets []ratelimit.Bucket) (bool, time.Duration, error) {
+	conn, err := l.redisPool.GetContext(ctx)
+	if err != nil {
+		return false, 0, errors.Wrap(err, "ratelimit: unable to get connection from redis pool")
+	}
+	defer conn.Close()
+
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	args := []interface{}{len(buckets)}
+	for _, b := range buckets {
+		args = append(args, l.prefix+b.Key)
+	}
+	args = append(args, now)
+	for _, b := range buckets {
+		// keep the bucket around for as long as it takes to refill completely
+		ttl := int64(float64(b.Limit.Burst)/b.Limit.Rate*1000) + 1000
+		args = append(args, b.Limit.Rate, b.Limit.Burst, ttl)
+	}
+
+	res, err := redis.Int64s(tokenBuckets.Do(conn, args...))
+	if err != nil {
+		return false, 0, errors.Wrap(err, "ratelimit: error evaluating token bucket script")
+	}
+	if len(res) != 2 {
+		return false, 0, errors.New("ratelimit: unexpected response from token bucket script")
+	}
+
+	return res[0] == 1, time.Duration(res[1]) * time.Millisecond, nil
+}
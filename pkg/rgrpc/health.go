@@ -0,0 +1,104 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package rgrpc
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/cs3org/reva/pkg/health"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// healthEndpoints must be reachable without authentication.
+const healthEndpoints = "/grpc.health.v1.Health/"
+
+const healthWatchInterval = 10 * time.Second
+
+// healthServer implements the standard gRPC health service
+// on top of the checks registered in the health package.
+type healthServer struct {
+	services map[string]bool
+	stopping atomic.Bool
+}
+
+func newHealthServer(services map[string]grpc.ServiceInfo) *healthServer {
+	h := &healthServer{services: map[string]bool{}}
+	for name := range services {
+		h.services[name] = true
+	}
+	return h
+}
+
+func (h *healthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	if req.Service != "" && !h.services[req.Service] {
+		return nil, status.Error(codes.NotFound, "unknown service")
+	}
+	return &healthpb.HealthCheckResponse{Status: h.status()}, nil
+}
+
+func (h *healthServer) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	ctx := stream.Context()
+	if req.Service != "" && !h.services[req.Service] {
+		// as per the protocol, the stream is kept open in case the service is added later
+		if err := stream.Send(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVICE_UNKNOWN}); err != nil {
+			return err
+		}
+		<-ctx.Done()
+		return nil
+	}
+
+	ticker := time.NewTicker(healthWatchInterval)
+	defer ticker.Stop()
+
+	last := healthpb.HealthCheckResponse_UNKNOWN
+	for {
+		if st := h.status(); st != last {
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: st}); err != nil {
+				return err
+			}
+			last = st
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// status reads the outcome of the checks run in the background,
+// so that the probes do not hit the dependencies.
+func (h *healthServer) status() healthpb.HealthCheckResponse_ServingStatus {
+	if h.stopping.Load() || !health.Current().Healthy() {
+		return healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	return healthpb.HealthCheckResponse_SERVING
+}
+
+// shutdown makes the server report NOT_SERVING while it is being stopped.
+func (h *healthServer) shutdown() {
+	if h != nil {
+		h.stopping.Store(true)
+	}
+}
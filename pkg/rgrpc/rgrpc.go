@@ -32,12 +32,14 @@ import (
 	"github.com/cs3org/reva/internal/grpc/interceptors/useragent"
 	"github.com/cs3org/reva/pkg/sharedconf"
 	rtrace "github.com/cs3org/reva/pkg/trace"
+	"github.com/cs3org/reva/pkg/utils"
 	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
 	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 )
 
@@ -101,6 +103,9 @@ type config struct {
 	Services         map[string]map[string]interface{} `mapstructure:"services"`
 	Interceptors     map[string]map[string]interface{} `mapstructure:"interceptors"`
 	EnableReflection bool                              `mapstructure:"enable_reflection"`
+	// TrustedProxies are the addresses and CIDR ranges of the peers
+	// allowed to forward the address of the client they act for.
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
 }
 
 func (c *config) init() {
@@ -111,6 +116,10 @@ func (c *config) init() {
 	if c.Address == "" {
 		c.Address = sharedconf.GetGatewaySVC("0.0.0.0:19000")
 	}
+
+	if c.TrustedProxies == nil {
+		c.TrustedProxies = []string{"127.0.0.0/8", "::1"}
+	}
 }
 
 // Server is a gRPC server.
@@ -120,6 +129,9 @@ type Server struct {
 	listener net.Listener
 	log      zerolog.Logger
 	services map[string]Service
+	health   *healthServer
+	// trustedProxies are the peers whose forwarded client address is honored
+	trustedProxies []*net.IPNet
 }
 
 // NewServer returns a new Server.
@@ -131,7 +143,12 @@ func NewServer(m interface{}, log zerolog.Logger) (*Server, error) {
 
 	conf.init()
 
-	server := &Server{conf: conf, log: log, services: map[string]Service{}}
+	trusted, err := utils.ParseTrustedProxies(conf.TrustedProxies)
+	if err != nil {
+		return nil, errors.Wrap(err, "rgrpc: error parsing trusted proxies")
+	}
+
+	server := &Server{conf: conf, log: log, services: map[string]Service{}, trustedProxies: trusted}
 
 	return server, nil
 }
@@ -192,6 +209,7 @@ func (s *Server) registerServices() error {
 	for _, svc := range s.services {
 		unprotected = append(unprotected, svc.UnprotectedEndpoints()...)
 	}
+	unprotected = append(unprotected, healthEndpoints)
 
 	opts, err := s.getInterceptors(unprotected)
 	if err != nil {
@@ -203,6 +221,11 @@ func (s *Server) registerServices() error {
 		svc.Register(grpcServer)
 	}
 
+	// the health service is exposed by every server, reporting
+	// the outcome of the checks registered by services and drivers
+	s.health = newHealthServer(grpcServer.GetServiceInfo())
+	healthpb.RegisterHealthServer(grpcServer, s.health)
+
 	if s.conf.EnableReflection {
 		s.log.Info().Msg("rgrpc: grpc server reflection enabled")
 		reflection.Register(grpcServer)
@@ -226,6 +249,7 @@ func (s *Server) cleanupServices() {
 
 // Stop stops the server.
 func (s *Server) Stop() error {
+	s.health.shutdown()
 	s.cleanupServices()
 	s.s.Stop()
 	return nil
@@ -233,6 +257,7 @@ func (s *Server) Stop() error {
 
 // GracefulStop gracefully stops the server.
 func (s *Server) GracefulStop() error {
+	s.health.shutdown()
 	s.cleanupServices()
 	s.s.GracefulStop()
 	return nil
@@ -291,7 +316,7 @@ func (s *Server) getInterceptors(unprotected []string) ([]grpc.ServerOption, err
 	unaryInterceptors = append([]grpc.UnaryServerInterceptor{
 		appctx.NewUnary(s.log),
 		token.NewUnary(),
-		useragent.NewUnary(),
+		useragent.NewUnary(s.trustedProxies),
 		log.NewUnary(),
 		recovery.NewUnary(),
 	}, unaryInterceptors...)
@@ -333,7 +358,7 @@ func (s *Server) getInterceptors(unprotected []string) ([]grpc.ServerOption, err
 		authStream,
 		appctx.NewStream(s.log),
 		token.NewStream(),
-		useragent.NewStream(),
+		useragent.NewStream(s.trustedProxies),
 		log.NewStream(),
 		recovery.NewStream(),
 	}, streamInterceptors...)
@@ -115,6 +115,17 @@ func NewInsufficientStorage(ctx context.Context, err error, msg string) *rpc.Sta
 	}
 }
 
+// NewUnavailable returns a Status with CODE_UNAVAILABLE and logs the msg.
+func NewUnavailable(ctx context.Context, err error, msg string) *rpc.Status {
+	log := appctx.GetLogger(ctx).With().CallerWithSkipFrameCount(3).Logger()
+	log.Warn().Err(err).Msg(msg)
+	return &rpc.Status{
+		Code:    rpc.Code_CODE_UNAVAILABLE,
+		Message: msg,
+		Trace:   getTrace(ctx),
+	}
+}
+
 // NewUnimplemented returns a Status with CODE_UNIMPLEMENTED and logs the msg.
 func NewUnimplemented(ctx context.Context, err error, msg string) *rpc.Status {
 	log := appctx.GetLogger(ctx).With().CallerWithSkipFrameCount(3).Logger()
@@ -181,6 +192,8 @@ func NewStatusFromErrType(ctx context.Context, msg string, err error) *rpc.Statu
 		return NewUnimplemented(ctx, err, "gateway: "+msg+":"+err.Error())
 	case errtypes.BadRequest:
 		return NewInvalidArg(ctx, "gateway: "+msg+":"+err.Error())
+	case errtypes.IsUnavailable:
+		return NewUnavailable(ctx, err, "gateway: "+msg+": "+err.Error())
 	}
 
 	// map GRPC status codes coming from the auth middleware
@@ -40,6 +40,7 @@ import (
 	storageprovider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	storageregistry "github.com/cs3org/go-cs3apis/cs3/storage/registry/v1beta1"
 	datatx "github.com/cs3org/go-cs3apis/cs3/tx/v1beta1"
+	invitelifecyclepb "github.com/cs3org/reva/pkg/ocm/invite/proto"
 	rtrace "github.com/cs3org/reva/pkg/trace"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
@@ -68,6 +69,7 @@ var (
 	userShareProviders     = newProvider()
 	ocmShareProviders      = newProvider()
 	ocmInviteManagers      = newProvider()
+	ocmInviteLifecycles    = newProvider()
 	ocmProviderAuthorizers = newProvider()
 	ocmCores               = newProvider()
 	publicShareProviders   = newProvider()
@@ -322,6 +324,26 @@ func GetOCMInviteManagerClient(opts ...Option) (invitepb.InviteAPIClient, error)
 	return v, nil
 }
 
+// GetOCMInviteLifecycleClient returns a new InviteLifecycleServiceClient.
+func GetOCMInviteLifecycleClient(opts ...Option) (invitelifecyclepb.InviteLifecycleServiceClient, error) {
+	ocmInviteLifecycles.m.Lock()
+	defer ocmInviteLifecycles.m.Unlock()
+
+	options := newOptions(opts...)
+	if c, ok := ocmInviteLifecycles.conn[options.Endpoint]; ok {
+		return c.(invitelifecyclepb.InviteLifecycleServiceClient), nil
+	}
+
+	conn, err := NewConn(options)
+	if err != nil {
+		return nil, err
+	}
+
+	v := invitelifecyclepb.NewInviteLifecycleServiceClient(conn)
+	ocmInviteLifecycles.conn[options.Endpoint] = v
+	return v, nil
+}
+
 // GetPublicShareProviderClient returns a new PublicShareProviderClient.
 func GetPublicShareProviderClient(opts ...Option) (link.LinkAPIClient, error) {
 	publicShareProviders.m.Lock()
@@ -32,6 +32,7 @@ import (
 	"github.com/cs3org/reva/internal/http/interceptors/auth"
 	"github.com/cs3org/reva/internal/http/interceptors/log"
 	"github.com/cs3org/reva/internal/http/interceptors/providerauthorizer"
+	"github.com/cs3org/reva/pkg/health"
 	"github.com/cs3org/reva/pkg/rhttp/global"
 	rtrace "github.com/cs3org/reva/pkg/trace"
 	"github.com/mitchellh/mapstructure"
@@ -217,6 +218,11 @@ func (s *Server) registerServices() error {
 			return errors.New(message)
 		}
 	}
+
+	// every server exposes the liveness and readiness probes
+	s.handlers["/healthz"] = health.LivenessHandler()
+	s.handlers["/readyz"] = health.ReadinessHandler()
+	s.unprotected = append(s.unprotected, "/healthz", "/readyz")
 	return nil
 }
 
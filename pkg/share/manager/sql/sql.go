@@ -32,6 +32,7 @@ import (
 	typespb "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
 	ctxpkg "github.com/cs3org/reva/pkg/ctx"
 	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/health"
 	"github.com/cs3org/reva/pkg/share"
 	"github.com/cs3org/reva/pkg/share/manager/registry"
 	"github.com/cs3org/reva/pkg/utils"
@@ -82,6 +83,8 @@ func NewMysql(m map[string]interface{}) (share.Manager, error) {
 		return nil, err
 	}
 
+	health.Register(fmt.Sprintf("share:sql:%s:%d/%s", c.DBHost, c.DBPort, c.DBName), health.SQL(db))
+
 	userConverter := NewGatewayUserConverter(c.GatewayAddr)
 
 	return New("mysql", db, c.StorageMountID, userConverter)
@@ -39,6 +39,15 @@ type Configuration struct {
 			OperatorsFile string `mapstructure:"operators_file"`
 			AccountsFile  string `mapstructure:"accounts_file"`
 		} `mapstructure:"file"`
+
+		SQL struct {
+			DBEngine   string `mapstructure:"db_engine"`
+			DBUsername string `mapstructure:"db_username"`
+			DBPassword string `mapstructure:"db_password"`
+			DBHost     string `mapstructure:"db_host"`
+			DBPort     int    `mapstructure:"db_port"`
+			DBName     string `mapstructure:"db_name"`
+		} `mapstructure:"sql"`
 	} `mapstructure:"storage"`
 
 	Email struct {
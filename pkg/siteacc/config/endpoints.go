@@ -53,6 +53,13 @@ const (
 	// EndpointContact is the endpoint path for sending contact emails.
 	EndpointContact = "/contact"
 
+	// EndpointTwoFactorEnroll is the endpoint path for starting the two-factor authentication enrollment.
+	EndpointTwoFactorEnroll = "/2fa-enroll"
+	// EndpointTwoFactorActivate is the endpoint path for activating two-factor authentication.
+	EndpointTwoFactorActivate = "/2fa-activate"
+	// EndpointTwoFactorDisable is the endpoint path for disabling two-factor authentication.
+	EndpointTwoFactorDisable = "/2fa-disable"
+
 	// EndpointVerifyUserToken is the endpoint path for user token validation.
 	EndpointVerifyUserToken = "/verify-user-token"
 
@@ -61,6 +68,9 @@ const (
 	// EndpointGrantGOCDBAccess is the endpoint path for granting or revoking GOCDB access.
 	EndpointGrantGOCDBAccess = "/grant-gocdb-access"
 
+	// EndpointEnforceTwoFactor is the endpoint path for enforcing two-factor authentication for all accounts of an operator.
+	EndpointEnforceTwoFactor = "/enforce-2fa"
+
 	// EndpointDispatchAlert is the endpoint path for dispatching alerts from Prometheus.
 	EndpointDispatchAlert = "/dispatch-alert"
 )
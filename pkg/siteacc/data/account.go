@@ -42,8 +42,9 @@ type Account struct {
 	DateCreated  time.Time `json:"dateCreated"`
 	DateModified time.Time `json:"dateModified"`
 
-	Data     AccountData     `json:"data"`
-	Settings AccountSettings `json:"settings"`
+	Data      AccountData      `json:"data"`
+	Settings  AccountSettings  `json:"settings"`
+	TwoFactor AccountTwoFactor `json:"twoFactor"`
 }
 
 // AccountData holds additional data for a sites account.
@@ -103,13 +104,14 @@ func (acc *Account) UpdatePassword(pwd string) error {
 	return nil
 }
 
-// Clone creates a copy of the account; if erasePassword is set to true, the password will be cleared in the cloned object.
+// Clone creates a copy of the account; if erasePassword is set to true, the password and two-factor secrets will be cleared in the cloned object.
 func (acc *Account) Clone(erasePassword bool) *Account {
 	clone := *acc
 
 	if erasePassword {
 		clone.Password.Clear()
 	}
+	clone.TwoFactor = acc.TwoFactor.Clone(erasePassword)
 
 	return &clone
 }
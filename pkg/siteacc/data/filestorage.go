@@ -68,6 +68,10 @@ func (storage *FileStorage) initialize(conf *config.Configuration, log *zerolog.
 }
 
 func (storage *FileStorage) readData(file string, obj interface{}) error {
+	return readJSONFile(file, obj)
+}
+
+func readJSONFile(file string, obj interface{}) error {
 	// Read the data from the specified file
 	jsonData, err := os.ReadFile(file)
 	if err != nil {
@@ -26,7 +26,13 @@ import (
 type Operator struct {
 	ID string `json:"id"`
 
-	Sites []*Site `json:"sites"`
+	Sites    []*Site          `json:"sites"`
+	Settings OperatorSettings `json:"settings"`
+}
+
+// OperatorSettings holds additional settings for an operator.
+type OperatorSettings struct {
+	RequireTwoFactor bool `json:"requireTwoFactor"`
 }
 
 // Operators holds an array of operators.
@@ -49,8 +55,9 @@ func (op *Operator) Update(other *Operator, credsPassphrase string) error {
 // Clone creates a copy of the operator; if eraseCredentials is set to true, the (test user) credentials will be cleared in the cloned object.
 func (op *Operator) Clone(eraseCredentials bool) *Operator {
 	clone := &Operator{
-		ID:    op.ID,
-		Sites: []*Site{},
+		ID:       op.ID,
+		Sites:    []*Site{},
+		Settings: op.Settings,
 	}
 
 	// Clone sites
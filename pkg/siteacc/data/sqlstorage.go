@@ -0,0 +1,348 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package data
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/cs3org/reva/pkg/health"
+	"github.com/cs3org/reva/pkg/siteacc/config"
+	"github.com/cs3org/reva/pkg/utils/sqlmigrate"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+
+	// Provides mysql drivers.
+	_ "github.com/go-sql-driver/mysql"
+	// Provides sqlite drivers.
+	_ "github.com/mattn/go-sqlite3"
+)
+
+var sqlMigrations = []sqlmigrate.Migration{
+	{
+		`CREATE TABLE siteacc_operators (
+			id VARCHAR(255) NOT NULL PRIMARY KEY,
+			data TEXT NOT NULL
+		)`,
+		`CREATE TABLE siteacc_accounts (
+			email VARCHAR(255) NOT NULL PRIMARY KEY,
+			operator VARCHAR(255) NOT NULL,
+			data TEXT NOT NULL
+		)`,
+	},
+	{
+		`CREATE TABLE siteacc_state (
+			name VARCHAR(255) NOT NULL PRIMARY KEY,
+			value BIGINT NOT NULL
+		)`,
+		`INSERT INTO siteacc_state (name, value) VALUES ('revision', 0)`,
+		`INSERT INTO siteacc_state (name, value) VALUES ('imported', 0)`,
+	},
+}
+
+const (
+	// stateRevision is incremented by every change, so that the instances sharing the database know when to read it again.
+	stateRevision = "revision"
+	// stateImported is set once the data of a previously used file storage has been imported.
+	stateImported = "imported"
+)
+
+// SQLStorage implements an SQL-based storage. Contrary to the file storage, all changes are written incrementally.
+// The database can be shared by several service instances.
+type SQLStorage struct {
+	Storage
+
+	conf *config.Configuration
+	log  *zerolog.Logger
+
+	db *sql.DB
+}
+
+func (storage *SQLStorage) initialize(conf *config.Configuration, log *zerolog.Logger) error {
+	if conf == nil {
+		return errors.Errorf("no configuration provided")
+	}
+	storage.conf = conf
+
+	if log == nil {
+		return errors.Errorf("no logger provided")
+	}
+	storage.log = log
+
+	c := conf.Storage.SQL
+	if c.DBEngine == "" {
+		c.DBEngine = "mysql"
+	}
+
+	var db *sql.DB
+	var err error
+	switch c.DBEngine {
+	case "mysql":
+		db, err = sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s:%d)/%s", c.DBUsername, c.DBPassword, c.DBHost, c.DBPort, c.DBName))
+	case "sqlite3":
+		db, err = sql.Open("sqlite3", c.DBName)
+	default:
+		return errors.Errorf("unsupported database engine %v", c.DBEngine)
+	}
+	if err != nil {
+		return errors.Wrap(err, "unable to open the database")
+	}
+	storage.db = db
+
+	if err := sqlmigrate.Migrate(db, "siteacc", sqlMigrations); err != nil {
+		return errors.Wrap(err, "unable to migrate the database")
+	}
+
+	health.Register(fmt.Sprintf("siteacc:sql:%s:%s:%d/%s", c.DBEngine, c.DBHost, c.DBPort, c.DBName), health.SQL(db))
+
+	if err := storage.importFiles(); err != nil {
+		return errors.Wrap(err, "unable to import the file storage")
+	}
+
+	return nil
+}
+
+// importFiles migrates the data of a previously used file storage, if configured, into the database.
+// The import is done once, in a single transaction: a failed import is retried on the next start, and
+// the instances starting at the same time wait for the one importing the data.
+func (storage *SQLStorage) importFiles() error {
+	return storage.update(func(tx *sql.Tx) error {
+		// Claiming the import locks the state row until the transaction ends
+		res, err := tx.Exec("UPDATE siteacc_state SET value=1 WHERE name=? AND value=0", stateImported)
+		if err != nil {
+			return errors.Wrap(err, "unable to claim the import")
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return errors.Wrap(err, "unable to claim the import")
+		} else if n == 0 {
+			// Already imported, by this or another instance
+			return nil
+		}
+		return storage.importFilesTx(tx)
+	})
+}
+
+func (storage *SQLStorage) importFilesTx(tx *sql.Tx) error {
+	files := storage.conf.Storage.File
+
+	if files.OperatorsFile != "" {
+		if _, err := os.Stat(files.OperatorsFile); err == nil {
+			operators := &Operators{}
+			if err := readJSONFile(files.OperatorsFile, operators); err != nil {
+				return errors.Wrap(err, "error reading operators")
+			}
+			for _, op := range *operators {
+				if err := writeOperator(tx, op); err != nil {
+					return errors.Wrapf(err, "error importing operator %v", op.ID)
+				}
+			}
+			storage.log.Info().Msgf("imported %v operators from %v", len(*operators), files.OperatorsFile)
+		}
+	}
+
+	if files.AccountsFile != "" {
+		if _, err := os.Stat(files.AccountsFile); err == nil {
+			accounts := &Accounts{}
+			if err := readJSONFile(files.AccountsFile, accounts); err != nil {
+				return errors.Wrap(err, "error reading accounts")
+			}
+			for _, account := range *accounts {
+				if err := writeAccount(tx, account); err != nil {
+					return errors.Wrapf(err, "error importing account %v", account.Email)
+				}
+			}
+			storage.log.Info().Msgf("imported %v accounts from %v", len(*accounts), files.AccountsFile)
+		}
+	}
+
+	return nil
+}
+
+// Revision returns the number of changes made to the stored data so far.
+func (storage *SQLStorage) Revision() (int64, error) {
+	var revision int64
+	if err := storage.db.QueryRow("SELECT value FROM siteacc_state WHERE name=?", stateRevision).Scan(&revision); err != nil {
+		return 0, errors.Wrap(err, "unable to query the database")
+	}
+	return revision, nil
+}
+
+// ReadOperators reads all stored operators into the given data object.
+func (storage *SQLStorage) ReadOperators() (*Operators, error) {
+	operators := &Operators{}
+	if err := storage.readRows("SELECT data FROM siteacc_operators ORDER BY id", func() interface{} {
+		op := &Operator{}
+		*operators = append(*operators, op)
+		return op
+	}); err != nil {
+		return nil, errors.Wrap(err, "error reading operators")
+	}
+	return operators, nil
+}
+
+// ReadAccounts reads all stored accounts into the given data object.
+func (storage *SQLStorage) ReadAccounts() (*Accounts, error) {
+	accounts := &Accounts{}
+	if err := storage.readRows("SELECT data FROM siteacc_accounts ORDER BY email", func() interface{} {
+		account := &Account{}
+		*accounts = append(*accounts, account)
+		return account
+	}); err != nil {
+		return nil, errors.Wrap(err, "error reading accounts")
+	}
+	return accounts, nil
+}
+
+func (storage *SQLStorage) readRows(query string, newObj func() interface{}) error {
+	rows, err := storage.db.Query(query)
+	if err != nil {
+		return errors.Wrap(err, "unable to query the database")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return errors.Wrap(err, "unable to scan row")
+		}
+		if err := json.Unmarshal([]byte(data), newObj()); err != nil {
+			return errors.Wrap(err, "invalid row data")
+		}
+	}
+	return rows.Err()
+}
+
+// WriteOperators writes all stored operators from the given data object.
+func (storage *SQLStorage) WriteOperators(ops *Operators) error {
+	// Simply skip this action; all data is saved incrementally
+	return nil
+}
+
+// WriteAccounts writes all stored accounts from the given data object.
+func (storage *SQLStorage) WriteAccounts(accounts *Accounts) error {
+	// Simply skip this action; all data is saved incrementally
+	return nil
+}
+
+func writeOperator(tx *sql.Tx, op *Operator) error {
+	data, err := json.Marshal(op)
+	if err != nil {
+		return errors.Wrap(err, "unable to marshal operator")
+	}
+	return replaceRow(tx, "DELETE FROM siteacc_operators WHERE id=?", []interface{}{op.ID},
+		"INSERT INTO siteacc_operators (id, data) VALUES (?, ?)", []interface{}{op.ID, string(data)})
+}
+
+func writeAccount(tx *sql.Tx, account *Account) error {
+	data, err := json.Marshal(account)
+	if err != nil {
+		return errors.Wrap(err, "unable to marshal account")
+	}
+	return replaceRow(tx, "DELETE FROM siteacc_accounts WHERE email=?", []interface{}{account.Email},
+		"INSERT INTO siteacc_accounts (email, operator, data) VALUES (?, ?, ?)", []interface{}{account.Email, account.Operator, string(data)})
+}
+
+// replaceRow deletes and re-inserts a row; unlike upserts, this works the same on all engines.
+func replaceRow(tx *sql.Tx, deleteQuery string, deleteArgs []interface{}, insertQuery string, insertArgs []interface{}) error {
+	if _, err := tx.Exec(deleteQuery, deleteArgs...); err != nil {
+		return errors.Wrap(err, "unable to delete row")
+	}
+	if _, err := tx.Exec(insertQuery, insertArgs...); err != nil {
+		return errors.Wrap(err, "unable to insert row")
+	}
+	return nil
+}
+
+// update runs f within a single transaction and increments the revision of the stored data.
+func (storage *SQLStorage) update(f func(tx *sql.Tx) error) error {
+	tx, err := storage.db.Begin()
+	if err != nil {
+		return errors.Wrap(err, "unable to begin transaction")
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if err := f(tx); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE siteacc_state SET value=value+1 WHERE name=?", stateRevision); err != nil {
+		return errors.Wrap(err, "unable to update the revision")
+	}
+	return tx.Commit()
+}
+
+// OperatorAdded is called when an operator has been added.
+func (storage *SQLStorage) OperatorAdded(op *Operator) {
+	if err := storage.update(func(tx *sql.Tx) error { return writeOperator(tx, op) }); err != nil {
+		storage.log.Err(err).Str("operator", op.ID).Msg("error storing added operator")
+	}
+}
+
+// OperatorUpdated is called when an operator has been updated.
+func (storage *SQLStorage) OperatorUpdated(op *Operator) {
+	if err := storage.update(func(tx *sql.Tx) error { return writeOperator(tx, op) }); err != nil {
+		storage.log.Err(err).Str("operator", op.ID).Msg("error storing updated operator")
+	}
+}
+
+// OperatorRemoved is called when an operator has been removed.
+func (storage *SQLStorage) OperatorRemoved(op *Operator) {
+	if err := storage.update(func(tx *sql.Tx) error {
+		_, err := tx.Exec("DELETE FROM siteacc_operators WHERE id=?", op.ID)
+		return err
+	}); err != nil {
+		storage.log.Err(err).Str("operator", op.ID).Msg("error removing operator")
+	}
+}
+
+// AccountAdded is called when an account has been added.
+func (storage *SQLStorage) AccountAdded(account *Account) {
+	if err := storage.update(func(tx *sql.Tx) error { return writeAccount(tx, account) }); err != nil {
+		storage.log.Err(err).Str("account", account.Email).Msg("error storing added account")
+	}
+}
+
+// AccountUpdated is called when an account has been updated.
+func (storage *SQLStorage) AccountUpdated(account *Account) {
+	if err := storage.update(func(tx *sql.Tx) error { return writeAccount(tx, account) }); err != nil {
+		storage.log.Err(err).Str("account", account.Email).Msg("error storing updated account")
+	}
+}
+
+// AccountRemoved is called when an account has been removed.
+func (storage *SQLStorage) AccountRemoved(account *Account) {
+	if err := storage.update(func(tx *sql.Tx) error {
+		_, err := tx.Exec("DELETE FROM siteacc_accounts WHERE email=?", account.Email)
+		return err
+	}); err != nil {
+		storage.log.Err(err).Str("account", account.Email).Msg("error removing account")
+	}
+}
+
+// NewSQLStorage creates a new SQL storage.
+func NewSQLStorage(conf *config.Configuration, log *zerolog.Logger) (*SQLStorage, error) {
+	storage := &SQLStorage{}
+	if err := storage.initialize(conf, log); err != nil {
+		return nil, errors.Wrap(err, "unable to initialize the SQL storage")
+	}
+	return storage, nil
+}
@@ -0,0 +1,153 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package data
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cs3org/reva/pkg/siteacc/config"
+	"github.com/rs/zerolog"
+)
+
+func TestSQLStorage(t *testing.T) {
+	dir := t.TempDir()
+	log := zerolog.Nop()
+
+	// Prepare the files of a previous file storage that will be imported
+	writeJSON := func(name string, obj interface{}) string {
+		data, _ := json.Marshal(obj)
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	conf := &config.Configuration{}
+	conf.Storage.SQL.DBEngine = "sqlite3"
+	conf.Storage.SQL.DBName = filepath.Join(dir, "siteacc.db")
+	conf.Storage.File.OperatorsFile = writeJSON("operators.json", Operators{{ID: "op1", Sites: []*Site{{ID: "site1"}}}})
+	conf.Storage.File.AccountsFile = writeJSON("accounts.json", Accounts{{Email: "jane@example.org", Operator: "op1"}})
+
+	storage, err := NewSQLStorage(conf, &log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ops, err := storage.ReadOperators()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*ops) != 1 || (*ops)[0].ID != "op1" || len((*ops)[0].Sites) != 1 {
+		t.Fatalf("operators not imported: %+v", *ops)
+	}
+
+	accounts, err := storage.ReadAccounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*accounts) != 1 || (*accounts)[0].Email != "jane@example.org" {
+		t.Fatalf("accounts not imported: %+v", *accounts)
+	}
+
+	revision, err := storage.Revision()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Incremental changes
+	account := (*accounts)[0]
+	account.FirstName = "Jane"
+	storage.AccountUpdated(account)
+	storage.AccountAdded(&Account{Email: "john@example.org", Operator: "op1"})
+	storage.OperatorRemoved((*ops)[0])
+
+	if r, err := storage.Revision(); err != nil {
+		t.Fatal(err)
+	} else if r != revision+3 {
+		t.Fatalf("expected revision %v, got %v", revision+3, r)
+	}
+
+	// Reopening the storage must neither lose changes nor import the files again
+	storage, err = NewSQLStorage(conf, &log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if accounts, err = storage.ReadAccounts(); err != nil {
+		t.Fatal(err)
+	}
+	if len(*accounts) != 2 || (*accounts)[0].FirstName != "Jane" || (*accounts)[1].Email != "john@example.org" {
+		t.Fatalf("unexpected accounts: %+v", *accounts)
+	}
+
+	if ops, err = storage.ReadOperators(); err != nil {
+		t.Fatal(err)
+	}
+	if len(*ops) != 0 {
+		t.Fatalf("operator not removed: %+v", *ops)
+	}
+
+	storage.AccountRemoved((*accounts)[1])
+	if accounts, err = storage.ReadAccounts(); err != nil {
+		t.Fatal(err)
+	}
+	if len(*accounts) != 1 {
+		t.Fatalf("account not removed: %+v", *accounts)
+	}
+}
+
+func TestSQLStorageRetriesFailedImport(t *testing.T) {
+	dir := t.TempDir()
+	log := zerolog.Nop()
+
+	accountsFile := filepath.Join(dir, "accounts.json")
+	if err := os.WriteFile(accountsFile, []byte("invalid"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	conf := &config.Configuration{}
+	conf.Storage.SQL.DBEngine = "sqlite3"
+	conf.Storage.SQL.DBName = filepath.Join(dir, "siteacc.db")
+	conf.Storage.File.AccountsFile = accountsFile
+
+	if _, err := NewSQLStorage(conf, &log); err == nil {
+		t.Fatal("expected the import of an invalid file to fail")
+	}
+
+	// The schema has been created, but the import must run again once the file has been fixed
+	data, _ := json.Marshal(Accounts{{Email: "jane@example.org", Operator: "op1"}})
+	if err := os.WriteFile(accountsFile, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	storage, err := NewSQLStorage(conf, &log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	accounts, err := storage.ReadAccounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*accounts) != 1 || (*accounts)[0].Email != "jane@example.org" {
+		t.Fatalf("accounts not imported: %+v", *accounts)
+	}
+}
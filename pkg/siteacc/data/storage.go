@@ -44,3 +44,12 @@ type Storage interface {
 	// AccountRemoved is called when an account has been removed.
 	AccountRemoved(account *Account)
 }
+
+// SharedStorage is implemented by storages which other service instances can modify concurrently, e.g. a common database.
+// The data of such storages is read again once it has changed instead of relying on a copy loaded at startup.
+type SharedStorage interface {
+	Storage
+
+	// Revision returns the number of changes made to the stored data so far.
+	Revision() (int64, error)
+}
@@ -0,0 +1,166 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package data
+
+import (
+	"time"
+
+	"github.com/cs3org/reva/pkg/siteacc/credentials"
+	"github.com/cs3org/reva/pkg/siteacc/credentials/crypto"
+	"github.com/cs3org/reva/pkg/utils/totp"
+	"github.com/pkg/errors"
+)
+
+const (
+	twoFactorIssuer        = "ScienceMesh"
+	twoFactorRecoveryCodes = 10
+)
+
+// AccountTwoFactor holds the two-factor authentication (TOTP) data of a sites account.
+type AccountTwoFactor struct {
+	Enabled bool `json:"enabled"`
+
+	Secret        string   `json:"secret,omitempty"`
+	PendingSecret string   `json:"pendingSecret,omitempty"`
+	RecoveryCodes []string `json:"recoveryCodes,omitempty"`
+	LastTimeStep  int64    `json:"lastTimeStep,omitempty"`
+}
+
+// TwoFactorEnrollment holds the information needed to add a TOTP secret to an authenticator app.
+type TwoFactorEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// Enroll generates a new pending TOTP secret; two-factor authentication is only enabled once the secret has been activated.
+func (tf *AccountTwoFactor) Enroll(account string, passphrase string) (*TwoFactorEnrollment, error) {
+	if tf.Enabled {
+		return nil, errors.Errorf("two-factor authentication is already enabled")
+	}
+	if passphrase == "" {
+		return nil, errors.Errorf("two-factor authentication requires a credentials passphrase to be configured")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	encSecret, err := crypto.EncodeString(secret, passphrase)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to encode the TOTP secret")
+	}
+	tf.PendingSecret = encSecret
+
+	return &TwoFactorEnrollment{
+		Secret: secret,
+		URI:    totp.URI(twoFactorIssuer, account, secret),
+	}, nil
+}
+
+// Activate enables two-factor authentication if the given code matches the pending secret; the newly generated recovery codes are returned in plain text.
+func (tf *AccountTwoFactor) Activate(code string, passphrase string, t time.Time) ([]string, error) {
+	if tf.Enabled {
+		return nil, errors.Errorf("two-factor authentication is already enabled")
+	}
+	if tf.PendingSecret == "" {
+		return nil, errors.Errorf("no two-factor enrollment pending")
+	}
+
+	secret, err := crypto.DecodeString(tf.PendingSecret, passphrase)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to decode the TOTP secret")
+	}
+	step, ok := validateCode(secret, code, t, 0)
+	if !ok {
+		return nil, errors.Errorf("invalid verification code")
+	}
+
+	codes, hashes, err := credentials.GenerateRecoveryCodes(twoFactorRecoveryCodes)
+	if err != nil {
+		return nil, err
+	}
+
+	tf.Enabled = true
+	tf.Secret = tf.PendingSecret
+	tf.PendingSecret = ""
+	tf.RecoveryCodes = hashes
+	tf.LastTimeStep = step
+	return codes, nil
+}
+
+// Disable turns off two-factor authentication; a valid code (or recovery code) is required to do so.
+func (tf *AccountTwoFactor) Disable(code string, passphrase string, t time.Time) error {
+	if !tf.Enabled {
+		return errors.Errorf("two-factor authentication is not enabled")
+	}
+	if err := tf.Verify(code, passphrase, t); err != nil {
+		return err
+	}
+
+	*tf = AccountTwoFactor{}
+	return nil
+}
+
+// Verify checks the given TOTP code; if it doesn't match, it is checked against the recovery codes instead, consuming the matching one.
+func (tf *AccountTwoFactor) Verify(code string, passphrase string, t time.Time) error {
+	if !tf.Enabled {
+		return errors.Errorf("two-factor authentication is not enabled")
+	}
+
+	secret, err := crypto.DecodeString(tf.Secret, passphrase)
+	if err != nil {
+		return errors.Wrap(err, "unable to decode the TOTP secret")
+	}
+	if step, ok := validateCode(secret, code, t, tf.LastTimeStep); ok {
+		tf.LastTimeStep = step
+		return nil
+	}
+
+	if idx := credentials.FindRecoveryCode(tf.RecoveryCodes, code); idx != -1 {
+		tf.RecoveryCodes = append(tf.RecoveryCodes[:idx:idx], tf.RecoveryCodes[idx+1:]...)
+		return nil
+	}
+
+	return errors.Errorf("invalid verification code")
+}
+
+// Clone creates a copy of the two-factor data; if eraseSecrets is set to true, all secrets will be cleared in the cloned object.
+func (tf *AccountTwoFactor) Clone(eraseSecrets bool) AccountTwoFactor {
+	clone := *tf
+
+	if eraseSecrets {
+		clone.Secret = ""
+		clone.PendingSecret = ""
+		clone.RecoveryCodes = nil
+	} else if tf.RecoveryCodes != nil {
+		clone.RecoveryCodes = append([]string{}, tf.RecoveryCodes...)
+	}
+
+	return clone
+}
+
+// validateCode checks a TOTP code against the base32 encoded secret; codes of time steps up to lastStep are rejected to prevent replays.
+func validateCode(secret, code string, t time.Time, lastStep int64) (int64, bool) {
+	key, err := totp.DecodeSecret(secret)
+	if err != nil {
+		return 0, false
+	}
+	step, ok := totp.Validate(key, code, t, totp.DefaultPeriod, totp.DefaultDigits, totp.DefaultSkew, uint64(lastStep))
+	return int64(step), ok
+}
@@ -0,0 +1,82 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package data
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cs3org/reva/pkg/utils/totp"
+)
+
+func TestAccountTwoFactor(t *testing.T) {
+	const passphrase = "secret-passphrase"
+	now := time.Now()
+
+	tf := &AccountTwoFactor{}
+	if _, err := tf.Enroll("jane@example.org", ""); err == nil {
+		t.Fatalf("enrollment without a passphrase succeeded")
+	}
+
+	enrollment, err := tf.Enroll("jane@example.org", passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tf.Enabled || tf.PendingSecret == "" || tf.PendingSecret == enrollment.Secret {
+		t.Fatalf("pending secret not stored encrypted")
+	}
+
+	if _, err := tf.Activate("000000", passphrase, now); err == nil {
+		t.Fatalf("activation with an invalid code succeeded")
+	}
+	secret, _ := totp.DecodeSecret(enrollment.Secret)
+	code := totp.Code(secret, totp.Counter(now, totp.DefaultPeriod), totp.DefaultDigits)
+	recoveryCodes, err := tf.Activate(code, passphrase, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tf.Enabled || tf.PendingSecret != "" || len(tf.RecoveryCodes) != len(recoveryCodes) {
+		t.Fatalf("two-factor authentication not activated properly")
+	}
+
+	// The activation code may not be reused
+	if err := tf.Verify(code, passphrase, now); err == nil {
+		t.Errorf("replayed code accepted")
+	}
+
+	// Recovery codes can only be used once
+	if err := tf.Verify(recoveryCodes[0], passphrase, now); err != nil {
+		t.Errorf("recovery code rejected: %v", err)
+	}
+	if err := tf.Verify(recoveryCodes[0], passphrase, now); err == nil {
+		t.Errorf("recovery code accepted twice")
+	}
+
+	clone := tf.Clone(true)
+	if !clone.Enabled || clone.Secret != "" || clone.RecoveryCodes != nil {
+		t.Errorf("secrets not erased in clone")
+	}
+
+	if err := tf.Disable(recoveryCodes[1], passphrase, now); err != nil {
+		t.Fatal(err)
+	}
+	if tf.Enabled || tf.Secret != "" {
+		t.Errorf("two-factor authentication not disabled")
+	}
+}
@@ -83,11 +83,16 @@ func getEndpoints() []endpoint {
 		{config.EndpointLogout, callMethodEndpoint, createMethodCallbacks(handleLogout, nil), true},
 		{config.EndpointResetPassword, callMethodEndpoint, createMethodCallbacks(nil, handleResetPassword), true},
 		{config.EndpointContact, callMethodEndpoint, createMethodCallbacks(nil, handleContact), true},
+		// Two-factor authentication endpoints
+		{config.EndpointTwoFactorEnroll, callMethodEndpoint, createMethodCallbacks(nil, handleTwoFactorEnroll), true},
+		{config.EndpointTwoFactorActivate, callMethodEndpoint, createMethodCallbacks(nil, handleTwoFactorActivate), true},
+		{config.EndpointTwoFactorDisable, callMethodEndpoint, createMethodCallbacks(nil, handleTwoFactorDisable), true},
 		// Authentication endpoints
 		{config.EndpointVerifyUserToken, callMethodEndpoint, createMethodCallbacks(handleVerifyUserToken, nil), true},
 		// Access management endpoints
 		{config.EndpointGrantSitesAccess, callMethodEndpoint, createMethodCallbacks(nil, handleGrantSitesAccess), false},
 		{config.EndpointGrantGOCDBAccess, callMethodEndpoint, createMethodCallbacks(nil, handleGrantGOCDBAccess), false},
+		{config.EndpointEnforceTwoFactor, callMethodEndpoint, createMethodCallbacks(nil, handleEnforceTwoFactor), false},
 		// Alerting endpoints
 		{config.EndpointDispatchAlert, callMethodEndpoint, createMethodCallbacks(nil, handleDispatchAlert), false},
 	}
@@ -135,9 +140,10 @@ func callMethodEndpoint(siteacc *SiteAccounts, ep endpoint, w http.ResponseWrite
 					resp.Error = ""
 					resp.Data = respData
 				} else {
+					// Failures may still carry additional data (e.g., to request a second login factor)
 					resp.Success = false
 					resp.Error = fmt.Sprintf("%v", err)
-					resp.Data = nil
+					resp.Data = respData
 				}
 			}
 		}
@@ -187,7 +193,7 @@ func handleUpdate(siteacc *SiteAccounts, values url.Values, body []byte, session
 		return nil, err
 	}
 
-	email, setPassword, err := processInvoker(siteacc, values, session)
+	email, setPassword, err := processSecuredInvoker(siteacc, values, session)
 	if err != nil {
 		return nil, err
 	}
@@ -207,7 +213,7 @@ func handleConfigure(siteacc *SiteAccounts, values url.Values, body []byte, sess
 		return nil, err
 	}
 
-	email, _, err := processInvoker(siteacc, values, session)
+	email, _, err := processSecuredInvoker(siteacc, values, session)
 	if err != nil {
 		return nil, err
 	}
@@ -248,7 +254,7 @@ func handleSiteGet(siteacc *SiteAccounts, values url.Values, body []byte, sessio
 }
 
 func handleSitesConfigure(siteacc *SiteAccounts, values url.Values, body []byte, session *html.Session) (interface{}, error) {
-	email, _, err := processInvoker(siteacc, values, session)
+	email, _, err := processSecuredInvoker(siteacc, values, session)
 	if err != nil {
 		return nil, err
 	}
@@ -280,9 +286,17 @@ func handleLogin(siteacc *SiteAccounts, values url.Values, body []byte, session
 		return nil, err
 	}
 
+	code, err := unmarshalTwoFactorCode(body)
+	if err != nil {
+		return nil, err
+	}
+
 	// Login the user through the users manager
-	token, err := siteacc.UsersManager().LoginUser(account.Email, account.Password.Value, values.Get("scope"), session)
+	token, err := siteacc.UsersManager().LoginUser(account.Email, account.Password.Value, code, values.Get("scope"), session)
 	if err != nil {
+		if errors.Is(err, manager.ErrTwoFactorRequired) {
+			return map[string]interface{}{"twoFactorRequired": true}, err
+		}
 		return nil, errors.Wrap(err, "unable to login user")
 	}
 
@@ -328,6 +342,65 @@ func handleContact(siteacc *SiteAccounts, values url.Values, body []byte, sessio
 	return nil, nil
 }
 
+func handleTwoFactorEnroll(siteacc *SiteAccounts, values url.Values, body []byte, session *html.Session) (interface{}, error) {
+	email, _, err := processInvoker(siteacc, values, session)
+	if err != nil {
+		return nil, err
+	}
+
+	// Start the enrollment through the accounts manager
+	enrollment, err := siteacc.AccountsManager().EnrollTwoFactor(&data.Account{Email: email})
+	if err != nil {
+		return nil, err
+	}
+
+	return enrollment, nil
+}
+
+func handleTwoFactorActivate(siteacc *SiteAccounts, values url.Values, body []byte, session *html.Session) (interface{}, error) {
+	code, err := unmarshalTwoFactorCode(body)
+	if err != nil {
+		return nil, err
+	}
+
+	email, _, err := processInvoker(siteacc, values, session)
+	if err != nil {
+		return nil, err
+	}
+
+	// Activate two-factor authentication through the accounts manager
+	recoveryCodes, err := siteacc.AccountsManager().ActivateTwoFactor(&data.Account{Email: email}, code)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{"recoveryCodes": recoveryCodes}, nil
+}
+
+func handleTwoFactorDisable(siteacc *SiteAccounts, values url.Values, body []byte, session *html.Session) (interface{}, error) {
+	code, err := unmarshalTwoFactorCode(body)
+	if err != nil {
+		return nil, err
+	}
+
+	email, _, err := processInvoker(siteacc, values, session)
+	if err != nil {
+		return nil, err
+	}
+
+	// Accounts of operators requiring two-factor authentication must keep it
+	if op := siteacc.OperatorsManager().FindOperator(session.LoggedInUser().Account.Operator); op != nil && op.Settings.RequireTwoFactor {
+		return nil, errors.Errorf("two-factor authentication is required by the operator")
+	}
+
+	// Disable two-factor authentication through the accounts manager
+	if err := siteacc.AccountsManager().DisableTwoFactor(&data.Account{Email: email}, code); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
 func handleVerifyUserToken(siteacc *SiteAccounts, values url.Values, body []byte, session *html.Session) (interface{}, error) {
 	token := values.Get("token")
 	if token == "" {
@@ -400,6 +473,36 @@ func handleGrantAccess(accessSetter accessSetterCallback, siteacc *SiteAccounts,
 	return nil, nil
 }
 
+func handleEnforceTwoFactor(siteacc *SiteAccounts, values url.Values, body []byte, session *html.Session) (interface{}, error) {
+	opID := values.Get("operator")
+	if opID == "" {
+		return nil, errors.Errorf("no operator specified")
+	}
+
+	if val := values.Get("status"); len(val) > 0 {
+		var enforce bool
+		switch strings.ToLower(val) {
+		case "true":
+			enforce = true
+
+		case "false":
+			enforce = false
+
+		default:
+			return nil, errors.Errorf("unsupported enforcement status %v", val)
+		}
+
+		// Change the two-factor requirement through the operators manager
+		if err := siteacc.OperatorsManager().EnforceTwoFactor(opID, enforce); err != nil {
+			return nil, errors.Wrap(err, "unable to change the two-factor requirement of the operator")
+		}
+	} else {
+		return nil, errors.Errorf("no enforcement status provided")
+	}
+
+	return nil, nil
+}
+
 func unmarshalRequestData(body []byte) (*data.Account, error) {
 	account := &data.Account{}
 	if err := json.Unmarshal(body, account); err != nil {
@@ -409,6 +512,16 @@ func unmarshalRequestData(body []byte) (*data.Account, error) {
 	return account, nil
 }
 
+func unmarshalTwoFactorCode(body []byte) (string, error) {
+	codeData := &struct {
+		Code string `json:"code"`
+	}{}
+	if err := json.Unmarshal(body, codeData); err != nil {
+		return "", errors.Wrap(err, "invalid two-factor data")
+	}
+	return strings.TrimSpace(codeData.Code), nil
+}
+
 func findAccount(siteacc *SiteAccounts, by string, value string) (*data.Account, error) {
 	if len(by) == 0 && len(value) == 0 {
 		return nil, errors.Errorf("missing search criteria")
@@ -442,3 +555,16 @@ func processInvoker(siteacc *SiteAccounts, values url.Values, session *html.Sess
 
 	return email, invokedByUser, nil
 }
+
+// processSecuredInvoker works like processInvoker, but additionally refuses accounts that haven't set up two-factor authentication
+// although their operator requires it; all endpoints changing credentials or sites must use it.
+func processSecuredInvoker(siteacc *SiteAccounts, values url.Values, session *html.Session) (string, bool, error) {
+	email, invokedByUser, err := processInvoker(siteacc, values, session)
+	if err != nil {
+		return "", false, err
+	}
+	if err := siteacc.UsersManager().CheckTwoFactorRequirement(email); err != nil {
+		return "", false, err
+	}
+	return email, invokedByUser, nil
+}
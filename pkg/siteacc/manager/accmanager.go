@@ -46,6 +46,7 @@ type AccountsManager struct {
 	storage data.Storage
 
 	accounts          data.Accounts
+	accountsRevision  int64
 	accountsListeners []AccountsListener
 
 	smtp *smtpclient.SMTPCredentials
@@ -88,11 +89,43 @@ func (mngr *AccountsManager) initialize(storage data.Storage, conf *config.Confi
 }
 
 func (mngr *AccountsManager) readAllAccounts() {
-	if accounts, err := mngr.storage.ReadAccounts(); err == nil {
-		mngr.accounts = *accounts
-	} else {
+	accounts, err := mngr.storage.ReadAccounts()
+	if err != nil {
 		// Just warn when not being able to read accounts
 		mngr.log.Warn().Err(err).Msg("error while reading accounts")
+		return
+	}
+
+	// Existing accounts are updated in place, as they might still be referenced (e.g., by sessions)
+	existing := make(map[string]*data.Account, len(mngr.accounts))
+	for _, account := range mngr.accounts {
+		existing[strings.ToLower(account.Email)] = account
+	}
+	for i, account := range *accounts {
+		if acc, ok := existing[strings.ToLower(account.Email)]; ok {
+			*acc = *account
+			(*accounts)[i] = acc
+		}
+	}
+	mngr.accounts = *accounts
+}
+
+// refreshAccounts reads the accounts again if they are kept in a shared storage and other instances have modified them since.
+// The caller must hold the write lock.
+func (mngr *AccountsManager) refreshAccounts() {
+	storage, ok := mngr.storage.(data.SharedStorage)
+	if !ok {
+		return
+	}
+
+	revision, err := storage.Revision()
+	if err != nil {
+		mngr.log.Warn().Err(err).Msg("error while reading the accounts revision")
+		return
+	}
+	if revision != mngr.accountsRevision {
+		mngr.readAllAccounts()
+		mngr.accountsRevision = revision
 	}
 }
 
@@ -137,6 +170,7 @@ func (mngr *AccountsManager) findAccountByPredicate(predicate func(*data.Account
 func (mngr *AccountsManager) CreateAccount(accountData *data.Account) error {
 	mngr.mutex.Lock()
 	defer mngr.mutex.Unlock()
+	mngr.refreshAccounts()
 
 	// Accounts must be unique (identified by their email address)
 	if account, _ := mngr.findAccount(FindByEmail, accountData.Email); account != nil {
@@ -161,6 +195,7 @@ func (mngr *AccountsManager) CreateAccount(accountData *data.Account) error {
 func (mngr *AccountsManager) UpdateAccount(accountData *data.Account, setPassword bool, copyData bool) error {
 	mngr.mutex.Lock()
 	defer mngr.mutex.Unlock()
+	mngr.refreshAccounts()
 
 	account, err := mngr.findAccount(FindByEmail, accountData.Email)
 	if err != nil {
@@ -185,6 +220,7 @@ func (mngr *AccountsManager) UpdateAccount(accountData *data.Account, setPasswor
 func (mngr *AccountsManager) ConfigureAccount(accountData *data.Account) error {
 	mngr.mutex.Lock()
 	defer mngr.mutex.Unlock()
+	mngr.refreshAccounts()
 
 	account, err := mngr.findAccount(FindByEmail, accountData.Email)
 	if err != nil {
@@ -207,7 +243,7 @@ func (mngr *AccountsManager) ConfigureAccount(accountData *data.Account) error {
 
 // ResetPassword resets the password for the given user.
 func (mngr *AccountsManager) ResetPassword(name string) error {
-	account, err := mngr.findAccount(FindByEmail, name)
+	account, err := mngr.FindAccountEx(FindByEmail, name, false)
 	if err != nil {
 		return errors.Wrap(err, "user to reset password for not found")
 	}
@@ -229,8 +265,9 @@ func (mngr *AccountsManager) FindAccount(by string, value string) (*data.Account
 
 // FindAccountEx is used to find an account by various criteria and optionally clone the account.
 func (mngr *AccountsManager) FindAccountEx(by string, value string, cloneAccount bool) (*data.Account, error) {
-	mngr.mutex.RLock()
-	defer mngr.mutex.RUnlock()
+	mngr.mutex.Lock()
+	defer mngr.mutex.Unlock()
+	mngr.refreshAccounts()
 
 	account, err := mngr.findAccount(by, value)
 	if err != nil {
@@ -248,6 +285,7 @@ func (mngr *AccountsManager) FindAccountEx(by string, value string, cloneAccount
 func (mngr *AccountsManager) GrantSitesAccess(accountData *data.Account, grantAccess bool) error {
 	mngr.mutex.Lock()
 	defer mngr.mutex.Unlock()
+	mngr.refreshAccounts()
 
 	account, err := mngr.findAccount(FindByEmail, accountData.Email)
 	if err != nil {
@@ -261,6 +299,7 @@ func (mngr *AccountsManager) GrantSitesAccess(accountData *data.Account, grantAc
 func (mngr *AccountsManager) GrantGOCDBAccess(accountData *data.Account, grantAccess bool) error {
 	mngr.mutex.Lock()
 	defer mngr.mutex.Unlock()
+	mngr.refreshAccounts()
 
 	account, err := mngr.findAccount(FindByEmail, accountData.Email)
 	if err != nil {
@@ -270,10 +309,90 @@ func (mngr *AccountsManager) GrantGOCDBAccess(accountData *data.Account, grantAc
 	return mngr.grantAccess(account, &account.Data.GOCDBAccess, grantAccess, email.SendGOCDBAccessGranted)
 }
 
+// EnrollTwoFactor starts the two-factor authentication enrollment of the account identified by the account email.
+func (mngr *AccountsManager) EnrollTwoFactor(accountData *data.Account) (*data.TwoFactorEnrollment, error) {
+	mngr.mutex.Lock()
+	defer mngr.mutex.Unlock()
+	mngr.refreshAccounts()
+
+	account, err := mngr.findAccount(FindByEmail, accountData.Email)
+	if err != nil {
+		return nil, errors.Wrap(err, "no account with the specified email exists")
+	}
+
+	enrollment, err := account.TwoFactor.Enroll(account.Email, mngr.conf.Security.CredentialsPassphrase)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to enroll two-factor authentication")
+	}
+	mngr.twoFactorUpdated(account)
+
+	return enrollment, nil
+}
+
+// ActivateTwoFactor completes the two-factor authentication enrollment; on success, the generated recovery codes are returned.
+func (mngr *AccountsManager) ActivateTwoFactor(accountData *data.Account, code string) ([]string, error) {
+	mngr.mutex.Lock()
+	defer mngr.mutex.Unlock()
+	mngr.refreshAccounts()
+
+	account, err := mngr.findAccount(FindByEmail, accountData.Email)
+	if err != nil {
+		return nil, errors.Wrap(err, "no account with the specified email exists")
+	}
+
+	recoveryCodes, err := account.TwoFactor.Activate(code, mngr.conf.Security.CredentialsPassphrase, time.Now())
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to activate two-factor authentication")
+	}
+	mngr.twoFactorUpdated(account)
+
+	return recoveryCodes, nil
+}
+
+// DisableTwoFactor turns off two-factor authentication for the account identified by the account email.
+func (mngr *AccountsManager) DisableTwoFactor(accountData *data.Account, code string) error {
+	mngr.mutex.Lock()
+	defer mngr.mutex.Unlock()
+	mngr.refreshAccounts()
+
+	account, err := mngr.findAccount(FindByEmail, accountData.Email)
+	if err != nil {
+		return errors.Wrap(err, "no account with the specified email exists")
+	}
+
+	if err := account.TwoFactor.Disable(code, mngr.conf.Security.CredentialsPassphrase, time.Now()); err != nil {
+		return errors.Wrap(err, "unable to disable two-factor authentication")
+	}
+	mngr.twoFactorUpdated(account)
+
+	return nil
+}
+
+// VerifyTwoFactor checks a two-factor authentication (or recovery) code of the account identified by the account email.
+func (mngr *AccountsManager) VerifyTwoFactor(accountData *data.Account, code string) error {
+	mngr.mutex.Lock()
+	defer mngr.mutex.Unlock()
+	mngr.refreshAccounts()
+
+	account, err := mngr.findAccount(FindByEmail, accountData.Email)
+	if err != nil {
+		return errors.Wrap(err, "no account with the specified email exists")
+	}
+
+	if err := account.TwoFactor.Verify(code, mngr.conf.Security.CredentialsPassphrase, time.Now()); err != nil {
+		return err
+	}
+	// The last used time step and the remaining recovery codes need to be persisted
+	mngr.twoFactorUpdated(account)
+
+	return nil
+}
+
 // RemoveAccount removes the account identified by the account email; if no such account exists, an error is returned.
 func (mngr *AccountsManager) RemoveAccount(accountData *data.Account) error {
 	mngr.mutex.Lock()
 	defer mngr.mutex.Unlock()
+	mngr.refreshAccounts()
 
 	for i, account := range mngr.accounts {
 		if strings.EqualFold(account.Email, accountData.Email) {
@@ -296,8 +415,9 @@ func (mngr *AccountsManager) SendContactForm(account *data.Account, subject, mes
 
 // CloneAccounts retrieves all accounts currently stored by cloning the data, thus avoiding race conflicts and making outside modifications impossible.
 func (mngr *AccountsManager) CloneAccounts(erasePasswords bool) data.Accounts {
-	mngr.mutex.RLock()
-	defer mngr.mutex.RUnlock()
+	mngr.mutex.Lock()
+	defer mngr.mutex.Unlock()
+	mngr.refreshAccounts()
 
 	clones := make(data.Accounts, 0, len(mngr.accounts))
 	for _, acc := range mngr.accounts {
@@ -323,6 +443,13 @@ func (mngr *AccountsManager) grantAccess(account *data.Account, accessFlag *bool
 	return nil
 }
 
+func (mngr *AccountsManager) twoFactorUpdated(account *data.Account) {
+	account.DateModified = time.Now()
+
+	mngr.storage.AccountUpdated(account)
+	mngr.writeAllAccounts()
+}
+
 func (mngr *AccountsManager) callListeners(account *data.Account, cb AccountsListenerCallback) {
 	for _, listener := range mngr.accountsListeners {
 		cb(listener, account)
@@ -35,7 +35,8 @@ type OperatorsManager struct {
 
 	storage data.Storage
 
-	operators data.Operators
+	operators         data.Operators
+	operatorsRevision int64
 
 	mutex sync.RWMutex
 }
@@ -63,11 +64,43 @@ func (mngr *OperatorsManager) initialize(storage data.Storage, conf *config.Conf
 }
 
 func (mngr *OperatorsManager) readAllOperators() {
-	if ops, err := mngr.storage.ReadOperators(); err == nil {
-		mngr.operators = *ops
-	} else {
+	ops, err := mngr.storage.ReadOperators()
+	if err != nil {
 		// Just warn when not being able to read operators
 		mngr.log.Warn().Err(err).Msg("error while reading operators")
+		return
+	}
+
+	// Existing operators are updated in place, as they might still be referenced (e.g., by sessions)
+	existing := make(map[string]*data.Operator, len(mngr.operators))
+	for _, op := range mngr.operators {
+		existing[strings.ToLower(op.ID)] = op
+	}
+	for i, op := range *ops {
+		if o, ok := existing[strings.ToLower(op.ID)]; ok {
+			*o = *op
+			(*ops)[i] = o
+		}
+	}
+	mngr.operators = *ops
+}
+
+// refreshOperators reads the operators again if they are kept in a shared storage and other instances have modified them since.
+// The caller must hold the write lock.
+func (mngr *OperatorsManager) refreshOperators() {
+	storage, ok := mngr.storage.(data.SharedStorage)
+	if !ok {
+		return
+	}
+
+	revision, err := storage.Revision()
+	if err != nil {
+		mngr.log.Warn().Err(err).Msg("error while reading the operators revision")
+		return
+	}
+	if revision != mngr.operatorsRevision {
+		mngr.readAllOperators()
+		mngr.operatorsRevision = revision
 	}
 }
 
@@ -80,8 +113,9 @@ func (mngr *OperatorsManager) writeAllOperators() {
 
 // GetOperator retrieves the operator with the given ID, creating it first if necessary.
 func (mngr *OperatorsManager) GetOperator(id string, clone bool) (*data.Operator, error) {
-	mngr.mutex.RLock()
-	defer mngr.mutex.RUnlock()
+	mngr.mutex.Lock()
+	defer mngr.mutex.Unlock()
+	mngr.refreshOperators()
 
 	op, err := mngr.getOperator(id)
 	if err != nil {
@@ -97,12 +131,20 @@ func (mngr *OperatorsManager) GetOperator(id string, clone bool) (*data.Operator
 
 // FindOperator returns the operator specified by the ID if one exists.
 func (mngr *OperatorsManager) FindOperator(id string) *data.Operator {
+	mngr.mutex.Lock()
+	defer mngr.mutex.Unlock()
+	mngr.refreshOperators()
+
 	op, _ := mngr.findOperator(id)
 	return op
 }
 
 // FindSite returns the site specified by the ID if one exists.
 func (mngr *OperatorsManager) FindSite(id string) (*data.Operator, *data.Site) {
+	mngr.mutex.Lock()
+	defer mngr.mutex.Unlock()
+	mngr.refreshOperators()
+
 	for _, op := range mngr.operators {
 		for _, site := range op.Sites {
 			if strings.EqualFold(site.ID, id) {
@@ -117,6 +159,7 @@ func (mngr *OperatorsManager) FindSite(id string) (*data.Operator, *data.Site) {
 func (mngr *OperatorsManager) UpdateOperator(opData *data.Operator) error {
 	mngr.mutex.Lock()
 	defer mngr.mutex.Unlock()
+	mngr.refreshOperators()
 
 	op, err := mngr.getOperator(opData.ID)
 	if err != nil {
@@ -133,10 +176,29 @@ func (mngr *OperatorsManager) UpdateOperator(opData *data.Operator) error {
 	return nil
 }
 
+// EnforceTwoFactor sets whether all accounts of the operator identified by the ID are required to use two-factor authentication.
+func (mngr *OperatorsManager) EnforceTwoFactor(id string, enforce bool) error {
+	mngr.mutex.Lock()
+	defer mngr.mutex.Unlock()
+	mngr.refreshOperators()
+
+	op, err := mngr.getOperator(id)
+	if err != nil {
+		return errors.Wrap(err, "operator to configure not found")
+	}
+
+	op.Settings.RequireTwoFactor = enforce
+	mngr.storage.OperatorUpdated(op)
+	mngr.writeAllOperators()
+
+	return nil
+}
+
 // CloneOperators retrieves all operators currently stored by cloning the data, thus avoiding race conflicts and making outside modifications impossible.
 func (mngr *OperatorsManager) CloneOperators(eraseCredentials bool) data.Operators {
-	mngr.mutex.RLock()
-	defer mngr.mutex.RUnlock()
+	mngr.mutex.Lock()
+	defer mngr.mutex.Unlock()
+	mngr.refreshOperators()
 
 	clones := make(data.Operators, 0, len(mngr.operators))
 	for _, op := range mngr.operators {
@@ -22,6 +22,7 @@ import (
 	"strings"
 
 	"github.com/cs3org/reva/pkg/siteacc/config"
+	"github.com/cs3org/reva/pkg/siteacc/data"
 	"github.com/cs3org/reva/pkg/siteacc/html"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
@@ -40,6 +41,9 @@ const (
 	defaultPasswordLength = 12
 )
 
+// ErrTwoFactorRequired is returned on login if the account requires a two-factor authentication code which wasn't provided.
+var ErrTwoFactorRequired = errors.New("two-factor authentication code required")
+
 func (mngr *UsersManager) initialize(conf *config.Configuration, log *zerolog.Logger, opsManager *OperatorsManager, accountsManager *AccountsManager) error {
 	if conf == nil {
 		return errors.Errorf("no configuration provided")
@@ -64,8 +68,9 @@ func (mngr *UsersManager) initialize(conf *config.Configuration, log *zerolog.Lo
 	return nil
 }
 
-// LoginUser tries to login a given username/password pair. On success, the corresponding user account is stored in the session and a user token is returned.
-func (mngr *UsersManager) LoginUser(name, password string, scope string, session *html.Session) (string, error) {
+// LoginUser tries to login a given username/password pair. If the account uses two-factor authentication, the code must be provided as well; if it is missing, ErrTwoFactorRequired is returned.
+// On success, the corresponding user account is stored in the session and a user token is returned.
+func (mngr *UsersManager) LoginUser(name, password string, code string, scope string, session *html.Session) (string, error) {
 	account, err := mngr.accountsManager.FindAccountEx(FindByEmail, name, false)
 	if err != nil {
 		return "", errors.Wrap(err, "no account with the specified email exists")
@@ -76,6 +81,16 @@ func (mngr *UsersManager) LoginUser(name, password string, scope string, session
 		return "", errors.Errorf("invalid password")
 	}
 
+	// Verify the second factor if the account uses one
+	if account.TwoFactor.Enabled {
+		if code == "" {
+			return "", ErrTwoFactorRequired
+		}
+		if err := mngr.accountsManager.VerifyTwoFactor(account, code); err != nil {
+			return "", errors.Wrap(err, "two-factor authentication failed")
+		}
+	}
+
 	// Check if the user has access to the specified scope
 	if !account.CheckScopeAccess(scope) {
 		return "", errors.Errorf("no access to the specified scope granted")
@@ -87,6 +102,12 @@ func (mngr *UsersManager) LoginUser(name, password string, scope string, session
 		return "", errors.Wrap(err, "no operator with the specified ID exists")
 	}
 
+	// Operators may require their accounts to use two-factor authentication; without it, only the account panel can be used (to set it up),
+	// and all endpoints changing credentials or sites refuse such accounts (see CheckTwoFactorRequirement)
+	if !strings.EqualFold(scope, data.ScopeDefault) && !checkTwoFactorRequirement(account, op) {
+		return "", errors.Errorf("two-factor authentication is required by the operator")
+	}
+
 	// Store the user account in the session
 	session.LoginUser(account, op)
 
@@ -124,6 +145,10 @@ func (mngr *UsersManager) VerifyUserToken(token string, user string, scope strin
 			if !acc.CheckScopeAccess(scope) {
 				return "", errors.Errorf("no scope access")
 			}
+
+			if op := mngr.operatorsManager.FindOperator(acc.Operator); op != nil && !checkTwoFactorRequirement(acc, op) {
+				return "", errors.Errorf("two-factor authentication required")
+			}
 		} else {
 			return "", errors.Errorf("invalid email")
 		}
@@ -140,6 +165,27 @@ func (mngr *UsersManager) VerifyUserToken(token string, user string, scope strin
 	return newToken, nil
 }
 
+// CheckTwoFactorRequirement returns an error if the operator of the account identified by the email requires two-factor authentication
+// and the account hasn't set it up yet. Endpoints changing credentials or sites must check this, as such accounts can still log in to set it up.
+func (mngr *UsersManager) CheckTwoFactorRequirement(email string) error {
+	account, err := mngr.accountsManager.FindAccountEx(FindByEmail, email, false)
+	if err != nil {
+		return errors.Wrap(err, "no account with the specified email exists")
+	}
+	op, err := mngr.operatorsManager.GetOperator(account.Operator, false)
+	if err != nil {
+		return errors.Wrap(err, "no operator with the specified ID exists")
+	}
+	if !checkTwoFactorRequirement(account, op) {
+		return errors.Errorf("two-factor authentication is required by the operator")
+	}
+	return nil
+}
+
+func checkTwoFactorRequirement(account *data.Account, op *data.Operator) bool {
+	return !op.Settings.RequireTwoFactor || account.TwoFactor.Enabled
+}
+
 // NewUsersManager creates a new users manager instance.
 func NewUsersManager(conf *config.Configuration, log *zerolog.Logger, opsManager *OperatorsManager, accountsManager *AccountsManager) (*UsersManager, error) {
 	mngr := &UsersManager{}
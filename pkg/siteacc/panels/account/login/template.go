@@ -53,7 +53,12 @@ function handleAction(action) {
 			window.location.replace("{{getServerAddress}}/account/?path=manage");
 		} else {
 			var resp = JSON.parse(this.responseText);
-			setState(STATE_ERROR, "An error occurred while trying to login your account:<br><em>" + resp.error + "</em>", "form", null, true);
+			if (resp.data && resp.data.twoFactorRequired) {
+				document.getElementById("twoFactor").style.display = "contents";
+				setState(STATE_STATUS, "Please enter the code from your authenticator app (or one of your recovery codes).", "form", "code", true);
+			} else {
+				setState(STATE_ERROR, "An error occurred while trying to login your account:<br><em>" + resp.error + "</em>", "form", null, true);
+			}
 		}
 	}
 
@@ -61,7 +66,8 @@ function handleAction(action) {
         "email": formData.getTrimmed("email"),
 		"password": {
 			"value": formData.get("password")
-		}
+		},
+		"code": formData.getTrimmed("code")
     };
 
     xhr.send(JSON.stringify(postData));
@@ -122,10 +128,15 @@ const tplBody = `
 			Forgot your password? Click <a href="#" onClick="handleResetPassword();">here</a> to reset it.
 		</div>
 
-		<div style="grid-row: 4; align-self: center;">
+		<div id="twoFactor" style="display: none;">
+			<div style="grid-row: 4;"><label for="code">Verification code: <span class="mandatory">*</span></label></div>
+			<div style="grid-row: 5;"><input type="text" id="code" name="code" autocomplete="one-time-code" placeholder="123456"/></div>
+		</div>
+
+		<div style="grid-row: 6; align-self: center;">
 			Fields marked with <span class="mandatory">*</span> are mandatory.
 		</div>
-		<div style="grid-row: 4; grid-column: 2; text-align: right;">
+		<div style="grid-row: 6; grid-column: 2; text-align: right;">
 			<button type="submit" style="font-weight: bold;">Login</button>
 		</div>	
 	</form>	
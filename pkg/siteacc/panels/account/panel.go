@@ -113,6 +113,11 @@ func (panel *Panel) PreExecute(session *html.Session, path string, w http.Respon
 	protectedPaths := []string{templateManage, templateSettings, templateEdit, templateSites, templateContact}
 
 	if user := session.LoggedInUser(); user != nil {
+		// If the operator requires two-factor authentication which the user hasn't set up yet, only allow the settings page
+		if user.Operator.Settings.RequireTwoFactor && !user.Account.TwoFactor.Enabled && path != templateSettings {
+			return panel.Redirect(templateSettings, w, r), nil
+		}
+
 		switch path {
 		case templateSites:
 			// If the logged in user doesn't have sites access, redirect him back to the main account page
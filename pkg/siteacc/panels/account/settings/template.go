@@ -52,6 +52,65 @@ function handleAction(action) {
 
     xhr.send(JSON.stringify(postData));
 }
+
+function handleTwoFactorEnroll() {
+	setState(STATE_STATUS, "Generating a new secret... this should only take a moment.", "form2fa", null, false);
+
+	var xhr = new XMLHttpRequest();
+    xhr.open("POST", "{{getServerAddress}}/2fa-enroll?invoker=user");
+    xhr.setRequestHeader('Content-Type', 'application/json; charset=UTF-8');
+
+	xhr.onload = function() {
+		var resp = JSON.parse(this.responseText);
+		if (this.status == 200) {
+			document.getElementById("secret").innerHTML = resp.data.secret;
+			document.getElementById("secretURI").innerHTML = resp.data.uri;
+			setElementVisibility("enrollment", true);
+			setState(STATE_SUCCESS, "Add the secret to your authenticator app and enter the generated code to activate two-factor authentication.", "form2fa", "code", true);
+		} else {
+			setState(STATE_ERROR, "An error occurred while trying to enroll two-factor authentication:<br><em>" + resp.error + "</em>", "form2fa", null, true);
+		}
+	}
+
+    xhr.send();
+}
+
+function handleTwoFactorAction(action) {
+	const formData = new FormData(document.getElementById("form2fa"));
+	if (formData.getTrimmed("code") == "") {
+		setState(STATE_ERROR, "Please enter a verification code.", "form2fa", "code", true);
+		return;
+	}
+
+	setState(STATE_STATUS, "Verifying code... this should only take a moment.", "form2fa", null, false);
+
+	var xhr = new XMLHttpRequest();
+    xhr.open("POST", "{{getServerAddress}}/" + action + "?invoker=user");
+    xhr.setRequestHeader('Content-Type', 'application/json; charset=UTF-8');
+
+	xhr.onload = function() {
+		var resp = JSON.parse(this.responseText);
+		if (this.status == 200) {
+			if (resp.data && resp.data.recoveryCodes) {
+				document.getElementById("recoveryCodes").innerHTML = resp.data.recoveryCodes.join("<br>");
+				setElementVisibility("enrollment", false);
+				setElementVisibility("recovery", true);
+				setState(STATE_SUCCESS, "Two-factor authentication has been enabled! Store your recovery codes in a safe place; they will not be shown again.", "form2fa", null, false);
+			} else {
+				setState(STATE_SUCCESS, "Two-factor authentication has been disabled! Reloading...");
+				location.reload();
+			}
+		} else {
+			setState(STATE_ERROR, "An error occurred while verifying the code:<br><em>" + resp.error + "</em>", "form2fa", null, true);
+		}
+	}
+
+	var postData = {
+		"code": formData.getTrimmed("code")
+	};
+
+    xhr.send(JSON.stringify(postData));
+}
 `
 
 const tplStyleSheet = `
@@ -86,6 +145,48 @@ const tplBody = `
 		</div>
 	</form>
 </div>
+<div>&nbsp;</div>
+<div>
+	<form id="form2fa" method="POST" class="box" style="width: 100%;" onSubmit="return false;">
+		<h3>Two-factor authentication</h3>
+		<hr>
+	{{if .Account.TwoFactor.Enabled}}
+		<p>Two-factor authentication is <strong>enabled</strong> for your account. To disable it, enter a code from your authenticator app or one of your recovery codes.</p>
+		<p>
+			<label for="code">Verification code:</label>
+			<input type="text" id="code" name="code" autocomplete="one-time-code"/>
+		</p>
+		<div style="text-align: right;">
+			<button type="button" onClick="handleTwoFactorAction('2fa-disable');">Disable</button>
+		</div>
+	{{else}}
+		{{if .Operator.Settings.RequireTwoFactor}}
+		<p><strong>Your operator requires two-factor authentication.</strong> Please set it up before using your account.</p>
+		{{end}}
+		<p>Protect your account using a time-based one-time password (TOTP) generated by an authenticator app.</p>
+		<div id="enrollment" class="hidden">
+			<p>
+				Secret: <code id="secret"></code><br>
+				<span style="font-size: 0.8em;">URI: <code id="secretURI"></code></span>
+			</p>
+			<p>
+				<label for="code">Verification code:</label>
+				<input type="text" id="code" name="code" autocomplete="one-time-code"/>
+			</p>
+			<div style="text-align: right;">
+				<button type="button" style="font-weight: bold;" onClick="handleTwoFactorAction('2fa-activate');">Activate</button>
+			</div>
+		</div>
+		<div id="recovery" class="hidden">
+			<p>Your recovery codes:</p>
+			<p><code id="recoveryCodes"></code></p>
+		</div>
+		<div style="text-align: right;">
+			<button type="button" onClick="handleTwoFactorEnroll();">Set up</button>
+		</div>
+	{{end}}
+	</form>
+</div>
 <div>
 	<p>Go <a href="{{getServerAddress}}/account/?path=manage">back</a> to the main account page.</p>
 </div>
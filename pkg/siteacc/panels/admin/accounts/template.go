@@ -85,6 +85,7 @@ const tplBody = `
 				<ul style="padding-left: 1em; padding-top: 0em;">	
 					<li>Sites access: <em>{{if .Data.SitesAccess}}Granted{{else}}Not granted{{end}}</em></li>
 					<li>GOCDB access: <em>{{if .Data.GOCDBAccess}}Granted{{else}}Not granted{{end}}</em></li>	
+					<li>Two-factor authentication: <em>{{if .TwoFactor.Enabled}}Enabled{{else}}Disabled{{end}}</em></li>
 				</ul>
 			</div>
 
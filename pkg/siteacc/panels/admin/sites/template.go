@@ -19,6 +19,24 @@
 package sites
 
 const tplJavaScript = `
+function handleAction(action) {
+	var xhr = new XMLHttpRequest();
+    xhr.open("POST", "{{getServerAddress}}/" + action);
+    xhr.setRequestHeader('Content-Type', 'application/json; charset=UTF-8');
+
+	setState(STATE_STATUS, "Performing request...");
+
+	xhr.onload = function() {
+		if (this.status == 200) {
+			setState(STATE_SUCCESS, "Done! Reloading...");
+			location.reload();
+		} else {
+			setState(STATE_ERROR, "An error occurred while performing the request: " + this.responseText);
+		}
+	}
+
+    xhr.send();
+}
 `
 
 const tplStyleSheet = `
@@ -56,6 +74,18 @@ const tplBody = `
 					</ul>
 				</div>
 			</div>
+
+			<div>&nbsp;</div>
+
+			<div>
+				<form method="POST" style="width: 100%;">
+				{{if .Settings.RequireTwoFactor}}
+					<button type="button" onClick="handleAction('enforce-2fa?operator={{.ID}}&status=false');">Stop enforcing two-factor authentication</button>
+				{{else}}
+					<button type="button" onClick="handleAction('enforce-2fa?operator={{.ID}}&status=true');">Enforce two-factor authentication</button>
+				{{end}}
+				</form>
+			</div>
 			<hr>
 		</li>
 	{{end}}
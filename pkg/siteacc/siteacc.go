@@ -199,8 +199,11 @@ func (siteacc *SiteAccounts) GetPublicEndpoints() []string {
 }
 
 func (siteacc *SiteAccounts) createStorage(driver string) (data.Storage, error) {
-	if driver == "file" {
+	switch driver {
+	case "file":
 		return data.NewFileStorage(siteacc.conf, siteacc.log)
+	case "sql":
+		return data.NewSQLStorage(siteacc.conf, siteacc.log)
 	}
 
 	return nil, errors.Errorf("unknown storage driver %v", driver)
@@ -0,0 +1,284 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package maintenance keeps track of the storage providers
+// that have been taken out of rotation by an administrator.
+package maintenance
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	registry "github.com/cs3org/go-cs3apis/cs3/storage/registry/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/cs3org/reva/pkg/sysinfo"
+	"github.com/pkg/errors"
+)
+
+// retryAfterKey is the opaque entry of the responses to the refused writes
+// holding the number of seconds after which the clients should retry.
+const retryAfterKey = "retry_after"
+
+// reloadInterval is how often the state file is checked for the changes
+// made by the other instances sharing it.
+const reloadInterval = 5 * time.Second
+
+// Status describes a storage provider in maintenance.
+type Status struct {
+	// Provider is the id or the address of the storage provider.
+	Provider   string    `json:"provider"`
+	Reason     string    `json:"reason"`
+	RetryAfter int64     `json:"retry_after"`
+	Since      time.Time `json:"since"`
+	SetBy      string    `json:"set_by"`
+}
+
+// Error is returned for the writes to a storage provider in maintenance.
+type Error struct {
+	*Status
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("storage provider %s is under maintenance", e.Provider)
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+	return "error: unavailable: " + msg
+}
+
+// IsUnavailable implements the errtypes.IsUnavailable interface.
+func (e *Error) IsUnavailable() {}
+
+// Opaque returns the opaque to set in the response to a write refused with err,
+// telling the clients when to retry. It is nil if the storage provider is not
+// in maintenance or no retry hint was given.
+func Opaque(err error) *types.Opaque {
+	e, ok := err.(*Error)
+	if !ok || e.RetryAfter <= 0 {
+		return nil
+	}
+	return &types.Opaque{
+		Map: map[string]*types.OpaqueEntry{
+			retryAfterKey: {
+				Decoder: "plain",
+				Value:   []byte(strconv.FormatInt(e.RetryAfter, 10)),
+			},
+		},
+	}
+}
+
+// RetryAfter returns the time after which a write refused
+// during a maintenance can be retried, as set by Opaque.
+func RetryAfter(o *types.Opaque) (time.Duration, bool) {
+	e, ok := o.GetMap()[retryAfterKey]
+	if !ok || e.Decoder != "plain" {
+		return 0, false
+	}
+	s, err := strconv.ParseInt(string(e.Value), 10, 64)
+	if err != nil || s <= 0 {
+		return 0, false
+	}
+	return time.Duration(s) * time.Second, true
+}
+
+// Manager holds the maintenance state of the storage providers.
+// If a file is given, the state is persisted there and survives restarts.
+// Several instances can share the file: the changes made by the others
+// are picked up within a few seconds.
+type Manager struct {
+	sync.RWMutex
+	file      string
+	providers map[string]*Status
+	modTime   time.Time
+	lastCheck time.Time
+}
+
+// New returns a new maintenance manager, loading the state from file if it exists.
+func New(file string) (*Manager, error) {
+	m := &Manager{
+		file:      file,
+		providers: map[string]*Status{},
+	}
+	if err := m.load(); err != nil {
+		return nil, err
+	}
+	m.publish()
+	return m, nil
+}
+
+// load reads the state file if it changed since it was last read.
+// It must be called with the lock held.
+func (m *Manager) load() error {
+	m.lastCheck = time.Now()
+	if m.file == "" {
+		return nil
+	}
+	fi, err := os.Stat(m.file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.Wrap(err, "maintenance: error reading state file")
+	}
+	if fi.ModTime().Equal(m.modTime) {
+		return nil
+	}
+
+	data, err := os.ReadFile(m.file)
+	if err != nil {
+		return errors.Wrap(err, "maintenance: error reading state file")
+	}
+	providers := map[string]*Status{}
+	if err := json.Unmarshal(data, &providers); err != nil {
+		return errors.Wrap(err, "maintenance: error decoding state file")
+	}
+	m.providers = providers
+	m.modTime = fi.ModTime()
+	return nil
+}
+
+// refresh reloads the state file if it has not been checked for a while,
+// keeping the current state if it cannot be read.
+func (m *Manager) refresh() {
+	m.RLock()
+	stale := m.file != "" && time.Since(m.lastCheck) >= reloadInterval
+	m.RUnlock()
+	if !stale {
+		return
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	if time.Since(m.lastCheck) < reloadInterval {
+		return
+	}
+	if err := m.load(); err == nil {
+		m.publish()
+	}
+}
+
+// Set puts a storage provider in maintenance.
+func (m *Manager) Set(s *Status) error {
+	m.Lock()
+	defer m.Unlock()
+	if err := m.load(); err != nil {
+		return err
+	}
+	if s.Since.IsZero() {
+		s.Since = time.Now()
+	}
+	m.providers[s.Provider] = s
+	return m.persist()
+}
+
+// Unset puts a storage provider back into rotation.
+func (m *Manager) Unset(provider string) error {
+	m.Lock()
+	defer m.Unlock()
+	if err := m.load(); err != nil {
+		return err
+	}
+	delete(m.providers, provider)
+	return m.persist()
+}
+
+// Get returns the maintenance status of a storage provider,
+// which may have been referred to by its id or by its address.
+func (m *Manager) Get(p *registry.ProviderInfo) (*Status, bool) {
+	m.refresh()
+	m.RLock()
+	defer m.RUnlock()
+	if s, ok := m.providers[p.ProviderId]; ok && p.ProviderId != "" {
+		return s, true
+	}
+	s, ok := m.providers[p.Address]
+	return s, ok
+}
+
+// List returns all the storage providers in maintenance.
+func (m *Manager) List() []*Status {
+	m.refresh()
+	m.RLock()
+	defer m.RUnlock()
+	return m.list()
+}
+
+func (m *Manager) list() []*Status {
+	l := make([]*Status, 0, len(m.providers))
+	for _, s := range m.providers {
+		l = append(l, s)
+	}
+	sort.Slice(l, func(i, j int) bool { return l[i].Provider < l[j].Provider })
+	return l
+}
+
+// persist must be called with the lock held.
+func (m *Manager) persist() error {
+	m.publish()
+	if m.file == "" {
+		return nil
+	}
+	data, err := json.Marshal(m.providers)
+	if err != nil {
+		return errors.Wrap(err, "maintenance: error encoding state")
+	}
+	if err := os.MkdirAll(filepath.Dir(m.file), 0700); err != nil {
+		return errors.Wrap(err, "maintenance: error creating state directory")
+	}
+	// each instance writes its own temporary file, as the state file may be shared
+	tmp, err := os.CreateTemp(filepath.Dir(m.file), filepath.Base(m.file)+".*.tmp")
+	if err != nil {
+		return errors.Wrap(err, "maintenance: error writing state file")
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return errors.Wrap(err, "maintenance: error writing state file")
+	}
+	if err := os.Rename(tmp.Name(), m.file); err != nil {
+		return errors.Wrap(err, "maintenance: error writing state file")
+	}
+	if fi, err := os.Stat(m.file); err == nil {
+		m.modTime = fi.ModTime()
+	}
+	return nil
+}
+
+// publish reports the current state in the system information.
+func (m *Manager) publish() {
+	l := m.list()
+	info := make([]*sysinfo.StorageProviderMaintenance, 0, len(l))
+	for _, s := range l {
+		info = append(info, &sysinfo.StorageProviderMaintenance{
+			Provider:   s.Provider,
+			Reason:     s.Reason,
+			RetryAfter: s.RetryAfter,
+			Since:      s.Since.Unix(),
+		})
+	}
+	sysinfo.SysInfo.SetStorageMaintenance(info)
+}
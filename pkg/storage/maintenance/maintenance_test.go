@@ -0,0 +1,122 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package maintenance
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	registry "github.com/cs3org/go-cs3apis/cs3/storage/registry/v1beta1"
+	"github.com/cs3org/reva/pkg/sysinfo"
+)
+
+func TestManager(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "maintenance.json")
+	m, err := New(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Set(&Status{Provider: "localhost:17000", Reason: "disk replacement", RetryAfter: 600}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Set(&Status{Provider: "1284d238-aa92-42ce-bdc4-0b0000009157"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		provider *registry.ProviderInfo
+		expected bool
+	}{
+		{&registry.ProviderInfo{Address: "localhost:17000"}, true},
+		{&registry.ProviderInfo{ProviderId: "1284d238-aa92-42ce-bdc4-0b0000009157", Address: "localhost:18000"}, true},
+		{&registry.ProviderInfo{ProviderId: "123e4567-e89b-12d3-a456-426655440000", Address: "localhost:19000"}, false},
+	}
+	for _, tt := range tests {
+		if _, ok := m.Get(tt.provider); ok != tt.expected {
+			t.Errorf("Get(%v): expected %t, got %t", tt.provider, tt.expected, ok)
+		}
+	}
+
+	if len(sysinfo.SysInfo.StorageMaintenance) != 2 {
+		t.Errorf("expected 2 providers in the system info, got %d", len(sysinfo.SysInfo.StorageMaintenance))
+	}
+
+	if err := m.Unset("localhost:17000"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the state must survive a restart
+	m, err = New(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := m.List()
+	if len(l) != 1 || l[0].Provider != "1284d238-aa92-42ce-bdc4-0b0000009157" {
+		t.Fatalf("unexpected providers after reload: %v", l)
+	}
+	if l[0].Since.IsZero() {
+		t.Error("expected the maintenance start time to be set")
+	}
+}
+
+func TestSharedState(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "maintenance.json")
+	a, err := New(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := New(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.Set(&Status{Provider: "localhost:17000"}); err != nil {
+		t.Fatal(err)
+	}
+	// the other instance picks up the change once its state is stale
+	b.lastCheck = time.Time{}
+	if _, ok := b.Get(&registry.ProviderInfo{Address: "localhost:17000"}); !ok {
+		t.Fatal("expected the maintenance set by another instance to apply")
+	}
+
+	// and does not lose it when making its own changes
+	if err := b.Set(&Status{Provider: "localhost:18000"}); err != nil {
+		t.Fatal(err)
+	}
+	a.lastCheck = time.Time{}
+	if l := a.List(); len(l) != 2 {
+		t.Fatalf("expected 2 providers in maintenance, got %v", l)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	err := &Error{&Status{Provider: "localhost:17000", RetryAfter: 600}}
+	if d, ok := RetryAfter(Opaque(err)); !ok || d != 10*time.Minute {
+		t.Fatalf("expected to retry after 10m, got %s, %t", d, ok)
+	}
+	if o := Opaque(&Error{&Status{Provider: "localhost:17000"}}); o != nil {
+		t.Fatalf("expected no retry hint, got %v", o)
+	}
+	if o := Opaque(errors.New("other error")); o != nil {
+		t.Fatalf("expected no retry hint for other errors, got %v", o)
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             (unknown)
+// source: maintenance.proto
+
+package proto
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// MaintenanceServiceClient is the client API for MaintenanceService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type MaintenanceServiceClient interface {
+	// Puts a storage provider in maintenance (draining) mode, or back into
+	// rotation. While in maintenance the gateway refuses new writes to the
+	// provider, whereas uploads already initiated can finish.
+	SetProviderMaintenance(ctx context.Context, in *SetProviderMaintenanceRequest, opts ...grpc.CallOption) (*SetProviderMaintenanceResponse, error)
+	// Lists the storage providers currently in maintenance.
+	ListProviderMaintenance(ctx context.Context, in *ListProviderMaintenanceRequest, opts ...grpc.CallOption) (*ListProviderMaintenanceResponse, error)
+}
+
+type maintenanceServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMaintenanceServiceClient(cc grpc.ClientConnInterface) MaintenanceServiceClient {
+	return &maintenanceServiceClient{cc}
+}
+
+func (c *maintenanceServiceClient) SetProviderMaintenance(ctx context.Context, in *SetProviderMaintenanceRequest, opts ...grpc.CallOption) (*SetProviderMaintenanceResponse, error) {
+	out := new(SetProviderMaintenanceResponse)
+	err := c.cc.Invoke(ctx, "/revad.maintenance.MaintenanceService/SetProviderMaintenance", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *maintenanceServiceClient) ListProviderMaintenance(ctx context.Context, in *ListProviderMaintenanceRequest, opts ...grpc.CallOption) (*ListProviderMaintenanceResponse, error) {
+	out := new(ListProviderMaintenanceResponse)
+	err := c.cc.Invoke(ctx, "/revad.maintenance.MaintenanceService/ListProviderMaintenance", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MaintenanceServiceServer is the server API for MaintenanceService service.
+// All implementations should embed UnimplementedMaintenanceServiceServer
+// for forward compatibility
+type MaintenanceServiceServer interface {
+	// Puts a storage provider in maintenance (draining) mode, or back into
+	// rotation. While in maintenance the gateway refuses new writes to the
+	// provider, whereas uploads already initiated can finish.
+	SetProviderMaintenance(context.Context, *SetProviderMaintenanceRequest) (*SetProviderMaintenanceResponse, error)
+	// Lists the storage providers currently in maintenance.
+	ListProviderMaintenance(context.Context, *ListProviderMaintenanceRequest) (*ListProviderMaintenanceResponse, error)
+}
+
+// UnimplementedMaintenanceServiceServer should be embedded to have forward compatible implementations.
+type UnimplementedMaintenanceServiceServer struct {
+}
+
+func (UnimplementedMaintenanceServiceServer) SetProviderMaintenance(context.Context, *SetProviderMaintenanceRequest) (*SetProviderMaintenanceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetProviderMaintenance not implemented")
+}
+func (UnimplementedMaintenanceServiceServer) ListProviderMaintenance(context.Context, *ListProviderMaintenanceRequest) (*ListProviderMaintenanceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListProviderMaintenance not implemented")
+}
+
+// UnsafeMaintenanceServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MaintenanceServiceServer will
+// result in compilation errors.
+type UnsafeMaintenanceServiceServer interface {
+	mustEmbedUnimplementedMaintenanceServiceServer()
+}
+
+func RegisterMaintenanceServiceServer(s grpc.ServiceRegistrar, srv MaintenanceServiceServer) {
+	s.RegisterService(&MaintenanceService_ServiceDesc, srv)
+}
+
+func _MaintenanceService_SetProviderMaintenance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetProviderMaintenanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MaintenanceServiceServer).SetProviderMaintenance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/revad.maintenance.MaintenanceService/SetProviderMaintenance",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MaintenanceServiceServer).SetProviderMaintenance(ctx, req.(*SetProviderMaintenanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MaintenanceService_ListProviderMaintenance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListProviderMaintenanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MaintenanceServiceServer).ListProviderMaintenance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/revad.maintenance.MaintenanceService/ListProviderMaintenance",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MaintenanceServiceServer).ListProviderMaintenance(ctx, req.(*ListProviderMaintenanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// MaintenanceService_ServiceDesc is the grpc.ServiceDesc for MaintenanceService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MaintenanceService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "revad.maintenance.MaintenanceService",
+	HandlerType: (*MaintenanceServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SetProviderMaintenance",
+			Handler:    _MaintenanceService_SetProviderMaintenance_Handler,
+		},
+		{
+			MethodName: "ListProviderMaintenance",
+			Handler:    _MaintenanceService_ListProviderMaintenance_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "maintenance.proto",
+}
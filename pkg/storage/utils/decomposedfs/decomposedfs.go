@@ -38,6 +38,7 @@ import (
 	"github.com/cs3org/reva/pkg/appctx"
 	ctxpkg "github.com/cs3org/reva/pkg/ctx"
 	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/health"
 	"github.com/cs3org/reva/pkg/logger"
 	"github.com/cs3org/reva/pkg/sharedconf"
 	"github.com/cs3org/reva/pkg/storage"
@@ -126,6 +127,8 @@ func New(o *options.Options, lu *Lookup, p PermissionsChecker, tp Tree) (storage
 		return nil, errors.Wrap(err, "could not setup tree")
 	}
 
+	health.Register("storage:decomposedfs:"+o.Root, health.Writable(o.Root))
+
 	return &Decomposedfs{
 		tp:           tp,
 		lu:           lu,
@@ -137,6 +140,7 @@ func New(o *options.Options, lu *Lookup, p PermissionsChecker, tp Tree) (storage
 
 // Shutdown shuts down the storage.
 func (fs *Decomposedfs) Shutdown(ctx context.Context) error {
+	health.Unregister("storage:decomposedfs:" + fs.o.Root)
 	return nil
 }
 
@@ -37,6 +37,7 @@ import (
 	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
 	ctxpkg "github.com/cs3org/reva/pkg/ctx"
 	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/health"
 	"github.com/cs3org/reva/pkg/mime"
 	"github.com/cs3org/reva/pkg/storage"
 	"github.com/cs3org/reva/pkg/storage/utils/acl"
@@ -120,6 +121,13 @@ func NewLocalFS(c *Config) (storage.FS, error) {
 		return nil, errors.Wrap(err, "localfs: error initializing db")
 	}
 
+	health.Register("storage:localfs:"+c.Root, func(ctx context.Context) error {
+		if err := health.Writable(c.Uploads)(ctx); err != nil {
+			return err
+		}
+		return db.PingContext(ctx)
+	})
+
 	return &localfs{
 		conf:         c,
 		db:           db,
@@ -128,6 +136,7 @@ func NewLocalFS(c *Config) (storage.FS, error) {
 }
 
 func (fs *localfs) Shutdown(ctx context.Context) error {
+	health.Unregister("storage:localfs:" + fs.conf.Root)
 	err := fs.db.Close()
 	if err != nil {
 		return errors.Wrap(err, "localfs: error closing db connection")
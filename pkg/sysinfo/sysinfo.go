@@ -22,6 +22,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"sync"
 
 	"github.com/pkg/errors"
 )
@@ -30,15 +31,36 @@ import (
 type SystemInformation struct {
 	// Reva holds the main Reva information
 	Reva *RevaVersion `json:"reva"`
+	// StorageMaintenance lists the storage providers currently in maintenance
+	StorageMaintenance []*StorageProviderMaintenance `json:"storage_maintenance,omitempty" sysinfo:"omitlabel"`
+}
+
+// StorageProviderMaintenance describes a storage provider taken out of rotation.
+type StorageProviderMaintenance struct {
+	Provider   string `json:"provider"`
+	Reason     string `json:"reason,omitempty"`
+	RetryAfter int64  `json:"retry_after,omitempty"`
+	Since      int64  `json:"since"`
 }
 
 var (
 	// SysInfo provides global system information.
 	SysInfo = &SystemInformation{}
+
+	mu sync.RWMutex
 )
 
+// SetStorageMaintenance replaces the list of storage providers in maintenance.
+func (sysInfo *SystemInformation) SetStorageMaintenance(providers []*StorageProviderMaintenance) {
+	mu.Lock()
+	defer mu.Unlock()
+	sysInfo.StorageMaintenance = providers
+}
+
 // ToJSON converts the system information to JSON.
 func (sysInfo *SystemInformation) ToJSON() (string, error) {
+	mu.RLock()
+	defer mu.RUnlock()
 	data, err := json.MarshalIndent(sysInfo, "", "\t")
 	if err != nil {
 		return "", fmt.Errorf("unable to marshal the system information: %v", err)
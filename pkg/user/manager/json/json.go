@@ -22,13 +22,19 @@ import (
 	"context"
 	"encoding/json"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
 	"github.com/cs3org/reva/pkg/errtypes"
 	"github.com/cs3org/reva/pkg/user"
 	"github.com/cs3org/reva/pkg/user/manager/registry"
+	"github.com/cs3org/reva/pkg/utils/filelock"
+	"github.com/golang/protobuf/proto"
+	"github.com/google/uuid"
 	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
 )
@@ -38,7 +44,12 @@ func init() {
 }
 
 type manager struct {
-	users []*userpb.User
+	sync.Mutex
+
+	file    string
+	modTime time.Time
+	size    int64
+	users   []*userpb.User
 }
 
 type config struct {
@@ -63,6 +74,7 @@ func parseConfig(m map[string]interface{}) (*config, error) {
 }
 
 // New returns a user manager implementation that reads a json file to provide user metadata.
+// The manager is writable: users created, updated or deleted are persisted to the same file.
 func New(m map[string]interface{}) (user.Manager, error) {
 	mgr := &manager{}
 	err := mgr.Configure(m)
@@ -78,7 +90,27 @@ func (m *manager) Configure(ml map[string]interface{}) error {
 		return err
 	}
 
-	f, err := os.ReadFile(c.Users)
+	m.Lock()
+	defer m.Unlock()
+
+	m.file = c.Users
+	m.modTime = time.Time{}
+	return m.reload()
+}
+
+// reload reads the users file again if it has been modified since it was last read,
+// e.g. by another manager instance writing to the same file.
+// The caller must hold the write lock.
+func (m *manager) reload() error {
+	info, err := os.Stat(m.file)
+	if err != nil {
+		return err
+	}
+	if m.users != nil && info.ModTime().Equal(m.modTime) && info.Size() == m.size {
+		return nil
+	}
+
+	f, err := os.ReadFile(m.file)
 	if err != nil {
 		return err
 	}
@@ -90,11 +122,84 @@ func (m *manager) Configure(ml map[string]interface{}) error {
 		return err
 	}
 	m.users = users
+	m.modTime = info.ModTime()
+	m.size = info.Size()
+	return nil
+}
+
+// lock takes the lock of the manager and the lock file next to the users
+// file, so that the managers sharing the file do not overwrite each other's
+// changes, then reads the file again if needed.
+// The returned function releases both locks.
+func (m *manager) lock() (func(), error) {
+	m.Lock()
+	unlockFile, err := filelock.Lock(m.file + ".lock")
+	if err != nil {
+		m.Unlock()
+		return nil, errors.Wrap(err, "json: error locking users file")
+	}
+	unlock := func() {
+		unlockFile()
+		m.Unlock()
+	}
+	if err := m.reload(); err != nil {
+		unlock()
+		return nil, errors.Wrap(err, "json: error reading users file")
+	}
+	return unlock, nil
+}
+
+// snapshot returns the current list of users, reloading the file if needed.
+func (m *manager) snapshot() ([]*userpb.User, error) {
+	m.Lock()
+	defer m.Unlock()
+	if err := m.reload(); err != nil {
+		return nil, errors.Wrap(err, "json: error reading users file")
+	}
+	return m.users, nil
+}
+
+// persist atomically writes the users to the file.
+// The caller must hold the lock returned by lock.
+func (m *manager) persist(users []*userpb.User) error {
+	data, err := json.MarshalIndent(users, "", "\t")
+	if err != nil {
+		return errors.Wrap(err, "json: error marshalling users")
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(m.file), filepath.Base(m.file)+".*")
+	if err != nil {
+		return errors.Wrap(err, "json: error creating temporary file")
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "json: error writing users file")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "json: error writing users file")
+	}
+	if err := os.Rename(tmp.Name(), m.file); err != nil {
+		return errors.Wrap(err, "json: error replacing users file")
+	}
+
+	info, err := os.Stat(m.file)
+	if err != nil {
+		return err
+	}
+	m.users = users
+	m.modTime = info.ModTime()
+	m.size = info.Size()
 	return nil
 }
 
 func (m *manager) GetUser(ctx context.Context, uid *userpb.UserId, skipFetchingGroups bool) (*userpb.User, error) {
-	for _, u := range m.users {
+	users, err := m.snapshot()
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range users {
 		if (u.Id.GetOpaqueId() == uid.OpaqueId || u.Username == uid.OpaqueId) && (uid.Idp == "" || uid.Idp == u.Id.GetIdp()) {
 			user := *u
 			if skipFetchingGroups {
@@ -107,7 +212,11 @@ func (m *manager) GetUser(ctx context.Context, uid *userpb.UserId, skipFetchingG
 }
 
 func (m *manager) GetUserByClaim(ctx context.Context, claim, value string, skipFetchingGroups bool) (*userpb.User, error) {
-	for _, u := range m.users {
+	users, err := m.snapshot()
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range users {
 		if userClaim, err := extractClaim(u, claim); err == nil && value == userClaim {
 			user := *u
 			if skipFetchingGroups {
@@ -141,8 +250,12 @@ func userContains(u *userpb.User, query string) bool {
 }
 
 func (m *manager) FindUsers(ctx context.Context, query string, skipFetchingGroups bool) ([]*userpb.User, error) {
+	all, err := m.snapshot()
+	if err != nil {
+		return nil, err
+	}
 	users := []*userpb.User{}
-	for _, u := range m.users {
+	for _, u := range all {
 		if userContains(u, query) {
 			user := *u
 			if skipFetchingGroups {
@@ -161,3 +274,102 @@ func (m *manager) GetUserGroups(ctx context.Context, uid *userpb.UserId) ([]stri
 	}
 	return user.Groups, nil
 }
+
+func (m *manager) CreateUser(ctx context.Context, u *userpb.User) (*userpb.User, error) {
+	if u.Username == "" {
+		return nil, errtypes.BadRequest("json: username must not be empty")
+	}
+
+	unlock, err := m.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	nu := proto.Clone(u).(*userpb.User)
+	if nu.Id == nil {
+		nu.Id = &userpb.UserId{}
+	}
+	if nu.Id.OpaqueId == "" {
+		nu.Id.OpaqueId = uuid.New().String()
+	}
+	if nu.Id.Type == userpb.UserType_USER_TYPE_INVALID {
+		nu.Id.Type = userpb.UserType_USER_TYPE_PRIMARY
+	}
+
+	for _, e := range m.users {
+		if e.Id.GetOpaqueId() == nu.Id.OpaqueId || e.Username == nu.Username {
+			return nil, errtypes.AlreadyExists(nu.Username)
+		}
+	}
+
+	users := append(append([]*userpb.User{}, m.users...), nu)
+	if err := m.persist(users); err != nil {
+		return nil, err
+	}
+	return proto.Clone(nu).(*userpb.User), nil
+}
+
+func (m *manager) UpdateUser(ctx context.Context, u *userpb.User) (*userpb.User, error) {
+	if u.Id.GetOpaqueId() == "" {
+		return nil, errtypes.BadRequest("json: user id must not be empty")
+	}
+
+	unlock, err := m.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	idx := -1
+	for i, e := range m.users {
+		if e.Id.GetOpaqueId() == u.Id.OpaqueId {
+			idx = i
+		} else if u.Username != "" && e.Username == u.Username {
+			return nil, errtypes.AlreadyExists(u.Username)
+		}
+	}
+	if idx == -1 {
+		return nil, errtypes.NotFound(u.Id.OpaqueId)
+	}
+
+	nu := proto.Clone(u).(*userpb.User)
+	if nu.Id.Idp == "" {
+		nu.Id.Idp = m.users[idx].Id.GetIdp()
+	}
+	if nu.Id.Type == userpb.UserType_USER_TYPE_INVALID {
+		nu.Id.Type = m.users[idx].Id.GetType()
+	}
+	if nu.Username == "" {
+		nu.Username = m.users[idx].Username
+	}
+
+	users := append([]*userpb.User{}, m.users...)
+	users[idx] = nu
+	if err := m.persist(users); err != nil {
+		return nil, err
+	}
+	return proto.Clone(nu).(*userpb.User), nil
+}
+
+func (m *manager) DeleteUser(ctx context.Context, uid *userpb.UserId) error {
+	unlock, err := m.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	users := make([]*userpb.User, 0, len(m.users))
+	found := false
+	for _, e := range m.users {
+		if e.Id.GetOpaqueId() == uid.GetOpaqueId() && (uid.Idp == "" || uid.Idp == e.Id.GetIdp()) {
+			found = true
+			continue
+		}
+		users = append(users, e)
+	}
+	if !found {
+		return errtypes.NotFound(uid.GetOpaqueId())
+	}
+	return m.persist(users)
+}
@@ -21,11 +21,15 @@ package json
 import (
 	"context"
 	"os"
+	"path/filepath"
 	"reflect"
+	"strconv"
+	"sync"
 	"testing"
 
 	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
 	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/user"
 )
 
 var ctx = context.Background()
@@ -146,3 +150,122 @@ func TestUserManager(t *testing.T) {
 		t.Fatalf("user differ: expected=%v got=%v", "einstein", resUser[0].Username)
 	}
 }
+
+func TestWritableUserManager(t *testing.T) {
+	tempdir, err := os.MkdirTemp("", "json_test")
+	if err != nil {
+		t.Fatalf("error while create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempdir)
+
+	file := filepath.Join(tempdir, "users.json")
+	if err := os.WriteFile(file, []byte(`[{"id":{"idp":"localhost","opaque_id":"einstein","type":1},"username":"einstein","mail":"einstein@example.org","display_name":"Albert Einstein"}]`), 0600); err != nil {
+		t.Fatalf("error while writing temp file: %v", err)
+	}
+
+	input := map[string]interface{}{
+		"users": file,
+	}
+	mgr, err := New(input)
+	if err != nil {
+		t.Fatalf("error while get manager: %v", err)
+	}
+	manager := mgr.(user.WritableManager)
+
+	// a second instance reading the same file must see the changes of the first one
+	other, err := New(input)
+	if err != nil {
+		t.Fatalf("error while get manager: %v", err)
+	}
+
+	// create
+	created, err := manager.CreateUser(ctx, &userpb.User{Username: "marie", Mail: "marie@example.org", DisplayName: "Marie Curie"})
+	if err != nil {
+		t.Fatalf("error while creating user: %v", err)
+	}
+	if created.Id.OpaqueId == "" || created.Id.Type != userpb.UserType_USER_TYPE_PRIMARY {
+		t.Fatalf("user id not assigned: %v", created.Id)
+	}
+	if _, err := manager.CreateUser(ctx, &userpb.User{Username: "einstein"}); !reflect.DeepEqual(err, errtypes.AlreadyExists("einstein")) {
+		t.Fatalf("expected an already exists error, got %v", err)
+	}
+	res, err := other.GetUser(ctx, &userpb.UserId{OpaqueId: created.Id.OpaqueId}, false)
+	if err != nil || res.Username != "marie" {
+		t.Fatalf("created user not found by other instance: %v %v", res, err)
+	}
+
+	// update
+	created.Groups = []string{"physics-lovers"}
+	created.DisplayName = "Marie Skłodowska-Curie"
+	if _, err := manager.UpdateUser(ctx, created); err != nil {
+		t.Fatalf("error while updating user: %v", err)
+	}
+	groups, err := other.GetUserGroups(ctx, created.Id)
+	if err != nil || !reflect.DeepEqual(groups, []string{"physics-lovers"}) {
+		t.Fatalf("groups differ: expected=%v got=%v (%v)", []string{"physics-lovers"}, groups, err)
+	}
+	if _, err := manager.UpdateUser(ctx, &userpb.User{Id: &userpb.UserId{OpaqueId: "unknown"}}); err == nil {
+		t.Fatalf("expected an error when updating an unknown user")
+	}
+	if _, err := manager.UpdateUser(ctx, &userpb.User{Id: created.Id, Username: "einstein"}); err == nil {
+		t.Fatalf("expected an error when renaming a user to an existing username")
+	}
+
+	// delete
+	if err := manager.DeleteUser(ctx, created.Id); err != nil {
+		t.Fatalf("error while deleting user: %v", err)
+	}
+	if _, err := other.GetUser(ctx, created.Id, false); err == nil {
+		t.Fatalf("deleted user still found")
+	}
+	if err := manager.DeleteUser(ctx, created.Id); err == nil {
+		t.Fatalf("expected an error when deleting an unknown user")
+	}
+
+	// the file stays readable by a fresh manager
+	fresh, err := New(input)
+	if err != nil {
+		t.Fatalf("error while get manager: %v", err)
+	}
+	users, _ := fresh.FindUsers(ctx, "", false)
+	if len(users) != 1 || users[0].Username != "einstein" {
+		t.Fatalf("unexpected users after reload: %v", users)
+	}
+}
+
+func TestConcurrentWrites(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "users.json")
+	if err := os.WriteFile(file, []byte(`[]`), 0600); err != nil {
+		t.Fatalf("error while writing temp file: %v", err)
+	}
+
+	// the instances sharing the file must not overwrite each other's changes
+	var managers []user.WritableManager
+	for i := 0; i < 2; i++ {
+		mgr, err := New(map[string]interface{}{"users": file})
+		if err != nil {
+			t.Fatalf("error while get manager: %v", err)
+		}
+		managers = append(managers, mgr.(user.WritableManager))
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := managers[i%2].CreateUser(ctx, &userpb.User{Username: "user" + strconv.Itoa(i)}); err != nil {
+				t.Errorf("error while creating user: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	fresh, err := New(map[string]interface{}{"users": file})
+	if err != nil {
+		t.Fatalf("error while get manager: %v", err)
+	}
+	if users, _ := fresh.FindUsers(ctx, "", false); len(users) != 20 {
+		t.Fatalf("expected 20 users, got %d", len(users))
+	}
+}
@@ -30,9 +30,11 @@ import (
 	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
 	"github.com/cs3org/reva/pkg/appctx"
 	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/health"
 	"github.com/cs3org/reva/pkg/user"
 	"github.com/cs3org/reva/pkg/user/manager/registry"
 	"github.com/cs3org/reva/pkg/utils"
+	"github.com/cs3org/reva/pkg/utils/ldapcache"
 	"github.com/go-ldap/ldap/v3"
 	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
@@ -46,6 +48,7 @@ type manager struct {
 	c           *config
 	userfilter  *template.Template
 	groupfilter *template.Template
+	searcher    *ldapcache.Searcher
 }
 
 type config struct {
@@ -58,6 +61,10 @@ type config struct {
 	Idp             string     `mapstructure:"idp"`
 	Schema          attributes `mapstructure:"schema"`
 	Nobody          int64      `mapstructure:"nobody"`
+	// Cache holds the settings of the lookup cache
+	Cache ldapcache.Config `mapstructure:"cache"`
+	// Nested holds the settings for resolving nested group memberships
+	Nested utils.LDAPNestedGroups `mapstructure:",squash"`
 }
 
 type attributes struct {
@@ -126,8 +133,18 @@ func (m *manager) Configure(ml map[string]interface{}) error {
 	if c.Nobody == 0 {
 		c.Nobody = 99
 	}
+	if err := c.Nested.Init(); err != nil {
+		return err
+	}
+
+	cache, err := ldapcache.New(&c.Cache, "userprovider:ldap:")
+	if err != nil {
+		return errors.Wrap(err, "error creating the lookup cache")
+	}
 
 	m.c = c
+	m.searcher = ldapcache.NewSearcher(utils.GetLDAPPool(&c.LDAPConn), cache)
+	health.Register(fmt.Sprintf("userprovider:ldap:%s:%d", c.Hostname, c.Port), health.LDAP(&c.LDAPConn))
 	m.userfilter, err = template.New("uf").Funcs(sprig.TxtFuncMap()).Parse(c.UserFilter)
 	if err != nil {
 		err := errors.Wrap(err, fmt.Sprintf("error parsing userfilter tpl:%s", c.UserFilter))
@@ -143,12 +160,6 @@ func (m *manager) Configure(ml map[string]interface{}) error {
 
 func (m *manager) GetUser(ctx context.Context, uid *userpb.UserId, skipFetchingGroups bool) (*userpb.User, error) {
 	log := appctx.GetLogger(ctx)
-	l, err := utils.GetLDAPConnection(&m.c.LDAPConn)
-	if err != nil {
-		return nil, err
-	}
-	defer l.Close()
-
 	// Search for the given clientID
 	searchRequest := ldap.NewSearchRequest(
 		m.c.BaseDN,
@@ -158,7 +169,7 @@ func (m *manager) GetUser(ctx context.Context, uid *userpb.UserId, skipFetchingG
 		nil,
 	)
 
-	sr, err := l.Search(searchRequest)
+	sr, err := m.searcher.Search(searchRequest)
 	if err != nil {
 		return nil, err
 	}
@@ -230,12 +241,6 @@ func (m *manager) GetUserByClaim(ctx context.Context, claim, value string, skipF
 	}
 
 	log := appctx.GetLogger(ctx)
-	l, err := utils.GetLDAPConnection(&m.c.LDAPConn)
-	if err != nil {
-		return nil, err
-	}
-	defer l.Close()
-
 	// Search for the given clientID
 	searchRequest := ldap.NewSearchRequest(
 		m.c.BaseDN,
@@ -245,7 +250,7 @@ func (m *manager) GetUserByClaim(ctx context.Context, claim, value string, skipF
 		nil,
 	)
 
-	sr, err := l.Search(searchRequest)
+	sr, err := m.searcher.Search(searchRequest)
 	if err != nil {
 		return nil, err
 	}
@@ -300,12 +305,6 @@ func (m *manager) GetUserByClaim(ctx context.Context, claim, value string, skipF
 }
 
 func (m *manager) FindUsers(ctx context.Context, query string, skipFetchingGroups bool) ([]*userpb.User, error) {
-	l, err := utils.GetLDAPConnection(&m.c.LDAPConn)
-	if err != nil {
-		return nil, err
-	}
-	defer l.Close()
-
 	// Search for the given clientID
 	searchRequest := ldap.NewSearchRequest(
 		m.c.BaseDN,
@@ -315,7 +314,7 @@ func (m *manager) FindUsers(ctx context.Context, query string, skipFetchingGroup
 		nil,
 	)
 
-	sr, err := l.Search(searchRequest)
+	sr, err := m.searcher.Search(searchRequest)
 	if err != nil {
 		return nil, err
 	}
@@ -369,22 +368,16 @@ func (m *manager) FindUsers(ctx context.Context, query string, skipFetchingGroup
 }
 
 func (m *manager) GetUserGroups(ctx context.Context, uid *userpb.UserId) ([]string, error) {
-	l, err := utils.GetLDAPConnection(&m.c.LDAPConn)
-	if err != nil {
-		return []string{}, err
-	}
-	defer l.Close()
-
 	// Search for the given clientID
 	searchRequest := ldap.NewSearchRequest(
 		m.c.BaseDN,
 		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
 		m.getGroupFilter(uid),
-		[]string{m.c.Schema.CN}, // TODO use DN to look up group id
+		append([]string{m.c.Schema.CN}, m.c.Nested.Attributes()...), // TODO use DN to look up group id
 		nil,
 	)
 
-	sr, err := l.Search(searchRequest)
+	sr, err := m.searcher.Search(searchRequest)
 	if err != nil {
 		return []string{}, err
 	}
@@ -398,6 +391,17 @@ func (m *manager) GetUserGroups(ctx context.Context, uid *userpb.UserId) ([]stri
 		groups = append(groups, entry.GetEqualFoldAttributeValue(m.c.Schema.CN))
 	}
 
+	if m.c.Nested.Enabled() {
+		// Add all groups the direct groups of the user are nested in
+		parents, err := m.c.Nested.ExpandParentGroups(m.searcher, m.c.BaseDN, sr.Entries, []string{m.c.Schema.CN})
+		if err != nil {
+			return []string{}, err
+		}
+		for _, entry := range parents {
+			groups = append(groups, entry.GetEqualFoldAttributeValue(m.c.Schema.CN))
+		}
+	}
+
 	return groups, nil
 }
 
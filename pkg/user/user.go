@@ -39,3 +39,15 @@ type Manager interface {
 	// FindUsers returns all the user objects which match a query parameter.
 	FindUsers(ctx context.Context, query string, skipFetchingGroups bool) ([]*userpb.User, error)
 }
+
+// WritableManager is the interface to implement by user managers which
+// support provisioning users, e.g. through SCIM.
+type WritableManager interface {
+	Manager
+	// CreateUser stores a new user; if the ID of the user is empty, a new one is assigned.
+	CreateUser(ctx context.Context, u *userpb.User) (*userpb.User, error)
+	// UpdateUser replaces the stored data of the user identified by the ID of u.
+	UpdateUser(ctx context.Context, u *userpb.User) (*userpb.User, error)
+	// DeleteUser removes the user identified by a uid.
+	DeleteUser(ctx context.Context, uid *userpb.UserId) error
+}
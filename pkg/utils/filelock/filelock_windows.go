@@ -0,0 +1,30 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+//go:build windows
+// +build windows
+
+// Package filelock provides advisory locks on files, used to synchronize the
+// processes reading and writing the same file.
+package filelock
+
+// Lock is a no-op on windows, where the processes sharing a file
+// are not synchronized.
+func Lock(path string) (func(), error) {
+	return func() {}, nil
+}
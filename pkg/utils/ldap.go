@@ -37,6 +37,10 @@ type LDAPConn struct {
 	CACert       string `mapstructure:"cacert"`
 	BindUsername string `mapstructure:"bind_username"`
 	BindPassword string `mapstructure:"bind_password"`
+
+	PoolSize        int `mapstructure:"pool_size" docs:"10;The maximum number of connections kept open to the LDAP server."`
+	PoolIdleTimeout int `mapstructure:"pool_idle_timeout" docs:"120;The time in seconds after which idle pooled connections are closed."`
+	PageSize        int `mapstructure:"page_size" docs:"500;The page size of paged searches (RFC 2696); set to -1 to disable paging."`
 }
 
 // GetLDAPConnection initializes an LDAPS connection and allows
@@ -0,0 +1,140 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package ldapcache caches the results of LDAP lookups, either in memory or
+// in Redis, so that the LDAP user, group and auth managers don't have to
+// query the directory server for every request.
+package ldapcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/cs3org/reva/pkg/utils"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DriverNone disables caching. It is the default.
+	DriverNone = "none"
+	// DriverMemory caches the results in memory.
+	DriverMemory = "memory"
+	// DriverRedis caches the results in Redis.
+	DriverRedis = "redis"
+
+	defaultTTL  = 300
+	defaultSize = 10000
+)
+
+// ErrNotCached is returned by caches if no (valid) entry exists for a key.
+var ErrNotCached = errors.New("ldapcache: entry not cached")
+
+// Config holds the configuration of an LDAP lookup cache.
+type Config struct {
+	Driver        string `mapstructure:"driver" docs:"none;The cache driver to use (none, memory or redis). Caching is disabled by default."`
+	TTL           int    `mapstructure:"ttl" docs:"300;The time in seconds for which lookup results are cached."`
+	Size          int    `mapstructure:"size" docs:"10000;The maximum number of entries of the memory cache."`
+	RedisAddress  string `mapstructure:"redis_address" docs:"localhost:6379"`
+	RedisUsername string `mapstructure:"redis_username"`
+	RedisPassword string `mapstructure:"redis_password"`
+}
+
+// Cache is the interface to implement LDAP lookup caches. Values are stored
+// JSON-encoded, so callers always get their own copies.
+type Cache interface {
+	Get(key string, value interface{}) error
+	Set(key string, value interface{}) error
+}
+
+// New creates a new cache using the configured driver; the namespace is
+// prepended to all keys to separate the entries of different managers.
+func New(c *Config, namespace string) (Cache, error) {
+	ttl := c.TTL
+	if ttl <= 0 {
+		ttl = defaultTTL
+	}
+
+	switch strings.ToLower(c.Driver) {
+	case "", DriverNone:
+		return nil, nil
+
+	case DriverMemory:
+		size := c.Size
+		if size <= 0 {
+			size = defaultSize
+		}
+		return newMemoryCache(namespace, size, time.Duration(ttl)*time.Second), nil
+
+	case DriverRedis:
+		return newRedisCache(namespace, c.RedisAddress, c.RedisUsername, c.RedisPassword, ttl), nil
+
+	default:
+		return nil, errors.Errorf("ldapcache: unknown cache driver %s", c.Driver)
+	}
+}
+
+// Searcher runs LDAP searches on a connection pool and caches non-empty results.
+type Searcher struct {
+	pool  *utils.LDAPPool
+	cache Cache
+}
+
+// NewSearcher creates a new searcher; if cache is nil, results aren't cached.
+func NewSearcher(pool *utils.LDAPPool, cache Cache) *Searcher {
+	return &Searcher{
+		pool:  pool,
+		cache: cache,
+	}
+}
+
+// Search performs the given search request, returning cached entries if available.
+func (s *Searcher) Search(req *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	key := searchKey(req)
+	if s.cache != nil {
+		var entries []*ldap.Entry
+		if err := s.cache.Get(key, &entries); err == nil {
+			return &ldap.SearchResult{Entries: entries}, nil
+		}
+	}
+
+	sr, err := s.pool.Search(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Empty results are not cached, as new entries would otherwise be missing until they expire
+	if s.cache != nil && len(sr.Entries) > 0 {
+		_ = s.cache.Set(key, sr.Entries)
+	}
+	return sr, nil
+}
+
+// Pool returns the connection pool used by the searcher.
+func (s *Searcher) Pool() *utils.LDAPPool {
+	return s.pool
+}
+
+func searchKey(req *ldap.SearchRequest) string {
+	data, _ := json.Marshal([]interface{}{req.BaseDN, req.Scope, req.Filter, req.Attributes, req.SizeLimit})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
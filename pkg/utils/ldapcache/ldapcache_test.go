@@ -0,0 +1,95 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ldapcache
+
+import (
+	"testing"
+
+	"github.com/cs3org/reva/pkg/utils"
+	"github.com/go-ldap/ldap/v3"
+)
+
+func TestMemoryCache(t *testing.T) {
+	c, err := New(&Config{Driver: DriverMemory, TTL: 60}, "test:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := []*ldap.Entry{ldap.NewEntry("cn=einstein,dc=example,dc=org", map[string][]string{"mail": {"einstein@example.org"}})}
+	if err := c.Set("key", entries); err != nil {
+		t.Fatal(err)
+	}
+
+	var cached []*ldap.Entry
+	if err := c.Get("key", &cached); err != nil {
+		t.Fatal(err)
+	}
+	if len(cached) != 1 || cached[0].DN != entries[0].DN || cached[0].GetAttributeValue("mail") != "einstein@example.org" {
+		t.Fatalf("unexpected cached entries %+v", cached)
+	}
+
+	// Cached values must be copies
+	cached[0].DN = "changed"
+	cached = nil
+	_ = c.Get("key", &cached)
+	if cached[0].DN != entries[0].DN {
+		t.Errorf("cached value was modified")
+	}
+
+	if err := c.Get("missing", &cached); err != ErrNotCached {
+		t.Errorf("expected ErrNotCached, got %v", err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	if c, err := New(&Config{Driver: DriverNone}, ""); err != nil || c != nil {
+		t.Errorf("expected no cache, got %v, %v", c, err)
+	}
+	if c, err := New(&Config{}, ""); err != nil || c != nil {
+		t.Errorf("expected caching to be disabled by default, got %v, %v", c, err)
+	}
+	if _, err := New(&Config{Driver: "invalid"}, ""); err == nil {
+		t.Errorf("expected an error for an unknown driver")
+	}
+}
+
+func TestSearcherUsesCache(t *testing.T) {
+	c, _ := New(&Config{Driver: DriverMemory}, "test:")
+	// The pool points to an unreachable server, so results can only come from the cache
+	s := NewSearcher(utils.NewLDAPPool(&utils.LDAPConn{Hostname: "localhost", Port: 1}), c)
+
+	req := ldap.NewSearchRequest("dc=example,dc=org", ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false, "(cn=einstein)", []string{"mail"}, nil)
+	if _, err := s.Search(req); err == nil {
+		t.Fatalf("expected the search to fail")
+	}
+
+	_ = c.Set(searchKey(req), []*ldap.Entry{ldap.NewEntry("cn=einstein,dc=example,dc=org", nil)})
+	sr, err := s.Search(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sr.Entries) != 1 {
+		t.Errorf("expected one cached entry, got %d", len(sr.Entries))
+	}
+
+	other := ldap.NewSearchRequest("dc=example,dc=org", ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false, "(cn=curie)", []string{"mail"}, nil)
+	if searchKey(other) == searchKey(req) {
+		t.Errorf("different requests share the same cache key")
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ldapcache
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/bluele/gcache"
+)
+
+type memoryCache struct {
+	namespace string
+	cache     gcache.Cache
+}
+
+func newMemoryCache(namespace string, size int, ttl time.Duration) *memoryCache {
+	return &memoryCache{
+		namespace: namespace,
+		cache:     gcache.New(size).LRU().Expiration(ttl).Build(),
+	}
+}
+
+func (m *memoryCache) Get(key string, value interface{}) error {
+	data, err := m.cache.Get(m.namespace + key)
+	if err != nil {
+		return ErrNotCached
+	}
+	return json.Unmarshal(data.([]byte), value)
+}
+
+func (m *memoryCache) Set(key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return m.cache.Set(m.namespace+key, data)
+}
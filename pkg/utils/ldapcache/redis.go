@@ -0,0 +1,100 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ldapcache
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/pkg/errors"
+)
+
+type redisCache struct {
+	namespace string
+	ttl       int
+	redisPool *redis.Pool
+}
+
+func newRedisCache(namespace, address, username, password string, ttl int) *redisCache {
+	if address == "" {
+		address = "localhost:6379"
+	}
+
+	pool := &redis.Pool{
+		MaxIdle:     50,
+		MaxActive:   1000,
+		IdleTimeout: 240 * time.Second,
+
+		Dial: func() (redis.Conn, error) {
+			var opts []redis.DialOption
+			if username != "" {
+				opts = append(opts, redis.DialUsername(username))
+			}
+			if password != "" {
+				opts = append(opts, redis.DialPassword(password))
+			}
+
+			c, err := redis.Dial("tcp", address, opts...)
+			if err != nil {
+				return nil, err
+			}
+			return c, err
+		},
+
+		TestOnBorrow: func(c redis.Conn, t time.Time) error {
+			_, err := c.Do("PING")
+			return err
+		},
+	}
+
+	return &redisCache{
+		namespace: namespace,
+		ttl:       ttl,
+		redisPool: pool,
+	}
+}
+
+func (m *redisCache) Get(key string, value interface{}) error {
+	conn := m.redisPool.Get()
+	defer conn.Close()
+
+	data, err := redis.Bytes(conn.Do("GET", m.namespace+key))
+	if err != nil {
+		if errors.Is(err, redis.ErrNil) {
+			return ErrNotCached
+		}
+		return err
+	}
+	return json.Unmarshal(data, value)
+}
+
+func (m *redisCache) Set(key string, value interface{}) error {
+	conn := m.redisPool.Get()
+	defer conn.Close()
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if _, err := conn.Do("SET", m.namespace+key, data, "EX", m.ttl); err != nil {
+		return errors.Wrap(err, "ldapcache: error writing to redis")
+	}
+	return nil
+}
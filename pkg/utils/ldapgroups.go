@@ -0,0 +1,177 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/pkg/errors"
+)
+
+const (
+	// LDAPNestedGroupsMemberOf resolves nested groups using the memberOf attribute of entries.
+	LDAPNestedGroupsMemberOf = "memberof"
+	// LDAPNestedGroupsRecursive resolves nested groups by recursively expanding the member attribute of groups.
+	LDAPNestedGroupsRecursive = "recursive"
+
+	defaultLDAPNestingDepth      = 10
+	defaultLDAPGroupObjectFilter = "(|(objectclass=groupOfNames)(objectclass=groupOfUniqueNames)(objectclass=group))"
+)
+
+// LDAPSearcher is the interface to implement to run LDAP searches.
+type LDAPSearcher interface {
+	Search(req *ldap.SearchRequest) (*ldap.SearchResult, error)
+}
+
+// LDAPNestedGroups holds the settings for resolving group-in-group memberships.
+type LDAPNestedGroups struct {
+	Mode              string `mapstructure:"nested_groups" docs:";How nested group memberships are resolved: memberof or recursive. Nested groups are ignored if empty."`
+	MaxDepth          int    `mapstructure:"nested_groups_max_depth" docs:"10;The maximum nesting depth that is resolved."`
+	GroupObjectFilter string `mapstructure:"group_object_filter" docs:"(|(objectclass=groupOfNames)(objectclass=groupOfUniqueNames)(objectclass=group));The filter matching group entries."`
+	MemberAttribute   string `mapstructure:"member_attribute" docs:"member;The attribute of groups holding the DNs of their members."`
+	MemberOfAttribute string `mapstructure:"memberof_attribute" docs:"memberOf;The attribute holding the DNs of the groups an entry belongs to."`
+}
+
+// Init validates the nested groups settings and fills in defaults.
+func (n *LDAPNestedGroups) Init() error {
+	n.Mode = strings.ToLower(n.Mode)
+	switch n.Mode {
+	case "", LDAPNestedGroupsMemberOf, LDAPNestedGroupsRecursive:
+	default:
+		return errors.Errorf("unknown nested groups mode %s", n.Mode)
+	}
+
+	if n.MaxDepth <= 0 {
+		n.MaxDepth = defaultLDAPNestingDepth
+	}
+	if n.GroupObjectFilter == "" {
+		n.GroupObjectFilter = defaultLDAPGroupObjectFilter
+	}
+	if n.MemberAttribute == "" {
+		n.MemberAttribute = "member"
+	}
+	if n.MemberOfAttribute == "" {
+		n.MemberOfAttribute = "memberOf"
+	}
+	return nil
+}
+
+// Enabled tells whether nested groups should be resolved.
+func (n *LDAPNestedGroups) Enabled() bool {
+	return n.Mode != ""
+}
+
+// Attributes returns the attributes that group entries passed to the
+// Expand functions need to contain.
+func (n *LDAPNestedGroups) Attributes() []string {
+	switch n.Mode {
+	case LDAPNestedGroupsMemberOf:
+		return []string{n.MemberOfAttribute}
+	case LDAPNestedGroupsRecursive:
+		return []string{n.MemberAttribute}
+	}
+	return nil
+}
+
+// ExpandSubgroups returns all groups which are (directly or indirectly)
+// members of the given groups. The given groups aren't part of the result;
+// each group is returned only once, even if the memberships form a cycle.
+func (n *LDAPNestedGroups) ExpandSubgroups(s LDAPSearcher, baseDN string, groups []*ldap.Entry, attributes []string) ([]*ldap.Entry, error) {
+	return n.expand(s, groups, attributes, func(group *ldap.Entry, attrs []string) ([]*ldap.Entry, error) {
+		if n.Mode == LDAPNestedGroupsMemberOf {
+			filter := fmt.Sprintf("(&%s(%s=%s))", n.GroupObjectFilter, n.MemberOfAttribute, ldap.EscapeFilter(group.DN))
+			return n.search(s, baseDN, ldap.ScopeWholeSubtree, filter, attrs)
+		}
+		return n.lookupGroups(s, group.GetEqualFoldAttributeValues(n.MemberAttribute), attrs)
+	})
+}
+
+// ExpandParentGroups returns all groups which the given groups are
+// (directly or indirectly) members of. The given groups aren't part of the
+// result; each group is returned only once, even if the memberships form a cycle.
+func (n *LDAPNestedGroups) ExpandParentGroups(s LDAPSearcher, baseDN string, groups []*ldap.Entry, attributes []string) ([]*ldap.Entry, error) {
+	return n.expand(s, groups, attributes, func(group *ldap.Entry, attrs []string) ([]*ldap.Entry, error) {
+		if n.Mode == LDAPNestedGroupsMemberOf {
+			return n.lookupGroups(s, group.GetEqualFoldAttributeValues(n.MemberOfAttribute), attrs)
+		}
+		filter := fmt.Sprintf("(&%s(%s=%s))", n.GroupObjectFilter, n.MemberAttribute, ldap.EscapeFilter(group.DN))
+		return n.search(s, baseDN, ldap.ScopeWholeSubtree, filter, attrs)
+	})
+}
+
+func (n *LDAPNestedGroups) expand(s LDAPSearcher, groups []*ldap.Entry, attributes []string, next func(*ldap.Entry, []string) ([]*ldap.Entry, error)) ([]*ldap.Entry, error) {
+	if !n.Enabled() {
+		return nil, nil
+	}
+
+	attrs := append(append([]string{}, attributes...), n.Attributes()...)
+	visited := make(map[string]bool, len(groups))
+	for _, group := range groups {
+		visited[strings.ToLower(group.DN)] = true
+	}
+
+	var result []*ldap.Entry
+	current := groups
+	for depth := 0; depth < n.MaxDepth && len(current) > 0; depth++ {
+		var found []*ldap.Entry
+		for _, group := range current {
+			entries, err := next(group, attrs)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error resolving nested groups of %s", group.DN)
+			}
+			for _, entry := range entries {
+				dn := strings.ToLower(entry.DN)
+				if !visited[dn] {
+					visited[dn] = true
+					found = append(found, entry)
+				}
+			}
+		}
+		result = append(result, found...)
+		current = found
+	}
+	return result, nil
+}
+
+// lookupGroups reads the entries with the given DNs, ignoring those which aren't groups.
+func (n *LDAPNestedGroups) lookupGroups(s LDAPSearcher, dns []string, attrs []string) ([]*ldap.Entry, error) {
+	var groups []*ldap.Entry
+	for _, dn := range dns {
+		entries, err := n.search(s, dn, ldap.ScopeBaseObject, n.GroupObjectFilter, attrs)
+		if err != nil {
+			if ldap.IsErrorWithCode(err, ldap.LDAPResultNoSuchObject) {
+				continue
+			}
+			return nil, err
+		}
+		groups = append(groups, entries...)
+	}
+	return groups, nil
+}
+
+func (n *LDAPNestedGroups) search(s LDAPSearcher, baseDN string, scope int, filter string, attrs []string) ([]*ldap.Entry, error) {
+	req := ldap.NewSearchRequest(baseDN, scope, ldap.NeverDerefAliases, 0, 0, false, filter, attrs, nil)
+	sr, err := s.Search(req)
+	if err != nil {
+		return nil, err
+	}
+	return sr.Entries, nil
+}
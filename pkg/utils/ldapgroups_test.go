@@ -0,0 +1,123 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package utils
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// fakeDirectory answers the searches issued by the nested groups resolution from a static set of groups.
+type fakeDirectory struct {
+	members map[string][]string
+}
+
+func (d *fakeDirectory) entry(dn string) *ldap.Entry {
+	var memberOf []string
+	for group, members := range d.members {
+		for _, m := range members {
+			if m == dn {
+				memberOf = append(memberOf, group)
+			}
+		}
+	}
+	return ldap.NewEntry(dn, map[string][]string{"member": d.members[dn], "memberOf": memberOf})
+}
+
+func (d *fakeDirectory) Search(req *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	sr := &ldap.SearchResult{}
+	if req.Scope == ldap.ScopeBaseObject {
+		if _, ok := d.members[req.BaseDN]; ok {
+			sr.Entries = append(sr.Entries, d.entry(req.BaseDN))
+		}
+		return sr, nil
+	}
+
+	// Subtree searches look for groups having a specific attribute value: (&<group filter>(attr=value))
+	cond := req.Filter[strings.LastIndex(req.Filter, "(")+1 : len(req.Filter)-2]
+	attr, value := cond[:strings.Index(cond, "=")], cond[strings.Index(cond, "=")+1:]
+	for group := range d.members {
+		entry := d.entry(group)
+		for _, v := range entry.GetEqualFoldAttributeValues(attr) {
+			if v == value {
+				sr.Entries = append(sr.Entries, entry)
+			}
+		}
+	}
+	return sr, nil
+}
+
+func dnsOf(entries []*ldap.Entry) []string {
+	dns := make([]string, 0, len(entries))
+	for _, e := range entries {
+		dns = append(dns, e.DN)
+	}
+	sort.Strings(dns)
+	return dns
+}
+
+func TestLDAPNestedGroups(t *testing.T) {
+	dir := &fakeDirectory{members: map[string][]string{
+		"cn=all":    {"cn=staff", "cn=admins"},
+		"cn=staff":  {"cn=devs", "uid=alice"},
+		"cn=devs":   {"cn=all", "uid=bob"}, // Cycle back to the top-level group
+		"cn=admins": {"uid=carol"},
+		"cn=other":  {"uid=dave"},
+	}}
+
+	for _, mode := range []string{LDAPNestedGroupsMemberOf, LDAPNestedGroupsRecursive} {
+		t.Run(mode, func(t *testing.T) {
+			n := &LDAPNestedGroups{Mode: mode}
+			if err := n.Init(); err != nil {
+				t.Fatal(err)
+			}
+
+			subgroups, err := n.ExpandSubgroups(dir, "dc=example", []*ldap.Entry{dir.entry("cn=all")}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Join(dnsOf(subgroups), ";"); got != "cn=admins;cn=devs;cn=staff" {
+				t.Errorf("unexpected subgroups %v", got)
+			}
+
+			parents, err := n.ExpandParentGroups(dir, "dc=example", []*ldap.Entry{dir.entry("cn=admins")}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Join(dnsOf(parents), ";"); got != "cn=all;cn=devs;cn=staff" {
+				t.Errorf("unexpected parent groups %v", got)
+			}
+
+			// Limit the resolution to direct subgroups
+			n.MaxDepth = 1
+			subgroups, _ = n.ExpandSubgroups(dir, "dc=example", []*ldap.Entry{dir.entry("cn=all")}, nil)
+			if got := strings.Join(dnsOf(subgroups), ";"); got != "cn=admins;cn=staff" {
+				t.Errorf("unexpected subgroups with depth 1: %v", got)
+			}
+		})
+	}
+
+	n := &LDAPNestedGroups{Mode: "invalid"}
+	if err := n.Init(); err == nil {
+		t.Errorf("expected an error for an invalid mode")
+	}
+}
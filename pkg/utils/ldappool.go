@@ -0,0 +1,218 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package utils
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultLDAPPoolSize        = 10
+	defaultLDAPPoolIdleTimeout = 120
+	defaultLDAPPageSize        = 500
+)
+
+var (
+	ldapPools   = map[LDAPConn]*LDAPPool{}
+	ldapPoolsMu sync.Mutex
+)
+
+// LDAPPool keeps a limited number of bound LDAP connections open and hands
+// them out to callers. Connections are checked before being reused and
+// re-established if the server dropped them.
+type LDAPPool struct {
+	conf        LDAPConn
+	idleTimeout time.Duration
+	pageSize    int
+
+	slots chan struct{}
+
+	mu   sync.Mutex
+	idle []*pooledLDAPConn
+}
+
+type pooledLDAPConn struct {
+	conn     *ldap.Conn
+	lastUsed time.Time
+}
+
+// GetLDAPPool returns the connection pool for the given connection parameters.
+// Pools are shared, so all managers configured with the same connection
+// parameters use the same connections.
+func GetLDAPPool(c *LDAPConn) *LDAPPool {
+	ldapPoolsMu.Lock()
+	defer ldapPoolsMu.Unlock()
+
+	if pool, ok := ldapPools[*c]; ok {
+		return pool
+	}
+	pool := NewLDAPPool(c)
+	ldapPools[*c] = pool
+	return pool
+}
+
+// NewLDAPPool creates a new, unshared LDAP connection pool.
+func NewLDAPPool(c *LDAPConn) *LDAPPool {
+	size := c.PoolSize
+	if size <= 0 {
+		size = defaultLDAPPoolSize
+	}
+	idleTimeout := c.PoolIdleTimeout
+	if idleTimeout <= 0 {
+		idleTimeout = defaultLDAPPoolIdleTimeout
+	}
+
+	pageSize := c.PageSize
+	if pageSize == 0 {
+		pageSize = defaultLDAPPageSize
+	}
+
+	return &LDAPPool{
+		conf:        *c,
+		idleTimeout: time.Duration(idleTimeout) * time.Second,
+		pageSize:    pageSize,
+		slots:       make(chan struct{}, size),
+	}
+}
+
+// Do runs fn with a pooled connection. If fn fails because of a network
+// error, the connection is discarded and fn is retried once on a new one.
+func (p *LDAPPool) Do(fn func(*ldap.Conn) error) error {
+	p.slots <- struct{}{}
+	defer func() { <-p.slots }()
+
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		var pc *pooledLDAPConn
+		if pc, err = p.get(); err != nil {
+			return err
+		}
+
+		err = fn(pc.conn)
+		if err != nil && ldap.IsErrorWithCode(err, ldap.ErrorNetwork) {
+			pc.conn.Close()
+			continue
+		}
+
+		p.put(pc)
+		return err
+	}
+	return err
+}
+
+// Search runs the given search request on a pooled connection. Unless paging
+// was disabled, the results are retrieved page by page (RFC 2696), so large
+// result sets aren't truncated by server-side size limits.
+func (p *LDAPPool) Search(req *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	var sr *ldap.SearchResult
+	err := p.Do(func(l *ldap.Conn) error {
+		var err error
+		if p.pageSize > 0 {
+			// Paged searches modify the request controls, so always use a fresh copy
+			pagedReq := *req
+			pagedReq.Controls = append([]ldap.Control{}, req.Controls...)
+			sr, err = l.SearchWithPaging(&pagedReq, uint32(p.pageSize))
+		} else {
+			sr, err = l.Search(req)
+		}
+		return err
+	})
+	return sr, err
+}
+
+// Authenticate verifies the password of the given DN by binding with it on a
+// pooled connection. Afterwards, the connection is bound with the service
+// credentials again; if this fails, the connection is dropped.
+func (p *LDAPPool) Authenticate(dn, password string) error {
+	var bindErr error
+	err := p.Do(func(l *ldap.Conn) error {
+		bindErr = l.Bind(dn, password)
+		if bindErr != nil && ldap.IsErrorWithCode(bindErr, ldap.ErrorNetwork) {
+			return bindErr
+		}
+		if err := p.bindService(l); err != nil {
+			// Never hand out a connection bound as the user to other callers
+			l.Close()
+			return err
+		}
+		return nil
+	})
+	if bindErr != nil {
+		return bindErr
+	}
+	return err
+}
+
+// Close closes all idle connections of the pool.
+func (p *LDAPPool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, pc := range p.idle {
+		pc.conn.Close()
+	}
+	p.idle = nil
+}
+
+func (p *LDAPPool) get() (*pooledLDAPConn, error) {
+	p.mu.Lock()
+	for len(p.idle) > 0 {
+		pc := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+
+		// Drop connections which were closed by the server or have been idle for too long
+		if pc.conn.IsClosing() || time.Since(pc.lastUsed) > p.idleTimeout {
+			pc.conn.Close()
+			continue
+		}
+		p.mu.Unlock()
+		return pc, nil
+	}
+	p.mu.Unlock()
+
+	l, err := GetLDAPConnection(&p.conf)
+	if err != nil {
+		return nil, errors.Wrap(err, "error connecting to the LDAP server")
+	}
+	return &pooledLDAPConn{conn: l}, nil
+}
+
+func (p *LDAPPool) put(pc *pooledLDAPConn) {
+	if pc.conn.IsClosing() {
+		pc.conn.Close()
+		return
+	}
+
+	pc.lastUsed = time.Now()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.idle = append(p.idle, pc)
+}
+
+func (p *LDAPPool) bindService(l *ldap.Conn) error {
+	if p.conf.BindUsername != "" && p.conf.BindPassword != "" {
+		return l.Bind(p.conf.BindUsername, p.conf.BindPassword)
+	}
+	return l.UnauthenticatedBind("")
+}
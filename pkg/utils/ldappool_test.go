@@ -0,0 +1,43 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package utils
+
+import "testing"
+
+func TestGetLDAPPool(t *testing.T) {
+	c := LDAPConn{Hostname: "localhost", Port: 636, BindUsername: "cn=reva", BindPassword: "secret"}
+	pool := GetLDAPPool(&c)
+	same := c
+	if GetLDAPPool(&same) != pool {
+		t.Fatal("expected the pool to be shared for the same configuration")
+	}
+
+	for name, change := range map[string]func(*LDAPConn){
+		"bind password": func(c *LDAPConn) { c.BindPassword = "other" },
+		"ca cert":       func(c *LDAPConn) { c.CACert = "/etc/ssl/ldap.pem" },
+		"pool size":     func(c *LDAPConn) { c.PoolSize = 20 },
+		"page size":     func(c *LDAPConn) { c.PageSize = -1 },
+	} {
+		other := c
+		change(&other)
+		if GetLDAPPool(&other) == pool {
+			t.Errorf("expected a different pool for a different %s", name)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package sqlmigrate applies versioned schema migrations to SQL databases.
+// The statements of the migrations must be understood by all the engines
+// supported by the caller, e.g. MySQL and SQLite.
+package sqlmigrate
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/pkg/errors"
+)
+
+// Migration is a step in the evolution of a schema, made of one or more statements.
+type Migration []string
+
+const createMigrationsTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	component VARCHAR(255) NOT NULL,
+	version INTEGER NOT NULL,
+	PRIMARY KEY (component, version)
+)`
+
+// lockTimeout is how long to wait for the other instances migrating the same component.
+const lockTimeout = 5 * time.Minute
+
+// Migrate applies the migrations of component not applied yet, in order.
+// The version reached by every component is recorded in the schema_migrations table.
+// On MySQL, the instances sharing the database migrate one at a time.
+func Migrate(db *sql.DB, component string, migrations []Migration) error {
+	ctx := context.Background()
+	if _, err := db.ExecContext(ctx, createMigrationsTable); err != nil {
+		return errors.Wrap(err, "sqlmigrate: error creating migrations table")
+	}
+
+	// the lock and the migrations must use the same connection
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return errors.Wrap(err, "sqlmigrate: error getting a connection")
+	}
+	defer conn.Close()
+
+	if _, ok := db.Driver().(*mysql.MySQLDriver); ok {
+		unlock, err := lock(ctx, conn, component)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+
+	var current int
+	if err := conn.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations WHERE component=?", component).Scan(&current); err != nil {
+		return errors.Wrap(err, "sqlmigrate: error getting schema version of "+component)
+	}
+
+	for v := current; v < len(migrations); v++ {
+		if err := apply(ctx, conn, component, v+1, migrations[v]); err != nil {
+			return errors.Wrapf(err, "sqlmigrate: error migrating %s to version %d", component, v+1)
+		}
+	}
+	return nil
+}
+
+// lock takes the MySQL lock of component, held until the connection is closed
+// or the returned function is called.
+func lock(ctx context.Context, conn *sql.Conn, component string) (func(), error) {
+	name := "schema_migrations:" + component
+	var acquired sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", name, int(lockTimeout/time.Second)).Scan(&acquired); err != nil {
+		return nil, errors.Wrap(err, "sqlmigrate: error locking "+component)
+	}
+	if acquired.Int64 != 1 {
+		return nil, errors.New("sqlmigrate: timeout waiting for the migration of " + component + " by another instance")
+	}
+	return func() {
+		_, _ = conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", name)
+	}, nil
+}
+
+func apply(ctx context.Context, conn *sql.Conn, component string, version int, m Migration) error {
+	// MySQL commits DDL statements implicitly, so the transaction only protects
+	// the version bookkeeping there: if a migration failed halfway, the statements
+	// already applied fail when it is retried and are skipped
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	for _, stmt := range m {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil && !alreadyApplied(err) {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (component, version) VALUES (?, ?)", component, version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// The MySQL errors of the DDL statements whose changes are already in place.
+const (
+	mysqlTableExists     = 1050
+	mysqlDuplicateColumn = 1060
+	mysqlDuplicateKey    = 1061
+	mysqlCannotDrop      = 1091
+)
+
+// alreadyApplied returns whether err tells that the changes of a statement are already in place.
+func alreadyApplied(err error) bool {
+	if e, ok := err.(*mysql.MySQLError); ok {
+		switch e.Number {
+		case mysqlTableExists, mysqlDuplicateColumn, mysqlDuplicateKey, mysqlCannotDrop:
+			return true
+		}
+		return false
+	}
+	// SQLite only reports the errors by message
+	msg := err.Error()
+	return strings.HasSuffix(msg, "already exists") || strings.HasPrefix(msg, "duplicate column name")
+}
@@ -0,0 +1,79 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package sqlmigrate
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+var migrations = []Migration{
+	{
+		`CREATE TABLE items (id VARCHAR(255) NOT NULL PRIMARY KEY)`,
+		`CREATE INDEX items_id ON items (id)`,
+	},
+	{
+		`ALTER TABLE items ADD COLUMN name TEXT`,
+	},
+}
+
+func version(t *testing.T, db *sql.DB) int {
+	var v int
+	if err := db.QueryRow("SELECT MAX(version) FROM schema_migrations WHERE component='items'").Scan(&v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestMigrate(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if err := Migrate(db, "items", migrations[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if v := version(t, db); v != 1 {
+		t.Fatalf("expected version 1, got %d", v)
+	}
+
+	// the second step was partially applied by an instance that failed before recording it
+	if _, err := db.Exec(migrations[1][0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := Migrate(db, "items", migrations); err != nil {
+		t.Fatal(err)
+	}
+	if v := version(t, db); v != 2 {
+		t.Fatalf("expected version 2, got %d", v)
+	}
+
+	// nothing left to apply
+	if err := Migrate(db, "items", migrations); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("INSERT INTO items (id, name) VALUES ('1', 'one')"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package totp implements the one-time passwords of RFC 4226 (HOTP) and
+// RFC 6238 (TOTP) as used by the common authenticator apps.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// The default parameters are the ones supported by all common authenticator apps.
+const (
+	DefaultPeriod = 30
+	DefaultDigits = 6
+	DefaultSkew   = 1
+
+	secretSize = 20
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret returns a new random secret, encoded as base32.
+func GenerateSecret() (string, error) {
+	secret := make([]byte, secretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", errors.Wrap(err, "totp: error generating secret")
+	}
+	return encoding.EncodeToString(secret), nil
+}
+
+// DecodeSecret decodes a base32 encoded secret, ignoring spaces and padding.
+func DecodeSecret(s string) ([]byte, error) {
+	s = strings.ToUpper(strings.ReplaceAll(s, " ", ""))
+	return encoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+// URI returns the provisioning URI of a secret using the default
+// parameters, which can be rendered as a QR code for authenticator apps.
+func URI(issuer, account, secret string) string {
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", issuer)
+	params.Set("period", fmt.Sprint(DefaultPeriod))
+	params.Set("digits", fmt.Sprint(DefaultDigits))
+	return fmt.Sprintf("otpauth://totp/%s:%s?%s", url.PathEscape(issuer), url.PathEscape(account), params.Encode())
+}
+
+// Counter returns the counter of the period the given time falls in.
+func Counter(t time.Time, period int) uint64 {
+	return uint64(t.Unix()) / uint64(period)
+}
+
+// Validate checks the code against the secret for the counters up to skew
+// periods before and after t. Counters up to last are skipped, so that
+// a code cannot be replayed. On success, the matching counter is returned.
+func Validate(secret []byte, response string, t time.Time, period, digits, skew int, last uint64) (uint64, bool) {
+	response = strings.TrimSpace(response)
+	current := Counter(t, period)
+	for i := -skew; i <= skew; i++ {
+		counter := current + uint64(i)
+		if counter <= last {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(Code(secret, counter, digits)), []byte(response)) == 1 {
+			return counter, true
+		}
+	}
+	return 0, false
+}
+
+// Code computes the HOTP value of RFC 4226 for the given counter.
+func Code(secret []byte, counter uint64, digits int) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, counter)
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%mod)
+}
@@ -0,0 +1,77 @@
+// Copyright 2018-2022 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCode(t *testing.T) {
+	// test vectors from RFC 6238, appendix B
+	secret := []byte("12345678901234567890")
+	tests := map[int64]string{
+		59:          "94287082",
+		1111111109:  "07081804",
+		1111111111:  "14050471",
+		1234567890:  "89005924",
+		2000000000:  "69279037",
+		20000000000: "65353130",
+	}
+	for ts, want := range tests {
+		if got := Code(secret, Counter(time.Unix(ts, 0), DefaultPeriod), 8); got != want {
+			t.Errorf("code at %d: got %s, want %s", ts, got, want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret := []byte("12345678901234567890")
+	now := time.Unix(1111111109, 0)
+
+	counter, ok := Validate(secret, "081804", now, DefaultPeriod, DefaultDigits, DefaultSkew, 0)
+	if !ok || counter != Counter(now, DefaultPeriod) {
+		t.Fatal("expected a valid code to be accepted")
+	}
+	if _, ok := Validate(secret, "081804", now, DefaultPeriod, DefaultDigits, DefaultSkew, counter); ok {
+		t.Error("expected a replayed code to be rejected")
+	}
+	if _, ok := Validate(secret, " 081804 ", now.Add(30*time.Second), DefaultPeriod, DefaultDigits, DefaultSkew, 0); !ok {
+		t.Error("expected the code of the previous period to be accepted")
+	}
+	if _, ok := Validate(secret, "081804", now.Add(90*time.Second), DefaultPeriod, DefaultDigits, DefaultSkew, 0); ok {
+		t.Error("expected an expired code to be rejected")
+	}
+}
+
+func TestSecret(t *testing.T) {
+	s, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret, err := DecodeSecret(strings.ToLower(s)); err != nil || len(secret) != secretSize {
+		t.Fatalf("expected a decodable secret of %d bytes: %v", secretSize, err)
+	}
+
+	uri := URI("ScienceMesh", "jane@example.org", s)
+	if !strings.HasPrefix(uri, "otpauth://totp/ScienceMesh:jane@example.org?") || !strings.Contains(uri, "secret="+s) {
+		t.Errorf("unexpected URI %v", uri)
+	}
+}
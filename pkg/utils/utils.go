@@ -385,9 +385,16 @@ func HasPermissions(target, toCheck *provider.ResourcePermissions) bool {
 	return true
 }
 
-// UserIsLightweight returns true if the user is a lightweith
-// or federated account.
+// UserIsLightweight returns true if the user is a lightweith,
+// federated or guest account.
 func UserIsLightweight(u *userpb.User) bool {
-	return u.Id.Type == userpb.UserType_USER_TYPE_FEDERATED ||
-		u.Id.Type == userpb.UserType_USER_TYPE_LIGHTWEIGHT
+	return UserTypeIsLightweight(u.Id.Type)
+}
+
+// UserTypeIsLightweight returns true for the types of the accounts
+// without a primary identity, i.e. lightweight, federated and guest accounts.
+func UserTypeIsLightweight(t userpb.UserType) bool {
+	return t == userpb.UserType_USER_TYPE_FEDERATED ||
+		t == userpb.UserType_USER_TYPE_LIGHTWEIGHT ||
+		t == userpb.UserType_USER_TYPE_GUEST
 }